@@ -143,7 +143,7 @@ func (ers *EventRecoverySystem) simplifiedProcessingStrategy(event *TransactionE
 		SourceAddress: event.FromAddress,
 		DestAddress:   event.ToAddress,
 		TokenSymbol:   event.TokenSymbol,
-		Amount:        uint64(event.Amount * 1e18),
+		Amount:        event.BaseUnits(),
 		Status:        "completed", // Mark as completed immediately
 		CreatedAt:     event.Timestamp,
 		CompletedAt:   time.Now().Unix(),
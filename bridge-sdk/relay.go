@@ -81,7 +81,7 @@ func (br *BridgeRelay) HandleEvent(event *TransactionEvent) error {
 		SourceAddress: event.FromAddress,
 		DestAddress:   event.ToAddress,
 		TokenSymbol:   event.TokenSymbol,
-		Amount:        uint64(event.Amount * 1e18), // Convert to wei equivalent
+		Amount:        event.BaseUnits(),
 		Status:        "pending",
 		CreatedAt:     event.Timestamp,
 		SourceTxHash:  event.TxHash,
@@ -142,7 +142,9 @@ func (br *BridgeRelay) RelayToChain(tx *RelayTransaction, targetChain ChainType)
 			return nil
 		}
 
-		// Use the core bridge to initiate the transfer for supported chains
+		// Use the core bridge to initiate the transfer for supported chains.
+		// Relayed events carry no signed Blackhole lock, so transfers out of
+		// Blackhole are refused here and must start from the sender's wallet.
 		bridgeTx, err := br.bridge.InitiateBridgeTransfer(
 			bridge.ChainType(tx.SourceChain),
 			bridge.ChainType(tx.DestChain),
@@ -150,6 +152,7 @@ func (br *BridgeRelay) RelayToChain(tx *RelayTransaction, targetChain ChainType)
 			tx.DestAddress,
 			tx.TokenSymbol,
 			tx.Amount,
+			nil,
 		)
 
 		if err != nil {
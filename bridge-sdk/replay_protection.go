@@ -132,7 +132,7 @@ func (rp *ReplayProtection) GenerateEventHash(event *TransactionEvent) string {
 // GenerateRelayTransactionHash creates a hash for relay transactions
 func (rp *ReplayProtection) GenerateRelayTransactionHash(tx *RelayTransaction) string {
 	// Create a normalized input for hashing
-	hashData := fmt.Sprintf("%s|%s|%s|%d|%s|%s|%s|%d",
+	hashData := fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s|%d",
 		strings.ToLower(strings.TrimSpace(tx.SourceTxHash)),
 		strings.ToLower(string(tx.SourceChain)),
 		strings.ToLower(string(tx.DestChain)),
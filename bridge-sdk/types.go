@@ -1,8 +1,11 @@
 package bridgesdk
 
 import (
+	"math/big"
 	"sync"
 	"time"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
 // ChainType represents different blockchain types
@@ -26,22 +29,33 @@ type TransactionEvent struct {
 	TokenSymbol string  `json:"tokenSymbol,omitempty"`
 }
 
+// BaseUnits converts the event amount, given in whole tokens, to 18-decimal
+// base units. Negative amounts and amounts too large for 256 bits give zero.
+func (e *TransactionEvent) BaseUnits() uint256.Int {
+	if e.Amount <= 0 {
+		return uint256.Int{}
+	}
+	wei, _ := new(big.Float).Mul(big.NewFloat(e.Amount), big.NewFloat(1e18)).Int(nil)
+	amount, _ := uint256.FromBig(wei)
+	return amount
+}
+
 // RelayTransaction represents a transaction being relayed
 type RelayTransaction struct {
-	ID              string    `json:"id"`
-	SourceChain     ChainType `json:"source_chain"`
-	DestChain       ChainType `json:"dest_chain"`
-	SourceAddress   string    `json:"source_address"`
-	DestAddress     string    `json:"dest_address"`
-	TokenSymbol     string    `json:"token_symbol"`
-	Amount          uint64    `json:"amount"`
-	Status          string    `json:"status"` // "pending", "confirmed", "completed", "failed"
-	CreatedAt       int64     `json:"created_at"`
-	ConfirmedAt     int64     `json:"confirmed_at,omitempty"`
-	CompletedAt     int64     `json:"completed_at,omitempty"`
-	SourceTxHash    string    `json:"source_tx_hash,omitempty"`
-	DestTxHash      string    `json:"dest_tx_hash,omitempty"`
-	RelaySignatures []string  `json:"relay_signatures"`
+	ID              string      `json:"id"`
+	SourceChain     ChainType   `json:"source_chain"`
+	DestChain       ChainType   `json:"dest_chain"`
+	SourceAddress   string      `json:"source_address"`
+	DestAddress     string      `json:"dest_address"`
+	TokenSymbol     string      `json:"token_symbol"`
+	Amount          uint256.Int `json:"amount"`
+	Status          string      `json:"status"` // "pending", "confirmed", "completed", "failed"
+	CreatedAt       int64       `json:"created_at"`
+	ConfirmedAt     int64       `json:"confirmed_at,omitempty"`
+	CompletedAt     int64       `json:"completed_at,omitempty"`
+	SourceTxHash    string      `json:"source_tx_hash,omitempty"`
+	DestTxHash      string      `json:"dest_tx_hash,omitempty"`
+	RelaySignatures []string    `json:"relay_signatures"`
 	mu              sync.RWMutex
 }
 
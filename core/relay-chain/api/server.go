@@ -1,51 +1,40 @@
 package api
 
 import (
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/bridge"
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/chain"
-	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/escrow"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
 type APIServer struct {
-	blockchain    *chain.Blockchain
-	bridge        *bridge.Bridge
-	port          int
-	escrowManager interface{} // Will be initialized as *escrow.EscrowManager
+	blockchain *chain.Blockchain
+	bridge     *bridge.Bridge
+	port       int
 }
 
 func NewAPIServer(blockchain *chain.Blockchain, bridgeInstance *bridge.Bridge, port int) *APIServer {
-	// Initialize proper escrow manager using dependency injection
-	escrowManager := NewEscrowManagerForBlockchain(blockchain)
-
-	// Inject the escrow manager into the blockchain
-	blockchain.EscrowManager = escrowManager
-
 	return &APIServer{
-		blockchain:    blockchain,
-		bridge:        bridgeInstance,
-		port:          port,
-		escrowManager: escrowManager,
+		blockchain: blockchain,
+		bridge:     bridgeInstance,
+		port:       port,
 	}
 }
 
-// NewEscrowManagerForBlockchain creates a new escrow manager for the blockchain
-func NewEscrowManagerForBlockchain(blockchain *chain.Blockchain) interface{} {
-	// Create a real escrow manager using dependency injection
-	return escrow.NewEscrowManager(blockchain)
-}
-
 func (s *APIServer) Start() {
 	// Enable CORS for all routes
 	http.HandleFunc("/", s.enableCORS(s.serveUI))
 	http.HandleFunc("/dev", s.enableCORS(s.serveDevMode))
 	http.HandleFunc("/api/blockchain/info", s.enableCORS(s.getBlockchainInfo))
-	http.HandleFunc("/api/admin/add-tokens", s.enableCORS(s.addTokens))
 	http.HandleFunc("/api/wallets", s.enableCORS(s.getWallets))
 	http.HandleFunc("/api/node/info", s.enableCORS(s.getNodeInfo))
 	http.HandleFunc("/api/dev/test-dex", s.enableCORS(s.testDEX))
@@ -54,15 +43,39 @@ func (s *APIServer) Start() {
 	http.HandleFunc("/api/dev/test-multisig", s.enableCORS(s.testMultisig))
 	http.HandleFunc("/api/dev/test-otc", s.enableCORS(s.testOTC))
 	http.HandleFunc("/api/dev/test-escrow", s.enableCORS(s.testEscrow))
-	http.HandleFunc("/api/escrow/request", s.enableCORS(s.handleEscrowRequest))
-	http.HandleFunc("/api/balance/query", s.enableCORS(s.handleBalanceQuery))
-
-	// OTC Trading API endpoints
-	http.HandleFunc("/api/otc/create", s.enableCORS(s.handleOTCCreate))
+	http.HandleFunc("/api/escrow", s.enableCORS(s.handleEscrow))
+	http.HandleFunc("/api/escrows", s.enableCORS(s.handleEscrows))
+	http.HandleFunc("/api/otc/order", s.enableCORS(s.handleOTCOrder))
 	http.HandleFunc("/api/otc/orders", s.enableCORS(s.handleOTCOrders))
-	http.HandleFunc("/api/otc/match", s.enableCORS(s.handleOTCMatch))
-	http.HandleFunc("/api/otc/cancel", s.enableCORS(s.handleOTCCancel))
-	http.HandleFunc("/api/otc/events", s.enableCORS(s.handleOTCEvents))
+	http.HandleFunc("/api/multisig", s.enableCORS(s.handleMultisigWallet))
+	http.HandleFunc("/api/multisig/wallets", s.enableCORS(s.handleMultisigWallets))
+	http.HandleFunc("/api/multisig/proposal", s.enableCORS(s.handleMultisigProposal))
+	http.HandleFunc("/api/dex/pool", s.enableCORS(s.handleDEXPool))
+	http.HandleFunc("/api/dex/pools", s.enableCORS(s.handleDEXPools))
+	http.HandleFunc("/api/dex/quote", s.enableCORS(s.handleDEXQuote))
+	http.HandleFunc("/api/balance/query", s.enableCORS(s.handleBalanceQuery))
+	http.HandleFunc("/api/state/proof", s.enableCORS(s.handleStateProof))
+	http.HandleFunc("/api/tx/receipt", s.enableCORS(s.handleTransactionReceipt))
+	http.HandleFunc("/api/account/nonce", s.enableCORS(s.handleAccountNonce))
+	http.HandleFunc("/api/tx", s.enableCORS(s.handleTransactionLookup))
+	http.HandleFunc("/api/tx/proof", s.enableCORS(s.handleTransactionProof))
+	http.HandleFunc("/api/headers", s.enableCORS(s.handleHeaders))
+	http.HandleFunc("/api/address/transactions", s.enableCORS(s.handleAddressTransactions))
+	http.HandleFunc("/api/token/transfers", s.enableCORS(s.handleTokenTransfers))
+	http.HandleFunc("/api/token/ledger", s.enableCORS(s.handleExportLedger))
+	http.HandleFunc("/api/token/controls", s.enableCORS(s.handleTokenControls))
+	http.HandleFunc("/api/token/vesting", s.enableCORS(s.handleTokenVesting))
+	http.HandleFunc("/api/token/balance", s.enableCORS(s.handleBalanceAt))
+	http.HandleFunc("/api/stake", s.enableCORS(s.handleStakeAt))
+	http.HandleFunc("/api/collection", s.enableCORS(s.handleCollection))
+	http.HandleFunc("/api/collection/item", s.enableCORS(s.handleCollectionItem))
+	http.HandleFunc("/api/collection/items", s.enableCORS(s.handleCollectionItems))
+	http.HandleFunc("/api/collection/ledger", s.enableCORS(s.handleExportCollection))
+	http.HandleFunc("/api/contract", s.enableCORS(s.handleContract))
+	http.HandleFunc("/api/contract/code", s.enableCORS(s.handleContractCode))
+	http.HandleFunc("/api/contract/storage", s.enableCORS(s.handleContractStorage))
+	http.HandleFunc("/api/contract/query", s.enableCORS(s.handleContractQuery))
+	http.HandleFunc("/api/events", s.enableCORS(s.handleEventLogs))
 
 	// Slashing API endpoints
 	http.HandleFunc("/api/slashing/events", s.enableCORS(s.handleSlashingEvents))
@@ -140,10 +153,6 @@ func (s *APIServer) serveUI(w http.ResponseWriter, r *http.Request) {
         .address { font-family: monospace; font-size: 12px; word-break: break-all; max-width: 200px; }
         .btn { background: #3498db; color: white; border: none; padding: 10px 20px; border-radius: 4px; cursor: pointer; }
         .btn:hover { background: #2980b9; }
-        .admin-form { background: #fff3cd; padding: 15px; border-radius: 4px; margin-top: 10px; }
-        .form-group { margin-bottom: 10px; }
-        .form-group label { display: block; margin-bottom: 5px; }
-        .form-group input { width: 100%; padding: 8px; border: 1px solid #ddd; border-radius: 4px; }
         .refresh-btn { position: fixed; top: 20px; right: 20px; z-index: 1000; }
         .block-item { background: #f8f9fa; margin: 5px 0; padding: 10px; border-radius: 4px; }
         .card { overflow-x: auto; }
@@ -221,26 +230,6 @@ func (s *APIServer) serveUI(w http.ResponseWriter, r *http.Request) {
                     <code>go run main.go -web -port 9000</code>
                 </p>
             </div>
-
-            <div class="card">
-                <h3>⚙️ Admin Panel</h3>
-                <div class="admin-form">
-                    <h4>Add Tokens to Address</h4>
-                    <div class="form-group">
-                        <label>Address:</label>
-                        <input type="text" id="admin-address" placeholder="Enter wallet address">
-                    </div>
-                    <div class="form-group">
-                        <label>Token Symbol:</label>
-                        <input type="text" id="admin-token" value="BHX" placeholder="Token symbol">
-                    </div>
-                    <div class="form-group">
-                        <label>Amount:</label>
-                        <input type="number" id="admin-amount" placeholder="Amount to add">
-                    </div>
-                    <button class="btn" onclick="addTokens()">Add Tokens</button>
-                </div>
-            </div>
         </div>
     </div>
 
@@ -324,37 +313,6 @@ func (s *APIServer) serveUI(w http.ResponseWriter, r *http.Request) {
             container.innerHTML = html;
         }
 
-        async function addTokens() {
-            const address = document.getElementById('admin-address').value;
-            const token = document.getElementById('admin-token').value;
-            const amount = document.getElementById('admin-amount').value;
-
-            if (!address || !token || !amount) {
-                alert('Please fill all fields');
-                return;
-            }
-
-            try {
-                const response = await fetch('/api/admin/add-tokens', {
-                    method: 'POST',
-                    headers: { 'Content-Type': 'application/json' },
-                    body: JSON.stringify({ address, token, amount: parseInt(amount) })
-                });
-
-                const result = await response.json();
-                if (result.success) {
-                    alert('Tokens added successfully!');
-                    document.getElementById('admin-address').value = '';
-                    document.getElementById('admin-amount').value = '';
-                    fetchBlockchainInfo(); // Refresh data
-                } else {
-                    alert('Error: ' + result.error);
-                }
-            } catch (error) {
-                alert('Error adding tokens: ' + error.message);
-            }
-        }
-
         function refreshData() {
             fetchBlockchainInfo();
         }
@@ -395,44 +353,6 @@ func (s *APIServer) getBlockchainInfo(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(info)
 }
 
-func (s *APIServer) addTokens(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	var req struct {
-		Address string `json:"address"`
-		Token   string `json:"token"`
-		Amount  uint64 `json:"amount"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"error":   "Invalid request format",
-		})
-		return
-	}
-
-	err := s.blockchain.AddTokenBalance(req.Address, req.Token, req.Amount)
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
-		})
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": fmt.Sprintf("Added %d %s tokens to %s", req.Amount, req.Token, req.Address),
-	})
-}
-
 func (s *APIServer) getWallets(w http.ResponseWriter, r *http.Request) {
 	// This would integrate with the wallet service to get wallet information
 	// For now, return the accounts from blockchain state
@@ -491,9 +411,6 @@ func (s *APIServer) serveDevMode(w http.ResponseWriter, r *http.Request) {
         .btn-warning:hover { background: #e67e22; }
         .btn-danger { background: #e74c3c; }
         .btn-danger:hover { background: #c0392b; }
-        .form-group { margin-bottom: 15px; }
-        .form-group label { display: block; margin-bottom: 5px; font-weight: bold; }
-        .form-group input, .form-group select, .form-group textarea { width: 100%; padding: 8px; border: 1px solid #ddd; border-radius: 4px; box-sizing: border-box; }
         .result { margin-top: 15px; padding: 10px; border-radius: 4px; white-space: pre-wrap; word-wrap: break-word; }
         .success { background: #d4edda; color: #155724; border: 1px solid #c3e6cb; }
         .error { background: #f8d7da; color: #721c24; border: 1px solid #f5c6cb; }
@@ -1134,66 +1051,89 @@ func (s *APIServer) testEscrow(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(result)
 }
 
-// handleEscrowRequest handles real escrow operations from the blockchain client
-func (s *APIServer) handleEscrowRequest(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
+// handleEscrow returns an escrow with its parties, amount and status. Escrows
+// are created, confirmed, released and cancelled by EscrowCreate and
+// EscrowAction transactions.
+func (s *APIServer) handleEscrow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var req map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	id := r.URL.Query().Get("id")
+	if id == "" {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
-			"error":   "Invalid request format: " + err.Error(),
+			"error":   "Escrow ID is required",
 		})
 		return
 	}
 
-	action, ok := req["action"].(string)
-	if !ok {
+	e, err := s.blockchain.GetEscrow(id)
+	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
-			"error":   "Missing or invalid action",
+			"error":   err.Error(),
 		})
 		return
 	}
 
-	// Log the escrow request
-	fmt.Printf("🔒 ESCROW REQUEST: %s\n", action)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    e,
+	})
+}
+
+// handleEscrows returns every escrow an address is the sender, receiver or arbitrator of
+func (s *APIServer) handleEscrows(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-	// Check if escrow manager is initialized
-	if s.escrowManager == nil {
+	address := r.URL.Query().Get("address")
+	if address == "" {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
-			"error":   "Escrow manager not initialized",
+			"error":   "Address is required",
 		})
 		return
 	}
 
-	var result map[string]interface{}
-	var err error
+	escrows := s.blockchain.EscrowsOf(address)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"escrows": escrows,
+			"count":   len(escrows),
+		},
+	})
+}
 
-	switch action {
-	case "create_escrow":
-		result, err = s.handleCreateEscrow(req)
-	case "confirm_escrow":
-		result, err = s.handleConfirmEscrow(req)
-	case "release_escrow":
-		result, err = s.handleReleaseEscrow(req)
-	case "cancel_escrow":
-		result, err = s.handleCancelEscrow(req)
-	case "get_escrow":
-		result, err = s.handleGetEscrow(req)
-	case "get_user_escrows":
-		result, err = s.handleGetUserEscrows(req)
-	default:
-		err = fmt.Errorf("unknown action: %s", action)
+// handleOTCOrder returns an OTC order with its terms and status. Orders are
+// created, filled and cancelled by OTCCreate and OTCAction transactions.
+func (s *APIServer) handleOTCOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Order ID is required",
+		})
+		return
+	}
+
+	o, err := s.blockchain.GetOTCOrder(id)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -1204,243 +1144,235 @@ func (s *APIServer) handleEscrowRequest(w http.ResponseWriter, r *http.Request)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    o,
+	})
 }
 
-// handleCreateEscrow handles escrow creation requests
-func (s *APIServer) handleCreateEscrow(req map[string]interface{}) (map[string]interface{}, error) {
-	sender, ok := req["sender"].(string)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid sender")
+// handleOTCOrders returns every OTC order a user created or filled, or every
+// open order when no user is given
+func (s *APIServer) handleOTCOrders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	receiver, ok := req["receiver"].(string)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid receiver")
+	var orders []*chain.OTCOrder
+	if user := r.URL.Query().Get("user"); user != "" {
+		orders = s.blockchain.OTCOrdersOf(user)
+	} else {
+		orders = s.blockchain.OpenOTCOrders()
 	}
 
-	tokenSymbol, ok := req["token_symbol"].(string)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid token_symbol")
-	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"orders": orders,
+			"count":  len(orders),
+		},
+	})
+}
 
-	amount, ok := req["amount"].(float64)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid amount")
+// handleMultisigWallet returns a multisig wallet with its owners, threshold
+// and proposals
+func (s *APIServer) handleMultisigWallet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	expirationHours, ok := req["expiration_hours"].(float64)
-	if !ok {
-		expirationHours = 24 // Default to 24 hours
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Address is required",
+		})
+		return
 	}
 
-	arbitrator, _ := req["arbitrator"].(string)   // Optional
-	description, _ := req["description"].(string) // Optional
-
-	// Create escrow using the real escrow manager
-	escrowManager := s.escrowManager.(*escrow.EscrowManager)
-
-	contract, err := escrowManager.CreateEscrow(
-		sender,
-		receiver,
-		arbitrator,
-		tokenSymbol,
-		uint64(amount),
-		int(expirationHours),
-		description,
-	)
+	wallet, err := s.blockchain.GetMultisigWallet(address)
 	if err != nil {
-		return nil, err
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
 	}
 
-	return map[string]interface{}{
-		"success":   true,
-		"escrow_id": contract.ID,
-		"message":   fmt.Sprintf("Escrow created successfully: %s", contract.ID),
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
 		"data": map[string]interface{}{
-			"id":            contract.ID,
-			"sender":        contract.Sender,
-			"receiver":      contract.Receiver,
-			"arbitrator":    contract.Arbitrator,
-			"token_symbol":  contract.TokenSymbol,
-			"amount":        contract.Amount,
-			"status":        contract.Status.String(),
-			"created_at":    contract.CreatedAt,
-			"expires_at":    contract.ExpiresAt,
-			"required_sigs": contract.RequiredSigs,
-			"description":   contract.Description,
+			"wallet":    wallet,
+			"proposals": s.blockchain.MultisigProposals(address),
 		},
-	}, nil
+	})
 }
 
-// handleConfirmEscrow handles escrow confirmation requests
-func (s *APIServer) handleConfirmEscrow(req map[string]interface{}) (map[string]interface{}, error) {
-	escrowID, ok := req["escrow_id"].(string)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid escrow_id")
-	}
-
-	confirmer, ok := req["confirmer"].(string)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid confirmer")
+// handleMultisigWallets returns every multisig wallet an address owns
+func (s *APIServer) handleMultisigWallets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	// Use the real escrow manager
-	escrowManager := s.escrowManager.(*escrow.EscrowManager)
-
-	err := escrowManager.ConfirmEscrow(escrowID, confirmer)
-	if err != nil {
-		return nil, err
+	owner := r.URL.Query().Get("owner")
+	if owner == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Owner is required",
+		})
+		return
 	}
 
-	return map[string]interface{}{
+	wallets := s.blockchain.MultisigWalletsOf(owner)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"message": fmt.Sprintf("Escrow %s confirmed successfully", escrowID),
 		"data": map[string]interface{}{
-			"escrow_id": escrowID,
-			"confirmer": confirmer,
-			"status":    "confirmed",
+			"wallets": wallets,
+			"count":   len(wallets),
 		},
-	}, nil
+	})
 }
 
-// handleReleaseEscrow handles escrow release requests
-func (s *APIServer) handleReleaseEscrow(req map[string]interface{}) (map[string]interface{}, error) {
-	escrowID, ok := req["escrow_id"].(string)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid escrow_id")
+// handleMultisigProposal returns a multisig payment proposal with its approvals
+func (s *APIServer) handleMultisigProposal(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	releaser, ok := req["releaser"].(string)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid releaser")
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Proposal ID is required",
+		})
+		return
 	}
 
-	// Use the real escrow manager
-	escrowManager := s.escrowManager.(*escrow.EscrowManager)
-
-	err := escrowManager.ReleaseEscrow(escrowID, releaser)
+	p, err := s.blockchain.GetMultisigProposal(id)
 	if err != nil {
-		return nil, err
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
 	}
 
-	return map[string]interface{}{
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"message": fmt.Sprintf("Escrow %s released successfully", escrowID),
-		"data": map[string]interface{}{
-			"escrow_id": escrowID,
-			"releaser":  releaser,
-			"status":    "released",
-		},
-	}, nil
+		"data":    p,
+	})
 }
 
-// handleCancelEscrow handles escrow cancellation requests
-func (s *APIServer) handleCancelEscrow(req map[string]interface{}) (map[string]interface{}, error) {
-	escrowID, ok := req["escrow_id"].(string)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid escrow_id")
+// handleDEXPool returns a pool with its reserves and total liquidity shares,
+// and the shares of a provider if one is given
+func (s *APIServer) handleDEXPool(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	canceller, ok := req["canceller"].(string)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid canceller")
+	query := r.URL.Query()
+	tokenA, tokenB := query.Get("token_a"), query.Get("token_b")
+	if tokenA == "" || tokenB == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "token_a and token_b are required",
+		})
+		return
 	}
 
-	// Use the real escrow manager
-	escrowManager := s.escrowManager.(*escrow.EscrowManager)
-
-	err := escrowManager.CancelEscrow(escrowID, canceller)
+	pool, err := s.blockchain.GetPool(tokenA, tokenB)
 	if err != nil {
-		return nil, err
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
 	}
 
-	return map[string]interface{}{
+	data := map[string]interface{}{"pool": pool}
+	if provider := query.Get("provider"); provider != "" {
+		data["shares"] = s.blockchain.LiquidityShares(tokenA, tokenB, provider)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// handleDEXPools returns every pool with its reserves
+func (s *APIServer) handleDEXPools(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pools := s.blockchain.Pools()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"message": fmt.Sprintf("Escrow %s cancelled successfully", escrowID),
 		"data": map[string]interface{}{
-			"escrow_id": escrowID,
-			"canceller": canceller,
-			"status":    "cancelled",
+			"pools": pools,
+			"count": len(pools),
 		},
-	}, nil
+	})
 }
 
-// handleGetEscrow handles getting escrow details
-func (s *APIServer) handleGetEscrow(req map[string]interface{}) (map[string]interface{}, error) {
-	escrowID, ok := req["escrow_id"].(string)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid escrow_id")
+// handleDEXQuote returns what a Swap transaction selling amount_in of
+// token_in for token_out would pay out at the current reserves
+func (s *APIServer) handleDEXQuote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	// Use the real escrow manager
-	escrowManager := s.escrowManager.(*escrow.EscrowManager)
-
-	contract, exists := escrowManager.Contracts[escrowID]
-	if !exists {
-		return nil, fmt.Errorf("escrow %s not found", escrowID)
+	query := r.URL.Query()
+	tokenIn, tokenOut := query.Get("token_in"), query.Get("token_out")
+	amountIn, err := uint256.FromDecimal(query.Get("amount_in"))
+	if err != nil || tokenIn == "" || tokenOut == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "token_in, token_out and a decimal amount_in are required",
+		})
+		return
 	}
 
-	return map[string]interface{}{
-		"success": true,
-		"message": fmt.Sprintf("Escrow %s details retrieved", escrowID),
-		"data": map[string]interface{}{
-			"id":            contract.ID,
-			"sender":        contract.Sender,
-			"receiver":      contract.Receiver,
-			"arbitrator":    contract.Arbitrator,
-			"token_symbol":  contract.TokenSymbol,
-			"amount":        contract.Amount,
-			"status":        contract.Status.String(),
-			"created_at":    contract.CreatedAt,
-			"expires_at":    contract.ExpiresAt,
-			"required_sigs": contract.RequiredSigs,
-			"description":   contract.Description,
-		},
-	}, nil
-}
-
-// handleGetUserEscrows handles getting all escrows for a user
-func (s *APIServer) handleGetUserEscrows(req map[string]interface{}) (map[string]interface{}, error) {
-	userAddress, ok := req["user_address"].(string)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid user_address")
-	}
-
-	// Use the real escrow manager
-	escrowManager := s.escrowManager.(*escrow.EscrowManager)
-
-	var userEscrows []interface{}
-
-	// Filter escrows where user is involved
-	for _, contract := range escrowManager.Contracts {
-		// Check if user is involved in this escrow
-		if contract.Sender == userAddress || contract.Receiver == userAddress || contract.Arbitrator == userAddress {
-			escrowData := map[string]interface{}{
-				"id":            contract.ID,
-				"sender":        contract.Sender,
-				"receiver":      contract.Receiver,
-				"arbitrator":    contract.Arbitrator,
-				"token_symbol":  contract.TokenSymbol,
-				"amount":        contract.Amount,
-				"status":        contract.Status.String(),
-				"created_at":    contract.CreatedAt,
-				"expires_at":    contract.ExpiresAt,
-				"required_sigs": contract.RequiredSigs,
-				"description":   contract.Description,
-			}
-			userEscrows = append(userEscrows, escrowData)
-		}
+	amountOut, err := s.blockchain.QuoteSwap(tokenIn, tokenOut, amountIn)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
 	}
 
-	return map[string]interface{}{
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"message": fmt.Sprintf("Found %d escrows for user %s", len(userEscrows), userAddress),
 		"data": map[string]interface{}{
-			"escrows": userEscrows,
-			"count":   len(userEscrows),
+			"token_in":   tokenIn,
+			"token_out":  tokenOut,
+			"amount_in":  amountIn,
+			"amount_out": amountOut,
 		},
-	}, nil
+	})
 }
 
 // handleBalanceQuery handles dedicated balance query requests
@@ -1503,7 +1435,7 @@ func (s *APIServer) handleBalanceQuery(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Printf("✅ Balance found: %d %s for address %s\n", balance, req.TokenSymbol, req.Address)
+	fmt.Printf("✅ Balance found: %s %s for address %s\n", balance, req.TokenSymbol, req.Address)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -1516,200 +1448,763 @@ func (s *APIServer) handleBalanceQuery(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// OTC Trading API Handlers
-func (s *APIServer) handleOTCCreate(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"error":   "Method not allowed",
-		})
+// handleStateProof returns a balance with a Merkle proof against a block's state root
+func (s *APIServer) handleStateProof(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var req struct {
-		Creator         string   `json:"creator"`
-		TokenOffered    string   `json:"token_offered"`
-		AmountOffered   uint64   `json:"amount_offered"`
-		TokenRequested  string   `json:"token_requested"`
-		AmountRequested uint64   `json:"amount_requested"`
-		ExpirationHours int      `json:"expiration_hours"`
-		IsMultiSig      bool     `json:"is_multisig"`
-		RequiredSigs    []string `json:"required_sigs"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	address := r.URL.Query().Get("address")
+	tokenSymbol := r.URL.Query().Get("token")
+	if address == "" {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
-			"error":   "Invalid request format: " + err.Error(),
+			"error":   "Address is required",
 		})
 		return
 	}
 
-	fmt.Printf("🤝 Creating OTC order: %+v\n", req)
-
-	// For now, simulate OTC order creation since we don't have the OTC manager initialized
-	// In a real implementation, this would use: s.blockchain.OTCManager.CreateOrder(...)
-	orderID := fmt.Sprintf("otc_%d_%s", time.Now().UnixNano(), req.Creator[:8])
-
-	// Simulate token balance check
-	if token, exists := s.blockchain.TokenRegistry[req.TokenOffered]; exists {
-		balance, err := token.BalanceOf(req.Creator)
+	// Default to the latest block
+	latestBlock := s.blockchain.GetLatestBlock()
+	height := latestBlock.Header.Index
+	if heightParam := r.URL.Query().Get("height"); heightParam != "" {
+		parsed, err := strconv.ParseUint(heightParam, 10, 64)
 		if err != nil {
 			w.Header().Set("Content-Type", "application/json")
 			json.NewEncoder(w).Encode(map[string]interface{}{
 				"success": false,
-				"error":   "Failed to check balance: " + err.Error(),
+				"error":   "Invalid height: " + err.Error(),
 			})
 			return
 		}
+		height = parsed
+	}
 
-		if balance < req.AmountOffered {
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": false,
-				"error":   fmt.Sprintf("Insufficient balance: has %d, needs %d", balance, req.AmountOffered),
-			})
-			return
-		}
+	key := chain.AccountStateKey(address)
+	if tokenSymbol != "" {
+		key = chain.TokenBalanceKey(tokenSymbol, address)
+	}
+
+	proof, err := s.blockchain.GetStateProof(key, height)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// Decode the committed balance (token leaves hold the balance, account leaves balance+nonce)
+	balance := uint64(0)
+	if value, err := hex.DecodeString(proof.Value); err == nil && len(value) >= 8 {
+		balance = binary.BigEndian.Uint64(value[:8])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"address":      address,
+			"token_symbol": tokenSymbol,
+			"block_height": height,
+			"state_root":   proof.Root,
+			"balance":      balance,
+			"proof":        proof,
+		},
+	})
+}
+
+// handleTransactionReceipt returns the receipt of an included transaction, or
+// reports it as pending or unknown so clients can tell it was dropped
+func (s *APIServer) handleTransactionReceipt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	txID := r.URL.Query().Get("id")
+	if txID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Transaction id is required",
+		})
+		return
+	}
+
+	receipt, err := s.blockchain.GetReceipt(txID)
+	if err == nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data":    receipt,
+		})
+		return
+	}
+	if err != chain.ErrReceiptNotFound {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   fmt.Sprintf("Failed to load receipt: %v", err),
+		})
+		return
+	}
+
+	status := "unknown"
+	if s.blockchain.IsPending(txID) {
+		status = "pending"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"tx_id":  txID,
+			"status": status,
+		},
+	})
+}
+
+// handleAccountNonce returns an account's confirmed nonce and the nonce its
+// next transaction should use given the transactions already pending
+func (s *APIServer) handleAccountNonce(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Address is required",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"address":      address,
+			"nonce":        s.blockchain.GetNonce(address),
+			"next_nonce":   s.blockchain.GetNextNonce(address),
+			"permit_nonce": s.blockchain.GetPermitNonce(address),
+		},
+	})
+}
+
+// handleTransactionLookup finds an included transaction by ID through the
+// transaction index
+func (s *APIServer) handleTransactionLookup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	txID := r.URL.Query().Get("id")
+	if txID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Transaction id is required",
+		})
+		return
+	}
+
+	tx, err := s.blockchain.GetTransaction(txID)
+	if err != nil {
+		message := err.Error()
+		if err == chain.ErrTxNotFound && s.blockchain.IsPending(txID) {
+			message = "Transaction is pending"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   message,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    tx,
+	})
+}
+
+// handleTransactionProof returns a Merkle proof that a transaction is included
+// in its block, for light clients that only hold headers
+func (s *APIServer) handleTransactionProof(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	txID := r.URL.Query().Get("id")
+	if txID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Transaction id is required",
+		})
+		return
+	}
+
+	proof, err := s.blockchain.GetTransactionProof(txID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    proof,
+	})
+}
+
+// handleHeaders returns a range of signed block headers for light clients
+func (s *APIServer) handleHeaders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var from uint64
+	to := s.blockchain.GetLatestBlock().Header.Index
+	for _, param := range []struct {
+		name  string
+		value *uint64
+	}{{"from", &from}, {"to", &to}} {
+		if raw := r.URL.Query().Get(param.name); raw != "" {
+			parsed, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"success": false,
+					"error":   fmt.Sprintf("Invalid %s: %v", param.name, err),
+				})
+				return
+			}
+			*param.value = parsed
+		}
+	}
+
+	headers, err := s.blockchain.GetHeaders(from, to)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    headers,
+	})
+}
+
+// handleAddressTransactions pages through the transactions an address sent or
+// received, newest first
+func (s *APIServer) handleAddressTransactions(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Address is required",
+		})
+		return
+	}
+	s.writeTxPage(w, r, func(cursor string, limit int) (*chain.TxPage, error) {
+		return s.blockchain.GetAddressTransactions(address, cursor, limit)
+	})
+}
+
+// handleTokenTransfers pages through the transfers, mints and burns of a token,
+// newest first
+func (s *APIServer) handleTokenTransfers(w http.ResponseWriter, r *http.Request) {
+	symbol := r.URL.Query().Get("token")
+	if symbol == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Token is required",
+		})
+		return
+	}
+	s.writeTxPage(w, r, func(cursor string, limit int) (*chain.TxPage, error) {
+		return s.blockchain.GetTokenTransfers(symbol, cursor, limit)
+	})
+}
+
+// handleExportLedger returns a token's persisted holders, allowances and supply
+func (s *APIServer) handleExportLedger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbol := r.URL.Query().Get("token")
+	if symbol == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Token is required",
+		})
+		return
+	}
+
+	ledger, err := s.blockchain.ExportTokenLedger(symbol)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    ledger,
+	})
+}
+
+// handleTokenControls returns a token's role holders, pause flag, frozen
+// accounts and blocklist
+func (s *APIServer) handleTokenControls(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbol := r.URL.Query().Get("token")
+	if symbol == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Token is required",
+		})
+		return
+	}
+
+	controls, err := s.blockchain.TokenControls(symbol)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    controls,
+	})
+}
+
+// handleTokenVesting returns an account's vested and unvested balance of a token
+func (s *APIServer) handleTokenVesting(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbol := r.URL.Query().Get("token")
+	address := r.URL.Query().Get("address")
+	if symbol == "" || address == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Token and address are required",
+		})
+		return
+	}
+
+	status, err := s.blockchain.VestingStatus(symbol, address)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    status,
+	})
+}
+
+// handleCollection returns a collection's name, kind, minter and item count
+func (s *APIServer) handleCollection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbol := r.URL.Query().Get("collection")
+	if symbol == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Collection is required",
+		})
+		return
+	}
+
+	info, err := s.blockchain.GetCollection(symbol)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    info,
+	})
+}
+
+// handleCollectionItem returns an item with its URI, supply, owner and holders
+func (s *APIServer) handleCollectionItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbol := r.URL.Query().Get("collection")
+	id := r.URL.Query().Get("id")
+	if symbol == "" || id == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Collection and id are required",
+		})
+		return
+	}
+
+	item, err := s.blockchain.GetItem(symbol, id)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    item,
+	})
+}
+
+// handleCollectionItems lists the items of a collection an address holds
+func (s *APIServer) handleCollectionItems(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbol := r.URL.Query().Get("collection")
+	address := r.URL.Query().Get("address")
+	if symbol == "" || address == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Collection and address are required",
+		})
+		return
+	}
+
+	holdings, err := s.blockchain.ItemsOf(symbol, address)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    holdings,
+	})
+}
+
+// handleExportCollection returns a collection's items, holdings and operators
+// as persisted with the last block
+func (s *APIServer) handleExportCollection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-		// Lock tokens by transferring to OTC contract
-		err = token.Transfer(req.Creator, "otc_contract", req.AmountOffered)
-		if err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": false,
-				"error":   "Failed to lock tokens: " + err.Error(),
-			})
-			return
-		}
+	symbol := r.URL.Query().Get("collection")
+	if symbol == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Collection is required",
+		})
+		return
 	}
 
-	orderData := map[string]interface{}{
-		"order_id":         orderID,
-		"creator":          req.Creator,
-		"token_offered":    req.TokenOffered,
-		"amount_offered":   req.AmountOffered,
-		"token_requested":  req.TokenRequested,
-		"amount_requested": req.AmountRequested,
-		"expiration_hours": req.ExpirationHours,
-		"is_multi_sig":     req.IsMultiSig,
-		"required_sigs":    req.RequiredSigs,
-		"status":           "open",
-		"created_at":       time.Now().Unix(),
-		"expires_at":       time.Now().Add(time.Duration(req.ExpirationHours) * time.Hour).Unix(),
+	ledger, err := s.blockchain.ExportCollection(symbol)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
 	}
 
-	// Store the order for future operations
-	s.storeOTCOrder(orderID, orderData)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    ledger,
+	})
+}
+
+// handleContract returns a contract's code hash, creator and callable functions
+func (s *APIServer) handleContract(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-	// Broadcast order creation event
-	s.broadcastOTCEvent("order_created", orderData)
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Address is required",
+		})
+		return
+	}
 
-	fmt.Printf("✅ OTC order created: %s\n", orderID)
+	info, err := s.blockchain.GetContract(address)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"message": "OTC order created successfully",
-		"data":    orderData,
+		"data":    info,
 	})
 }
 
-func (s *APIServer) handleOTCOrders(w http.ResponseWriter, r *http.Request) {
+// handleContractCode returns the hex encoded code stored under a code hash
+func (s *APIServer) handleContractCode(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hash := r.URL.Query().Get("hash")
+	if hash == "" {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
-			"error":   "Method not allowed",
+			"error":   "Hash is required",
 		})
 		return
 	}
 
-	// Get user parameter from query string
-	userAddress := r.URL.Query().Get("user")
-
-	fmt.Printf("🔍 Getting OTC orders for user: %s\n", userAddress)
+	code, err := s.blockchain.GetContractCode(hash)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
 
-	// For now, return simulated orders
-	// In a real implementation, this would use: s.blockchain.OTCManager.GetUserOrders(userAddress)
-	orders := []map[string]interface{}{
-		{
-			"order_id":         "otc_example_1",
-			"creator":          userAddress,
-			"token_offered":    "BHX",
-			"amount_offered":   1000,
-			"token_requested":  "USDT",
-			"amount_requested": 5000,
-			"status":           "open",
-			"created_at":       time.Now().Unix() - 3600,
-			"expires_at":       time.Now().Unix() + 82800,
-			"note":             "Simulated order from blockchain",
-		},
-		{
-			"order_id":         "otc_market_1",
-			"creator":          "0x9876...4321",
-			"token_offered":    "USDT",
-			"amount_offered":   2000,
-			"token_requested":  "BHX",
-			"amount_requested": 400,
-			"status":           "open",
-			"created_at":       time.Now().Unix() - 1800,
-			"expires_at":       time.Now().Unix() + 84600,
-			"note":             "Market order from another user",
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"hash": hash,
+			"code": hex.EncodeToString(code),
 		},
+	})
+}
+
+// handleContractStorage returns a key of a contract's storage as persisted
+// with the last block. The key and value are hex encoded.
+func (s *APIServer) handleContractStorage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	address := r.URL.Query().Get("address")
+	key, err := hex.DecodeString(r.URL.Query().Get("key"))
+	if address == "" || err != nil || len(key) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Address and a hex key are required",
+		})
+		return
+	}
+
+	value, err := s.blockchain.GetContractStorage(address, string(key))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"data":    orders,
+		"data": map[string]interface{}{
+			"address": address,
+			"key":     hex.EncodeToString(key),
+			"value":   hex.EncodeToString(value),
+			"exists":  value != nil,
+		},
 	})
 }
 
-func (s *APIServer) handleOTCMatch(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
+// handleContractQuery runs a contract function read-only against the current
+// state, without a transaction
+func (s *APIServer) handleContractQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	address, function := query.Get("address"), query.Get("function")
+	if address == "" || function == "" {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
-			"error":   "Method not allowed",
+			"error":   "Address and function are required",
 		})
 		return
 	}
-
-	var req struct {
-		OrderID      string `json:"order_id"`
-		Counterparty string `json:"counterparty"`
+	input, err := hex.DecodeString(query.Get("input"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Invalid input: " + err.Error(),
+		})
+		return
+	}
+	var gas uint64
+	if param := query.Get("gas"); param != "" {
+		if gas, err = strconv.ParseUint(param, 10, 64); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   "Invalid gas: " + err.Error(),
+			})
+			return
+		}
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	result, err := s.blockchain.QueryContract(address, function, input, query.Get("caller"), gas)
+	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
-			"error":   "Invalid request format: " + err.Error(),
+			"error":   err.Error(),
 		})
 		return
 	}
 
-	fmt.Printf("🤝 Matching OTC order %s with counterparty %s\n", req.OrderID, req.Counterparty)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"output":   hex.EncodeToString(result.Output),
+			"gas_used": result.GasUsed,
+		},
+	})
+}
 
-	// Real order matching implementation
-	success, err := s.executeOTCOrderMatch(req.OrderID, req.Counterparty)
+// queryHeight reads the optional height parameter, defaulting to the tip
+func (s *APIServer) queryHeight(r *http.Request) (uint64, error) {
+	param := r.URL.Query().Get("height")
+	if param == "" {
+		return s.blockchain.GetLatestBlock().Header.Index, nil
+	}
+	height, err := strconv.ParseUint(param, 10, 64)
 	if err != nil {
+		return 0, fmt.Errorf("invalid height: %v", err)
+	}
+	return height, nil
+}
+
+// handleBalanceAt returns a token balance at the tip or at a past height
+func (s *APIServer) handleBalanceAt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbol := r.URL.Query().Get("token")
+	address := r.URL.Query().Get("address")
+	if symbol == "" || address == "" {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
-			"error":   err.Error(),
+			"error":   "Token and address are required",
 		})
 		return
 	}
 
-	if !success {
+	height, err := s.queryHeight(r)
+	var balance uint256.Int
+	if err == nil {
+		balance, err = s.blockchain.BalanceAt(symbol, address, height)
+	}
+	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
-			"error":   "Order matching failed",
+			"error":   err.Error(),
 		})
 		return
 	}
@@ -1717,133 +2212,153 @@ func (s *APIServer) handleOTCMatch(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"message": "OTC order matched and executed successfully",
 		"data": map[string]interface{}{
-			"order_id":     req.OrderID,
-			"counterparty": req.Counterparty,
-			"status":       "completed",
-			"matched_at":   time.Now().Unix(),
-			"completed_at": time.Now().Unix(),
+			"address":      address,
+			"token_symbol": symbol,
+			"block_height": height,
+			"balance":      balance,
 		},
 	})
 }
 
-func (s *APIServer) handleOTCCancel(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
+// handleStakeAt returns a validator stake at the tip or at a past height
+func (s *APIServer) handleStakeAt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	address := r.URL.Query().Get("address")
+	if address == "" {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
-			"error":   "Method not allowed",
+			"error":   "Address is required",
 		})
 		return
 	}
 
-	var req struct {
-		OrderID   string `json:"order_id"`
-		Canceller string `json:"canceller"`
+	height, err := s.queryHeight(r)
+	var stake uint256.Int
+	if err == nil {
+		stake, err = s.blockchain.StakeAt(address, height)
 	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
-			"error":   "Invalid request format: " + err.Error(),
+			"error":   err.Error(),
 		})
 		return
 	}
 
-	fmt.Printf("❌ Cancelling OTC order %s by %s\n", req.OrderID, req.Canceller)
-
-	// For now, simulate order cancellation
-	// In a real implementation, this would use: s.blockchain.OTCManager.CancelOrder(req.OrderID, req.Canceller)
-
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"message": "OTC order cancelled successfully",
 		"data": map[string]interface{}{
-			"order_id":     req.OrderID,
-			"status":       "cancelled",
-			"cancelled_at": time.Now().Unix(),
+			"address":      address,
+			"block_height": height,
+			"stake":        stake,
 		},
 	})
 }
 
-// OTC Order Management Functions
-func (s *APIServer) executeOTCOrderMatch(orderID, counterparty string) (bool, error) {
-	fmt.Printf("🔄 Executing OTC order match: %s with %s\n", orderID, counterparty)
-
-	// In a real implementation, this would:
-	// 1. Find the order in the OTC manager
-	// 2. Validate counterparty has required tokens
-	// 3. Execute the token swap
-	// 4. Update order status
+// writeTxPage runs an index query with the request's cursor and limit
+// parameters and writes the resulting page
+func (s *APIServer) writeTxPage(w http.ResponseWriter, r *http.Request, query func(cursor string, limit int) (*chain.TxPage, error)) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-	// For now, simulate a successful match with actual token transfers
-	// This demonstrates the complete flow
+	limit := chain.DefaultPageSize
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed <= 0 {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   "Invalid limit",
+			})
+			return
+		}
+		limit = parsed
+	}
 
-	// Simulate order data (in real implementation, this would come from OTC manager)
-	orderData := map[string]interface{}{
-		"creator":          "test_creator",
-		"token_offered":    "BHX",
-		"amount_offered":   uint64(1000),
-		"token_requested":  "USDT",
-		"amount_requested": uint64(5000),
+	page, err := query(r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
 	}
 
-	// Check if counterparty has required tokens
-	if requestedToken, exists := s.blockchain.TokenRegistry[orderData["token_requested"].(string)]; exists {
-		balance, err := requestedToken.BalanceOf(counterparty)
-		if err != nil {
-			return false, fmt.Errorf("failed to check counterparty balance: %v", err)
-		}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    page,
+	})
+}
 
-		if balance < orderData["amount_requested"].(uint64) {
-			return false, fmt.Errorf("counterparty has insufficient balance: has %d, needs %d",
-				balance, orderData["amount_requested"].(uint64))
-		}
+// handleEventLogs queries the chain event log by block range, event type,
+// address and token
+func (s *APIServer) handleEventLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-		// Execute the token swap
-		// 1. Transfer offered tokens from OTC contract to counterparty
-		if offeredToken, exists := s.blockchain.TokenRegistry[orderData["token_offered"].(string)]; exists {
-			err = offeredToken.Transfer("otc_contract", counterparty, orderData["amount_offered"].(uint64))
+	query := r.URL.Query()
+	filter := chain.LogFilter{
+		Address: query.Get("address"),
+		Token:   query.Get("token"),
+	}
+	for _, param := range []struct {
+		name  string
+		value *uint64
+	}{{"from_block", &filter.FromBlock}, {"to_block", &filter.ToBlock}} {
+		if raw := query.Get(param.name); raw != "" {
+			parsed, err := strconv.ParseUint(raw, 10, 64)
 			if err != nil {
-				return false, fmt.Errorf("failed to transfer offered tokens: %v", err)
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"success": false,
+					"error":   fmt.Sprintf("Invalid %s: %v", param.name, err),
+				})
+				return
 			}
+			*param.value = parsed
 		}
-
-		// 2. Transfer requested tokens from counterparty to creator
-		err = requestedToken.Transfer(counterparty, orderData["creator"].(string), orderData["amount_requested"].(uint64))
-		if err != nil {
-			return false, fmt.Errorf("failed to transfer requested tokens: %v", err)
+	}
+	if types := query.Get("type"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			filter.Types = append(filter.Types, chain.EventType(strings.TrimSpace(t)))
 		}
+	}
 
-		fmt.Printf("✅ OTC trade completed: %d %s ↔ %d %s\n",
-			orderData["amount_offered"], orderData["token_offered"],
-			orderData["amount_requested"], orderData["token_requested"])
-
-		return true, nil
+	logs, err := s.blockchain.GetLogs(filter)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
 	}
 
-	return false, fmt.Errorf("requested token not found")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    logs,
+	})
 }
 
-// Store for OTC orders (in real implementation, this would be in the blockchain)
-var otcOrderStore = make(map[string]map[string]interface{})
-
 // Store for Cross-Chain DEX orders
 var crossChainOrderStore = make(map[string]map[string]interface{})
 var crossChainOrdersByUser = make(map[string][]string) // user -> order IDs
 
-func (s *APIServer) storeOTCOrder(orderID string, orderData map[string]interface{}) {
-	otcOrderStore[orderID] = orderData
-}
-
-func (s *APIServer) getOTCOrder(orderID string) (map[string]interface{}, bool) {
-	order, exists := otcOrderStore[orderID]
-	return order, exists
-}
-
 // Cross-Chain DEX order storage functions
 func (s *APIServer) storeCrossChainOrder(orderID string, orderData map[string]interface{}) {
 	crossChainOrderStore[orderID] = orderData
@@ -1894,15 +2409,19 @@ func (s *APIServer) handleRelaySubmit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Type      string `json:"type"`
-		From      string `json:"from"`
-		To        string `json:"to"`
-		Amount    uint64 `json:"amount"`
-		TokenID   string `json:"token_id"`
-		Fee       uint64 `json:"fee"`
-		Nonce     uint64 `json:"nonce"`
-		Timestamp int64  `json:"timestamp"`
-		Signature string `json:"signature"`
+		ChainID   uint64      `json:"chain_id"`
+		Type      string      `json:"type"`
+		From      string      `json:"from"`
+		To        string      `json:"to"`
+		Amount    uint256.Int `json:"amount"`
+		TokenID   string      `json:"token_id"`
+		Data      string      `json:"data"` // hex encoded
+		Fee       uint64      `json:"fee"`
+		GasLimit  uint64      `json:"gas_limit"`
+		GasPrice  uint64      `json:"gas_price"`
+		Nonce     uint64      `json:"nonce"`
+		Timestamp int64       `json:"timestamp"`
+		Signature string      `json:"signature"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -1929,23 +2448,87 @@ func (s *APIServer) handleRelaySubmit(w http.ResponseWriter, r *http.Request) {
 		txType = chain.TokenMint
 	case "burn":
 		txType = chain.TokenBurn
+	case "create_token":
+		txType = chain.TokenCreate
+	case "token_admin":
+		txType = chain.TokenAdmin
+	case "vest":
+		txType = chain.TokenVest
+	case "permit":
+		txType = chain.TokenPermit
+	case "create_collection":
+		txType = chain.CollectionCreate
+	case "item_mint":
+		txType = chain.ItemMint
+	case "item_transfer":
+		txType = chain.ItemTransfer
+	case "item_burn":
+		txType = chain.ItemBurn
+	case "item_approve":
+		txType = chain.ItemApprove
+	case "contract_deploy":
+		txType = chain.ContractDeploy
+	case "contract_call":
+		txType = chain.SmartContractCall
+	case "escrow_create":
+		txType = chain.EscrowCreate
+	case "escrow_action":
+		txType = chain.EscrowAction
+	case "add_liquidity":
+		txType = chain.AddLiquidity
+	case "remove_liquidity":
+		txType = chain.RemoveLiquidity
+	case "swap":
+		txType = chain.Swap
+	case "otc_create":
+		txType = chain.OTCCreate
+	case "otc_action":
+		txType = chain.OTCAction
+	case "multisig_create":
+		txType = chain.MultisigCreate
+	case "multisig_action":
+		txType = chain.MultisigAction
+	}
+
+	signature, err := hex.DecodeString(req.Signature)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Invalid signature encoding: " + err.Error(),
+		})
+		return
+	}
+	data, err := hex.DecodeString(req.Data)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Invalid data encoding: " + err.Error(),
+		})
+		return
 	}
 
 	// Create transaction
 	tx := &chain.Transaction{
+		ChainID:   req.ChainID,
 		Type:      txType,
 		From:      req.From,
 		To:        req.To,
 		Amount:    req.Amount,
 		TokenID:   req.TokenID,
+		Data:      data,
 		Fee:       req.Fee,
+		GasLimit:  req.GasLimit,
+		GasPrice:  req.GasPrice,
 		Nonce:     req.Nonce,
 		Timestamp: req.Timestamp,
+		Signature: signature,
 	}
 	tx.ID = tx.CalculateHash()
 
 	// Validate and add to pending transactions
-	err := s.blockchain.ValidateTransaction(tx)
+	err = s.blockchain.ProcessTransaction(tx)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -1954,6 +2537,9 @@ func (s *APIServer) handleRelaySubmit(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	if s.blockchain.P2PNode != nil {
+		s.blockchain.BroadcastTransaction(tx)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -2028,11 +2614,11 @@ func (s *APIServer) handleRelayValidate(w http.ResponseWriter, r *http.Request)
 	}
 
 	var req struct {
-		Type    string `json:"type"`
-		From    string `json:"from"`
-		To      string `json:"to"`
-		Amount  uint64 `json:"amount"`
-		TokenID string `json:"token_id"`
+		Type    string      `json:"type"`
+		From    string      `json:"from"`
+		To      string      `json:"to"`
+		Amount  uint256.Int `json:"amount"`
+		TokenID string      `json:"token_id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -2053,7 +2639,7 @@ func (s *APIServer) handleRelayValidate(w http.ResponseWriter, r *http.Request)
 		warnings = append(warnings, "from and to addresses are required")
 	}
 
-	if req.Amount == 0 {
+	if req.Amount.IsZero() {
 		valid = false
 		warnings = append(warnings, "amount must be greater than 0")
 	}
@@ -2109,72 +2695,34 @@ func (s *APIServer) processCrossChainSwap(orderID string) {
 		order["swap_tx_id"] = fmt.Sprintf("swap_%s", orderID)
 
 		// Simulate slight slippage
-		estimatedOut := order["estimated_out"].(uint64)
-		actualOut := uint64(float64(estimatedOut) * 0.998) // 0.2% slippage
-		order["actual_out"] = actualOut
+		estimatedOut := order["estimated_out"].(uint256.Int)
+		order["actual_out"] = basisPointsOf(estimatedOut, 9980) // 0.2% slippage
 	}
 
 	fmt.Printf("✅ Order %s: Cross-chain swap completed!\n", orderID)
 }
 
-func (s *APIServer) updateOTCOrderStatus(orderID, status string) {
-	if order, exists := otcOrderStore[orderID]; exists {
-		order["status"] = status
-		order["updated_at"] = time.Now().Unix()
-
-		// Broadcast status update
-		s.broadcastOTCEvent("order_updated", order)
-	}
-}
-
-// Simple event broadcasting system (in production, use WebSockets)
-func (s *APIServer) broadcastOTCEvent(eventType string, data map[string]interface{}) {
-	fmt.Printf("📡 Broadcasting OTC event: %s\n", eventType)
-	// In a real implementation, this would send WebSocket messages to connected clients
-	// For now, just log the event
-	eventData := map[string]interface{}{
-		"type":      eventType,
-		"data":      data,
-		"timestamp": time.Now().Unix(),
-	}
-
-	// Store recent events for polling-based updates
-	s.storeRecentOTCEvent(eventData)
-}
-
-// Store for recent OTC events
-var recentOTCEvents = make([]map[string]interface{}, 0, 100)
-
-func (s *APIServer) storeRecentOTCEvent(event map[string]interface{}) {
-	recentOTCEvents = append(recentOTCEvents, event)
-
-	// Keep only last 100 events
-	if len(recentOTCEvents) > 100 {
-		recentOTCEvents = recentOTCEvents[1:]
-	}
-}
-
-func (s *APIServer) getRecentOTCEvents() []map[string]interface{} {
-	return recentOTCEvents
+// basisPointsOf returns amount * bps / 10000. bps never exceeds 10000 here,
+// so the result always fits.
+func basisPointsOf(amount uint256.Int, bps uint64) uint256.Int {
+	result, _ := amount.MulDiv(uint256.NewInt(bps), uint256.NewInt(10000))
+	return result
 }
 
-func (s *APIServer) handleOTCEvents(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "GET" {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"error":   "Method not allowed",
-		})
-		return
+// amountParam reads a token amount from a generically decoded JSON body. The
+// decimal string form is exact; plain numbers are accepted while they are
+// whole and within the range a float64 holds exactly.
+func amountParam(v interface{}) (uint256.Int, error) {
+	switch v := v.(type) {
+	case string:
+		return uint256.FromDecimal(v)
+	case float64:
+		if v < 0 || v != float64(uint64(v)) || v > 1<<53 {
+			return uint256.Int{}, fmt.Errorf("%v is not an exact whole amount", v)
+		}
+		return uint256.NewInt(uint64(v)), nil
 	}
-
-	events := s.getRecentOTCEvents()
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    events,
-	})
+	return uint256.Int{}, fmt.Errorf("expected a decimal string, got %T", v)
 }
 
 // Slashing API Handlers
@@ -2287,7 +2835,7 @@ func (s *APIServer) handleSlashingExecute(w http.ResponseWriter, r *http.Request
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"message": "Slashing executed successfully",
+		"message": "Slashing recorded; stakes and balances are unchanged until a block changes them",
 	})
 }
 
@@ -2390,11 +2938,11 @@ func (s *APIServer) handleCrossChainQuote(w http.ResponseWriter, r *http.Request
 	}
 
 	var req struct {
-		SourceChain string `json:"source_chain"`
-		DestChain   string `json:"dest_chain"`
-		TokenIn     string `json:"token_in"`
-		TokenOut    string `json:"token_out"`
-		AmountIn    uint64 `json:"amount_in"`
+		SourceChain string      `json:"source_chain"`
+		DestChain   string      `json:"dest_chain"`
+		TokenIn     string      `json:"token_in"`
+		TokenOut    string      `json:"token_out"`
+		AmountIn    uint256.Int `json:"amount_in"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -2413,10 +2961,10 @@ func (s *APIServer) handleCrossChainQuote(w http.ResponseWriter, r *http.Request
 		"token_in":      req.TokenIn,
 		"token_out":     req.TokenOut,
 		"amount_in":     req.AmountIn,
-		"estimated_out": uint64(float64(req.AmountIn) * 0.95), // 5% total fees
+		"estimated_out": basisPointsOf(req.AmountIn, 9500), // 5% total fees
 		"price_impact":  0.5,
-		"bridge_fee":    uint64(float64(req.AmountIn) * 0.01),  // 1% bridge fee
-		"swap_fee":      uint64(float64(req.AmountIn) * 0.003), // 0.3% swap fee
+		"bridge_fee":    basisPointsOf(req.AmountIn, 100), // 1% bridge fee
+		"swap_fee":      basisPointsOf(req.AmountIn, 30),  // 0.3% swap fee
 		"expires_at":    time.Now().Add(10 * time.Minute).Unix(),
 	}
 
@@ -2438,13 +2986,13 @@ func (s *APIServer) handleCrossChainSwap(w http.ResponseWriter, r *http.Request)
 	}
 
 	var req struct {
-		User         string `json:"user"`
-		SourceChain  string `json:"source_chain"`
-		DestChain    string `json:"dest_chain"`
-		TokenIn      string `json:"token_in"`
-		TokenOut     string `json:"token_out"`
-		AmountIn     uint64 `json:"amount_in"`
-		MinAmountOut uint64 `json:"min_amount_out"`
+		User         string      `json:"user"`
+		SourceChain  string      `json:"source_chain"`
+		DestChain    string      `json:"dest_chain"`
+		TokenIn      string      `json:"token_in"`
+		TokenOut     string      `json:"token_out"`
+		AmountIn     uint256.Int `json:"amount_in"`
+		MinAmountOut uint256.Int `json:"min_amount_out"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -2464,9 +3012,9 @@ func (s *APIServer) handleCrossChainSwap(w http.ResponseWriter, r *http.Request)
 	orderID := fmt.Sprintf("ccswap_%d_%s", time.Now().UnixNano(), userSuffix)
 
 	// Calculate fees and estimated output
-	bridgeFee := uint64(float64(req.AmountIn) * 0.01)    // 1% bridge fee
-	swapFee := uint64(float64(req.AmountIn) * 0.003)     // 0.3% swap fee
-	estimatedOut := uint64(float64(req.AmountIn) * 0.95) // 5% total fees
+	bridgeFee := basisPointsOf(req.AmountIn, 100)     // 1% bridge fee
+	swapFee := basisPointsOf(req.AmountIn, 30)        // 0.3% swap fee
+	estimatedOut := basisPointsOf(req.AmountIn, 9500) // 5% total fees
 
 	// Create real cross-chain swap order
 	order := map[string]interface{}{
@@ -2493,7 +3041,7 @@ func (s *APIServer) handleCrossChainSwap(w http.ResponseWriter, r *http.Request)
 	// Start background processing to simulate swap execution
 	go s.processCrossChainSwap(orderID)
 
-	fmt.Printf("✅ Cross-chain swap initiated: %s (%d %s → %s)\n",
+	fmt.Printf("✅ Cross-chain swap initiated: %s (%s %s → %s)\n",
 		orderID, req.AmountIn, req.TokenIn, req.TokenOut)
 
 	w.Header().Set("Content-Type", "application/json")
@@ -2712,10 +3260,10 @@ func (s *APIServer) handleBridgeApprovalSimulation(w http.ResponseWriter, r *htt
 	}
 
 	var req struct {
-		TokenSymbol string `json:"token_symbol"`
-		Owner       string `json:"owner"`
-		Spender     string `json:"spender"`
-		Amount      uint64 `json:"amount"`
+		TokenSymbol string      `json:"token_symbol"`
+		Owner       string      `json:"owner"`
+		Spender     string      `json:"spender"`
+		Amount      uint256.Int `json:"amount"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -2,11 +2,26 @@ package bridge
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/chain"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// BridgeAddress holds the tokens locked on Blackhole for transfers out
+const BridgeAddress = "bridge_contract"
+
+// receiptTimeout bounds how long the bridge waits for its Blackhole
+// transactions to be included in a block
+const receiptTimeout = 2 * time.Minute
+
+var (
+	ErrInvalidLockTx = errors.New("invalid bridge lock transaction")
+	ErrNoRelayer     = errors.New("no bridge relayer key configured")
 )
 
 // ChainType represents different blockchain types
@@ -21,20 +36,20 @@ const (
 
 // BridgeTransaction represents a cross-chain transaction
 type BridgeTransaction struct {
-	ID              string    `json:"id"`
-	SourceChain     ChainType `json:"source_chain"`
-	DestChain       ChainType `json:"dest_chain"`
-	SourceAddress   string    `json:"source_address"`
-	DestAddress     string    `json:"dest_address"`
-	TokenSymbol     string    `json:"token_symbol"`
-	Amount          uint64    `json:"amount"`
-	Status          string    `json:"status"` // "pending", "confirmed", "completed", "failed"
-	CreatedAt       int64     `json:"created_at"`
-	ConfirmedAt     int64     `json:"confirmed_at,omitempty"`
-	CompletedAt     int64     `json:"completed_at,omitempty"`
-	SourceTxHash    string    `json:"source_tx_hash,omitempty"`
-	DestTxHash      string    `json:"dest_tx_hash,omitempty"`
-	RelaySignatures []string  `json:"relay_signatures"`
+	ID              string      `json:"id"`
+	SourceChain     ChainType   `json:"source_chain"`
+	DestChain       ChainType   `json:"dest_chain"`
+	SourceAddress   string      `json:"source_address"`
+	DestAddress     string      `json:"dest_address"`
+	TokenSymbol     string      `json:"token_symbol"`
+	Amount          uint256.Int `json:"amount"`
+	Status          string      `json:"status"` // "pending", "confirmed", "completed", "failed"
+	CreatedAt       int64       `json:"created_at"`
+	ConfirmedAt     int64       `json:"confirmed_at,omitempty"`
+	CompletedAt     int64       `json:"completed_at,omitempty"`
+	SourceTxHash    string      `json:"source_tx_hash,omitempty"`
+	DestTxHash      string      `json:"dest_tx_hash,omitempty"`
+	RelaySignatures []string    `json:"relay_signatures"`
 	mu              sync.RWMutex
 }
 
@@ -53,6 +68,7 @@ type Bridge struct {
 	RelayNodes      map[string]*RelayNode           `json:"relay_nodes"`
 	TokenMappings   map[ChainType]map[string]string `json:"token_mappings"` // chain -> original_token -> wrapped_token
 	Blockchain      *chain.Blockchain               `json:"-"`
+	relayerKey      *btcec.PrivateKey               // signs the mints that release transfers into Blackhole
 	mu              sync.RWMutex
 }
 
@@ -102,8 +118,20 @@ func (b *Bridge) AddRelayNode(id, address, publicKey string) {
 	}
 }
 
-// InitiateBridgeTransfer initiates a cross-chain transfer
-func (b *Bridge) InitiateBridgeTransfer(sourceChain, destChain ChainType, sourceAddr, destAddr, tokenSymbol string, amount uint64) (*BridgeTransaction, error) {
+// SetRelayer sets the key that signs the TokenMint transactions releasing
+// transfers into Blackhole. Its address needs the minter role of every
+// wrapped token it releases.
+func (b *Bridge) SetRelayer(key *btcec.PrivateKey) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.relayerKey = key
+}
+
+// InitiateBridgeTransfer initiates a cross-chain transfer. Tokens leaving
+// Blackhole are locked by lockTx, a TokenTransfer of amount from sourceAddr to
+// BridgeAddress signed by the sender; the bridge submits it and relays the
+// transfer once it is in a block. lockTx is ignored for other source chains.
+func (b *Bridge) InitiateBridgeTransfer(sourceChain, destChain ChainType, sourceAddr, destAddr, tokenSymbol string, amount uint256.Int, lockTx *chain.Transaction) (*BridgeTransaction, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -135,34 +163,23 @@ func (b *Bridge) InitiateBridgeTransfer(sourceChain, destChain ChainType, source
 		RelaySignatures: make([]string, 0),
 	}
 
-	// If source chain is Blackhole, lock tokens
+	// If source chain is Blackhole, lock tokens with the sender's transaction
 	if sourceChain == ChainTypeBlackhole {
-		token, exists := b.Blockchain.TokenRegistry[tokenSymbol]
-		if !exists {
-			return nil, fmt.Errorf("token %s not found", tokenSymbol)
+		if lockTx == nil || lockTx.Type != chain.TokenTransfer || lockTx.From != sourceAddr ||
+			lockTx.To != BridgeAddress || lockTx.TokenID != tokenSymbol || lockTx.Amount != amount {
+			return nil, fmt.Errorf("%w: need a TokenTransfer of %s %s from %s to %s", ErrInvalidLockTx, amount, tokenSymbol, sourceAddr, BridgeAddress)
 		}
-
-		// Check balance
-		balance, err := token.BalanceOf(sourceAddr)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check balance: %v", err)
-		}
-
-		if balance < amount {
-			return nil, fmt.Errorf("insufficient balance: has %d, needs %d", balance, amount)
-		}
-
-		// Lock tokens in bridge contract
-		err = token.Transfer(sourceAddr, "bridge_contract", amount)
-		if err != nil {
+		if err := b.Blockchain.ProcessTransaction(lockTx); err != nil {
 			return nil, fmt.Errorf("failed to lock tokens: %v", err)
 		}
-
-		bridgeTx.SourceTxHash = fmt.Sprintf("blackhole_tx_%d", time.Now().UnixNano())
+		if b.Blockchain.P2PNode != nil {
+			b.Blockchain.BroadcastTransaction(lockTx)
+		}
+		bridgeTx.SourceTxHash = lockTx.ID
 	}
 
 	b.Transactions[bridgeTxID] = bridgeTx
-	fmt.Printf("✅ Bridge transfer initiated: %s (%d %s from %s to %s)\n",
+	fmt.Printf("✅ Bridge transfer initiated: %s (%s %s from %s to %s)\n",
 		bridgeTxID, amount, tokenSymbol, sourceChain, destChain)
 
 	// Simulate relay processing
@@ -171,19 +188,28 @@ func (b *Bridge) InitiateBridgeTransfer(sourceChain, destChain ChainType, source
 	return bridgeTx, nil
 }
 
-// processRelayConfirmation simulates relay node confirmation
+// processRelayConfirmation waits for the Blackhole lock, if any, and
+// simulates relay node confirmation
 func (b *Bridge) processRelayConfirmation(bridgeTxID string) {
 	// Simulate relay processing time
 	time.Sleep(5 * time.Second)
 
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
+	b.mu.RLock()
 	bridgeTx, exists := b.Transactions[bridgeTxID]
+	b.mu.RUnlock()
 	if !exists {
 		return
 	}
 
+	if bridgeTx.SourceChain == ChainTypeBlackhole {
+		if err := b.awaitReceipt(bridgeTx.SourceTxHash); err != nil {
+			b.fail(bridgeTx, fmt.Errorf("lock %s: %v", bridgeTx.SourceTxHash, err))
+			return
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	bridgeTx.mu.Lock()
 	defer bridgeTx.mu.Unlock()
 
@@ -206,44 +232,102 @@ func (b *Bridge) processRelayConfirmation(bridgeTxID string) {
 	go b.processDestinationTransfer(bridgeTxID)
 }
 
-// processDestinationTransfer simulates transfer on destination chain
+// processDestinationTransfer releases the transfer on the destination chain.
+// Transfers into Blackhole are minted by a TokenMint transaction signed by the
+// relayer; other chains are simulated.
 func (b *Bridge) processDestinationTransfer(bridgeTxID string) {
 	// Simulate destination processing time
 	time.Sleep(3 * time.Second)
 
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
+	b.mu.RLock()
 	bridgeTx, exists := b.Transactions[bridgeTxID]
+	b.mu.RUnlock()
 	if !exists {
 		return
 	}
 
-	bridgeTx.mu.Lock()
-	defer bridgeTx.mu.Unlock()
-
-	// If destination is Blackhole, mint wrapped tokens
+	destTxHash := fmt.Sprintf("%s_tx_%d", bridgeTx.DestChain, time.Now().UnixNano())
 	if bridgeTx.DestChain == ChainTypeBlackhole {
-		destToken := b.TokenMappings[bridgeTx.DestChain][bridgeTx.TokenSymbol]
-		token, exists := b.Blockchain.TokenRegistry[destToken]
-		if exists {
-			// Mint wrapped tokens to destination address
-			err := token.Mint(bridgeTx.DestAddress, bridgeTx.Amount)
-			if err == nil {
-				bridgeTx.DestTxHash = fmt.Sprintf("blackhole_mint_%d", time.Now().UnixNano())
-			}
+		destToken := b.GetTokenMapping(ChainTypeBlackhole)[bridgeTx.TokenSymbol]
+		mint, err := b.submitMint(destToken, bridgeTx.DestAddress, bridgeTx.Amount)
+		if err == nil {
+			err = b.awaitReceipt(mint.ID)
+		}
+		if err != nil {
+			b.fail(bridgeTx, fmt.Errorf("release: %v", err))
+			return
 		}
-	} else {
-		// Simulate external chain transaction
-		bridgeTx.DestTxHash = fmt.Sprintf("%s_tx_%d", bridgeTx.DestChain, time.Now().UnixNano())
+		destTxHash = mint.ID
 	}
 
+	bridgeTx.mu.Lock()
+	defer bridgeTx.mu.Unlock()
+	bridgeTx.DestTxHash = destTxHash
 	bridgeTx.Status = "completed"
 	bridgeTx.CompletedAt = time.Now().Unix()
 
 	fmt.Printf("✅ Bridge transfer completed: %s (tx: %s)\n", bridgeTxID, bridgeTx.DestTxHash)
 }
 
+// submitMint signs a TokenMint of amount of symbol to to with the relayer key
+// and adds it to the transaction pool
+func (b *Bridge) submitMint(symbol, to string, amount uint256.Int) (*chain.Transaction, error) {
+	b.mu.RLock()
+	key := b.relayerKey
+	b.mu.RUnlock()
+	if key == nil {
+		return nil, ErrNoRelayer
+	}
+
+	bc := b.Blockchain
+	tx := chain.NewTransaction(chain.TokenMint, chain.PublicKeyToAddress(key.PubKey()), to, amount, nil)
+	tx.ChainID = bc.ChainID
+	tx.TokenID = symbol
+	tx.Nonce = bc.GetNextNonce(tx.From)
+	tx.GasPrice = bc.Fees.MinGasPrice
+	tx.GasLimit = chain.IntrinsicGas(tx)
+	if err := tx.Sign(key); err != nil {
+		return nil, err
+	}
+	if err := bc.ProcessTransaction(tx); err != nil {
+		return nil, err
+	}
+	if bc.P2PNode != nil {
+		bc.BroadcastTransaction(tx)
+	}
+	return tx, nil
+}
+
+// awaitReceipt waits until the transaction with ID txID is in a block and
+// returns an error unless it succeeded
+func (b *Bridge) awaitReceipt(txID string) error {
+	deadline := time.Now().Add(receiptTimeout)
+	for {
+		receipt, err := b.Blockchain.GetReceipt(txID)
+		if err == nil {
+			if receipt.Status != chain.ReceiptSuccess {
+				return fmt.Errorf("transaction %s failed: %s", txID, receipt.Error)
+			}
+			return nil
+		}
+		if !errors.Is(err, chain.ErrReceiptNotFound) {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("transaction %s not included after %s", txID, receiptTimeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// fail marks a bridge transaction as failed
+func (b *Bridge) fail(bridgeTx *BridgeTransaction, err error) {
+	bridgeTx.mu.Lock()
+	defer bridgeTx.mu.Unlock()
+	bridgeTx.Status = "failed"
+	fmt.Printf("❌ Bridge transfer %s failed: %v\n", bridgeTx.ID, err)
+}
+
 // GetBridgeTransaction returns a bridge transaction
 func (b *Bridge) GetBridgeTransaction(bridgeTxID string) (*BridgeTransaction, error) {
 	b.mu.RLock()
@@ -314,7 +398,7 @@ func (b *Bridge) GenerateTestBridgeTransaction() string {
 		"source_address":   "blackhole_addr_123",
 		"dest_address":     "0x742d35Cc6634C0532925a3b8D4C9db96590b5",
 		"token_symbol":     "BHX",
-		"amount":           1000,
+		"amount":           "1000",
 		"status":           "pending",
 		"created_at":       time.Now().Unix(),
 		"relay_signatures": []string{},
@@ -326,22 +410,22 @@ func (b *Bridge) GenerateTestBridgeTransaction() string {
 
 // ApprovalSimulation represents the result of a bridge approval simulation
 type ApprovalSimulation struct {
-	Valid               bool     `json:"valid"`
-	TokenSymbol         string   `json:"token_symbol"`
-	Owner               string   `json:"owner"`
-	Spender             string   `json:"spender"`
-	RequestedAmount     uint64   `json:"requested_amount"`
-	CurrentAllowance    uint64   `json:"current_allowance"`
-	CurrentBalance      uint64   `json:"current_balance"`
-	SufficientBalance   bool     `json:"sufficient_balance"`
-	SufficientAllowance bool     `json:"sufficient_allowance"`
-	Warnings            []string `json:"warnings"`
-	EstimatedGasCost    uint64   `json:"estimated_gas_cost"`
-	Timestamp           int64    `json:"timestamp"`
+	Valid               bool        `json:"valid"`
+	TokenSymbol         string      `json:"token_symbol"`
+	Owner               string      `json:"owner"`
+	Spender             string      `json:"spender"`
+	RequestedAmount     uint256.Int `json:"requested_amount"`
+	CurrentAllowance    uint256.Int `json:"current_allowance"`
+	CurrentBalance      uint256.Int `json:"current_balance"`
+	SufficientBalance   bool        `json:"sufficient_balance"`
+	SufficientAllowance bool        `json:"sufficient_allowance"`
+	Warnings            []string    `json:"warnings"`
+	EstimatedGasCost    uint64      `json:"estimated_gas_cost"`
+	Timestamp           int64       `json:"timestamp"`
 }
 
 // SimulateApproval simulates a token approval for bridge operations
-func (b *Bridge) SimulateApproval(sourceChain ChainType, tokenSymbol, owner, spender string, amount uint64) (*ApprovalSimulation, error) {
+func (b *Bridge) SimulateApproval(sourceChain ChainType, tokenSymbol, owner, spender string, amount uint256.Int) (*ApprovalSimulation, error) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
@@ -371,7 +455,7 @@ func (b *Bridge) SimulateApproval(sourceChain ChainType, tokenSymbol, owner, spe
 		return simulation, nil
 	}
 	simulation.CurrentBalance = balance
-	simulation.SufficientBalance = balance >= amount
+	simulation.SufficientBalance = !balance.Lt(amount)
 
 	// Check current allowance
 	allowance, err := token.Allowance(owner, spender)
@@ -381,21 +465,21 @@ func (b *Bridge) SimulateApproval(sourceChain ChainType, tokenSymbol, owner, spe
 		return simulation, nil
 	}
 	simulation.CurrentAllowance = allowance
-	simulation.SufficientAllowance = allowance >= amount
+	simulation.SufficientAllowance = !allowance.Lt(amount)
 
 	// Validate approval requirements
 	if !simulation.SufficientBalance {
 		simulation.Warnings = append(simulation.Warnings,
-			fmt.Sprintf("Insufficient balance: has %d, needs %d", balance, amount))
+			fmt.Sprintf("Insufficient balance: has %s, needs %s", balance, amount))
 	}
 
 	if !simulation.SufficientAllowance {
 		simulation.Warnings = append(simulation.Warnings,
-			fmt.Sprintf("Insufficient allowance: has %d, needs %d", allowance, amount))
+			fmt.Sprintf("Insufficient allowance: has %s, needs %s", allowance, amount))
 	}
 
 	// Check for common issues
-	if amount > 1000000000 { // Very large amount
+	if amount.Gt(uint256.NewInt(1000000000)) { // Very large amount
 		simulation.Warnings = append(simulation.Warnings, "Large amount detected - please verify")
 	}
 
@@ -421,7 +505,7 @@ func (b *Bridge) ValidateApprovalForBridge(bridgeTx *BridgeTransaction) error {
 		bridgeTx.SourceChain,
 		bridgeTx.TokenSymbol,
 		bridgeTx.SourceAddress,
-		"bridge_contract", // Bridge contract as spender
+		BridgeAddress, // Bridge contract as spender
 		bridgeTx.Amount,
 	)
 	if err != nil {
@@ -440,7 +524,7 @@ func (b *Bridge) ValidateApprovalForBridge(bridgeTx *BridgeTransaction) error {
 }
 
 // PreValidateBridgeTransfer performs pre-flight validation of a bridge transfer
-func (b *Bridge) PreValidateBridgeTransfer(sourceAddr, tokenSymbol string, amount uint64) error {
+func (b *Bridge) PreValidateBridgeTransfer(sourceAddr, tokenSymbol string, amount uint256.Int) error {
 	// Check if token exists
 	token, exists := b.Blockchain.TokenRegistry[tokenSymbol]
 	if !exists {
@@ -453,18 +537,18 @@ func (b *Bridge) PreValidateBridgeTransfer(sourceAddr, tokenSymbol string, amoun
 		return fmt.Errorf("failed to check balance: %v", err)
 	}
 
-	if balance < amount {
-		return fmt.Errorf("insufficient balance: has %d, needs %d", balance, amount)
+	if balance.Lt(amount) {
+		return fmt.Errorf("insufficient balance: has %s, needs %s", balance, amount)
 	}
 
 	// Check allowance for bridge contract
-	allowance, err := token.Allowance(sourceAddr, "bridge_contract")
+	allowance, err := token.Allowance(sourceAddr, BridgeAddress)
 	if err != nil {
 		return fmt.Errorf("failed to check bridge allowance: %v", err)
 	}
 
-	if allowance < amount {
-		return fmt.Errorf("insufficient bridge allowance: has %d, needs %d. Please approve bridge contract first", allowance, amount)
+	if allowance.Lt(amount) {
+		return fmt.Errorf("insufficient bridge allowance: has %s, needs %s. Please approve bridge contract first", allowance, amount)
 	}
 
 	return nil
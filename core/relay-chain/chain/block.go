@@ -1,42 +1,48 @@
 package chain
 
 import (
-	"bytes"
 	"crypto/sha256"
-	"encoding/gob"
 	"encoding/hex"
-	"fmt"
 	"time"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
 type Block struct {
 	Header       BlockHeader
 	Transactions []*Transaction
 	Hash         string `json:"hash"`
+	Signature    []byte `json:"signature,omitempty"` // proposer's signature over Hash
 }
 
+// Serialize returns the canonical wire and storage encoding of the block
 func (b *Block) Serialize() []byte {
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
-	if err := enc.Encode(b); err != nil {
-		panic("failed to serialize block: " + err.Error())
-	}
-	return buf.Bytes()
+	return b.Encode()
 }
 
 type BlockHeader struct {
-	Index          uint64    `json:"index"`
-	Timestamp      time.Time `json:"timestamp"`
-	PreviousHash   string    `json:"previousHash"`
-	Validator      string    `json:"validator"`
-	StakeSnapshot  uint64    `json:"stakeSnapshot"`
-	MerkleRoot     string    `json:"merkleRoot"`
-	StateRoot      string    `json:"stateRoot"`
-	ReceiptsRoot   string    `json:"receiptsRoot"`
-	ConsensusRound uint64    `json:"consensusRound"`
+	Index          uint64      `json:"index"`
+	Timestamp      time.Time   `json:"timestamp"`
+	PreviousHash   string      `json:"previousHash"`
+	Validator      string      `json:"validator"`
+	StakeSnapshot  uint256.Int `json:"stakeSnapshot"`
+	MerkleRoot     string      `json:"merkleRoot"`
+	StateRoot      string      `json:"stateRoot"`
+	ReceiptsRoot   string      `json:"receiptsRoot"`
+	ConsensusRound uint64      `json:"consensusRound"`
+}
+
+// SignedHeader is a block header with its hash and proposer signature. It is
+// all a light client needs to follow the chain.
+type SignedHeader struct {
+	Header    BlockHeader `json:"header"`
+	Hash      string      `json:"hash"`
+	Signature []byte      `json:"signature,omitempty"`
 }
 
-func NewBlock(index uint64, txs []*Transaction, prevHash string, validator string, stake uint64) *Block {
+// NewBlock assembles a block on top of prevHash. stateRoot and receiptsRoot commit to
+// the state after the block and the receipts of its own transactions.
+func NewBlock(index uint64, txs []*Transaction, prevHash string, validator string, stake uint256.Int, stateRoot, receiptsRoot string) *Block {
 	block := &Block{
 		Header: BlockHeader{
 			Index:         index,
@@ -49,64 +55,38 @@ func NewBlock(index uint64, txs []*Transaction, prevHash string, validator strin
 	}
 
 	block.Header.MerkleRoot = block.CalculateMerkleRoot()
-	block.Header.StateRoot = "0x0"
-	block.Header.ReceiptsRoot = "0x0"
+	block.Header.StateRoot = stateRoot
+	block.Header.ReceiptsRoot = receiptsRoot
 	block.Hash = block.CalculateHash()
 
 	return block
 }
 
+// CalculateHash returns the SHA-256 of the canonical header encoding
 func (b *Block) CalculateHash() string {
-	headerData := fmt.Sprintf("%d%s%s%s%d%s",
-		b.Header.Index,
-		b.Header.Timestamp.UTC().Format(time.RFC3339Nano),
-		b.Header.PreviousHash,
-		b.Header.Validator,
-		b.Header.StakeSnapshot,
-		b.Header.MerkleRoot,
-	)
-	hash := sha256.Sum256([]byte(headerData))
+	return b.Header.Hash()
+}
+
+// Hash returns the SHA-256 of the canonical header encoding, which is the block hash
+func (h *BlockHeader) Hash() string {
+	hash := sha256.Sum256(h.Encode())
 	return hex.EncodeToString(hash[:])
 }
 
+// CalculateMerkleRoot returns the root of the Merkle tree over the transaction IDs
 func (b *Block) CalculateMerkleRoot() string {
-	if len(b.Transactions) == 0 {
-		return ""
-	}
-
-	var hashes []string
-	for _, tx := range b.Transactions {
-		hashes = append(hashes, tx.ID)
-	}
-
-	for len(hashes) > 1 {
-		var newHashes []string
-		for i := 0; i < len(hashes); i += 2 {
-			if i+1 == len(hashes) {
-				newHashes = append(newHashes, hashPair(hashes[i], hashes[i]))
-			} else {
-				newHashes = append(newHashes, hashPair(hashes[i], hashes[i+1]))
-			}
-		}
-		hashes = newHashes
-	}
-
-	return hashes[0]
+	return b.txTree().Root()
 }
 
-func hashPair(a, b string) string {
-	h := sha256.New()
-	h.Write([]byte(a + b))
-	return hex.EncodeToString(h.Sum(nil))
+func (b *Block) txTree() *MerkleTree {
+	ids := make([]string, len(b.Transactions))
+	for i, tx := range b.Transactions {
+		ids[i] = tx.ID
+	}
+	return NewMerkleTree(ids)
 }
 
+// IsValid reports whether the block passes the checks that need no chain state
 func (b *Block) IsValid() bool {
-	// Verify hash matches header data
-	calculatedHash := b.CalculateHash()
-	if b.Header.PreviousHash != "" && calculatedHash != b.CalculateHash() {
-		return false
-	}
-
-	// Add any other validation rules you need
-	return true
+	return b.ValidateStructure() == nil
 }
@@ -0,0 +1,483 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// LevelDB keys used by the block store
+var (
+	headKey      = []byte("head")
+	headStateKey = []byte("head:stateroot")
+)
+
+func blockKey(hash string) []byte {
+	return []byte("block:" + hash)
+}
+
+func heightKey(height uint64) []byte {
+	return []byte(fmt.Sprintf("height:%020d", height))
+}
+
+// executeBlock applies every transaction in block and returns their receipts.
+// Caller must hold bc.mu.
+func (bc *Blockchain) executeBlock(block *Block) []*Receipt {
+	receipts := make([]*Receipt, 0, len(block.Transactions))
+	bc.clock.Set(block.Header.Index, block.Header.Timestamp.Unix())
+
+	for i, tx := range block.Transactions {
+		// Validate transaction security before applying
+		if !bc.validateTransactionSecurity(tx) {
+			fmt.Printf("⚠️ Suspicious transaction detected: %s\n", tx.ID)
+
+			// Skip this transaction but continue processing the block
+			fmt.Printf("⏭️ Skipping suspicious transaction %s\n", tx.ID)
+			receipts = append(receipts, newReceipt(tx, block, i, nil, errors.New("rejected by transaction security checks")))
+			continue
+		}
+
+		// The sender pays the fee and consumes its nonce even if the transaction
+		// then fails, so it cannot be replayed. System transactions are free and unsequenced.
+		// Contract transactions pay for the gas they used after they run.
+		var gasUsed uint64
+		var fee uint256.Int
+		paysGas := !isSystemSender(tx.From) && isContractTx(tx.Type)
+		if !isSystemSender(tx.From) {
+			err := bc.checkChainID(tx)
+			if err == nil {
+				err = bc.checkNonce(tx)
+			}
+			if err == nil {
+				err = bc.checkGas(tx)
+			}
+			if err == nil && paysGas {
+				err = bc.checkFunds(tx)
+			} else if err == nil {
+				gasUsed, fee, err = bc.chargeFee(tx, block.Header.Validator)
+			}
+			if err != nil {
+				fmt.Printf("⚠️ Rejected transaction %s: %v\n", tx.ID, err)
+				receipts = append(receipts, newReceipt(tx, block, i, nil, err))
+				continue
+			}
+			bc.useNonce(tx)
+		}
+
+		var events []Event
+		var err error
+		if paysGas {
+			events, gasUsed, err = bc.applyContract(tx)
+			var feeErr error
+			if gasUsed, fee, feeErr = bc.chargeGas(tx, block.Header.Validator, gasUsed); feeErr != nil {
+				// checkFunds covered the whole gas limit, so this cannot happen
+				fmt.Printf("⚠️ Failed to charge gas for transaction %s: %v\n", tx.ID, feeErr)
+			}
+		} else {
+			events, err = bc.applyTransaction(tx)
+		}
+		if err != nil {
+			fmt.Printf("⚠️ Failed to apply transaction %s, skipping: %v\n", tx.ID, err)
+		}
+		receipt := newReceipt(tx, block, i, events, err)
+		receipt.GasUsed, receipt.Fee = gasUsed, fee
+		// The fee is charged even when the transaction fails
+		if !fee.IsZero() {
+			receipt.Events = append(bc.feeEvents(tx, block.Header.Validator, fee), receipt.Events...)
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	bc.receiptsRoot = CalculateReceiptsRoot(receipts)
+	return receipts
+}
+
+// applyBlock executes the block in node while journaling the state it changes
+// into the node's undo log, and updates the state tree with the leaves it
+// touched. Caller must hold bc.mu.
+func (bc *Blockchain) applyBlock(node *blockNode) []*Receipt {
+	undo := bc.beginUndo()
+	receipts := bc.executeBlock(node.block)
+	bc.endUndo()
+
+	node.undo = undo
+	bc.updateStateTree(bc.touchedLeaves(undo))
+	return receipts
+}
+
+// unapplyBlock rolls state back to parent using the undo log of the block in
+// node, which must be the last block applied. Caller must hold bc.mu.
+func (bc *Blockchain) unapplyBlock(node *blockNode, parent *Block) {
+	leaves := bc.touchedLeaves(node.undo)
+	bc.revert(node.undo)
+	bc.updateStateTree(leaves)
+	node.undo = nil
+
+	bc.clock.Set(parent.Header.Index, parent.Header.Timestamp.Unix())
+	bc.rewindHistory(parent.Header.Index)
+}
+
+// commitResults executes block on top of the tip, writes the state and
+// receipts roots it produces into the header and rolls the state back. The
+// block hash is updated to match. Caller must hold bc.mu.
+func (bc *Blockchain) commitResults(block *Block) {
+	node := &blockNode{block: block}
+	bc.applyBlock(node)
+	block.Header.StateRoot = bc.currentStateTree().Root()
+	block.Header.ReceiptsRoot = bc.receiptsRoot
+	bc.unapplyBlock(node, bc.Blocks[len(bc.Blocks)-1])
+	block.Hash = block.CalculateHash()
+}
+
+// connectBlock validates the block in node against the tip, applies it, checks
+// the roots it commits to and persists it. Caller must hold bc.mu.
+func (bc *Blockchain) connectBlock(node *blockNode) error {
+	block := node.block
+	tip := bc.Blocks[len(bc.Blocks)-1]
+	if err := bc.validateBlock(block, tip, time.Now()); err != nil {
+		return err
+	}
+
+	receipts := bc.applyBlock(node)
+	if err := bc.verifyCommitments(block); err != nil {
+		bc.unapplyBlock(node, tip)
+		return err
+	}
+	bc.appendBlock(block)
+
+	if err := bc.persistBlock(block, receipts); err != nil {
+		log.Printf("❌ Failed to persist block %d: %v", block.Header.Index, err)
+	}
+	return nil
+}
+
+// disconnectTip rolls state back to before the tip block using its undo log and
+// removes the block from the main chain. Caller must hold bc.mu.
+func (bc *Blockchain) disconnectTip() (*blockNode, error) {
+	block := bc.Blocks[len(bc.Blocks)-1]
+	if block.Header.Index == 0 {
+		return nil, errors.New("cannot disconnect the genesis block")
+	}
+	node, exists := bc.tree.nodes[block.Hash]
+	if !exists || node.undo == nil {
+		return nil, fmt.Errorf("%w: no undo log for block %d", ErrReorgTooDeep, block.Header.Index)
+	}
+
+	bc.Blocks = bc.Blocks[:len(bc.Blocks)-1]
+	delete(bc.blocksByHash, block.Hash)
+	bc.unapplyBlock(node, bc.Blocks[len(bc.Blocks)-1])
+
+	if err := bc.persistDisconnect(block); err != nil {
+		log.Printf("❌ Failed to persist disconnect of block %d: %v", block.Header.Index, err)
+	}
+	fmt.Printf("⏪ Disconnected block %d (%s)\n", block.Header.Index, block.Hash)
+	return node, nil
+}
+
+// appendBlock extends the in-memory chain and its hash index, and drops the
+// side branches, undo log and balance history that fall out of range. Caller
+// must hold bc.mu.
+func (bc *Blockchain) appendBlock(block *Block) {
+	bc.Blocks = append(bc.Blocks, block)
+	bc.blocksByHash[block.Hash] = block
+
+	if height := block.Header.Index; height > MaxReorgDepth {
+		bc.tree.prune(height-MaxReorgDepth, bc.Blocks[height-MaxReorgDepth])
+	}
+	bc.pruneHistory(block.Header.Index)
+}
+
+// writeDirtyAccounts adds every account changed since the last write to batch
+func (bc *Blockchain) writeDirtyAccounts(batch *leveldb.Batch) error {
+	for addr := range bc.dirtyAccounts {
+		state, exists := bc.GlobalState[addr]
+		if !exists {
+			batch.Delete([]byte("account:" + addr))
+			continue
+		}
+		data, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		batch.Put([]byte("account:"+addr), data)
+	}
+	return nil
+}
+
+// persistBlock writes the block, its height index, its receipts, its transaction
+// index entries and events, the accounts and other state it changed and the new head in a single batch. The post-block state root is stored
+// with the head so a restart can check the replayed state against it.
+func (bc *Blockchain) persistBlock(block *Block, receipts []*Receipt) error {
+	batch := new(leveldb.Batch)
+	if err := bc.writeDirtyAccounts(batch); err != nil {
+		return err
+	}
+	batch.Put(blockKey(block.Hash), block.Serialize())
+	batch.Put(heightKey(block.Header.Index), []byte(block.Hash))
+	batch.Put(headKey, []byte(block.Hash))
+	batch.Put(headStateKey, []byte(bc.currentStateTree().Root()))
+
+	for _, receipt := range receipts {
+		if err := putReceipt(batch, receipt); err != nil {
+			return err
+		}
+	}
+	if err := indexBlock(batch, block); err != nil {
+		return err
+	}
+	if err := putLogs(batch, block, receipts); err != nil {
+		return err
+	}
+	pending := bc.writePendingState(batch)
+	if err := bc.DB.Write(batch, nil); err != nil {
+		bc.stateStore.putBack(pending)
+		return err
+	}
+	bc.dirtyAccounts = make(map[string]bool)
+	return nil
+}
+
+// persistDisconnect removes a disconnected block's height entry, receipts, index
+// entries and events and moves the stored head back to its parent. The block itself stays stored.
+func (bc *Blockchain) persistDisconnect(block *Block) error {
+	batch := new(leveldb.Batch)
+	if err := bc.writeDirtyAccounts(batch); err != nil {
+		return err
+	}
+	for _, tx := range block.Transactions {
+		batch.Delete([]byte("receipt:" + tx.ID))
+	}
+	unindexBlock(batch, block)
+	if err := bc.deleteLogs(batch, block.Header.Index); err != nil {
+		return err
+	}
+	batch.Delete(heightKey(block.Header.Index))
+	batch.Put(headKey, []byte(block.Header.PreviousHash))
+	batch.Put(headStateKey, []byte(bc.currentStateTree().Root()))
+
+	pending := bc.writePendingState(batch)
+	if err := bc.DB.Write(batch, nil); err != nil {
+		bc.stateStore.putBack(pending)
+		return err
+	}
+	bc.dirtyAccounts = make(map[string]bool)
+	return nil
+}
+
+// reorganize moves the main chain to end at target. Blocks back to the fork
+// point are disconnected using their undo logs, then target's branch is
+// connected with full validation. If a branch block is invalid the original
+// chain is restored. Caller must hold bc.mu.
+func (bc *Blockchain) reorganize(target *blockNode) error {
+	tip := bc.tipNode()
+	fork := forkPoint(tip, target)
+	for n := tip; n != fork; n = n.parent {
+		if n.undo == nil {
+			return fmt.Errorf("%w: fork point %d, tip %d", ErrReorgTooDeep, fork.height(), tip.height())
+		}
+	}
+
+	var branch []*blockNode
+	for n := target; n != fork; n = n.parent {
+		branch = append([]*blockNode{n}, branch...)
+	}
+	fmt.Printf("🔀 Reorganizing: rolling back %d blocks to %d and applying %d (weight %s over %s)\n",
+		tip.height()-fork.height(), fork.height(), len(branch), target.weight, tip.weight)
+
+	detached, err := bc.disconnectTo(fork)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range branch {
+		if err := bc.connectBlock(node); err != nil {
+			bc.rejectBlock(node, err)
+			if restoreErr := bc.restoreBranch(fork, detached); restoreErr != nil {
+				return fmt.Errorf("failed to restore the main chain after %v: %v", err, restoreErr)
+			}
+			return err
+		}
+	}
+
+	// Transactions only the abandoned blocks included go back to the pool
+	for _, node := range detached {
+		for _, tx := range node.block.Transactions {
+			if !isSystemSender(tx.From) {
+				bc.txPool.Add(tx)
+			}
+		}
+	}
+	for _, node := range branch {
+		bc.txPool.RemoveTxs(node.block.Transactions)
+	}
+
+	fmt.Printf("✅ Chain reorganized to block %d (%s)\n", target.height(), target.block.Hash)
+	return nil
+}
+
+// disconnectTo disconnects blocks until fork is the tip and returns them tip first
+func (bc *Blockchain) disconnectTo(fork *blockNode) ([]*blockNode, error) {
+	var detached []*blockNode
+	for bc.tipNode() != fork {
+		node, err := bc.disconnectTip()
+		if err != nil {
+			return detached, err
+		}
+		detached = append(detached, node)
+	}
+	return detached, nil
+}
+
+// restoreBranch rolls back to fork and reconnects the blocks a failed reorg detached
+func (bc *Blockchain) restoreBranch(fork *blockNode, detached []*blockNode) error {
+	if _, err := bc.disconnectTo(fork); err != nil {
+		return err
+	}
+	for i := len(detached) - 1; i >= 0; i-- {
+		if err := bc.connectBlock(detached[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tipNode returns the tree node of the main chain tip. Caller must hold bc.mu.
+func (bc *Blockchain) tipNode() *blockNode {
+	return bc.tree.nodes[bc.Blocks[len(bc.Blocks)-1].Hash]
+}
+
+// GetBlockByHash returns a block on the current chain by its hash
+func (bc *Blockchain) GetBlockByHash(hash string) (*Block, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	block, exists := bc.blocksByHash[hash]
+	if !exists {
+		return nil, fmt.Errorf("block not found")
+	}
+	return block, nil
+}
+
+// loadStoredBlock reads a block from the store by hash
+func (bc *Blockchain) loadStoredBlock(hash string) (*Block, error) {
+	data, err := bc.DB.Get(blockKey(hash), nil)
+	if err != nil {
+		return nil, err
+	}
+	block, err := DecodeBlock(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode block %s: %v", hash, err)
+	}
+	return block, nil
+}
+
+// loadChain restores the chain from the block store by replaying every stored
+// block on top of the genesis state. A fresh store is initialised with genesis.
+// It fails if the stored blocks do not reproduce their own commitments or the
+// state recorded with the head, so a node never starts on inconsistent data.
+func (bc *Blockchain) loadChain() error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	genesis := bc.Blocks[0]
+	bc.blocksByHash[genesis.Hash] = genesis
+
+	headHash, err := bc.DB.Get(headKey, nil)
+	if err == leveldb.ErrNotFound {
+		fmt.Println("📦 Empty block store, initialising with genesis block")
+		return bc.persistBlock(genesis, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read chain head: %v", err)
+	}
+
+	head, err := bc.loadStoredBlock(string(headHash))
+	if err != nil {
+		return fmt.Errorf("failed to load chain head: %v", err)
+	}
+
+	storedGenesis, err := bc.DB.Get(heightKey(0), nil)
+	if err != nil || string(storedGenesis) != genesis.Hash {
+		return fmt.Errorf("block store was created with a different genesis block")
+	}
+
+	// Stores written before the transaction indexes and event log existed are
+	// indexed during replay
+	indexed, hasIndex := bc.indexedHeight()
+	if !hasIndex {
+		fmt.Println("🗂️ Block store has no transaction index, building it")
+	}
+
+	fmt.Printf("📦 Replaying %d stored blocks\n", head.Header.Index)
+	for height := uint64(1); height <= head.Header.Index; height++ {
+		hash, err := bc.DB.Get(heightKey(height), nil)
+		if err != nil {
+			return fmt.Errorf("missing block at height %d: %v", height, err)
+		}
+		block, err := bc.loadStoredBlock(string(hash))
+		if err != nil {
+			return err
+		}
+
+		tip := bc.Blocks[len(bc.Blocks)-1]
+		if block.Header.Index != height || block.Header.PreviousHash != tip.Hash || block.CalculateHash() != block.Hash {
+			return fmt.Errorf("stored block at height %d does not link to its parent", height)
+		}
+
+		node, err := bc.tree.add(block)
+		if err != nil {
+			return fmt.Errorf("stored block at height %d: %v", height, err)
+		}
+		receipts := bc.applyBlock(node)
+		if err := bc.verifyCommitments(block); err != nil {
+			return fmt.Errorf("stored chain disagrees with replayed state: %v", err)
+		}
+		bc.appendBlock(block)
+
+		if !hasIndex || height > indexed {
+			batch := new(leveldb.Batch)
+			if err := indexBlock(batch, block); err != nil {
+				return err
+			}
+			if err := putLogs(batch, block, receipts); err != nil {
+				return err
+			}
+			if err := bc.DB.Write(batch, nil); err != nil {
+				return fmt.Errorf("failed to index block %d: %v", height, err)
+			}
+		}
+	}
+
+	if err := bc.checkStoredTokens(); err != nil {
+		return err
+	}
+
+	storedRoot, err := bc.DB.Get(headStateKey, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read head state root: %v", err)
+	}
+	if stateRoot := bc.currentStateTree().Root(); string(storedRoot) != stateRoot {
+		return fmt.Errorf("stored state root %s does not match replayed state %s", storedRoot, stateRoot)
+	}
+
+	if err := bc.verifyStoredAccounts(); err != nil {
+		return err
+	}
+	bc.dirtyAccounts = make(map[string]bool)
+
+	// Write out ledgers the store did not have yet or had wrong
+	batch := new(leveldb.Batch)
+	ledgers := bc.writePendingState(batch)
+	if err := bc.DB.Write(batch, nil); err != nil {
+		bc.stateStore.putBack(ledgers)
+		return fmt.Errorf("failed to write token ledgers: %v", err)
+	}
+
+	fmt.Printf("✅ Chain restored to height %d\n", head.Header.Index)
+	return nil
+}
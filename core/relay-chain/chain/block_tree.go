@@ -0,0 +1,155 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+// MaxReorgDepth is how many blocks the main chain can be rolled back to switch
+// to a heavier branch. Undo logs and side branches older than this are dropped.
+const MaxReorgDepth = 100
+
+var (
+	ErrUnknownParent  = errors.New("parent block is unknown")
+	ErrInvalidParent  = errors.New("block builds on an invalid block")
+	ErrReorgTooDeep   = errors.New("reorg is deeper than the undo history")
+	ErrStaleSideBlock = errors.New("block forks off below the reorg limit")
+)
+
+// blockNode is a block in the tree of every known branch
+type blockNode struct {
+	block   *Block
+	parent  *blockNode
+	weight  uint256.Int // cumulative stake snapshot from genesis through this block
+	undo    *blockUndo  // state the block overwrote, kept while it is on the main chain
+	invalid bool        // the block failed validation when it was connected
+}
+
+func (n *blockNode) height() uint64 {
+	return n.block.Header.Index
+}
+
+// heavierThan is the fork-choice rule: more cumulative stake wins and equal
+// weights are broken by the lower hash so every node picks the same branch
+func (n *blockNode) heavierThan(other *blockNode) bool {
+	if n.weight != other.weight {
+		return n.weight.Gt(other.weight)
+	}
+	return n.block.Hash < other.block.Hash
+}
+
+// blockTree holds every known block that builds on genesis
+type blockTree struct {
+	nodes    map[string]*blockNode
+	byHeight map[uint64][]*blockNode
+}
+
+func newBlockTree(genesis *Block) *blockTree {
+	t := &blockTree{
+		nodes:    make(map[string]*blockNode),
+		byHeight: make(map[uint64][]*blockNode),
+	}
+	t.insert(&blockNode{block: genesis, weight: genesis.Header.StakeSnapshot})
+	return t
+}
+
+func (t *blockTree) insert(node *blockNode) {
+	t.nodes[node.block.Hash] = node
+	t.byHeight[node.height()] = append(t.byHeight[node.height()], node)
+}
+
+// add links block under its parent. The block must not be known yet.
+func (t *blockTree) add(block *Block) (*blockNode, error) {
+	parent, exists := t.nodes[block.Header.PreviousHash]
+	if !exists {
+		return nil, ErrUnknownParent
+	}
+	if parent.invalid {
+		return nil, ErrInvalidParent
+	}
+	if block.Header.Index != parent.height()+1 {
+		return nil, fmt.Errorf("%w: height %d on parent at %d", ErrBadPreviousHash, block.Header.Index, parent.height())
+	}
+
+	// A sum past 2^256 - 1 cannot come from real stakes; saturating keeps the
+	// comparison well defined anyway
+	weight, err := parent.weight.Add(block.Header.StakeSnapshot)
+	if err != nil {
+		weight = uint256.Max
+	}
+	node := &blockNode{
+		block:  block,
+		parent: parent,
+		weight: weight,
+	}
+	t.insert(node)
+	return node, nil
+}
+
+// remove forgets node and every known descendant
+func (t *blockTree) remove(node *blockNode) {
+	removed := map[*blockNode]bool{node: true}
+	for height := node.height(); ; height++ {
+		nodes, exists := t.byHeight[height]
+		if !exists {
+			return
+		}
+		kept := nodes[:0]
+		for _, n := range nodes {
+			if removed[n] || removed[n.parent] {
+				removed[n] = true
+				delete(t.nodes, n.block.Hash)
+				continue
+			}
+			kept = append(kept, n)
+		}
+		t.byHeight[height] = kept
+	}
+}
+
+// markInvalid flags node and every known descendant as invalid
+func (t *blockTree) markInvalid(node *blockNode) {
+	node.invalid = true
+	for height := node.height() + 1; ; height++ {
+		nodes, exists := t.byHeight[height]
+		if !exists {
+			return
+		}
+		for _, n := range nodes {
+			if n.parent.invalid {
+				n.invalid = true
+			}
+		}
+	}
+}
+
+// prune forgets the side branches at height, which has fallen out of reorg
+// range, along with the undo log of main, the main chain block there
+func (t *blockTree) prune(height uint64, main *Block) {
+	for _, n := range t.byHeight[height] {
+		if n.block.Hash == main.Hash {
+			n.undo = nil
+			continue
+		}
+		delete(t.nodes, n.block.Hash)
+	}
+	if node, exists := t.nodes[main.Hash]; exists {
+		t.byHeight[height] = []*blockNode{node}
+	}
+}
+
+// forkPoint returns the last block that a and b have in common
+func forkPoint(a, b *blockNode) *blockNode {
+	for a.height() > b.height() {
+		a = a.parent
+	}
+	for b.height() > a.height() {
+		b = b.parent
+	}
+	for a != b {
+		a, b = a.parent, b.parent
+	}
+	return a
+}
@@ -0,0 +1,113 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+func bhxBalance(bc *Blockchain, address string) uint256.Int {
+	balance, _ := bc.TokenRegistry["BHX"].BalanceOf(address)
+	return balance
+}
+
+func TestForkChoice(t *testing.T) {
+	bc, _ := newTestChain(t)
+	genesis := bc.tipNode()
+
+	light := blockBy(bc, node2)
+	heavy := blockBy(bc, node1)
+	assert.True(t, bc.AddBlock(light))
+
+	// A single heavier block outweighs the current tip
+	heavyWeight, _ := genesis.weight.Add(uint256.NewInt(1000))
+	lightNode, heavyNode := bc.tree.nodes[light.Hash], &blockNode{block: heavy, parent: genesis, weight: heavyWeight}
+	assert.True(t, heavyNode.heavierThan(lightNode))
+
+	// Two light blocks outweigh one heavy block
+	twoLightWeight, _ := lightNode.weight.Add(uint256.NewInt(600))
+	twoLight := &blockNode{block: light, parent: lightNode, weight: twoLightWeight}
+	assert.True(t, twoLight.heavierThan(heavyNode))
+
+	// Equal weights fall back to the lower hash
+	a := &blockNode{block: &Block{Hash: "aa"}, weight: uint256.NewInt(5)}
+	b := &blockNode{block: &Block{Hash: "bb"}, weight: uint256.NewInt(5)}
+	assert.True(t, a.heavierThan(b))
+	assert.False(t, b.heavierThan(a))
+
+	assert.Equal(t, genesis, forkPoint(lightNode, heavyNode))
+}
+
+func TestReorgRevertsState(t *testing.T) {
+	bc, key := newTestChain(t)
+	sender := PublicKeyToAddress(key.PubKey())
+	genesisRoot := bc.currentStateTree().Root()
+
+	// node2 mines a payment, node1 mines an empty block on the same parent
+	payment := signedPayment(t, key, 0, 100)
+	light := blockBy(bc, node2, payment)
+	heavy := blockBy(bc, node1)
+
+	assert.True(t, bc.AddBlock(light))
+	assert.Equal(t, uint256.NewInt(100), bhxBalance(bc, "0xBob"))
+	assert.Equal(t, uint256.NewInt(500), bc.StakeLedger.GetStake(node2))
+	assert.Equal(t, uint64(1), bc.GetNonce(sender))
+
+	// node1's block carries more stake, so the chain switches to it and the
+	// payment, fee and reward of node2's block are undone
+	assert.True(t, bc.AddBlock(heavy))
+	assert.Equal(t, heavy.Hash, bc.GetLatestBlock().Hash)
+	assert.Equal(t, uint256.NewInt(0), bhxBalance(bc, "0xBob"))
+	assert.Equal(t, uint256.NewInt(1000), bhxBalance(bc, sender))
+	assert.Equal(t, uint256.NewInt(0), bhxBalance(bc, node2))
+	assert.Equal(t, uint256.NewInt(10), bhxBalance(bc, node1))
+	assert.Equal(t, uint256.NewInt(500), bc.StakeLedger.GetStake(node2))
+	assert.Equal(t, uint256.NewInt(1000), bc.StakeLedger.GetStake(node1))
+	assert.Equal(t, uint64(0), bc.GetNonce(sender))
+	assert.True(t, bc.txPool.Has(payment.ID), "abandoned transaction returns to the pool")
+
+	_, err := bc.GetReceipt(payment.ID)
+	assert.ErrorIs(t, err, ErrReceiptNotFound)
+
+	// Undoing the heavy block as well leads back to the genesis state
+	_, err = bc.disconnectTip()
+	assert.Nil(t, err)
+	assert.Equal(t, genesisRoot, bc.currentStateTree().Root())
+	assertStateTreeMatches(t, bc)
+}
+
+func TestReorgToInvalidBranchRestoresChain(t *testing.T) {
+	bc, key := newTestChain(t)
+
+	light := blockBy(bc, node2, signedPayment(t, key, 0, 100))
+	bad := blockBy(bc, node1)
+	bad.Header.StateRoot = "bb"
+	rehash(bad)
+
+	assert.True(t, bc.AddBlock(light))
+	root := bc.currentStateTree().Root()
+
+	assert.False(t, bc.AddBlock(bad))
+	assert.Equal(t, light.Hash, bc.GetLatestBlock().Hash)
+	assert.Equal(t, root, bc.currentStateTree().Root())
+	assert.True(t, bc.tree.nodes[bad.Hash].invalid)
+
+	// Blocks building on the invalid block are refused
+	child := &Block{Header: BlockHeader{Index: 2, PreviousHash: bad.Hash}}
+	child.Hash = child.CalculateHash()
+	_, err := bc.tree.add(child)
+	assert.ErrorIs(t, err, ErrInvalidParent)
+}
+
+func TestLighterBranchIsKept(t *testing.T) {
+	bc, _ := newTestChain(t)
+
+	heavy := blockBy(bc, node1)
+	light := blockBy(bc, node2)
+	assert.True(t, bc.AddBlock(heavy))
+	assert.False(t, bc.AddBlock(light))
+
+	assert.Equal(t, heavy.Hash, bc.GetLatestBlock().Hash)
+	assert.Contains(t, bc.tree.nodes, light.Hash)
+}
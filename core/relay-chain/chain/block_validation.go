@@ -0,0 +1,229 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+// Block validation limits
+const (
+	MaxBlockTransactions = 1000
+	MaxBlockSize         = 2 << 20 // Encoded bytes
+	MaxClockDrift        = 15 * time.Second
+
+	// Space MineBlock leaves for the header and the reward transaction's length prefix
+	blockHeaderAllowance = 1024
+)
+
+// Reasons a block is rejected. validateBlock wraps one of these so callers can
+// tell what went wrong with errors.Is.
+var (
+	ErrTooManyTransactions  = errors.New("block has too many transactions")
+	ErrBlockTooLarge        = errors.New("block exceeds the size limit")
+	ErrBadBlockHash         = errors.New("block hash does not match header")
+	ErrBadMerkleRoot        = errors.New("merkle root does not match transactions")
+	ErrBadProposerSignature = errors.New("block is not signed by its proposer")
+	ErrBadPreviousHash      = errors.New("block does not extend the current tip")
+	ErrBadTimestamp         = errors.New("block timestamp is not after its parent")
+	ErrFutureBlock          = errors.New("block timestamp is too far in the future")
+	ErrProposerNotStaked    = errors.New("block proposer has no stake")
+	ErrBadStakeSnapshot     = errors.New("stake snapshot does not match the stake ledger")
+	ErrBadStateRoot         = errors.New("state root does not match local state")
+	ErrBadReceiptsRoot      = errors.New("receipts root does not match local receipts")
+	ErrInvalidReward        = errors.New("invalid block reward transaction")
+	ErrInvalidTransaction   = errors.New("invalid transaction in block")
+	ErrSystemTransaction    = errors.New("system transactions cannot be submitted")
+)
+
+// ValidateStructure runs the checks that need nothing but the block itself
+func (b *Block) ValidateStructure() error {
+	if len(b.Transactions) > MaxBlockTransactions {
+		return fmt.Errorf("%w: %d, limit %d", ErrTooManyTransactions, len(b.Transactions), MaxBlockTransactions)
+	}
+	if size := len(b.Encode()); size > MaxBlockSize {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrBlockTooLarge, size, MaxBlockSize)
+	}
+	if b.CalculateHash() != b.Hash {
+		return ErrBadBlockHash
+	}
+	if root := b.CalculateMerkleRoot(); root != b.Header.MerkleRoot {
+		return fmt.Errorf("%w: expected %s, got %s", ErrBadMerkleRoot, root, b.Header.MerkleRoot)
+	}
+	// Every block after genesis must be signed by the validator it names
+	if b.Header.Index > 0 {
+		return b.SignedHeader().VerifyProposer()
+	}
+	return nil
+}
+
+// validateBlock runs every check a block must pass before it is applied on top
+// of parent. now bounds the block timestamp. The state and receipts roots can
+// only be checked once the block is applied, by verifyCommitments. Caller must
+// hold bc.mu.
+func (bc *Blockchain) validateBlock(block, parent *Block, now time.Time) error {
+	if err := block.ValidateStructure(); err != nil {
+		return err
+	}
+
+	header := block.Header
+	if header.Index != parent.Header.Index+1 || header.PreviousHash != parent.Hash {
+		return fmt.Errorf("%w: expected %s at height %d", ErrBadPreviousHash, parent.Hash, parent.Header.Index+1)
+	}
+	if !header.Timestamp.After(parent.Header.Timestamp) {
+		return fmt.Errorf("%w: %s, parent %s", ErrBadTimestamp, header.Timestamp, parent.Header.Timestamp)
+	}
+	if limit := now.Add(MaxClockDrift); header.Timestamp.After(limit) {
+		return fmt.Errorf("%w: %s is after %s", ErrFutureBlock, header.Timestamp, limit)
+	}
+
+	stake := bc.StakeLedger.GetStake(header.Validator)
+	if stake.IsZero() {
+		return fmt.Errorf("%w: %q", ErrProposerNotStaked, header.Validator)
+	}
+	if header.StakeSnapshot != stake {
+		return fmt.Errorf("%w: expected %s, got %s", ErrBadStakeSnapshot, stake, header.StakeSnapshot)
+	}
+
+	return bc.validateBlockTransactions(block)
+}
+
+// verifyCommitments checks that the block's roots match the state and receipts
+// applying it produced. Caller must hold bc.mu.
+func (bc *Blockchain) verifyCommitments(block *Block) error {
+	if stateRoot := bc.currentStateTree().Root(); block.Header.StateRoot != stateRoot {
+		return fmt.Errorf("%w at height %d: expected %s, got %s", ErrBadStateRoot, block.Header.Index, stateRoot, block.Header.StateRoot)
+	}
+	if block.Header.ReceiptsRoot != bc.receiptsRoot {
+		return fmt.Errorf("%w at height %d: expected %s, got %s", ErrBadReceiptsRoot, block.Header.Index, bc.receiptsRoot, block.Header.ReceiptsRoot)
+	}
+	return nil
+}
+
+// validateBlockTransactions checks the reward transaction and then every other
+// transaction against the state it will be applied on. Caller must hold bc.mu.
+func (bc *Blockchain) validateBlockTransactions(block *Block) error {
+	if len(block.Transactions) == 0 {
+		return fmt.Errorf("%w: block has no transactions", ErrInvalidReward)
+	}
+	if err := bc.checkReward(block.Transactions[0], block.Header.Validator); err != nil {
+		return err
+	}
+
+	budget := bc.newBlockBudget()
+	for _, tx := range block.Transactions[1:] {
+		if err := budget.add(tx); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidTransaction, tx.ID, err)
+		}
+	}
+	return nil
+}
+
+// checkReward verifies that tx pays exactly the block reward to the proposer
+func (bc *Blockchain) checkReward(tx *Transaction, validator string) error {
+	switch {
+	case !isSystemSender(tx.From):
+		return fmt.Errorf("%w: first transaction is from %s", ErrInvalidReward, tx.From)
+	case tx.Type != TokenTransfer || tx.TokenID != FeeToken:
+		return fmt.Errorf("%w: must be a %s transfer", ErrInvalidReward, FeeToken)
+	case tx.To != validator:
+		return fmt.Errorf("%w: pays %s, proposer is %s", ErrInvalidReward, tx.To, validator)
+	case tx.Amount != bc.BlockReward:
+		return fmt.Errorf("%w: pays %s, reward is %s", ErrInvalidReward, tx.Amount, bc.BlockReward)
+	case tx.ChainID != bc.ChainID:
+		return fmt.Errorf("%w: %v", ErrInvalidReward, ErrWrongChainID)
+	case tx.ID != tx.CalculateHash():
+		return fmt.Errorf("%w: ID does not match contents", ErrInvalidReward)
+	}
+	return nil
+}
+
+// blockBudget follows the nonce and the BHX each sender has committed earlier in
+// a block, so every transaction is checked against the state it will meet. Funds
+// received within the block are not counted.
+type blockBudget struct {
+	bc     *Blockchain
+	nonces map[string]uint64
+	spent  map[string]uint256.Int
+}
+
+func (bc *Blockchain) newBlockBudget() *blockBudget {
+	return &blockBudget{
+		bc:     bc,
+		nonces: make(map[string]uint64),
+		spent:  make(map[string]uint256.Int),
+	}
+}
+
+// add checks tx and, if it is valid, counts its nonce and spending against the sender
+func (b *blockBudget) add(tx *Transaction) error {
+	if isSystemSender(tx.From) {
+		return ErrSystemTransaction
+	}
+	if err := b.bc.sigCache.Verify(tx); err != nil {
+		return err
+	}
+	if err := b.bc.checkChainID(tx); err != nil {
+		return err
+	}
+	if err := b.bc.checkGas(tx); err != nil {
+		return err
+	}
+
+	nonce, seen := b.nonces[tx.From]
+	if !seen {
+		nonce = b.bc.GetNonce(tx.From)
+	}
+	if tx.Nonce != nonce {
+		return fmt.Errorf("invalid nonce: expected %d, got %d", nonce, tx.Nonce)
+	}
+
+	needed, err := b.spent[tx.From].Add(fundsNeeded(tx))
+	if err != nil {
+		needed = uint256.Max
+	}
+	if balance := b.bc.feeBalance(tx.From); balance.Lt(needed) {
+		return fmt.Errorf("%w: has %s %s, needs %s", ErrInsufficientFunds, balance, FeeToken, needed)
+	}
+
+	b.nonces[tx.From] = nonce + 1
+	b.spent[tx.From] = needed
+	return nil
+}
+
+// reportInvalidBlock records a violation against the proposer of a rejected
+// block according to why it was rejected. Anyone can name a validator in a
+// header, so only blocks the validator actually signed count as evidence; a
+// forged or unsigned block is just dropped. Faults any node can see in the
+// block itself are slashed. Root and stake snapshot mismatches can come from
+// this node's own state having drifted, so they are only reported for review.
+// Timestamp and linkage errors can come from clock skew or a lagging node, and
+// a proposer without stake has nothing to slash, so those are only logged.
+// Caller must hold bc.mu.
+func (bc *Blockchain) reportInvalidBlock(block *Block, err error) {
+	validator := block.Header.Validator
+	if validator == "" || bc.SlashingManager == nil {
+		return
+	}
+	if sigErr := block.SignedHeader().VerifyProposer(); sigErr != nil {
+		fmt.Printf("⚠️ Not reporting %s for block %d: %v\n", validator, block.Header.Index, sigErr)
+		return
+	}
+	evidence := fmt.Sprintf("Block %d rejected: %v", block.Header.Index, err)
+
+	switch {
+	case errors.Is(err, ErrInvalidTransaction):
+		bc.SlashingManager.AutoSlash(validator, MaliciousTransaction, evidence, block.Header.Index)
+	case errors.Is(err, ErrTooManyTransactions),
+		errors.Is(err, ErrBlockTooLarge),
+		errors.Is(err, ErrBadMerkleRoot),
+		errors.Is(err, ErrInvalidReward):
+		bc.SlashingManager.AutoSlash(validator, InvalidBlock, evidence, block.Header.Index)
+	case errors.Is(err, ErrBadStakeSnapshot),
+		errors.Is(err, ErrBadStateRoot),
+		errors.Is(err, ErrBadReceiptsRoot):
+		bc.SlashingManager.ReportViolation(validator, ConsensusViolation, evidence, block.Header.Index)
+	}
+}
@@ -0,0 +1,315 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/wasm"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+// testGasPrice is the minimum gas price of test chains, low enough that the
+// small balances tests work with pay for many transactions
+const testGasPrice = 1
+
+// signTestTx prices tx for a test chain and signs it with key
+func signTestTx(t *testing.T, tx *Transaction, key *btcec.PrivateKey) {
+	tx.GasPrice = testGasPrice
+	assert.Nil(t, tx.Sign(key))
+}
+
+// Test validators have keys derived from their names, so blocks built for
+// them can be signed. node3 holds no stake on test chains.
+var (
+	testValidatorKeys = make(map[string]*btcec.PrivateKey)
+	node1             = testValidator("node1")
+	node2             = testValidator("node2")
+	node3             = testValidator("node3")
+)
+
+// testValidator derives the key of the test validator called name and returns
+// its address
+func testValidator(name string) string {
+	seed := sha256.Sum256([]byte(name))
+	key, _ := btcec.PrivKeyFromBytes(seed[:])
+	address := PublicKeyToAddress(key.PubKey())
+	testValidatorKeys[address] = key
+	return address
+}
+
+// newTestChain returns an in-memory chain at genesis where node1 and
+// node2 are staked, key holds 1000 BHX and the system account can pay rewards
+func newTestChain(t *testing.T) (*Blockchain, *btcec.PrivateKey) {
+	key, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+
+	bhx := token.NewTokenWithMaxSupply("Blockchain Hex", "BHX", 18, uint256.NewInt(1000000))
+	assert.Nil(t, bhx.Mint(PublicKeyToAddress(key.PubKey()), uint256.NewInt(1000)))
+	assert.Nil(t, bhx.Mint("system", uint256.NewInt(100000)))
+
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	assert.Nil(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	genesis := validGenesis(t).block(NewStateTree(nil).Root())
+	stakes := &StakeLedger{Stakes: map[string]uint256.Int{node1: uint256.NewInt(1000), node2: uint256.NewInt(500)}}
+	registry := map[string]*token.Token{"BHX": bhx}
+	bc := &Blockchain{
+		Blocks:          []*Block{genesis},
+		BlockReward:     uint256.NewInt(10),
+		Fees:            FeeConfig{MinGasPrice: testGasPrice, BurnPercent: DefaultFeeConfig().BurnPercent},
+		ChainID:         DefaultChainID,
+		StakeLedger:     stakes,
+		SlashingManager: NewSlashingManager(stakes, registry),
+		TokenRegistry:   registry,
+		Collections:     make(map[string]*token.Collection),
+		Contracts:       make(map[string]*Contract),
+		contractCode:    make(map[string][]byte),
+		contractModules: make(map[string]*wasm.Module),
+		GlobalState:     make(map[string]*AccountState),
+		DB:              db,
+		pendingBlocks:   make(map[string]*Block),
+		stateTrees:      make(map[string]*StateTree),
+		blocksByHash:    map[string]*Block{genesis.Hash: genesis},
+		dirtyAccounts:   make(map[string]bool),
+		tree:            newBlockTree(genesis),
+	}
+	bc.txPool = NewTxPool(DefaultTxPoolConfig(), bc.GetNonce)
+	return bc, key
+}
+
+// nextBlock builds a block by node1 on top of the tip holding the reward and txs
+func nextBlock(bc *Blockchain, txs ...*Transaction) *Block {
+	return blockBy(bc, node1, txs...)
+}
+
+// blockBy builds a block by validator on top of the tip holding the reward and txs
+func blockBy(bc *Blockchain, validator string, txs ...*Transaction) *Block {
+	parent := bc.Blocks[len(bc.Blocks)-1]
+	reward := &Transaction{ChainID: bc.ChainID, Type: TokenTransfer, From: "system", To: validator, Amount: bc.BlockReward, TokenID: "BHX"}
+	reward.ID = reward.CalculateHash()
+
+	all := append([]*Transaction{reward}, txs...)
+	block := NewBlock(parent.Header.Index+1, all, parent.Hash, validator, bc.StakeLedger.GetStake(validator), "", "")
+	bc.commitResults(block)
+	return sign(block)
+}
+
+func signedPayment(t *testing.T, key *btcec.PrivateKey, nonce, amount uint64) *Transaction {
+	tx := NewTransaction(TokenTransfer, "", "0xBob", uint256.NewInt(amount), nil)
+	tx.Nonce = nonce
+	signTestTx(t, tx, key)
+	return tx
+}
+
+// sign signs block for its proposer if that is a test validator, leaving
+// blocks of other proposers unsigned
+func sign(block *Block) *Block {
+	if key := testValidatorKeys[block.Header.Validator]; key != nil {
+		if err := block.Sign(key); err != nil {
+			panic(err)
+		}
+	}
+	return block
+}
+
+// rehash refreshes the block hash and signature after a test edits its header
+func rehash(block *Block) *Block {
+	block.Hash = block.CalculateHash()
+	return sign(block)
+}
+
+func TestValidateBlock(t *testing.T) {
+	bc, key := newTestChain(t)
+	parent := bc.Blocks[0]
+	now := time.Now()
+
+	t.Run("Valid", func(t *testing.T) {
+		block := nextBlock(bc, signedPayment(t, key, 0, 100), signedPayment(t, key, 1, 100))
+		assert.Nil(t, bc.validateBlock(block, parent, now))
+	})
+
+	cases := []struct {
+		name  string
+		block func() *Block
+		err   error
+	}{
+		{"Bad hash", func() *Block {
+			block := nextBlock(bc)
+			block.Header.ConsensusRound = 7
+			return block
+		}, ErrBadBlockHash},
+		{"Bad merkle root", func() *Block {
+			block := nextBlock(bc, signedPayment(t, key, 0, 100))
+			block.Transactions = block.Transactions[:1]
+			return block
+		}, ErrBadMerkleRoot},
+		{"Too many transactions", func() *Block {
+			block := nextBlock(bc)
+			for len(block.Transactions) <= MaxBlockTransactions {
+				block.Transactions = append(block.Transactions, block.Transactions[0])
+			}
+			return block
+		}, ErrTooManyTransactions},
+		{"Wrong parent", func() *Block {
+			block := nextBlock(bc)
+			block.Header.PreviousHash = "aa"
+			return rehash(block)
+		}, ErrBadPreviousHash},
+		{"Timestamp before parent", func() *Block {
+			block := nextBlock(bc)
+			block.Header.Timestamp = parent.Header.Timestamp
+			return rehash(block)
+		}, ErrBadTimestamp},
+		{"Timestamp too far ahead", func() *Block {
+			block := nextBlock(bc)
+			block.Header.Timestamp = now.Add(MaxClockDrift + time.Second)
+			return rehash(block)
+		}, ErrFutureBlock},
+		{"Unstaked proposer", func() *Block {
+			block := nextBlock(bc)
+			block.Header.Validator = node3
+			return rehash(block)
+		}, ErrProposerNotStaked},
+		{"Inflated stake snapshot", func() *Block {
+			block := nextBlock(bc)
+			block.Header.StakeSnapshot = uint256.NewInt(5000)
+			return rehash(block)
+		}, ErrBadStakeSnapshot},
+		{"Reward too large", func() *Block {
+			block := nextBlock(bc)
+			block.Transactions[0].Amount = uint256.NewInt(1000)
+			block.Transactions[0].ID = block.Transactions[0].CalculateHash()
+			block.Header.MerkleRoot = block.CalculateMerkleRoot()
+			return rehash(block)
+		}, ErrInvalidReward},
+		{"Second system transaction", func() *Block {
+			block := nextBlock(bc)
+			return sign(NewBlock(1, append(block.Transactions, block.Transactions[0]), parent.Hash, node1, uint256.NewInt(1000), block.Header.StateRoot, ""))
+		}, ErrInvalidTransaction},
+		{"Forged signature", func() *Block {
+			tx := signedPayment(t, key, 0, 100)
+			tx.Amount = uint256.NewInt(900)
+			tx.ID = tx.CalculateHash()
+			return nextBlock(bc, tx)
+		}, ErrInvalidTransaction},
+		{"Nonce gap", func() *Block {
+			return nextBlock(bc, signedPayment(t, key, 1, 100))
+		}, ErrInvalidTransaction},
+		{"Overspend across transactions", func() *Block {
+			return nextBlock(bc, signedPayment(t, key, 0, 600), signedPayment(t, key, 1, 600))
+		}, ErrInvalidTransaction},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.ErrorIs(t, bc.validateBlock(c.block(), parent, now), c.err)
+		})
+	}
+}
+
+func TestBlockCommitsToItsResults(t *testing.T) {
+	bc, key := newTestChain(t)
+	before := bc.StateRoot()
+
+	payment := signedPayment(t, key, 0, 100)
+	block := nextBlock(bc, payment)
+	assert.Equal(t, before, bc.StateRoot(), "building a block leaves the state alone")
+	assert.NotEqual(t, before, block.Header.StateRoot)
+
+	t.Run("Wrong roots are rejected after the block is rolled back", func(t *testing.T) {
+		cases := []struct {
+			change func(h *BlockHeader)
+			err    error
+		}{
+			{func(h *BlockHeader) { h.StateRoot = before }, ErrBadStateRoot},
+			{func(h *BlockHeader) { h.ReceiptsRoot = "bb" }, ErrBadReceiptsRoot},
+		}
+		for _, c := range cases {
+			bad := *block
+			c.change(&bad.Header)
+			node, err := bc.tree.add(rehash(&bad))
+			assert.Nil(t, err)
+			assert.ErrorIs(t, bc.connectBlock(node), c.err)
+			assert.Nil(t, node.undo)
+			assert.Equal(t, before, bc.StateRoot())
+			assert.True(t, bhxBalance(bc, "0xBob").IsZero())
+			assert.Equal(t, uint64(0), bc.GetNonce(payment.From))
+		}
+	})
+
+	t.Run("Roots match the state and receipts after the block", func(t *testing.T) {
+		assert.True(t, bc.AddBlock(block))
+		assert.Equal(t, block.Header.StateRoot, bc.StateRoot())
+
+		var receipts []*Receipt
+		for _, tx := range block.Transactions {
+			receipt, err := bc.GetReceipt(tx.ID)
+			assert.Nil(t, err)
+			receipts = append(receipts, receipt)
+		}
+		assert.Equal(t, CalculateReceiptsRoot(receipts), block.Header.ReceiptsRoot)
+
+		proof, err := bc.GetStateProof(TokenBalanceKey("BHX", "0xBob"), 1)
+		assert.Nil(t, err)
+		assert.Equal(t, hex.EncodeToString(encodeBalanceLeaf(uint256.NewInt(100))), proof.Value)
+		ok, err := VerifyStateProof(block.Header.StateRoot, proof)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	})
+}
+
+func TestReportInvalidBlock(t *testing.T) {
+	bc, _ := newTestChain(t)
+	key, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+	proposer := PublicKeyToAddress(key.PubKey())
+	bc.StakeLedger.SetStake(proposer, uint256.NewInt(1000))
+	block := blockBy(bc, proposer)
+
+	// Anyone can name a validator in a header, so unsigned blocks are not evidence
+	bc.reportInvalidBlock(block, ErrBadMerkleRoot)
+	assert.Empty(t, bc.SlashingManager.GetSlashingEvents())
+
+	// Nor is a signed block whose header was changed afterwards
+	assert.Nil(t, block.Sign(key))
+	forged := *block
+	forged.Header.Timestamp = forged.Header.Timestamp.Add(time.Second)
+	bc.reportInvalidBlock(&forged, ErrBadBlockHash)
+	bc.reportInvalidBlock(rehash(&forged), ErrBadProposerSignature)
+	assert.Empty(t, bc.SlashingManager.GetSlashingEvents())
+
+	// Clock problems are not the proposer's fault to prove
+	bc.reportInvalidBlock(block, ErrFutureBlock)
+	assert.Empty(t, bc.SlashingManager.GetSlashingEvents())
+
+	bc.reportInvalidBlock(block, ErrBadMerkleRoot)
+	bc.reportInvalidBlock(block, ErrInvalidTransaction)
+	// A root mismatch may be this node's fault, so it is only left for review
+	bc.reportInvalidBlock(block, ErrBadStateRoot)
+
+	conditions := make(map[SlashingCondition]int)
+	for _, event := range bc.SlashingManager.GetSlashingEvents() {
+		assert.Equal(t, proposer, event.Validator)
+		assert.Equal(t, "pending", event.Status)
+		conditions[event.Condition]++
+	}
+	assert.Equal(t, map[SlashingCondition]int{InvalidBlock: 1, MaliciousTransaction: 1, ConsensusViolation: 1}, conditions)
+
+	// Executing slashing records strikes but leaves committed stakes and
+	// balances to blocks
+	root := bc.StateRoot()
+	for id := range bc.SlashingManager.GetSlashingEvents() {
+		assert.Nil(t, bc.SlashingManager.ExecuteSlashing(id))
+	}
+	assert.True(t, bc.SlashingManager.IsValidatorJailed(proposer))
+	assert.Equal(t, uint256.NewInt(1000), bc.StakeLedger.GetStake(proposer))
+	assert.Equal(t, root, bc.StateRoot())
+}
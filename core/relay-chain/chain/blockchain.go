@@ -6,47 +6,75 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"sync"
 	"time"
 
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/wasm"
+	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 type AccountState struct {
-	Balance uint64
-	Nonce   uint64
+	Balance     uint256.Int
+	Nonce       uint64
+	PermitNonce uint64 // permits the account has signed that were used
 }
 
 type Blockchain struct {
 	Blocks           []*Block
-	PendingTxs       []*Transaction
 	StakeLedger      *StakeLedger
-	BlockReward      uint64
+	BlockReward      uint256.Int
+	ChainID          uint64
+	Fees             FeeConfig
+	History          HistoryConfig
 	mu               sync.RWMutex
 	txPool           *TxPool
 	validatorManager *ValidatorManager
 	TokenRegistry    map[string]*token.Token
+	Collections      map[string]*token.Collection // non-fungible and semi-fungible collections by symbol
+	Contracts        map[string]*Contract         // deployed WebAssembly contracts by address
+	contractCode     map[string][]byte            // contract code by hex SHA-256 hash
+	contractModules  map[string]*wasm.Module      // decoded contract code by hash
+	records          map[string][]byte            // native module state by state tree key, see records.go
 	P2PNode          *Node
 	GenesisTime      time.Time
-	TotalSupply      uint64
-	pendingBlocks    map[uint64]*Block
+	TotalSupply      uint256.Int
+	pendingBlocks    map[string]*Block // blocks waiting for their parent, by hash
 	GlobalState      map[string]*AccountState
 	DB               *leveldb.DB
-	DEX              interface{}
-	CrossChainDEX    interface{} // Will be *dex.CrossChainDEX
-	EscrowManager    interface{}
-	MultiSigManager  interface{}
-	OTCManager       interface{} // Will be *otc.OTCManager
 	SlashingManager  *SlashingManager
+	stateTree        *StateTree // tree of the current state, see currentStateTree
+	stateTrees       map[string]*StateTree
+	stateTreeOrder   []string
+	stateCacheMu     sync.Mutex
+	blocksByHash     map[string]*Block
+	dirtyAccounts    map[string]bool   // accounts changed since the last persisted block
+	stateStore       *blockStateStore  // ledger, collection and contract storage changes since the last persisted block
+	clock            token.Clock       // height and time of the block being applied or of the tip, for vesting and history
+	sigCache         *SignatureCache   // transactions whose signatures are already verified
+	tree             *blockTree        // every known branch, for fork choice
+	undo             *blockUndo        // journal of the block being applied
+	receiptsRoot     string            // receipts root of the current tip
+	proposerKey      *btcec.PrivateKey // signs blocks this node mines for its own validator
 }
 type RealBlockchain struct {
 	Blockchain *Blockchain // Pointer to the real blockchain
 }
 
+// NewBlockchain starts a node on p2pPort with the development genesis config
 func NewBlockchain(p2pPort int) (*Blockchain, error) {
-	genesis := createGenesisBlock()
+	return NewBlockchainWithGenesis(p2pPort, DefaultGenesis(), DefaultHistoryConfig())
+}
+
+// NewBlockchainWithGenesis starts a node on p2pPort for the network described by
+// genesis, keeping the balance and stake history set by history
+func NewBlockchainWithGenesis(p2pPort int, genesis *GenesisConfig, history HistoryConfig) (*Blockchain, error) {
+	if err := genesis.Validate(); err != nil {
+		return nil, err
+	}
 
 	dbPath := fmt.Sprintf("blockchaindb_%d", p2pPort)
 	db, err := leveldb.OpenFile(dbPath, nil)
@@ -60,117 +88,91 @@ func NewBlockchain(p2pPort int) (*Blockchain, error) {
 		return nil, err
 	}
 
+	// Rebuild the chain and state from the block store
+	bc, err := openChain(db, genesis, history)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to restore chain from %s: %v", dbPath, err)
+	}
+	bc.P2PNode = node
+	node.SetGenesis(bc.Blocks[0].Hash)
+
+	// Start validator monitoring in background
+	go bc.MonitorValidatorPerformance()
+	fmt.Printf("⚡ Slashing manager initialized and monitoring started\n")
+
+	return bc, nil
+}
+
+// openChain loads the genesis state and replays the chain stored in db on top
+// of it. genesis must already be validated. history applies from the first
+// replayed block, so history pruned during the replay is never rebuilt.
+func openChain(db *leveldb.DB, genesis *GenesisConfig, history HistoryConfig) (*Blockchain, error) {
 	// Initialize stake ledger
 	stakeLedger := NewStakeLedger()
-	// Genesis validator starts with 1000 stake (will get tokens minted to match)
 
 	bc := &Blockchain{
-		Blocks:           []*Block{genesis},
-		PendingTxs:       make([]*Transaction, 0),
 		StakeLedger:      stakeLedger,
-		P2PNode:          node,
-		GenesisTime:      time.Now().UTC(),
-		TotalSupply:      1000000000,
-		BlockReward:      10,
-		pendingBlocks:    make(map[uint64]*Block),
+		GenesisTime:      genesis.GenesisTime,
+		BlockReward:      genesis.Consensus.BlockReward,
+		ChainID:          genesis.ChainID,
+		Fees:             FeeConfig{MinGasPrice: genesis.Consensus.MinGasPrice, BurnPercent: genesis.Consensus.BurnPercent},
+		History:          history,
+		pendingBlocks:    make(map[string]*Block),
 		GlobalState:      make(map[string]*AccountState),
 		DB:               db,
-		txPool:           &TxPool{Transactions: make([]*Transaction, 0)},
 		validatorManager: NewValidatorManager(stakeLedger),
 		TokenRegistry:    make(map[string]*token.Token),
-	}
+		Collections:      make(map[string]*token.Collection),
+		Contracts:        make(map[string]*Contract),
+		contractCode:     make(map[string][]byte),
+		contractModules:  make(map[string]*wasm.Module),
+		records:          make(map[string][]byte),
+		stateTrees:       make(map[string]*StateTree),
+		blocksByHash:     make(map[string]*Block),
+		dirtyAccounts:    make(map[string]bool),
+		stateStore:       newBlockStateStore(),
+		sigCache:         NewSignatureCache(DefaultSignatureCacheSize),
+	}
+	stakeLedger.setClock(&bc.clock)
+
+	// The pool sequences transactions against account nonces in chain state
+	bc.txPool = NewTxPool(DefaultTxPoolConfig(), bc.GetNonce)
 
 	// Initialize slashing manager after TokenRegistry is created
 	bc.SlashingManager = NewSlashingManager(stakeLedger, bc.TokenRegistry)
 
-	// Initialize Cross-Chain DEX (will be properly initialized later with bridge)
-	// bc.CrossChainDEX = dex.NewCrossChainDEX(localDEX, bridge, bc)
-
-	bc.GlobalState["system"] = &AccountState{
-		Balance: 10000000, // same as genesis rewardTx.Amount
-		Nonce:   0,
-	}
-	bc.GlobalState["03e2459b73c0c6522530f6b26e834d992dfc55d170bee35d0bcdc047fe0d61c25b"] = &AccountState{
-		Balance: 1000, // give this wallet 1000 tokens initially
-		Nonce:   0,
-	}
-
-	// Create native token with proper supply management
-	nativeToken := token.NewTokenWithMaxSupply("Blockchain Hex", "BHX", 18, 1000000000) // 1B max supply
-	bc.TokenRegistry["BHX"] = nativeToken
-
-	// Initialize controlled token distribution
-	// System gets initial allocation for rewards and operations
-	err = nativeToken.Mint("system", 10000000) // 10M tokens (1% of max supply)
-	if err != nil {
-		return nil, fmt.Errorf("failed to mint system tokens: %v", err)
+	// Load token definitions, balances and validator stakes from genesis
+	if err := genesis.apply(bc); err != nil {
+		return nil, err
 	}
+	bc.TotalSupply = bc.TokenRegistry[FeeToken].MaxSupply()
 
-	// Test wallet gets small allocation
-	err = nativeToken.Mint("03e2459b73c0c6522530f6b26e834d992dfc55d170bee35d0bcdc047fe0d61c25b", 1000)
-	if err != nil {
-		return nil, fmt.Errorf("failed to mint test tokens: %v", err)
-	}
+	genesisBlock := genesis.block(bc.currentStateTree().Root())
+	bc.Blocks = []*Block{genesisBlock}
+	bc.tree = newBlockTree(genesisBlock)
+	bc.clock.Set(0, genesisBlock.Header.Timestamp.Unix())
 
-	// Initialize genesis validator with consistent stake and tokens
-	genesisValidatorStake := uint64(1000)
-	stakeLedger.SetStake("genesis-validator", genesisValidatorStake)
+	fmt.Printf("✅ Genesis loaded: chain %d, %d validators, genesis block %s\n",
+		genesis.ChainID, len(genesis.Validators), genesisBlock.Hash)
 
-	// Mint tokens to genesis validator to match their stake
-	err = nativeToken.Mint("genesis-validator", genesisValidatorStake)
-	if err != nil {
-		return nil, fmt.Errorf("failed to mint genesis validator tokens: %v", err)
+	if err := bc.loadChain(); err != nil {
+		return nil, err
 	}
-
-	fmt.Printf("✅ Genesis validator initialized with %d stake and %d BHX tokens\n",
-		genesisValidatorStake, genesisValidatorStake)
-
-	// Start validator monitoring in background
-	go bc.MonitorValidatorPerformance()
-	fmt.Printf("⚡ Slashing manager initialized and monitoring started\n")
-
-	// Initialize OTC Manager
-	// bc.OTCManager = otc.NewOTCManager(bc)
-	// fmt.Printf("✅ OTC Manager initialized\n")
-
-	// Optional: Load GlobalState from DB
-	bc.loadGlobalState()
 	return bc, nil
 }
 
-func createGenesisBlock() *Block {
-	rewardTx := &Transaction{
-		ID:        "",
-		Type:      TokenTransfer,
-		From:      "system",
-		To:        "genesis-validator",
-		Amount:    10,
-		TokenID:   "BHX", // Changed from Token to TokenID
-		Fee:       0,
-		Nonce:     0,
-		Timestamp: time.Date(2025, 5, 15, 7, 55, 0, 0, time.UTC).Unix(),
-		Signature: nil,
-		PublicKey: nil,
-	}
-	rewardTx.ID = rewardTx.CalculateHash()
-
-	block := NewBlock(
-		0,
-		[]*Transaction{rewardTx},
-		"0000000000000000000000000000000000000000000000000000000000000000",
-		"genesis-validator",
-		1000,
-	)
-
-	block.Header.Timestamp = time.Date(2025, 5, 15, 7, 55, 0, 0, time.UTC)
-	block.Header.MerkleRoot = block.CalculateMerkleRoot()
-	block.Hash = block.CalculateHash()
-
-	return block
-}
+// MineBlock builds and signs the next block for selectedValidator. It returns
+// nil unless this node holds that validator's key, since unsigned blocks are
+// rejected.
 func (bc *Blockchain) MineBlock(selectedValidator string) *Block {
-	bc.mu.RLock()
-	defer bc.mu.RUnlock()
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.proposerKey == nil || PublicKeyToAddress(bc.proposerKey.PubKey()) != selectedValidator {
+		fmt.Printf("⏭️ Not mining for %s: this node does not hold its key\n", selectedValidator)
+		return nil
+	}
 
 	// Get current index
 	index := uint64(len(bc.Blocks))
@@ -190,6 +192,7 @@ func (bc *Blockchain) MineBlock(selectedValidator string) *Block {
 	// Create reward transaction from system to validator with correct fields
 	rewardTx := &Transaction{
 		ID:        "",
+		ChainID:   bc.ChainID,
 		Type:      TokenTransfer,
 		From:      "system",
 		To:        selectedValidator,
@@ -203,193 +206,187 @@ func (bc *Blockchain) MineBlock(selectedValidator string) *Block {
 	}
 	rewardTx.ID = rewardTx.CalculateHash()
 
-	// Combine reward transaction with pending transactions
-	txs := append([]*Transaction{rewardTx}, bc.PendingTxs...)
+	// Combine reward transaction with the best priced pending transactions that
+	// pass block validation, stopping before the block grows past its limits
+	txs := []*Transaction{rewardTx}
+	size := blockHeaderAllowance + len(rewardTx.Encode())
+	budget := bc.newBlockBudget()
+	for _, tx := range bc.txPool.Select(0) {
+		if len(txs) == MaxBlockTransactions || size+4+len(tx.Encode()) > MaxBlockSize {
+			break
+		}
+		if err := budget.add(tx); err != nil {
+			fmt.Printf("⏭️ Leaving transaction %s out of block: %v\n", tx.ID, err)
+			continue
+		}
+		txs = append(txs, tx)
+		size += 4 + len(tx.Encode())
+	}
 
-	// Create new block
-	block := NewBlock(index, txs, prevHash, selectedValidator, stake)
+	block := NewBlock(index, txs, prevHash, selectedValidator, stake, "", "")
+
+	// Keep timestamps increasing even if the parent was stamped by a faster clock
+	if len(bc.Blocks) > 0 {
+		if parentTime := bc.Blocks[len(bc.Blocks)-1].Header.Timestamp; !block.Header.Timestamp.After(parentTime) {
+			block.Header.Timestamp = parentTime.Add(time.Millisecond)
+		}
+	}
+
+	// Commit to the state and receipts the block produces. The block is only
+	// executed to compute them; the state is rolled back afterwards.
+	bc.commitResults(block)
+
+	if err := block.Sign(bc.proposerKey); err != nil {
+		fmt.Printf("⚠️ Failed to sign block %d: %v\n", index, err)
+		return nil
+	}
 
-	// DO NOT modify blockchain state here!
 	return block
 }
 
-func (bc *Blockchain) AddBlock(block *Block) bool {
+// SetProposerKey sets the key of the validator this node runs. The node only
+// mines blocks for that validator, and signs them with it.
+func (bc *Blockchain) SetProposerKey(key *btcec.PrivateKey) {
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
+	bc.proposerKey = key
+}
 
+// AddBlock imports a block mined locally or received from a peer and reports
+// whether it is now on the main chain
+func (bc *Blockchain) AddBlock(block *Block) bool {
 	fmt.Printf("🧪 Validating block %d, Hash=%s, PrevHash=%s\n", block.Header.Index, block.Hash, block.Header.PreviousHash)
 
-	if len(bc.Blocks) == 0 {
-		fmt.Println("❌ Blockchain is empty, expected genesis block")
+	// Verify signatures before taking the lock, so blocks arriving from several
+	// peers are checked in parallel and bc.mu is only held to apply state
+	if err := bc.verifyBlockSignatures(block); err != nil {
+		err = fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+		fmt.Printf("❌ Block %d rejected: %v\n", block.Header.Index, err)
+		bc.mu.Lock()
+		bc.reportInvalidBlock(block, err)
+		bc.mu.Unlock()
 		return false
 	}
 
-	currentTip := bc.Blocks[len(bc.Blocks)-1]
-	expectedIndex := currentTip.Header.Index + 1
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
 
-	// CASE: Block is stale (already behind tip)
-	if block.Header.Index < currentTip.Header.Index {
-		fmt.Printf("⚠️ Stale block %d ignored (current chain height is %d)\n", block.Header.Index, currentTip.Header.Index)
+	if !bc.importBlock(block) {
 		return false
 	}
+	bc.importOrphans(block.Hash)
+	return true
+}
 
-	// CASE: Block at current height (possible fork)
-	if block.Header.Index == currentTip.Header.Index {
-		fmt.Printf("🔍 Fork detected at index %d\n", block.Header.Index)
-
-		if block.Hash == currentTip.Hash {
-			fmt.Println("✅ Identical block already exists")
-			return true
-		}
-
-		// Compare stake or hash to resolve fork
-		if block.Header.PreviousHash == currentTip.Header.PreviousHash {
-			fmt.Println("🔄 Competing block found at same height with same parent")
-
-			if block.Header.StakeSnapshot > currentTip.Header.StakeSnapshot ||
-				(block.Header.StakeSnapshot == currentTip.Header.StakeSnapshot && block.Hash < currentTip.Hash) {
-				fmt.Println("🔁 Fork wins, switching to better block")
-				return bc.reorganizeToFork([]*Block{block})
-			}
-
-			fmt.Println("🚫 Fork loses, ignoring")
-			return false
-		}
-
-		// Deep fork (diverges earlier)
-		return bc.handleFork(block)
+// importBlock adds a block to the tree and lets fork choice decide whether it
+// extends or replaces the main chain. It reports whether the block ends up on
+// the main chain. Caller must hold bc.mu.
+func (bc *Blockchain) importBlock(block *Block) bool {
+	if _, known := bc.tree.nodes[block.Hash]; known {
+		_, onMain := bc.blocksByHash[block.Hash]
+		fmt.Printf("✅ Block %d already known\n", block.Header.Index)
+		return onMain
 	}
 
-	// CASE: Block is ahead of tip (future block)
-	if block.Header.Index > expectedIndex {
-		fmt.Printf("⏳ Future block received (current %d < block %d), queuing\n", expectedIndex, block.Header.Index)
-		bc.pendingBlocks[block.Header.Index] = block
-		bc.requestMissingBlocks(expectedIndex, block.Header.Index-1)
+	if block.Header.Index == 0 {
+		fmt.Printf("❌ Block %s claims to be a different genesis block\n", block.Hash)
 		return false
 	}
-
-	// CASE: Normal append to tip
-	if block.Header.PreviousHash != currentTip.Hash {
-		fmt.Printf("❌ Invalid previous hash at height %d. Expected %s, got %s\n", block.Header.Index, currentTip.Hash, block.Header.PreviousHash)
+	tip := bc.tipNode()
+	if block.Header.Index+MaxReorgDepth < tip.height() {
+		fmt.Printf("⚠️ Stale block %d ignored (current chain height is %d)\n", block.Header.Index, tip.height())
 		return false
 	}
-
-	if block.CalculateHash() != block.Hash {
-		fmt.Printf("❌ Invalid block hash at height %d\n", block.Header.Index)
-
-		// Report invalid block violation
-		if block.Header.Validator != "" {
-			bc.SlashingManager.AutoSlash(block.Header.Validator, InvalidBlock,
-				fmt.Sprintf("Invalid block hash at height %d", block.Header.Index),
-				block.Header.Index)
-		}
+	if err := block.ValidateStructure(); err != nil {
+		fmt.Printf("❌ Block %d rejected: %v\n", block.Header.Index, err)
+		bc.reportInvalidBlock(block, err)
 		return false
 	}
 
-	// for _, tx := range block.Transactions {
-	// 	if !tx.Verify() {
-	// 		fmt.Printf("❌ Invalid transaction: %s\n", tx.ID)
-	// 		return false
-	// 	}
-	// }
-
-	// Track suspicious transactions but be much more conservative about slashing
-	suspiciousCount := 0
-	totalTransactions := len(block.Transactions)
-
-	for _, tx := range block.Transactions {
-		// Validate transaction security before applying
-		if !bc.validateTransactionSecurity(tx) {
-			fmt.Printf("⚠️ Suspicious transaction detected: %s\n", tx.ID)
-			suspiciousCount++
-
-			// Skip this transaction but continue processing the block
-			fmt.Printf("⏭️ Skipping suspicious transaction %s\n", tx.ID)
-			continue
-		}
-
-		success := bc.ApplyTransaction(tx)
-		if !success {
-			fmt.Println("⚠️ Failed to apply transaction, skipping:", tx.ID)
-		}
+	node, err := bc.tree.add(block)
+	if errors.Is(err, ErrUnknownParent) {
+		bc.queueOrphan(block)
+		return false
 	}
-
-	// Only report violations if there's a significant percentage of suspicious transactions
-	// AND there are multiple transactions (avoid false positives on single transactions)
-	if totalTransactions > 1 && suspiciousCount > 0 {
-		suspiciousPercentage := float64(suspiciousCount) / float64(totalTransactions)
-
-		// Only report if more than 50% of transactions are suspicious
-		if suspiciousPercentage > 0.5 {
-			fmt.Printf("🚨 High percentage of suspicious transactions: %d/%d (%.1f%%)\n",
-				suspiciousCount, totalTransactions, suspiciousPercentage*100)
-
-			if block.Header.Validator != "" {
-				// Report for manual review, don't auto-slash
-				bc.SlashingManager.ReportViolation(block.Header.Validator, MaliciousTransaction,
-					fmt.Sprintf("High suspicious transaction rate: %d/%d (%.1f%%) in block %d",
-						suspiciousCount, totalTransactions, suspiciousPercentage*100, block.Header.Index),
-					block.Header.Index)
-			}
-		}
+	if err != nil {
+		fmt.Printf("❌ Block %d rejected: %v\n", block.Header.Index, err)
+		return false
 	}
-	// Add block normally
-	bc.Blocks = append(bc.Blocks, block)
-	bc.PendingTxs = make([]*Transaction, 0)
-	fmt.Printf("✅ Block %d added successfully\n", block.Header.Index)
 
-	// Process queued blocks
-	for {
-		nextBlock, exists := bc.pendingBlocks[expectedIndex+1]
-		if !exists {
-			break
-		}
-		fmt.Printf("🧪 Attempting to add queued block %d\n", nextBlock.Header.Index)
-		if nextBlock.Header.PreviousHash == block.Hash && nextBlock.CalculateHash() == nextBlock.Hash {
-			bc.Blocks = append(bc.Blocks, nextBlock)
-			bc.PendingTxs = make([]*Transaction, 0)
-			fmt.Printf("✅ Queued block %d added successfully\n", nextBlock.Header.Index)
-			delete(bc.pendingBlocks, nextBlock.Header.Index)
-			expectedIndex++
-			block = nextBlock
-		} else {
-			fmt.Printf("❌ Queued block %d invalid, discarding\n", nextBlock.Header.Index)
-			delete(bc.pendingBlocks, nextBlock.Header.Index)
-			break
+	if node.parent == tip {
+		if err := bc.connectBlock(node); err != nil {
+			bc.rejectBlock(node, err)
+			return false
 		}
+		bc.txPool.RemoveTxs(block.Transactions)
+		fmt.Printf("✅ Block %d added successfully\n", block.Header.Index)
+		return true
 	}
 
+	if !node.heavierThan(tip) {
+		fmt.Printf("🌿 Block %d kept on a side branch (weight %s, main chain %s)\n", block.Header.Index, node.weight, tip.weight)
+		return false
+	}
+	if err := bc.reorganize(node); err != nil {
+		fmt.Printf("❌ Reorganization to block %d failed: %v\n", block.Header.Index, err)
+		return false
+	}
 	return true
 }
 
-func (bc *Blockchain) calculateCumulativeStake() uint64 {
-	var totalStake uint64
-	for _, block := range bc.Blocks {
-		totalStake += block.Header.StakeSnapshot
-	}
-	return totalStake
+// rejectBlock handles a block that failed validation when connected. A block
+// from the future may become valid later, so it is forgotten rather than
+// marked invalid. Caller must hold bc.mu.
+func (bc *Blockchain) rejectBlock(node *blockNode, err error) {
+	fmt.Printf("❌ Block %d rejected: %v\n", node.height(), err)
+	if errors.Is(err, ErrFutureBlock) {
+		bc.tree.remove(node)
+		return
+	}
+	bc.tree.markInvalid(node)
+	bc.reportInvalidBlock(node.block, err)
 }
 
-func (bc *Blockchain) reorganizeChain(blocks []*Block) {
-	bc.mu.Lock()
-	defer bc.mu.Unlock()
+// maxOrphanBlocks bounds how many blocks wait for a missing parent
+const maxOrphanBlocks = 256
+
+// queueOrphan holds a block whose parent is unknown and asks peers for the
+// blocks leading up to it. Caller must hold bc.mu.
+func (bc *Blockchain) queueOrphan(block *Block) {
+	if len(bc.pendingBlocks) >= maxOrphanBlocks {
+		fmt.Printf("⚠️ Orphan queue full, dropping block %d\n", block.Header.Index)
+		return
+	}
+	fmt.Printf("⏳ Parent of block %d unknown, queuing\n", block.Header.Index)
+	bc.pendingBlocks[block.Hash] = block
+
+	start := bc.tipNode().height() + 1
+	if block.Header.Index-1 < start {
+		start = block.Header.Index - 1
+	}
+	bc.requestMissingBlocks(start, block.Header.Index-1)
+}
 
-	// Validate and replace chain
-	newBlocks := []*Block{bc.Blocks[0]} // Keep genesis block
-	for _, block := range blocks {
-		if len(newBlocks) > 0 {
-			prevBlock := newBlocks[len(newBlocks)-1]
-			if block.Header.PreviousHash != prevBlock.Hash || block.CalculateHash() != block.Hash {
-				fmt.Printf("❌ Invalid block %d during reorganization\n", block.Header.Index)
-				return
+// importOrphans imports queued blocks that build on hash, and then their own
+// descendants. Caller must hold bc.mu.
+func (bc *Blockchain) importOrphans(hash string) {
+	parents := []string{hash}
+	for len(parents) > 0 {
+		parent := parents[0]
+		parents = parents[1:]
+
+		for orphanHash, orphan := range bc.pendingBlocks {
+			if orphan.Header.PreviousHash != parent {
+				continue
+			}
+			delete(bc.pendingBlocks, orphanHash)
+			fmt.Printf("🧪 Attempting to add queued block %d\n", orphan.Header.Index)
+			bc.importBlock(orphan)
+			if _, known := bc.tree.nodes[orphanHash]; known {
+				parents = append(parents, orphanHash)
 			}
 		}
-		newBlocks = append(newBlocks, block)
 	}
-
-	bc.Blocks = newBlocks
-	bc.PendingTxs = make([]*Transaction, 0)
-	fmt.Printf("✅ Reorganized chain to height %d\n", newBlocks[len(newBlocks)-1].Header.Index)
 }
 
 func (bc *Blockchain) requestMissingBlocks(startIndex, endIndex uint64) {
@@ -406,10 +403,9 @@ func (bc *Blockchain) requestMissingBlocks(startIndex, endIndex uint64) {
 }
 
 func (bc *Blockchain) BroadcastTransaction(tx *Transaction) {
-	data, _ := tx.Serialize()
 	msg := &Message{
 		Type:    MessageTypeTx,
-		Data:    data.([]byte),
+		Data:    tx.Serialize(),
 		Version: ProtocolVersion,
 	}
 	bc.P2PNode.Broadcast(msg)
@@ -513,105 +509,74 @@ func (bc *Blockchain) GetBlockByPreviousHash(prevHash string) (*Block, error) {
 	bc.mu.RLock()
 	defer bc.mu.RUnlock()
 
-	for _, block := range bc.Blocks {
-		if block.CalculateHash() == prevHash {
-			return block, nil
-		}
+	if block, exists := bc.blocksByHash[prevHash]; exists {
+		return block, nil
 	}
 
 	return nil, fmt.Errorf("block not found")
 }
 
-// Reorganize switches to a longer valid chain
+// Reorganize imports the blocks of a competing chain and reports whether the
+// main chain now ends with its last block. Fork choice decides whether to switch.
 func (bc *Blockchain) Reorganize(newChain []*Block) bool {
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
 
-	// Validate the entire new chain
-	for i, block := range newChain {
-		// Skip genesis block
-		if i == 0 {
-			if block.Header.PreviousHash != "" {
-				return false // Genesis block shouldn't have previous hash
-			}
-			continue
-		}
-
-		// Check block links
-		prevBlockHash := newChain[i-1].CalculateHash()
-		if block.Header.PreviousHash != prevBlockHash {
-			return false
-		}
-
-	}
-
-	// Only reorganize if new chain is longer
-	if len(newChain) <= len(bc.Blocks) {
-		return false
-	}
-
-	// Switch to new chain
-	bc.Blocks = newChain
-	return true
-}
-
-func (bc *Blockchain) handleFork(forkBlock *Block) bool {
-	chain := bc.reconstructChain(forkBlock)
-	if chain == nil || len(chain) <= len(bc.Blocks) {
-		fmt.Println("🚫 Forked chain is not longer, discarding")
+	if len(newChain) == 0 {
 		return false
 	}
-	return bc.reorganizeToFork(chain)
-}
-
-func (bc *Blockchain) reorganizeToFork(newChain []*Block) bool {
-	// Validate entire chain
-	for i, block := range newChain {
-		if !block.IsValid() || block.CalculateHash() != block.Hash {
-			fmt.Printf("❌ Invalid block at position %d in forked chain\n", i)
-			return false
+	for _, block := range newChain {
+		if _, known := bc.tree.nodes[block.Hash]; !known {
+			bc.importBlock(block)
 		}
 	}
-
-	// Replace current chain
-	bc.Blocks = newChain
-	fmt.Println("✅ Chain reorganized to better fork")
-	bc.PendingTxs = []*Transaction{}
-	return true
+	_, onMain := bc.blocksByHash[newChain[len(newChain)-1].Hash]
+	return onMain
 }
 
-func (bc *Blockchain) reconstructChain(block *Block) []*Block {
-	// Walk back from the given block to genesis using known blocks or peer requests
-	chain := []*Block{block}
-	current := block
-
-	for {
-		if current.Header.Index == 0 {
-			break // Genesis
-		}
+func (bc *Blockchain) GetPendingTransactions() []*Transaction {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.txPool.Transactions()
+}
 
-		parent, _ := bc.GetBlockByPreviousHash(current.Header.PreviousHash)
-		if parent == nil {
-			fmt.Printf("❌ Missing parent for block %d\n", current.Header.Index)
-			return nil
-		}
+// AddPendingTransaction queues a transaction in the pool without balance checks
+func (bc *Blockchain) AddPendingTransaction(tx *Transaction) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
 
-		chain = append([]*Block{parent}, chain...)
-		current = parent
+	if isSystemSender(tx.From) {
+		return ErrSystemTransaction
 	}
-
-	return chain
+	if err := checkFields(tx); err != nil {
+		return err
+	}
+	if err := bc.sigCache.Verify(tx); err != nil {
+		return err
+	}
+	if err := bc.checkChainID(tx); err != nil {
+		return err
+	}
+	if err := bc.checkGas(tx); err != nil {
+		return err
+	}
+	if err := bc.checkFunds(tx); err != nil {
+		return err
+	}
+	return bc.txPool.Add(tx)
 }
-func (bc *Blockchain) GetPendingTransactions() []*Transaction {
+
+// GetTxPoolStats returns the number of pending and future transactions in the pool
+func (bc *Blockchain) GetTxPoolStats() (int, int) {
 	bc.mu.RLock()
 	defer bc.mu.RUnlock()
-	return bc.PendingTxs
+	return bc.txPool.Stats()
 }
 
-func (bc *Blockchain) GetBalance(addr string) uint64 {
+func (bc *Blockchain) GetBalance(addr string) uint256.Int {
 	state, ok := bc.GlobalState[addr]
 	if !ok {
-		return 0
+		return uint256.Int{}
 	}
 	return state.Balance
 }
@@ -628,14 +593,33 @@ func (bc *Blockchain) ProcessTransaction(tx *Transaction) error {
 	defer bc.mu.Unlock()
 
 	// Validate basic transaction fields
-	if tx.From == "" || tx.To == "" || tx.Amount <= 0 {
-		return fmt.Errorf("invalid transaction: missing fields or negative amount")
+	if err := checkFields(tx); err != nil {
+		return err
 	}
 
-	// Skip validation for system transactions (rewards, minting)
-	if tx.From == "system" {
-		bc.PendingTxs = append(bc.PendingTxs, tx)
-		return nil
+	// System transactions only appear as block rewards
+	if isSystemSender(tx.From) {
+		return ErrSystemTransaction
+	}
+
+	if err := bc.sigCache.Verify(tx); err != nil {
+		return err
+	}
+	if err := bc.checkChainID(tx); err != nil {
+		return err
+	}
+
+	// Reject replays; a nonce beyond the next one is held by the pool as future
+	if accountNonce := bc.GetNonce(tx.From); tx.Nonce < accountNonce {
+		return fmt.Errorf("nonce %d already used: account nonce is %d", tx.Nonce, accountNonce)
+	}
+
+	// The sender must offer a valid gas price and be able to pay amount plus fee
+	if err := bc.checkGas(tx); err != nil {
+		return err
+	}
+	if err := bc.checkFunds(tx); err != nil {
+		return err
 	}
 
 	// Ensure sender exists and has sufficient balance
@@ -644,21 +628,24 @@ func (bc *Blockchain) ProcessTransaction(tx *Transaction) error {
 	// Validate balance based on transaction type
 	switch tx.Type {
 	case RegularTransfer:
-		if senderState.Balance < tx.Amount {
-			return fmt.Errorf("insufficient balance: has %d, needs %d", senderState.Balance, tx.Amount)
+		if senderState.Balance.Lt(tx.Amount) {
+			return fmt.Errorf("insufficient balance: has %s, needs %s", senderState.Balance, tx.Amount)
 		}
-	case TokenTransfer:
+	case TokenTransfer, TokenVest:
 		// Check token balance
 		token, exists := bc.TokenRegistry[tx.TokenID]
 		if !exists {
 			return fmt.Errorf("token %s not found", tx.TokenID)
 		}
-		balance, err := token.BalanceOf(tx.From)
+		balance, err := token.Spendable(tx.From)
 		if err != nil {
 			return fmt.Errorf("failed to get token balance: %v", err)
 		}
-		if balance < tx.Amount {
-			return fmt.Errorf("insufficient token balance: has %d, needs %d", balance, tx.Amount)
+		if balance.Lt(tx.Amount) {
+			return fmt.Errorf("insufficient token balance: has %s, needs %s", balance, tx.Amount)
+		}
+		if err := token.CheckMovement(tx.From, tx.To); err != nil {
+			return err
 		}
 	case StakeDeposit:
 		// Check token balance for staking
@@ -666,41 +653,84 @@ func (bc *Blockchain) ProcessTransaction(tx *Transaction) error {
 		if !exists {
 			return fmt.Errorf("token %s not found", tx.TokenID)
 		}
-		balance, err := token.BalanceOf(tx.From)
+		balance, err := token.Spendable(tx.From)
 		if err != nil {
 			return fmt.Errorf("failed to get token balance: %v", err)
 		}
-		if balance < tx.Amount {
-			return fmt.Errorf("insufficient token balance for staking: has %d, needs %d", balance, tx.Amount)
+		if balance.Lt(tx.Amount) {
+			return fmt.Errorf("insufficient token balance for staking: has %s, needs %s", balance, tx.Amount)
+		}
+		if err := token.CheckMovement(tx.From, "staking_contract"); err != nil {
+			return err
+		}
+	case TokenMint, TokenBurn, TokenCreate, TokenAdmin, TokenPermit:
+		if err := bc.checkTokenAction(tx); err != nil {
+			return err
+		}
+	case CollectionCreate, ItemMint, ItemTransfer, ItemBurn, ItemApprove:
+		if err := bc.checkCollectionAction(tx); err != nil {
+			return err
+		}
+	case ContractDeploy, SmartContractCall:
+		if err := bc.checkContractAction(tx); err != nil {
+			return err
+		}
+	case EscrowCreate, EscrowAction:
+		if err := bc.checkEscrowAction(tx); err != nil {
+			return err
+		}
+	case AddLiquidity, RemoveLiquidity, Swap:
+		if err := bc.checkDEXAction(tx); err != nil {
+			return err
+		}
+	case OTCCreate, OTCAction:
+		if err := bc.checkOTCAction(tx); err != nil {
+			return err
+		}
+	case MultisigCreate, MultisigAction:
+		if err := bc.checkMultisigAction(tx); err != nil {
+			return err
 		}
 	}
 
 	// Queue transaction for block inclusion
-	bc.PendingTxs = append(bc.PendingTxs, tx)
+	if err := bc.txPool.Add(tx); err != nil {
+		return fmt.Errorf("rejected by transaction pool: %w", err)
+	}
 	fmt.Printf("✅ Transaction validated and added to pending pool\n")
 
 	return nil
 }
 func (bc *Blockchain) getOrCreateAccount(address string) *AccountState {
+	if bc.undo != nil {
+		bc.undo.recordAccount(address, bc.GlobalState[address])
+	}
 	if state, exists := bc.GlobalState[address]; exists {
 		return state
 	}
 
 	// Create new account with zero balance
 	newState := &AccountState{
-		Balance: 0,
+		Balance: uint256.Int{},
 		Nonce:   0,
 	}
 	bc.GlobalState[address] = newState
 	return newState
 }
 
+// ApplyTransaction applies tx to state and reports whether it succeeded
 func (bc *Blockchain) ApplyTransaction(tx *Transaction) bool {
+	_, err := bc.applyTransaction(tx)
+	return err == nil
+}
+
+// applyTransaction applies tx to state, returning the events it emitted or the reason it failed
+func (bc *Blockchain) applyTransaction(tx *Transaction) ([]Event, error) {
 	fmt.Println("🔄 Applying transaction:")
 	fmt.Printf("   ➤ Type: %d\n", tx.Type)
 	fmt.Printf("   ➤ From: %s\n", tx.From)
 	fmt.Printf("   ➤ To: %s\n", tx.To)
-	fmt.Printf("   ➤ Amount: %d\n", tx.Amount)
+	fmt.Printf("   ➤ Amount: %s\n", tx.Amount)
 	fmt.Printf("   ➤ TokenID: %s\n", tx.TokenID)
 
 	switch tx.Type {
@@ -712,126 +742,209 @@ func (bc *Blockchain) ApplyTransaction(tx *Transaction) bool {
 		return bc.applyStakeDeposit(tx)
 	case StakeWithdraw:
 		return bc.applyStakeWithdraw(tx)
+	case TokenMint:
+		return bc.applyTokenMint(tx)
+	case TokenBurn:
+		return bc.applyTokenBurn(tx)
+	case TokenCreate:
+		return bc.applyTokenCreate(tx)
+	case TokenAdmin:
+		return bc.applyTokenAdmin(tx)
+	case TokenVest:
+		return bc.applyTokenVest(tx)
+	case TokenPermit:
+		return bc.applyTokenPermit(tx)
+	case CollectionCreate:
+		return bc.applyCollectionCreate(tx)
+	case ItemMint:
+		return bc.applyItemMint(tx)
+	case ItemTransfer:
+		return bc.applyItemTransfer(tx)
+	case ItemBurn:
+		return bc.applyItemBurn(tx)
+	case ItemApprove:
+		return bc.applyItemApprove(tx)
+	case ContractDeploy, SmartContractCall:
+		events, _, err := bc.applyContract(tx)
+		return events, err
+	case EscrowCreate:
+		return bc.applyEscrowCreate(tx)
+	case EscrowAction:
+		return bc.applyEscrowAction(tx)
+	case AddLiquidity:
+		return bc.applyAtomic(tx, bc.applyAddLiquidity)
+	case RemoveLiquidity:
+		return bc.applyAtomic(tx, bc.applyRemoveLiquidity)
+	case Swap:
+		return bc.applyAtomic(tx, bc.applySwap)
+	case OTCCreate:
+		return bc.applyOTCCreate(tx)
+	case OTCAction:
+		return bc.applyAtomic(tx, bc.applyOTCAction)
+	case MultisigCreate:
+		return bc.applyMultisigCreate(tx)
+	case MultisigAction:
+		return bc.applyAtomic(tx, bc.applyMultisigAction)
 	default:
-		fmt.Printf("   ❌ Unknown transaction type: %d\n", tx.Type)
-		return false
+		return nil, fmt.Errorf("unknown transaction type: %d", tx.Type)
 	}
 }
 
-func (bc *Blockchain) applyRegularTransfer(tx *Transaction) bool {
+func (bc *Blockchain) applyRegularTransfer(tx *Transaction) ([]Event, error) {
 	sender := tx.From
 	receiver := tx.To
 	amount := tx.Amount
 
 	// Get or create sender account
 	senderState := bc.getOrCreateAccount(sender)
-	fmt.Printf("   📤 Sender '%s' balance before transaction: %d\n", sender, senderState.Balance)
+	fmt.Printf("   📤 Sender '%s' balance before transaction: %s\n", sender, senderState.Balance)
 
 	// Check for insufficient funds (should already be validated, but double-check)
-	if sender != "system" && senderState.Balance < amount {
-		fmt.Printf("   ❌ Transaction failed: Insufficient funds (has %d, needs %d)\n", senderState.Balance, amount)
-		return false
+	if sender != "system" && senderState.Balance.Lt(amount) {
+		return nil, fmt.Errorf("insufficient funds: has %s, needs %s", senderState.Balance, amount)
 	}
 
 	// Deduct from sender (unless it's a system transaction)
 	if sender != "system" {
-		senderState.Balance -= amount
-		bc.SetBalance(sender, senderState.Balance)
-		fmt.Printf("   ✅ Sender '%s' balance after deduction: %d\n", sender, senderState.Balance)
+		remaining, _ := senderState.Balance.Sub(amount)
+		bc.SetBalance(sender, remaining)
+		fmt.Printf("   ✅ Sender '%s' balance after deduction: %s\n", sender, remaining)
 	}
 
 	// Get or create receiver account
 	receiverState := bc.getOrCreateAccount(receiver)
-	fmt.Printf("   📥 Receiver '%s' balance before transaction: %d\n", receiver, receiverState.Balance)
+	fmt.Printf("   📥 Receiver '%s' balance before transaction: %s\n", receiver, receiverState.Balance)
 
-	// Add to receiver
-	receiverState.Balance += amount
-	bc.SetBalance(receiver, receiverState.Balance)
-	fmt.Printf("   ✅ Receiver '%s' balance after addition: %d\n", receiver, receiverState.Balance)
+	// Add to receiver. Only amounts minted out of thin air by the system can
+	// overflow, since every other amount comes out of a balance.
+	received, err := receiverState.Balance.Add(amount)
+	if err != nil {
+		return nil, fmt.Errorf("receiver balance overflows: %w", err)
+	}
+	bc.SetBalance(receiver, received)
+	fmt.Printf("   ✅ Receiver '%s' balance after addition: %s\n", receiver, received)
 
 	fmt.Println("✅ Regular transfer applied successfully")
-	return true
+	return []Event{{Type: EventTransfer, From: sender, To: receiver, Amount: amount}}, nil
 }
 
-func (bc *Blockchain) applyTokenTransfer(tx *Transaction) bool {
+func (bc *Blockchain) applyTokenTransfer(tx *Transaction) ([]Event, error) {
 	token, exists := bc.TokenRegistry[tx.TokenID]
 	if !exists {
-		fmt.Printf("   ❌ Token %s not found\n", tx.TokenID)
-		return false
+		return nil, fmt.Errorf("token %s not found", tx.TokenID)
 	}
 
 	err := token.Transfer(tx.From, tx.To, tx.Amount)
 	if err != nil {
-		fmt.Printf("   ❌ Token transfer failed: %v\n", err)
-		return false
+		return nil, fmt.Errorf("token transfer failed: %v", err)
 	}
 
-	fmt.Printf("   ✅ Token transfer applied successfully: %d %s from %s to %s\n",
+	fmt.Printf("   ✅ Token transfer applied successfully: %s %s from %s to %s\n",
 		tx.Amount, tx.TokenID, tx.From, tx.To)
-	return true
+	return []Event{{Type: EventTransfer, TokenID: tx.TokenID, From: tx.From, To: tx.To, Amount: tx.Amount}}, nil
 }
 
-func (bc *Blockchain) applyStakeDeposit(tx *Transaction) bool {
+func (bc *Blockchain) applyStakeDeposit(tx *Transaction) ([]Event, error) {
+	if err := checkStakeToken(tx); err != nil {
+		return nil, err
+	}
 	token, exists := bc.TokenRegistry[tx.TokenID]
 	if !exists {
-		fmt.Printf("   ❌ Token %s not found\n", tx.TokenID)
-		return false
+		return nil, fmt.Errorf("token %s not found", tx.TokenID)
 	}
 
 	// Transfer tokens to staking contract
 	err := token.Transfer(tx.From, "staking_contract", tx.Amount)
 	if err != nil {
-		fmt.Printf("   ❌ Stake deposit failed: %v\n", err)
-		return false
+		return nil, fmt.Errorf("stake deposit failed: %v", err)
 	}
 
-	// Update stake ledger
-	bc.StakeLedger.AddStake(tx.From, tx.Amount)
+	// Update stake ledger. Stakes are backed by staked tokens, so they cannot
+	// overflow while the token transfer succeeds.
+	if err := bc.StakeLedger.AddStake(tx.From, tx.Amount); err != nil {
+		return nil, fmt.Errorf("stake deposit failed: %v", err)
+	}
 
-	fmt.Printf("   ✅ Stake deposit applied successfully: %d %s staked by %s\n",
+	fmt.Printf("   ✅ Stake deposit applied successfully: %s %s staked by %s\n",
 		tx.Amount, tx.TokenID, tx.From)
-	fmt.Printf("   📊 New stake for %s: %d\n", tx.From, bc.StakeLedger.GetStake(tx.From))
-	return true
+	fmt.Printf("   📊 New stake for %s: %s\n", tx.From, bc.StakeLedger.GetStake(tx.From))
+	return []Event{{Type: EventStake, TokenID: tx.TokenID, From: tx.From, To: "staking_contract", Amount: tx.Amount}}, nil
 }
 
-func (bc *Blockchain) applyStakeWithdraw(tx *Transaction) bool {
+func (bc *Blockchain) applyStakeWithdraw(tx *Transaction) ([]Event, error) {
+	if err := checkStakeToken(tx); err != nil {
+		return nil, err
+	}
 	// Check if user has enough stake
 	currentStake := bc.StakeLedger.GetStake(tx.From)
-	if currentStake < tx.Amount {
-		fmt.Printf("   ❌ Insufficient stake: has %d, trying to withdraw %d\n", currentStake, tx.Amount)
-		return false
+	remainingStake, err := currentStake.Sub(tx.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("insufficient stake: has %s, trying to withdraw %s", currentStake, tx.Amount)
 	}
 
 	token, exists := bc.TokenRegistry[tx.TokenID]
 	if !exists {
-		fmt.Printf("   ❌ Token %s not found\n", tx.TokenID)
-		return false
+		return nil, fmt.Errorf("token %s not found", tx.TokenID)
 	}
 
 	// Transfer tokens back from staking contract
-	err := token.Transfer("staking_contract", tx.From, tx.Amount)
-	if err != nil {
-		fmt.Printf("   ❌ Stake withdrawal failed: %v\n", err)
-		return false
+	if err := token.Transfer("staking_contract", tx.From, tx.Amount); err != nil {
+		return nil, fmt.Errorf("stake withdrawal failed: %v", err)
 	}
 
 	// Update stake ledger
-	bc.StakeLedger.SetStake(tx.From, currentStake-tx.Amount)
+	bc.StakeLedger.SetStake(tx.From, remainingStake)
 
-	fmt.Printf("   ✅ Stake withdrawal applied successfully: %d %s withdrawn by %s\n",
+	fmt.Printf("   ✅ Stake withdrawal applied successfully: %s %s withdrawn by %s\n",
 		tx.Amount, tx.TokenID, tx.From)
-	fmt.Printf("   📊 New stake for %s: %d\n", tx.From, bc.StakeLedger.GetStake(tx.From))
-	return true
+	fmt.Printf("   📊 New stake for %s: %s\n", tx.From, bc.StakeLedger.GetStake(tx.From))
+	return []Event{{Type: EventUnstake, TokenID: tx.TokenID, From: "staking_contract", To: tx.From, Amount: tx.Amount}}, nil
 }
 
-func (bc *Blockchain) SetBalance(addr string, balance uint64) {
+// SetBalance updates an account balance. The account is written to the store
+// together with the next persisted block.
+func (bc *Blockchain) SetBalance(addr string, balance uint256.Int) {
+	if bc.undo != nil {
+		bc.undo.recordAccount(addr, bc.GlobalState[addr])
+	}
 	state, ok := bc.GlobalState[addr]
 	if !ok {
 		state = &AccountState{}
 	}
 	state.Balance = balance
 	bc.GlobalState[addr] = state
-	_ = bc.SaveAccountState(addr, state)
+	bc.dirtyAccounts[addr] = true
+}
+
+// checkChainID rejects transactions signed for another network
+func (bc *Blockchain) checkChainID(tx *Transaction) error {
+	if tx.ChainID != bc.ChainID {
+		return fmt.Errorf("%w: expected %d, got %d", ErrWrongChainID, bc.ChainID, tx.ChainID)
+	}
+	return nil
+}
+
+// checkNonce verifies that tx carries the sender's next nonce
+func (bc *Blockchain) checkNonce(tx *Transaction) error {
+	if expected := bc.GetNonce(tx.From); tx.Nonce != expected {
+		return fmt.Errorf("invalid nonce: expected %d, got %d", expected, tx.Nonce)
+	}
+	return nil
+}
+
+// useNonce advances the sender's nonce once tx is included
+func (bc *Blockchain) useNonce(tx *Transaction) {
+	bc.getOrCreateAccount(tx.From).Nonce++
+	bc.dirtyAccounts[tx.From] = true
+}
+
+// GetNextNonce returns the nonce the sender's next transaction should use,
+// counting transactions already waiting in the pool
+func (bc *Blockchain) GetNextNonce(address string) uint64 {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.txPool.NextNonce(address, bc.GetNonce(address))
 }
 
 func (bc *Blockchain) SaveAccountState(addr string, state *AccountState) error {
@@ -856,78 +969,98 @@ func (bc *Blockchain) LoadAccountState(addr string) (*AccountState, error) {
 	return &state, nil
 }
 
-func (bc *Blockchain) loadGlobalState() {
-	iter := bc.DB.NewIterator(nil, nil)
+// verifyStoredAccounts checks that the persisted account states match the
+// state rebuilt from the block store
+func (bc *Blockchain) verifyStoredAccounts() error {
+	iter := bc.DB.NewIterator(util.BytesPrefix([]byte("account:")), nil)
 	defer iter.Release()
 
 	for iter.Next() {
-		key := string(iter.Key())
-		if len(key) >= 8 && key[:8] == "account:" {
-			addr := key[8:]
-			var state AccountState
-			err := json.Unmarshal(iter.Value(), &state)
-			if err == nil {
-				bc.GlobalState[addr] = &state
-			}
+		addr := string(iter.Key()[len("account:"):])
+		var stored AccountState
+		if err := json.Unmarshal(iter.Value(), &stored); err != nil {
+			return fmt.Errorf("corrupt stored account %s: %v", addr, err)
+		}
+		current, exists := bc.GlobalState[addr]
+		if !exists {
+			current = &AccountState{}
+		}
+		if stored != *current {
+			return fmt.Errorf("stored account %s (balance %s, nonce %d) disagrees with chain state (balance %s, nonce %d)",
+				addr, stored.Balance, stored.Nonce, current.Balance, current.Nonce)
 		}
 	}
 
-	if err := iter.Error(); err != nil {
-		log.Println("Error loading global state:", err)
-	}
+	return iter.Error()
 }
 
 func (bc *Blockchain) ValidateTransaction(tx *Transaction) error {
 	// Existing validation...
+	if isSystemSender(tx.From) {
+		return ErrSystemTransaction
+	}
+	if err := checkFields(tx); err != nil {
+		return err
+	}
+	if err := bc.sigCache.Verify(tx); err != nil {
+		return err
+	}
+	if err := bc.checkChainID(tx); err != nil {
+		return err
+	}
+	if err := bc.checkGas(tx); err != nil {
+		return err
+	}
+	if err := bc.checkFunds(tx); err != nil {
+		return err
+	}
 
 	// Token-specific validation
-	if tx.Type == TokenTransfer || tx.Type == StakeDeposit || tx.Type == StakeWithdraw {
+	if tx.Type == TokenTransfer || tx.Type == StakeDeposit || tx.Type == StakeWithdraw || tx.Type == TokenVest {
 		token, exists := bc.TokenRegistry[tx.TokenID]
 		if !exists {
 			return errors.New("token not found")
 		}
 
 		// Check token balance
-		balance, err := token.BalanceOf(tx.From)
+		balance, err := token.Spendable(tx.From)
 		if err != nil {
 			return err
 		}
 
-		if balance < tx.Amount {
+		if balance.Lt(tx.Amount) {
 			return errors.New("insufficient token balance")
 		}
-	}
 
-	return nil
-}
-
-func (bc *Blockchain) processTransaction(tx *Transaction) error {
-	switch tx.Type {
-	case RegularTransfer:
-		// Process regular transfer
-		// ...
-	case TokenTransfer:
-		token, exists := bc.TokenRegistry[tx.TokenID]
-		if !exists {
-			return errors.New("token not found")
-		}
-		return token.Transfer(tx.From, tx.To, tx.Amount)
-	case StakeDeposit:
-		token, exists := bc.TokenRegistry[tx.TokenID]
-		if !exists {
-			return errors.New("token not found")
+		switch tx.Type {
+		case TokenTransfer, TokenVest:
+			return token.CheckMovement(tx.From, tx.To)
+		case StakeDeposit:
+			return token.CheckMovement(tx.From, "staking_contract")
 		}
-		// Transfer tokens to staking contract
-		if err := token.Transfer(tx.From, "staking_contract", tx.Amount); err != nil {
-			return err
-		}
-		// Update stake ledger
-		bc.StakeLedger.AddStake(tx.From, tx.Amount)
-		return nil
-	case StakeWithdraw:
-		// Implement stake withdrawal logic
-		// ...
 	}
+	if tx.Type == TokenMint || tx.Type == TokenBurn || tx.Type == TokenCreate || tx.Type == TokenAdmin || tx.Type == TokenPermit {
+		return bc.checkTokenAction(tx)
+	}
+	if isCollectionTx(tx.Type) {
+		return bc.checkCollectionAction(tx)
+	}
+	if isContractTx(tx.Type) {
+		return bc.checkContractAction(tx)
+	}
+	if tx.Type == EscrowCreate || tx.Type == EscrowAction {
+		return bc.checkEscrowAction(tx)
+	}
+	if isDEXTx(tx.Type) {
+		return bc.checkDEXAction(tx)
+	}
+	if tx.Type == OTCCreate || tx.Type == OTCAction {
+		return bc.checkOTCAction(tx)
+	}
+	if tx.Type == MultisigCreate || tx.Type == MultisigAction {
+		return bc.checkMultisigAction(tx)
+	}
+
 	return nil
 }
 
@@ -946,9 +1079,9 @@ func (bc *Blockchain) GetBlockchainInfo() map[string]interface{} {
 	}
 
 	// Get token balances
-	tokenBalances := make(map[string]map[string]uint64)
+	tokenBalances := make(map[string]map[string]uint256.Int)
 	for tokenSymbol, token := range bc.TokenRegistry {
-		tokenBalances[tokenSymbol] = make(map[string]uint64)
+		tokenBalances[tokenSymbol] = make(map[string]uint256.Int)
 
 		// Get ALL addresses that have token balances (not just GlobalState addresses)
 		allAddresses := make(map[string]bool)
@@ -970,7 +1103,7 @@ func (bc *Blockchain) GetBlockchainInfo() map[string]interface{} {
 
 		// Get balances for all known addresses
 		for addr := range allAddresses {
-			if balance, err := token.BalanceOf(addr); err == nil && balance > 0 {
+			if balance, err := token.BalanceOf(addr); err == nil && !balance.IsZero() {
 				tokenBalances[tokenSymbol][addr] = balance
 			}
 		}
@@ -998,8 +1131,7 @@ func (bc *Blockchain) GetBlockchainInfo() map[string]interface{} {
 	}
 
 	// Calculate actual circulating supply from BHX token
-	circulatingSupply := uint64(0)
-	maxSupply := uint64(0)
+	var circulatingSupply, maxSupply uint256.Int
 	if bhxToken, exists := bc.TokenRegistry["BHX"]; exists {
 		circulatingSupply = bhxToken.CirculatingSupply()
 		maxSupply = bhxToken.MaxSupply()
@@ -1007,7 +1139,7 @@ func (bc *Blockchain) GetBlockchainInfo() map[string]interface{} {
 
 	return map[string]interface{}{
 		"blockHeight":       len(bc.Blocks),
-		"pendingTxs":        len(bc.PendingTxs),
+		"pendingTxs":        bc.txPool.Len(),
 		"totalSupply":       circulatingSupply, // Use actual circulating supply
 		"maxSupply":         maxSupply,         // Show maximum supply
 		"blockReward":       bc.BlockReward,
@@ -1016,7 +1148,7 @@ func (bc *Blockchain) GetBlockchainInfo() map[string]interface{} {
 		"stakes":            stakes,
 		"recentBlocks":      recentBlocks,
 		"tokenRegistry":     bc.getTokenRegistryInfo(),
-		"supplyUtilization": float64(circulatingSupply) / float64(maxSupply) * 100, // Percentage used
+		"supplyUtilization": circulatingSupply.Float64() / maxSupply.Float64() * 100, // Percentage used
 	}
 }
 
@@ -1029,49 +1161,28 @@ func (bc *Blockchain) getTokenRegistryInfo() map[string]interface{} {
 			"decimals":          token.Decimals,
 			"circulatingSupply": token.CirculatingSupply(),
 			"maxSupply":         token.MaxSupply(),
-			"utilization":       float64(token.CirculatingSupply()) / float64(token.MaxSupply()) * 100,
+			"utilization":       token.CirculatingSupply().Float64() / token.MaxSupply().Float64() * 100,
+			"mintAuthority":     token.MintAuthority(),
+			"roles":             token.Controls().Roles,
+			"paused":            token.Paused(),
 		}
 	}
 	return tokens
 }
 
-// AddTokenBalance adds tokens to an address (admin function for testing)
-func (bc *Blockchain) AddTokenBalance(address, tokenSymbol string, amount uint64) error {
-	bc.mu.Lock()
-	defer bc.mu.Unlock()
-
-	token, exists := bc.TokenRegistry[tokenSymbol]
-	if !exists {
-		return fmt.Errorf("token %s not found", tokenSymbol)
-	}
-
-	err := token.Mint(address, amount)
-	if err != nil {
-		return fmt.Errorf("failed to mint tokens: %v", err)
-	}
-
-	fmt.Printf("✅ Added %d %s tokens to %s\n", amount, tokenSymbol, address)
-	return nil
-}
-
 // Slashing-related validation functions
 func (bc *Blockchain) validateTransactionSecurity(tx *Transaction) bool {
 	// Check for malicious transaction patterns - MUCH MORE CONSERVATIVE
+	// Amounts are not capped here: they are in base units of tokens with
+	// different decimals, and balance checks already bound them.
 
-	// 1. Check for extremely excessive amounts (potential drain attack)
-	// Increased threshold to 1 billion (1,000,000,000) to avoid false positives
-	if tx.Amount > 1000000000 {
-		fmt.Printf("🚨 Extremely large transaction detected: %d (threshold: 1,000,000,000)\n", tx.Amount)
-		return false
-	}
-
-	// 2. Check for invalid self-transfers (but allow staking)
-	if tx.From == tx.To && tx.Type != StakeDeposit && tx.Type != StakeWithdraw {
+	// 1. Check for invalid self-transfers (but allow staking and minting to oneself)
+	if tx.From == tx.To && hasRecipient(tx.Type) && tx.Type != StakeDeposit && tx.Type != StakeWithdraw && tx.Type != TokenMint && tx.Type != ItemMint {
 		fmt.Printf("🚨 Invalid self-transfer detected (non-staking): %s\n", tx.ID)
 		return false
 	}
 
-	// 3. Check for extreme timestamp manipulation (extended window)
+	// 2. Check for extreme timestamp manipulation (extended window)
 	currentTime := time.Now().Unix()
 	if tx.Timestamp > currentTime+3600 { // 1 hour in future (was 5 minutes)
 		fmt.Printf("🚨 Extreme future timestamp detected: %d vs %d (diff: %d seconds)\n",
@@ -1079,15 +1190,8 @@ func (bc *Blockchain) validateTransactionSecurity(tx *Transaction) bool {
 		return false
 	}
 
-	// 4. Check for excessive duplicate nonces (potential replay attack)
-	// Only flag if there are many duplicates, not just one or two
-	if bc.isDuplicateNonce(tx) {
-		fmt.Printf("🚨 Excessive nonce reuse detected (potential replay attack): %s\n", tx.ID)
-		return false
-	}
-
-	// 5. Additional check: Ensure transaction has valid signature (if available)
-	if tx.From == "" || tx.To == "" {
+	// 3. Additional check: Ensure transaction has valid signature (if available)
+	if tx.From == "" || (hasRecipient(tx.Type) && tx.To == "") {
 		fmt.Printf("🚨 Invalid transaction addresses: from=%s, to=%s\n", tx.From, tx.To)
 		return false
 	}
@@ -1109,48 +1213,6 @@ func (bc *Blockchain) detectDoubleSign(block *Block) bool {
 	return false
 }
 
-func (bc *Blockchain) isDuplicateNonce(tx *Transaction) bool {
-	// Skip nonce validation for system transactions
-	if tx.From == "system" || tx.From == "staking_contract" || tx.From == "burn_address" {
-		return false
-	}
-
-	// Skip nonce validation for certain transaction types that don't need strict ordering
-	if tx.Type == StakeDeposit || tx.Type == StakeWithdraw {
-		return false
-	}
-
-	// Only check recent blocks (last 100) to avoid performance issues
-	// and reduce false positives from old transactions
-	startIndex := 0
-	if len(bc.Blocks) > 100 {
-		startIndex = len(bc.Blocks) - 100
-	}
-
-	duplicateCount := 0
-	for i := startIndex; i < len(bc.Blocks); i++ {
-		block := bc.Blocks[i]
-		for _, existingTx := range block.Transactions {
-			if existingTx.From == tx.From &&
-				existingTx.Nonce == tx.Nonce &&
-				existingTx.ID != tx.ID &&
-				existingTx.Type == tx.Type { // Same transaction type
-				duplicateCount++
-			}
-		}
-	}
-
-	// Only flag as duplicate if we see multiple instances
-	// This reduces false positives from legitimate retries
-	if duplicateCount > 2 {
-		fmt.Printf("🚨 Multiple duplicate nonces detected: %d instances of nonce %d for %s\n",
-			duplicateCount, tx.Nonce, tx.From)
-		return true
-	}
-
-	return false
-}
-
 // Validator monitoring functions
 func (bc *Blockchain) MonitorValidatorPerformance() {
 	// This would run as a goroutine to monitor validator behavior
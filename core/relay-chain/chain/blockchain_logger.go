@@ -7,18 +7,20 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
 // BlockchainState represents the state of the blockchain for logging
 type BlockchainState struct {
-	NodeID        string    `json:"node_id"`
-	BlockHeight   int       `json:"block_height"`
-	LatestBlock   *Block    `json:"latest_block"`
-	PendingBlocks int       `json:"pending_blocks"`
-	TotalSupply   uint64    `json:"total_supply"`
-	Timestamp     time.Time `json:"timestamp"`
-	ForkInfo      *ForkInfo `json:"fork_info,omitempty"`
-	BlockHashes   []string  `json:"block_hashes"`
+	NodeID        string      `json:"node_id"`
+	BlockHeight   int         `json:"block_height"`
+	LatestBlock   *Block      `json:"latest_block"`
+	PendingBlocks int         `json:"pending_blocks"`
+	TotalSupply   uint256.Int `json:"total_supply"`
+	Timestamp     time.Time   `json:"timestamp"`
+	ForkInfo      *ForkInfo   `json:"fork_info,omitempty"`
+	BlockHashes   []string    `json:"block_hashes"`
 }
 
 // ForkInfo contains information about potential forks
@@ -0,0 +1,121 @@
+package chain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// LevelDB key prefixes of the persisted collections. Items are stored as
+// JSON, balances as decimal strings and operator approvals as "1". Item IDs
+// never contain ':'.
+const (
+	ledgerItemPrefix        = "nftitem:" // nftitem:<symbol>:<id>
+	ledgerItemBalancePrefix = "nftbal:"  // nftbal:<symbol>:<id>:<address>
+	ledgerOperatorPrefix    = "nftop:"   // nftop:<symbol>:<owner>:<operator>
+)
+
+func ledgerItemKey(symbol, id string) string {
+	return ledgerItemPrefix + symbol + ":" + id
+}
+
+func ledgerItemBalanceKey(symbol, id, address string) string {
+	return ledgerItemBalancePrefix + symbol + ":" + id + ":" + address
+}
+
+func ledgerOperatorKey(symbol, owner, operator string) string {
+	return ledgerOperatorPrefix + symbol + ":" + owner + ":" + operator
+}
+
+// The blockStateStore is also the node's token.CollectionStore.
+func (s *blockStateStore) PutItem(symbol, id string, item *token.Item) {
+	var data []byte
+	if item != nil {
+		// A plain struct always marshals
+		data, _ = json.Marshal(item)
+	}
+	s.set(ledgerItemKey(symbol, id), data)
+}
+
+func (s *blockStateStore) PutItemBalance(symbol, id, address string, amount uint256.Int) {
+	s.put(ledgerItemBalanceKey(symbol, id, address), amount)
+}
+
+func (s *blockStateStore) PutOperator(symbol, owner, operator string, approved bool) {
+	if !approved {
+		s.set(ledgerOperatorKey(symbol, owner, operator), nil)
+		return
+	}
+	s.set(ledgerOperatorKey(symbol, owner, operator), []byte("1"))
+}
+
+// readCollectionLedger reads a collection's items, holdings and operators from the store
+func (bc *Blockchain) readCollectionLedger(symbol string) (*token.CollectionLedger, error) {
+	ledger := &token.CollectionLedger{Symbol: symbol, Items: []token.ItemEntry{}, Holdings: []token.Holding{}, Operators: []token.OperatorEntry{}}
+
+	// Keys sort by item ID, then address or operator
+	prefix := ledgerItemKey(symbol, "")
+	iter := bc.DB.NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+	for iter.Next() {
+		entry := token.ItemEntry{ID: string(iter.Key()[len(prefix):])}
+		if err := json.Unmarshal(iter.Value(), &entry.Item); err != nil {
+			iter.Release()
+			return nil, fmt.Errorf("corrupt stored item %s: %v", iter.Key(), err)
+		}
+		ledger.Items = append(ledger.Items, entry)
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	prefix = ledgerItemBalancePrefix + symbol + ":"
+	iter = bc.DB.NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+	for iter.Next() {
+		key := string(iter.Key())
+		amount, err := parseLedgerAmount(key, iter.Value())
+		if err != nil {
+			iter.Release()
+			return nil, err
+		}
+		id, address, ok := strings.Cut(key[len(prefix):], ":")
+		if !ok {
+			iter.Release()
+			return nil, fmt.Errorf("corrupt stored ledger key %s", key)
+		}
+		ledger.Holdings = append(ledger.Holdings, token.Holding{ID: id, Address: address, Amount: amount})
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	prefix = ledgerOperatorPrefix + symbol + ":"
+	iter = bc.DB.NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+	defer iter.Release()
+	for iter.Next() {
+		key := string(iter.Key())
+		owner, operator, ok := strings.Cut(key[len(prefix):], ":")
+		if !ok {
+			return nil, fmt.Errorf("corrupt stored ledger key %s", key)
+		}
+		ledger.Operators = append(ledger.Operators, token.OperatorEntry{Owner: owner, Operator: operator})
+	}
+	return ledger, iter.Error()
+}
+
+// ExportCollection returns a collection's items, holdings and operators as
+// persisted with the last block
+func (bc *Blockchain) ExportCollection(symbol string) (*token.CollectionLedger, error) {
+	bc.mu.RLock()
+	_, exists := bc.Collections[symbol]
+	bc.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrCollectionNotFound, symbol)
+	}
+	return bc.readCollectionLedger(symbol)
+}
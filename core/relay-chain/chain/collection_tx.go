@@ -0,0 +1,335 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+var (
+	ErrCollectionExists    = errors.New("collection already exists")
+	ErrInvalidCollectionTx = errors.New("invalid collection parameters")
+	ErrCollectionNotFound  = errors.New("collection not found")
+)
+
+// CollectionCreateParams is the JSON carried in the Data of a
+// CollectionCreate transaction. The symbol is the transaction's TokenID and
+// shares one namespace with token symbols.
+type CollectionCreateParams struct {
+	Name string               `json:"name"`
+	Kind token.CollectionKind `json:"kind"`
+	// Minter may mint items. It defaults to the sender; "none" closes the
+	// collection at creation.
+	Minter string `json:"minter,omitempty"`
+}
+
+// ItemParams is the JSON carried in the Data of ItemMint, ItemTransfer,
+// ItemBurn and ItemApprove transactions. The collection is the transaction's
+// TokenID, the amount its Amount and the recipient its To.
+type ItemParams struct {
+	ID  string `json:"id,omitempty"`
+	URI string `json:"uri,omitempty"` // metadata URI of a newly minted item
+	// Owner is whose items a transfer or burn moves. It defaults to the sender.
+	Owner string `json:"owner,omitempty"`
+	// Operator makes an approval without an ID grant (true) or revoke (false)
+	// To as operator of all the sender's items
+	Operator *bool `json:"operator,omitempty"`
+}
+
+// NewCollectionCreateTransaction builds an unsigned transaction that creates a collection
+func NewCollectionCreateTransaction(from, symbol string, params CollectionCreateParams) (*Transaction, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(CollectionCreate, from, "", uint256.Int{}, nil)
+	tx.TokenID = symbol
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx)
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// NewItemTransaction builds an unsigned ItemMint, ItemTransfer, ItemBurn or
+// ItemApprove transaction on a collection
+func NewItemTransaction(txType int, from, to, symbol string, amount uint256.Int, params ItemParams) (*Transaction, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(txType, from, to, amount, nil)
+	tx.TokenID = symbol
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx)
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// isCollectionTx reports whether txType acts on a collection
+func isCollectionTx(txType int) bool {
+	switch txType {
+	case CollectionCreate, ItemMint, ItemTransfer, ItemBurn, ItemApprove:
+		return true
+	}
+	return false
+}
+
+// decodeCollectionCreate parses and validates the parameters of a CollectionCreate transaction
+func decodeCollectionCreate(tx *Transaction) (*CollectionCreateParams, error) {
+	var params CollectionCreateParams
+	if err := json.Unmarshal(tx.Data, &params); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCollectionTx, err)
+	}
+	if !validTokenSymbol(tx.TokenID) {
+		return nil, fmt.Errorf("%w: symbol must be 1-%d upper case letters or digits", ErrInvalidCollectionTx, MaxTokenSymbolLength)
+	}
+	if params.Name == "" || len(params.Name) > MaxTokenNameLength {
+		return nil, fmt.Errorf("%w: name must be 1-%d bytes", ErrInvalidCollectionTx, MaxTokenNameLength)
+	}
+	if !params.Kind.Valid() {
+		return nil, fmt.Errorf("%w: kind must be %q or %q", ErrInvalidCollectionTx, token.NonFungible, token.SemiFungible)
+	}
+	if !tx.Amount.IsZero() {
+		return nil, fmt.Errorf("%w: a collection is created without items", ErrInvalidCollectionTx)
+	}
+	return &params, nil
+}
+
+// decodeItem parses and validates the parameters of an item transaction
+func decodeItem(tx *Transaction) (*ItemParams, error) {
+	var params ItemParams
+	if err := json.Unmarshal(tx.Data, &params); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCollectionTx, err)
+	}
+	if tx.Type == ItemApprove && params.Operator != nil {
+		if params.ID != "" || tx.To == "" || tx.To == tx.From {
+			return nil, fmt.Errorf("%w: an operator approval names another account in To and no item", ErrInvalidCollectionTx)
+		}
+		return &params, nil
+	}
+	if !token.ValidItemID(params.ID) {
+		return nil, fmt.Errorf("%w: item ID must be 1-%d letters, digits, '.', '_' or '-'", ErrInvalidCollectionTx, token.MaxItemIDLength)
+	}
+	if tx.Type == ItemApprove {
+		return &params, nil
+	}
+	if tx.Amount.IsZero() {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidTxFields)
+	}
+	if params.Owner == "" {
+		params.Owner = tx.From
+	}
+	return &params, nil
+}
+
+// checkCollectionAction runs the checks of collection transactions that
+// depend on chain state. Caller must hold bc.mu.
+func (bc *Blockchain) checkCollectionAction(tx *Transaction) error {
+	if tx.Type == CollectionCreate {
+		return bc.checkSymbolFree(tx.TokenID)
+	}
+
+	c, exists := bc.Collections[tx.TokenID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrCollectionNotFound, tx.TokenID)
+	}
+	params, err := decodeItem(tx)
+	if err != nil {
+		return err
+	}
+	switch tx.Type {
+	case ItemMint:
+		return c.CheckMint(tx.From, params.ID, tx.Amount, params.URI)
+	case ItemTransfer, ItemBurn:
+		return c.CheckMove(tx.From, params.Owner, params.ID, tx.Amount)
+	case ItemApprove:
+		if params.Operator == nil {
+			return c.CheckApprove(tx.From, params.ID)
+		}
+	}
+	return nil
+}
+
+// checkSymbolFree reports whether symbol is neither a token nor a collection.
+// Caller must hold bc.mu.
+func (bc *Blockchain) checkSymbolFree(symbol string) error {
+	if _, exists := bc.TokenRegistry[symbol]; exists {
+		return fmt.Errorf("%w: %s", ErrTokenExists, symbol)
+	}
+	if _, exists := bc.Collections[symbol]; exists {
+		return fmt.Errorf("%w: %s", ErrCollectionExists, symbol)
+	}
+	return nil
+}
+
+// applyCollectionCreate registers a new, empty collection. Caller must hold bc.mu.
+func (bc *Blockchain) applyCollectionCreate(tx *Transaction) ([]Event, error) {
+	params, err := decodeCollectionCreate(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := bc.checkCollectionAction(tx); err != nil {
+		return nil, err
+	}
+
+	c := token.NewCollection(params.Name, tx.TokenID, params.Kind)
+	c.SetMinter(creationRole(params.Minter, tx.From))
+	bc.registerCollection(c)
+
+	fmt.Printf("   🖼️ Created %s collection %s (%s) with minter %q\n", params.Kind, tx.TokenID, params.Name, c.Minter())
+	return nil, nil
+}
+
+func (bc *Blockchain) applyItemMint(tx *Transaction) ([]Event, error) {
+	if err := bc.checkCollectionAction(tx); err != nil {
+		return nil, err
+	}
+	params, _ := decodeItem(tx)
+	if err := bc.Collections[tx.TokenID].Mint(tx.From, tx.To, params.ID, tx.Amount, params.URI); err != nil {
+		return nil, fmt.Errorf("item mint failed: %v", err)
+	}
+
+	fmt.Printf("   ✅ Minted %s of %s/%s to %s\n", tx.Amount, tx.TokenID, params.ID, tx.To)
+	return []Event{{Type: EventItemMint, TokenID: tx.TokenID, To: tx.To, Amount: tx.Amount, ItemID: params.ID}}, nil
+}
+
+func (bc *Blockchain) applyItemTransfer(tx *Transaction) ([]Event, error) {
+	if err := bc.checkCollectionAction(tx); err != nil {
+		return nil, err
+	}
+	params, _ := decodeItem(tx)
+	if err := bc.Collections[tx.TokenID].Transfer(tx.From, params.Owner, tx.To, params.ID, tx.Amount); err != nil {
+		return nil, fmt.Errorf("item transfer failed: %v", err)
+	}
+
+	fmt.Printf("   📦 Moved %s of %s/%s from %s to %s\n", tx.Amount, tx.TokenID, params.ID, params.Owner, tx.To)
+	return []Event{{Type: EventItemTransfer, TokenID: tx.TokenID, From: params.Owner, To: tx.To, Amount: tx.Amount, ItemID: params.ID}}, nil
+}
+
+func (bc *Blockchain) applyItemBurn(tx *Transaction) ([]Event, error) {
+	if err := bc.checkCollectionAction(tx); err != nil {
+		return nil, err
+	}
+	params, _ := decodeItem(tx)
+	if err := bc.Collections[tx.TokenID].Burn(tx.From, params.Owner, params.ID, tx.Amount); err != nil {
+		return nil, fmt.Errorf("item burn failed: %v", err)
+	}
+
+	fmt.Printf("   🔥 Burned %s of %s/%s from %s\n", tx.Amount, tx.TokenID, params.ID, params.Owner)
+	return []Event{{Type: EventItemBurn, TokenID: tx.TokenID, From: params.Owner, Amount: tx.Amount, ItemID: params.ID}}, nil
+}
+
+// applyItemApprove sets the approved address of one non-fungible item, or
+// grants or revokes an operator for all of the sender's items
+func (bc *Blockchain) applyItemApprove(tx *Transaction) ([]Event, error) {
+	if err := bc.checkCollectionAction(tx); err != nil {
+		return nil, err
+	}
+	params, _ := decodeItem(tx)
+	c := bc.Collections[tx.TokenID]
+
+	if params.Operator != nil {
+		if err := c.SetOperator(tx.From, tx.To, *params.Operator); err != nil {
+			return nil, fmt.Errorf("operator approval failed: %v", err)
+		}
+		event := Event{Type: EventOperatorGranted, TokenID: tx.TokenID, From: tx.From, To: tx.To}
+		if !*params.Operator {
+			event.Type = EventOperatorRevoked
+		}
+		fmt.Printf("   🤝 %s operator %s on %s: %t\n", tx.From, tx.To, tx.TokenID, *params.Operator)
+		return []Event{event}, nil
+	}
+
+	owner, _ := c.OwnerOf(params.ID) // checked by CheckApprove
+	if err := c.Approve(tx.From, params.ID, tx.To); err != nil {
+		return nil, fmt.Errorf("item approval failed: %v", err)
+	}
+
+	fmt.Printf("   🤝 %s/%s approved for %q\n", tx.TokenID, params.ID, tx.To)
+	return []Event{{Type: EventItemApproval, TokenID: tx.TokenID, From: owner, To: tx.To, ItemID: params.ID}}, nil
+}
+
+// registerCollection adds a collection created by a transaction to the
+// registry. While a block is applied, the collection is journaled so a reorg
+// removes it again. Caller must hold bc.mu.
+func (bc *Blockchain) registerCollection(c *token.Collection) {
+	bc.Collections[c.Symbol] = c
+	if bc.stateStore != nil {
+		c.SetStore(bc.stateStore)
+	}
+	if bc.undo != nil {
+		bc.undo.createdCollections = append(bc.undo.createdCollections, c.Symbol)
+		c.SetJournal(bc.undo)
+	}
+}
+
+// CollectionInfo describes a collection
+type CollectionInfo struct {
+	Name   string               `json:"name"`
+	Symbol string               `json:"symbol"`
+	Kind   token.CollectionKind `json:"kind"`
+	Minter string               `json:"minter"`
+	Items  int                  `json:"items"`
+}
+
+// ItemInfo is an item with who holds it
+type ItemInfo struct {
+	Collection string `json:"collection"`
+	ID         string `json:"id"`
+	token.Item
+	Owner   string          `json:"owner,omitempty"` // only for non-fungible items
+	Holders []token.Holding `json:"holders"`
+}
+
+// GetCollection returns a collection's parameters and item count
+func (bc *Blockchain) GetCollection(symbol string) (*CollectionInfo, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	c, exists := bc.Collections[symbol]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrCollectionNotFound, symbol)
+	}
+	return &CollectionInfo{
+		Name:   c.Name,
+		Symbol: c.Symbol,
+		Kind:   c.Kind,
+		Minter: c.Minter(),
+		Items:  c.ItemCount(),
+	}, nil
+}
+
+// GetItem returns an item of a collection with its holders
+func (bc *Blockchain) GetItem(symbol, id string) (*ItemInfo, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	c, exists := bc.Collections[symbol]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrCollectionNotFound, symbol)
+	}
+	item, err := c.Item(id)
+	if err != nil {
+		return nil, err
+	}
+	info := &ItemInfo{Collection: symbol, ID: id, Item: *item, Holders: c.HoldersOf(id)}
+	if c.Kind == token.NonFungible {
+		info.Owner, _ = c.OwnerOf(id)
+	}
+	return info, nil
+}
+
+// ItemsOf lists the items of a collection an address holds
+func (bc *Blockchain) ItemsOf(symbol, address string) ([]token.Holding, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	c, exists := bc.Collections[symbol]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrCollectionNotFound, symbol)
+	}
+	return c.HoldingsOf(address), nil
+}
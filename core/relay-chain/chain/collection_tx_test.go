@@ -0,0 +1,175 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func signedCollectionCreate(t *testing.T, key *btcec.PrivateKey, nonce uint64, symbol string, params CollectionCreateParams) *Transaction {
+	tx, err := NewCollectionCreateTransaction("", symbol, params)
+	assert.Nil(t, err)
+	tx.Nonce = nonce
+	signTestTx(t, tx, key)
+	return tx
+}
+
+func signedItemTx(t *testing.T, key *btcec.PrivateKey, txType int, nonce uint64, symbol, to string, amount uint64, params ItemParams) *Transaction {
+	tx, err := NewItemTransaction(txType, "", to, symbol, uint256.NewInt(amount), params)
+	assert.Nil(t, err)
+	tx.Nonce = nonce
+	signTestTx(t, tx, key)
+	return tx
+}
+
+func TestCollectionLifecycle(t *testing.T) {
+	bc, key := newTestChain(t)
+	boxOffice := PublicKeyToAddress(key.PubKey())
+	fanKey, _ := btcec.NewPrivateKey()
+	fan := PublicKeyToAddress(fanKey.PubKey())
+
+	create := signedCollectionCreate(t, key, 0, "TIX", CollectionCreateParams{Name: "Tickets", Kind: token.NonFungible})
+	fund := signedTokenTx(t, key, TokenTransfer, 1, "BHX", fan, 200)
+	mint := signedItemTx(t, key, ItemMint, 2, "TIX", fan, 1, ItemParams{ID: "seat-1", URI: "ipfs://seat-1"})
+	assert.True(t, bc.AddBlock(nextBlock(bc, create, fund, mint)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, create))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, mint))
+
+	info, err := bc.GetCollection("TIX")
+	assert.Nil(t, err)
+	assert.Equal(t, &CollectionInfo{Name: "Tickets", Symbol: "TIX", Kind: token.NonFungible, Minter: boxOffice, Items: 1}, info)
+
+	receipt, _ := bc.GetReceipt(mint.ID)
+	assert.Contains(t, receipt.Events, Event{Type: EventItemMint, TokenID: "TIX", To: fan, Amount: uint256.NewInt(1), ItemID: "seat-1"})
+
+	t.Run("Only the minter mints", func(t *testing.T) {
+		steal := signedItemTx(t, fanKey, ItemMint, 0, "TIX", fan, 1, ItemParams{ID: "seat-2"})
+		assert.True(t, bc.AddBlock(nextBlock(bc, steal)))
+		assert.Equal(t, ReceiptFailed, receiptStatus(t, bc, steal))
+	})
+
+	t.Run("Approved address transfers for the owner", func(t *testing.T) {
+		approve := signedItemTx(t, fanKey, ItemApprove, 1, "TIX", boxOffice, 0, ItemParams{ID: "seat-1"})
+		resell := signedItemTx(t, key, ItemTransfer, 3, "TIX", "0xBob", 1, ItemParams{ID: "seat-1", Owner: fan})
+		assert.True(t, bc.AddBlock(nextBlock(bc, approve, resell)))
+		assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, approve))
+		assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, resell))
+
+		item, err := bc.GetItem("TIX", "seat-1")
+		assert.Nil(t, err)
+		assert.Equal(t, "0xBob", item.Owner)
+		assert.Equal(t, "", item.Approved)
+		holdings, _ := bc.ItemsOf("TIX", "0xBob")
+		assert.Equal(t, []token.Holding{{ID: "seat-1", Address: "0xBob", Amount: uint256.NewInt(1)}}, holdings)
+		holdings, _ = bc.ItemsOf("TIX", fan)
+		assert.Empty(t, holdings)
+	})
+
+	t.Run("Tokens and collections share symbols", func(t *testing.T) {
+		tokenCreate := signedTokenCreate(t, key, 4, "TIX", TokenCreateParams{Name: "Tix"}, 1)
+		collectionCreate := signedCollectionCreate(t, key, 5, "BHX", CollectionCreateParams{Name: "Hex", Kind: token.SemiFungible})
+		assert.True(t, bc.AddBlock(nextBlock(bc, tokenCreate, collectionCreate)))
+		assert.Equal(t, ReceiptFailed, receiptStatus(t, bc, tokenCreate))
+		assert.Equal(t, ReceiptFailed, receiptStatus(t, bc, collectionCreate))
+	})
+	assertStateTreeMatches(t, bc)
+}
+
+func TestCollectionReverts(t *testing.T) {
+	bc, key := newTestChain(t)
+	root := bc.currentStateTree().Root()
+
+	create := signedCollectionCreate(t, key, 0, "PASS", CollectionCreateParams{Name: "Passes", Kind: token.SemiFungible})
+	mint := signedItemTx(t, key, ItemMint, 1, "PASS", "0xBob", 10, ItemParams{ID: "day"})
+	assert.True(t, bc.AddBlock(nextBlock(bc, create, mint)))
+	afterCreate := bc.currentStateTree().Root()
+
+	operator := true
+	grant := signedItemTx(t, key, ItemApprove, 2, "PASS", "0xCarol", 0, ItemParams{Operator: &operator})
+	more := signedItemTx(t, key, ItemMint, 3, "PASS", "0xCarol", 5, ItemParams{ID: "day"})
+	assert.True(t, bc.AddBlock(nextBlock(bc, grant, more)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, grant))
+	assert.True(t, bc.Collections["PASS"].IsOperator(PublicKeyToAddress(key.PubKey()), "0xCarol"))
+
+	_, err := bc.disconnectTip()
+	assert.Nil(t, err)
+	assert.Equal(t, afterCreate, bc.currentStateTree().Root())
+	assert.False(t, bc.Collections["PASS"].IsOperator(PublicKeyToAddress(key.PubKey()), "0xCarol"))
+
+	_, err = bc.disconnectTip()
+	assert.Nil(t, err)
+	assert.NotContains(t, bc.Collections, "PASS")
+	assert.Equal(t, root, bc.currentStateTree().Root())
+	assertStateTreeMatches(t, bc)
+}
+
+func TestDecodeItem(t *testing.T) {
+	yes := true
+	cases := []struct {
+		name   string
+		txType int
+		to     string
+		amount uint64
+		params ItemParams
+		valid  bool
+	}{
+		{"mint", ItemMint, "0xBob", 1, ItemParams{ID: "seat-1", URI: "ipfs://x"}, true},
+		{"missing ID", ItemMint, "0xBob", 1, ItemParams{}, false},
+		{"bad ID", ItemTransfer, "0xBob", 1, ItemParams{ID: "a:b"}, false},
+		{"zero amount", ItemBurn, "", 0, ItemParams{ID: "seat-1"}, false},
+		{"clear approval", ItemApprove, "", 0, ItemParams{ID: "seat-1"}, true},
+		{"operator", ItemApprove, "0xBob", 0, ItemParams{Operator: &yes}, true},
+		{"operator without To", ItemApprove, "", 0, ItemParams{Operator: &yes}, false},
+		{"operator with ID", ItemApprove, "0xBob", 0, ItemParams{ID: "seat-1", Operator: &yes}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tx, err := NewItemTransaction(c.txType, "0xAlice", c.to, "TIX", uint256.NewInt(c.amount), c.params)
+			assert.Nil(t, err)
+			assert.Equal(t, c.valid, checkFields(tx) == nil)
+		})
+	}
+}
+
+func TestCollectionsSurviveRestart(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+	issuer := PublicKeyToAddress(key.PubKey())
+
+	g := testGenesis(t)
+	g.Tokens[0].Balances[issuer] = uint256.NewInt(1000)
+	validator := g.Validators[0].Address
+
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	bc, err := openChain(db, g, DefaultHistoryConfig())
+	assert.Nil(t, err)
+
+	create := signedCollectionCreate(t, key, 0, "TIX", CollectionCreateParams{Name: "Tickets", Kind: token.NonFungible})
+	mint := signedItemTx(t, key, ItemMint, 1, "TIX", "0xBob", 1, ItemParams{ID: "seat-1", URI: "ipfs://seat-1"})
+	assert.True(t, bc.AddBlock(blockBy(bc, validator, create, mint)))
+	root := bc.StateRoot()
+
+	ledger, err := bc.ExportCollection("TIX")
+	assert.Nil(t, err)
+	assert.Equal(t, &token.CollectionLedger{
+		Symbol:    "TIX",
+		Items:     []token.ItemEntry{{ID: "seat-1", Item: token.Item{URI: "ipfs://seat-1", Supply: uint256.NewInt(1)}}},
+		Holdings:  []token.Holding{{ID: "seat-1", Address: "0xBob", Amount: uint256.NewInt(1)}},
+		Operators: []token.OperatorEntry{},
+	}, ledger)
+
+	restarted, err := openChain(db, g, DefaultHistoryConfig())
+	assert.Nil(t, err)
+	assert.Equal(t, root, restarted.StateRoot())
+	owner, err := restarted.Collections["TIX"].OwnerOf("seat-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "0xBob", owner)
+}
@@ -0,0 +1,470 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/wasm"
+)
+
+// Limits on contract code and on what contracts pass through the host API
+const (
+	MaxContractCodeSize    = 128 << 10
+	MaxContractInputSize   = 16 << 10
+	MaxFunctionNameLength  = 64
+	MaxStorageKeyLength    = 256
+	MaxStorageValueLength  = 16 << 10
+	MaxContractStorageSize = 1 << 20 // bytes of keys and values one contract may store
+	MaxEventTopicLength    = 64
+	MaxEventDataLength     = 1024
+	MaxRevertReasonLength  = 256
+	MaxQueryResultSize     = 16 << 10
+)
+
+// Gas a contract pays for host functions, on top of the gas the interpreter
+// charges per instruction
+const (
+	HostCallGas         uint64 = 10 // every host function call
+	HostGasPerByte      uint64 = 1  // bytes copied between contract memory and the host
+	StorageReadGas      uint64 = 50
+	StorageWriteGas     uint64 = 100
+	StorageGasPerByte   uint64 = 10 // per byte a write adds to the contract's storage
+	ContractTransferGas uint64 = 50
+	ContractEventGas    uint64 = 20
+)
+
+// MaxQueryGas is the most gas a read-only contract query may use
+const MaxQueryGas uint64 = 1_000_000
+
+// ContractInitFunction is run when a contract is deployed, if the code exports it
+const ContractInitFunction = "init"
+
+// contractAddressDomain starts the payload hashed into a contract address
+const contractAddressDomain = "blackhole-contract"
+
+var (
+	ErrInvalidContractTx    = errors.New("invalid contract transaction")
+	ErrContractNotFound     = errors.New("contract not found")
+	ErrContractExists       = errors.New("contract already exists")
+	ErrContractReverted     = errors.New("contract reverted")
+	ErrContractStorageFull  = errors.New("contract storage full")
+	ErrContractReadOnly     = errors.New("contract state cannot change in a query")
+	ErrInvalidContractQuery = errors.New("invalid contract query")
+)
+
+// ContractDeployParams is the JSON payload of a ContractDeploy transaction.
+// Input is passed to the init function. Amount of TokenID, if any, is sent to
+// the new contract before init runs.
+type ContractDeployParams struct {
+	Code  []byte `json:"code"`
+	Input []byte `json:"input,omitempty"`
+}
+
+// ContractCallParams is the JSON payload of a SmartContractCall transaction.
+// Function must be exported by the contract and take no parameters and return
+// nothing; it reads Input through the host API. Amount of TokenID, if any, is
+// sent to the contract before the function runs.
+type ContractCallParams struct {
+	Function string `json:"function"`
+	Input    []byte `json:"input,omitempty"`
+}
+
+// Contract is deployed WebAssembly code with its own address and storage
+type Contract struct {
+	Address  string
+	CodeHash string // hex SHA-256 of the code
+	Creator  string
+	storage  map[string][]byte
+	size     int              // bytes of keys and values in storage
+	store    *blockStateStore // persists storage changes, if set
+}
+
+// newContract creates a contract with empty storage, persisted through store if not nil
+func newContract(address, codeHash, creator string, store *blockStateStore) *Contract {
+	return &Contract{Address: address, CodeHash: codeHash, Creator: creator, storage: make(map[string][]byte), store: store}
+}
+
+// storageSize returns the bytes a storage entry counts against the contract's limit
+func storageSize(key string, value []byte) int {
+	if len(value) == 0 {
+		return 0
+	}
+	return len(key) + len(value)
+}
+
+// put sets key to value, deleting it if value is empty, and persists the change
+func (c *Contract) put(key string, value []byte) {
+	c.size += storageSize(key, value) - storageSize(key, c.storage[key])
+	if len(value) == 0 {
+		delete(c.storage, key)
+	} else {
+		c.storage[key] = value
+	}
+	if c.store != nil {
+		c.store.PutContractStorage(c.Address, key, value)
+	}
+}
+
+// ContractInfo describes a deployed contract
+type ContractInfo struct {
+	Address     string   `json:"address"`
+	CodeHash    string   `json:"code_hash"`
+	Creator     string   `json:"creator"`
+	CodeSize    int      `json:"code_size"`
+	StorageSize int      `json:"storage_size"` // bytes of keys and values, at most MaxContractStorageSize
+	Functions   []string `json:"functions"`    // exported functions transactions can call
+}
+
+// ContractAddress returns the address of the contract creator deploys with
+// its transaction of the given nonce
+func ContractAddress(creator string, nonce uint64) string {
+	var e encoder
+	e.string(contractAddressDomain)
+	e.string(creator)
+	e.uint64(nonce)
+	hash := sha256.Sum256(e.buf.Bytes())
+	return "0x" + hex.EncodeToString(hash[:20])
+}
+
+func codeHash(code []byte) string {
+	hash := sha256.Sum256(code)
+	return hex.EncodeToString(hash[:])
+}
+
+// NewContractDeployTransaction builds an unsigned transaction that deploys
+// code, sending value of token to the contract. The gas limit covers the
+// intrinsic gas plus gas for running init.
+func NewContractDeployTransaction(from string, params ContractDeployParams, token string, value uint256.Int, gas uint64) (*Transaction, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(ContractDeploy, from, "", value, nil)
+	tx.TokenID = token
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx) + gas
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// NewContractCallTransaction builds an unsigned transaction that calls a
+// contract function, sending value of token to the contract. The gas limit
+// covers the intrinsic gas plus gas for running the function.
+func NewContractCallTransaction(from, contract string, params ContractCallParams, token string, value uint256.Int, gas uint64) (*Transaction, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(SmartContractCall, from, contract, value, nil)
+	tx.TokenID = token
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx) + gas
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// isContractTx reports whether txType runs contract code
+func isContractTx(txType int) bool {
+	return txType == ContractDeploy || txType == SmartContractCall
+}
+
+// checkContractValue checks the token and amount a contract transaction sends
+func checkContractValue(tx *Transaction) error {
+	if !tx.Amount.IsZero() && tx.TokenID == "" {
+		return fmt.Errorf("%w: a value needs a token", ErrInvalidContractTx)
+	}
+	return nil
+}
+
+// decodeContractDeploy parses a deploy transaction and decodes its code,
+// which may only import functions of the contract host API
+func decodeContractDeploy(tx *Transaction) (*ContractDeployParams, *wasm.Module, error) {
+	var params ContractDeployParams
+	if err := json.Unmarshal(tx.Data, &params); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidContractTx, err)
+	}
+	if len(params.Code) == 0 || len(params.Code) > MaxContractCodeSize {
+		return nil, nil, fmt.Errorf("%w: code must be 1-%d bytes", ErrInvalidContractTx, MaxContractCodeSize)
+	}
+	if len(params.Input) > MaxContractInputSize {
+		return nil, nil, fmt.Errorf("%w: input exceeds %d bytes", ErrInvalidContractTx, MaxContractInputSize)
+	}
+	if err := checkContractValue(tx); err != nil {
+		return nil, nil, err
+	}
+	module, err := wasm.Decode(params.Code)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidContractTx, err)
+	}
+	if err := module.CheckImports((*contractExecution)(nil).hostFuncs()); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidContractTx, err)
+	}
+	if ft, ok := module.ExportedFunction(ContractInitFunction); ok && !callable(ft) {
+		return nil, nil, fmt.Errorf("%w: %s must take no parameters and return nothing", ErrInvalidContractTx, ContractInitFunction)
+	}
+	return &params, module, nil
+}
+
+// decodeContractCall parses a call transaction
+func decodeContractCall(tx *Transaction) (*ContractCallParams, error) {
+	var params ContractCallParams
+	if err := json.Unmarshal(tx.Data, &params); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidContractTx, err)
+	}
+	if err := checkContractCall(params.Function, params.Input); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidContractTx, err)
+	}
+	if err := checkContractValue(tx); err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+// checkContractCall checks the function name and input of a call or query
+func checkContractCall(function string, input []byte) error {
+	if function == "" || len(function) > MaxFunctionNameLength {
+		return fmt.Errorf("function name must be 1-%d bytes", MaxFunctionNameLength)
+	}
+	if function == ContractInitFunction {
+		return fmt.Errorf("%s only runs on deploy", ContractInitFunction)
+	}
+	if len(input) > MaxContractInputSize {
+		return fmt.Errorf("input exceeds %d bytes", MaxContractInputSize)
+	}
+	return nil
+}
+
+// callable reports whether transactions can call a function of type ft
+func callable(ft wasm.FuncType) bool {
+	return len(ft.Params) == 0 && len(ft.Results) == 0
+}
+
+// checkContractAction runs the checks of deploy and call transactions that
+// depend on chain state. Caller must hold bc.mu.
+func (bc *Blockchain) checkContractAction(tx *Transaction) error {
+	if tx.Type == SmartContractCall {
+		params, err := decodeContractCall(tx)
+		if err != nil {
+			return err
+		}
+		c, exists := bc.Contracts[tx.To]
+		if !exists {
+			return fmt.Errorf("%w: %s", ErrContractNotFound, tx.To)
+		}
+		if ft, ok := bc.contractModules[c.CodeHash].ExportedFunction(params.Function); !ok || !callable(ft) {
+			return fmt.Errorf("%w: %s has no callable function %s", ErrInvalidContractTx, tx.To, params.Function)
+		}
+	}
+	if tx.Amount.IsZero() {
+		return nil
+	}
+	tk, exists := bc.TokenRegistry[tx.TokenID]
+	if !exists {
+		return fmt.Errorf("token %s not found", tx.TokenID)
+	}
+	balance, err := tk.Spendable(tx.From)
+	if err != nil {
+		return fmt.Errorf("failed to get token balance: %v", err)
+	}
+	if balance.Lt(tx.Amount) {
+		return fmt.Errorf("insufficient token balance: has %s, needs %s", balance, tx.Amount)
+	}
+	return nil
+}
+
+// applyContract deploys or calls a contract with the gas left after the
+// intrinsic gas, and returns the events and the total gas used. If execution
+// fails, everything it changed is rolled back; the sender still pays for the
+// gas used. Caller must hold bc.mu.
+func (bc *Blockchain) applyContract(tx *Transaction) ([]Event, uint64, error) {
+	intrinsic := IntrinsicGas(tx)
+	var gas uint64
+	if tx.GasLimit > intrinsic {
+		gas = tx.GasLimit - intrinsic
+	}
+
+	outer, undo := bc.beginTxUndo()
+	var events []Event
+	var used uint64
+	var err error
+	if tx.Type == ContractDeploy {
+		events, used, err = bc.deployContract(tx, gas)
+	} else {
+		events, used, err = bc.callContract(tx, gas)
+	}
+	bc.endTxUndo(outer, undo, err != nil)
+	return events, intrinsic + used, err
+}
+
+func (bc *Blockchain) deployContract(tx *Transaction, gas uint64) ([]Event, uint64, error) {
+	params, module, err := decodeContractDeploy(tx)
+	if err != nil {
+		return nil, 0, err
+	}
+	address := ContractAddress(tx.From, tx.Nonce)
+	if _, exists := bc.Contracts[address]; exists {
+		return nil, 0, fmt.Errorf("%w: %s", ErrContractExists, address)
+	}
+
+	hash := codeHash(params.Code)
+	if _, stored := bc.contractCode[hash]; !stored {
+		bc.contractCode[hash] = params.Code
+		bc.contractModules[hash] = module
+		if bc.undo != nil {
+			bc.undo.storedCode = append(bc.undo.storedCode, hash)
+		}
+	}
+	c := newContract(address, hash, tx.From, bc.stateStore)
+	bc.Contracts[address] = c
+	if bc.undo != nil {
+		bc.undo.createdContracts = append(bc.undo.createdContracts, address)
+	}
+	fmt.Printf("   📜 Deployed contract %s (code %s) for %s\n", address, hash, tx.From)
+
+	events := []Event{{Type: EventContractDeployed, From: tx.From, To: address}}
+	function := ""
+	if _, ok := module.ExportedFunction(ContractInitFunction); ok {
+		function = ContractInitFunction
+	}
+	more, used, err := bc.runContract(tx, c, function, params.Input, gas)
+	return append(events, more...), used, err
+}
+
+func (bc *Blockchain) callContract(tx *Transaction, gas uint64) ([]Event, uint64, error) {
+	if err := bc.checkContractAction(tx); err != nil {
+		return nil, 0, err
+	}
+	params, err := decodeContractCall(tx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bc.runContract(tx, bc.Contracts[tx.To], params.Function, params.Input, gas)
+}
+
+// runContract sends the value of tx to c and then runs function, if not
+// empty, with at most gas gas. It returns the gas execution used.
+func (bc *Blockchain) runContract(tx *Transaction, c *Contract, function string, input []byte, gas uint64) ([]Event, uint64, error) {
+	x := &contractExecution{bc: bc, contract: c, caller: tx.From, token: tx.TokenID, value: tx.Amount, input: input}
+	if !tx.Amount.IsZero() {
+		tk, exists := bc.TokenRegistry[tx.TokenID]
+		if !exists {
+			return nil, 0, fmt.Errorf("token %s not found", tx.TokenID)
+		}
+		if err := tk.Transfer(tx.From, c.Address, tx.Amount); err != nil {
+			return nil, 0, fmt.Errorf("contract value transfer failed: %v", err)
+		}
+		x.events = append(x.events, Event{Type: EventTransfer, TokenID: tx.TokenID, From: tx.From, To: c.Address, Amount: tx.Amount})
+	}
+	if function == "" {
+		return x.events, 0, nil
+	}
+
+	inst, err := wasm.Instantiate(bc.contractModules[c.CodeHash], x.hostFuncs(), gas)
+	if err != nil {
+		// Code that cannot even be set up uses all its gas
+		return nil, gas, fmt.Errorf("contract %s failed: %w", c.Address, err)
+	}
+	if _, err := inst.Call(function); err != nil {
+		return nil, inst.GasUsed(), fmt.Errorf("contract %s failed: %w", c.Address, err)
+	}
+	fmt.Printf("   📜 Ran %s on contract %s for %s, %d gas\n", function, c.Address, tx.From, inst.GasUsed())
+	return x.events, inst.GasUsed(), nil
+}
+
+// setContractStorage sets a key of a contract's storage, deleting it if value
+// is empty. Caller must hold bc.mu.
+func (bc *Blockchain) setContractStorage(c *Contract, key string, value []byte) {
+	if bc.undo != nil {
+		bc.undo.storageChanged(c.Address, key, c.storage[key])
+	}
+	c.put(key, value)
+}
+
+// GetContract returns a deployed contract's code hash, creator and callable functions
+func (bc *Blockchain) GetContract(address string) (*ContractInfo, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	c, exists := bc.Contracts[address]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrContractNotFound, address)
+	}
+	module := bc.contractModules[c.CodeHash]
+	info := &ContractInfo{
+		Address:     c.Address,
+		CodeHash:    c.CodeHash,
+		Creator:     c.Creator,
+		CodeSize:    len(bc.contractCode[c.CodeHash]),
+		StorageSize: c.size,
+		Functions:   []string{},
+	}
+	for _, name := range module.Exports() {
+		if ft, _ := module.ExportedFunction(name); callable(ft) && name != ContractInitFunction {
+			info.Functions = append(info.Functions, name)
+		}
+	}
+	sort.Strings(info.Functions)
+	return info, nil
+}
+
+// GetContractCode returns the code stored under a hex SHA-256 hash
+func (bc *Blockchain) GetContractCode(hash string) ([]byte, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	code, exists := bc.contractCode[hash]
+	if !exists {
+		return nil, fmt.Errorf("%w: no code with hash %s", ErrContractNotFound, hash)
+	}
+	return append([]byte(nil), code...), nil
+}
+
+// ContractQueryResult is what a read-only contract query returned
+type ContractQueryResult struct {
+	Output  []byte `json:"output"`   // data the function passed to result
+	GasUsed uint64 `json:"gas_used"` // gas the function used, including memory setup
+}
+
+// QueryContract runs a contract function against the current state without a
+// transaction, as a view for UIs. The function sees caller as its caller and
+// no value, and runs with at most gas gas, or MaxQueryGas if gas is 0. Storage
+// writes, transfers and events fail the query with ErrContractReadOnly, so it
+// cannot change state.
+func (bc *Blockchain) QueryContract(address, function string, input []byte, caller string, gas uint64) (*ContractQueryResult, error) {
+	if err := checkContractCall(function, input); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidContractQuery, err)
+	}
+	if gas == 0 {
+		gas = MaxQueryGas
+	}
+	if gas > MaxQueryGas {
+		return nil, fmt.Errorf("%w: gas exceeds %d", ErrInvalidContractQuery, MaxQueryGas)
+	}
+
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	c, exists := bc.Contracts[address]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrContractNotFound, address)
+	}
+	module := bc.contractModules[c.CodeHash]
+	if ft, ok := module.ExportedFunction(function); !ok || !callable(ft) {
+		return nil, fmt.Errorf("%w: %s has no callable function %s", ErrInvalidContractQuery, address, function)
+	}
+
+	x := &contractExecution{bc: bc, contract: c, caller: caller, input: input, readOnly: true}
+	inst, err := wasm.Instantiate(module, x.hostFuncs(), gas)
+	if err != nil {
+		return nil, fmt.Errorf("contract %s failed: %w", address, err)
+	}
+	if _, err := inst.Call(function); err != nil {
+		return nil, fmt.Errorf("contract %s failed: %w", address, err)
+	}
+	return &ContractQueryResult{Output: x.result, GasUsed: inst.GasUsed()}, nil
+}
@@ -0,0 +1,302 @@
+package chain
+
+import (
+	"fmt"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/wasm"
+)
+
+// The host API contracts import from the "env" module. Pointers and lengths
+// are i32 offsets into contract memory. Functions that return a variable
+// length value take an output buffer and its capacity, copy the value only if
+// it fits, and return its length so the contract can retry with a larger
+// buffer. Amounts are 32 byte big-endian integers.
+//
+//	storage_read(key, key_len, out, cap) -> i32    value length, or -1 if unset
+//	storage_write(key, key_len, value, value_len)  an empty value deletes the key; each
+//	                                               byte added to storage costs StorageGasPerByte
+//	caller(out, cap) -> i32                        sender of the transaction
+//	address(out, cap) -> i32                       the contract's own address
+//	input(out, cap) -> i32                         input of the deploy or call
+//	value(out)                                     amount sent with the transaction
+//	value_token(out, cap) -> i32                   token of that amount
+//	balance(token, token_len, addr, addr_len, out) spendable balance of an account
+//	transfer(token, token_len, to, to_len, amount) -> i32  0 on success, 1 if the
+//	                                               contract's own transfer failed
+//	emit(topic, topic_len, data, data_len)         adds a ContractEvent to the receipt
+//	block_height() -> i64
+//	block_time() -> i64                            Unix seconds
+//	revert(reason, reason_len)                     fails the transaction
+//	result(data, data_len)                         sets what a read-only query returns
+//
+// In a read-only query storage_write, transfer and emit fail the call.
+
+// contractExecution is the state one contract execution reaches through the host API
+type contractExecution struct {
+	bc       *Blockchain
+	contract *Contract
+	caller   string
+	token    string
+	value    uint256.Int
+	input    []byte
+	events   []Event
+	readOnly bool   // a query, which must not change state
+	result   []byte // set by the result host function
+}
+
+func hostType(params int, results ...wasm.ValueType) wasm.FuncType {
+	ft := wasm.FuncType{Results: results}
+	for i := 0; i < params; i++ {
+		ft.Params = append(ft.Params, wasm.I32)
+	}
+	return ft
+}
+
+// hostFuncs returns the host API bound to x. Only the signatures are used when
+// x is nil.
+func (x *contractExecution) hostFuncs() map[string]wasm.HostFunc {
+	return map[string]wasm.HostFunc{
+		"storage_read":  {Type: hostType(4, wasm.I32), Call: x.storageRead},
+		"storage_write": {Type: hostType(4), Call: x.storageWrite},
+		"caller":        {Type: hostType(2, wasm.I32), Call: x.callerAddress},
+		"address":       {Type: hostType(2, wasm.I32), Call: x.selfAddress},
+		"input":         {Type: hostType(2, wasm.I32), Call: x.inputData},
+		"value":         {Type: hostType(1), Call: x.valueAmount},
+		"value_token":   {Type: hostType(2, wasm.I32), Call: x.valueToken},
+		"balance":       {Type: hostType(5), Call: x.balance},
+		"transfer":      {Type: hostType(5, wasm.I32), Call: x.transfer},
+		"emit":          {Type: hostType(4), Call: x.emit},
+		"block_height":  {Type: hostType(0, wasm.I64), Call: x.blockHeight},
+		"block_time":    {Type: hostType(0, wasm.I64), Call: x.blockTime},
+		"revert":        {Type: hostType(2), Call: x.revert},
+		"result":        {Type: hostType(2), Call: x.setResult},
+	}
+}
+
+// read charges for and copies n bytes at ptr out of contract memory, failing
+// if n exceeds max
+func read(inst *wasm.Instance, ptr, n uint64, max int, what string) ([]byte, error) {
+	if n > uint64(max) {
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", ErrContractReverted, what, max)
+	}
+	if err := inst.UseGas(n * HostGasPerByte); err != nil {
+		return nil, err
+	}
+	return inst.Read(uint32(ptr), uint32(n))
+}
+
+// output copies value to the buffer at ptr if it fits in capacity and returns its length
+func output(inst *wasm.Instance, ptr, capacity uint64, value []byte) ([]uint64, error) {
+	if uint64(len(value)) <= capacity {
+		if err := inst.UseGas(uint64(len(value)) * HostGasPerByte); err != nil {
+			return nil, err
+		}
+		if err := inst.Write(uint32(ptr), value); err != nil {
+			return nil, err
+		}
+	}
+	return []uint64{uint64(len(value))}, nil
+}
+
+// checkWritable fails a host function that changes state during a query
+func (x *contractExecution) checkWritable(what string) error {
+	if x.readOnly {
+		return fmt.Errorf("%w: %s", ErrContractReadOnly, what)
+	}
+	return nil
+}
+
+func writeAmount(inst *wasm.Instance, ptr uint64, amount uint256.Int) error {
+	b := amount.Bytes32()
+	return inst.Write(uint32(ptr), b[:])
+}
+
+func (x *contractExecution) storageRead(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := inst.UseGas(HostCallGas + StorageReadGas); err != nil {
+		return nil, err
+	}
+	key, err := read(inst, args[0], args[1], MaxStorageKeyLength, "storage key")
+	if err != nil {
+		return nil, err
+	}
+	value, ok := x.contract.storage[string(key)]
+	if !ok {
+		return []uint64{0xffffffff}, nil
+	}
+	return output(inst, args[2], args[3], value)
+}
+
+func (x *contractExecution) storageWrite(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := x.checkWritable("storage write"); err != nil {
+		return nil, err
+	}
+	if err := inst.UseGas(HostCallGas + StorageWriteGas); err != nil {
+		return nil, err
+	}
+	key, err := read(inst, args[0], args[1], MaxStorageKeyLength, "storage key")
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%w: empty storage key", ErrContractReverted)
+	}
+	value, err := read(inst, args[2], args[3], MaxStorageValueLength, "storage value")
+	if err != nil {
+		return nil, err
+	}
+
+	c := x.contract
+	size := c.size + storageSize(string(key), value) - storageSize(string(key), c.storage[string(key)])
+	if size > MaxContractStorageSize {
+		return nil, fmt.Errorf("%w: %s would hold %d bytes, more than %d", ErrContractStorageFull, c.Address, size, MaxContractStorageSize)
+	}
+	if size > c.size {
+		if err := inst.UseGas(uint64(size-c.size) * StorageGasPerByte); err != nil {
+			return nil, err
+		}
+	}
+	x.bc.setContractStorage(c, string(key), value)
+	return nil, nil
+}
+
+func (x *contractExecution) callerAddress(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := inst.UseGas(HostCallGas); err != nil {
+		return nil, err
+	}
+	return output(inst, args[0], args[1], []byte(x.caller))
+}
+
+func (x *contractExecution) selfAddress(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := inst.UseGas(HostCallGas); err != nil {
+		return nil, err
+	}
+	return output(inst, args[0], args[1], []byte(x.contract.Address))
+}
+
+func (x *contractExecution) inputData(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := inst.UseGas(HostCallGas); err != nil {
+		return nil, err
+	}
+	return output(inst, args[0], args[1], x.input)
+}
+
+func (x *contractExecution) valueAmount(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := inst.UseGas(HostCallGas); err != nil {
+		return nil, err
+	}
+	return nil, writeAmount(inst, args[0], x.value)
+}
+
+func (x *contractExecution) valueToken(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := inst.UseGas(HostCallGas); err != nil {
+		return nil, err
+	}
+	return output(inst, args[0], args[1], []byte(x.token))
+}
+
+func (x *contractExecution) balance(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := inst.UseGas(HostCallGas); err != nil {
+		return nil, err
+	}
+	symbol, err := read(inst, args[0], args[1], MaxTokenSymbolLength, "token")
+	if err != nil {
+		return nil, err
+	}
+	address, err := read(inst, args[2], args[3], MaxStorageKeyLength, "address")
+	if err != nil {
+		return nil, err
+	}
+	var balance uint256.Int
+	if tk, exists := x.bc.TokenRegistry[string(symbol)]; exists {
+		// An invalid address has no balance
+		balance, _ = tk.Spendable(string(address))
+	}
+	return nil, writeAmount(inst, args[4], balance)
+}
+
+func (x *contractExecution) transfer(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := x.checkWritable("transfer"); err != nil {
+		return nil, err
+	}
+	if err := inst.UseGas(HostCallGas + ContractTransferGas); err != nil {
+		return nil, err
+	}
+	symbol, err := read(inst, args[0], args[1], MaxTokenSymbolLength, "token")
+	if err != nil {
+		return nil, err
+	}
+	to, err := read(inst, args[2], args[3], MaxStorageKeyLength, "recipient")
+	if err != nil {
+		return nil, err
+	}
+	b, err := inst.Read(uint32(args[4]), 32)
+	if err != nil {
+		return nil, err
+	}
+	var raw [32]byte
+	copy(raw[:], b)
+	amount := uint256.FromBytes32(raw)
+
+	tk, exists := x.bc.TokenRegistry[string(symbol)]
+	if !exists || amount.IsZero() {
+		return []uint64{1}, nil
+	}
+	if err := tk.Transfer(x.contract.Address, string(to), amount); err != nil {
+		return []uint64{1}, nil
+	}
+	x.events = append(x.events, Event{Type: EventTransfer, TokenID: string(symbol), From: x.contract.Address, To: string(to), Amount: amount})
+	return []uint64{0}, nil
+}
+
+func (x *contractExecution) emit(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := x.checkWritable("event"); err != nil {
+		return nil, err
+	}
+	if err := inst.UseGas(HostCallGas + ContractEventGas); err != nil {
+		return nil, err
+	}
+	topic, err := read(inst, args[0], args[1], MaxEventTopicLength, "event topic")
+	if err != nil {
+		return nil, err
+	}
+	data, err := read(inst, args[2], args[3], MaxEventDataLength, "event data")
+	if err != nil {
+		return nil, err
+	}
+	x.events = append(x.events, Event{Type: EventContract, From: x.contract.Address, Topic: string(topic), Data: data})
+	return nil, nil
+}
+
+func (x *contractExecution) blockHeight(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	height, _ := x.bc.clock.Now()
+	return []uint64{height}, inst.UseGas(HostCallGas)
+}
+
+func (x *contractExecution) blockTime(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	_, unix := x.bc.clock.Now()
+	return []uint64{uint64(unix)}, inst.UseGas(HostCallGas)
+}
+
+func (x *contractExecution) revert(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := inst.UseGas(HostCallGas); err != nil {
+		return nil, err
+	}
+	reason, err := read(inst, args[0], args[1], MaxRevertReasonLength, "revert reason")
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: %s", ErrContractReverted, reason)
+}
+
+func (x *contractExecution) setResult(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := inst.UseGas(HostCallGas); err != nil {
+		return nil, err
+	}
+	result, err := read(inst, args[0], args[1], MaxQueryResultSize, "result")
+	if err != nil {
+		return nil, err
+	}
+	x.result = result
+	return nil, nil
+}
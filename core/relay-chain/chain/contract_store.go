@@ -0,0 +1,39 @@
+package chain
+
+import (
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// LevelDB key prefix of persisted contract storage. Each contract's keys live
+// under its own address, and addresses never contain ':', so contracts cannot
+// reach each other's storage.
+const ledgerContractStoragePrefix = "contractkv:" // contractkv:<address>:<key>
+
+func ledgerContractStorageKey(address, key string) string {
+	return ledgerContractStoragePrefix + address + ":" + key
+}
+
+// PutContractStorage records a change to a contract's storage. An empty value
+// deletes the key.
+func (s *blockStateStore) PutContractStorage(address, key string, value []byte) {
+	s.set(ledgerContractStorageKey(address, key), append([]byte(nil), value...))
+}
+
+// GetContractStorage returns the value of a key in a contract's storage as
+// persisted with the last block, or nil if the key is unset
+func (bc *Blockchain) GetContractStorage(address, key string) ([]byte, error) {
+	bc.mu.RLock()
+	_, exists := bc.Contracts[address]
+	bc.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrContractNotFound, address)
+	}
+
+	value, err := bc.DB.Get([]byte(ledgerContractStorageKey(address, key)), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	return value, err
+}
@@ -0,0 +1,321 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/wasm"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+// wasmVec prefixes items with their count, each item already encoded
+func wasmVec(items ...[]byte) []byte {
+	out := []byte{byte(len(items))}
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func wasmName(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+// wasmSized prefixes b with its length as an unsigned LEB128, for sections and
+// function bodies
+func wasmSized(b ...byte) []byte {
+	var size []byte
+	for n := len(b); ; n >>= 7 {
+		if n < 0x80 {
+			size = append(size, byte(n))
+			break
+		}
+		size = append(size, byte(n&0x7f)|0x80)
+	}
+	return append(size, b...)
+}
+
+func wasmImport(name string, typ byte) []byte {
+	return append(append(wasmName("env"), wasmName(name)...), 0x00, typ)
+}
+
+func wasmExport(name string, idx byte) []byte {
+	return append(wasmName(name), 0x00, idx)
+}
+
+func wasmData(offset []byte, data string) []byte {
+	return append(append(append([]byte{0x00, 0x41}, offset...), 0x0b), wasmName(data)...)
+}
+
+// escrowContract is a hand-assembled contract. init stores its caller as
+// "owner"; store saves its input as "data" and emits it as a "stored" event;
+// pay sends 5 BHX to the address in its input; fail writes storage and then
+// reverts with "nope"; spin loops forever; get returns "data" as its result.
+func escrowContract() []byte {
+	i32 := byte(0x7f)
+	code := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	sections := [][]byte{
+		append([]byte{0x01}, wasmSized(wasmVec(
+			[]byte{0x60, 0x04, i32, i32, i32, i32, 0x00},
+			[]byte{0x60, 0x02, i32, i32, 0x01, i32},
+			[]byte{0x60, 0x05, i32, i32, i32, i32, i32, 0x01, i32},
+			[]byte{0x60, 0x02, i32, i32, 0x00},
+			[]byte{0x60, 0x00, 0x00},
+			[]byte{0x60, 0x04, i32, i32, i32, i32, 0x01, i32},
+		)...)...),
+		append([]byte{0x02}, wasmSized(wasmVec(
+			wasmImport("storage_write", 0),
+			wasmImport("input", 1),
+			wasmImport("transfer", 2),
+			wasmImport("emit", 0),
+			wasmImport("revert", 3),
+			wasmImport("caller", 1),
+			wasmImport("storage_read", 5),
+			wasmImport("result", 3),
+		)...)...),
+		{0x03, 0x07, 0x06, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+		{0x05, 0x03, 0x01, 0x00, 0x01},
+		append([]byte{0x07}, wasmSized(wasmVec(
+			wasmExport("init", 8), wasmExport("store", 9), wasmExport("pay", 10), wasmExport("fail", 11), wasmExport("spin", 12), wasmExport("get", 13),
+		)...)...),
+		append([]byte{0x0a}, wasmSized(wasmVec(
+			wasmSized(0x00, 0x41, 0x10, 0x41, 0x05, 0x41, 0xc0, 0x00, 0x41, 0xc0, 0x00, 0x41, 0xe4, 0x00, 0x10, 0x05, 0x10, 0x00, 0x0b),
+			wasmSized(0x01, 0x01, i32, 0x41, 0x80, 0x02, 0x41, 0x80, 0x08, 0x10, 0x01, 0x21, 0x00,
+				0x41, 0x20, 0x41, 0x04, 0x41, 0x80, 0x02, 0x20, 0x00, 0x10, 0x00,
+				0x41, 0x30, 0x41, 0x06, 0x41, 0x80, 0x02, 0x20, 0x00, 0x10, 0x03, 0x0b),
+			wasmSized(0x00, 0x41, 0x00, 0x41, 0x03, 0x41, 0x80, 0x02, 0x41, 0x80, 0x02, 0x41, 0x80, 0x01, 0x10, 0x01,
+				0x41, 0x80, 0x04, 0x10, 0x02, 0x04, 0x40, 0x41, 0xe0, 0x00, 0x41, 0x0f, 0x10, 0x04, 0x0b, 0x0b),
+			wasmSized(0x00, 0x41, 0x20, 0x41, 0x04, 0x41, 0x30, 0x41, 0x06, 0x10, 0x00, 0x41, 0xd0, 0x00, 0x41, 0x04, 0x10, 0x04, 0x0b),
+			wasmSized(0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b),
+			wasmSized(0x00, 0x41, 0x80, 0x02, 0x41, 0x20, 0x41, 0x04, 0x41, 0x80, 0x02, 0x41, 0x80, 0x08, 0x10, 0x06, 0x10, 0x07, 0x0b),
+		)...)...),
+		append([]byte{0x0b}, wasmSized(wasmVec(
+			wasmData([]byte{0x00}, "BHX"),
+			wasmData([]byte{0x10}, "owner"),
+			wasmData([]byte{0x20}, "data"),
+			wasmData([]byte{0x30}, "stored"),
+			wasmData([]byte{0xd0, 0x00}, "nope"),
+			wasmData([]byte{0xe0, 0x00}, "transfer failed"),
+			wasmData([]byte{0x9f, 0x04}, "\x05"), // the last byte of a 32 byte amount at 512
+		)...)...),
+	}
+	for _, s := range sections {
+		code = append(code, s...)
+	}
+	return code
+}
+
+func signedContractCall(t *testing.T, key *btcec.PrivateKey, nonce uint64, contract, function string, input []byte, gas uint64) *Transaction {
+	tx, err := NewContractCallTransaction("", contract, ContractCallParams{Function: function, Input: input}, "", uint256.Int{}, gas)
+	assert.Nil(t, err)
+	tx.Nonce = nonce
+	signTestTx(t, tx, key)
+	return tx
+}
+
+func TestContractLifecycle(t *testing.T) {
+	bc, key := newTestChain(t)
+	deployer := PublicKeyToAddress(key.PubKey())
+	assert.Nil(t, bc.TokenRegistry["BHX"].Mint(deployer, uint256.NewInt(100000)))
+	root := bc.currentStateTree().Root()
+
+	deploy, err := NewContractDeployTransaction("", ContractDeployParams{Code: escrowContract()}, "BHX", uint256.NewInt(10), 5000)
+	assert.Nil(t, err)
+	signTestTx(t, deploy, key)
+	assert.True(t, bc.AddBlock(nextBlock(bc, deploy)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, deploy))
+
+	address := ContractAddress(deployer, 0)
+	receipt, _ := bc.GetReceipt(deploy.ID)
+	assert.Contains(t, receipt.Events, Event{Type: EventContractDeployed, From: deployer, To: address})
+	assert.Greater(t, receipt.GasUsed, IntrinsicGas(deploy))
+	assert.Less(t, receipt.GasUsed, deploy.GasLimit)
+	assert.Equal(t, uint256.NewInt(receipt.GasUsed), receipt.Fee)
+
+	info, err := bc.GetContract(address)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"fail", "get", "pay", "spin", "store"}, info.Functions)
+	assert.Equal(t, len("owner")+len(deployer), info.StorageSize)
+	assert.Equal(t, deployer, info.Creator)
+	code, err := bc.GetContractCode(info.CodeHash)
+	assert.Nil(t, err)
+	assert.Equal(t, escrowContract(), code)
+	assert.Equal(t, []byte(deployer), bc.Contracts[address].storage["owner"])
+	assert.Equal(t, uint256.NewInt(10), bhxBalance(bc, address))
+	afterDeploy := bc.currentStateTree().Root()
+
+	store := signedContractCall(t, key, 1, address, "store", []byte("hello"), 1000)
+	pay := signedContractCall(t, key, 2, address, "pay", []byte("0xBob"), 1000)
+	assert.True(t, bc.AddBlock(nextBlock(bc, store, pay)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, store))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, pay))
+	assert.Equal(t, []byte("hello"), bc.Contracts[address].storage["data"])
+	receipt, _ = bc.GetReceipt(store.ID)
+	assert.Contains(t, receipt.Events, Event{Type: EventContract, From: address, Topic: "stored", Data: []byte("hello")})
+	assert.Equal(t, uint256.NewInt(5), bhxBalance(bc, address))
+	assert.Equal(t, uint256.NewInt(5), bhxBalance(bc, "0xBob"))
+
+	t.Run("Revert rolls back storage but charges gas", func(t *testing.T) {
+		before := bhxBalance(bc, deployer)
+		fail := signedContractCall(t, key, 3, address, "fail", nil, 1000)
+		assert.True(t, bc.AddBlock(nextBlock(bc, fail)))
+		receipt, _ := bc.GetReceipt(fail.ID)
+		assert.Equal(t, ReceiptFailed, receipt.Status)
+		assert.Contains(t, receipt.Error, "contract reverted: nope")
+		assert.Equal(t, []byte("hello"), bc.Contracts[address].storage["data"])
+		assert.NotContains(t, bc.Contracts[address].storage, "stored")
+		assert.False(t, receipt.Fee.IsZero())
+		after, _ := before.Sub(receipt.Fee)
+		assert.Equal(t, after, bhxBalance(bc, deployer))
+	})
+
+	t.Run("Running out of gas uses the whole limit", func(t *testing.T) {
+		spin := signedContractCall(t, key, 4, address, "spin", nil, 500)
+		assert.True(t, bc.AddBlock(nextBlock(bc, spin)))
+		receipt, _ := bc.GetReceipt(spin.ID)
+		assert.Equal(t, ReceiptFailed, receipt.Status)
+		assert.Equal(t, spin.GasLimit, receipt.GasUsed)
+	})
+
+	t.Run("Calls of unknown functions are rejected", func(t *testing.T) {
+		tx := signedContractCall(t, key, 5, address, "missing", nil, 1000)
+		assert.ErrorIs(t, bc.ValidateTransaction(tx), ErrInvalidContractTx)
+		tx = signedContractCall(t, key, 5, "0xNowhere", "store", nil, 1000)
+		assert.ErrorIs(t, bc.ValidateTransaction(tx), ErrContractNotFound)
+	})
+
+	t.Run("Storage is committed to the state root", func(t *testing.T) {
+		value, ok := bc.currentStateTree().Get(ContractStorageKey(address, "data"))
+		assert.True(t, ok)
+		assert.Equal(t, []byte("hello"), value)
+	})
+
+	t.Run("Queries read state without changing it", func(t *testing.T) {
+		result, err := bc.QueryContract(address, "get", nil, "", 0)
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("hello"), result.Output)
+		assert.Greater(t, result.GasUsed, uint64(0))
+
+		before := bc.currentStateTree().Root()
+		_, err = bc.QueryContract(address, "store", []byte("changed"), "", 0)
+		assert.ErrorIs(t, err, ErrContractReadOnly)
+		_, err = bc.QueryContract(address, "spin", nil, "", 1000)
+		assert.ErrorIs(t, err, wasm.ErrOutOfGas)
+		_, err = bc.QueryContract(address, "init", nil, "", 0)
+		assert.ErrorIs(t, err, ErrInvalidContractQuery)
+		_, err = bc.QueryContract(address, "get", nil, "", MaxQueryGas+1)
+		assert.ErrorIs(t, err, ErrInvalidContractQuery)
+		assert.Equal(t, before, bc.currentStateTree().Root())
+		assert.Equal(t, []byte("hello"), bc.Contracts[address].storage["data"])
+	})
+
+	t.Run("Storage is capped per contract", func(t *testing.T) {
+		c := bc.Contracts[address]
+		size := c.size
+		c.size = MaxContractStorageSize - 5
+		defer func() { c.size = size }()
+
+		store := signedContractCall(t, key, 5, address, "store", []byte("hello world"), 1000)
+		assert.True(t, bc.AddBlock(nextBlock(bc, store)))
+		receipt, _ := bc.GetReceipt(store.ID)
+		assert.Equal(t, ReceiptFailed, receipt.Status)
+		assert.Contains(t, receipt.Error, ErrContractStorageFull.Error())
+		assert.Equal(t, MaxContractStorageSize-5, c.size)
+	})
+
+	assertStateTreeMatches(t, bc)
+	for bc.Blocks[len(bc.Blocks)-1].Header.Index > 1 {
+		_, err := bc.disconnectTip()
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, afterDeploy, bc.currentStateTree().Root())
+	assert.NotContains(t, bc.Contracts[address].storage, "data")
+
+	_, err = bc.disconnectTip()
+	assert.Nil(t, err)
+	assert.NotContains(t, bc.Contracts, address)
+	_, err = bc.GetContractCode(info.CodeHash)
+	assert.ErrorIs(t, err, ErrContractNotFound)
+	assert.Equal(t, root, bc.currentStateTree().Root())
+	assertStateTreeMatches(t, bc)
+}
+
+func TestContractStorageSurvivesRestart(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+	deployer := PublicKeyToAddress(key.PubKey())
+
+	g := testGenesis(t)
+	g.Tokens[0].Balances[deployer] = uint256.NewInt(100000)
+	validator := g.Validators[0].Address
+
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	bc, err := openChain(db, g, DefaultHistoryConfig())
+	assert.Nil(t, err)
+
+	deploy, err := NewContractDeployTransaction("", ContractDeployParams{Code: escrowContract()}, "", uint256.Int{}, 5000)
+	assert.Nil(t, err)
+	signTestTx(t, deploy, key)
+	address := ContractAddress(deployer, 0)
+	store := signedContractCall(t, key, 1, address, "store", []byte("hello"), 1000)
+	assert.True(t, bc.AddBlock(blockBy(bc, validator, deploy, store)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, store))
+
+	value, err := bc.GetContractStorage(address, "data")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello"), value)
+	value, err = bc.GetContractStorage(address, "missing")
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+	_, err = bc.GetContractStorage("0xNowhere", "data")
+	assert.ErrorIs(t, err, ErrContractNotFound)
+
+	restarted, err := openChain(db, g, DefaultHistoryConfig())
+	assert.Nil(t, err)
+	assert.Equal(t, bc.StateRoot(), restarted.StateRoot())
+	assert.Equal(t, bc.Contracts[address].storage, restarted.Contracts[address].storage)
+	assert.Equal(t, bc.Contracts[address].size, restarted.Contracts[address].size)
+
+	// Disconnecting the block deletes the storage it wrote
+	_, err = restarted.disconnectTip()
+	assert.Nil(t, err)
+	_, err = db.Get([]byte(ledgerContractStorageKey(address, "data")), nil)
+	assert.Equal(t, leveldb.ErrNotFound, err)
+}
+
+func TestDecodeContractDeploy(t *testing.T) {
+	bad := escrowContract()
+	// Rename the imported "caller" to "callex"
+	for i := 0; i+6 <= len(bad); i++ {
+		if string(bad[i:i+6]) == "caller" {
+			bad[i+5] = 'x'
+		}
+	}
+	cases := map[string]struct {
+		code  []byte
+		valid bool
+	}{
+		"escrow":         {escrowContract(), true},
+		"empty":          {nil, false},
+		"not wasm":       {[]byte("hello"), false},
+		"unknown import": {bad, false},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			tx, err := NewContractDeployTransaction("0xAlice", ContractDeployParams{Code: c.code}, "", uint256.Int{}, 0)
+			assert.Nil(t, err)
+			_, _, err = decodeContractDeploy(tx)
+			if c.valid {
+				assert.Nil(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidContractTx)
+			}
+		})
+	}
+}
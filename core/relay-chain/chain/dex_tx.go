@@ -0,0 +1,462 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+var (
+	ErrInvalidDEXTx       = errors.New("invalid DEX parameters")
+	ErrPoolNotFound       = errors.New("pool not found")
+	ErrEmptyPool          = errors.New("pool has no liquidity")
+	ErrInsufficientOutput = errors.New("insufficient output amount")
+)
+
+// SwapFeeBasisPoints is the share of every swap's input left in the pool for
+// its liquidity providers, 0.3%
+const SwapFeeBasisPoints = 30
+
+// LiquidityParams is the JSON carried in the Data of AddLiquidity and
+// RemoveLiquidity transactions. The pool is the pair of the transaction's
+// TokenID and Token. Adding deposits the transaction's Amount of TokenID and
+// Amount of Token; removing burns the transaction's Amount of shares.
+type LiquidityParams struct {
+	Token  string      `json:"token"`
+	Amount uint256.Int `json:"amount"` // zero when removing
+}
+
+// SwapParams is the JSON carried in the Data of a Swap transaction, which
+// sells the transaction's Amount of TokenID for TokenOut
+type SwapParams struct {
+	TokenOut     string      `json:"token_out"`
+	MinAmountOut uint256.Int `json:"min_amount_out"`
+}
+
+// Pool is a constant product pool of two tokens. Its reserves are the token
+// balances of its PoolAddress.
+type Pool struct {
+	TokenA      string      `json:"token_a"`
+	TokenB      string      `json:"token_b"`
+	Address     string      `json:"address"`
+	ReserveA    uint256.Int `json:"reserve_a"`
+	ReserveB    uint256.Int `json:"reserve_b"`
+	TotalShares uint256.Int `json:"total_shares"`
+}
+
+// poolRecord is the part of a pool that is not a token balance
+type poolRecord struct {
+	TotalShares uint256.Int `json:"total_shares"`
+}
+
+// sortPair orders the tokens of a pool
+func sortPair(tokenA, tokenB string) (string, string) {
+	if tokenB < tokenA {
+		return tokenB, tokenA
+	}
+	return tokenA, tokenB
+}
+
+// PoolAddress is the account that holds the reserves of the pool of two tokens
+func PoolAddress(tokenA, tokenB string) string {
+	tokenA, tokenB = sortPair(tokenA, tokenB)
+	return "dex_pool_" + tokenA + "_" + tokenB
+}
+
+// SwapQuote returns what selling amountIn into a pool with the given reserves
+// pays out, after the swap fee, by the constant product formula x * y = k
+func SwapQuote(reserveIn, reserveOut, amountIn uint256.Int) (uint256.Int, error) {
+	if reserveIn.IsZero() || reserveOut.IsZero() {
+		return uint256.Int{}, ErrEmptyPool
+	}
+	amountInWithFee, err := amountIn.MulDiv(uint256.NewInt(10000-SwapFeeBasisPoints), uint256.NewInt(10000))
+	if err != nil {
+		return uint256.Int{}, err
+	}
+	denominator, err := reserveIn.Add(amountInWithFee)
+	if err != nil {
+		return uint256.Int{}, err
+	}
+	return amountInWithFee.MulDiv(reserveOut, denominator)
+}
+
+// geometricMean returns sqrt(a * b). The product is taken at full precision,
+// so the result never overflows.
+func geometricMean(a, b uint256.Int) uint256.Int {
+	product := new(big.Int).Mul(a.Big(), b.Big())
+	mean, _ := uint256.FromBig(product.Sqrt(product))
+	return mean
+}
+
+// NewLiquidityTransaction builds an unsigned AddLiquidity or RemoveLiquidity
+// transaction on the pool of symbol and params.Token
+func NewLiquidityTransaction(txType int, from, symbol string, amount uint256.Int, params LiquidityParams) (*Transaction, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(txType, from, "", amount, nil)
+	tx.TokenID = symbol
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx)
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// NewSwapTransaction builds an unsigned transaction that sells amount of symbol for params.TokenOut
+func NewSwapTransaction(from, symbol string, amount uint256.Int, params SwapParams) (*Transaction, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(Swap, from, "", amount, nil)
+	tx.TokenID = symbol
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx)
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// isDEXTx reports whether txType trades with or funds a pool
+func isDEXTx(txType int) bool {
+	switch txType {
+	case AddLiquidity, RemoveLiquidity, Swap:
+		return true
+	}
+	return false
+}
+
+// decodeLiquidity parses and validates the parameters of a liquidity transaction
+func decodeLiquidity(tx *Transaction) (*LiquidityParams, error) {
+	var params LiquidityParams
+	if err := json.Unmarshal(tx.Data, &params); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDEXTx, err)
+	}
+	if params.Token == "" || params.Token == tx.TokenID {
+		return nil, fmt.Errorf("%w: a pool needs two different tokens", ErrInvalidDEXTx)
+	}
+	if tx.Amount.IsZero() {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidTxFields)
+	}
+	if tx.Type == AddLiquidity && params.Amount.IsZero() {
+		return nil, fmt.Errorf("%w: both amounts must be positive", ErrInvalidDEXTx)
+	}
+	if tx.Type == RemoveLiquidity && !params.Amount.IsZero() {
+		return nil, fmt.Errorf("%w: removing liquidity burns shares and names no amount of %s", ErrInvalidDEXTx, params.Token)
+	}
+	return &params, nil
+}
+
+// decodeSwap parses and validates the parameters of a Swap transaction
+func decodeSwap(tx *Transaction) (*SwapParams, error) {
+	var params SwapParams
+	if err := json.Unmarshal(tx.Data, &params); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDEXTx, err)
+	}
+	if params.TokenOut == "" || params.TokenOut == tx.TokenID {
+		return nil, fmt.Errorf("%w: a swap needs two different tokens", ErrInvalidDEXTx)
+	}
+	if tx.Amount.IsZero() {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidTxFields)
+	}
+	return &params, nil
+}
+
+// pool returns the pool of two tokens with its reserves, or false if nobody
+// has added liquidity to it. Caller must hold bc.mu.
+func (bc *Blockchain) pool(tokenA, tokenB string) (*Pool, bool) {
+	var record poolRecord
+	if !bc.getRecord(PoolKey(tokenA, tokenB), &record) {
+		return nil, false
+	}
+	tokenA, tokenB = sortPair(tokenA, tokenB)
+	p := &Pool{TokenA: tokenA, TokenB: tokenB, Address: PoolAddress(tokenA, tokenB), TotalShares: record.TotalShares}
+	p.ReserveA = bc.tokenBalance(tokenA, p.Address)
+	p.ReserveB = bc.tokenBalance(tokenB, p.Address)
+	return p, true
+}
+
+// tokenBalance returns address's balance of symbol, or zero if the token does
+// not exist. Caller must hold bc.mu.
+func (bc *Blockchain) tokenBalance(symbol, address string) uint256.Int {
+	tk, exists := bc.TokenRegistry[symbol]
+	if !exists {
+		return uint256.Int{}
+	}
+	balance, _ := tk.BalanceOf(address)
+	return balance
+}
+
+// reserves returns the reserves of p ordered as (in, out) for tokenIn
+func (p *Pool) reserves(tokenIn string) (uint256.Int, uint256.Int) {
+	if tokenIn == p.TokenA {
+		return p.ReserveA, p.ReserveB
+	}
+	return p.ReserveB, p.ReserveA
+}
+
+// shares returns the liquidity shares provider holds in the pool of two
+// tokens. Caller must hold bc.mu.
+func (bc *Blockchain) shares(tokenA, tokenB, provider string) uint256.Int {
+	var shares uint256.Int
+	bc.getRecord(PoolShareKey(tokenA, tokenB, provider), &shares)
+	return shares
+}
+
+// checkSpend reports whether from can move amount of symbol into to. Caller must hold bc.mu.
+func (bc *Blockchain) checkSpend(symbol, from, to string, amount uint256.Int) error {
+	tk, exists := bc.TokenRegistry[symbol]
+	if !exists {
+		return fmt.Errorf("token %s not found", symbol)
+	}
+	if balance, _ := tk.Spendable(from); balance.Lt(amount) {
+		return fmt.Errorf("insufficient %s balance: has %s, needs %s", symbol, balance, amount)
+	}
+	return tk.CheckMovement(from, to)
+}
+
+// checkDEXAction runs the checks of DEX transactions that depend on chain
+// state. Caller must hold bc.mu.
+func (bc *Blockchain) checkDEXAction(tx *Transaction) error {
+	switch tx.Type {
+	case AddLiquidity:
+		params, err := decodeLiquidity(tx)
+		if err != nil {
+			return err
+		}
+		address := PoolAddress(tx.TokenID, params.Token)
+		if err := bc.checkSpend(tx.TokenID, tx.From, address, tx.Amount); err != nil {
+			return err
+		}
+		return bc.checkSpend(params.Token, tx.From, address, params.Amount)
+	case RemoveLiquidity:
+		params, err := decodeLiquidity(tx)
+		if err != nil {
+			return err
+		}
+		if _, exists := bc.pool(tx.TokenID, params.Token); !exists {
+			return fmt.Errorf("%w: %s-%s", ErrPoolNotFound, tx.TokenID, params.Token)
+		}
+		if shares := bc.shares(tx.TokenID, params.Token, tx.From); shares.Lt(tx.Amount) {
+			return fmt.Errorf("%w: has %s shares, burning %s", ErrInvalidDEXTx, shares, tx.Amount)
+		}
+	case Swap:
+		_, _, err := bc.swapQuote(tx)
+		return err
+	}
+	return nil
+}
+
+// swapQuote returns the pool a Swap transaction trades with and what it pays
+// out, once the trade is possible. Caller must hold bc.mu.
+func (bc *Blockchain) swapQuote(tx *Transaction) (*Pool, uint256.Int, error) {
+	params, err := decodeSwap(tx)
+	if err != nil {
+		return nil, uint256.Int{}, err
+	}
+	p, exists := bc.pool(tx.TokenID, params.TokenOut)
+	if !exists {
+		return nil, uint256.Int{}, fmt.Errorf("%w: %s-%s", ErrPoolNotFound, tx.TokenID, params.TokenOut)
+	}
+	if err := bc.checkSpend(tx.TokenID, tx.From, p.Address, tx.Amount); err != nil {
+		return nil, uint256.Int{}, err
+	}
+	reserveIn, reserveOut := p.reserves(tx.TokenID)
+	amountOut, err := SwapQuote(reserveIn, reserveOut, tx.Amount)
+	if err != nil {
+		return nil, uint256.Int{}, fmt.Errorf("%w: %s-%s", err, p.TokenA, p.TokenB)
+	}
+	if amountOut.IsZero() || amountOut.Lt(params.MinAmountOut) {
+		return nil, uint256.Int{}, fmt.Errorf("%w: got %s, minimum %s", ErrInsufficientOutput, amountOut, params.MinAmountOut)
+	}
+	if err := bc.TokenRegistry[params.TokenOut].CheckMovement(p.Address, tx.From); err != nil {
+		return nil, uint256.Int{}, err
+	}
+	return p, amountOut, nil
+}
+
+// applyAddLiquidity deposits both amounts into the pool, creating it if
+// needed, and credits the provider with shares. The first deposit into an
+// empty pool mints the geometric mean of its amounts; later ones mint in
+// proportion to the smaller of their shares of the reserves.
+func (bc *Blockchain) applyAddLiquidity(tx *Transaction) ([]Event, error) {
+	if err := bc.checkDEXAction(tx); err != nil {
+		return nil, err
+	}
+	params, _ := decodeLiquidity(tx)
+
+	p, exists := bc.pool(tx.TokenID, params.Token)
+	if !exists {
+		tokenA, tokenB := sortPair(tx.TokenID, params.Token)
+		p = &Pool{TokenA: tokenA, TokenB: tokenB, Address: PoolAddress(tokenA, tokenB)}
+	}
+	amounts := map[string]uint256.Int{tx.TokenID: tx.Amount, params.Token: params.Amount}
+
+	var minted uint256.Int
+	if p.TotalShares.IsZero() {
+		minted = geometricMean(tx.Amount, params.Amount)
+	} else {
+		if p.ReserveA.IsZero() || p.ReserveB.IsZero() {
+			return nil, fmt.Errorf("%w: %s-%s", ErrEmptyPool, p.TokenA, p.TokenB)
+		}
+		sharesA, err := amounts[p.TokenA].MulDiv(p.TotalShares, p.ReserveA)
+		if err != nil {
+			return nil, fmt.Errorf("liquidity shares: %w", err)
+		}
+		sharesB, err := amounts[p.TokenB].MulDiv(p.TotalShares, p.ReserveB)
+		if err != nil {
+			return nil, fmt.Errorf("liquidity shares: %w", err)
+		}
+		minted = sharesA
+		if sharesB.Lt(minted) {
+			minted = sharesB
+		}
+	}
+	if minted.IsZero() {
+		return nil, fmt.Errorf("%w: the deposit is too small to mint a share", ErrInvalidDEXTx)
+	}
+	total, err := p.TotalShares.Add(minted)
+	if err != nil {
+		return nil, fmt.Errorf("total shares: %w", err)
+	}
+	held, _ := bc.shares(p.TokenA, p.TokenB, tx.From).Add(minted) // never above the total
+
+	var events []Event
+	for _, symbol := range []string{p.TokenA, p.TokenB} {
+		if err := bc.TokenRegistry[symbol].Transfer(tx.From, p.Address, amounts[symbol]); err != nil {
+			return nil, fmt.Errorf("add liquidity failed: %v", err)
+		}
+		events = append(events, Event{Type: EventTransfer, TokenID: symbol, From: tx.From, To: p.Address, Amount: amounts[symbol]})
+	}
+	if err := bc.putRecord(PoolKey(p.TokenA, p.TokenB), poolRecord{TotalShares: total}); err != nil {
+		return nil, err
+	}
+	if err := bc.putRecord(PoolShareKey(p.TokenA, p.TokenB, tx.From), held); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("   💧 %s added %s %s and %s %s to %s, received %s shares\n",
+		tx.From, tx.Amount, tx.TokenID, params.Amount, params.Token, p.Address, minted)
+	return events, nil
+}
+
+// applyRemoveLiquidity burns the provider's shares and pays out the same
+// fraction of each reserve
+func (bc *Blockchain) applyRemoveLiquidity(tx *Transaction) ([]Event, error) {
+	if err := bc.checkDEXAction(tx); err != nil {
+		return nil, err
+	}
+	params, _ := decodeLiquidity(tx)
+	p, _ := bc.pool(tx.TokenID, params.Token)
+
+	amountA, err := p.ReserveA.MulDiv(tx.Amount, p.TotalShares)
+	if err != nil {
+		return nil, err
+	}
+	amountB, err := p.ReserveB.MulDiv(tx.Amount, p.TotalShares)
+	if err != nil {
+		return nil, err
+	}
+	total, _ := p.TotalShares.Sub(tx.Amount)                         // the provider's shares are part of the total
+	held, _ := bc.shares(p.TokenA, p.TokenB, tx.From).Sub(tx.Amount) // checked by checkDEXAction
+
+	var events []Event
+	for _, out := range []struct {
+		symbol string
+		amount uint256.Int
+	}{{p.TokenA, amountA}, {p.TokenB, amountB}} {
+		if out.amount.IsZero() {
+			continue
+		}
+		if err := bc.TokenRegistry[out.symbol].Transfer(p.Address, tx.From, out.amount); err != nil {
+			return nil, fmt.Errorf("remove liquidity failed: %v", err)
+		}
+		events = append(events, Event{Type: EventTransfer, TokenID: out.symbol, From: p.Address, To: tx.From, Amount: out.amount})
+	}
+	if err := bc.putRecord(PoolKey(p.TokenA, p.TokenB), poolRecord{TotalShares: total}); err != nil {
+		return nil, err
+	}
+	if held.IsZero() {
+		bc.setRecord(PoolShareKey(p.TokenA, p.TokenB, tx.From), nil)
+	} else if err := bc.putRecord(PoolShareKey(p.TokenA, p.TokenB, tx.From), held); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("   💧 %s burned %s shares of %s for %s %s and %s %s\n",
+		tx.From, tx.Amount, p.Address, amountA, p.TokenA, amountB, p.TokenB)
+	return events, nil
+}
+
+// applySwap sells the amount into the pool and pays out the quote
+func (bc *Blockchain) applySwap(tx *Transaction) ([]Event, error) {
+	p, amountOut, err := bc.swapQuote(tx)
+	if err != nil {
+		return nil, err
+	}
+	params, _ := decodeSwap(tx)
+
+	if err := bc.TokenRegistry[tx.TokenID].Transfer(tx.From, p.Address, tx.Amount); err != nil {
+		return nil, fmt.Errorf("swap failed: %v", err)
+	}
+	if err := bc.TokenRegistry[params.TokenOut].Transfer(p.Address, tx.From, amountOut); err != nil {
+		return nil, fmt.Errorf("swap failed: %v", err)
+	}
+
+	fmt.Printf("   🔄 %s swapped %s %s for %s %s\n", tx.From, tx.Amount, tx.TokenID, amountOut, params.TokenOut)
+	return []Event{{Type: EventSwap, TokenID: tx.TokenID, From: tx.From, To: p.Address, Amount: tx.Amount, TokenOut: params.TokenOut, AmountOut: &amountOut}}, nil
+}
+
+// GetPool returns the pool of two tokens with its reserves
+func (bc *Blockchain) GetPool(tokenA, tokenB string) (*Pool, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	p, exists := bc.pool(tokenA, tokenB)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s-%s", ErrPoolNotFound, tokenA, tokenB)
+	}
+	return p, nil
+}
+
+// Pools returns every pool anyone has added liquidity to
+func (bc *Blockchain) Pools() []*Pool {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	pools := []*Pool{}
+	for _, key := range bc.recordKeys("pool/") {
+		pair := strings.SplitN(strings.TrimPrefix(key, "pool/"), "/", 2)
+		if len(pair) != 2 {
+			continue
+		}
+		if p, exists := bc.pool(pair[0], pair[1]); exists {
+			pools = append(pools, p)
+		}
+	}
+	return pools
+}
+
+// QuoteSwap returns what selling amountIn of tokenIn for tokenOut pays out at
+// the current reserves
+func (bc *Blockchain) QuoteSwap(tokenIn, tokenOut string, amountIn uint256.Int) (uint256.Int, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	p, exists := bc.pool(tokenIn, tokenOut)
+	if !exists {
+		return uint256.Int{}, fmt.Errorf("%w: %s-%s", ErrPoolNotFound, tokenIn, tokenOut)
+	}
+	reserveIn, reserveOut := p.reserves(tokenIn)
+	return SwapQuote(reserveIn, reserveOut, amountIn)
+}
+
+// LiquidityShares returns the shares provider holds in the pool of two tokens
+func (bc *Blockchain) LiquidityShares(tokenA, tokenB, provider string) uint256.Int {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.shares(tokenA, tokenB, provider)
+}
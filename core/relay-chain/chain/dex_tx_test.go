@@ -0,0 +1,161 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func signedLiquidityTx(t *testing.T, key *btcec.PrivateKey, txType int, nonce uint64, symbol string, amount uint64, params LiquidityParams) *Transaction {
+	tx, err := NewLiquidityTransaction(txType, "", symbol, uint256.NewInt(amount), params)
+	assert.Nil(t, err)
+	tx.Nonce = nonce
+	signTestTx(t, tx, key)
+	return tx
+}
+
+func signedSwap(t *testing.T, key *btcec.PrivateKey, nonce uint64, symbol string, amount uint64, params SwapParams) *Transaction {
+	tx, err := NewSwapTransaction("", symbol, uint256.NewInt(amount), params)
+	assert.Nil(t, err)
+	tx.Nonce = nonce
+	signTestTx(t, tx, key)
+	return tx
+}
+
+func TestSwapQuote(t *testing.T) {
+	out, err := SwapQuote(uint256.NewInt(1000), uint256.NewInt(1000), uint256.NewInt(100))
+	assert.Nil(t, err)
+	assert.Equal(t, uint256.NewInt(90), out) // 99.7 * 1000 / 1099.7, rounded down
+
+	_, err = SwapQuote(uint256.Int{}, uint256.NewInt(1000), uint256.NewInt(100))
+	assert.ErrorIs(t, err, ErrEmptyPool)
+}
+
+func TestDEXLifecycle(t *testing.T) {
+	bc, key := newTestChain(t)
+	provider := PublicKeyToAddress(key.PubKey())
+	traderKey, _ := btcec.NewPrivateKey()
+	trader := PublicKeyToAddress(traderKey.PubKey())
+	pool := PoolAddress("GOLD", "BHX")
+	assert.Equal(t, "dex_pool_BHX_GOLD", pool)
+
+	create := signedTokenCreate(t, key, 0, "GOLD", TokenCreateParams{Name: "Gold"}, 1000)
+	fund := signedTokenTx(t, key, TokenTransfer, 1, "BHX", trader, 200)
+	add := signedLiquidityTx(t, key, AddLiquidity, 2, "GOLD", 400, LiquidityParams{Token: "BHX", Amount: uint256.NewInt(100)})
+	assert.True(t, bc.AddBlock(nextBlock(bc, create, fund, add)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, add))
+
+	receipt, _ := bc.GetReceipt(add.ID)
+	assert.Contains(t, receipt.Events, Event{Type: EventTransfer, TokenID: "BHX", From: provider, To: pool, Amount: uint256.NewInt(100)})
+	assert.Contains(t, receipt.Events, Event{Type: EventTransfer, TokenID: "GOLD", From: provider, To: pool, Amount: uint256.NewInt(400)})
+
+	p, err := bc.GetPool("GOLD", "BHX")
+	assert.Nil(t, err)
+	assert.Equal(t, &Pool{TokenA: "BHX", TokenB: "GOLD", Address: pool, ReserveA: uint256.NewInt(100), ReserveB: uint256.NewInt(400), TotalShares: uint256.NewInt(200)}, p)
+	assert.Equal(t, uint256.NewInt(200), bc.LiquidityShares("BHX", "GOLD", provider))
+	assert.Equal(t, []*Pool{p}, bc.Pools())
+
+	t.Run("Swaps respect the minimum output", func(t *testing.T) {
+		quote, err := bc.QuoteSwap("BHX", "GOLD", uint256.NewInt(50))
+		assert.Nil(t, err)
+		assert.Equal(t, uint256.NewInt(131), quote) // 49 left after the fee * 400 / 149, rounded down
+
+		greedy := signedSwap(t, traderKey, 0, "BHX", 50, SwapParams{TokenOut: "GOLD", MinAmountOut: uint256.NewInt(132)})
+		assert.ErrorIs(t, bc.ValidateTransaction(greedy), ErrInsufficientOutput)
+
+		swap := signedSwap(t, traderKey, 0, "BHX", 50, SwapParams{TokenOut: "GOLD", MinAmountOut: quote})
+		assert.True(t, bc.AddBlock(nextBlock(bc, swap)))
+		assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, swap))
+
+		receipt, _ := bc.GetReceipt(swap.ID)
+		assert.Contains(t, receipt.Events, Event{Type: EventSwap, TokenID: "BHX", From: trader, To: pool, Amount: uint256.NewInt(50), TokenOut: "GOLD", AmountOut: &quote})
+		balance, _ := bc.TokenRegistry["GOLD"].BalanceOf(trader)
+		assert.Equal(t, quote, balance)
+
+		p, _ := bc.GetPool("BHX", "GOLD")
+		assert.Equal(t, uint256.NewInt(150), p.ReserveA)
+		assert.Equal(t, uint256.NewInt(269), p.ReserveB)
+	})
+
+	t.Run("Only shares held can be removed", func(t *testing.T) {
+		steal := signedLiquidityTx(t, traderKey, RemoveLiquidity, 1, "BHX", 10, LiquidityParams{Token: "GOLD"})
+		assert.NotNil(t, bc.ValidateTransaction(steal))
+	})
+
+	remove := signedLiquidityTx(t, key, RemoveLiquidity, 3, "BHX", 200, LiquidityParams{Token: "GOLD"})
+	assert.True(t, bc.AddBlock(nextBlock(bc, remove)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, remove))
+	p, _ = bc.GetPool("BHX", "GOLD")
+	assert.True(t, p.TotalShares.IsZero())
+	assert.True(t, p.ReserveA.IsZero())
+	assert.True(t, p.ReserveB.IsZero())
+	assert.True(t, bc.LiquidityShares("BHX", "GOLD", provider).IsZero())
+
+	receipt, _ = bc.GetReceipt(remove.ID)
+	assert.Contains(t, receipt.Events, Event{Type: EventTransfer, TokenID: "BHX", From: pool, To: provider, Amount: uint256.NewInt(150)})
+	assert.Contains(t, receipt.Events, Event{Type: EventTransfer, TokenID: "GOLD", From: pool, To: provider, Amount: uint256.NewInt(269)})
+	assertStateTreeMatches(t, bc)
+}
+
+func TestDEXReverts(t *testing.T) {
+	bc, key := newTestChain(t)
+
+	create := signedTokenCreate(t, key, 0, "GOLD", TokenCreateParams{Name: "Gold"}, 1000)
+	assert.True(t, bc.AddBlock(nextBlock(bc, create)))
+	root := bc.currentStateTree().Root()
+
+	add := signedLiquidityTx(t, key, AddLiquidity, 1, "GOLD", 400, LiquidityParams{Token: "BHX", Amount: uint256.NewInt(100)})
+	swap := signedSwap(t, key, 2, "BHX", 50, SwapParams{TokenOut: "GOLD"})
+	assert.True(t, bc.AddBlock(nextBlock(bc, add, swap)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, swap))
+
+	_, err := bc.disconnectTip()
+	assert.Nil(t, err)
+	_, err = bc.GetPool("BHX", "GOLD")
+	assert.ErrorIs(t, err, ErrPoolNotFound)
+	assert.True(t, bc.LiquidityShares("BHX", "GOLD", PublicKeyToAddress(key.PubKey())).IsZero())
+	assert.Equal(t, root, bc.currentStateTree().Root())
+	assertStateTreeMatches(t, bc)
+}
+
+func TestDecodeDEX(t *testing.T) {
+	cases := []struct {
+		name  string
+		tx    func() (*Transaction, error)
+		valid bool
+	}{
+		{"add", func() (*Transaction, error) {
+			return NewLiquidityTransaction(AddLiquidity, "0xAlice", "GOLD", uint256.NewInt(10), LiquidityParams{Token: "BHX", Amount: uint256.NewInt(5)})
+		}, true},
+		{"add one token", func() (*Transaction, error) {
+			return NewLiquidityTransaction(AddLiquidity, "0xAlice", "GOLD", uint256.NewInt(10), LiquidityParams{Token: "BHX"})
+		}, false},
+		{"pool of one token", func() (*Transaction, error) {
+			return NewLiquidityTransaction(AddLiquidity, "0xAlice", "GOLD", uint256.NewInt(10), LiquidityParams{Token: "GOLD", Amount: uint256.NewInt(5)})
+		}, false},
+		{"remove", func() (*Transaction, error) {
+			return NewLiquidityTransaction(RemoveLiquidity, "0xAlice", "GOLD", uint256.NewInt(10), LiquidityParams{Token: "BHX"})
+		}, true},
+		{"remove with amount", func() (*Transaction, error) {
+			return NewLiquidityTransaction(RemoveLiquidity, "0xAlice", "GOLD", uint256.NewInt(10), LiquidityParams{Token: "BHX", Amount: uint256.NewInt(5)})
+		}, false},
+		{"swap", func() (*Transaction, error) {
+			return NewSwapTransaction("0xAlice", "GOLD", uint256.NewInt(10), SwapParams{TokenOut: "BHX"})
+		}, true},
+		{"swap for itself", func() (*Transaction, error) {
+			return NewSwapTransaction("0xAlice", "GOLD", uint256.NewInt(10), SwapParams{TokenOut: "GOLD"})
+		}, false},
+		{"zero swap", func() (*Transaction, error) {
+			return NewSwapTransaction("0xAlice", "GOLD", uint256.Int{}, SwapParams{TokenOut: "BHX"})
+		}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tx, err := c.tx()
+			assert.Nil(t, err)
+			assert.Equal(t, c.valid, checkFields(tx) == nil)
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+// Canonical binary encoding of transactions and blocks. Every integer is
+// big-endian and fixed width, amounts taking 32 bytes; strings and byte slices
+// are a uint32 length followed by the raw bytes. See
+// docs/TRANSACTION_ENCODING.md for the layout and test vectors.
+
+const (
+	// TxFormatVersion is the first byte of every encoded transaction
+	TxFormatVersion byte = 2
+	// BlockFormatVersion is the first byte of every encoded block header
+	BlockFormatVersion byte = 3
+	// DefaultChainID identifies the network transactions are signed for
+	DefaultChainID uint64 = 2025
+
+	// maxFieldLength bounds variable length fields so a corrupt length cannot
+	// force a huge allocation
+	maxFieldLength = 1 << 20
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported encoding version")
+	ErrTruncated          = errors.New("encoded data is truncated")
+	ErrTrailingData       = errors.New("trailing data after encoded value")
+	ErrWrongChainID       = errors.New("transaction signed for a different chain")
+)
+
+type encoder struct {
+	buf bytes.Buffer
+}
+
+func (e *encoder) uint8(v byte) {
+	e.buf.WriteByte(v)
+}
+
+func (e *encoder) uint32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	e.buf.Write(b[:])
+}
+
+func (e *encoder) uint64(v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	e.buf.Write(b[:])
+}
+
+func (e *encoder) uint256(v uint256.Int) {
+	b := v.Bytes32()
+	e.buf.Write(b[:])
+}
+
+func (e *encoder) bytes(v []byte) {
+	e.uint32(uint32(len(v)))
+	e.buf.Write(v)
+}
+
+func (e *encoder) string(v string) {
+	e.bytes([]byte(v))
+}
+
+// decoder reads fields in order; the first error sticks and later reads return zero values
+type decoder struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.data)-d.pos < n {
+		d.err = ErrTruncated
+		return nil
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b
+}
+
+func (d *decoder) uint8() byte {
+	if b := d.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.next(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint256() uint256.Int {
+	var v [32]byte
+	copy(v[:], d.next(32))
+	return uint256.FromBytes32(v)
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.uint32()
+	if d.err == nil && n > maxFieldLength {
+		d.err = fmt.Errorf("field length %d exceeds limit", n)
+		return nil
+	}
+	b := d.next(int(n))
+	if len(b) == 0 {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes())
+}
+
+// finish reports the first error, or trailing bytes if everything was read
+func (d *decoder) finish() error {
+	if d.err == nil && d.pos != len(d.data) {
+		return ErrTrailingData
+	}
+	return d.err
+}
+
+// encodeUnsigned writes every transaction field except the signature
+func (tx *Transaction) encodeUnsigned(e *encoder) {
+	e.uint8(TxFormatVersion)
+	e.uint64(tx.ChainID)
+	e.uint8(byte(tx.Type))
+	e.string(tx.From)
+	e.string(tx.To)
+	e.uint256(tx.Amount)
+	e.string(tx.TokenID)
+	e.bytes(tx.Data)
+	e.uint64(uint64(tx.Timestamp))
+	e.uint64(tx.Nonce)
+	e.uint64(tx.Fee)
+	e.uint64(tx.GasLimit)
+	e.uint64(tx.GasPrice)
+	e.bytes(tx.PublicKey)
+}
+
+// SigningBytes returns the payload that is hashed into the transaction ID and signed
+func (tx *Transaction) SigningBytes() []byte {
+	var e encoder
+	tx.encodeUnsigned(&e)
+	return e.buf.Bytes()
+}
+
+// Encode returns the canonical encoding of the transaction including its signature
+func (tx *Transaction) Encode() []byte {
+	var e encoder
+	tx.encodeUnsigned(&e)
+	e.bytes(tx.Signature)
+	return e.buf.Bytes()
+}
+
+// DecodeTransaction parses a canonical transaction encoding. The ID is
+// recomputed from the decoded fields rather than trusted from the sender.
+func DecodeTransaction(data []byte) (*Transaction, error) {
+	d := &decoder{data: data}
+	tx, err := decodeTransaction(d)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func decodeTransaction(d *decoder) (*Transaction, error) {
+	if version := d.uint8(); d.err == nil && version != TxFormatVersion {
+		return nil, fmt.Errorf("%w: transaction version %d", ErrUnsupportedVersion, version)
+	}
+
+	tx := &Transaction{}
+	tx.ChainID = d.uint64()
+	tx.Type = int(d.uint8())
+	tx.From = d.string()
+	tx.To = d.string()
+	tx.Amount = d.uint256()
+	tx.TokenID = d.string()
+	tx.Data = d.bytes()
+	tx.Timestamp = int64(d.uint64())
+	tx.Nonce = d.uint64()
+	tx.Fee = d.uint64()
+	tx.GasLimit = d.uint64()
+	tx.GasPrice = d.uint64()
+	tx.PublicKey = d.bytes()
+	tx.Signature = d.bytes()
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// EncodeHeader returns the canonical encoding of the block header, which the block hash commits to
+func (b *Block) EncodeHeader() []byte {
+	return b.Header.Encode()
+}
+
+// Encode returns the canonical encoding of the header
+func (h *BlockHeader) Encode() []byte {
+	var e encoder
+	h.encode(&e)
+	return e.buf.Bytes()
+}
+
+func (h *BlockHeader) encode(e *encoder) {
+	e.uint8(BlockFormatVersion)
+	e.uint64(h.Index)
+	e.uint64(uint64(h.Timestamp.UnixNano()))
+	e.string(h.PreviousHash)
+	e.string(h.Validator)
+	e.uint256(h.StakeSnapshot)
+	e.string(h.MerkleRoot)
+	e.string(h.StateRoot)
+	e.string(h.ReceiptsRoot)
+	e.uint64(h.ConsensusRound)
+}
+
+// Encode returns the canonical encoding of the block: its header, the
+// length-prefixed encodings of its transactions and the proposer signature
+func (b *Block) Encode() []byte {
+	var e encoder
+	b.Header.encode(&e)
+	e.uint32(uint32(len(b.Transactions)))
+	for _, tx := range b.Transactions {
+		e.bytes(tx.Encode())
+	}
+	e.bytes(b.Signature)
+	return e.buf.Bytes()
+}
+
+// DecodeBlock parses a canonical block encoding and recomputes its hash
+func DecodeBlock(data []byte) (*Block, error) {
+	d := &decoder{data: data}
+	if version := d.uint8(); d.err == nil && version != BlockFormatVersion {
+		return nil, fmt.Errorf("%w: block version %d", ErrUnsupportedVersion, version)
+	}
+
+	block := &Block{}
+	h := &block.Header
+	h.Index = d.uint64()
+	h.Timestamp = time.Unix(0, int64(d.uint64())).UTC()
+	h.PreviousHash = d.string()
+	h.Validator = d.string()
+	h.StakeSnapshot = d.uint256()
+	h.MerkleRoot = d.string()
+	h.StateRoot = d.string()
+	h.ReceiptsRoot = d.string()
+	h.ConsensusRound = d.uint64()
+
+	count := d.uint32()
+	if d.err == nil && uint64(count) > uint64(len(data)) {
+		return nil, ErrTruncated
+	}
+	block.Transactions = make([]*Transaction, 0, count)
+	for i := uint32(0); i < count && d.err == nil; i++ {
+		tx, err := DecodeTransaction(d.bytes())
+		if d.err != nil {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		block.Transactions = append(block.Transactions, tx)
+	}
+	block.Signature = d.bytes()
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+
+	block.Hash = block.CalculateHash()
+	return block, nil
+}
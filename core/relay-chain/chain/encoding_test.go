@@ -0,0 +1,116 @@
+package chain
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors published in docs/TRANSACTION_ENCODING.md. Other signers must
+// reproduce these bytes exactly.
+const (
+	vectorTransferSigning = "0200000000000007e901000000073078416c696365000000053078426f6200000000000000000000000000000000000000000000000000000000000003e800000003424858000000000000000068259dd400000000000000070000000000000000000000000000000f000000000000000200000000"
+	vectorTransferID      = "470915ab6bb7d562a5a26fa448f5a08d1819604ae700e4af23632fe94beacc7d"
+	vectorCallEncoded     = "0200000000000007e906000000073078416c696365000000053078426f6200000000000000000000000000000000000000000000000000000000000003e8000000034248580000000268690000000068259dd400000000000000070000000000000000000000000000000f00000000000000020000000000000004deadbeef"
+	vectorCallID          = "6ce5c814de86592b57bec03d7c5676a1803dfb906ac7bc6381e16deb3b2d16ae"
+	vectorBlockEncoded    = "030000000000000001183fa47455760000000000026161000000056e6f64653100000000000000000000000000000000000000000000000000000000000003e80000000000000002626200000002636300000000000000000000000000000000"
+	vectorBlockHash       = "c521e2f86025542f8ce8a667762e5ca9af000c408d07f9c923af9498807e41ee"
+)
+
+func vectorTransfer() *Transaction {
+	return &Transaction{
+		ChainID:   DefaultChainID,
+		Type:      TokenTransfer,
+		From:      "0xAlice",
+		To:        "0xBob",
+		Amount:    uint256.NewInt(1000),
+		TokenID:   "BHX",
+		Timestamp: 1747295700,
+		Nonce:     7,
+		GasLimit:  15,
+		GasPrice:  2,
+	}
+}
+
+func TestTransactionVectors(t *testing.T) {
+	tx := vectorTransfer()
+	assert.Equal(t, vectorTransferSigning, hex.EncodeToString(tx.SigningBytes()))
+	assert.Equal(t, vectorTransferID, tx.CalculateHash())
+
+	tx.Type = SmartContractCall
+	tx.Data = []byte("hi")
+	tx.Signature = []byte{0xde, 0xad, 0xbe, 0xef}
+	assert.Equal(t, vectorCallEncoded, hex.EncodeToString(tx.Encode()))
+	assert.Equal(t, vectorCallID, tx.CalculateHash())
+}
+
+func TestTransactionRoundTrip(t *testing.T) {
+	data, _ := hex.DecodeString(vectorCallEncoded)
+
+	tx, err := DecodeTransaction(data)
+	assert.Nil(t, err)
+	assert.Equal(t, vectorCallID, tx.ID)
+	assert.Equal(t, "0xAlice", tx.From)
+	assert.Equal(t, []byte("hi"), tx.Data)
+	assert.Equal(t, data, tx.Encode())
+
+	t.Run("Chain ID changes the ID", func(t *testing.T) {
+		other := vectorTransfer()
+		other.ChainID = DefaultChainID + 1
+		assert.NotEqual(t, vectorTransferID, other.CalculateHash())
+	})
+
+	t.Run("Unknown version", func(t *testing.T) {
+		bad := append([]byte{1}, data[1:]...)
+		_, err := DecodeTransaction(bad)
+		assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		_, err := DecodeTransaction(data[:len(data)-1])
+		assert.ErrorIs(t, err, ErrTruncated)
+	})
+
+	t.Run("Trailing data", func(t *testing.T) {
+		_, err := DecodeTransaction(append(append([]byte{}, data...), 0))
+		assert.ErrorIs(t, err, ErrTrailingData)
+	})
+}
+
+func TestBlockRoundTrip(t *testing.T) {
+	block := &Block{
+		Header: BlockHeader{
+			Index:         1,
+			Timestamp:     time.Date(2025, 5, 15, 8, 0, 0, 0, time.UTC),
+			PreviousHash:  "aa",
+			Validator:     "node1",
+			StakeSnapshot: uint256.NewInt(1000),
+			StateRoot:     "bb",
+			ReceiptsRoot:  "cc",
+		},
+		Transactions: []*Transaction{},
+	}
+	assert.Equal(t, vectorBlockEncoded, hex.EncodeToString(block.Encode()))
+	assert.Equal(t, vectorBlockHash, block.CalculateHash())
+
+	tx := vectorTransfer()
+	tx.ID = tx.CalculateHash()
+	block = NewBlock(2, []*Transaction{tx}, vectorBlockHash, "node1", uint256.NewInt(1000), "bb", "cc")
+
+	decoded, err := DecodeBlock(block.Encode())
+	assert.Nil(t, err)
+	assert.Equal(t, block.Hash, decoded.Hash)
+	assert.Equal(t, tx.ID, decoded.Transactions[0].ID)
+	assert.Equal(t, block.Encode(), decoded.Encode())
+
+	t.Run("Signature round trips", func(t *testing.T) {
+		block.Signature = []byte{0xde, 0xad, 0xbe, 0xef}
+		decoded, err := DecodeBlock(block.Encode())
+		assert.Nil(t, err)
+		assert.Equal(t, block.Signature, decoded.Signature)
+		assert.Equal(t, block.Hash, decoded.Hash)
+	})
+}
@@ -0,0 +1,316 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+var (
+	ErrInvalidEscrowTx = errors.New("invalid escrow parameters")
+	ErrEscrowNotFound  = errors.New("escrow not found")
+	ErrEscrowState     = errors.New("escrow action not allowed")
+)
+
+// EscrowAddress holds the tokens of every open escrow
+const EscrowAddress = "escrow_contract"
+
+// Bounds on the parameters of an escrow
+const (
+	MaxEscrowDuration          int64 = 365 * 24 * 60 * 60 // seconds
+	MaxEscrowDescriptionLength       = 256
+)
+
+// EscrowStatus is where an escrow is in its life
+type EscrowStatus string
+
+const (
+	EscrowPending   EscrowStatus = "pending"
+	EscrowConfirmed EscrowStatus = "confirmed" // two parties confirmed, the funds may be released
+	EscrowReleased  EscrowStatus = "released"  // paid to the receiver
+	EscrowCancelled EscrowStatus = "cancelled" // refunded to the sender
+)
+
+// Actions of an EscrowAction transaction
+const (
+	EscrowConfirm = "confirm"
+	EscrowRelease = "release"
+	EscrowCancel  = "cancel"
+)
+
+// EscrowCreateParams is the JSON carried in the Data of an EscrowCreate
+// transaction. The sender locks Amount of TokenID for the receiver in To.
+type EscrowCreateParams struct {
+	Arbitrator  string `json:"arbitrator,omitempty"`
+	ExpiresIn   int64  `json:"expires_in"` // seconds after the block that creates the escrow
+	Description string `json:"description,omitempty"`
+}
+
+// EscrowActionParams is the JSON carried in the Data of an EscrowAction transaction
+type EscrowActionParams struct {
+	ID     string `json:"id"` // ID of the transaction that created the escrow
+	Action string `json:"action"`
+}
+
+// Escrow is an amount of a token locked by its sender until it is released to
+// the receiver or refunded. Two of the sender, receiver and arbitrator must
+// confirm before the sender or the arbitrator can release it. The sender may
+// cancel while it is pending or once it has expired, and the receiver and the
+// arbitrator at any time before release.
+type Escrow struct {
+	ID            string       `json:"id"`
+	Sender        string       `json:"sender"`
+	Receiver      string       `json:"receiver"`
+	Arbitrator    string       `json:"arbitrator,omitempty"`
+	TokenID       string       `json:"token_id"`
+	Amount        uint256.Int  `json:"amount"`
+	Status        EscrowStatus `json:"status"`
+	Confirmations []string     `json:"confirmations,omitempty"`
+	Description   string       `json:"description,omitempty"`
+	CreatedAt     int64        `json:"created_at"`
+	ExpiresAt     int64        `json:"expires_at"`
+}
+
+// isParty reports whether address is the sender, receiver or arbitrator
+func (e *Escrow) isParty(address string) bool {
+	return address == e.Sender || address == e.Receiver || (e.Arbitrator != "" && address == e.Arbitrator)
+}
+
+func (e *Escrow) confirmedBy(address string) bool {
+	for _, confirmer := range e.Confirmations {
+		if confirmer == address {
+			return true
+		}
+	}
+	return false
+}
+
+// NewEscrowCreateTransaction builds an unsigned transaction that locks amount
+// of symbol for receiver
+func NewEscrowCreateTransaction(from, receiver, symbol string, amount uint256.Int, params EscrowCreateParams) (*Transaction, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(EscrowCreate, from, receiver, amount, nil)
+	tx.TokenID = symbol
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx)
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// NewEscrowActionTransaction builds an unsigned transaction that confirms,
+// releases or cancels an escrow
+func NewEscrowActionTransaction(from, id, action string) (*Transaction, error) {
+	data, err := json.Marshal(EscrowActionParams{ID: id, Action: action})
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(EscrowAction, from, "", uint256.Int{}, nil)
+	tx.TokenID = ""
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx)
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// decodeEscrowCreate parses and validates the parameters of an EscrowCreate transaction
+func decodeEscrowCreate(tx *Transaction) (*EscrowCreateParams, error) {
+	var params EscrowCreateParams
+	if err := json.Unmarshal(tx.Data, &params); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEscrowTx, err)
+	}
+	if tx.Amount.IsZero() {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidTxFields)
+	}
+	if tx.To == tx.From {
+		return nil, fmt.Errorf("%w: the receiver must not be the sender", ErrInvalidEscrowTx)
+	}
+	if params.Arbitrator == tx.From || params.Arbitrator == tx.To {
+		return nil, fmt.Errorf("%w: the arbitrator must be a third party", ErrInvalidEscrowTx)
+	}
+	if params.ExpiresIn <= 0 || params.ExpiresIn > MaxEscrowDuration {
+		return nil, fmt.Errorf("%w: expires_in must be 1-%d seconds", ErrInvalidEscrowTx, MaxEscrowDuration)
+	}
+	if len(params.Description) > MaxEscrowDescriptionLength {
+		return nil, fmt.Errorf("%w: description must be at most %d bytes", ErrInvalidEscrowTx, MaxEscrowDescriptionLength)
+	}
+	return &params, nil
+}
+
+// decodeEscrowAction parses and validates the parameters of an EscrowAction transaction
+func decodeEscrowAction(tx *Transaction) (*EscrowActionParams, error) {
+	var params EscrowActionParams
+	if err := json.Unmarshal(tx.Data, &params); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEscrowTx, err)
+	}
+	if params.ID == "" {
+		return nil, fmt.Errorf("%w: missing escrow ID", ErrInvalidEscrowTx)
+	}
+	switch params.Action {
+	case EscrowConfirm, EscrowRelease, EscrowCancel:
+	default:
+		return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidEscrowTx, params.Action)
+	}
+	if !tx.Amount.IsZero() {
+		return nil, fmt.Errorf("%w: an escrow action moves no amount of its own", ErrInvalidEscrowTx)
+	}
+	return &params, nil
+}
+
+// checkEscrowAction runs the checks of escrow transactions that depend on
+// chain state. Expiry is measured against the time of the block being applied,
+// or of the tip. Caller must hold bc.mu.
+func (bc *Blockchain) checkEscrowAction(tx *Transaction) error {
+	if tx.Type == EscrowCreate {
+		return bc.checkSpend(tx.TokenID, tx.From, EscrowAddress, tx.Amount)
+	}
+	_, _, err := bc.escrowFor(tx)
+	return err
+}
+
+// escrowFor returns the escrow an EscrowAction transaction acts on, once the
+// sender is allowed to take the action. Caller must hold bc.mu.
+func (bc *Blockchain) escrowFor(tx *Transaction) (*Escrow, *EscrowActionParams, error) {
+	params, err := decodeEscrowAction(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var e Escrow
+	if !bc.getRecord(EscrowKey(params.ID), &e) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrEscrowNotFound, params.ID)
+	}
+	if !e.isParty(tx.From) {
+		return nil, nil, fmt.Errorf("%w: %s is not a party to escrow %s", ErrEscrowState, tx.From, e.ID)
+	}
+	if e.Status == EscrowReleased || e.Status == EscrowCancelled {
+		return nil, nil, fmt.Errorf("%w: escrow %s is %s", ErrEscrowState, e.ID, e.Status)
+	}
+	_, now := bc.clock.Now()
+	expired := now >= e.ExpiresAt
+
+	switch params.Action {
+	case EscrowConfirm:
+		if e.Status != EscrowPending || expired {
+			return nil, nil, fmt.Errorf("%w: only a pending escrow that has not expired can be confirmed", ErrEscrowState)
+		}
+		if e.confirmedBy(tx.From) {
+			return nil, nil, fmt.Errorf("%w: %s already confirmed escrow %s", ErrEscrowState, tx.From, e.ID)
+		}
+	case EscrowRelease:
+		if e.Status != EscrowConfirmed {
+			return nil, nil, fmt.Errorf("%w: escrow %s is not confirmed", ErrEscrowState, e.ID)
+		}
+		if tx.From == e.Receiver {
+			return nil, nil, fmt.Errorf("%w: only the sender or the arbitrator can release", ErrEscrowState)
+		}
+	case EscrowCancel:
+		if tx.From == e.Sender && e.Status != EscrowPending && !expired {
+			return nil, nil, fmt.Errorf("%w: the sender can only cancel a confirmed escrow once it expires", ErrEscrowState)
+		}
+	}
+	return &e, params, nil
+}
+
+// applyEscrowCreate locks the amount with the escrow account and records the
+// escrow under the transaction's ID
+func (bc *Blockchain) applyEscrowCreate(tx *Transaction) ([]Event, error) {
+	params, err := decodeEscrowCreate(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := bc.checkEscrowAction(tx); err != nil {
+		return nil, err
+	}
+	if err := bc.TokenRegistry[tx.TokenID].Transfer(tx.From, EscrowAddress, tx.Amount); err != nil {
+		return nil, fmt.Errorf("escrow failed: %v", err)
+	}
+
+	_, now := bc.clock.Now()
+	e := &Escrow{
+		ID:          tx.ID,
+		Sender:      tx.From,
+		Receiver:    tx.To,
+		Arbitrator:  params.Arbitrator,
+		TokenID:     tx.TokenID,
+		Amount:      tx.Amount,
+		Status:      EscrowPending,
+		Description: params.Description,
+		CreatedAt:   now,
+		ExpiresAt:   now + params.ExpiresIn,
+	}
+	if err := bc.putRecord(EscrowKey(e.ID), e); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("   🔐 Escrow %s: %s %s from %s for %s\n", e.ID, e.Amount, e.TokenID, e.Sender, e.Receiver)
+	return []Event{{Type: EventEscrowCreated, TokenID: tx.TokenID, From: tx.From, To: EscrowAddress, Amount: tx.Amount, EscrowID: e.ID}}, nil
+}
+
+// applyEscrowAction confirms an escrow, or pays it out to the receiver on
+// release or back to the sender on cancel
+func (bc *Blockchain) applyEscrowAction(tx *Transaction) ([]Event, error) {
+	e, params, err := bc.escrowFor(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	event := Event{TokenID: e.TokenID, EscrowID: e.ID}
+	switch params.Action {
+	case EscrowConfirm:
+		e.Confirmations = append(e.Confirmations, tx.From)
+		if len(e.Confirmations) >= 2 {
+			e.Status = EscrowConfirmed
+		}
+		event.Type, event.From = EventEscrowConfirmed, tx.From
+	case EscrowRelease, EscrowCancel:
+		e.Status, event.Type, event.To = EscrowReleased, EventEscrowReleased, e.Receiver
+		if params.Action == EscrowCancel {
+			e.Status, event.Type, event.To = EscrowCancelled, EventEscrowCancelled, e.Sender
+		}
+		tk, exists := bc.TokenRegistry[e.TokenID]
+		if !exists {
+			return nil, fmt.Errorf("token %s not found", e.TokenID)
+		}
+		if err := tk.Transfer(EscrowAddress, event.To, e.Amount); err != nil {
+			return nil, fmt.Errorf("escrow %s failed: %v", params.Action, err)
+		}
+		event.From, event.Amount = EscrowAddress, e.Amount
+	}
+	if err := bc.putRecord(EscrowKey(e.ID), e); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("   🔐 Escrow %s %s by %s, now %s\n", e.ID, params.Action, tx.From, e.Status)
+	return []Event{event}, nil
+}
+
+// GetEscrow returns the escrow created by the transaction with ID id
+func (bc *Blockchain) GetEscrow(id string) (*Escrow, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	var e Escrow
+	if !bc.getRecord(EscrowKey(id), &e) {
+		return nil, fmt.Errorf("%w: %s", ErrEscrowNotFound, id)
+	}
+	return &e, nil
+}
+
+// EscrowsOf returns every escrow address is the sender, receiver or arbitrator of
+func (bc *Blockchain) EscrowsOf(address string) []*Escrow {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	escrows := []*Escrow{}
+	for _, key := range bc.recordKeys(EscrowKey("")) {
+		var e Escrow
+		if bc.getRecord(key, &e) && e.isParty(address) {
+			escrows = append(escrows, &e)
+		}
+	}
+	return escrows
+}
@@ -0,0 +1,180 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func signedEscrowCreate(t *testing.T, key *btcec.PrivateKey, nonce uint64, receiver string, amount uint64, params EscrowCreateParams) *Transaction {
+	tx, err := NewEscrowCreateTransaction("", receiver, "BHX", uint256.NewInt(amount), params)
+	assert.Nil(t, err)
+	tx.Nonce = nonce
+	signTestTx(t, tx, key)
+	return tx
+}
+
+func signedEscrowAction(t *testing.T, key *btcec.PrivateKey, nonce uint64, id, action string) *Transaction {
+	tx, err := NewEscrowActionTransaction("", id, action)
+	assert.Nil(t, err)
+	tx.Nonce = nonce
+	signTestTx(t, tx, key)
+	return tx
+}
+
+func TestEscrowLifecycle(t *testing.T) {
+	bc, key := newTestChain(t)
+	sender := PublicKeyToAddress(key.PubKey())
+	receiverKey, _ := btcec.NewPrivateKey()
+	receiver := PublicKeyToAddress(receiverKey.PubKey())
+	arbitratorKey, _ := btcec.NewPrivateKey()
+	arbitrator := PublicKeyToAddress(arbitratorKey.PubKey())
+	strangerKey, _ := btcec.NewPrivateKey()
+	params := EscrowCreateParams{Arbitrator: arbitrator, ExpiresIn: 3600, Description: "laptop"}
+
+	fund := signedTokenTx(t, key, TokenTransfer, 0, "BHX", receiver, 150)
+	create := signedEscrowCreate(t, key, 1, receiver, 200, params)
+	assert.True(t, bc.AddBlock(nextBlock(bc, fund, create)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, create))
+	assert.Equal(t, uint256.NewInt(200), bhxBalance(bc, EscrowAddress))
+
+	receipt, _ := bc.GetReceipt(create.ID)
+	assert.Contains(t, receipt.Events, Event{Type: EventEscrowCreated, TokenID: "BHX", From: sender, To: EscrowAddress, Amount: uint256.NewInt(200), EscrowID: create.ID})
+
+	e, err := bc.GetEscrow(create.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, EscrowPending, e.Status)
+	assert.Equal(t, e.CreatedAt+3600, e.ExpiresAt)
+	assert.Equal(t, []*Escrow{e}, bc.EscrowsOf(arbitrator))
+
+	t.Run("Only parties act", func(t *testing.T) {
+		confirm := signedEscrowAction(t, strangerKey, 0, create.ID, EscrowConfirm)
+		assert.ErrorIs(t, bc.checkEscrowAction(confirm), ErrEscrowState)
+	})
+
+	t.Run("Release needs two confirmations", func(t *testing.T) {
+		release := signedEscrowAction(t, key, 2, create.ID, EscrowRelease)
+		assert.ErrorIs(t, bc.checkEscrowAction(release), ErrEscrowState)
+
+		byReceiver := signedEscrowAction(t, receiverKey, 0, create.ID, EscrowConfirm)
+		bySender := signedEscrowAction(t, key, 2, create.ID, EscrowConfirm)
+		assert.True(t, bc.AddBlock(nextBlock(bc, byReceiver, bySender)))
+		assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, byReceiver))
+		assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, bySender))
+
+		e, _ := bc.GetEscrow(create.ID)
+		assert.Equal(t, EscrowConfirmed, e.Status)
+		assert.Equal(t, []string{receiver, sender}, e.Confirmations)
+	})
+
+	t.Run("The sender cannot cancel a confirmed escrow before it expires", func(t *testing.T) {
+		cancel := signedEscrowAction(t, key, 3, create.ID, EscrowCancel)
+		assert.ErrorIs(t, bc.checkEscrowAction(cancel), ErrEscrowState)
+	})
+
+	t.Run("The receiver cannot release", func(t *testing.T) {
+		release := signedEscrowAction(t, receiverKey, 1, create.ID, EscrowRelease)
+		assert.ErrorIs(t, bc.checkEscrowAction(release), ErrEscrowState)
+	})
+
+	release := signedEscrowAction(t, key, 3, create.ID, EscrowRelease)
+	assert.True(t, bc.AddBlock(nextBlock(bc, release)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, release))
+	assert.True(t, bhxBalance(bc, EscrowAddress).IsZero())
+	balance := bhxBalance(bc, receiver)
+	assert.True(t, balance.Gt(uint256.NewInt(200)))
+
+	receipt, _ = bc.GetReceipt(release.ID)
+	assert.Contains(t, receipt.Events, Event{Type: EventEscrowReleased, TokenID: "BHX", From: EscrowAddress, To: receiver, Amount: uint256.NewInt(200), EscrowID: create.ID})
+
+	again := signedEscrowAction(t, arbitratorKey, 0, create.ID, EscrowCancel)
+	assert.ErrorIs(t, bc.checkEscrowAction(again), ErrEscrowState)
+	assertStateTreeMatches(t, bc)
+}
+
+func TestEscrowReverts(t *testing.T) {
+	bc, key := newTestChain(t)
+	root := bc.currentStateTree().Root()
+
+	create := signedEscrowCreate(t, key, 0, "0xBob", 300, EscrowCreateParams{ExpiresIn: 3600})
+	assert.True(t, bc.AddBlock(nextBlock(bc, create)))
+	afterCreate := bc.currentStateTree().Root()
+
+	cancel := signedEscrowAction(t, key, 1, create.ID, EscrowCancel)
+	assert.True(t, bc.AddBlock(nextBlock(bc, cancel)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, cancel))
+	e, _ := bc.GetEscrow(create.ID)
+	assert.Equal(t, EscrowCancelled, e.Status)
+
+	_, err := bc.disconnectTip()
+	assert.Nil(t, err)
+	assert.Equal(t, afterCreate, bc.currentStateTree().Root())
+	e, _ = bc.GetEscrow(create.ID)
+	assert.Equal(t, EscrowPending, e.Status)
+	assert.Equal(t, uint256.NewInt(300), bhxBalance(bc, EscrowAddress))
+
+	_, err = bc.disconnectTip()
+	assert.Nil(t, err)
+	_, err = bc.GetEscrow(create.ID)
+	assert.ErrorIs(t, err, ErrEscrowNotFound)
+	assert.Equal(t, root, bc.currentStateTree().Root())
+	assertStateTreeMatches(t, bc)
+}
+
+func TestDecodeEscrowCreate(t *testing.T) {
+	cases := []struct {
+		name   string
+		to     string
+		amount uint64
+		params EscrowCreateParams
+		valid  bool
+	}{
+		{"escrow", "0xBob", 10, EscrowCreateParams{Arbitrator: "0xCarol", ExpiresIn: 3600}, true},
+		{"zero amount", "0xBob", 0, EscrowCreateParams{ExpiresIn: 3600}, false},
+		{"to self", "0xAlice", 10, EscrowCreateParams{ExpiresIn: 3600}, false},
+		{"arbitrator is a party", "0xBob", 10, EscrowCreateParams{Arbitrator: "0xBob", ExpiresIn: 3600}, false},
+		{"no expiry", "0xBob", 10, EscrowCreateParams{}, false},
+		{"too long", "0xBob", 10, EscrowCreateParams{ExpiresIn: MaxEscrowDuration + 1}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tx, err := NewEscrowCreateTransaction("0xAlice", c.to, "BHX", uint256.NewInt(c.amount), c.params)
+			assert.Nil(t, err)
+			assert.Equal(t, c.valid, checkFields(tx) == nil)
+		})
+	}
+}
+
+func TestEscrowsSurviveRestart(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+	sender := PublicKeyToAddress(key.PubKey())
+
+	g := testGenesis(t)
+	g.Tokens[0].Balances[sender] = uint256.NewInt(1000)
+	validator := g.Validators[0].Address
+
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	bc, err := openChain(db, g, DefaultHistoryConfig())
+	assert.Nil(t, err)
+
+	create := signedEscrowCreate(t, key, 0, "0xBob", 300, EscrowCreateParams{ExpiresIn: 3600})
+	assert.True(t, bc.AddBlock(blockBy(bc, validator, create)))
+	root := bc.StateRoot()
+	e, err := bc.GetEscrow(create.ID)
+	assert.Nil(t, err)
+
+	restarted, err := openChain(db, g, DefaultHistoryConfig())
+	assert.Nil(t, err)
+	assert.Equal(t, root, restarted.StateRoot())
+	restored, err := restarted.GetEscrow(create.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, e, restored)
+}
@@ -0,0 +1,213 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// EventType names what an event records. Balance events move Amount of
+// TokenID from From to To; mints have no From and burns have no To. Events
+// without a TokenID move native account balance. Token admin events move
+// nothing: From is the sender and To the affected account, except for role
+// changes, where From is the previous holder of Role and To the new one.
+// Item events move Amount of item ItemID in collection TokenID the same way;
+// approval events move nothing, From is the owner and To the approved
+// address, which is empty when an item approval is cleared. A deploy event
+// has the deployer in From and the new contract in To; events a contract
+// emits itself have it in From and carry its Topic and Data.
+//
+// Escrow events carry the EscrowID. EscrowCreated moves the amount from the
+// sender to the escrow account, and EscrowReleased and EscrowCancelled move
+// it on to the receiver or back to the sender; EscrowConfirmed moves nothing
+// and has the confirming party in From. A Swap moves Amount of TokenID from
+// the trader in From to the pool in To, and AmountOut of TokenOut back.
+// Liquidity deposits and withdrawals emit a Transfer for each token.
+//
+// OTC events carry the OrderID. OTCCreated moves the offer from the creator
+// to the OTC account and OTCCancelled moves it back; a fill emits a Transfer
+// of the requested amount from the counterparty to the creator and an
+// OTCFilled moving the offer from the OTC account to the counterparty.
+// Multisig proposal events carry the ProposalID. MultisigCreated,
+// MultisigProposed and MultisigApproved move nothing; From is the sender and
+// To the wallet. MultisigExecuted moves the proposal's amount from the wallet
+// to its recipient.
+type EventType string
+
+const (
+	EventTransfer EventType = "Transfer"
+	EventMint     EventType = "Mint"
+	EventBurn     EventType = "Burn"
+	EventStake    EventType = "Stake"
+	EventUnstake  EventType = "Unstake"
+	EventFee      EventType = "Fee"      // the validator's share of a transaction fee
+	EventVest     EventType = "Vest"     // a locked transfer under a vesting schedule
+	EventApproval EventType = "Approval" // an allowance set by a permit
+
+	EventRoleChanged   EventType = "RoleChanged"
+	EventPaused        EventType = "Paused"
+	EventUnpaused      EventType = "Unpaused"
+	EventFrozen        EventType = "Frozen"
+	EventUnfrozen      EventType = "Unfrozen"
+	EventBlocklisted   EventType = "Blocklisted"
+	EventUnblocklisted EventType = "Unblocklisted"
+
+	EventItemMint        EventType = "ItemMint"
+	EventItemTransfer    EventType = "ItemTransfer"
+	EventItemBurn        EventType = "ItemBurn"
+	EventItemApproval    EventType = "ItemApproval" // To may move item ItemID for its owner
+	EventOperatorGranted EventType = "OperatorGranted"
+	EventOperatorRevoked EventType = "OperatorRevoked"
+
+	EventContractDeployed EventType = "ContractDeployed"
+	EventContract         EventType = "ContractEvent" // emitted by contract code
+
+	EventEscrowCreated   EventType = "EscrowCreated"
+	EventEscrowConfirmed EventType = "EscrowConfirmed"
+	EventEscrowReleased  EventType = "EscrowReleased"
+	EventEscrowCancelled EventType = "EscrowCancelled"
+	EventSwap            EventType = "Swap"
+
+	EventOTCCreated       EventType = "OTCCreated"
+	EventOTCFilled        EventType = "OTCFilled"
+	EventOTCCancelled     EventType = "OTCCancelled"
+	EventMultisigCreated  EventType = "MultisigCreated"
+	EventMultisigProposed EventType = "MultisigProposed"
+	EventMultisigApproved EventType = "MultisigApproved"
+	EventMultisigExecuted EventType = "MultisigExecuted"
+)
+
+// Bounds on a single log query
+const (
+	MaxLogBlockRange = 10000
+	MaxLogResults    = 10000
+)
+
+var (
+	ErrLogRangeTooLarge = fmt.Errorf("log query spans more than %d blocks", MaxLogBlockRange)
+	ErrTooManyLogs      = fmt.Errorf("log query matches more than %d events, narrow the filter", MaxLogResults)
+	ErrInvalidLogRange  = errors.New("from_block is after to_block")
+)
+
+// Log is an event as recorded in the chain's event log. LogIndex is the
+// event's position among all events of its block.
+type Log struct {
+	Event
+	BlockHeight uint64 `json:"block_height"`
+	BlockHash   string `json:"block_hash"`
+	TxID        string `json:"tx_id"`
+	TxIndex     int    `json:"tx_index"`
+	LogIndex    int    `json:"log_index"`
+}
+
+// LogFilter selects events from the log. Empty fields match everything.
+type LogFilter struct {
+	FromBlock uint64
+	ToBlock   uint64 // 0 means the current tip
+	Types     []EventType
+	Address   string // matches either side of the event
+	Token     string // matches the event's token or the token a swap paid out
+}
+
+func (f *LogFilter) matches(log *Log) bool {
+	if f.Address != "" && log.From != f.Address && log.To != f.Address {
+		return false
+	}
+	if f.Token != "" && log.TokenID != f.Token && log.TokenOut != f.Token {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if log.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Events are stored as log:<height>:<log index> so a block range is one key range
+func logPrefix(height uint64) []byte {
+	return []byte(fmt.Sprintf("log:%020d:", height))
+}
+
+func logKey(height uint64, logIndex int) []byte {
+	return []byte(fmt.Sprintf("log:%020d:%06d", height, logIndex))
+}
+
+// putLogs adds the events of a block's receipts to batch
+func putLogs(batch *leveldb.Batch, block *Block, receipts []*Receipt) error {
+	logIndex := 0
+	for _, receipt := range receipts {
+		for _, event := range receipt.Events {
+			data, err := json.Marshal(&Log{
+				Event:       event,
+				BlockHeight: block.Header.Index,
+				BlockHash:   block.Hash,
+				TxID:        receipt.TxID,
+				TxIndex:     receipt.Index,
+				LogIndex:    logIndex,
+			})
+			if err != nil {
+				return err
+			}
+			batch.Put(logKey(block.Header.Index, logIndex), data)
+			logIndex++
+		}
+	}
+	return nil
+}
+
+// deleteLogs adds the removal of every event at height to batch
+func (bc *Blockchain) deleteLogs(batch *leveldb.Batch, height uint64) error {
+	iter := bc.DB.NewIterator(util.BytesPrefix(logPrefix(height)), nil)
+	defer iter.Release()
+	for iter.Next() {
+		batch.Delete(append([]byte{}, iter.Key()...))
+	}
+	return iter.Error()
+}
+
+// GetLogs returns the events on the main chain that match filter, oldest first
+func (bc *Blockchain) GetLogs(filter LogFilter) ([]*Log, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if filter.ToBlock != 0 && filter.FromBlock > filter.ToBlock {
+		return nil, ErrInvalidLogRange
+	}
+	tip := uint64(len(bc.Blocks) - 1)
+	if filter.FromBlock > tip {
+		return []*Log{}, nil
+	}
+	to := filter.ToBlock
+	if to == 0 || to > tip {
+		to = tip
+	}
+	if to-filter.FromBlock >= MaxLogBlockRange {
+		return nil, ErrLogRangeTooLarge
+	}
+
+	iter := bc.DB.NewIterator(&util.Range{Start: logPrefix(filter.FromBlock), Limit: logPrefix(to + 1)}, nil)
+	defer iter.Release()
+
+	logs := []*Log{}
+	for iter.Next() {
+		var log Log
+		if err := json.Unmarshal(iter.Value(), &log); err != nil {
+			return nil, fmt.Errorf("corrupt log entry %q: %v", iter.Key(), err)
+		}
+		if !filter.matches(&log) {
+			continue
+		}
+		if len(logs) == MaxLogResults {
+			return nil, ErrTooManyLogs
+		}
+		logs = append(logs, &log)
+	}
+	return logs, iter.Error()
+}
@@ -0,0 +1,87 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventLog(t *testing.T) {
+	bc, key := newTestChain(t)
+	sender := PublicKeyToAddress(key.PubKey())
+
+	payment := signedPayment(t, key, 0, 100)
+	unknownToken := NewTransaction(TokenTransfer, "", "0xCarol", uint256.NewInt(5), nil)
+	unknownToken.TokenID = "ABC"
+	unknownToken.Nonce = 1
+	signTestTx(t, unknownToken, key)
+
+	assert.True(t, bc.AddBlock(nextBlock(bc, payment, unknownToken)))
+	assert.True(t, bc.AddBlock(blockBy(bc, node2)))
+
+	t.Run("Every event of a block in order", func(t *testing.T) {
+		logs, err := bc.GetLogs(LogFilter{FromBlock: 1, ToBlock: 1})
+		assert.Nil(t, err)
+
+		var types []EventType
+		for i, log := range logs {
+			assert.Equal(t, i, log.LogIndex)
+			assert.Equal(t, uint64(1), log.BlockHeight)
+			assert.Equal(t, bc.Blocks[1].Hash, log.BlockHash)
+			types = append(types, log.Type)
+		}
+		// Reward, then fee, burn and transfer of the payment, then the fee and
+		// burn of the failed transfer, which is still charged
+		assert.Equal(t, []EventType{EventTransfer, EventFee, EventBurn, EventTransfer, EventFee, EventBurn}, types)
+		assert.Equal(t, unknownToken.ID, logs[5].TxID)
+		assert.Equal(t, 2, logs[5].TxIndex)
+	})
+
+	t.Run("Fee events add up to the fee", func(t *testing.T) {
+		receipt, err := bc.GetReceipt(payment.ID)
+		assert.Nil(t, err)
+		logs, err := bc.GetLogs(LogFilter{Types: []EventType{EventFee, EventBurn}, Address: sender})
+		assert.Nil(t, err)
+
+		var total uint256.Int
+		for _, log := range logs {
+			if log.TxID == payment.ID {
+				total, _ = total.Add(log.Amount)
+			}
+		}
+		assert.Equal(t, receipt.Fee, total)
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		logs, err := bc.GetLogs(LogFilter{Address: "0xBob"})
+		assert.Nil(t, err)
+		assert.Len(t, logs, 1)
+		assert.Equal(t, payment.ID, logs[0].TxID)
+
+		logs, err = bc.GetLogs(LogFilter{Types: []EventType{EventTransfer}, Token: "BHX"})
+		assert.Nil(t, err)
+		assert.Len(t, logs, 3, "two rewards and the payment")
+
+		logs, err = bc.GetLogs(LogFilter{FromBlock: 2, Address: node2})
+		assert.Nil(t, err)
+		assert.Len(t, logs, 1)
+
+		logs, err = bc.GetLogs(LogFilter{FromBlock: 5})
+		assert.Nil(t, err)
+		assert.Empty(t, logs)
+	})
+
+	t.Run("Bad ranges", func(t *testing.T) {
+		_, err := bc.GetLogs(LogFilter{FromBlock: 2, ToBlock: 1})
+		assert.ErrorIs(t, err, ErrInvalidLogRange)
+	})
+
+	t.Run("Disconnected blocks leave the log", func(t *testing.T) {
+		_, err := bc.disconnectTip()
+		assert.Nil(t, err)
+		logs, err := bc.GetLogs(LogFilter{Address: node2})
+		assert.Nil(t, err)
+		assert.Empty(t, logs)
+	})
+}
@@ -0,0 +1,236 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+// FeeToken is the token every transaction fee is paid in
+const FeeToken = "BHX"
+
+// Intrinsic gas charged per transaction type
+const (
+	TransferGas          uint64 = 10
+	TokenTransferGas     uint64 = 15
+	TokenSupplyGas       uint64 = 20 // mint and burn
+	StakeGas             uint64 = 25
+	SmartContractCallGas uint64 = 50
+	TokenCreateGas       uint64 = 100
+	TokenAdminGas        uint64 = 30 // role changes, pause, freeze and blocklist
+	TokenVestGas         uint64 = 40
+	TokenPermitGas       uint64 = 35 // recovers the owner's signature on top of the sender's
+	CollectionCreateGas  uint64 = 100
+	ItemGas              uint64 = 20 // item mints, transfers, burns and approvals
+	ContractDeployGas    uint64 = 200
+	EscrowGas            uint64 = 30 // escrow creation and actions
+	LiquidityGas         uint64 = 40 // adding and removing liquidity
+	SwapGas              uint64 = 40
+	OTCGas               uint64 = 30 // OTC orders, fills and cancellations
+	MultisigGas          uint64 = 30 // multisig wallets, proposals and approvals
+	DataGasPerByte       uint64 = 1
+)
+
+var (
+	ErrIntrinsicGas      = errors.New("gas limit below intrinsic gas")
+	ErrGasPriceTooLow    = errors.New("gas price below minimum")
+	ErrInsufficientFunds = errors.New("insufficient funds for amount plus fee")
+)
+
+// FeeConfig controls how transactions are priced and where fees go
+type FeeConfig struct {
+	MinGasPrice uint64 // Lowest gas price accepted, in BHX base units per gas
+	BurnPercent uint64 // Share of each fee that is burned; the rest goes to the block validator
+}
+
+// Gwei is 10^9 base units, a billionth of a BHX
+const Gwei uint64 = 1000000000
+
+// DefaultFeeConfig returns the fee rules used by nodes
+func DefaultFeeConfig() FeeConfig {
+	return FeeConfig{
+		MinGasPrice: Gwei,
+		BurnPercent: 50,
+	}
+}
+
+// IntrinsicGas returns the gas a transaction uses before any execution
+func IntrinsicGas(tx *Transaction) uint64 {
+	var gas uint64
+	switch tx.Type {
+	case RegularTransfer:
+		gas = TransferGas
+	case TokenTransfer:
+		gas = TokenTransferGas
+	case TokenMint, TokenBurn:
+		gas = TokenSupplyGas
+	case StakeDeposit, StakeWithdraw:
+		gas = StakeGas
+	case SmartContractCall:
+		gas = SmartContractCallGas
+	case TokenCreate:
+		gas = TokenCreateGas
+	case TokenAdmin:
+		gas = TokenAdminGas
+	case TokenVest:
+		gas = TokenVestGas
+	case TokenPermit:
+		gas = TokenPermitGas
+	case CollectionCreate:
+		gas = CollectionCreateGas
+	case ItemMint, ItemTransfer, ItemBurn, ItemApprove:
+		gas = ItemGas
+	case ContractDeploy:
+		gas = ContractDeployGas
+	case EscrowCreate, EscrowAction:
+		gas = EscrowGas
+	case AddLiquidity, RemoveLiquidity:
+		gas = LiquidityGas
+	case Swap:
+		gas = SwapGas
+	case OTCCreate, OTCAction:
+		gas = OTCGas
+	case MultisigCreate, MultisigAction:
+		gas = MultisigGas
+	default:
+		gas = TransferGas
+	}
+	return gas + uint64(len(tx.Data))*DataGasPerByte
+}
+
+// TransactionFee returns the gas used by tx and the BHX fee it pays. The
+// product of two uint64 values always fits in 256 bits.
+func TransactionFee(tx *Transaction) (uint64, uint256.Int) {
+	gas := IntrinsicGas(tx)
+	fee, _ := uint256.NewInt(gas).Mul(uint256.NewInt(tx.GasPrice))
+	return gas, fee
+}
+
+// checkGas validates the gas fields of a non-system transaction
+func (bc *Blockchain) checkGas(tx *Transaction) error {
+	if gas := IntrinsicGas(tx); tx.GasLimit < gas {
+		return fmt.Errorf("%w: limit %d, need %d", ErrIntrinsicGas, tx.GasLimit, gas)
+	}
+	if tx.GasPrice < bc.Fees.MinGasPrice {
+		return fmt.Errorf("%w: price %d, minimum %d", ErrGasPriceTooLow, tx.GasPrice, bc.Fees.MinGasPrice)
+	}
+	return nil
+}
+
+// fundsNeeded returns the BHX tx draws from its sender. Transfers, stakes,
+// burns, vesting grants, escrows, OTC offers, swaps, liquidity deposits and
+// contract values in BHX take the amount from the same balance as the fee,
+// and contract transactions may use their whole gas limit. A sum that
+// overflows is more than any balance, so it saturates at the largest amount.
+func fundsNeeded(tx *Transaction) uint256.Int {
+	_, fee := TransactionFee(tx)
+	if isContractTx(tx.Type) {
+		fee, _ = uint256.NewInt(tx.GasLimit).Mul(uint256.NewInt(tx.GasPrice))
+	}
+	needed, err := fee.Add(feeTokenSpent(tx))
+	if err != nil {
+		return uint256.Max
+	}
+	return needed
+}
+
+// feeTokenSpent returns the BHX tx moves out of its sender besides its fee
+func feeTokenSpent(tx *Transaction) uint256.Int {
+	switch tx.Type {
+	case TokenTransfer, StakeDeposit, TokenBurn, TokenVest, ContractDeploy, SmartContractCall, EscrowCreate, Swap, OTCCreate:
+		if tx.TokenID == FeeToken {
+			return tx.Amount
+		}
+	case AddLiquidity:
+		if tx.TokenID == FeeToken {
+			return tx.Amount
+		}
+		if params, err := decodeLiquidity(tx); err == nil && params.Token == FeeToken {
+			return params.Amount
+		}
+	}
+	return uint256.Int{}
+}
+
+// feeBalance returns the BHX that address can spend, leaving out amounts locked
+// by vesting, or zero if it cannot be read
+func (bc *Blockchain) feeBalance(address string) uint256.Int {
+	feeToken, exists := bc.TokenRegistry[FeeToken]
+	if !exists {
+		return uint256.Int{}
+	}
+	balance, err := feeToken.Spendable(address)
+	if err != nil {
+		return uint256.Int{}
+	}
+	return balance
+}
+
+// checkFunds verifies the sender can pay both the amount moved by tx and its fee
+func (bc *Blockchain) checkFunds(tx *Transaction) error {
+	needed := fundsNeeded(tx)
+	if balance := bc.feeBalance(tx.From); balance.Lt(needed) {
+		return fmt.Errorf("%w: has %s %s, needs %s", ErrInsufficientFunds, balance, FeeToken, needed)
+	}
+	return nil
+}
+
+// chargeFee takes the fee for the intrinsic gas of tx from the sender, pays
+// the validator's share and burns the rest. Caller must hold bc.mu.
+func (bc *Blockchain) chargeFee(tx *Transaction, validator string) (uint64, uint256.Int, error) {
+	return bc.chargeGas(tx, validator, IntrinsicGas(tx))
+}
+
+// chargeGas is chargeFee for gas units of tx's gas price, which contract
+// transactions pay once they know how much gas they used. Caller must hold bc.mu.
+func (bc *Blockchain) chargeGas(tx *Transaction, validator string, gas uint64) (uint64, uint256.Int, error) {
+	fee, _ := uint256.NewInt(gas).Mul(uint256.NewInt(tx.GasPrice))
+	if fee.IsZero() {
+		return gas, fee, nil
+	}
+
+	feeToken, exists := bc.TokenRegistry[FeeToken]
+	if !exists {
+		return 0, uint256.Int{}, fmt.Errorf("fee token %s not found", FeeToken)
+	}
+	if balance, _ := feeToken.Spendable(tx.From); balance.Lt(fee) {
+		return 0, uint256.Int{}, fmt.Errorf("%w: has %s %s, fee is %s", ErrInsufficientFunds, balance, FeeToken, fee)
+	}
+
+	burned, paid := bc.splitFee(fee)
+	if err := feeToken.Transfer(tx.From, validator, paid); err != nil {
+		return 0, uint256.Int{}, fmt.Errorf("failed to pay fee: %v", err)
+	}
+	if !burned.IsZero() {
+		if err := feeToken.Burn(tx.From, burned); err != nil {
+			return 0, uint256.Int{}, fmt.Errorf("failed to burn fee: %v", err)
+		}
+	}
+
+	fmt.Printf("   💸 Charged fee %s %s for %s (%s to %s, %s burned)\n", fee, FeeToken, tx.ID, paid, validator, burned)
+	return gas, fee, nil
+}
+
+// splitFee returns the part of fee that is burned and the part paid to the validator
+func (bc *Blockchain) splitFee(fee uint256.Int) (burned, paid uint256.Int) {
+	burned, err := fee.MulDiv(uint256.NewInt(bc.Fees.BurnPercent), uint256.NewInt(100))
+	if err != nil || burned.Gt(fee) {
+		burned = fee
+	}
+	paid, _ = fee.Sub(burned)
+	return burned, paid
+}
+
+// feeEvents describes how chargeFee moved a transaction's fee
+func (bc *Blockchain) feeEvents(tx *Transaction, validator string, fee uint256.Int) []Event {
+	burned, paid := bc.splitFee(fee)
+	var events []Event
+	if !paid.IsZero() {
+		events = append(events, Event{Type: EventFee, TokenID: FeeToken, From: tx.From, To: validator, Amount: paid})
+	}
+	if !burned.IsZero() {
+		events = append(events, Event{Type: EventBurn, TokenID: FeeToken, From: tx.From, Amount: burned})
+	}
+	return events
+}
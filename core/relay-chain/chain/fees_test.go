@@ -0,0 +1,80 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+func feeTestChain(t *testing.T, balance uint64) *Blockchain {
+	bhx := token.NewTokenWithMaxSupply("Blockchain Hex", "BHX", 18, uint256.NewInt(1000000))
+	if balance > 0 {
+		assert.Nil(t, bhx.Mint("0xAlice", uint256.NewInt(balance)))
+	}
+	return &Blockchain{
+		Fees:          DefaultFeeConfig(),
+		TokenRegistry: map[string]*token.Token{"BHX": bhx},
+	}
+}
+
+func TestIntrinsicGas(t *testing.T) {
+	assert.Equal(t, TransferGas, IntrinsicGas(&Transaction{Type: RegularTransfer}))
+	assert.Equal(t, StakeGas, IntrinsicGas(&Transaction{Type: StakeWithdraw}))
+	assert.Equal(t, SmartContractCallGas+4*DataGasPerByte, IntrinsicGas(&Transaction{Type: SmartContractCall, Data: []byte("call")}))
+
+	gas, fee := TransactionFee(&Transaction{Type: TokenTransfer, GasPrice: 3})
+	assert.Equal(t, TokenTransferGas, gas)
+	assert.Equal(t, uint256.NewInt(TokenTransferGas*3), fee)
+}
+
+func TestCheckGas(t *testing.T) {
+	bc := feeTestChain(t, 0)
+
+	tx := &Transaction{Type: TokenTransfer, GasLimit: TokenTransferGas - 1, GasPrice: Gwei}
+	assert.ErrorIs(t, bc.checkGas(tx), ErrIntrinsicGas)
+
+	// The minimum price is 1 gwei, not one base unit
+	tx = &Transaction{Type: TokenTransfer, GasLimit: TokenTransferGas, GasPrice: Gwei - 1}
+	assert.ErrorIs(t, bc.checkGas(tx), ErrGasPriceTooLow)
+
+	tx = &Transaction{Type: TokenTransfer, GasLimit: TokenTransferGas, GasPrice: Gwei}
+	assert.Nil(t, bc.checkGas(tx))
+}
+
+func TestCheckFunds(t *testing.T) {
+	bc := feeTestChain(t, 100)
+
+	// 90 BHX plus a 15 BHX fee exceeds the balance
+	tx := &Transaction{Type: TokenTransfer, From: "0xAlice", TokenID: "BHX", Amount: uint256.NewInt(90), GasPrice: 1}
+	assert.ErrorIs(t, bc.checkFunds(tx), ErrInsufficientFunds)
+
+	tx.Amount = uint256.NewInt(85)
+	assert.Nil(t, bc.checkFunds(tx))
+
+	// Amounts in other tokens do not draw on the BHX balance
+	tx = &Transaction{Type: TokenTransfer, From: "0xAlice", TokenID: "USDT", Amount: uint256.NewInt(1000), GasPrice: 1}
+	assert.Nil(t, bc.checkFunds(tx))
+}
+
+func TestChargeFee(t *testing.T) {
+	bc := feeTestChain(t, 100)
+	bhx := bc.TokenRegistry["BHX"]
+
+	tx := &Transaction{Type: StakeDeposit, From: "0xAlice", GasPrice: 2}
+	gas, fee, err := bc.chargeFee(tx, "0xValidator")
+	assert.Nil(t, err)
+	assert.Equal(t, StakeGas, gas)
+	assert.Equal(t, uint256.NewInt(50), fee)
+
+	alice, _ := bhx.BalanceOf("0xAlice")
+	validator, _ := bhx.BalanceOf("0xValidator")
+	assert.Equal(t, uint256.NewInt(50), alice)
+	assert.Equal(t, uint256.NewInt(25), validator)
+	assert.Equal(t, uint256.NewInt(75), bhx.TotalSupply())
+
+	tx.GasPrice = 10
+	_, _, err = bc.chargeFee(tx, "0xValidator")
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+}
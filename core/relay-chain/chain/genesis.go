@@ -0,0 +1,327 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+var ErrInvalidGenesis = errors.New("invalid genesis config")
+
+// GenesisConfig declares the initial state and rules of a network. Every node
+// on a network must start from the same config, since the genesis block hash
+// is derived from it.
+type GenesisConfig struct {
+	ChainID     uint64             `json:"chain_id"`
+	GenesisTime time.Time          `json:"genesis_time"`
+	Tokens      []GenesisToken     `json:"tokens"`
+	Accounts    []GenesisAccount   `json:"accounts"`
+	Validators  []GenesisValidator `json:"validators"`
+	Consensus   ConsensusParams    `json:"consensus"`
+}
+
+// GenesisToken is a token that exists from genesis along with its initial balances
+type GenesisToken struct {
+	Name      string                 `json:"name"`
+	Symbol    string                 `json:"symbol"`
+	Decimals  uint8                  `json:"decimals"`
+	MaxSupply uint256.Int            `json:"max_supply"` // 0 means unlimited
+	Balances  map[string]uint256.Int `json:"balances"`
+	// MintAuthority may mint the token through TokenMint transactions; empty fixes the supply
+	MintAuthority string `json:"mint_authority,omitempty"`
+	// Admin assigns the token's roles through TokenAdmin transactions; empty fixes them
+	Admin string `json:"admin,omitempty"`
+	// Ledger restores a ledger exported from another network in place of
+	// Balances, with its allowances and vesting schedules
+	Ledger *token.Ledger `json:"ledger,omitempty"`
+}
+
+// GenesisAccount is an initial native account balance
+type GenesisAccount struct {
+	Address string      `json:"address"`
+	Balance uint256.Int `json:"balance"`
+}
+
+// GenesisValidator is a validator staked from genesis. Address may be left
+// empty, in which case it is derived from PubKey.
+type GenesisValidator struct {
+	Address string      `json:"address,omitempty"`
+	PubKey  string      `json:"pub_key"` // hex of the 33-byte compressed secp256k1 key
+	Stake   uint256.Int `json:"stake"`
+}
+
+// ConsensusParams are the block and fee rules fixed at genesis. Amounts are
+// decimal strings in JSON; plain numbers are accepted too.
+type ConsensusParams struct {
+	BlockReward uint256.Int `json:"block_reward"`
+	MinGasPrice uint64      `json:"min_gas_price"`
+	BurnPercent uint64      `json:"burn_percent"`
+}
+
+// LoadGenesis reads and validates a genesis config from a JSON file
+func LoadGenesis(path string) (*GenesisConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read genesis file: %v", err)
+	}
+
+	var g GenesisConfig
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGenesis, err)
+	}
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// DefaultGenesis returns the development network config. The validator keys
+// are derived from public seeds and must not be used on a real network.
+func DefaultGenesis() *GenesisConfig {
+	return &GenesisConfig{
+		ChainID:     DefaultChainID,
+		GenesisTime: time.Date(2025, 5, 15, 7, 55, 0, 0, time.UTC),
+		Tokens: []GenesisToken{{
+			Name:      "Blockchain Hex",
+			Symbol:    "BHX",
+			Decimals:  18,
+			MaxSupply: wholeBHX(1000000000),
+			Balances: map[string]uint256.Int{
+				"system": wholeBHX(10000000),
+				"03e2459b73c0c6522530f6b26e834d992dfc55d170bee35d0bcdc047fe0d61c25b": wholeBHX(1000),
+				"0330355c855622ac9064776c5f2cb331fd17bb5a73298f286741cc9999e1e1f687": wholeBHX(1000),
+			},
+		}},
+		Accounts: []GenesisAccount{
+			{Address: "system", Balance: wholeBHX(10000000)},
+			{Address: "03e2459b73c0c6522530f6b26e834d992dfc55d170bee35d0bcdc047fe0d61c25b", Balance: wholeBHX(1000)},
+		},
+		Validators: []GenesisValidator{
+			{PubKey: "0330355c855622ac9064776c5f2cb331fd17bb5a73298f286741cc9999e1e1f687", Stake: wholeBHX(1000)},
+			{PubKey: "03a6a0b64416cdf93e21f37826fb06103e86ca32e14b311ff86c078a3f9544e6ae", Stake: wholeBHX(1000)},
+			{PubKey: "023eef3bbf876aa5e58a3340087c9e0e7fa1080d6ba24807401dee8face95a52da", Stake: wholeBHX(500)},
+		},
+		Consensus: ConsensusParams{
+			BlockReward: wholeBHX(10),
+			MinGasPrice: DefaultFeeConfig().MinGasPrice,
+			BurnPercent: DefaultFeeConfig().BurnPercent,
+		},
+	}
+}
+
+// wholeBHX converts whole BHX to base units; BHX has 18 decimals
+func wholeBHX(amount uint64) uint256.Int {
+	units, err := uint256.NewInt(amount).Mul(uint256.MustFromDecimal("1000000000000000000"))
+	if err != nil {
+		panic(err)
+	}
+	return units
+}
+
+// Validate checks the config and fills in derived fields: validator addresses
+// and the UTC genesis time
+func (g *GenesisConfig) Validate() error {
+	if g.ChainID == 0 {
+		return fmt.Errorf("%w: chain_id is required", ErrInvalidGenesis)
+	}
+	if g.GenesisTime.IsZero() {
+		return fmt.Errorf("%w: genesis_time is required", ErrInvalidGenesis)
+	}
+	g.GenesisTime = g.GenesisTime.UTC()
+
+	symbols := make(map[string]bool)
+	for _, tk := range g.Tokens {
+		if tk.Symbol == "" {
+			return fmt.Errorf("%w: token %q has no symbol", ErrInvalidGenesis, tk.Name)
+		}
+		if symbols[tk.Symbol] {
+			return fmt.Errorf("%w: token %s is declared twice", ErrInvalidGenesis, tk.Symbol)
+		}
+		symbols[tk.Symbol] = true
+
+		total, err := tk.ledgerSupply()
+		if err != nil {
+			return err
+		}
+		for address, balance := range tk.Balances {
+			if address == "" || balance.IsZero() {
+				return fmt.Errorf("%w: token %s has an empty balance entry", ErrInvalidGenesis, tk.Symbol)
+			}
+			if total, err = total.Add(balance); err != nil {
+				return fmt.Errorf("%w: token %s balances overflow", ErrInvalidGenesis, tk.Symbol)
+			}
+		}
+		if tk.Symbol == FeeToken {
+			// Validator stakes are minted to the staking contract as well
+			stake, err := g.totalStake()
+			if err == nil {
+				total, err = total.Add(stake)
+			}
+			if err != nil {
+				return fmt.Errorf("%w: token %s balances and validator stakes overflow", ErrInvalidGenesis, tk.Symbol)
+			}
+		}
+		if !tk.MaxSupply.IsZero() && total.Gt(tk.MaxSupply) {
+			return fmt.Errorf("%w: token %s balances exceed max supply %s", ErrInvalidGenesis, tk.Symbol, tk.MaxSupply)
+		}
+	}
+	if !symbols[FeeToken] {
+		return fmt.Errorf("%w: fee token %s is not declared", ErrInvalidGenesis, FeeToken)
+	}
+
+	accounts := make(map[string]bool)
+	for _, account := range g.Accounts {
+		if account.Address == "" {
+			return fmt.Errorf("%w: account without address", ErrInvalidGenesis)
+		}
+		if accounts[account.Address] {
+			return fmt.Errorf("%w: account %s is declared twice", ErrInvalidGenesis, account.Address)
+		}
+		accounts[account.Address] = true
+	}
+
+	if len(g.Validators) == 0 {
+		return fmt.Errorf("%w: at least one validator is required", ErrInvalidGenesis)
+	}
+	validators := make(map[string]bool)
+	for i := range g.Validators {
+		v := &g.Validators[i]
+		raw, err := hex.DecodeString(v.PubKey)
+		if err != nil || len(raw) != btcec.PubKeyBytesLenCompressed {
+			return fmt.Errorf("%w: validator %d pub_key must be a hex compressed secp256k1 key", ErrInvalidGenesis, i)
+		}
+		pub, err := btcec.ParsePubKey(raw)
+		if err != nil {
+			return fmt.Errorf("%w: validator %d pub_key: %v", ErrInvalidGenesis, i, err)
+		}
+
+		address := PublicKeyToAddress(pub)
+		if v.Address != "" && v.Address != address {
+			return fmt.Errorf("%w: validator %d address %s does not match its pub_key", ErrInvalidGenesis, i, v.Address)
+		}
+		v.Address = address
+
+		if v.Stake.IsZero() {
+			return fmt.Errorf("%w: validator %s has no stake", ErrInvalidGenesis, address)
+		}
+		if validators[address] {
+			return fmt.Errorf("%w: validator %s is declared twice", ErrInvalidGenesis, address)
+		}
+		validators[address] = true
+	}
+
+	if _, err := g.totalStake(); err != nil {
+		return fmt.Errorf("%w: validator stakes overflow", ErrInvalidGenesis)
+	}
+
+	if g.Consensus.BurnPercent > 100 {
+		return fmt.Errorf("%w: burn_percent must be at most 100", ErrInvalidGenesis)
+	}
+	return nil
+}
+
+// ledgerSupply checks the token's restored ledger, if it has one, and returns
+// its supply
+func (tk *GenesisToken) ledgerSupply() (uint256.Int, error) {
+	if tk.Ledger == nil {
+		return uint256.Int{}, nil
+	}
+	if len(tk.Balances) > 0 {
+		return uint256.Int{}, fmt.Errorf("%w: token %s has both balances and a ledger", ErrInvalidGenesis, tk.Symbol)
+	}
+	if tk.Symbol == FeeToken {
+		for _, h := range tk.Ledger.Holders {
+			if h.Address == "staking_contract" {
+				return uint256.Int{}, fmt.Errorf("%w: the %s ledger must leave out staking_contract, which genesis mints from validator stakes", ErrInvalidGenesis, tk.Symbol)
+			}
+		}
+	}
+
+	// Import the ledger into a scratch token for the same checks apply runs
+	scratch := token.NewTokenWithMaxSupply(tk.Name, tk.Symbol, tk.Decimals, tk.MaxSupply)
+	if err := scratch.Import(tk.Ledger); err != nil {
+		return uint256.Int{}, fmt.Errorf("%w: %v", ErrInvalidGenesis, err)
+	}
+	return tk.Ledger.TotalSupply, nil
+}
+
+// Hash returns the hex SHA-256 of the config's JSON encoding. Call Validate
+// first so that equivalent files hash the same.
+func (g *GenesisConfig) Hash() string {
+	data, _ := json.Marshal(g)
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// TotalStake returns the sum of all genesis validator stakes. Validate makes
+// sure it does not overflow.
+func (g *GenesisConfig) TotalStake() uint256.Int {
+	total, _ := g.totalStake()
+	return total
+}
+
+func (g *GenesisConfig) totalStake() (uint256.Int, error) {
+	var total uint256.Int
+	for _, v := range g.Validators {
+		var err error
+		if total, err = total.Add(v.Stake); err != nil {
+			return uint256.Max, err
+		}
+	}
+	return total, nil
+}
+
+// apply loads the genesis allocations into an empty chain. Caller must hold bc.mu
+// or have exclusive access to bc.
+func (g *GenesisConfig) apply(bc *Blockchain) error {
+	for _, gt := range g.Tokens {
+		tk := token.NewTokenWithMaxSupply(gt.Name, gt.Symbol, gt.Decimals, gt.MaxSupply)
+		tk.SetMintAuthority(gt.MintAuthority)
+		tk.SetRoleHolder(token.RoleAdmin, gt.Admin)
+		bc.attachToken(tk)
+		if gt.Ledger != nil {
+			if err := tk.Import(gt.Ledger); err != nil {
+				return fmt.Errorf("failed to restore genesis %s ledger: %v", gt.Symbol, err)
+			}
+		}
+		for address, balance := range gt.Balances {
+			if err := tk.Mint(address, balance); err != nil {
+				return fmt.Errorf("failed to mint genesis %s to %s: %v", gt.Symbol, address, err)
+			}
+		}
+		bc.TokenRegistry[gt.Symbol] = tk
+	}
+
+	for _, account := range g.Accounts {
+		bc.GlobalState[account.Address] = &AccountState{Balance: account.Balance}
+		bc.dirtyAccounts[account.Address] = true
+	}
+
+	// Genesis stakes are backed by BHX held by the staking contract, the same
+	// as stakes deposited later, so withdrawing them pays out their own tokens
+	bhx := bc.TokenRegistry[FeeToken]
+	for _, v := range g.Validators {
+		if err := bhx.Mint("staking_contract", v.Stake); err != nil {
+			return fmt.Errorf("failed to mint genesis stake of %s: %v", v.Address, err)
+		}
+		bc.StakeLedger.SetStake(v.Address, v.Stake)
+	}
+	return nil
+}
+
+// block builds the genesis block. It has no parent, so its previous hash
+// commits to the config, and its state root commits to the genesis state.
+func (g *GenesisConfig) block(stateRoot string) *Block {
+	block := NewBlock(0, nil, g.Hash(), "", g.TotalStake(), stateRoot, "")
+	block.Header.Timestamp = g.GenesisTime
+	block.Hash = block.CalculateHash()
+	return block
+}
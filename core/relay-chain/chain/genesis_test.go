@@ -0,0 +1,273 @@
+package chain
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func validGenesis(t *testing.T) *GenesisConfig {
+	g := DefaultGenesis()
+	assert.Nil(t, g.Validate())
+	return g
+}
+
+// testGenesis is the default genesis with the test validators node1, node2
+// and node3, priced for test transactions
+func testGenesis(t *testing.T) *GenesisConfig {
+	g := DefaultGenesis()
+	for i, validator := range []string{node1, node2, node3} {
+		pub := testValidatorKeys[validator].PubKey().SerializeCompressed()
+		g.Validators[i] = GenesisValidator{PubKey: hex.EncodeToString(pub), Stake: g.Validators[i].Stake}
+	}
+	g.Consensus.MinGasPrice = testGasPrice
+	assert.Nil(t, g.Validate())
+	return g
+}
+
+func TestLoadGenesis(t *testing.T) {
+	// The shipped genesis.json describes the development network
+	g, err := LoadGenesis("../genesis.json")
+	assert.Nil(t, err)
+	assert.Equal(t, validGenesis(t).Hash(), g.Hash())
+
+	_, err = LoadGenesis(filepath.Join(t.TempDir(), "missing.json"))
+	assert.NotNil(t, err)
+
+	path := filepath.Join(t.TempDir(), "genesis.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"chain_id": "x"}`), 0644))
+	_, err = LoadGenesis(path)
+	assert.ErrorIs(t, err, ErrInvalidGenesis)
+}
+
+func TestGenesisValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		change func(g *GenesisConfig)
+	}{
+		{"No chain ID", func(g *GenesisConfig) { g.ChainID = 0 }},
+		{"No genesis time", func(g *GenesisConfig) { g.GenesisTime = time.Time{} }},
+		{"No fee token", func(g *GenesisConfig) { g.Tokens[0].Symbol = "ABC" }},
+		{"Duplicate token", func(g *GenesisConfig) { g.Tokens = append(g.Tokens, g.Tokens[0]) }},
+		{"Balances above max supply", func(g *GenesisConfig) { g.Tokens[0].MaxSupply = uint256.NewInt(100) }},
+		{"Stakes above max supply", func(g *GenesisConfig) { g.Tokens[0].MaxSupply = wholeBHX(10002000) }},
+		{"Zero balance", func(g *GenesisConfig) { g.Tokens[0].Balances["0xBob"] = uint256.NewInt(0) }},
+		{"Duplicate account", func(g *GenesisConfig) { g.Accounts = append(g.Accounts, g.Accounts[0]) }},
+		{"No validators", func(g *GenesisConfig) { g.Validators = nil }},
+		{"Bad pubkey", func(g *GenesisConfig) { g.Validators[0].PubKey = "node1" }},
+		{"Address not matching pubkey", func(g *GenesisConfig) { g.Validators[0].Address = "node1" }},
+		{"Zero stake", func(g *GenesisConfig) { g.Validators[0].Stake = uint256.NewInt(0) }},
+		{"Duplicate validator", func(g *GenesisConfig) { g.Validators = append(g.Validators, g.Validators[0]) }},
+		{"Burn above 100 percent", func(g *GenesisConfig) { g.Consensus.BurnPercent = 101 }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := DefaultGenesis()
+			c.change(g)
+			assert.ErrorIs(t, g.Validate(), ErrInvalidGenesis)
+		})
+	}
+}
+
+func TestGenesisBlockCommitsToConfig(t *testing.T) {
+	g := validGenesis(t)
+	block := g.block("root")
+	assert.Equal(t, g.Hash(), block.Header.PreviousHash)
+	assert.Equal(t, g.GenesisTime, block.Header.Timestamp)
+	assert.Equal(t, wholeBHX(2500), block.Header.StakeSnapshot)
+
+	// Giving the derived address explicitly is the same config
+	explicit := DefaultGenesis()
+	explicit.Validators[0].Address = g.Validators[0].Address
+	assert.Nil(t, explicit.Validate())
+	assert.Equal(t, block.Hash, explicit.block("root").Hash)
+
+	// Any other change produces a different genesis block
+	changed := validGenesis(t)
+	changed.Consensus.BlockReward = wholeBHX(11)
+	assert.NotEqual(t, block.Hash, changed.block("root").Hash)
+}
+
+func TestGenesisApply(t *testing.T) {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	g := validGenesis(t)
+	stakes := NewStakeLedger()
+	bc := &Blockchain{
+		StakeLedger:   stakes,
+		GlobalState:   make(map[string]*AccountState),
+		TokenRegistry: make(map[string]*token.Token),
+		stateTrees:    make(map[string]*StateTree),
+		dirtyAccounts: make(map[string]bool),
+		DB:            db,
+	}
+	assert.Nil(t, g.apply(bc))
+
+	validator := g.Validators[0].Address
+	assert.Equal(t, wholeBHX(1000), stakes.GetStake(validator))
+	assert.Len(t, stakes.GetAllStakes(), 3)
+	assert.Equal(t, wholeBHX(1000), bhxBalance(bc, validator))
+	assert.Equal(t, g.TotalStake(), bhxBalance(bc, "staking_contract"), "stakes are backed by BHX")
+	assert.Equal(t, wholeBHX(10000000), bhxBalance(bc, "system"))
+	assert.Equal(t, wholeBHX(10000000), bc.GlobalState["system"].Balance)
+	assert.True(t, bc.dirtyAccounts["system"])
+}
+
+func TestGenesisStakeWithdraw(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+	validator := PublicKeyToAddress(key.PubKey())
+
+	g := testGenesis(t)
+	g.Validators[0] = GenesisValidator{PubKey: hex.EncodeToString(key.PubKey().SerializeCompressed()), Stake: uint256.NewInt(300)}
+	g.Tokens[0].Balances[validator] = wholeBHX(1)
+	assert.Nil(t, g.Validate())
+
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	assert.Nil(t, err)
+	defer db.Close()
+	bc, err := openChain(db, g, DefaultHistoryConfig())
+	assert.Nil(t, err)
+	backing := bhxBalance(bc, "staking_contract")
+
+	// A genesis validator withdraws the tokens minted for its own stake, and
+	// cannot take out more than that
+	withdraw := signedTokenTx(t, key, StakeWithdraw, 0, "BHX", "staking_contract", 300)
+	tooMuch := signedTokenTx(t, key, StakeWithdraw, 1, "BHX", "staking_contract", 1)
+	assert.True(t, bc.AddBlock(blockBy(bc, g.Validators[1].Address, withdraw, tooMuch)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, withdraw))
+	assert.Equal(t, ReceiptFailed, receiptStatus(t, bc, tooMuch))
+	assert.True(t, bc.StakeLedger.GetStake(validator).IsZero())
+	want, _ := backing.Sub(uint256.NewInt(300))
+	assert.Equal(t, want, bhxBalance(bc, "staking_contract"))
+}
+
+func TestGenesisRestoresLedger(t *testing.T) {
+	restored := func() *token.Ledger {
+		return &token.Ledger{
+			Symbol:      "BHX",
+			TotalSupply: wholeBHX(1300),
+			Holders:     []token.Holder{{Address: "0xAlice", Balance: wholeBHX(200)}, {Address: "0xBob", Balance: wholeBHX(100)}, {Address: "system", Balance: wholeBHX(1000)}},
+			Allowances:  []token.AllowanceEntry{{Owner: "0xAlice", Spender: "0xBob", Amount: wholeBHX(50)}},
+			Vesting:     []token.VestingSchedule{{Beneficiary: "0xBob", Grantor: "0xAlice", Total: wholeBHX(40), Basis: token.VestByHeight, Duration: 10}},
+		}
+	}
+	g := testGenesis(t)
+	g.Tokens[0].Balances = nil
+	g.Tokens[0].Ledger = restored()
+	assert.Nil(t, g.Validate())
+
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	assert.Nil(t, err)
+	defer db.Close()
+	bc, err := openChain(db, g, DefaultHistoryConfig())
+	assert.Nil(t, err)
+
+	// The ledger is restored as it was exported, with the stakes minted on top
+	want := restored()
+	want.Holders = []token.Holder{want.Holders[0], want.Holders[1], {Address: "staking_contract", Balance: g.TotalStake()}, want.Holders[2]}
+	want.TotalSupply, _ = want.TotalSupply.Add(g.TotalStake())
+	assert.Equal(t, want, bc.TokenRegistry["BHX"].Export())
+
+	// Nodes replay the chain from the restored genesis like from any other
+	assert.True(t, bc.AddBlock(blockBy(bc, g.Validators[0].Address)))
+	restarted, err := openChain(db, g, DefaultHistoryConfig())
+	assert.Nil(t, err)
+	assert.Equal(t, bc.StateRoot(), restarted.StateRoot())
+
+	cases := []struct {
+		name   string
+		change func(gt *GenesisToken)
+	}{
+		{"Balances and a ledger", func(gt *GenesisToken) { gt.Balances = map[string]uint256.Int{"0xCarol": wholeBHX(1)} }},
+		{"Ledger of another token", func(gt *GenesisToken) { gt.Ledger.Symbol = "GOLD" }},
+		{"Holders not adding up to the supply", func(gt *GenesisToken) { gt.Ledger.TotalSupply = wholeBHX(1) }},
+		{"Staking contract in the ledger", func(gt *GenesisToken) {
+			gt.Ledger.Holders = append(gt.Ledger.Holders, token.Holder{Address: "staking_contract", Balance: wholeBHX(1)})
+			gt.Ledger.TotalSupply = wholeBHX(1301)
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bad := testGenesis(t)
+			bad.Tokens[0].Balances = nil
+			bad.Tokens[0].Ledger = restored()
+			c.change(&bad.Tokens[0])
+			assert.ErrorIs(t, bad.Validate(), ErrInvalidGenesis)
+		})
+	}
+}
+
+func TestGenesisAmountsInBaseUnits(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+	sender := PublicKeyToAddress(key.PubKey())
+
+	g := testGenesis(t)
+	g.Consensus.MinGasPrice = DefaultFeeConfig().MinGasPrice
+	g.Tokens[0].Balances[sender] = wholeBHX(5)
+	validator := g.Validators[0].Address
+
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	bc, err := openChain(db, g, DefaultHistoryConfig())
+	assert.Nil(t, err)
+	assert.Equal(t, uint256.MustFromDecimal("10000000000000000000"), bc.BlockReward)
+
+	// One whole BHX is 10^18 base units, above the old fixed amount cap
+	payment := signedPayment(t, key, 0, 1000000000000000000)
+	payment.GasPrice = g.Consensus.MinGasPrice
+	assert.Nil(t, payment.Sign(key))
+	assert.True(t, bc.AddBlock(blockBy(bc, validator, payment)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, payment))
+	assert.Equal(t, wholeBHX(1), bhxBalance(bc, "0xBob"))
+	// The sender also paid a fee at the 1 gwei minimum, part of it to the validator
+	assert.True(t, bhxBalance(bc, sender).Lt(wholeBHX(4)))
+	assert.True(t, bhxBalance(bc, sender).Gt(wholeBHX(3)))
+	assert.True(t, bhxBalance(bc, validator).Gt(wholeBHX(10)))
+}
+
+func TestHandshakeRefusesOtherGenesis(t *testing.T) {
+	newNode := func(genesis string) *Node {
+		node, err := NewNode(context.Background(), 0)
+		assert.Nil(t, err)
+		t.Cleanup(func() { node.Host.Close() })
+		node.SetGenesis(genesis)
+		return node
+	}
+	addr := func(n *Node) string {
+		return n.Host.Addrs()[0].String() + "/p2p/" + n.Host.ID().String()
+	}
+
+	a, b, other := newNode("aa"), newNode("aa"), newNode("bb")
+
+	assert.Nil(t, a.Connect(context.Background(), addr(b)))
+	assert.Contains(t, a.peers, b.Host.ID())
+	assert.Eventually(t, func() bool {
+		b.peersLock.RLock()
+		defer b.peersLock.RUnlock()
+		return b.peers[a.Host.ID()] != nil
+	}, time.Second, 10*time.Millisecond, "the accepting side learns the peer too")
+
+	err := a.Connect(context.Background(), addr(other))
+	assert.ErrorIs(t, err, ErrGenesisMismatch)
+	assert.NotContains(t, a.peers, other.Host.ID())
+	other.peersLock.RLock()
+	assert.NotContains(t, other.peers, a.Host.ID())
+	other.peersLock.RUnlock()
+}
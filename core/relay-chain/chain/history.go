@@ -0,0 +1,67 @@
+package chain
+
+import (
+	"fmt"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+// HistoryConfig controls how much token balance and stake history a node keeps
+// for queries at past heights
+type HistoryConfig struct {
+	// Retention is how many blocks below the tip stay queryable. Older
+	// checkpoints are pruned as blocks are added. 0 keeps the full history,
+	// which grows without bound.
+	Retention uint64
+}
+
+// DefaultHistoryRetention is the number of blocks of history nodes keep by
+// default, about a week at one block every five seconds
+const DefaultHistoryRetention = 120000
+
+// DefaultHistoryConfig returns the history kept by nodes
+func DefaultHistoryConfig() HistoryConfig {
+	return HistoryConfig{Retention: DefaultHistoryRetention}
+}
+
+// BalanceAt returns the balance of a token an address held at the end of the
+// block at height
+func (bc *Blockchain) BalanceAt(symbol, address string, height uint64) (uint256.Int, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	tk, exists := bc.TokenRegistry[symbol]
+	if !exists {
+		return uint256.Int{}, fmt.Errorf("token %s not found", symbol)
+	}
+	return tk.BalanceOfAt(address, height)
+}
+
+// StakeAt returns the stake an address held at the end of the block at height
+func (bc *Blockchain) StakeAt(address string, height uint64) (uint256.Int, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.StakeLedger.StakeAt(address, height)
+}
+
+// pruneHistory drops the history that falls out of the retention window once
+// the block at height is the tip. Caller must hold bc.mu.
+func (bc *Blockchain) pruneHistory(height uint64) {
+	retention := bc.History.Retention
+	if retention == 0 || height <= retention {
+		return
+	}
+	for _, tk := range bc.TokenRegistry {
+		tk.PruneHistory(height - retention)
+	}
+	bc.StakeLedger.pruneHistory(height - retention)
+}
+
+// rewindHistory drops the history of disconnected blocks above height. Caller
+// must hold bc.mu.
+func (bc *Blockchain) rewindHistory(height uint64) {
+	for _, tk := range bc.TokenRegistry {
+		tk.RewindHistory(height)
+	}
+	bc.StakeLedger.rewindHistory(height)
+}
@@ -0,0 +1,109 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func TestBalanceAndStakeHistory(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+	sender := PublicKeyToAddress(key.PubKey())
+
+	g := testGenesis(t)
+	g.Tokens[0].Balances[sender] = uint256.NewInt(1000)
+	validator := g.Validators[0].Address
+
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	bc, err := openChain(db, g, DefaultHistoryConfig())
+	assert.Nil(t, err)
+	genesisStake := bc.StakeLedger.GetStake(validator)
+
+	assert.True(t, bc.AddBlock(blockBy(bc, validator, signedPayment(t, key, 0, 100))))
+	assert.True(t, bc.AddBlock(blockBy(bc, validator, signedTokenTx(t, key, StakeDeposit, 1, "BHX", "staking_contract", 200))))
+	assert.True(t, bc.AddBlock(blockBy(bc, validator, signedPayment(t, key, 2, 50))))
+
+	balanceAt := func(address string, height uint64) uint256.Int {
+		balance, err := bc.BalanceAt("BHX", address, height)
+		assert.Nil(t, err)
+		return balance
+	}
+
+	t.Run("Balances at past heights", func(t *testing.T) {
+		assert.True(t, balanceAt("0xBob", 0).IsZero())
+		assert.Equal(t, uint256.NewInt(100), balanceAt("0xBob", 1))
+		assert.Equal(t, uint256.NewInt(100), balanceAt("0xBob", 2))
+		assert.Equal(t, uint256.NewInt(150), balanceAt("0xBob", 3))
+		assert.Equal(t, uint256.NewInt(1000), balanceAt(sender, 0))
+	})
+
+	t.Run("Stakes at past heights", func(t *testing.T) {
+		for height := uint64(0); height <= 3; height++ {
+			stake, err := bc.StakeAt(validator, height)
+			assert.Nil(t, err)
+			assert.Equal(t, genesisStake, stake, "block rewards are not staked")
+		}
+		stake, err := bc.StakeAt(sender, 1)
+		assert.Nil(t, err)
+		assert.True(t, stake.IsZero())
+		stake, err = bc.StakeAt(sender, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, uint256.NewInt(200), stake)
+	})
+
+	t.Run("Heights above the tip", func(t *testing.T) {
+		_, err := bc.BalanceAt("BHX", "0xBob", 4)
+		assert.ErrorIs(t, err, token.ErrFutureHeight)
+		_, err = bc.StakeAt(validator, 4)
+		assert.ErrorIs(t, err, token.ErrFutureHeight)
+	})
+
+	t.Run("Disconnected blocks leave no history", func(t *testing.T) {
+		_, err := bc.disconnectTip()
+		assert.Nil(t, err)
+		assert.Equal(t, uint256.NewInt(100), balanceAt("0xBob", 2))
+
+		assert.True(t, bc.AddBlock(blockBy(bc, validator, signedPayment(t, key, 2, 20))))
+		assert.Equal(t, uint256.NewInt(100), balanceAt("0xBob", 2))
+		assert.Equal(t, uint256.NewInt(120), balanceAt("0xBob", 3))
+	})
+
+	t.Run("History is rebuilt on restart", func(t *testing.T) {
+		restarted, err := openChain(db, g, DefaultHistoryConfig())
+		assert.Nil(t, err)
+		balance, err := restarted.BalanceAt("BHX", "0xBob", 1)
+		assert.Nil(t, err)
+		assert.Equal(t, uint256.NewInt(100), balance)
+	})
+
+	t.Run("Retention applies while the chain is replayed", func(t *testing.T) {
+		pruned, err := openChain(db, g, HistoryConfig{Retention: 1})
+		assert.Nil(t, err)
+		_, err = pruned.BalanceAt("BHX", "0xBob", 1)
+		assert.ErrorIs(t, err, token.ErrHistoryPruned)
+		balance, err := pruned.BalanceAt("BHX", "0xBob", 2)
+		assert.Nil(t, err)
+		assert.Equal(t, uint256.NewInt(100), balance)
+	})
+
+	t.Run("Pruning", func(t *testing.T) {
+		bc.History.Retention = 2
+		assert.True(t, bc.AddBlock(blockBy(bc, validator)))
+
+		_, err := bc.BalanceAt("BHX", "0xBob", 1)
+		assert.ErrorIs(t, err, token.ErrHistoryPruned)
+		_, err = bc.StakeAt(sender, 1)
+		assert.ErrorIs(t, err, token.ErrHistoryPruned)
+		assert.Equal(t, uint256.NewInt(100), balanceAt("0xBob", 2))
+		assert.Equal(t, uint256.NewInt(120), balanceAt("0xBob", 3))
+	})
+}
@@ -0,0 +1,101 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxHeaderRange bounds how many headers one GetHeaders call returns
+const MaxHeaderRange = 1000
+
+var ErrBadTxProof = errors.New("transaction is not included in the block")
+
+// TxProof proves that a transaction is included in the block with BlockHash,
+// through the merkle root committed in that block's header
+type TxProof struct {
+	TxID        string       `json:"tx_id"`
+	BlockHeight uint64       `json:"block_height"`
+	BlockHash   string       `json:"block_hash"`
+	Proof       *MerkleProof `json:"proof"`
+}
+
+// ProveTransaction builds an inclusion proof for the transaction at index
+func (b *Block) ProveTransaction(index int) (*TxProof, error) {
+	proof, err := b.txTree().Prove(index)
+	if err != nil {
+		return nil, err
+	}
+	return &TxProof{
+		TxID:        proof.Leaf,
+		BlockHeight: b.Header.Index,
+		BlockHash:   b.Hash,
+		Proof:       proof,
+	}, nil
+}
+
+// VerifyTxProof checks proof against a header the caller already trusts
+func VerifyTxProof(header *SignedHeader, proof *TxProof) error {
+	if proof == nil || proof.Proof == nil {
+		return fmt.Errorf("%w: empty proof", ErrBadTxProof)
+	}
+	if proof.BlockHash != header.Hash || proof.BlockHeight != header.Header.Index {
+		return fmt.Errorf("%w: proof is for block %s, header is %s", ErrBadTxProof, proof.BlockHash, header.Hash)
+	}
+	if proof.Proof.Leaf != proof.TxID {
+		return fmt.Errorf("%w: proof leaf is not %s", ErrBadTxProof, proof.TxID)
+	}
+	ok, err := VerifyMerkleProof(header.Header.MerkleRoot, proof.Proof)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadTxProof, err)
+	}
+	if !ok {
+		return fmt.Errorf("%w: proof does not match merkle root %s", ErrBadTxProof, header.Header.MerkleRoot)
+	}
+	return nil
+}
+
+// GetTransactionProof returns an inclusion proof for a transaction on the main chain
+func (bc *Blockchain) GetTransactionProof(txID string) (*TxProof, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	location, err := bc.txLocation(txID)
+	if err != nil {
+		return nil, err
+	}
+	if location.BlockHeight >= uint64(len(bc.Blocks)) {
+		return nil, ErrTxNotFound
+	}
+	block := bc.Blocks[location.BlockHeight]
+	if location.Index < 0 || location.Index >= len(block.Transactions) || block.Transactions[location.Index].ID != txID {
+		return nil, fmt.Errorf("index entry for %s does not match block %d", txID, location.BlockHeight)
+	}
+	return block.ProveTransaction(location.Index)
+}
+
+// GetHeaders returns the signed headers of main chain blocks from through to,
+// inclusive, capped at MaxHeaderRange headers
+func (bc *Blockchain) GetHeaders(from, to uint64) ([]*SignedHeader, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	tip := uint64(len(bc.Blocks)) - 1
+	if from > tip {
+		return nil, fmt.Errorf("block %d not found", from)
+	}
+	if to > tip {
+		to = tip
+	}
+	if to < from {
+		return nil, fmt.Errorf("invalid range %d-%d", from, to)
+	}
+	if to-from >= MaxHeaderRange {
+		to = from + MaxHeaderRange - 1
+	}
+
+	headers := make([]*SignedHeader, 0, to-from+1)
+	for height := from; height <= to; height++ {
+		headers = append(headers, bc.Blocks[height].SignedHeader())
+	}
+	return headers, nil
+}
@@ -0,0 +1,144 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// MerkleTree is a binary hash tree over an ordered list of leaves, used for the
+// transaction and receipts roots. Leaves and inner nodes are hashed with
+// different prefixes, and the last node of an odd-sized level is carried up
+// unchanged rather than paired with itself, so no two leaf lists share a root.
+type MerkleTree struct {
+	leaves []string
+	levels [][][32]byte // levels[0] holds the leaf hashes, the last level the root
+}
+
+// MerkleProof proves that Leaf is at Index among LeafCount leaves
+type MerkleProof struct {
+	Leaf      string   `json:"leaf"`
+	Index     int      `json:"index"`
+	LeafCount int      `json:"leaf_count"`
+	Siblings  []string `json:"siblings"` // ordered from the leaf up to the root
+}
+
+// NewMerkleTree builds a tree over leaves in the given order
+func NewMerkleTree(leaves []string) *MerkleTree {
+	mt := &MerkleTree{leaves: leaves}
+	if len(leaves) == 0 {
+		return mt
+	}
+
+	level := make([][32]byte, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = hashMerkleLeaf(leaf)
+	}
+	mt.levels = append(mt.levels, level)
+
+	for len(level) > 1 {
+		next := make([][32]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+			} else {
+				next = append(next, hashMerkleNode(level[i], level[i+1]))
+			}
+		}
+		mt.levels = append(mt.levels, next)
+		level = next
+	}
+	return mt
+}
+
+// Root returns the hex encoded root, or an empty string for a tree without leaves
+func (mt *MerkleTree) Root() string {
+	if len(mt.levels) == 0 {
+		return ""
+	}
+	root := mt.levels[len(mt.levels)-1][0]
+	return hex.EncodeToString(root[:])
+}
+
+// Prove builds an inclusion proof for the leaf at index
+func (mt *MerkleTree) Prove(index int) (*MerkleProof, error) {
+	if index < 0 || index >= len(mt.leaves) {
+		return nil, fmt.Errorf("leaf %d is out of range", index)
+	}
+
+	proof := &MerkleProof{
+		Leaf:      mt.leaves[index],
+		Index:     index,
+		LeafCount: len(mt.levels[0]),
+		Siblings:  make([]string, 0, len(mt.levels)-1),
+	}
+	pos := index
+	for _, level := range mt.levels[:len(mt.levels)-1] {
+		if sibling := pos ^ 1; sibling < len(level) {
+			proof.Siblings = append(proof.Siblings, hex.EncodeToString(level[sibling][:]))
+		}
+		pos /= 2
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof checks a proof against the expected root
+func VerifyMerkleProof(root string, proof *MerkleProof) (bool, error) {
+	if proof == nil {
+		return false, errors.New("nil proof")
+	}
+	if proof.LeafCount <= 0 || proof.Index < 0 || proof.Index >= proof.LeafCount {
+		return false, fmt.Errorf("leaf %d is out of range of %d leaves", proof.Index, proof.LeafCount)
+	}
+
+	current := hashMerkleLeaf(proof.Leaf)
+	pos, width, next := proof.Index, proof.LeafCount, 0
+	for width > 1 {
+		// The last node of an odd level has no sibling and moves up as is
+		if pos^1 < width {
+			if next == len(proof.Siblings) {
+				return false, errors.New("proof has too few siblings")
+			}
+			decoded, err := hex.DecodeString(proof.Siblings[next])
+			if err != nil || len(decoded) != 32 {
+				return false, errors.New("invalid proof sibling")
+			}
+			var sibling [32]byte
+			copy(sibling[:], decoded)
+			next++
+
+			if pos%2 == 0 {
+				current = hashMerkleNode(current, sibling)
+			} else {
+				current = hashMerkleNode(sibling, current)
+			}
+		}
+		pos /= 2
+		width = (width + 1) / 2
+	}
+	if next != len(proof.Siblings) {
+		return false, errors.New("proof has unused siblings")
+	}
+
+	return hex.EncodeToString(current[:]) == root, nil
+}
+
+func hashMerkleLeaf(leaf string) [32]byte {
+	h := sha256.New()
+	h.Write(leafPrefix)
+	h.Write([]byte(leaf))
+	var out [32]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+func hashMerkleNode(left, right [32]byte) [32]byte {
+	h := sha256.New()
+	h.Write(nodePrefix)
+	h.Write(left[:])
+	h.Write(right[:])
+	var out [32]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
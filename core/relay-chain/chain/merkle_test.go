@@ -0,0 +1,100 @@
+package chain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+func merkleLeaves(n int) []string {
+	leaves := make([]string, n)
+	for i := range leaves {
+		leaves[i] = fmt.Sprintf("tx%d", i)
+	}
+	return leaves
+}
+
+func TestMerkleRoot(t *testing.T) {
+	t.Run("Empty tree", func(t *testing.T) {
+		assert.Equal(t, "", NewMerkleTree(nil).Root())
+	})
+
+	t.Run("Odd leaf is not paired with itself", func(t *testing.T) {
+		// Duplicating the last leaf used to give both lists the same root
+		three := NewMerkleTree([]string{"a", "b", "c"})
+		four := NewMerkleTree([]string{"a", "b", "c", "c"})
+		assert.NotEqual(t, three.Root(), four.Root())
+	})
+
+	t.Run("Inner node cannot pose as a leaf", func(t *testing.T) {
+		pair := NewMerkleTree([]string{"a", "b"})
+		single := NewMerkleTree([]string{pair.Root()})
+		assert.NotEqual(t, pair.Root(), single.Root())
+	})
+}
+
+func TestMerkleProof(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 5, 8, 13} {
+		tree := NewMerkleTree(merkleLeaves(n))
+		for i := 0; i < n; i++ {
+			proof, err := tree.Prove(i)
+			assert.Nil(t, err)
+
+			ok, err := VerifyMerkleProof(tree.Root(), proof)
+			assert.Nil(t, err, "leaf %d of %d", i, n)
+			assert.True(t, ok, "leaf %d of %d", i, n)
+		}
+	}
+
+	tree := NewMerkleTree(merkleLeaves(5))
+
+	t.Run("Out of range", func(t *testing.T) {
+		_, err := tree.Prove(5)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Wrong leaf", func(t *testing.T) {
+		proof, _ := tree.Prove(2)
+		proof.Leaf = "tx9"
+		ok, err := VerifyMerkleProof(tree.Root(), proof)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("Wrong position", func(t *testing.T) {
+		proof, _ := tree.Prove(2)
+		proof.Index = 3
+		ok, _ := VerifyMerkleProof(tree.Root(), proof)
+		assert.False(t, ok)
+	})
+
+	t.Run("Extra sibling", func(t *testing.T) {
+		proof, _ := tree.Prove(4)
+		proof.Siblings = append(proof.Siblings, proof.Siblings[0])
+		_, err := VerifyMerkleProof(tree.Root(), proof)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestTxProof(t *testing.T) {
+	txs := signedTransfers(t, 3)
+	block := NewBlock(1, txs, "aa", "node1", uint256.NewInt(1000), "bb", "cc")
+
+	proof, err := block.ProveTransaction(1)
+	assert.Nil(t, err)
+	assert.Equal(t, txs[1].ID, proof.TxID)
+	assert.Nil(t, VerifyTxProof(block.SignedHeader(), proof))
+
+	t.Run("Proof for another block", func(t *testing.T) {
+		other := NewBlock(1, txs[:2], "aa", "node1", uint256.NewInt(1000), "bb", "cc")
+		assert.ErrorIs(t, VerifyTxProof(other.SignedHeader(), proof), ErrBadTxProof)
+	})
+
+	t.Run("Proof claims another transaction", func(t *testing.T) {
+		forged := *proof
+		forged.TxID = txs[2].ID
+		assert.ErrorIs(t, VerifyTxProof(block.SignedHeader(), &forged), ErrBadTxProof)
+	})
+}
@@ -1,7 +1,6 @@
 package chain
 
 import (
-	"bytes"
 	"encoding/gob"
 	"encoding/hex"
 	"fmt"
@@ -15,14 +14,16 @@ const (
 	MessageTypeBlock
 	MessageTypeSyncReq
 	MessageTypeSyncResp
+	MessageTypeHandshake
 )
 
-const ProtocolVersion = 2
+const ProtocolVersion = 5
 
 type Message struct {
 	Type    MessageType
 	Data    []byte
 	Version uint32
+	Genesis string // genesis block hash of the sender's chain
 }
 
 func (m *Message) Encode(w io.Writer) error {
@@ -45,22 +46,12 @@ type TransactionWrapper struct {
 }
 
 func (tw *TransactionWrapper) Serialize() ([]byte, error) {
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
-	if err := enc.Encode(tw.Transaction); err != nil {
-		return nil, err
-	}
-	return buf.Bytes(), nil
+	return tw.Transaction.Encode(), nil
 }
 
+// DeserializeTransaction decodes a transaction received from the network
 func DeserializeTransaction(data []byte) (*Transaction, error) {
-	var tx Transaction
-	dec := gob.NewDecoder(bytes.NewReader(data))
-	if err := dec.Decode(&tx); err != nil {
-		fmt.Println("hello here ")
-		return nil, err
-	}
-	return &tx, nil
+	return DecodeTransaction(data)
 }
 
 type BlockWrapper struct {
@@ -68,40 +59,21 @@ type BlockWrapper struct {
 }
 
 func (bw *BlockWrapper) Serialize() ([]byte, error) {
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
-	if err := enc.Encode(bw.Block); err != nil {
-		return nil, err
-	}
-	return buf.Bytes(), nil
+	return bw.Block.Encode(), nil
 }
 
+// DeserializeBlock decodes a block received from the network
 func DeserializeBlock(data []byte) (*Block, error) {
 	fmt.Println("➡️ Deserializing block, data length:", len(data))
-	if len(data) > 0 {
+	block, err := DecodeBlock(data)
+	if err != nil {
 		dumpLen := len(data)
 		if dumpLen > 100 {
 			dumpLen = 100
 		}
 		fmt.Println("📜 Data prefix (hex):", hex.EncodeToString(data[:dumpLen]))
-	}
-	if bytes.Contains(data, []byte("CommonType")) {
-		fmt.Println("❌ Block data contains CommonType reference")
-		fmt.Println("📜 Full block data (hex):", hex.EncodeToString(data))
-		return nil, fmt.Errorf("invalid block: contains CommonType reference")
-	}
-	var block Block
-	dec := gob.NewDecoder(bytes.NewReader(data))
-	if err := dec.Decode(&block); err != nil {
-		fmt.Println("❌ Gob Decode Error:", err)
-		fmt.Println("📜 Full block data (hex):", hex.EncodeToString(data))
 		return nil, fmt.Errorf("failed to deserialize block: %v", err)
 	}
-	computedHash := block.CalculateHash()
-	if block.Hash != computedHash {
-		fmt.Println("❌ Hash mismatch: expected", block.Hash, "got", computedHash)
-		return nil, fmt.Errorf("hash mismatch: expected %s, got %s", block.Hash, computedHash)
-	}
 	fmt.Println("✅ Successfully deserialized block, index:", block.Header.Index)
-	return &block, nil
+	return block, nil
 }
@@ -0,0 +1,391 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+var (
+	ErrInvalidMultisigTx = errors.New("invalid multisig parameters")
+	ErrMultisigNotFound  = errors.New("multisig wallet not found")
+	ErrProposalNotFound  = errors.New("multisig proposal not found")
+	ErrMultisigState     = errors.New("multisig action not allowed")
+)
+
+// Bounds on the parameters of a multisig wallet and its proposals
+const (
+	MaxMultisigOwners         = 20
+	MaxProposalDuration int64 = 30 * 24 * 60 * 60 // seconds
+)
+
+// multisigAddressDomain starts the payload hashed into a multisig wallet address
+const multisigAddressDomain = "blackhole-multisig"
+
+// Actions of a MultisigAction transaction
+const (
+	MultisigPropose = "propose"
+	MultisigApprove = "approve"
+)
+
+// ProposalStatus is where a multisig proposal is in its life
+type ProposalStatus string
+
+const (
+	ProposalPending  ProposalStatus = "pending"
+	ProposalExecuted ProposalStatus = "executed" // approved by the threshold and paid out
+)
+
+// MultisigCreateParams is the JSON carried in the Data of a MultisigCreate transaction
+type MultisigCreateParams struct {
+	Owners    []string `json:"owners"`
+	Threshold int      `json:"threshold"` // approvals needed to move funds
+}
+
+// MultisigActionParams is the JSON carried in the Data of a MultisigAction
+// transaction. A proposal pays the transaction's Amount of TokenID to To from
+// Wallet; an approval names the proposal by ID.
+type MultisigActionParams struct {
+	Wallet    string `json:"wallet"`
+	Action    string `json:"action"`
+	ID        string `json:"id,omitempty"`         // ID of the transaction that made the proposal, for approvals
+	ExpiresIn int64  `json:"expires_in,omitempty"` // seconds after the proposing block, for proposals
+}
+
+// MultisigWallet is an account that only moves funds once Threshold of its
+// Owners approve. Anyone can fund it with an ordinary transfer; it has no key
+// and never signs.
+type MultisigWallet struct {
+	Address   string   `json:"address"`
+	Creator   string   `json:"creator"`
+	Owners    []string `json:"owners"`
+	Threshold int      `json:"threshold"`
+	CreatedAt int64    `json:"created_at"`
+}
+
+func (w *MultisigWallet) isOwner(address string) bool {
+	for _, owner := range w.Owners {
+		if owner == address {
+			return true
+		}
+	}
+	return false
+}
+
+// MultisigProposal is a payment out of a multisig wallet that owners approve.
+// The proposer approves it by proposing, and it is paid as soon as Threshold
+// owners have approved, which must happen before it expires.
+type MultisigProposal struct {
+	ID        string         `json:"id"`
+	Wallet    string         `json:"wallet"`
+	Proposer  string         `json:"proposer"`
+	To        string         `json:"to"`
+	TokenID   string         `json:"token_id"`
+	Amount    uint256.Int    `json:"amount"`
+	Approvals []string       `json:"approvals"`
+	Status    ProposalStatus `json:"status"`
+	CreatedAt int64          `json:"created_at"`
+	ExpiresAt int64          `json:"expires_at"`
+}
+
+func (p *MultisigProposal) approvedBy(address string) bool {
+	for _, approver := range p.Approvals {
+		if approver == address {
+			return true
+		}
+	}
+	return false
+}
+
+// MultisigAddress returns the address of the multisig wallet creator makes
+// with the transaction of the given nonce
+func MultisigAddress(creator string, nonce uint64) string {
+	var e encoder
+	e.string(multisigAddressDomain)
+	e.string(creator)
+	e.uint64(nonce)
+	hash := sha256.Sum256(e.buf.Bytes())
+	return "0x" + hex.EncodeToString(hash[:20])
+}
+
+// NewMultisigCreateTransaction builds an unsigned transaction that creates a
+// multisig wallet at MultisigAddress(from, nonce) once its nonce is set
+func NewMultisigCreateTransaction(from string, params MultisigCreateParams) (*Transaction, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(MultisigCreate, from, "", uint256.Int{}, nil)
+	tx.TokenID = ""
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx)
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// NewMultisigProposeTransaction builds an unsigned transaction that proposes
+// paying amount of symbol from a multisig wallet to to
+func NewMultisigProposeTransaction(from, wallet, to, symbol string, amount uint256.Int, expiresIn int64) (*Transaction, error) {
+	data, err := json.Marshal(MultisigActionParams{Wallet: wallet, Action: MultisigPropose, ExpiresIn: expiresIn})
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(MultisigAction, from, to, amount, nil)
+	tx.TokenID = symbol
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx)
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// NewMultisigApproveTransaction builds an unsigned transaction that approves
+// the proposal with ID id
+func NewMultisigApproveTransaction(from, wallet, id string) (*Transaction, error) {
+	data, err := json.Marshal(MultisigActionParams{Wallet: wallet, Action: MultisigApprove, ID: id})
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(MultisigAction, from, "", uint256.Int{}, nil)
+	tx.TokenID = ""
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx)
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// decodeMultisigCreate parses and validates the parameters of a MultisigCreate transaction
+func decodeMultisigCreate(tx *Transaction) (*MultisigCreateParams, error) {
+	var params MultisigCreateParams
+	if err := json.Unmarshal(tx.Data, &params); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMultisigTx, err)
+	}
+	if len(params.Owners) < 2 || len(params.Owners) > MaxMultisigOwners {
+		return nil, fmt.Errorf("%w: a wallet needs 2-%d owners", ErrInvalidMultisigTx, MaxMultisigOwners)
+	}
+	seen := make(map[string]bool, len(params.Owners))
+	for _, owner := range params.Owners {
+		if owner == "" || seen[owner] {
+			return nil, fmt.Errorf("%w: owners must be distinct addresses", ErrInvalidMultisigTx)
+		}
+		seen[owner] = true
+	}
+	if params.Threshold < 1 || params.Threshold > len(params.Owners) {
+		return nil, fmt.Errorf("%w: threshold must be 1-%d", ErrInvalidMultisigTx, len(params.Owners))
+	}
+	if !tx.Amount.IsZero() {
+		return nil, fmt.Errorf("%w: creating a wallet moves no amount", ErrInvalidMultisigTx)
+	}
+	return &params, nil
+}
+
+// decodeMultisigAction parses and validates the parameters of a MultisigAction transaction
+func decodeMultisigAction(tx *Transaction) (*MultisigActionParams, error) {
+	var params MultisigActionParams
+	if err := json.Unmarshal(tx.Data, &params); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMultisigTx, err)
+	}
+	if params.Wallet == "" {
+		return nil, fmt.Errorf("%w: missing wallet", ErrInvalidMultisigTx)
+	}
+	switch params.Action {
+	case MultisigPropose:
+		if tx.To == "" || tx.TokenID == "" || tx.Amount.IsZero() {
+			return nil, fmt.Errorf("%w: a proposal needs a recipient, a token and a positive amount", ErrInvalidTxFields)
+		}
+		if params.ExpiresIn <= 0 || params.ExpiresIn > MaxProposalDuration {
+			return nil, fmt.Errorf("%w: expires_in must be 1-%d seconds", ErrInvalidMultisigTx, MaxProposalDuration)
+		}
+	case MultisigApprove:
+		if params.ID == "" {
+			return nil, fmt.Errorf("%w: missing proposal ID", ErrInvalidMultisigTx)
+		}
+		if !tx.Amount.IsZero() {
+			return nil, fmt.Errorf("%w: an approval moves no amount of its own", ErrInvalidMultisigTx)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidMultisigTx, params.Action)
+	}
+	return &params, nil
+}
+
+// checkMultisigAction runs the checks of multisig transactions that depend on
+// chain state. Caller must hold bc.mu.
+func (bc *Blockchain) checkMultisigAction(tx *Transaction) error {
+	if tx.Type == MultisigCreate {
+		address := MultisigAddress(tx.From, tx.Nonce)
+		if _, exists := bc.records[MultisigKey(address)]; exists {
+			return fmt.Errorf("%w: wallet %s already exists", ErrMultisigState, address)
+		}
+		return nil
+	}
+	_, _, err := bc.proposalFor(tx)
+	return err
+}
+
+// proposalFor returns the wallet and the proposal a MultisigAction
+// transaction makes or approves, once the sender is allowed to. Expiry is
+// measured against the time of the block being applied, or of the tip. A
+// proposal that reaches the threshold must be payable. Caller must hold bc.mu.
+func (bc *Blockchain) proposalFor(tx *Transaction) (*MultisigWallet, *MultisigProposal, error) {
+	params, err := decodeMultisigAction(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var w MultisigWallet
+	if !bc.getRecord(MultisigKey(params.Wallet), &w) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrMultisigNotFound, params.Wallet)
+	}
+	if !w.isOwner(tx.From) {
+		return nil, nil, fmt.Errorf("%w: %s is not an owner of %s", ErrMultisigState, tx.From, w.Address)
+	}
+	_, now := bc.clock.Now()
+
+	var p MultisigProposal
+	if params.Action == MultisigPropose {
+		if _, exists := bc.TokenRegistry[tx.TokenID]; !exists {
+			return nil, nil, fmt.Errorf("token %s not found", tx.TokenID)
+		}
+		p = MultisigProposal{
+			ID:        tx.ID,
+			Wallet:    w.Address,
+			Proposer:  tx.From,
+			To:        tx.To,
+			TokenID:   tx.TokenID,
+			Amount:    tx.Amount,
+			Approvals: []string{tx.From},
+			Status:    ProposalPending,
+			CreatedAt: now,
+			ExpiresAt: now + params.ExpiresIn,
+		}
+	} else {
+		if !bc.getRecord(MultisigProposalKey(params.ID), &p) || p.Wallet != w.Address {
+			return nil, nil, fmt.Errorf("%w: %s", ErrProposalNotFound, params.ID)
+		}
+		if p.Status != ProposalPending || now >= p.ExpiresAt {
+			return nil, nil, fmt.Errorf("%w: proposal %s is %s or has expired", ErrMultisigState, p.ID, p.Status)
+		}
+		if p.approvedBy(tx.From) {
+			return nil, nil, fmt.Errorf("%w: %s already approved proposal %s", ErrMultisigState, tx.From, p.ID)
+		}
+		p.Approvals = append(p.Approvals, tx.From)
+	}
+	if len(p.Approvals) >= w.Threshold {
+		if err := bc.checkSpend(p.TokenID, w.Address, p.To, p.Amount); err != nil {
+			return nil, nil, err
+		}
+	}
+	return &w, &p, nil
+}
+
+// applyMultisigCreate records a multisig wallet at the address derived from
+// the creator and the transaction's nonce
+func (bc *Blockchain) applyMultisigCreate(tx *Transaction) ([]Event, error) {
+	params, err := decodeMultisigCreate(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := bc.checkMultisigAction(tx); err != nil {
+		return nil, err
+	}
+
+	_, now := bc.clock.Now()
+	w := &MultisigWallet{
+		Address:   MultisigAddress(tx.From, tx.Nonce),
+		Creator:   tx.From,
+		Owners:    params.Owners,
+		Threshold: params.Threshold,
+		CreatedAt: now,
+	}
+	if err := bc.putRecord(MultisigKey(w.Address), w); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("   🔏 Multisig wallet %s: %d of %d owners\n", w.Address, w.Threshold, len(w.Owners))
+	return []Event{{Type: EventMultisigCreated, From: tx.From, To: w.Address}}, nil
+}
+
+// applyMultisigAction records a proposal or an approval, and pays the
+// proposal out of the wallet once the threshold of owners approved it
+func (bc *Blockchain) applyMultisigAction(tx *Transaction) ([]Event, error) {
+	w, p, err := bc.proposalFor(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	eventType := EventMultisigApproved
+	if p.ID == tx.ID {
+		eventType = EventMultisigProposed
+	}
+	events := []Event{{Type: eventType, From: tx.From, To: w.Address, ProposalID: p.ID}}
+	if len(p.Approvals) >= w.Threshold {
+		if err := bc.TokenRegistry[p.TokenID].Transfer(w.Address, p.To, p.Amount); err != nil {
+			return nil, fmt.Errorf("multisig payment failed: %v", err)
+		}
+		p.Status = ProposalExecuted
+		events = append(events, Event{Type: EventMultisigExecuted, TokenID: p.TokenID, From: w.Address, To: p.To, Amount: p.Amount, ProposalID: p.ID})
+	}
+	if err := bc.putRecord(MultisigProposalKey(p.ID), p); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("   🔏 Multisig proposal %s approved by %d of %d, now %s\n", p.ID, len(p.Approvals), w.Threshold, p.Status)
+	return events, nil
+}
+
+// GetMultisigWallet returns the multisig wallet at address
+func (bc *Blockchain) GetMultisigWallet(address string) (*MultisigWallet, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	var w MultisigWallet
+	if !bc.getRecord(MultisigKey(address), &w) {
+		return nil, fmt.Errorf("%w: %s", ErrMultisigNotFound, address)
+	}
+	return &w, nil
+}
+
+// MultisigWalletsOf returns every multisig wallet address is an owner of
+func (bc *Blockchain) MultisigWalletsOf(address string) []*MultisigWallet {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	wallets := []*MultisigWallet{}
+	for _, key := range bc.recordKeys(MultisigKey("")) {
+		var w MultisigWallet
+		if bc.getRecord(key, &w) && w.isOwner(address) {
+			wallets = append(wallets, &w)
+		}
+	}
+	return wallets
+}
+
+// GetMultisigProposal returns the proposal made by the transaction with ID id
+func (bc *Blockchain) GetMultisigProposal(id string) (*MultisigProposal, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	var p MultisigProposal
+	if !bc.getRecord(MultisigProposalKey(id), &p) {
+		return nil, fmt.Errorf("%w: %s", ErrProposalNotFound, id)
+	}
+	return &p, nil
+}
+
+// MultisigProposals returns every proposal made for the wallet at address
+func (bc *Blockchain) MultisigProposals(address string) []*MultisigProposal {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	proposals := []*MultisigProposal{}
+	for _, key := range bc.recordKeys(MultisigProposalKey("")) {
+		var p MultisigProposal
+		if bc.getRecord(key, &p) && p.Wallet == address {
+			proposals = append(proposals, &p)
+		}
+	}
+	return proposals
+}
@@ -0,0 +1,144 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func signedMultisigCreate(t *testing.T, key *btcec.PrivateKey, nonce uint64, params MultisigCreateParams) *Transaction {
+	tx, err := NewMultisigCreateTransaction("", params)
+	assert.Nil(t, err)
+	tx.Nonce = nonce
+	signTestTx(t, tx, key)
+	return tx
+}
+
+func signedMultisigPropose(t *testing.T, key *btcec.PrivateKey, nonce uint64, wallet, to string, amount uint64) *Transaction {
+	tx, err := NewMultisigProposeTransaction("", wallet, to, "BHX", uint256.NewInt(amount), 3600)
+	assert.Nil(t, err)
+	tx.Nonce = nonce
+	signTestTx(t, tx, key)
+	return tx
+}
+
+func signedMultisigApprove(t *testing.T, key *btcec.PrivateKey, nonce uint64, wallet, id string) *Transaction {
+	tx, err := NewMultisigApproveTransaction("", wallet, id)
+	assert.Nil(t, err)
+	tx.Nonce = nonce
+	signTestTx(t, tx, key)
+	return tx
+}
+
+func TestMultisigLifecycle(t *testing.T) {
+	bc, key := newTestChain(t)
+	owner := PublicKeyToAddress(key.PubKey())
+	cosignerKey, _ := btcec.NewPrivateKey()
+	cosigner := PublicKeyToAddress(cosignerKey.PubKey())
+	strangerKey, _ := btcec.NewPrivateKey()
+	wallet := MultisigAddress(owner, 0)
+
+	create := signedMultisigCreate(t, key, 0, MultisigCreateParams{Owners: []string{owner, cosigner, "0xCarol"}, Threshold: 2})
+	fundWallet := signedTokenTx(t, key, TokenTransfer, 1, "BHX", wallet, 100)
+	fundCosigner := signedTokenTx(t, key, TokenTransfer, 2, "BHX", cosigner, 200)
+	propose := signedMultisigPropose(t, key, 3, wallet, "0xBob", 60)
+	assert.True(t, bc.AddBlock(nextBlock(bc, create, fundWallet, fundCosigner, propose)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, create))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, propose))
+
+	w, err := bc.GetMultisigWallet(wallet)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, w.Threshold)
+	assert.Equal(t, []*MultisigWallet{w}, bc.MultisigWalletsOf(cosigner))
+
+	p, err := bc.GetMultisigProposal(propose.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, ProposalPending, p.Status)
+	assert.Equal(t, []string{owner}, p.Approvals)
+	assert.Equal(t, uint256.NewInt(100), bhxBalance(bc, wallet))
+
+	t.Run("Only owners approve, once each", func(t *testing.T) {
+		byStranger := signedMultisigApprove(t, strangerKey, 0, wallet, propose.ID)
+		assert.ErrorIs(t, bc.checkMultisigAction(byStranger), ErrMultisigState)
+		twice := signedMultisigApprove(t, key, 4, wallet, propose.ID)
+		assert.ErrorIs(t, bc.checkMultisigAction(twice), ErrMultisigState)
+	})
+
+	t.Run("A payment the wallet cannot cover is not approved", func(t *testing.T) {
+		tooMuch := signedMultisigPropose(t, key, 4, wallet, "0xBob", 101)
+		assert.True(t, bc.AddBlock(nextBlock(bc, tooMuch)))
+		assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, tooMuch)) // one approval moves nothing yet
+
+		approve := signedMultisigApprove(t, cosignerKey, 0, wallet, tooMuch.ID)
+		assert.NotNil(t, bc.checkMultisigAction(approve))
+	})
+
+	approve := signedMultisigApprove(t, cosignerKey, 0, wallet, propose.ID)
+	assert.True(t, bc.AddBlock(nextBlock(bc, approve)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, approve))
+	assert.Equal(t, uint256.NewInt(60), bhxBalance(bc, "0xBob"))
+	assert.Equal(t, uint256.NewInt(40), bhxBalance(bc, wallet))
+
+	receipt, _ := bc.GetReceipt(approve.ID)
+	assert.Contains(t, receipt.Events, Event{Type: EventMultisigApproved, From: cosigner, To: wallet, ProposalID: propose.ID})
+	assert.Contains(t, receipt.Events, Event{Type: EventMultisigExecuted, TokenID: "BHX", From: wallet, To: "0xBob", Amount: uint256.NewInt(60), ProposalID: propose.ID})
+
+	p, _ = bc.GetMultisigProposal(propose.ID)
+	assert.Equal(t, ProposalExecuted, p.Status)
+	assert.Contains(t, bc.MultisigProposals(wallet), p)
+
+	late := signedMultisigApprove(t, key, 5, wallet, propose.ID)
+	assert.ErrorIs(t, bc.checkMultisigAction(late), ErrMultisigState)
+
+	_, err = bc.disconnectTip()
+	assert.Nil(t, err)
+	p, _ = bc.GetMultisigProposal(propose.ID)
+	assert.Equal(t, ProposalPending, p.Status)
+	assert.Equal(t, uint256.NewInt(100), bhxBalance(bc, wallet))
+	assertStateTreeMatches(t, bc)
+}
+
+func TestDecodeMultisig(t *testing.T) {
+	cases := []struct {
+		name  string
+		tx    func() (*Transaction, error)
+		valid bool
+	}{
+		{"create", func() (*Transaction, error) {
+			return NewMultisigCreateTransaction("0xAlice", MultisigCreateParams{Owners: []string{"0xAlice", "0xBob"}, Threshold: 2})
+		}, true},
+		{"one owner", func() (*Transaction, error) {
+			return NewMultisigCreateTransaction("0xAlice", MultisigCreateParams{Owners: []string{"0xAlice"}, Threshold: 1})
+		}, false},
+		{"repeated owner", func() (*Transaction, error) {
+			return NewMultisigCreateTransaction("0xAlice", MultisigCreateParams{Owners: []string{"0xAlice", "0xAlice"}, Threshold: 1})
+		}, false},
+		{"threshold above owners", func() (*Transaction, error) {
+			return NewMultisigCreateTransaction("0xAlice", MultisigCreateParams{Owners: []string{"0xAlice", "0xBob"}, Threshold: 3})
+		}, false},
+		{"propose", func() (*Transaction, error) {
+			return NewMultisigProposeTransaction("0xAlice", "0xWallet", "0xBob", "BHX", uint256.NewInt(10), 3600)
+		}, true},
+		{"propose nothing", func() (*Transaction, error) {
+			return NewMultisigProposeTransaction("0xAlice", "0xWallet", "0xBob", "BHX", uint256.Int{}, 3600)
+		}, false},
+		{"propose without expiry", func() (*Transaction, error) {
+			return NewMultisigProposeTransaction("0xAlice", "0xWallet", "0xBob", "BHX", uint256.NewInt(10), 0)
+		}, false},
+		{"approve", func() (*Transaction, error) {
+			return NewMultisigApproveTransaction("0xAlice", "0xWallet", "0xProposal")
+		}, true},
+		{"approve nothing", func() (*Transaction, error) {
+			return NewMultisigApproveTransaction("0xAlice", "0xWallet", "")
+		}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tx, err := c.tx()
+			assert.Nil(t, err)
+			assert.Equal(t, c.valid, checkFields(tx) == nil)
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+var (
+	ErrInvalidOTCTx  = errors.New("invalid OTC parameters")
+	ErrOTCNotFound   = errors.New("OTC order not found")
+	ErrOTCOrderState = errors.New("OTC action not allowed")
+)
+
+// OTCAddress holds the offered tokens of every open OTC order
+const OTCAddress = "otc_contract"
+
+// MaxOTCDuration bounds how long an OTC order stays open, in seconds
+const MaxOTCDuration int64 = 30 * 24 * 60 * 60
+
+// OTCStatus is where an OTC order is in its life
+type OTCStatus string
+
+const (
+	OTCOpen      OTCStatus = "open"
+	OTCCompleted OTCStatus = "completed" // filled by a counterparty
+	OTCCancelled OTCStatus = "cancelled" // refunded to the creator
+)
+
+// Actions of an OTCAction transaction
+const (
+	OTCMatch  = "match"
+	OTCCancel = "cancel"
+)
+
+// OTCCreateParams is the JSON carried in the Data of an OTCCreate
+// transaction. The creator offers the transaction's Amount of TokenID for
+// AmountRequested of TokenRequested.
+type OTCCreateParams struct {
+	TokenRequested  string      `json:"token_requested"`
+	AmountRequested uint256.Int `json:"amount_requested"`
+	ExpiresIn       int64       `json:"expires_in"` // seconds after the block that creates the order
+}
+
+// OTCActionParams is the JSON carried in the Data of an OTCAction transaction
+type OTCActionParams struct {
+	ID     string `json:"id"` // ID of the transaction that created the order
+	Action string `json:"action"`
+}
+
+// OTCOrder is an offer to trade a locked amount of one token for an amount of
+// another. Anyone but the creator may fill an open order before it expires,
+// paying the requested amount to the creator and receiving the offer. The
+// creator may cancel an open order at any time, expired or not.
+type OTCOrder struct {
+	ID              string      `json:"order_id"`
+	Creator         string      `json:"creator"`
+	TokenOffered    string      `json:"token_offered"`
+	AmountOffered   uint256.Int `json:"amount_offered"`
+	TokenRequested  string      `json:"token_requested"`
+	AmountRequested uint256.Int `json:"amount_requested"`
+	Status          OTCStatus   `json:"status"`
+	Counterparty    string      `json:"counterparty,omitempty"`
+	CreatedAt       int64       `json:"created_at"`
+	ExpiresAt       int64       `json:"expires_at"`
+}
+
+// NewOTCCreateTransaction builds an unsigned transaction that offers amount of
+// symbol for the amount of another token in params
+func NewOTCCreateTransaction(from, symbol string, amount uint256.Int, params OTCCreateParams) (*Transaction, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(OTCCreate, from, "", amount, nil)
+	tx.TokenID = symbol
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx)
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// NewOTCActionTransaction builds an unsigned transaction that fills or cancels an OTC order
+func NewOTCActionTransaction(from, id, action string) (*Transaction, error) {
+	data, err := json.Marshal(OTCActionParams{ID: id, Action: action})
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(OTCAction, from, "", uint256.Int{}, nil)
+	tx.TokenID = ""
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx)
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// decodeOTCCreate parses and validates the parameters of an OTCCreate transaction
+func decodeOTCCreate(tx *Transaction) (*OTCCreateParams, error) {
+	var params OTCCreateParams
+	if err := json.Unmarshal(tx.Data, &params); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOTCTx, err)
+	}
+	if tx.Amount.IsZero() || params.AmountRequested.IsZero() {
+		return nil, fmt.Errorf("%w: amounts must be positive", ErrInvalidTxFields)
+	}
+	if params.TokenRequested == "" || params.TokenRequested == tx.TokenID {
+		return nil, fmt.Errorf("%w: the requested token must differ from the offered one", ErrInvalidOTCTx)
+	}
+	if params.ExpiresIn <= 0 || params.ExpiresIn > MaxOTCDuration {
+		return nil, fmt.Errorf("%w: expires_in must be 1-%d seconds", ErrInvalidOTCTx, MaxOTCDuration)
+	}
+	return &params, nil
+}
+
+// decodeOTCAction parses and validates the parameters of an OTCAction transaction
+func decodeOTCAction(tx *Transaction) (*OTCActionParams, error) {
+	var params OTCActionParams
+	if err := json.Unmarshal(tx.Data, &params); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOTCTx, err)
+	}
+	if params.ID == "" {
+		return nil, fmt.Errorf("%w: missing order ID", ErrInvalidOTCTx)
+	}
+	if params.Action != OTCMatch && params.Action != OTCCancel {
+		return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidOTCTx, params.Action)
+	}
+	if !tx.Amount.IsZero() {
+		return nil, fmt.Errorf("%w: an OTC action moves no amount of its own", ErrInvalidOTCTx)
+	}
+	return &params, nil
+}
+
+// checkOTCAction runs the checks of OTC transactions that depend on chain
+// state. Expiry is measured against the time of the block being applied, or
+// of the tip. Caller must hold bc.mu.
+func (bc *Blockchain) checkOTCAction(tx *Transaction) error {
+	if tx.Type == OTCCreate {
+		params, err := decodeOTCCreate(tx)
+		if err != nil {
+			return err
+		}
+		if _, exists := bc.TokenRegistry[params.TokenRequested]; !exists {
+			return fmt.Errorf("token %s not found", params.TokenRequested)
+		}
+		return bc.checkSpend(tx.TokenID, tx.From, OTCAddress, tx.Amount)
+	}
+	_, _, err := bc.otcOrderFor(tx)
+	return err
+}
+
+// otcOrderFor returns the order an OTCAction transaction acts on, once the
+// sender is allowed to take the action. Caller must hold bc.mu.
+func (bc *Blockchain) otcOrderFor(tx *Transaction) (*OTCOrder, *OTCActionParams, error) {
+	params, err := decodeOTCAction(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var o OTCOrder
+	if !bc.getRecord(OTCOrderKey(params.ID), &o) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrOTCNotFound, params.ID)
+	}
+	if o.Status != OTCOpen {
+		return nil, nil, fmt.Errorf("%w: order %s is %s", ErrOTCOrderState, o.ID, o.Status)
+	}
+
+	switch params.Action {
+	case OTCMatch:
+		if tx.From == o.Creator {
+			return nil, nil, fmt.Errorf("%w: the creator cannot fill their own order", ErrOTCOrderState)
+		}
+		if _, now := bc.clock.Now(); now >= o.ExpiresAt {
+			return nil, nil, fmt.Errorf("%w: order %s has expired", ErrOTCOrderState, o.ID)
+		}
+		if err := bc.checkSpend(o.TokenRequested, tx.From, o.Creator, o.AmountRequested); err != nil {
+			return nil, nil, err
+		}
+		if err := bc.TokenRegistry[o.TokenOffered].CheckMovement(OTCAddress, tx.From); err != nil {
+			return nil, nil, err
+		}
+	case OTCCancel:
+		if tx.From != o.Creator {
+			return nil, nil, fmt.Errorf("%w: only the creator can cancel order %s", ErrOTCOrderState, o.ID)
+		}
+	}
+	return &o, params, nil
+}
+
+// applyOTCCreate locks the offer with the OTC account and records the order
+// under the transaction's ID
+func (bc *Blockchain) applyOTCCreate(tx *Transaction) ([]Event, error) {
+	if err := bc.checkOTCAction(tx); err != nil {
+		return nil, err
+	}
+	params, _ := decodeOTCCreate(tx)
+	if err := bc.TokenRegistry[tx.TokenID].Transfer(tx.From, OTCAddress, tx.Amount); err != nil {
+		return nil, fmt.Errorf("OTC order failed: %v", err)
+	}
+
+	_, now := bc.clock.Now()
+	o := &OTCOrder{
+		ID:              tx.ID,
+		Creator:         tx.From,
+		TokenOffered:    tx.TokenID,
+		AmountOffered:   tx.Amount,
+		TokenRequested:  params.TokenRequested,
+		AmountRequested: params.AmountRequested,
+		Status:          OTCOpen,
+		CreatedAt:       now,
+		ExpiresAt:       now + params.ExpiresIn,
+	}
+	if err := bc.putRecord(OTCOrderKey(o.ID), o); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("   🤝 OTC order %s: %s %s for %s %s\n", o.ID, o.AmountOffered, o.TokenOffered, o.AmountRequested, o.TokenRequested)
+	return []Event{{Type: EventOTCCreated, TokenID: tx.TokenID, From: tx.From, To: OTCAddress, Amount: tx.Amount, OrderID: o.ID}}, nil
+}
+
+// applyOTCAction fills an order, paying the requested amount to the creator
+// and the offer to the counterparty, or refunds the offer to the creator on
+// cancel
+func (bc *Blockchain) applyOTCAction(tx *Transaction) ([]Event, error) {
+	o, params, err := bc.otcOrderFor(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	if params.Action == OTCMatch {
+		if err := bc.TokenRegistry[o.TokenRequested].Transfer(tx.From, o.Creator, o.AmountRequested); err != nil {
+			return nil, fmt.Errorf("OTC match failed: %v", err)
+		}
+		if err := bc.TokenRegistry[o.TokenOffered].Transfer(OTCAddress, tx.From, o.AmountOffered); err != nil {
+			return nil, fmt.Errorf("OTC match failed: %v", err)
+		}
+		o.Status, o.Counterparty = OTCCompleted, tx.From
+		events = []Event{
+			{Type: EventTransfer, TokenID: o.TokenRequested, From: tx.From, To: o.Creator, Amount: o.AmountRequested, OrderID: o.ID},
+			{Type: EventOTCFilled, TokenID: o.TokenOffered, From: OTCAddress, To: tx.From, Amount: o.AmountOffered, OrderID: o.ID},
+		}
+	} else {
+		if err := bc.TokenRegistry[o.TokenOffered].Transfer(OTCAddress, o.Creator, o.AmountOffered); err != nil {
+			return nil, fmt.Errorf("OTC cancel failed: %v", err)
+		}
+		o.Status = OTCCancelled
+		events = []Event{{Type: EventOTCCancelled, TokenID: o.TokenOffered, From: OTCAddress, To: o.Creator, Amount: o.AmountOffered, OrderID: o.ID}}
+	}
+	if err := bc.putRecord(OTCOrderKey(o.ID), o); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("   🤝 OTC order %s %s by %s, now %s\n", o.ID, params.Action, tx.From, o.Status)
+	return events, nil
+}
+
+// GetOTCOrder returns the OTC order created by the transaction with ID id
+func (bc *Blockchain) GetOTCOrder(id string) (*OTCOrder, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	var o OTCOrder
+	if !bc.getRecord(OTCOrderKey(id), &o) {
+		return nil, fmt.Errorf("%w: %s", ErrOTCNotFound, id)
+	}
+	return &o, nil
+}
+
+// OTCOrdersOf returns every OTC order address created or filled
+func (bc *Blockchain) OTCOrdersOf(address string) []*OTCOrder {
+	return bc.otcOrders(func(o *OTCOrder, _ int64) bool {
+		return o.Creator == address || o.Counterparty == address
+	})
+}
+
+// OpenOTCOrders returns every OTC order that can still be filled
+func (bc *Blockchain) OpenOTCOrders() []*OTCOrder {
+	return bc.otcOrders(func(o *OTCOrder, now int64) bool {
+		return o.Status == OTCOpen && now < o.ExpiresAt
+	})
+}
+
+// otcOrders returns the OTC orders keep selects, given the time of the tip
+func (bc *Blockchain) otcOrders(keep func(o *OTCOrder, now int64) bool) []*OTCOrder {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	_, now := bc.clock.Now()
+	orders := []*OTCOrder{}
+	for _, key := range bc.recordKeys(OTCOrderKey("")) {
+		var o OTCOrder
+		if bc.getRecord(key, &o) && keep(&o, now) {
+			orders = append(orders, &o)
+		}
+	}
+	return orders
+}
@@ -0,0 +1,139 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func signedOTCCreate(t *testing.T, key *btcec.PrivateKey, nonce uint64, symbol string, amount uint64, params OTCCreateParams) *Transaction {
+	tx, err := NewOTCCreateTransaction("", symbol, uint256.NewInt(amount), params)
+	assert.Nil(t, err)
+	tx.Nonce = nonce
+	signTestTx(t, tx, key)
+	return tx
+}
+
+func signedOTCAction(t *testing.T, key *btcec.PrivateKey, nonce uint64, id, action string) *Transaction {
+	tx, err := NewOTCActionTransaction("", id, action)
+	assert.Nil(t, err)
+	tx.Nonce = nonce
+	signTestTx(t, tx, key)
+	return tx
+}
+
+func TestOTCLifecycle(t *testing.T) {
+	bc, key := newTestChain(t)
+	creator := PublicKeyToAddress(key.PubKey())
+	takerKey, _ := btcec.NewPrivateKey()
+	taker := PublicKeyToAddress(takerKey.PubKey())
+	params := OTCCreateParams{TokenRequested: "BHX", AmountRequested: uint256.NewInt(50), ExpiresIn: 3600}
+
+	gold := signedTokenCreate(t, key, 0, "GOLD", TokenCreateParams{Name: "Gold"}, 1000)
+	fund := signedTokenTx(t, key, TokenTransfer, 1, "BHX", taker, 300)
+	create := signedOTCCreate(t, key, 2, "GOLD", 100, params)
+	assert.True(t, bc.AddBlock(nextBlock(bc, gold, fund, create)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, create))
+
+	receipt, _ := bc.GetReceipt(create.ID)
+	assert.Contains(t, receipt.Events, Event{Type: EventOTCCreated, TokenID: "GOLD", From: creator, To: OTCAddress, Amount: uint256.NewInt(100), OrderID: create.ID})
+
+	o, err := bc.GetOTCOrder(create.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, OTCOpen, o.Status)
+	assert.Equal(t, o.CreatedAt+3600, o.ExpiresAt)
+	assert.Equal(t, []*OTCOrder{o}, bc.OpenOTCOrders())
+
+	t.Run("The creator cannot fill their own order", func(t *testing.T) {
+		match := signedOTCAction(t, key, 3, create.ID, OTCMatch)
+		assert.ErrorIs(t, bc.checkOTCAction(match), ErrOTCOrderState)
+	})
+
+	t.Run("Only the creator cancels", func(t *testing.T) {
+		cancel := signedOTCAction(t, takerKey, 0, create.ID, OTCCancel)
+		assert.ErrorIs(t, bc.checkOTCAction(cancel), ErrOTCOrderState)
+	})
+
+	before := bhxBalance(bc, creator)
+	match := signedOTCAction(t, takerKey, 0, create.ID, OTCMatch)
+	assert.True(t, bc.AddBlock(nextBlock(bc, match)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, match))
+
+	receipt, _ = bc.GetReceipt(match.ID)
+	assert.Contains(t, receipt.Events, Event{Type: EventTransfer, TokenID: "BHX", From: taker, To: creator, Amount: uint256.NewInt(50), OrderID: create.ID})
+	assert.Contains(t, receipt.Events, Event{Type: EventOTCFilled, TokenID: "GOLD", From: OTCAddress, To: taker, Amount: uint256.NewInt(100), OrderID: create.ID})
+
+	received, _ := bc.TokenRegistry["GOLD"].BalanceOf(taker)
+	assert.Equal(t, uint256.NewInt(100), received)
+	paid, _ := bhxBalance(bc, creator).Sub(before)
+	assert.Equal(t, uint256.NewInt(50), paid)
+
+	o, _ = bc.GetOTCOrder(create.ID)
+	assert.Equal(t, OTCCompleted, o.Status)
+	assert.Equal(t, taker, o.Counterparty)
+	assert.Empty(t, bc.OpenOTCOrders())
+	assert.Equal(t, []*OTCOrder{o}, bc.OTCOrdersOf(taker))
+
+	again := signedOTCAction(t, key, 3, create.ID, OTCCancel)
+	assert.ErrorIs(t, bc.checkOTCAction(again), ErrOTCOrderState)
+	assertStateTreeMatches(t, bc)
+}
+
+func TestOTCReverts(t *testing.T) {
+	bc, key := newTestChain(t)
+	creator := PublicKeyToAddress(key.PubKey())
+
+	gold := signedTokenCreate(t, key, 0, "GOLD", TokenCreateParams{Name: "Gold"}, 1000)
+	assert.True(t, bc.AddBlock(nextBlock(bc, gold)))
+	root := bc.currentStateTree().Root()
+
+	create := signedOTCCreate(t, key, 1, "GOLD", 100, OTCCreateParams{TokenRequested: "BHX", AmountRequested: uint256.NewInt(50), ExpiresIn: 3600})
+	assert.True(t, bc.AddBlock(nextBlock(bc, create)))
+	afterCreate := bc.currentStateTree().Root()
+
+	cancel := signedOTCAction(t, key, 2, create.ID, OTCCancel)
+	assert.True(t, bc.AddBlock(nextBlock(bc, cancel)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, cancel))
+	receipt, _ := bc.GetReceipt(cancel.ID)
+	assert.Contains(t, receipt.Events, Event{Type: EventOTCCancelled, TokenID: "GOLD", From: OTCAddress, To: creator, Amount: uint256.NewInt(100), OrderID: create.ID})
+
+	_, err := bc.disconnectTip()
+	assert.Nil(t, err)
+	assert.Equal(t, afterCreate, bc.currentStateTree().Root())
+	o, _ := bc.GetOTCOrder(create.ID)
+	assert.Equal(t, OTCOpen, o.Status)
+	locked, _ := bc.TokenRegistry["GOLD"].BalanceOf(OTCAddress)
+	assert.Equal(t, uint256.NewInt(100), locked)
+
+	_, err = bc.disconnectTip()
+	assert.Nil(t, err)
+	_, err = bc.GetOTCOrder(create.ID)
+	assert.ErrorIs(t, err, ErrOTCNotFound)
+	assert.Equal(t, root, bc.currentStateTree().Root())
+	assertStateTreeMatches(t, bc)
+}
+
+func TestDecodeOTCCreate(t *testing.T) {
+	cases := []struct {
+		name   string
+		amount uint64
+		params OTCCreateParams
+		valid  bool
+	}{
+		{"order", 10, OTCCreateParams{TokenRequested: "BHX", AmountRequested: uint256.NewInt(5), ExpiresIn: 3600}, true},
+		{"zero offer", 0, OTCCreateParams{TokenRequested: "BHX", AmountRequested: uint256.NewInt(5), ExpiresIn: 3600}, false},
+		{"zero price", 10, OTCCreateParams{TokenRequested: "BHX", ExpiresIn: 3600}, false},
+		{"same token", 10, OTCCreateParams{TokenRequested: "GOLD", AmountRequested: uint256.NewInt(5), ExpiresIn: 3600}, false},
+		{"no expiry", 10, OTCCreateParams{TokenRequested: "BHX", AmountRequested: uint256.NewInt(5)}, false},
+		{"too long", 10, OTCCreateParams{TokenRequested: "BHX", AmountRequested: uint256.NewInt(5), ExpiresIn: MaxOTCDuration + 1}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tx, err := NewOTCCreateTransaction("0xAlice", "GOLD", uint256.NewInt(c.amount), c.params)
+			assert.Nil(t, err)
+			assert.Equal(t, c.valid, checkFields(tx) == nil)
+		})
+	}
+}
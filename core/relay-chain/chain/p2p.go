@@ -3,6 +3,7 @@ package chain
 import (
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"net"
 	"strings"
@@ -16,11 +17,17 @@ import (
 	"github.com/multiformats/go-multiaddr"
 )
 
+// protocolID is the libp2p protocol every message stream uses
+const protocolID = "/blackhole/1.0.0"
+
+var ErrGenesisMismatch = errors.New("peer runs a different genesis")
+
 type Node struct {
 	Host         host.Host
 	peers        map[peer.ID]*peer.AddrInfo
 	peersLock    sync.RWMutex
 	chain        *Blockchain
+	genesis      string // genesis block hash, exchanged with peers on connect
 	badPeers     map[peer.ID]int
 	badPeersLock sync.RWMutex
 }
@@ -56,7 +63,7 @@ func NewNode(ctx context.Context, port int) (*Node, error) {
 		badPeers: make(map[peer.ID]int),
 	}
 
-	h.SetStreamHandler("/blackhole/1.0.0", node.handleStream)
+	h.SetStreamHandler(protocolID, node.handleStream)
 
 	fmt.Println("🆔 Peer ID:", h.ID().String())
 	for _, addr := range h.Addrs() {
@@ -84,6 +91,10 @@ func (n *Node) Connect(ctx context.Context, addr string) error {
 	if err := n.Host.Connect(ctx, *info); err != nil {
 		return err
 	}
+	if err := n.handshake(ctx, info.ID); err != nil {
+		n.Host.Network().ClosePeer(info.ID)
+		return err
+	}
 
 	n.peersLock.Lock()
 	n.peers[info.ID] = info
@@ -95,12 +106,88 @@ func (n *Node) SetChain(bc *Blockchain) {
 	n.chain = bc
 }
 
+// SetGenesis sets the genesis block hash that peers must share
+func (n *Node) SetGenesis(hash string) {
+	n.genesis = hash
+}
+
+// handshake sends our genesis hash to a newly connected peer and fails unless
+// the peer answers with the same one
+func (n *Node) handshake(ctx context.Context, peerID peer.ID) error {
+	s, err := n.Host.NewStream(ctx, peerID, protocolID)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	hello := &Message{Type: MessageTypeHandshake, Genesis: n.genesis}
+	if err := hello.Encode(s); err != nil {
+		return err
+	}
+
+	var reply Message
+	s.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := reply.Decode(s); err != nil {
+		return fmt.Errorf("handshake with %s failed: %v", peerID, err)
+	}
+	if reply.Genesis != n.genesis {
+		return fmt.Errorf("%w: %s has genesis %s, we have %s", ErrGenesisMismatch, peerID, reply.Genesis, n.genesis)
+	}
+	fmt.Printf("🤝 Handshake with %s complete\n", peerID)
+	return nil
+}
+
+// answerHandshake replies with our genesis hash and, if the peer shares it,
+// starts sending it our messages. A peer on another genesis closes the
+// connection itself once it sees the reply.
+func (n *Node) answerHandshake(s network.Stream, msg *Message) {
+	peerID := s.Conn().RemotePeer()
+	reply := &Message{Type: MessageTypeHandshake, Genesis: n.genesis}
+	if err := reply.Encode(s); err != nil {
+		fmt.Printf("❌ Error answering handshake from %s: %v\n", peerID, err)
+		return
+	}
+	if msg.Genesis != n.genesis {
+		fmt.Printf("🚫 Refusing peer %s with genesis %q\n", peerID, msg.Genesis)
+		return
+	}
+	fmt.Printf("🤝 Accepted handshake from peer %s\n", peerID)
+
+	info := n.Host.Peerstore().PeerInfo(peerID)
+	n.peersLock.Lock()
+	n.peers[peerID] = &info
+	n.peersLock.Unlock()
+}
+
 func (n *Node) disconnectPeer(peerID peer.ID) {
 	n.peersLock.Lock()
 	delete(n.peers, peerID)
 	n.peersLock.Unlock()
 	n.Host.Network().ClosePeer(peerID)
-	fmt.Printf("🚫 Disconnected peer %s due to invalid blocks\n", peerID)
+	fmt.Printf("🚫 Disconnected peer %s\n", peerID)
+}
+
+// penalizePeer counts a bad message against a peer and disconnects it after three
+func (n *Node) penalizePeer(peerID peer.ID) {
+	n.badPeersLock.Lock()
+	defer n.badPeersLock.Unlock()
+	n.badPeers[peerID]++
+	if n.badPeers[peerID] >= 3 {
+		n.disconnectPeer(peerID)
+	}
+}
+
+// checkBlockOrigin rejects a block whose hash or proposer signature is forged.
+// Such a block proves nothing about the validator it names, so the peer that
+// relayed it is blamed instead of the validator.
+func checkBlockOrigin(block *Block) error {
+	if block.CalculateHash() != block.Hash {
+		return ErrBadBlockHash
+	}
+	if block.Header.Index > 0 {
+		return block.SignedHeader().VerifyProposer()
+	}
+	return nil
 }
 
 type BlockchainComparisonResult struct {
@@ -184,6 +271,16 @@ func (n *Node) handleStream(s network.Stream) {
 		return
 	}
 
+	if msg.Type == MessageTypeHandshake {
+		n.answerHandshake(s, &msg)
+		return
+	}
+	if msg.Genesis != n.genesis {
+		fmt.Printf("🚫 Peer %s runs genesis %q, we run %s\n", peerID, msg.Genesis, n.genesis)
+		n.disconnectPeer(peerID)
+		return
+	}
+
 	switch msg.Type {
 	case MessageTypeTx:
 		tx, err := DeserializeTransaction(msg.Data)
@@ -191,14 +288,12 @@ func (n *Node) handleStream(s network.Stream) {
 			fmt.Printf("❌ Error deserializing transaction from peer %s: %v\n", peerID, err)
 			return
 		}
-		fmt.Println("hello inside msgtypetx")
-		// if tx.Verify() {
-		fmt.Println("hello inside tx.verify")
-		n.chain.mu.Lock()
-		n.chain.PendingTxs = append(n.chain.PendingTxs, tx)
-		n.chain.mu.Unlock()
+		// The pool verifies the signature before accepting the transaction
+		if err := n.chain.AddPendingTransaction(tx); err != nil {
+			fmt.Printf("⚠️ Transaction %s from peer %s not pooled: %v\n", tx.ID, peerID, err)
+			return
+		}
 		fmt.Printf("📥 Added transaction %s from peer %s to pending\n", tx.ID, peerID)
-		// }
 		fmt.Println(msg.Type)
 	case MessageTypeBlock:
 		block, err := DeserializeBlock(msg.Data)
@@ -209,16 +304,16 @@ func (n *Node) handleStream(s network.Stream) {
 				return
 			}
 			fmt.Printf("❌ Error deserializing block from peer %s: %v\n", peerID, err)
-			n.badPeersLock.Lock()
-			n.badPeers[peerID]++
-			if n.badPeers[peerID] >= 3 {
-				n.disconnectPeer(peerID)
-			}
-			n.badPeersLock.Unlock()
+			n.penalizePeer(peerID)
 			return
 		}
 		fmt.Printf("📑 Block details: Index=%d, Hash=%s, PrevHash=%s, Validator=%s, TxCount=%d\n",
 			block.Header.Index, block.Hash, block.Header.PreviousHash, block.Header.Validator, len(block.Transactions))
+		if err := checkBlockOrigin(block); err != nil {
+			fmt.Printf("❌ Dropping block %d from peer %s: %v\n", block.Header.Index, peerID, err)
+			n.penalizePeer(peerID)
+			return
+		}
 		if n.chain.AddBlock(block) {
 			fmt.Printf("🧱 Added block %d from peer %s\n", block.Header.Index, peerID)
 			n.badPeersLock.Lock()
@@ -256,8 +351,9 @@ func (n *Node) handleStream(s network.Stream) {
 				Type:    MessageTypeSyncResp,
 				Data:    data,
 				Version: ProtocolVersion,
+				Genesis: n.genesis,
 			}
-			s, err := n.Host.NewStream(context.Background(), peerID, "/blackhole/1.0.0")
+			s, err := n.Host.NewStream(context.Background(), peerID, protocolID)
 			if err != nil {
 				fmt.Printf("❌ Error opening stream to %s: %v\n", peerID, err)
 				continue
@@ -279,16 +375,16 @@ func (n *Node) handleStream(s network.Stream) {
 				return
 			}
 			fmt.Printf("❌ Error deserializing sync block from peer %s: %v\n", peerID, err)
-			n.badPeersLock.Lock()
-			n.badPeers[peerID]++
-			if n.badPeers[peerID] >= 3 {
-				n.disconnectPeer(peerID)
-			}
-			n.badPeersLock.Unlock()
+			n.penalizePeer(peerID)
 			return
 		}
 		fmt.Printf("📑 Sync block details: Index=%d, Hash=%s, PrevHash=%s, Validator=%s, TxCount=%d\n",
 			block.Header.Index, block.Hash, block.Header.PreviousHash, block.Header.Validator, len(block.Transactions))
+		if err := checkBlockOrigin(block); err != nil {
+			fmt.Printf("❌ Dropping sync block %d from peer %s: %v\n", block.Header.Index, peerID, err)
+			n.penalizePeer(peerID)
+			return
+		}
 		if n.chain.AddBlock(block) {
 			fmt.Printf("🧱 Added sync block %d from peer %s\n", block.Header.Index, peerID)
 			n.badPeersLock.Lock()
@@ -308,12 +404,13 @@ func (n *Node) Broadcast(msg *Message) {
 	defer n.peersLock.RUnlock()
 
 	for peerID := range n.peers {
-		s, err := n.Host.NewStream(context.Background(), peerID, "/blackhole/1.0.0")
+		s, err := n.Host.NewStream(context.Background(), peerID, protocolID)
 		if err != nil {
 			fmt.Printf("❌ Error opening stream to %s: %v\n", peerID, err)
 			continue
 		}
 		msg.Version = ProtocolVersion
+		msg.Genesis = n.genesis
 		if err := msg.Encode(s); err != nil {
 			fmt.Printf("❌ Error encoding message to %s: %v\n", peerID, err)
 			s.Close()
@@ -0,0 +1,179 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+)
+
+// PermitFormatVersion is the version byte of the permit signing payload
+const PermitFormatVersion byte = 1
+
+// permitDomain starts every permit signing payload, so a permit signature can
+// never be mistaken for a transaction signature
+const permitDomain = "blackhole-permit"
+
+var (
+	ErrInvalidPermit = errors.New("invalid permit")
+	ErrPermitExpired = errors.New("permit has expired")
+	ErrPermitNonce   = errors.New("permit nonce does not match the owner's")
+)
+
+// Permit is an allowance signed by the token owner off chain. Anyone can
+// submit it in a TokenPermit transaction, which sets the owner's allowance
+// for Spender to Amount and uses up the owner's permit nonce.
+type Permit struct {
+	ChainID   uint64      `json:"chain_id"`
+	Token     string      `json:"token"`
+	Owner     string      `json:"owner"`
+	Spender   string      `json:"spender"`
+	Amount    uint256.Int `json:"amount"`
+	Nonce     uint64      `json:"nonce"`     // the owner's permit nonce, not its transaction nonce
+	Deadline  int64       `json:"deadline"`  // last block time, in Unix seconds, that may include it
+	Signature string      `json:"signature"` // hex compact signature by the owner
+}
+
+// SigningBytes returns the payload the owner signs
+func (p *Permit) SigningBytes() []byte {
+	var e encoder
+	e.string(permitDomain)
+	e.uint8(PermitFormatVersion)
+	e.uint64(p.ChainID)
+	e.string(p.Token)
+	e.string(p.Owner)
+	e.string(p.Spender)
+	e.uint256(p.Amount)
+	e.uint64(p.Nonce)
+	e.uint64(uint64(p.Deadline))
+	return e.buf.Bytes()
+}
+
+// Hash returns the SHA-256 digest of the signing payload
+func (p *Permit) Hash() []byte {
+	hash := sha256.Sum256(p.SigningBytes())
+	return hash[:]
+}
+
+// Sign sets Owner to the address of privateKey and signs the permit
+func (p *Permit) Sign(privateKey *btcec.PrivateKey) error {
+	if privateKey == nil {
+		return errors.New("missing private key")
+	}
+	p.Owner = PublicKeyToAddress(privateKey.PubKey())
+	p.Signature = hex.EncodeToString(ecdsa.SignCompact(privateKey, p.Hash(), true))
+	return nil
+}
+
+// Verify checks the permit's fields and that it was signed by Owner
+func (p *Permit) Verify() error {
+	if p.Token == "" || p.Owner == "" || p.Spender == "" {
+		return fmt.Errorf("%w: token, owner and spender are required", ErrInvalidPermit)
+	}
+	if p.Deadline <= 0 {
+		return fmt.Errorf("%w: deadline must be positive", ErrInvalidPermit)
+	}
+	signature, err := hex.DecodeString(p.Signature)
+	if err != nil || len(signature) != SignatureLength {
+		return fmt.Errorf("%w: signature must be %d hex bytes", ErrInvalidPermit, SignatureLength)
+	}
+	pub, compressed, err := ecdsa.RecoverCompact(signature, p.Hash())
+	if err != nil || !compressed {
+		return fmt.Errorf("%w: bad signature", ErrInvalidPermit)
+	}
+	if signer := PublicKeyToAddress(pub); signer != p.Owner {
+		return fmt.Errorf("%w: signed by %s, owner is %s", ErrInvalidPermit, signer, p.Owner)
+	}
+	return nil
+}
+
+// NewTokenPermitTransaction builds an unsigned transaction from submitter that
+// carries a signed permit. The spender and amount are repeated in To and
+// Amount so the transaction is indexed under the spender.
+func NewTokenPermitTransaction(submitter string, p *Permit) (*Transaction, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(TokenPermit, submitter, p.Spender, p.Amount, nil)
+	tx.TokenID = p.Token
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx)
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// decodeTokenPermit parses the permit in a TokenPermit transaction and checks
+// that it matches the transaction and carries the owner's signature
+func decodeTokenPermit(tx *Transaction) (*Permit, error) {
+	var p Permit
+	if err := json.Unmarshal(tx.Data, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPermit, err)
+	}
+	if p.Token != tx.TokenID || p.Spender != tx.To || p.Amount != tx.Amount {
+		return nil, fmt.Errorf("%w: token, spender and amount must match the transaction", ErrInvalidPermit)
+	}
+	if p.ChainID != tx.ChainID {
+		return nil, fmt.Errorf("%w: signed for chain %d", ErrInvalidPermit, p.ChainID)
+	}
+	if err := p.Verify(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetPermitNonce returns the nonce the owner's next permit must carry
+func (bc *Blockchain) GetPermitNonce(owner string) uint64 {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.permitNonce(owner)
+}
+
+// permitNonce is GetPermitNonce for callers that hold bc.mu
+func (bc *Blockchain) permitNonce(owner string) uint64 {
+	if acc, ok := bc.GlobalState[owner]; ok {
+		return acc.PermitNonce
+	}
+	return 0
+}
+
+// checkPermit runs the checks of a permit that depend on chain state. unix is
+// the block time the permit is checked against. Caller must hold bc.mu.
+func (bc *Blockchain) checkPermit(p *Permit, unix int64) error {
+	if _, exists := bc.TokenRegistry[p.Token]; !exists {
+		return fmt.Errorf("token %s not found", p.Token)
+	}
+	if unix > p.Deadline {
+		return fmt.Errorf("%w: deadline %d, block time %d", ErrPermitExpired, p.Deadline, unix)
+	}
+	if expected := bc.permitNonce(p.Owner); p.Nonce != expected {
+		return fmt.Errorf("%w: expected %d, got %d", ErrPermitNonce, expected, p.Nonce)
+	}
+	return nil
+}
+
+// applyTokenPermit sets the allowance a permit grants and uses up the owner's
+// permit nonce. Both happen or neither does.
+func (bc *Blockchain) applyTokenPermit(tx *Transaction) ([]Event, error) {
+	p, err := decodeTokenPermit(tx)
+	if err != nil {
+		return nil, err
+	}
+	_, unix := bc.clock.Now()
+	if err := bc.checkPermit(p, unix); err != nil {
+		return nil, err
+	}
+	if err := bc.TokenRegistry[p.Token].Approve(p.Owner, p.Spender, p.Amount); err != nil {
+		return nil, fmt.Errorf("permit failed: %v", err)
+	}
+	bc.getOrCreateAccount(p.Owner).PermitNonce++
+	bc.dirtyAccounts[p.Owner] = true
+
+	fmt.Printf("   ✍️ Permit: %s allows %s to spend %s %s\n", p.Owner, p.Spender, p.Amount, p.Token)
+	return []Event{{Type: EventApproval, TokenID: p.Token, From: p.Owner, To: p.Spender, Amount: p.Amount}}, nil
+}
@@ -0,0 +1,117 @@
+package chain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func signedPermit(t *testing.T, bc *Blockchain, owner *btcec.PrivateKey, spender string, amount, nonce uint64) *Permit {
+	p := &Permit{
+		ChainID:  bc.ChainID,
+		Token:    "BHX",
+		Spender:  spender,
+		Amount:   uint256.NewInt(amount),
+		Nonce:    nonce,
+		Deadline: time.Now().Add(time.Hour).Unix(),
+	}
+	assert.Nil(t, p.Sign(owner))
+	return p
+}
+
+func permitTx(t *testing.T, relayer *btcec.PrivateKey, nonce uint64, p *Permit) *Transaction {
+	tx, err := NewTokenPermitTransaction("", p)
+	assert.Nil(t, err)
+	tx.Nonce = nonce
+	signTestTx(t, tx, relayer)
+	return tx
+}
+
+func TestTokenPermit(t *testing.T) {
+	ownerKey, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+	owner := PublicKeyToAddress(ownerKey.PubKey())
+	relayerKey, _ := btcec.NewPrivateKey()
+
+	g := testGenesis(t)
+	g.Tokens[0].Balances[owner] = uint256.NewInt(500)
+	g.Tokens[0].Balances[PublicKeyToAddress(relayerKey.PubKey())] = uint256.NewInt(5000)
+	validator := g.Validators[0].Address
+
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	bc, err := openChain(db, g, DefaultHistoryConfig())
+	assert.Nil(t, err)
+	before := bc.StateRoot()
+
+	// The owner never sends a transaction; the relayer pays for the permit
+	permit := signedPermit(t, bc, ownerKey, "0xDex", 200, 0)
+	tx := permitTx(t, relayerKey, 0, permit)
+	assert.Nil(t, bc.ValidateTransaction(tx))
+	assert.True(t, bc.AddBlock(blockBy(bc, validator, tx)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, tx))
+
+	allowance, err := bc.TokenRegistry["BHX"].Allowance(owner, "0xDex")
+	assert.Nil(t, err)
+	assert.Equal(t, uint256.NewInt(200), allowance)
+	assert.Equal(t, uint64(1), bc.GetPermitNonce(owner))
+	assert.Equal(t, uint64(0), bc.GetNonce(owner))
+
+	receipt, _ := bc.GetReceipt(tx.ID)
+	last := receipt.Events[len(receipt.Events)-1]
+	assert.Equal(t, EventApproval, last.Type)
+	assert.Equal(t, owner, last.From)
+	assert.Equal(t, "0xDex", last.To)
+
+	t.Run("Replayed permit", func(t *testing.T) {
+		err := bc.ValidateTransaction(permitTx(t, relayerKey, 1, permit))
+		assert.ErrorIs(t, err, ErrPermitNonce)
+	})
+
+	t.Run("Expired permit", func(t *testing.T) {
+		expired := signedPermit(t, bc, ownerKey, "0xDex", 10, 1)
+		expired.Deadline = bc.Blocks[0].Header.Timestamp.Unix() - 1
+		assert.Nil(t, expired.Sign(ownerKey))
+		err := bc.ValidateTransaction(permitTx(t, relayerKey, 1, expired))
+		assert.ErrorIs(t, err, ErrPermitExpired)
+	})
+
+	t.Run("Permit for another chain", func(t *testing.T) {
+		other := signedPermit(t, bc, ownerKey, "0xDex", 10, 1)
+		other.ChainID = bc.ChainID + 1
+		assert.Nil(t, other.Sign(ownerKey))
+		err := bc.ValidateTransaction(permitTx(t, relayerKey, 1, other))
+		assert.ErrorIs(t, err, ErrInvalidPermit)
+	})
+
+	t.Run("Tampered amount", func(t *testing.T) {
+		tampered := signedPermit(t, bc, ownerKey, "0xDex", 10, 1)
+		tampered.Amount = uint256.NewInt(10000)
+		err := bc.ValidateTransaction(permitTx(t, relayerKey, 1, tampered))
+		assert.ErrorIs(t, err, ErrInvalidPermit)
+	})
+
+	t.Run("Signed by someone else", func(t *testing.T) {
+		forged := signedPermit(t, bc, relayerKey, "0xDex", 10, 0)
+		forged.Owner = owner
+		err := bc.ValidateTransaction(permitTx(t, relayerKey, 1, forged))
+		assert.ErrorIs(t, err, ErrInvalidPermit)
+	})
+
+	t.Run("Disconnecting the block undoes the permit", func(t *testing.T) {
+		_, err := bc.disconnectTip()
+		assert.Nil(t, err)
+		allowance, _ := bc.TokenRegistry["BHX"].Allowance(owner, "0xDex")
+		assert.True(t, allowance.IsZero())
+		assert.Equal(t, uint64(0), bc.GetPermitNonce(owner))
+		assert.Equal(t, before, bc.StateRoot())
+	})
+	assertStateTreeMatches(t, bc)
+}
@@ -0,0 +1,127 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// ReceiptStatus is the outcome of applying a transaction
+type ReceiptStatus string
+
+const (
+	ReceiptSuccess ReceiptStatus = "success"
+	ReceiptFailed  ReceiptStatus = "failed"
+)
+
+// Event is a state change emitted while applying a transaction
+type Event struct {
+	Type       EventType    `json:"type"`
+	TokenID    string       `json:"token_id,omitempty"`
+	From       string       `json:"from,omitempty"`
+	To         string       `json:"to,omitempty"`
+	Amount     uint256.Int  `json:"amount"`
+	Role       string       `json:"role,omitempty"`        // only for role changes
+	ItemID     string       `json:"item_id,omitempty"`     // only for collection item events
+	Topic      string       `json:"topic,omitempty"`       // only for contract events
+	Data       []byte       `json:"data,omitempty"`        // only for contract events
+	EscrowID   string       `json:"escrow_id,omitempty"`   // only for escrow events
+	TokenOut   string       `json:"token_out,omitempty"`   // only for swaps, the token paid out
+	AmountOut  *uint256.Int `json:"amount_out,omitempty"`  // only for swaps, the amount paid out
+	OrderID    string       `json:"order_id,omitempty"`    // only for OTC events
+	ProposalID string       `json:"proposal_id,omitempty"` // only for multisig proposal events
+}
+
+// Receipt records the result of a transaction included in a block
+type Receipt struct {
+	TxID        string        `json:"tx_id"`
+	Status      ReceiptStatus `json:"status"`
+	Error       string        `json:"error,omitempty"`
+	Fee         uint256.Int   `json:"fee"`
+	GasUsed     uint64        `json:"gas_used"`
+	BlockHeight uint64        `json:"block_height"`
+	BlockHash   string        `json:"block_hash"`
+	Index       int           `json:"index"`
+	Events      []Event       `json:"events"`
+}
+
+// ErrReceiptNotFound is returned when no receipt exists for a transaction
+var ErrReceiptNotFound = errors.New("receipt not found")
+
+// Hash returns the hash committed into the receipts root. The block hash is
+// left out, since the block header itself commits to the receipts root.
+func (r *Receipt) Hash() string {
+	committed := *r
+	committed.BlockHash = ""
+	data, _ := json.Marshal(&committed)
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// CalculateReceiptsRoot returns the Merkle root over the receipt hashes in block order
+func CalculateReceiptsRoot(receipts []*Receipt) string {
+	hashes := make([]string, 0, len(receipts))
+	for _, receipt := range receipts {
+		hashes = append(hashes, receipt.Hash())
+	}
+	return NewMerkleTree(hashes).Root()
+}
+
+func newReceipt(tx *Transaction, block *Block, index int, events []Event, err error) *Receipt {
+	receipt := &Receipt{
+		TxID:        tx.ID,
+		Status:      ReceiptSuccess,
+		BlockHeight: block.Header.Index,
+		BlockHash:   block.Hash,
+		Index:       index,
+		Events:      events,
+	}
+	if receipt.Events == nil {
+		receipt.Events = []Event{}
+	}
+	if err != nil {
+		receipt.Status = ReceiptFailed
+		receipt.Error = err.Error()
+		receipt.Events = []Event{}
+	}
+	return receipt
+}
+
+// putReceipt adds a receipt to a block's write batch
+func putReceipt(batch *leveldb.Batch, receipt *Receipt) error {
+	data, err := json.Marshal(receipt)
+	if err != nil {
+		return err
+	}
+	batch.Put([]byte("receipt:"+receipt.TxID), data)
+	return nil
+}
+
+// GetReceipt loads the receipt for a transaction
+func (bc *Blockchain) GetReceipt(txID string) (*Receipt, error) {
+	data, err := bc.DB.Get([]byte("receipt:"+txID), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrReceiptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var receipt Receipt
+	if err := json.Unmarshal(data, &receipt); err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// IsPending reports whether a transaction is waiting in the pending pool
+func (bc *Blockchain) IsPending(txID string) bool {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.txPool.Has(txID)
+}
@@ -0,0 +1,64 @@
+package chain
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Records are the state of the chain's native modules, such as escrows and
+// DEX pools: JSON values stored under their state tree key. They are committed
+// in the state root and journaled like balances, and are rebuilt by replaying
+// the chain rather than stored on their own.
+
+// getRecord decodes the record at key into v and reports whether it exists.
+// Caller must hold bc.mu.
+func (bc *Blockchain) getRecord(key string, v interface{}) bool {
+	value, exists := bc.records[key]
+	if !exists {
+		return false
+	}
+	return json.Unmarshal(value, v) == nil
+}
+
+// putRecord stores v as the record at key. Caller must hold bc.mu.
+func (bc *Blockchain) putRecord(key string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	bc.setRecord(key, value)
+	return nil
+}
+
+// setRecord sets the record at key, or removes it if value is nil. Caller must hold bc.mu.
+func (bc *Blockchain) setRecord(key string, value []byte) {
+	if bc.undo != nil {
+		bc.undo.recordChanged(key, bc.records[key])
+	}
+	bc.restoreRecord(key, value)
+}
+
+// restoreRecord sets the record at key without journaling. Caller must hold bc.mu.
+func (bc *Blockchain) restoreRecord(key string, value []byte) {
+	if value == nil {
+		delete(bc.records, key)
+		return
+	}
+	if bc.records == nil {
+		bc.records = make(map[string][]byte)
+	}
+	bc.records[key] = value
+}
+
+// recordKeys returns the keys of every record under prefix in order. Caller must hold bc.mu.
+func (bc *Blockchain) recordKeys(prefix string) []string {
+	var keys []string
+	for key := range bc.records {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,131 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+)
+
+// SignatureLength is the size of a compact recoverable secp256k1 signature:
+// one recovery byte followed by 32-byte R and S values
+const SignatureLength = 65
+
+var (
+	ErrMissingSignature = errors.New("transaction is not signed")
+	ErrInvalidSignature = errors.New("invalid transaction signature")
+	ErrSenderMismatch   = errors.New("transaction sender does not match signing key")
+	ErrProposerMismatch = errors.New("block proposer does not match signing key")
+)
+
+// PublicKeyToAddress returns the account address of a public key: the hex of
+// its compressed encoding
+func PublicKeyToAddress(pub *btcec.PublicKey) string {
+	return hex.EncodeToString(pub.SerializeCompressed())
+}
+
+// signingHash returns the 32-byte digest that is signed, which is the raw form of the ID
+func (tx *Transaction) signingHash() []byte {
+	hash, _ := hex.DecodeString(tx.CalculateHash())
+	return hash
+}
+
+// Sign sets From to the address of privateKey and signs the transaction
+func (tx *Transaction) Sign(privateKey *btcec.PrivateKey) error {
+	if privateKey == nil {
+		return errors.New("missing private key")
+	}
+
+	tx.From = PublicKeyToAddress(privateKey.PubKey())
+	tx.PublicKey = nil // the key is recovered from the signature
+	tx.ID = tx.CalculateHash()
+	tx.Signature = ecdsa.SignCompact(privateKey, tx.signingHash(), true)
+	return nil
+}
+
+// Sender recovers the address that signed the transaction
+func (tx *Transaction) Sender() (string, error) {
+	if len(tx.Signature) == 0 {
+		return "", ErrMissingSignature
+	}
+	if len(tx.Signature) != SignatureLength {
+		return "", fmt.Errorf("%w: expected %d bytes, got %d", ErrInvalidSignature, SignatureLength, len(tx.Signature))
+	}
+
+	pub, compressed, err := ecdsa.RecoverCompact(tx.Signature, tx.signingHash())
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if !compressed {
+		return "", fmt.Errorf("%w: signature must use a compressed key", ErrInvalidSignature)
+	}
+	if len(tx.PublicKey) > 0 && !bytes.Equal(tx.PublicKey, pub.SerializeCompressed()) {
+		return "", fmt.Errorf("%w: attached public key is not the signing key", ErrInvalidSignature)
+	}
+	return PublicKeyToAddress(pub), nil
+}
+
+// VerifySignature checks that the transaction was signed by the key behind From
+func (tx *Transaction) VerifySignature() error {
+	if tx.ID != tx.CalculateHash() {
+		return fmt.Errorf("%w: ID does not match contents", ErrInvalidSignature)
+	}
+	sender, err := tx.Sender()
+	if err != nil {
+		return err
+	}
+	if sender != tx.From {
+		return fmt.Errorf("%w: signed by %s, from %s", ErrSenderMismatch, sender, tx.From)
+	}
+	return nil
+}
+
+// Sign signs the block hash with the proposer's key. The key must belong to
+// the validator named in the header.
+func (b *Block) Sign(privateKey *btcec.PrivateKey) error {
+	if privateKey == nil {
+		return errors.New("missing private key")
+	}
+	if signer := PublicKeyToAddress(privateKey.PubKey()); signer != b.Header.Validator {
+		return fmt.Errorf("%w: key is for %s, proposer is %s", ErrProposerMismatch, signer, b.Header.Validator)
+	}
+
+	b.Hash = b.CalculateHash()
+	hash, _ := hex.DecodeString(b.Hash)
+	b.Signature = ecdsa.SignCompact(privateKey, hash, true)
+	return nil
+}
+
+// SignedHeader returns the header, hash and proposer signature of the block
+func (b *Block) SignedHeader() *SignedHeader {
+	return &SignedHeader{Header: b.Header, Hash: b.Hash, Signature: b.Signature}
+}
+
+// VerifyProposer checks that the header hashes to Hash and was signed by its validator
+func (h *SignedHeader) VerifyProposer() error {
+	if h.Header.Hash() != h.Hash {
+		return ErrBadBlockHash
+	}
+	if len(h.Signature) == 0 {
+		return fmt.Errorf("%w: block is not signed", ErrBadProposerSignature)
+	}
+	if len(h.Signature) != SignatureLength {
+		return fmt.Errorf("%w: expected %d bytes, got %d", ErrBadProposerSignature, SignatureLength, len(h.Signature))
+	}
+
+	hash, _ := hex.DecodeString(h.Hash)
+	pub, compressed, err := ecdsa.RecoverCompact(h.Signature, hash)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadProposerSignature, err)
+	}
+	if !compressed {
+		return fmt.Errorf("%w: signature must use a compressed key", ErrBadProposerSignature)
+	}
+	if signer := PublicKeyToAddress(pub); signer != h.Header.Validator {
+		return fmt.Errorf("%w: signed by %s, proposer is %s", ErrBadProposerSignature, signer, h.Header.Validator)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func signedTransfer(t *testing.T) (*Transaction, *btcec.PrivateKey) {
+	key, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+
+	tx := NewTransaction(TokenTransfer, "", "0xBob", uint256.NewInt(10), nil)
+	assert.Nil(t, tx.Sign(key))
+	return tx, key
+}
+
+func TestSignAndVerify(t *testing.T) {
+	tx, key := signedTransfer(t)
+
+	assert.Len(t, tx.Signature, SignatureLength)
+	assert.Equal(t, PublicKeyToAddress(key.PubKey()), tx.From)
+	assert.Nil(t, tx.VerifySignature())
+	assert.True(t, tx.Verify())
+
+	sender, err := tx.Sender()
+	assert.Nil(t, err)
+	assert.Equal(t, tx.From, sender)
+}
+
+func TestVerifySignatureRejects(t *testing.T) {
+	t.Run("Unsigned", func(t *testing.T) {
+		tx := NewTransaction(TokenTransfer, "0xAlice", "0xBob", uint256.NewInt(10), nil)
+		assert.ErrorIs(t, tx.VerifySignature(), ErrMissingSignature)
+		assert.False(t, tx.Verify())
+	})
+
+	t.Run("Tampered amount", func(t *testing.T) {
+		tx, _ := signedTransfer(t)
+		tx.Amount = uint256.NewInt(1000)
+		tx.ID = tx.CalculateHash()
+		assert.ErrorIs(t, tx.VerifySignature(), ErrSenderMismatch)
+	})
+
+	t.Run("Forged sender", func(t *testing.T) {
+		tx, _ := signedTransfer(t)
+		other, _ := signedTransfer(t)
+		tx.From = other.From
+		tx.ID = tx.CalculateHash()
+		assert.ErrorIs(t, tx.VerifySignature(), ErrSenderMismatch)
+	})
+
+	t.Run("Wrong length", func(t *testing.T) {
+		tx, _ := signedTransfer(t)
+		tx.Signature = tx.Signature[:64]
+		assert.ErrorIs(t, tx.VerifySignature(), ErrInvalidSignature)
+	})
+
+	t.Run("Attached key is not the signer", func(t *testing.T) {
+		tx, _ := signedTransfer(t)
+		other, _ := btcec.NewPrivateKey()
+		tx.PublicKey = other.PubKey().SerializeCompressed()
+		tx.ID = tx.CalculateHash()
+		assert.ErrorIs(t, tx.VerifySignature(), ErrInvalidSignature)
+	})
+
+	t.Run("System transactions are unsigned", func(t *testing.T) {
+		tx := NewTransaction(TokenTransfer, "system", "0xBob", uint256.NewInt(10), nil)
+		assert.True(t, tx.Verify())
+	})
+}
+
+func TestBlockSignature(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+	proposer := PublicKeyToAddress(key.PubKey())
+
+	block := NewBlock(1, nil, "aa", proposer, uint256.NewInt(1000), "bb", "cc")
+	assert.Nil(t, block.Sign(key))
+	assert.Nil(t, block.SignedHeader().VerifyProposer())
+	assert.Nil(t, block.ValidateStructure())
+
+	t.Run("Key must belong to the proposer", func(t *testing.T) {
+		other := NewBlock(1, nil, "aa", "node1", uint256.NewInt(1000), "bb", "cc")
+		assert.ErrorIs(t, other.Sign(key), ErrProposerMismatch)
+	})
+
+	t.Run("Unsigned header", func(t *testing.T) {
+		unsigned := NewBlock(1, nil, "aa", proposer, uint256.NewInt(1000), "bb", "cc")
+		assert.ErrorIs(t, unsigned.SignedHeader().VerifyProposer(), ErrBadProposerSignature)
+		assert.ErrorIs(t, unsigned.ValidateStructure(), ErrBadProposerSignature)
+	})
+
+	t.Run("Signature does not carry over to another header", func(t *testing.T) {
+		forged := NewBlock(2, nil, block.Hash, proposer, uint256.NewInt(1000), "bb", "cc")
+		forged.Signature = block.Signature
+		assert.ErrorIs(t, forged.SignedHeader().VerifyProposer(), ErrBadProposerSignature)
+		assert.ErrorIs(t, forged.ValidateStructure(), ErrBadProposerSignature)
+	})
+}
+
+func TestMinedBlocksAreSigned(t *testing.T) {
+	bc, _ := newTestChain(t)
+	assert.Nil(t, bc.MineBlock(node1), "a node without a proposer key does not mine")
+
+	bc.SetProposerKey(testValidatorKeys[node1])
+	assert.Nil(t, bc.MineBlock(node2), "nor does it mine for other validators")
+
+	block := bc.MineBlock(node1)
+	assert.NotNil(t, block)
+	assert.Nil(t, block.SignedHeader().VerifyProposer())
+	assert.True(t, bc.AddBlock(block))
+}
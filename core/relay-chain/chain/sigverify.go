@@ -0,0 +1,127 @@
+package chain
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultSignatureCacheSize is how many verified transactions a node remembers
+const DefaultSignatureCacheSize = 100000
+
+// SignatureCache remembers transactions whose signatures have already been
+// verified, so a transaction checked on entry to the pool is not checked again
+// when a block including it is imported. Entries are keyed by ID and only match
+// the exact signature that was verified. A nil cache verifies every time.
+type SignatureCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte // tx ID -> verified signature
+	order   []string
+	size    int
+}
+
+func NewSignatureCache(size int) *SignatureCache {
+	return &SignatureCache{
+		entries: make(map[string][]byte),
+		size:    size,
+	}
+}
+
+// Verify checks the signature of tx unless the same ID and signature were verified before
+func (c *SignatureCache) Verify(tx *Transaction) error {
+	if c == nil {
+		return tx.VerifySignature()
+	}
+
+	// The ID is cheap to recompute and ties the cached signature to these contents
+	if c.contains(tx) && tx.ID == tx.CalculateHash() {
+		return nil
+	}
+	if err := tx.VerifySignature(); err != nil {
+		return err
+	}
+	c.add(tx)
+	return nil
+}
+
+// Len returns the number of cached transactions
+func (c *SignatureCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *SignatureCache) contains(tx *Transaction) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sig, exists := c.entries[tx.ID]
+	return exists && bytes.Equal(sig, tx.Signature)
+}
+
+func (c *SignatureCache) add(tx *Transaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[tx.ID]; !exists {
+		c.order = append(c.order, tx.ID)
+	}
+	c.entries[tx.ID] = tx.Signature
+
+	for len(c.order) > c.size {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// VerifySignatures checks the signatures of all non-system transactions in txs
+// on at most workers goroutines and returns the error of the first invalid one.
+// Verified transactions are added to cache.
+func VerifySignatures(cache *SignatureCache, txs []*Transaction, workers int) error {
+	pending := make([]*Transaction, 0, len(txs))
+	for _, tx := range txs {
+		if !isSystemSender(tx.From) {
+			pending = append(pending, tx)
+		}
+	}
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+
+	errs := make([]error, len(pending))
+	var next atomic.Int64
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Stop taking work once any signature has failed
+			for !failed.Load() {
+				i := int(next.Add(1) - 1)
+				if i >= len(pending) {
+					return
+				}
+				if errs[i] = cache.Verify(pending[i]); errs[i] != nil {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("transaction %s: %w", pending[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// verifyBlockSignatures checks every signature in block using one worker per CPU.
+// It needs no lock, so blocks can be verified before bc.mu is taken to apply them.
+func (bc *Blockchain) verifyBlockSignatures(block *Block) error {
+	return VerifySignatures(bc.sigCache, block.Transactions, runtime.GOMAXPROCS(0))
+}
@@ -0,0 +1,109 @@
+package chain
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func signedTransfers(t testing.TB, n int) []*Transaction {
+	key, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+
+	txs := make([]*Transaction, n)
+	for i := range txs {
+		txs[i] = NewTransaction(TokenTransfer, "", "0xBob", uint256.NewInt(10), nil)
+		txs[i].Nonce = uint64(i)
+		assert.Nil(t, txs[i].Sign(key))
+	}
+	return txs
+}
+
+func TestSignatureCache(t *testing.T) {
+	cache := NewSignatureCache(2)
+	txs := signedTransfers(t, 3)
+
+	assert.Nil(t, cache.Verify(txs[0]))
+	assert.Equal(t, 1, cache.Len())
+
+	t.Run("Cached ID with another signature is verified again", func(t *testing.T) {
+		forged := *txs[0]
+		forged.Signature = txs[1].Signature
+		assert.NotNil(t, cache.Verify(&forged))
+	})
+
+	t.Run("Cached signature with edited contents is rejected", func(t *testing.T) {
+		edited := *txs[0]
+		edited.Amount = uint256.NewInt(1000)
+		assert.NotNil(t, cache.Verify(&edited))
+	})
+
+	t.Run("Oldest entries are evicted", func(t *testing.T) {
+		assert.Nil(t, cache.Verify(txs[1]))
+		assert.Nil(t, cache.Verify(txs[2]))
+		assert.Equal(t, 2, cache.Len())
+		assert.False(t, cache.contains(txs[0]))
+	})
+
+	t.Run("Nil cache verifies directly", func(t *testing.T) {
+		var none *SignatureCache
+		assert.Nil(t, none.Verify(txs[0]))
+	})
+}
+
+func TestVerifySignatures(t *testing.T) {
+	txs := signedTransfers(t, 50)
+	reward := NewTransaction(TokenTransfer, "system", "node1", uint256.NewInt(10), nil)
+	all := append([]*Transaction{reward}, txs...)
+
+	cache := NewSignatureCache(DefaultSignatureCacheSize)
+	assert.Nil(t, VerifySignatures(cache, all, 4))
+	assert.Equal(t, len(txs), cache.Len())
+
+	bad := *txs[30]
+	bad.To = "0xMallory"
+	bad.ID = bad.CalculateHash()
+	all[31] = &bad
+	err := VerifySignatures(NewSignatureCache(DefaultSignatureCacheSize), all, 4)
+	assert.ErrorIs(t, err, ErrSenderMismatch)
+	assert.Contains(t, err.Error(), bad.ID)
+}
+
+// BenchmarkBlockSignatures measures how many blocks of signed transactions can be
+// verified per second one at a time, on a worker pool, and from a warm cache
+func BenchmarkBlockSignatures(b *testing.B) {
+	const txsPerBlock = 200
+	blocks := make([][]*Transaction, 16)
+	for i := range blocks {
+		blocks[i] = signedTransfers(b, txsPerBlock)
+	}
+
+	run := func(b *testing.B, workers int, cache func() *SignatureCache) {
+		n := 0
+		for b.Loop() {
+			if err := VerifySignatures(cache(), blocks[n%len(blocks)], workers); err != nil {
+				b.Fatal(err)
+			}
+			n++
+		}
+		b.ReportMetric(float64(n)/b.Elapsed().Seconds(), "blocks/s")
+	}
+	fresh := func() *SignatureCache { return nil }
+
+	b.Run("Sequential", func(b *testing.B) { run(b, 1, fresh) })
+	b.Run(fmt.Sprintf("Parallel-%d", runtime.GOMAXPROCS(0)), func(b *testing.B) {
+		run(b, runtime.GOMAXPROCS(0), fresh)
+	})
+
+	warm := NewSignatureCache(DefaultSignatureCacheSize)
+	for _, txs := range blocks {
+		assert.Nil(b, VerifySignatures(warm, txs, runtime.GOMAXPROCS(0)))
+	}
+	b.Run("Cached", func(b *testing.B) {
+		run(b, runtime.GOMAXPROCS(0), func() *SignatureCache { return warm })
+	})
+}
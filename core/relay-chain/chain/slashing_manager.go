@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
 // SlashingCondition represents different types of validator violations
@@ -26,7 +27,7 @@ type SlashingEvent struct {
 	Validator   string            `json:"validator"`
 	Condition   SlashingCondition `json:"condition"`
 	Severity    SlashingSeverity  `json:"severity"`
-	Amount      uint64            `json:"amount"`
+	Amount      uint256.Int       `json:"amount"`
 	Evidence    string            `json:"evidence"`
 	Timestamp   int64             `json:"timestamp"`
 	BlockHeight uint64            `json:"block_height"`
@@ -84,11 +85,16 @@ func (sm *SlashingManager) ReportViolation(validator string, condition SlashingC
 
 	// Calculate slashing amount
 	validatorStake := sm.StakeLedger.GetStake(validator)
-	if validatorStake == 0 {
+	if validatorStake.IsZero() {
 		return nil, fmt.Errorf("validator %s has no stake to slash", validator)
 	}
 
-	slashAmount := uint64(float64(validatorStake) * sm.SlashingRates[severity])
+	// The rate is applied in basis points so the amount is computed exactly
+	rate := uint256.NewInt(uint64(sm.SlashingRates[severity] * 10000))
+	slashAmount, err := validatorStake.MulDiv(rate, uint256.NewInt(10000))
+	if err != nil || slashAmount.Gt(validatorStake) {
+		slashAmount = validatorStake
+	}
 
 	// Create slashing event
 	event := &SlashingEvent{
@@ -105,13 +111,16 @@ func (sm *SlashingManager) ReportViolation(validator string, condition SlashingC
 
 	sm.Events[eventID] = event
 
-	fmt.Printf("🚨 Slashing violation reported: %s for %s (Severity: %v, Amount: %d)\n",
+	fmt.Printf("🚨 Slashing violation reported: %s for %s (Severity: %v, Amount: %s)\n",
 		sm.getConditionName(condition), validator, severity, slashAmount)
 
 	return event, nil
 }
 
-// ExecuteSlashing executes a pending slashing event
+// ExecuteSlashing executes a pending slashing event. Stakes and balances are
+// committed in the state root and change only through blocks, so executing an
+// event records the penalty and a strike against the validator on this node
+// without moving its stake or burning any BHX.
 func (sm *SlashingManager) ExecuteSlashing(eventID string) error {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
@@ -127,47 +136,24 @@ func (sm *SlashingManager) ExecuteSlashing(eventID string) error {
 
 	// Get validator's current stake
 	currentStake := sm.StakeLedger.GetStake(event.Validator)
-	if currentStake == 0 {
+	if currentStake.IsZero() {
 		fmt.Printf("⚠️ Validator %s already has zero stake, skipping slashing\n", event.Validator)
 		event.Status = "skipped"
 		return nil
 	}
 
-	if currentStake < event.Amount {
+	if currentStake.Lt(event.Amount) {
 		// Slash all remaining stake if insufficient
 		event.Amount = currentStake
 	}
 
-	// SAFETY CHECK: Prevent slashing if it would leave no active validators
-	activeValidators := sm.countActiveValidators()
-	if activeValidators <= 1 && event.Amount >= currentStake {
-		fmt.Printf("🛡️ SAFETY: Preventing slashing that would jail last validator %s\n", event.Validator)
-		event.Status = "blocked_safety"
-		return fmt.Errorf("cannot jail last active validator - network safety protection")
-	}
-
-	// Execute the slashing
-	newStake := currentStake - event.Amount
-	sm.StakeLedger.SetStake(event.Validator, newStake)
-
-	// Burn the slashed tokens (remove from circulation)
-	if bhxToken, exists := sm.TokenRegistry["BHX"]; exists {
-		// Transfer slashed tokens from staking contract to burn address
-		err := bhxToken.Transfer("staking_contract", "burn_address", event.Amount)
-		if err != nil {
-			fmt.Printf("⚠️ Failed to burn slashed tokens: %v\n", err)
-		} else {
-			fmt.Printf("🔥 Burned %d BHX tokens from slashing\n", event.Amount)
-		}
-	}
-
 	// Update validator strikes
 	sm.ValidatorStrike[event.Validator]++
 
 	// Check if validator should be jailed (3 strikes rule)
 	if sm.ValidatorStrike[event.Validator] >= 3 {
-		// Additional safety check before jailing
-		if activeValidators > 1 {
+		// SAFETY: never jail the last active validator
+		if sm.countActiveValidators() > 1 {
 			sm.jailValidator(event.Validator)
 		} else {
 			fmt.Printf("🛡️ SAFETY: Not jailing last validator %s despite 3 strikes\n", event.Validator)
@@ -177,8 +163,8 @@ func (sm *SlashingManager) ExecuteSlashing(eventID string) error {
 	// Update event status
 	event.Status = "executed"
 
-	fmt.Printf("⚡ Slashing executed: %d stake removed from %s (New stake: %d)\n",
-		event.Amount, event.Validator, newStake)
+	fmt.Printf("⚡ Slashing recorded: %s of %s's stake (stake %s is unchanged until a block changes it)\n",
+		event.Amount, event.Validator, currentStake)
 
 	return nil
 }
@@ -235,13 +221,11 @@ func (sm *SlashingManager) determineSeverity(validator string, condition Slashin
 	}
 }
 
-// jailValidator removes a validator from active set
+// jailValidator marks a validator as jailed on this node. Its stake is left
+// alone, since stakes change only through blocks. Caller must hold sm.mu.
 func (sm *SlashingManager) jailValidator(validator string) {
 	fmt.Printf("🔒 Validator %s has been jailed (3+ strikes)\n", validator)
 
-	// Set stake to 0 to remove from validator set
-	sm.StakeLedger.SetStake(validator, 0)
-
 	// Mark as jailed (could implement unjailing mechanism later)
 	sm.ValidatorStrike[validator] = -1 // Special value for jailed
 }
@@ -272,14 +256,15 @@ func (sm *SlashingManager) IsValidatorJailed(validator string) bool {
 	return sm.ValidatorStrike[validator] == -1
 }
 
-// countActiveValidators counts validators with stake > 0 and not jailed
+// countActiveValidators counts validators with stake > 0 and not jailed.
+// Caller must hold sm.mu.
 func (sm *SlashingManager) countActiveValidators() int {
 	activeCount := 0
 	allStakes := sm.StakeLedger.GetAllStakes()
 
 	for validator, stake := range allStakes {
 		// Count as active if has stake and not jailed
-		if stake > 0 && !sm.IsValidatorJailed(validator) {
+		if !stake.IsZero() && sm.ValidatorStrike[validator] != -1 {
 			activeCount++
 		}
 	}
@@ -1,71 +1,140 @@
 package chain
 
 import (
-	"errors"
 	"fmt"
 	"sync"
 	"time"
 	
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
 type StakeLedger struct {
-	Stakes map[string]uint64
+	Stakes map[string]uint256.Int
 	mu     sync.RWMutex
+
+	// journal is told a stake's previous value before it changes, while a block is applied
+	journal func(address string, previous uint256.Int, existed bool)
+
+	clock   *token.Clock  // height stake changes are checkpointed at
+	history token.History // stakes before each block changed them
 }
 
 func NewStakeLedger() *StakeLedger {
 	sl := &StakeLedger{
-		Stakes: make(map[string]uint64),
+		Stakes: make(map[string]uint256.Int),
 	}
-	sl.InitializeDefaultStakes()
 	return sl
 }
 
-func (sl *StakeLedger) ToMap() map[string]uint64 {
+func (sl *StakeLedger) ToMap() map[string]uint256.Int {
 	sl.mu.RLock()
 	defer sl.mu.RUnlock()
-	stakes := make(map[string]uint64)
+	stakes := make(map[string]uint256.Int)
 	for addr, stake := range sl.Stakes {
 		stakes[addr] = stake
 	}
 	return stakes
 }
 
-func (sl *StakeLedger) GetStake(address string) uint64 {
+func (sl *StakeLedger) GetStake(address string) uint256.Int {
 	sl.mu.RLock()
 	defer sl.mu.RUnlock()
 	return sl.Stakes[address]
 }
 
-func (sl *StakeLedger) SetStake(address string, stake uint64) {
+func (sl *StakeLedger) SetStake(address string, stake uint256.Int) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.record(address)
+	sl.Stakes[address] = stake
+}
+
+func (sl *StakeLedger) AddStake(address string, amount uint256.Int) error {
 	sl.mu.Lock()
 	defer sl.mu.Unlock()
+	stake, err := sl.Stakes[address].Add(amount)
+	if err != nil {
+		return fmt.Errorf("stake of %s overflows: %w", address, err)
+	}
+	sl.record(address)
 	sl.Stakes[address] = stake
+	return nil
+}
+
+// setJournal reports every later stake change to fn. A nil fn stops reporting.
+func (sl *StakeLedger) setJournal(fn func(address string, previous uint256.Int, existed bool)) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.journal = fn
+}
+
+// record reports the stake of address before it changes and checkpoints it
+// into the history. Caller must hold sl.mu.
+func (sl *StakeLedger) record(address string) {
+	previous, existed := sl.Stakes[address]
+	height, _ := sl.clock.Now()
+	sl.history.Record(address, height, previous)
+	if sl.journal != nil {
+		sl.journal(address, previous, existed)
+	}
 }
 
-func (sl *StakeLedger) AddStake(address string, amount uint64) {
+// restoreStake puts back a journaled stake, removing the entry if it did not exist
+func (sl *StakeLedger) restoreStake(address string, previous uint256.Int, existed bool) {
 	sl.mu.Lock()
 	defer sl.mu.Unlock()
-	sl.Stakes[address] += amount
+	if !existed {
+		delete(sl.Stakes, address)
+		return
+	}
+	sl.Stakes[address] = previous
 }
 
-func (sl *StakeLedger) GetAllStakes() map[string]uint64 {
+// StakeAt returns the stake address held at the end of the block at height
+func (sl *StakeLedger) StakeAt(address string, height uint64) (uint256.Int, error) {
 	sl.mu.RLock()
 	defer sl.mu.RUnlock()
-	stakes := make(map[string]uint64)
-	for addr, stake := range sl.Stakes {
-		stakes[addr] = stake
+
+	tip, _ := sl.clock.Now()
+	switch {
+	case height > tip:
+		return uint256.Int{}, fmt.Errorf("%w: %d > %d", token.ErrFutureHeight, height, tip)
+	case height == tip:
+		return sl.Stakes[address], nil
 	}
-	return stakes
+	return sl.history.At(address, height, sl.Stakes[address])
+}
+
+// setClock checkpoints later stake changes at c's height
+func (sl *StakeLedger) setClock(c *token.Clock) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.clock = c
+}
+
+// rewindHistory drops stake history above height
+func (sl *StakeLedger) rewindHistory(height uint64) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.history.Rewind(height)
 }
 
-func (sl *StakeLedger) InitializeDefaultStakes() {
+// pruneHistory forgets stakes at heights below before
+func (sl *StakeLedger) pruneHistory(before uint64) {
 	sl.mu.Lock()
 	defer sl.mu.Unlock()
-	// Set default stakes for testing
-	sl.Stakes["node1"] = 1000 // First node (port 3000)
-	sl.Stakes["node2"] = 500  // Second node (port 3001)
+	sl.history.Prune(before)
+}
+
+func (sl *StakeLedger) GetAllStakes() map[string]uint256.Int {
+	sl.mu.RLock()
+	defer sl.mu.RUnlock()
+	stakes := make(map[string]uint256.Int)
+	for addr, stake := range sl.Stakes {
+		stakes[addr] = stake
+	}
+	return stakes
 }
 
 func (sl *StakeLedger) IsSelectedValidator(address string) bool {
@@ -74,17 +143,17 @@ func (sl *StakeLedger) IsSelectedValidator(address string) bool {
 
 	// Simple PoS: Select validator with highest stake
 	// Deterministic based on current time slot for coordination
-	maxStake := uint64(0)
+	var maxStake uint256.Int
 	maxAddr := ""
 	for addr, stake := range sl.Stakes {
-		if stake > maxStake {
+		if stake.Gt(maxStake) {
 			maxStake = stake
 			maxAddr = addr
 		}
 	}
 
 	// Ensure the address has stake and is the highest
-	if maxStake == 0 || address != maxAddr {
+	if maxStake.IsZero() || address != maxAddr {
 		return false
 	}
 
@@ -100,35 +169,13 @@ func (sl *StakeLedger) GetHighestStakeValidator() string {
 	sl.mu.RLock()
 	defer sl.mu.RUnlock()
 
-	maxStake := uint64(0)
+	var maxStake uint256.Int
 	maxAddr := ""
 	for addr, stake := range sl.Stakes {
-		if stake > maxStake {
+		if stake.Gt(maxStake) {
 			maxStake = stake
 			maxAddr = addr
 		}
 	}
 	return maxAddr
 }
-
-// Integrate with token system
-func (sl *StakeLedger) StakeTokens(address string, amount uint64, tokenSystem *token.Token) error {
-	// Verify token balance
-	balance, err := tokenSystem.BalanceOf(address)
-	if err != nil {
-		return err
-	}
-	if balance < amount {
-		return errors.New("insufficient token balance for staking")
-	}
-	
-	// Lock tokens (could implement as internal transfer to staking contract address)
-	err = tokenSystem.Transfer(address, "staking_contract", amount)
-	if err != nil {
-		return err
-	}
-	
-	// Add stake
-	sl.AddStake(address, amount)
-	return nil
-}
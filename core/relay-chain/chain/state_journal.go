@@ -0,0 +1,360 @@
+package chain
+
+import (
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+// The state journal records what a block overwrote while it is applied, so the
+// block can later be disconnected during a reorg. Only the first value seen for
+// each key is kept, which is the value from before the block.
+
+type tokenBalanceKey struct {
+	symbol, address string
+}
+
+type allowanceKey struct {
+	symbol, owner, spender string
+}
+
+type vestingKey struct {
+	symbol, address string
+}
+
+type itemKey struct {
+	symbol, id string
+}
+
+type itemBalanceKey struct {
+	symbol, id, address string
+}
+
+type operatorKey struct {
+	symbol, owner, operator string
+}
+
+type storageKey struct {
+	contract, key string
+}
+
+type stakeEntry struct {
+	amount  uint256.Int
+	existed bool
+}
+
+// blockUndo is the undo log of one block
+type blockUndo struct {
+	accounts      map[string]*AccountState // nil when the account did not exist
+	balances      map[tokenBalanceKey]uint256.Int
+	supplies      map[string]uint256.Int
+	allowances    map[allowanceKey]uint256.Int
+	controls      map[string]*token.Controls
+	vesting       map[vestingKey][]token.VestingSchedule
+	stakes        map[string]stakeEntry
+	items         map[itemKey]*token.Item // nil when the item did not exist
+	itemBalances  map[itemBalanceKey]uint256.Int
+	operators     map[operatorKey]bool
+	storage       map[storageKey][]byte // nil when the key was not set
+	records       map[string][]byte     // nil when the record did not exist
+	createdTokens []string              // symbols of tokens the block created
+	// symbols of collections the block created
+	createdCollections []string
+	createdContracts   []string // addresses of contracts the block deployed
+	storedCode         []string // hashes of contract code the block stored first
+	receiptsRoot       string
+}
+
+func newBlockUndo(receiptsRoot string) *blockUndo {
+	return &blockUndo{
+		accounts:     make(map[string]*AccountState),
+		balances:     make(map[tokenBalanceKey]uint256.Int),
+		supplies:     make(map[string]uint256.Int),
+		allowances:   make(map[allowanceKey]uint256.Int),
+		controls:     make(map[string]*token.Controls),
+		vesting:      make(map[vestingKey][]token.VestingSchedule),
+		stakes:       make(map[string]stakeEntry),
+		items:        make(map[itemKey]*token.Item),
+		itemBalances: make(map[itemBalanceKey]uint256.Int),
+		operators:    make(map[operatorKey]bool),
+		storage:      make(map[storageKey][]byte),
+		records:      make(map[string][]byte),
+		receiptsRoot: receiptsRoot,
+	}
+}
+
+// recordAccount keeps a copy of the account before the block first touches it
+func (u *blockUndo) recordAccount(address string, state *AccountState) {
+	if _, seen := u.accounts[address]; seen {
+		return
+	}
+	if state == nil {
+		u.accounts[address] = nil
+		return
+	}
+	previous := *state
+	u.accounts[address] = &previous
+}
+
+func (u *blockUndo) BalanceChanged(symbol, address string, previous uint256.Int) {
+	key := tokenBalanceKey{symbol, address}
+	if _, seen := u.balances[key]; !seen {
+		u.balances[key] = previous
+	}
+}
+
+func (u *blockUndo) SupplyChanged(symbol string, previous uint256.Int) {
+	if _, seen := u.supplies[symbol]; !seen {
+		u.supplies[symbol] = previous
+	}
+}
+
+func (u *blockUndo) AllowanceChanged(symbol, owner, spender string, previous uint256.Int) {
+	key := allowanceKey{symbol, owner, spender}
+	if _, seen := u.allowances[key]; !seen {
+		u.allowances[key] = previous
+	}
+}
+
+func (u *blockUndo) ControlsChanged(symbol string, previous *token.Controls) {
+	if _, seen := u.controls[symbol]; !seen {
+		u.controls[symbol] = previous
+	}
+}
+
+func (u *blockUndo) VestingChanged(symbol, address string, previous []token.VestingSchedule) {
+	key := vestingKey{symbol, address}
+	if _, seen := u.vesting[key]; !seen {
+		u.vesting[key] = previous
+	}
+}
+
+func (u *blockUndo) ItemChanged(symbol, id string, previous *token.Item) {
+	key := itemKey{symbol, id}
+	if _, seen := u.items[key]; !seen {
+		u.items[key] = previous
+	}
+}
+
+func (u *blockUndo) ItemBalanceChanged(symbol, id, address string, previous uint256.Int) {
+	key := itemBalanceKey{symbol, id, address}
+	if _, seen := u.itemBalances[key]; !seen {
+		u.itemBalances[key] = previous
+	}
+}
+
+func (u *blockUndo) OperatorChanged(symbol, owner, operator string, previous bool) {
+	key := operatorKey{symbol, owner, operator}
+	if _, seen := u.operators[key]; !seen {
+		u.operators[key] = previous
+	}
+}
+
+func (u *blockUndo) storageChanged(contract, key string, previous []byte) {
+	k := storageKey{contract, key}
+	if _, seen := u.storage[k]; !seen {
+		u.storage[k] = previous
+	}
+}
+
+func (u *blockUndo) recordChanged(key string, previous []byte) {
+	if _, seen := u.records[key]; !seen {
+		u.records[key] = previous
+	}
+}
+
+func (u *blockUndo) stakeChanged(address string, previous uint256.Int, existed bool) {
+	if _, seen := u.stakes[address]; !seen {
+		u.stakes[address] = stakeEntry{previous, existed}
+	}
+}
+
+// merge adds the log of one transaction to u, keeping the values u already
+// holds since they are older
+func (u *blockUndo) merge(tx *blockUndo) {
+	for address, previous := range tx.accounts {
+		if _, seen := u.accounts[address]; !seen {
+			u.accounts[address] = previous
+		}
+	}
+	for key, previous := range tx.balances {
+		u.BalanceChanged(key.symbol, key.address, previous)
+	}
+	for symbol, previous := range tx.supplies {
+		u.SupplyChanged(symbol, previous)
+	}
+	for key, previous := range tx.allowances {
+		u.AllowanceChanged(key.symbol, key.owner, key.spender, previous)
+	}
+	for symbol, previous := range tx.controls {
+		u.ControlsChanged(symbol, previous)
+	}
+	for key, previous := range tx.vesting {
+		u.VestingChanged(key.symbol, key.address, previous)
+	}
+	for address, previous := range tx.stakes {
+		u.stakeChanged(address, previous.amount, previous.existed)
+	}
+	for key, previous := range tx.items {
+		u.ItemChanged(key.symbol, key.id, previous)
+	}
+	for key, previous := range tx.itemBalances {
+		u.ItemBalanceChanged(key.symbol, key.id, key.address, previous)
+	}
+	for key, previous := range tx.operators {
+		u.OperatorChanged(key.symbol, key.owner, key.operator, previous)
+	}
+	for key, previous := range tx.storage {
+		u.storageChanged(key.contract, key.key, previous)
+	}
+	for key, previous := range tx.records {
+		u.recordChanged(key, previous)
+	}
+	u.createdTokens = append(u.createdTokens, tx.createdTokens...)
+	u.createdCollections = append(u.createdCollections, tx.createdCollections...)
+	u.createdContracts = append(u.createdContracts, tx.createdContracts...)
+	u.storedCode = append(u.storedCode, tx.storedCode...)
+}
+
+// beginUndo starts journaling state changes into a new undo log. Caller must hold bc.mu.
+func (bc *Blockchain) beginUndo() *blockUndo {
+	undo := newBlockUndo(bc.receiptsRoot)
+	bc.journalTo(undo)
+	return undo
+}
+
+// endUndo stops journaling. Caller must hold bc.mu.
+func (bc *Blockchain) endUndo() {
+	bc.journalTo(nil)
+}
+
+// beginTxUndo journals the changes of one transaction into a log of their own
+// so they can be rolled back without the rest of the block. It returns the log
+// that was in use before. Caller must hold bc.mu.
+func (bc *Blockchain) beginTxUndo() (outer, tx *blockUndo) {
+	outer = bc.undo
+	tx = newBlockUndo(bc.receiptsRoot)
+	bc.journalTo(tx)
+	return outer, tx
+}
+
+// endTxUndo rolls back the transaction's changes if it failed, or adds them to
+// the outer log if it succeeded, and journals into the outer log again. Caller
+// must hold bc.mu.
+func (bc *Blockchain) endTxUndo(outer, tx *blockUndo, failed bool) {
+	bc.journalTo(outer)
+	if failed {
+		bc.revert(tx)
+	} else if outer != nil {
+		outer.merge(tx)
+	}
+}
+
+// applyAtomic applies tx with apply and rolls back everything it changed if it
+// fails, for transactions that make more than one change. Caller must hold bc.mu.
+func (bc *Blockchain) applyAtomic(tx *Transaction, apply func(*Transaction) ([]Event, error)) ([]Event, error) {
+	outer, undo := bc.beginTxUndo()
+	events, err := apply(tx)
+	bc.endTxUndo(outer, undo, err != nil)
+	return events, err
+}
+
+// journalTo sends state changes to u, or stops journaling if u is nil. Caller
+// must hold bc.mu.
+func (bc *Blockchain) journalTo(u *blockUndo) {
+	bc.undo = u
+	if u == nil {
+		for _, tk := range bc.TokenRegistry {
+			tk.SetJournal(nil)
+		}
+		for _, c := range bc.Collections {
+			c.SetJournal(nil)
+		}
+		bc.StakeLedger.setJournal(nil)
+		return
+	}
+	for _, tk := range bc.TokenRegistry {
+		tk.SetJournal(u)
+	}
+	for _, c := range bc.Collections {
+		c.SetJournal(u)
+	}
+	bc.StakeLedger.setJournal(u.stakeChanged)
+}
+
+// revert puts back every value recorded in u. Caller must hold bc.mu.
+func (bc *Blockchain) revert(u *blockUndo) {
+	for address, previous := range u.accounts {
+		if previous == nil {
+			delete(bc.GlobalState, address)
+		} else {
+			state := *previous
+			bc.GlobalState[address] = &state
+		}
+		bc.dirtyAccounts[address] = true
+	}
+	for key, previous := range u.balances {
+		if tk, exists := bc.TokenRegistry[key.symbol]; exists {
+			tk.RestoreBalance(key.address, previous)
+		}
+	}
+	for symbol, previous := range u.supplies {
+		if tk, exists := bc.TokenRegistry[symbol]; exists {
+			tk.RestoreSupply(previous)
+		}
+	}
+	for key, previous := range u.allowances {
+		if tk, exists := bc.TokenRegistry[key.symbol]; exists {
+			tk.RestoreAllowance(key.owner, key.spender, previous)
+		}
+	}
+	for symbol, previous := range u.controls {
+		if tk, exists := bc.TokenRegistry[symbol]; exists {
+			tk.RestoreControls(previous)
+		}
+	}
+	for key, previous := range u.vesting {
+		if tk, exists := bc.TokenRegistry[key.symbol]; exists {
+			tk.RestoreVesting(key.address, previous)
+		}
+	}
+	for address, previous := range u.stakes {
+		bc.StakeLedger.restoreStake(address, previous.amount, previous.existed)
+	}
+	for key, previous := range u.items {
+		if c, exists := bc.Collections[key.symbol]; exists {
+			c.RestoreItem(key.id, previous)
+		}
+	}
+	for key, previous := range u.itemBalances {
+		if c, exists := bc.Collections[key.symbol]; exists {
+			c.RestoreItemBalance(key.id, key.address, previous)
+		}
+	}
+	for key, previous := range u.operators {
+		if c, exists := bc.Collections[key.symbol]; exists {
+			c.RestoreOperator(key.owner, key.operator, previous)
+		}
+	}
+	for key, previous := range u.storage {
+		if c, exists := bc.Contracts[key.contract]; exists {
+			c.put(key.key, previous)
+		}
+	}
+	for key, previous := range u.records {
+		bc.restoreRecord(key, previous)
+	}
+	for _, symbol := range u.createdTokens {
+		delete(bc.TokenRegistry, symbol)
+	}
+	for _, symbol := range u.createdCollections {
+		delete(bc.Collections, symbol)
+	}
+	for _, address := range u.createdContracts {
+		delete(bc.Contracts, address)
+	}
+	for _, hash := range u.storedCode {
+		delete(bc.contractCode, hash)
+		delete(bc.contractModules, hash)
+	}
+	bc.receiptsRoot = u.receiptsRoot
+}
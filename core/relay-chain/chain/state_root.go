@@ -0,0 +1,380 @@
+package chain
+
+import (
+	"fmt"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+)
+
+// maxCachedStateTrees bounds how many historical state trees are kept for proofs
+const maxCachedStateTrees = 64
+
+// leafSet collects state tree keys, each with a function that reads the
+// leaf's current value from the chain. Keys are collected when they are
+// added and values when the set is read, so a set built before a block is
+// reverted still finds the leaves of tokens, collections and contracts the
+// revert removes. Caller must hold bc.mu while using it.
+type leafSet struct {
+	bc     *Blockchain
+	leaves map[string]func() []byte
+}
+
+func (bc *Blockchain) newLeafSet() *leafSet {
+	return &leafSet{bc: bc, leaves: make(map[string]func() []byte)}
+}
+
+// read returns the current value of every leaf in the set. Empty accounts and
+// zero balances are nil, so that lazily created entries do not change the root.
+func (s *leafSet) read() map[string][]byte {
+	values := make(map[string][]byte, len(s.leaves))
+	for key, read := range s.leaves {
+		values[key] = read()
+	}
+	return values
+}
+
+func (s *leafSet) account(address string) {
+	s.leaves[AccountStateKey(address)] = func() []byte {
+		state := s.bc.GlobalState[address]
+		if state == nil || (state.Balance.IsZero() && state.Nonce == 0) {
+			return nil
+		}
+		return encodeAccountLeaf(state)
+	}
+	s.leaves[PermitNonceKey(address)] = func() []byte {
+		state := s.bc.GlobalState[address]
+		if state == nil || state.PermitNonce == 0 {
+			return nil
+		}
+		return encodePermitNonceLeaf(state.PermitNonce)
+	}
+}
+
+// token adds the parameters, balances, vesting schedules and frozen or
+// blocklisted accounts of a token
+func (s *leafSet) token(symbol string) {
+	tk, exists := s.bc.TokenRegistry[symbol]
+	if !exists {
+		return
+	}
+	s.tokenInfo(symbol)
+	s.tokenControls(symbol, tk.Controls())
+	for address := range tk.Vesting() {
+		s.vesting(symbol, address)
+	}
+	for _, address := range tk.GetAllAddressesWithBalances() {
+		s.tokenBalance(symbol, address)
+	}
+}
+
+func (s *leafSet) tokenInfo(symbol string) {
+	s.leaves[TokenInfoKey(symbol)] = func() []byte {
+		tk, exists := s.bc.TokenRegistry[symbol]
+		if !exists {
+			return nil
+		}
+		return encodeTokenInfoLeaf(tk, tk.Controls())
+	}
+}
+
+func (s *leafSet) tokenBalance(symbol, address string) {
+	s.leaves[TokenBalanceKey(symbol, address)] = func() []byte {
+		tk, exists := s.bc.TokenRegistry[symbol]
+		if !exists {
+			return nil
+		}
+		balance, err := tk.BalanceOf(address)
+		if err != nil || balance.IsZero() {
+			return nil
+		}
+		return encodeBalanceLeaf(balance)
+	}
+}
+
+// tokenControls adds the control leaf of every account frozen or blocklisted in controls
+func (s *leafSet) tokenControls(symbol string, controls *token.Controls) {
+	for address := range controls.Frozen {
+		s.tokenControl(symbol, address)
+	}
+	for address := range controls.Blocklisted {
+		s.tokenControl(symbol, address)
+	}
+}
+
+func (s *leafSet) tokenControl(symbol, address string) {
+	s.leaves[TokenControlKey(symbol, address)] = func() []byte {
+		tk, exists := s.bc.TokenRegistry[symbol]
+		if !exists {
+			return nil
+		}
+		var flags byte
+		if tk.IsFrozen(address) {
+			flags |= controlFrozen
+		}
+		if tk.IsBlocklisted(address) {
+			flags |= controlBlocklisted
+		}
+		if flags == 0 {
+			return nil
+		}
+		return []byte{flags}
+	}
+}
+
+func (s *leafSet) vesting(symbol, address string) {
+	s.leaves[TokenVestingKey(symbol, address)] = func() []byte {
+		tk, exists := s.bc.TokenRegistry[symbol]
+		if !exists {
+			return nil
+		}
+		schedules := tk.VestingOf(address)
+		if len(schedules) == 0 {
+			return nil
+		}
+		return encodeVestingLeaf(schedules)
+	}
+}
+
+// collection adds the parameters, items, item balances and operators of a collection
+func (s *leafSet) collection(symbol string) {
+	c, exists := s.bc.Collections[symbol]
+	if !exists {
+		return
+	}
+	s.collectionInfo(symbol)
+	ledger := c.Export()
+	for _, entry := range ledger.Items {
+		s.item(symbol, entry.ID)
+	}
+	for _, h := range ledger.Holdings {
+		s.itemBalance(symbol, h.ID, h.Address)
+	}
+	for _, op := range ledger.Operators {
+		s.operator(symbol, op.Owner, op.Operator)
+	}
+}
+
+func (s *leafSet) collectionInfo(symbol string) {
+	s.leaves[CollectionInfoKey(symbol)] = func() []byte {
+		c, exists := s.bc.Collections[symbol]
+		if !exists {
+			return nil
+		}
+		return encodeCollectionInfoLeaf(c)
+	}
+}
+
+func (s *leafSet) item(symbol, id string) {
+	s.leaves[ItemKey(symbol, id)] = func() []byte {
+		c, exists := s.bc.Collections[symbol]
+		if !exists {
+			return nil
+		}
+		item, err := c.Item(id)
+		if err != nil {
+			return nil
+		}
+		return encodeItemLeaf(item)
+	}
+}
+
+func (s *leafSet) itemBalance(symbol, id, address string) {
+	s.leaves[ItemBalanceKey(symbol, id, address)] = func() []byte {
+		c, exists := s.bc.Collections[symbol]
+		if !exists {
+			return nil
+		}
+		balance := c.BalanceOf(address, id)
+		if balance.IsZero() {
+			return nil
+		}
+		return encodeBalanceLeaf(balance)
+	}
+}
+
+func (s *leafSet) operator(symbol, owner, operator string) {
+	s.leaves[OperatorKey(symbol, owner, operator)] = func() []byte {
+		c, exists := s.bc.Collections[symbol]
+		if !exists || !c.IsOperator(owner, operator) {
+			return nil
+		}
+		return []byte{1}
+	}
+}
+
+// contract adds a contract and every key in its storage
+func (s *leafSet) contract(address string) {
+	c, exists := s.bc.Contracts[address]
+	if !exists {
+		return
+	}
+	s.leaves[ContractKey(address)] = func() []byte {
+		c, exists := s.bc.Contracts[address]
+		if !exists {
+			return nil
+		}
+		return encodeContractLeaf(c)
+	}
+	for key := range c.storage {
+		s.storage(address, key)
+	}
+}
+
+func (s *leafSet) storage(address, key string) {
+	s.leaves[ContractStorageKey(address, key)] = func() []byte {
+		c, exists := s.bc.Contracts[address]
+		if !exists {
+			return nil
+		}
+		return c.storage[key]
+	}
+}
+
+func (s *leafSet) record(key string) {
+	s.leaves[key] = func() []byte {
+		return s.bc.records[key]
+	}
+}
+
+// stateLeaves collects every committed account, permit nonce, token, token
+// balance, vesting schedule list, frozen or blocklisted token account,
+// collection, item, item balance, operator approval, contract, contract
+// storage key and native module record. Caller must hold bc.mu.
+func (bc *Blockchain) stateLeaves() map[string][]byte {
+	set := bc.newLeafSet()
+	for address := range bc.GlobalState {
+		set.account(address)
+	}
+	for symbol := range bc.TokenRegistry {
+		set.token(symbol)
+	}
+	for symbol := range bc.Collections {
+		set.collection(symbol)
+	}
+	for address := range bc.Contracts {
+		set.contract(address)
+	}
+	for key := range bc.records {
+		set.record(key)
+	}
+
+	leaves := set.read()
+	for key, value := range leaves {
+		if value == nil {
+			delete(leaves, key)
+		}
+	}
+	return leaves
+}
+
+// touchedLeaves returns the leaves the block journaled in u may have changed.
+// Caller must hold bc.mu.
+func (bc *Blockchain) touchedLeaves(u *blockUndo) *leafSet {
+	set := bc.newLeafSet()
+	for address := range u.accounts {
+		set.account(address)
+	}
+	for key := range u.balances {
+		set.tokenBalance(key.symbol, key.address)
+	}
+	for symbol, previous := range u.controls {
+		set.tokenInfo(symbol)
+		if previous != nil {
+			set.tokenControls(symbol, previous)
+		}
+		if tk, exists := bc.TokenRegistry[symbol]; exists {
+			set.tokenControls(symbol, tk.Controls())
+		}
+	}
+	for key := range u.vesting {
+		set.vesting(key.symbol, key.address)
+	}
+	for key := range u.items {
+		set.item(key.symbol, key.id)
+	}
+	for key := range u.itemBalances {
+		set.itemBalance(key.symbol, key.id, key.address)
+	}
+	for key := range u.operators {
+		set.operator(key.symbol, key.owner, key.operator)
+	}
+	for key := range u.storage {
+		set.storage(key.contract, key.key)
+	}
+	for key := range u.records {
+		set.record(key)
+	}
+	for _, symbol := range u.createdTokens {
+		set.token(symbol)
+	}
+	for _, symbol := range u.createdCollections {
+		set.collection(symbol)
+	}
+	for _, address := range u.createdContracts {
+		set.contract(address)
+	}
+	return set
+}
+
+// currentStateTree returns the tree of the current state. It is built from the
+// whole state the first time; after that, applying or reverting a block only
+// rehashes the leaves the block touched. Caller must hold bc.mu.
+func (bc *Blockchain) currentStateTree() *StateTree {
+	if bc.stateTree == nil {
+		bc.stateTree = NewStateTree(bc.stateLeaves())
+		bc.cacheStateTree(bc.stateTree)
+	}
+	return bc.stateTree
+}
+
+// updateStateTree rereads the leaves in set into the current tree. Caller must hold bc.mu.
+func (bc *Blockchain) updateStateTree(set *leafSet) {
+	bc.stateTree = bc.currentStateTree().Update(set.read())
+	bc.cacheStateTree(bc.stateTree)
+}
+
+// cacheStateTree keeps tree by root so proofs can later be served against that root
+func (bc *Blockchain) cacheStateTree(tree *StateTree) {
+	root := tree.Root()
+
+	bc.stateCacheMu.Lock()
+	defer bc.stateCacheMu.Unlock()
+
+	if _, exists := bc.stateTrees[root]; !exists {
+		bc.stateTrees[root] = tree
+		bc.stateTreeOrder = append(bc.stateTreeOrder, root)
+		if len(bc.stateTreeOrder) > maxCachedStateTrees {
+			delete(bc.stateTrees, bc.stateTreeOrder[0])
+			bc.stateTreeOrder = bc.stateTreeOrder[1:]
+		}
+	}
+}
+
+// StateRoot returns the root of the current account and token state
+func (bc *Blockchain) StateRoot() string {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	return bc.currentStateTree().Root()
+}
+
+// GetStateProof returns a proof for key against the state root committed in the
+// block at height. A block's state root commits to the state after the block.
+func (bc *Blockchain) GetStateProof(key string, height uint64) (*StateProof, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if height >= uint64(len(bc.Blocks)) {
+		return nil, fmt.Errorf("block %d not found", height)
+	}
+	root := bc.Blocks[height].Header.StateRoot
+
+	bc.stateCacheMu.Lock()
+	tree, exists := bc.stateTrees[root]
+	bc.stateCacheMu.Unlock()
+
+	if !exists {
+		return nil, fmt.Errorf("state for block %d is no longer available", height)
+	}
+
+	return tree.Prove(key), nil
+}
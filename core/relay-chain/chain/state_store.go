@@ -0,0 +1,73 @@
+package chain
+
+import (
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// blockStateStore holds the node's state changes that are persisted outside
+// the account records: token ledgers, collections and contract storage.
+// Changes are held until the next block is persisted and are written in the
+// same batch as that block, so the stored state always belongs to the stored
+// head.
+type blockStateStore struct {
+	mu      sync.Mutex
+	pending map[string][]byte // nil deletes the key
+}
+
+func newBlockStateStore() *blockStateStore {
+	return &blockStateStore{pending: make(map[string][]byte)}
+}
+
+// set records value as the new value of key. An empty value deletes the key.
+func (s *blockStateStore) set(key string, value []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(value) == 0 {
+		s.pending[key] = nil
+		return
+	}
+	s.pending[key] = value
+}
+
+// take removes the pending changes and adds them to batch
+func (s *blockStateStore) take(batch *leveldb.Batch) map[string][]byte {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes := s.pending
+	s.pending = make(map[string][]byte)
+	for key, value := range changes {
+		if value == nil {
+			batch.Delete([]byte(key))
+		} else {
+			batch.Put([]byte(key), value)
+		}
+	}
+	return changes
+}
+
+// putBack returns changes whose batch failed to write, unless they were
+// superseded in the meantime
+func (s *blockStateStore) putBack(changes map[string][]byte) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, value := range changes {
+		if _, superseded := s.pending[key]; !superseded {
+			s.pending[key] = value
+		}
+	}
+}
+
+// writePendingState adds the pending state changes to batch. If the batch
+// then fails to write, the changes must be handed back with putBack.
+func (bc *Blockchain) writePendingState(batch *leveldb.Batch) map[string][]byte {
+	return bc.stateStore.take(batch)
+}
@@ -0,0 +1,512 @@
+package chain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+// StateTreeDepth is the number of levels in the sparse Merkle tree (one per key bit)
+const StateTreeDepth = 256
+
+var (
+	leafPrefix = []byte{0x00}
+	nodePrefix = []byte{0x01}
+
+	// emptyNode is the hash of any subtree that holds no leaves
+	emptyNode [32]byte
+)
+
+// stateLeaf is a single committed key/value pair in the state tree
+type stateLeaf struct {
+	path  [32]byte
+	key   string
+	value []byte
+	hash  [32]byte
+}
+
+func newStateLeaf(key string, value []byte) *stateLeaf {
+	leaf := &stateLeaf{
+		path:  sha256.Sum256([]byte(key)),
+		key:   key,
+		value: value,
+	}
+	leaf.hash = hashStateLeaf(leaf.path, value)
+	return leaf
+}
+
+// stateNode is a non-empty subtree. A subtree holding a single leaf is one
+// node, so branch nodes only exist where the paths of two or more leaves run
+// through them. Nodes never change once built: updates copy the nodes on the
+// changed paths, and older trees keep sharing the rest.
+type stateNode struct {
+	left, right *stateNode
+	leaf        *stateLeaf // set when the subtree holds a single leaf
+	hash        [32]byte
+}
+
+func nodeHash(n *stateNode) [32]byte {
+	if n == nil {
+		return emptyNode
+	}
+	return n.hash
+}
+
+// newLeafNode places leaf alone in the subtree at depth, hashing it up
+// through the empty siblings below
+func newLeafNode(leaf *stateLeaf, depth int) *stateNode {
+	hash := leaf.hash
+	for d := StateTreeDepth - 1; d >= depth; d-- {
+		if pathBit(leaf.path, d) == 0 {
+			hash = hashStateNode(hash, emptyNode)
+		} else {
+			hash = hashStateNode(emptyNode, hash)
+		}
+	}
+	return &stateNode{leaf: leaf, hash: hash}
+}
+
+func newBranchNode(left, right *stateNode) *stateNode {
+	return &stateNode{left: left, right: right, hash: hashStateNode(nodeHash(left), nodeHash(right))}
+}
+
+// StateTree is a sparse Merkle tree over the account and token balance state.
+// Keys are hashed into a 256-bit path; subtrees without leaves hash to zero so
+// the tree only does work proportional to the number of populated leaves.
+// Update returns a new tree and only rehashes the paths of the changed leaves.
+type StateTree struct {
+	root *stateNode
+}
+
+// StateProof proves the value stored under Key (or its absence) against Root
+type StateProof struct {
+	Key      string   `json:"key"`
+	Value    string   `json:"value"`    // hex encoded, empty for non-inclusion
+	Bitmap   string   `json:"bitmap"`   // bit i set when the sibling at depth i is non-empty
+	Siblings []string `json:"siblings"` // non-empty siblings ordered from root to leaf
+	Root     string   `json:"root"`
+}
+
+// NewStateTree builds a tree from the given key/value leaves
+func NewStateTree(entries map[string][]byte) *StateTree {
+	leaves := make([]*stateLeaf, 0, len(entries))
+	for key, value := range entries {
+		leaves = append(leaves, newStateLeaf(key, value))
+	}
+	sort.Slice(leaves, func(i, j int) bool {
+		return bytes.Compare(leaves[i].path[:], leaves[j].path[:]) < 0
+	})
+	return &StateTree{root: buildSubtree(leaves, 0)}
+}
+
+// buildSubtree builds the subtree at depth over leaves sorted by path
+func buildSubtree(leaves []*stateLeaf, depth int) *stateNode {
+	switch len(leaves) {
+	case 0:
+		return nil
+	case 1:
+		return newLeafNode(leaves[0], depth)
+	}
+	i := sort.Search(len(leaves), func(i int) bool {
+		return pathBit(leaves[i].path, depth) == 1
+	})
+	return newBranchNode(buildSubtree(leaves[:i], depth+1), buildSubtree(leaves[i:], depth+1))
+}
+
+// Update returns the tree with changes applied. A nil value removes the key.
+// The receiver is left unchanged.
+func (st *StateTree) Update(changes map[string][]byte) *StateTree {
+	root := st.root
+	for key, value := range changes {
+		if value == nil {
+			root = removeLeaf(root, sha256.Sum256([]byte(key)), key, 0)
+		} else {
+			root = insertLeaf(root, newStateLeaf(key, value), 0)
+		}
+	}
+	return &StateTree{root: root}
+}
+
+func insertLeaf(n *stateNode, leaf *stateLeaf, depth int) *stateNode {
+	switch {
+	case n == nil:
+		return newLeafNode(leaf, depth)
+	case n.leaf != nil && n.leaf.key == leaf.key:
+		if n.leaf.hash == leaf.hash {
+			return n
+		}
+		return newLeafNode(leaf, depth)
+	case n.leaf != nil:
+		return splitLeaf(n.leaf, leaf, depth)
+	case pathBit(leaf.path, depth) == 0:
+		return newBranchNode(insertLeaf(n.left, leaf, depth+1), n.right)
+	default:
+		return newBranchNode(n.left, insertLeaf(n.right, leaf, depth+1))
+	}
+}
+
+// splitLeaf builds the subtree at depth holding two leaves with different keys
+func splitLeaf(a, b *stateLeaf, depth int) *stateNode {
+	bitA, bitB := pathBit(a.path, depth), pathBit(b.path, depth)
+	switch {
+	case bitA == bitB && bitA == 0:
+		return newBranchNode(splitLeaf(a, b, depth+1), nil)
+	case bitA == bitB:
+		return newBranchNode(nil, splitLeaf(a, b, depth+1))
+	case bitA == 0:
+		return newBranchNode(newLeafNode(a, depth+1), newLeafNode(b, depth+1))
+	default:
+		return newBranchNode(newLeafNode(b, depth+1), newLeafNode(a, depth+1))
+	}
+}
+
+func removeLeaf(n *stateNode, path [32]byte, key string, depth int) *stateNode {
+	if n == nil {
+		return nil
+	}
+	if n.leaf != nil {
+		if n.leaf.key == key {
+			return nil
+		}
+		return n
+	}
+
+	left, right := n.left, n.right
+	if pathBit(path, depth) == 0 {
+		left = removeLeaf(left, path, key, depth+1)
+	} else {
+		right = removeLeaf(right, path, key, depth+1)
+	}
+	switch {
+	case left == n.left && right == n.right:
+		return n
+	case left == nil && right == nil:
+		return nil
+	// A single leaf left in the subtree moves up to stand for it
+	case left == nil && right.leaf != nil:
+		return newLeafNode(right.leaf, depth)
+	case right == nil && left.leaf != nil:
+		return newLeafNode(left.leaf, depth)
+	}
+	return newBranchNode(left, right)
+}
+
+// Root returns the hex encoded root hash
+func (st *StateTree) Root() string {
+	root := nodeHash(st.root)
+	return hex.EncodeToString(root[:])
+}
+
+// Get returns the value stored under key
+func (st *StateTree) Get(key string) ([]byte, bool) {
+	path := sha256.Sum256([]byte(key))
+	n := st.root
+	for depth := 0; n != nil && n.leaf == nil; depth++ {
+		if pathBit(path, depth) == 0 {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	if n == nil || n.leaf.key != key {
+		return nil, false
+	}
+	return n.leaf.value, true
+}
+
+// Prove builds an inclusion proof for key, or a non-inclusion proof if the key is absent
+func (st *StateTree) Prove(key string) *StateProof {
+	path := sha256.Sum256([]byte(key))
+	bitmap := make([]byte, StateTreeDepth/8)
+	siblings := make([]string, 0)
+
+	n := st.root
+	for depth := 0; depth < StateTreeDepth; depth++ {
+		sibling := emptyNode
+		switch {
+		case n == nil:
+		case n.leaf != nil:
+			// The only leaf below is the sibling from where its path leaves ours
+			if pathBit(n.leaf.path, depth) != pathBit(path, depth) {
+				sibling = newLeafNode(n.leaf, depth+1).hash
+				n = nil
+			}
+		case pathBit(path, depth) == 0:
+			sibling = nodeHash(n.right)
+			n = n.left
+		default:
+			sibling = nodeHash(n.left)
+			n = n.right
+		}
+		if sibling != emptyNode {
+			bitmap[depth/8] |= 1 << (7 - uint(depth%8))
+			siblings = append(siblings, hex.EncodeToString(sibling[:]))
+		}
+	}
+
+	proof := &StateProof{
+		Key:      key,
+		Bitmap:   hex.EncodeToString(bitmap),
+		Siblings: siblings,
+		Root:     st.Root(),
+	}
+	if n != nil && n.leaf != nil && n.leaf.key == key {
+		proof.Value = hex.EncodeToString(n.leaf.value)
+	}
+	return proof
+}
+
+// VerifyStateProof checks a proof against the expected root
+func VerifyStateProof(root string, proof *StateProof) (bool, error) {
+	if proof == nil {
+		return false, errors.New("nil proof")
+	}
+	bitmap, err := hex.DecodeString(proof.Bitmap)
+	if err != nil || len(bitmap) != StateTreeDepth/8 {
+		return false, errors.New("invalid proof bitmap")
+	}
+
+	path := sha256.Sum256([]byte(proof.Key))
+	current := emptyNode
+	if proof.Value != "" {
+		value, err := hex.DecodeString(proof.Value)
+		if err != nil {
+			return false, fmt.Errorf("invalid proof value: %v", err)
+		}
+		current = hashStateLeaf(path, value)
+	}
+
+	next := len(proof.Siblings) - 1
+	for depth := StateTreeDepth - 1; depth >= 0; depth-- {
+		sibling := emptyNode
+		if bitmap[depth/8]&(1<<(7-uint(depth%8))) != 0 {
+			if next < 0 {
+				return false, errors.New("proof has too few siblings")
+			}
+			decoded, err := hex.DecodeString(proof.Siblings[next])
+			if err != nil || len(decoded) != 32 {
+				return false, errors.New("invalid proof sibling")
+			}
+			copy(sibling[:], decoded)
+			next--
+		}
+		if pathBit(path, depth) == 0 {
+			current = hashStateNode(current, sibling)
+		} else {
+			current = hashStateNode(sibling, current)
+		}
+	}
+	if next != -1 {
+		return false, errors.New("proof has unused siblings")
+	}
+
+	return hex.EncodeToString(current[:]) == root, nil
+}
+
+func pathBit(path [32]byte, depth int) byte {
+	return (path[depth/8] >> (7 - uint(depth%8))) & 1
+}
+
+func hashStateLeaf(path [32]byte, value []byte) [32]byte {
+	valueHash := sha256.Sum256(value)
+	h := sha256.New()
+	h.Write(leafPrefix)
+	h.Write(path[:])
+	h.Write(valueHash[:])
+	var out [32]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+func hashStateNode(left, right [32]byte) [32]byte {
+	if left == emptyNode && right == emptyNode {
+		return emptyNode
+	}
+	h := sha256.New()
+	h.Write(nodePrefix)
+	h.Write(left[:])
+	h.Write(right[:])
+	var out [32]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// AccountStateKey is the state tree key for a native account
+func AccountStateKey(address string) string {
+	return "account/" + address
+}
+
+// TokenBalanceKey is the state tree key for a token balance
+func TokenBalanceKey(symbol, address string) string {
+	return "token/" + symbol + "/" + address
+}
+
+// TokenInfoKey is the state tree key for a token's parameters, roles and pause flag
+func TokenInfoKey(symbol string) string {
+	return "tokeninfo/" + symbol
+}
+
+// TokenControlKey is the state tree key for whether an account is frozen or
+// blocklisted on a token
+func TokenControlKey(symbol, address string) string {
+	return "tokenctl/" + symbol + "/" + address
+}
+
+// TokenVestingKey is the state tree key for an account's vesting schedules on a token
+func TokenVestingKey(symbol, address string) string {
+	return "vesting/" + symbol + "/" + address
+}
+
+// PermitNonceKey is the state tree key for how many of an account's permits were used
+func PermitNonceKey(address string) string {
+	return "permitnonce/" + address
+}
+
+// CollectionInfoKey is the state tree key for a collection's parameters and minter
+func CollectionInfoKey(symbol string) string {
+	return "collection/" + symbol
+}
+
+// ItemKey is the state tree key for an item's URI, supply and approved address
+func ItemKey(symbol, id string) string {
+	return "item/" + symbol + "/" + id
+}
+
+// ItemBalanceKey is the state tree key for how much of an item an account holds
+func ItemBalanceKey(symbol, id, address string) string {
+	return "itembal/" + symbol + "/" + id + "/" + address
+}
+
+// OperatorKey is the state tree key for an operator approved for all of an
+// owner's items in a collection
+func OperatorKey(symbol, owner, operator string) string {
+	return "operator/" + symbol + "/" + owner + "/" + operator
+}
+
+// ContractKey is the state tree key for a contract's code hash and creator
+func ContractKey(address string) string {
+	return "contract/" + address
+}
+
+// ContractStorageKey is the state tree key for a key in a contract's storage
+func ContractStorageKey(address, key string) string {
+	return "storage/" + address + "/" + key
+}
+
+// EscrowKey is the state tree key for an escrow's parties, amount and status
+func EscrowKey(id string) string {
+	return "escrow/" + id
+}
+
+// OTCOrderKey is the state tree key for an OTC order's offer, price and status
+func OTCOrderKey(id string) string {
+	return "otc/" + id
+}
+
+// MultisigKey is the state tree key for a multisig wallet's owners and
+// threshold. The wallet's funds are the token balances of its address.
+func MultisigKey(address string) string {
+	return "multisig/" + address
+}
+
+// MultisigProposalKey is the state tree key for a multisig proposal's payment and approvals
+func MultisigProposalKey(id string) string {
+	return "multisigtx/" + id
+}
+
+// PoolKey is the state tree key for a DEX pool's total liquidity shares. The
+// pool's reserves are the token balances of its PoolAddress.
+func PoolKey(tokenA, tokenB string) string {
+	tokenA, tokenB = sortPair(tokenA, tokenB)
+	return "pool/" + tokenA + "/" + tokenB
+}
+
+// PoolShareKey is the state tree key for the liquidity shares a provider holds in a DEX pool
+func PoolShareKey(tokenA, tokenB, provider string) string {
+	tokenA, tokenB = sortPair(tokenA, tokenB)
+	return "poolshare/" + tokenA + "/" + tokenB + "/" + provider
+}
+
+// Flags of a token control leaf
+const (
+	controlFrozen      byte = 1
+	controlBlocklisted byte = 2
+)
+
+func encodeAccountLeaf(state *AccountState) []byte {
+	var e encoder
+	e.uint256(state.Balance)
+	e.uint64(state.Nonce)
+	return e.buf.Bytes()
+}
+
+func encodePermitNonceLeaf(nonce uint64) []byte {
+	var e encoder
+	e.uint64(nonce)
+	return e.buf.Bytes()
+}
+
+func encodeBalanceLeaf(balance uint256.Int) []byte {
+	var e encoder
+	e.uint256(balance)
+	return e.buf.Bytes()
+}
+
+func encodeTokenInfoLeaf(tk *token.Token, controls *token.Controls) []byte {
+	var e encoder
+	e.string(tk.Name)
+	e.uint8(tk.Decimals)
+	e.uint256(tk.MaxSupply())
+	for _, role := range token.Roles {
+		e.string(controls.Roles[role])
+	}
+	if controls.Paused {
+		e.uint8(1)
+	} else {
+		e.uint8(0)
+	}
+	return e.buf.Bytes()
+}
+
+func encodeVestingLeaf(schedules []token.VestingSchedule) []byte {
+	var e encoder
+	e.uint32(uint32(len(schedules)))
+	for _, s := range schedules {
+		e.string(s.Grantor)
+		e.uint256(s.Total)
+		e.string(string(s.Basis))
+		e.uint64(s.Start)
+		e.uint64(s.Cliff)
+		e.uint64(s.Duration)
+	}
+	return e.buf.Bytes()
+}
+
+func encodeCollectionInfoLeaf(c *token.Collection) []byte {
+	var e encoder
+	e.string(c.Name)
+	e.string(string(c.Kind))
+	e.string(c.Minter())
+	return e.buf.Bytes()
+}
+
+func encodeItemLeaf(item *token.Item) []byte {
+	var e encoder
+	e.string(item.URI)
+	e.uint256(item.Supply)
+	e.string(item.Approved)
+	return e.buf.Bytes()
+}
+
+func encodeContractLeaf(c *Contract) []byte {
+	var e encoder
+	e.string(c.CodeHash)
+	e.string(c.Creator)
+	return e.buf.Bytes()
+}
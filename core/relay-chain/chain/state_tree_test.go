@@ -0,0 +1,125 @@
+package chain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateTreeRoot(t *testing.T) {
+	t.Run("Empty tree", func(t *testing.T) {
+		tree := NewStateTree(nil)
+		assert.Equal(t, "0000000000000000000000000000000000000000000000000000000000000000", tree.Root())
+	})
+
+	t.Run("Root is independent of insertion order", func(t *testing.T) {
+		a := NewStateTree(map[string][]byte{
+			AccountStateKey("0xAlice"):        encodeAccountLeaf(&AccountState{Balance: uint256.NewInt(100), Nonce: 1}),
+			TokenBalanceKey("BHX", "0xBob"):   encodeBalanceLeaf(uint256.NewInt(50)),
+			TokenBalanceKey("BHX", "0xCarol"): encodeBalanceLeaf(uint256.NewInt(7)),
+		})
+		b := NewStateTree(map[string][]byte{
+			TokenBalanceKey("BHX", "0xCarol"): encodeBalanceLeaf(uint256.NewInt(7)),
+			TokenBalanceKey("BHX", "0xBob"):   encodeBalanceLeaf(uint256.NewInt(50)),
+			AccountStateKey("0xAlice"):        encodeAccountLeaf(&AccountState{Balance: uint256.NewInt(100), Nonce: 1}),
+		})
+		assert.Equal(t, a.Root(), b.Root())
+	})
+
+	t.Run("Balance change changes root", func(t *testing.T) {
+		a := NewStateTree(map[string][]byte{TokenBalanceKey("BHX", "0xBob"): encodeBalanceLeaf(uint256.NewInt(50))})
+		b := NewStateTree(map[string][]byte{TokenBalanceKey("BHX", "0xBob"): encodeBalanceLeaf(uint256.NewInt(51))})
+		assert.NotEqual(t, a.Root(), b.Root())
+	})
+}
+
+func TestStateProof(t *testing.T) {
+	entries := map[string][]byte{}
+	for _, addr := range []string{"0xAlice", "0xBob", "0xCarol", "0xDave", "0xEve"} {
+		entries[TokenBalanceKey("BHX", addr)] = encodeBalanceLeaf(uint256.NewInt(uint64(len(addr)) * 10))
+	}
+	tree := NewStateTree(entries)
+
+	t.Run("Inclusion", func(t *testing.T) {
+		proof := tree.Prove(TokenBalanceKey("BHX", "0xCarol"))
+		assert.NotEmpty(t, proof.Value)
+
+		ok, err := VerifyStateProof(tree.Root(), proof)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("Non-inclusion", func(t *testing.T) {
+		proof := tree.Prove(TokenBalanceKey("BHX", "0xMallory"))
+		assert.Empty(t, proof.Value)
+
+		ok, err := VerifyStateProof(tree.Root(), proof)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("Tampered value", func(t *testing.T) {
+		proof := tree.Prove(TokenBalanceKey("BHX", "0xCarol"))
+		proof.Value = "00000000000003e8"
+
+		ok, err := VerifyStateProof(tree.Root(), proof)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestStateTreeUpdate(t *testing.T) {
+	entries := map[string][]byte{}
+	for i := 0; i < 50; i++ {
+		entries[TokenBalanceKey("BHX", fmt.Sprintf("0x%d", i))] = encodeBalanceLeaf(uint256.NewInt(uint64(i) + 1))
+	}
+	tree := NewStateTree(entries)
+	root := tree.Root()
+
+	changes := map[string][]byte{
+		TokenBalanceKey("BHX", "0x3"):   encodeBalanceLeaf(uint256.NewInt(999)),
+		TokenBalanceKey("BHX", "0x7"):   nil,
+		TokenBalanceKey("BHX", "0x8"):   nil,
+		TokenBalanceKey("BHX", "0xNew"): encodeBalanceLeaf(uint256.NewInt(5)),
+		TokenBalanceKey("BHX", "0xNil"): nil,
+	}
+	updated := tree.Update(changes)
+	for key, value := range changes {
+		if value == nil {
+			delete(entries, key)
+		} else {
+			entries[key] = value
+		}
+	}
+
+	assert.Equal(t, NewStateTree(entries).Root(), updated.Root())
+	assert.Equal(t, root, tree.Root(), "the old tree is left unchanged")
+
+	value, ok := updated.Get(TokenBalanceKey("BHX", "0x3"))
+	assert.True(t, ok)
+	assert.Equal(t, encodeBalanceLeaf(uint256.NewInt(999)), value)
+	_, ok = updated.Get(TokenBalanceKey("BHX", "0x7"))
+	assert.False(t, ok)
+
+	for _, key := range []string{TokenBalanceKey("BHX", "0xNew"), TokenBalanceKey("BHX", "0x7")} {
+		ok, err := VerifyStateProof(updated.Root(), updated.Prove(key))
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	}
+
+	// Removing every leaf again leaves the empty tree
+	removeAll := make(map[string][]byte)
+	for key := range entries {
+		removeAll[key] = nil
+	}
+	assert.Equal(t, NewStateTree(nil).Root(), updated.Update(removeAll).Root())
+}
+
+// assertStateTreeMatches checks the incrementally updated state tree against
+// one built from the whole state
+func assertStateTreeMatches(t *testing.T, bc *Blockchain) {
+	t.Helper()
+	assert.Equal(t, NewStateTree(bc.stateLeaves()).Root(), bc.currentStateTree().Root())
+}
@@ -0,0 +1,215 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// ErrLedgerMismatch is returned on startup when a stored token ledger does not
+// match the ledger replayed from the stored blocks
+var ErrLedgerMismatch = errors.New("stored token ledger disagrees with the stored blocks")
+
+// LevelDB key prefixes of the persisted token ledgers. Amounts are stored as
+// decimal strings and vesting schedules as JSON lists.
+const (
+	ledgerBalancePrefix   = "tokenbal:"    // tokenbal:<symbol>:<address>
+	ledgerSupplyPrefix    = "tokensupply:" // tokensupply:<symbol>
+	ledgerAllowancePrefix = "tokenallow:"  // tokenallow:<symbol>:<owner>:<spender>
+	ledgerVestingPrefix   = "tokenvest:"   // tokenvest:<symbol>:<address>
+)
+
+func ledgerBalanceKey(symbol, address string) string {
+	return ledgerBalancePrefix + symbol + ":" + address
+}
+
+func ledgerSupplyKey(symbol string) string {
+	return ledgerSupplyPrefix + symbol
+}
+
+func ledgerAllowanceKey(symbol, owner, spender string) string {
+	return ledgerAllowancePrefix + symbol + ":" + owner + ":" + spender
+}
+
+func ledgerVestingKey(symbol, address string) string {
+	return ledgerVestingPrefix + symbol + ":" + address
+}
+
+// The blockStateStore is the node's token.Store. put records an amount, and a
+// zero amount deletes the key.
+func (s *blockStateStore) put(key string, amount uint256.Int) {
+	if amount.IsZero() {
+		s.set(key, nil)
+		return
+	}
+	s.set(key, []byte(amount.String()))
+}
+
+func (s *blockStateStore) PutBalance(symbol, address string, amount uint256.Int) {
+	s.put(ledgerBalanceKey(symbol, address), amount)
+}
+
+func (s *blockStateStore) PutSupply(symbol string, supply uint256.Int) {
+	s.put(ledgerSupplyKey(symbol), supply)
+}
+
+func (s *blockStateStore) PutAllowance(symbol, owner, spender string, amount uint256.Int) {
+	s.put(ledgerAllowanceKey(symbol, owner, spender), amount)
+}
+
+func (s *blockStateStore) PutVesting(symbol, address string, schedules []token.VestingSchedule) {
+	var data []byte
+	if len(schedules) > 0 {
+		// A list of plain structs always marshals
+		data, _ = json.Marshal(schedules)
+	}
+	s.set(ledgerVestingKey(symbol, address), data)
+}
+
+// attachToken writes the token's ledger through the node's store from now on
+// and measures its vesting against the node's clock. Caller must hold bc.mu or
+// have exclusive access to bc.
+func (bc *Blockchain) attachToken(tk *token.Token) {
+	tk.SetClock(&bc.clock)
+	if bc.stateStore != nil {
+		tk.SetStore(bc.stateStore)
+	}
+}
+
+func parseLedgerAmount(key string, value []byte) (uint256.Int, error) {
+	amount, err := uint256.FromDecimal(string(value))
+	if err != nil {
+		return uint256.Int{}, fmt.Errorf("corrupt stored ledger entry %s: %v", key, err)
+	}
+	return amount, nil
+}
+
+// readTokenLedger reads a token's supply, holders and allowances from the
+// store. It reports false if nothing is stored for the token.
+func (bc *Blockchain) readTokenLedger(symbol string) (*token.Ledger, bool, error) {
+	ledger := &token.Ledger{Symbol: symbol, Holders: []token.Holder{}, Allowances: []token.AllowanceEntry{}, Vesting: []token.VestingSchedule{}}
+
+	data, err := bc.DB.Get([]byte(ledgerSupplyKey(symbol)), nil)
+	if err == leveldb.ErrNotFound {
+		return ledger, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if ledger.TotalSupply, err = parseLedgerAmount(ledgerSupplyKey(symbol), data); err != nil {
+		return nil, false, err
+	}
+
+	// Keys sort by address, so holders and allowances come out in order
+	prefix := ledgerBalanceKey(symbol, "")
+	iter := bc.DB.NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+	for iter.Next() {
+		key := string(iter.Key())
+		balance, err := parseLedgerAmount(key, iter.Value())
+		if err != nil {
+			iter.Release()
+			return nil, false, err
+		}
+		ledger.Holders = append(ledger.Holders, token.Holder{Address: key[len(prefix):], Balance: balance})
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return nil, false, err
+	}
+
+	prefix = ledgerAllowancePrefix + symbol + ":"
+	iter = bc.DB.NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+	for iter.Next() {
+		key := string(iter.Key())
+		amount, err := parseLedgerAmount(key, iter.Value())
+		if err != nil {
+			iter.Release()
+			return nil, false, err
+		}
+		owner, spender, ok := strings.Cut(key[len(prefix):], ":")
+		if !ok {
+			iter.Release()
+			return nil, false, fmt.Errorf("corrupt stored ledger key %s", key)
+		}
+		ledger.Allowances = append(ledger.Allowances, token.AllowanceEntry{Owner: owner, Spender: spender, Amount: amount})
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return nil, false, err
+	}
+
+	ledger.Vesting = []token.VestingSchedule{}
+	iter = bc.DB.NewIterator(util.BytesPrefix([]byte(ledgerVestingKey(symbol, ""))), nil)
+	defer iter.Release()
+	for iter.Next() {
+		var schedules []token.VestingSchedule
+		if err := json.Unmarshal(iter.Value(), &schedules); err != nil {
+			return nil, false, fmt.Errorf("corrupt stored vesting entry %s: %v", iter.Key(), err)
+		}
+		ledger.Vesting = append(ledger.Vesting, schedules...)
+	}
+	return ledger, true, iter.Error()
+}
+
+// checkStoredTokens compares the stored token ledgers with the ones replayed
+// from the stored blocks. A stored ledger that disagrees holds changes made
+// outside of blocks, so the node refuses to start rather than overwrite those
+// balances. Tokens with nothing stored, as in stores written before ledgers
+// were persisted, are written out with the pending changes. Caller must hold
+// bc.mu.
+func (bc *Blockchain) checkStoredTokens() error {
+	for symbol, tk := range bc.TokenRegistry {
+		stored, exists, err := bc.readTokenLedger(symbol)
+		if err != nil {
+			return fmt.Errorf("failed to read stored %s ledger: %v", symbol, err)
+		}
+		replayed := tk.Export()
+		if !exists || reflect.DeepEqual(stored, replayed) {
+			continue
+		}
+		return fmt.Errorf("%w: %s %s", ErrLedgerMismatch, symbol, ledgerDifference(stored, replayed))
+	}
+	return nil
+}
+
+// ledgerDifference describes the first way stored differs from replayed
+func ledgerDifference(stored, replayed *token.Ledger) string {
+	if stored.TotalSupply != replayed.TotalSupply {
+		return fmt.Sprintf("supply is %s stored, %s replayed", stored.TotalSupply, replayed.TotalSupply)
+	}
+	balances := make(map[string]uint256.Int, len(replayed.Holders))
+	for _, h := range replayed.Holders {
+		balances[h.Address] = h.Balance
+	}
+	for _, h := range stored.Holders {
+		if balance := balances[h.Address]; balance != h.Balance {
+			return fmt.Sprintf("balance of %s is %s stored, %s replayed", h.Address, h.Balance, balance)
+		}
+		delete(balances, h.Address)
+	}
+	for address, balance := range balances {
+		return fmt.Sprintf("balance of %s is 0 stored, %s replayed", address, balance)
+	}
+	return "allowances or vesting schedules differ"
+}
+
+// ExportTokenLedger returns a token's holders, allowances and supply as
+// persisted with the last block
+func (bc *Blockchain) ExportTokenLedger(symbol string) (*token.Ledger, error) {
+	bc.mu.RLock()
+	_, exists := bc.TokenRegistry[symbol]
+	bc.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("token %s not found", symbol)
+	}
+
+	ledger, _, err := bc.readTokenLedger(symbol)
+	return ledger, err
+}
@@ -0,0 +1,78 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func goldBalance(bc *Blockchain, address string) uint256.Int {
+	balance, _ := bc.TokenRegistry["GOLD"].BalanceOf(address)
+	return balance
+}
+
+func TestTokenLedgersSurviveRestart(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+	issuer := PublicKeyToAddress(key.PubKey())
+
+	g := testGenesis(t)
+	g.Tokens[0].Balances[issuer] = uint256.NewInt(1000)
+	validator := g.Validators[0].Address
+
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	bc, err := openChain(db, g, DefaultHistoryConfig())
+	assert.Nil(t, err)
+
+	create := signedTokenCreate(t, key, 0, "GOLD", TokenCreateParams{Name: "Gold"}, 500)
+	assert.True(t, bc.AddBlock(blockBy(bc, validator, create)))
+	transfer := signedTokenTx(t, key, TokenTransfer, 1, "GOLD", "0xBob", 100)
+	assert.True(t, bc.AddBlock(blockBy(bc, validator, transfer)))
+	root := bc.StateRoot()
+
+	ledger, err := bc.ExportTokenLedger("GOLD")
+	assert.Nil(t, err)
+	assert.Equal(t, &token.Ledger{
+		Symbol:      "GOLD",
+		TotalSupply: uint256.NewInt(500),
+		Holders:     []token.Holder{{Address: issuer, Balance: uint256.NewInt(400)}, {Address: "0xBob", Balance: uint256.NewInt(100)}},
+		Allowances:  []token.AllowanceEntry{},
+		Vesting:     []token.VestingSchedule{},
+	}, ledger)
+
+	restarted, err := openChain(db, g, DefaultHistoryConfig())
+	assert.Nil(t, err)
+	assert.Equal(t, root, restarted.StateRoot())
+	assert.Equal(t, uint256.NewInt(100), goldBalance(restarted, "0xBob"))
+	assert.Equal(t, bhxBalance(bc, issuer), bhxBalance(restarted, issuer))
+
+	t.Run("Stored ledgers that disagree with the blocks stop the node", func(t *testing.T) {
+		// A balance changed outside of any block, as an old admin mint left it
+		assert.Nil(t, db.Put([]byte(ledgerBalanceKey("GOLD", "0xEve")), []byte("50"), nil))
+		assert.Nil(t, db.Put([]byte(ledgerBalanceKey("GOLD", "0xBob")), []byte("999"), nil))
+
+		_, err := openChain(db, g, DefaultHistoryConfig())
+		assert.ErrorIs(t, err, ErrLedgerMismatch)
+		assert.ErrorContains(t, err, "balance of 0xBob is 999 stored, 100 replayed")
+
+		// Nothing stored is overwritten
+		eve, err := db.Get([]byte(ledgerBalanceKey("GOLD", "0xEve")), nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "50", string(eve))
+
+		// Once the operator settles the difference the node starts again
+		assert.Nil(t, db.Delete([]byte(ledgerBalanceKey("GOLD", "0xEve")), nil))
+		assert.Nil(t, db.Put([]byte(ledgerBalanceKey("GOLD", "0xBob")), []byte("100"), nil))
+		again, err := openChain(db, g, DefaultHistoryConfig())
+		assert.Nil(t, err)
+		assert.Equal(t, root, again.StateRoot())
+	})
+}
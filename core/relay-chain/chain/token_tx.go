@@ -0,0 +1,549 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+// Limits on tokens created through TokenCreate
+const (
+	MaxTokenSymbolLength = 10
+	MaxTokenNameLength   = 64
+	MaxTokenDecimals     = 18
+)
+
+var (
+	ErrInvalidTxFields    = errors.New("invalid transaction fields")
+	ErrTokenExists        = errors.New("token already exists")
+	ErrNotMintAuthority   = errors.New("sender is not the token's mint authority")
+	ErrInvalidTokenParams = errors.New("invalid token parameters")
+	ErrInvalidTokenAdmin  = errors.New("invalid token admin action")
+)
+
+// VestingParams is the JSON carried in the Data of a TokenVest transaction.
+// The transaction moves Amount of TokenID from the sender to To and locks it
+// there under this schedule.
+type VestingParams struct {
+	Basis token.VestingBasis `json:"basis"`
+	// Start is a block height or Unix time, depending on Basis. Zero starts
+	// the schedule at the block that includes the transaction.
+	Start    uint64 `json:"start,omitempty"`
+	Cliff    uint64 `json:"cliff,omitempty"`
+	Duration uint64 `json:"duration"`
+}
+
+// NewTokenVestTransaction builds an unsigned transaction that grants amount
+// to beneficiary under a vesting schedule
+func NewTokenVestTransaction(from, beneficiary, symbol string, amount uint256.Int, params VestingParams) (*Transaction, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(TokenVest, from, beneficiary, amount, nil)
+	tx.TokenID = symbol
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx)
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// decodeTokenVest parses a TokenVest transaction into the schedule it
+// creates. A schedule without a start begins at the given block height or time.
+func decodeTokenVest(tx *Transaction, height uint64, unix int64) (*token.VestingSchedule, error) {
+	var params VestingParams
+	if err := json.Unmarshal(tx.Data, &params); err != nil {
+		return nil, fmt.Errorf("%w: %v", token.ErrInvalidVesting, err)
+	}
+	schedule := &token.VestingSchedule{
+		Beneficiary: tx.To,
+		Total:       tx.Amount,
+		Basis:       params.Basis,
+		Start:       params.Start,
+		Cliff:       params.Cliff,
+		Duration:    params.Duration,
+	}
+	if schedule.Start == 0 {
+		schedule.Start = height
+		if params.Basis == token.VestByTime && unix > 0 {
+			schedule.Start = uint64(unix)
+		}
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// TokenCreateParams is the JSON carried in the Data of a TokenCreate
+// transaction. The symbol is the transaction's TokenID and the initial supply
+// its Amount, which is credited to To, or to the sender if To is empty.
+type TokenCreateParams struct {
+	Name      string      `json:"name"`
+	Decimals  uint8       `json:"decimals"`
+	MaxSupply uint256.Int `json:"max_supply"` // 0 means unlimited
+	// MintAuthority may mint later supply. It defaults to the sender; set it to
+	// "none" to fix the supply at creation.
+	MintAuthority string `json:"mint_authority,omitempty"`
+	// Admin assigns the minter, pauser and freezer roles. It defaults to the
+	// sender; "none" leaves the roles fixed at creation.
+	Admin string `json:"admin,omitempty"`
+}
+
+// noMintAuthority is the MintAuthority and Admin value that leaves a role empty
+const noMintAuthority = "none"
+
+// TokenAdminAction is what a TokenAdmin transaction does
+type TokenAdminAction string
+
+const (
+	ActionGrantRole   TokenAdminAction = "grant_role"  // gives Role to To
+	ActionRevokeRole  TokenAdminAction = "revoke_role" // leaves Role empty
+	ActionPause       TokenAdminAction = "pause"
+	ActionUnpause     TokenAdminAction = "unpause"
+	ActionFreeze      TokenAdminAction = "freeze" // To is the account
+	ActionUnfreeze    TokenAdminAction = "unfreeze"
+	ActionBlocklist   TokenAdminAction = "blocklist"
+	ActionUnblocklist TokenAdminAction = "unblocklist"
+)
+
+// TokenAdminParams is the JSON carried in the Data of a TokenAdmin
+// transaction. The token is the transaction's TokenID and the affected
+// account its To.
+type TokenAdminParams struct {
+	Action TokenAdminAction `json:"action"`
+	Role   token.Role       `json:"role,omitempty"` // only for grant_role and revoke_role
+}
+
+// requiredRole returns the role the sender of action must hold
+func (a TokenAdminAction) requiredRole() (token.Role, bool) {
+	switch a {
+	case ActionGrantRole, ActionRevokeRole:
+		return token.RoleAdmin, true
+	case ActionPause, ActionUnpause:
+		return token.RolePauser, true
+	case ActionFreeze, ActionUnfreeze, ActionBlocklist, ActionUnblocklist:
+		return token.RoleFreezer, true
+	}
+	return "", false
+}
+
+// hasAccount reports whether action names an account in To
+func (a TokenAdminAction) hasAccount() bool {
+	switch a {
+	case ActionRevokeRole, ActionPause, ActionUnpause:
+		return false
+	}
+	return true
+}
+
+// NewTokenAdminTransaction builds an unsigned transaction that changes a
+// token's roles, pause flag, frozen accounts or blocklist
+func NewTokenAdminTransaction(from, symbol, account string, params TokenAdminParams) (*Transaction, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(TokenAdmin, from, account, uint256.Int{}, nil)
+	tx.TokenID = symbol
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx)
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// decodeTokenAdmin parses and checks the parameters of a TokenAdmin transaction
+func decodeTokenAdmin(tx *Transaction) (*TokenAdminParams, error) {
+	var params TokenAdminParams
+	if err := json.Unmarshal(tx.Data, &params); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTokenAdmin, err)
+	}
+	if _, ok := params.Action.requiredRole(); !ok {
+		return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidTokenAdmin, params.Action)
+	}
+	if params.Action == ActionGrantRole || params.Action == ActionRevokeRole {
+		if !params.Role.Valid() {
+			return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidTokenAdmin, params.Role)
+		}
+	} else if params.Role != "" {
+		return nil, fmt.Errorf("%w: %s takes no role", ErrInvalidTokenAdmin, params.Action)
+	}
+	if params.Action.hasAccount() != (tx.To != "") {
+		return nil, fmt.Errorf("%w: %s must name an account exactly when it affects one", ErrInvalidTokenAdmin, params.Action)
+	}
+	if !tx.Amount.IsZero() {
+		return nil, fmt.Errorf("%w: amount must be zero", ErrInvalidTokenAdmin)
+	}
+	return &params, nil
+}
+
+// NewTokenCreateTransaction builds an unsigned transaction that issues a token
+func NewTokenCreateTransaction(from, symbol string, params TokenCreateParams, initialSupply uint256.Int) (*Transaction, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(TokenCreate, from, "", initialSupply, nil)
+	tx.TokenID = symbol
+	tx.Data = data
+	tx.GasLimit = IntrinsicGas(tx)
+	tx.ID = tx.CalculateHash()
+	return tx, nil
+}
+
+// decodeTokenCreate parses and checks the parameters of a TokenCreate transaction
+func decodeTokenCreate(tx *Transaction) (*TokenCreateParams, error) {
+	var params TokenCreateParams
+	if err := json.Unmarshal(tx.Data, &params); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTokenParams, err)
+	}
+	if !validTokenSymbol(tx.TokenID) {
+		return nil, fmt.Errorf("%w: symbol must be 1-%d upper case letters or digits", ErrInvalidTokenParams, MaxTokenSymbolLength)
+	}
+	if params.Name == "" || len(params.Name) > MaxTokenNameLength {
+		return nil, fmt.Errorf("%w: name must be 1-%d bytes", ErrInvalidTokenParams, MaxTokenNameLength)
+	}
+	if params.Decimals > MaxTokenDecimals {
+		return nil, fmt.Errorf("%w: decimals must be at most %d", ErrInvalidTokenParams, MaxTokenDecimals)
+	}
+	if !params.MaxSupply.IsZero() && tx.Amount.Gt(params.MaxSupply) {
+		return nil, fmt.Errorf("%w: initial supply %s exceeds max supply %s", ErrInvalidTokenParams, tx.Amount, params.MaxSupply)
+	}
+	return &params, nil
+}
+
+func validTokenSymbol(symbol string) bool {
+	if symbol == "" || len(symbol) > MaxTokenSymbolLength {
+		return false
+	}
+	for _, c := range symbol {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// hasRecipient reports whether transactions of txType must name a recipient in To
+func hasRecipient(txType int) bool {
+	switch txType {
+	case TokenBurn, TokenCreate, TokenAdmin, CollectionCreate, ItemBurn, ItemApprove, ContractDeploy,
+		EscrowAction, AddLiquidity, RemoveLiquidity, Swap, OTCCreate, OTCAction, MultisigCreate, MultisigAction:
+		return false
+	}
+	return true
+}
+
+// checkFields runs the per-type checks that need no chain state
+func checkFields(tx *Transaction) error {
+	if tx.From == "" {
+		return fmt.Errorf("%w: missing sender", ErrInvalidTxFields)
+	}
+	if hasRecipient(tx.Type) && tx.To == "" {
+		return fmt.Errorf("%w: missing recipient", ErrInvalidTxFields)
+	}
+
+	switch tx.Type {
+	case RegularTransfer, TokenTransfer, StakeDeposit, StakeWithdraw, TokenMint, TokenBurn:
+		if tx.Amount.IsZero() {
+			return fmt.Errorf("%w: amount must be positive", ErrInvalidTxFields)
+		}
+	case TokenCreate:
+		_, err := decodeTokenCreate(tx)
+		return err
+	case TokenAdmin:
+		if tx.TokenID == "" {
+			return fmt.Errorf("%w: missing token", ErrInvalidTxFields)
+		}
+		_, err := decodeTokenAdmin(tx)
+		return err
+	case TokenVest:
+		if _, err := decodeTokenVest(tx, 0, 0); err != nil {
+			return err
+		}
+	case TokenPermit:
+		if _, err := decodeTokenPermit(tx); err != nil {
+			return err
+		}
+	case CollectionCreate:
+		_, err := decodeCollectionCreate(tx)
+		return err
+	case ItemMint, ItemTransfer, ItemBurn, ItemApprove:
+		if _, err := decodeItem(tx); err != nil {
+			return err
+		}
+	case ContractDeploy:
+		_, _, err := decodeContractDeploy(tx)
+		return err
+	case SmartContractCall:
+		_, err := decodeContractCall(tx)
+		return err
+	case EscrowCreate:
+		if _, err := decodeEscrowCreate(tx); err != nil {
+			return err
+		}
+	case EscrowAction:
+		_, err := decodeEscrowAction(tx)
+		return err
+	case AddLiquidity, RemoveLiquidity:
+		if _, err := decodeLiquidity(tx); err != nil {
+			return err
+		}
+	case Swap:
+		if _, err := decodeSwap(tx); err != nil {
+			return err
+		}
+	case OTCCreate:
+		if _, err := decodeOTCCreate(tx); err != nil {
+			return err
+		}
+	case OTCAction:
+		_, err := decodeOTCAction(tx)
+		return err
+	case MultisigCreate:
+		_, err := decodeMultisigCreate(tx)
+		return err
+	case MultisigAction:
+		params, err := decodeMultisigAction(tx)
+		if err != nil || params.Action == MultisigApprove {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %d", ErrInvalidTxFields, tx.Type)
+	}
+	if tx.Type != RegularTransfer && tx.TokenID == "" {
+		return fmt.Errorf("%w: missing token", ErrInvalidTxFields)
+	}
+	return checkStakeToken(tx)
+}
+
+// checkStakeToken rejects stake deposits and withdrawals of any token but the
+// fee token. Stake is recorded as a single amount per validator, so staking
+// another token would mint stake from a token anyone can issue.
+func checkStakeToken(tx *Transaction) error {
+	if (tx.Type == StakeDeposit || tx.Type == StakeWithdraw) && tx.TokenID != FeeToken {
+		return fmt.Errorf("%w: only %s can be staked", ErrInvalidTxFields, FeeToken)
+	}
+	return nil
+}
+
+// checkTokenAction runs the checks of mint, burn, create, admin and permit
+// transactions that depend on chain state. A permit is checked against the
+// tip's time. Caller must hold bc.mu.
+func (bc *Blockchain) checkTokenAction(tx *Transaction) error {
+	switch tx.Type {
+	case TokenMint:
+		tk, exists := bc.TokenRegistry[tx.TokenID]
+		if !exists {
+			return fmt.Errorf("token %s not found", tx.TokenID)
+		}
+		if authority := tk.MintAuthority(); authority == "" || authority != tx.From {
+			return fmt.Errorf("%w: %s", ErrNotMintAuthority, tx.TokenID)
+		}
+		return tk.CheckMovement("", tx.To)
+	case TokenBurn:
+		tk, exists := bc.TokenRegistry[tx.TokenID]
+		if !exists {
+			return fmt.Errorf("token %s not found", tx.TokenID)
+		}
+		if err := tk.CheckMovement(tx.From, ""); err != nil {
+			return err
+		}
+		if balance, _ := tk.Spendable(tx.From); balance.Lt(tx.Amount) {
+			return fmt.Errorf("insufficient token balance to burn: has %s, needs %s", balance, tx.Amount)
+		}
+	case TokenCreate:
+		return bc.checkSymbolFree(tx.TokenID)
+	case TokenAdmin:
+		tk, exists := bc.TokenRegistry[tx.TokenID]
+		if !exists {
+			return fmt.Errorf("token %s not found", tx.TokenID)
+		}
+		params, err := decodeTokenAdmin(tx)
+		if err != nil {
+			return err
+		}
+		role, _ := params.Action.requiredRole()
+		if holder := tk.RoleHolder(role); holder == "" || holder != tx.From {
+			return fmt.Errorf("%w: %s of %s", token.ErrUnauthorized, role, tx.TokenID)
+		}
+	case TokenPermit:
+		p, err := decodeTokenPermit(tx)
+		if err != nil {
+			return err
+		}
+		_, unix := bc.clock.Now()
+		return bc.checkPermit(p, unix)
+	}
+	return nil
+}
+
+func (bc *Blockchain) applyTokenMint(tx *Transaction) ([]Event, error) {
+	if err := bc.checkTokenAction(tx); err != nil {
+		return nil, err
+	}
+	if err := bc.TokenRegistry[tx.TokenID].MintAs(tx.From, tx.To, tx.Amount); err != nil {
+		return nil, fmt.Errorf("token mint failed: %v", err)
+	}
+
+	fmt.Printf("   ✅ Minted %s %s to %s\n", tx.Amount, tx.TokenID, tx.To)
+	return []Event{{Type: EventMint, TokenID: tx.TokenID, To: tx.To, Amount: tx.Amount}}, nil
+}
+
+func (bc *Blockchain) applyTokenBurn(tx *Transaction) ([]Event, error) {
+	if err := bc.checkTokenAction(tx); err != nil {
+		return nil, err
+	}
+	if err := bc.TokenRegistry[tx.TokenID].Burn(tx.From, tx.Amount); err != nil {
+		return nil, fmt.Errorf("token burn failed: %v", err)
+	}
+
+	fmt.Printf("   🔥 Burned %s %s from %s\n", tx.Amount, tx.TokenID, tx.From)
+	return []Event{{Type: EventBurn, TokenID: tx.TokenID, From: tx.From, Amount: tx.Amount}}, nil
+}
+
+// applyTokenCreate registers a new token, records its mint authority and
+// credits the initial supply. Caller must hold bc.mu.
+func (bc *Blockchain) applyTokenCreate(tx *Transaction) ([]Event, error) {
+	params, err := decodeTokenCreate(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := bc.checkTokenAction(tx); err != nil {
+		return nil, err
+	}
+
+	tk := token.NewTokenWithMaxSupply(params.Name, tx.TokenID, params.Decimals, params.MaxSupply)
+	tk.SetRoleHolder(token.RoleMinter, creationRole(params.MintAuthority, tx.From))
+	tk.SetRoleHolder(token.RoleAdmin, creationRole(params.Admin, tx.From))
+	bc.registerToken(tk)
+
+	var events []Event
+	if !tx.Amount.IsZero() {
+		holder := tx.To
+		if holder == "" {
+			holder = tx.From
+		}
+		if err := tk.Mint(holder, tx.Amount); err != nil {
+			return nil, fmt.Errorf("initial mint failed: %v", err)
+		}
+		events = append(events, Event{Type: EventMint, TokenID: tx.TokenID, To: holder, Amount: tx.Amount})
+	}
+
+	fmt.Printf("   🪙 Created token %s (%s) with mint authority %q\n", tx.TokenID, params.Name, tk.MintAuthority())
+	return events, nil
+}
+
+// creationRole returns who holds a role given at token creation: the sender
+// by default, nobody for "none"
+func creationRole(holder, sender string) string {
+	switch holder {
+	case "":
+		return sender
+	case noMintAuthority:
+		return ""
+	}
+	return holder
+}
+
+// applyTokenAdmin changes a token's roles, pause flag, frozen accounts or
+// blocklist. The token checks that the sender holds the required role.
+func (bc *Blockchain) applyTokenAdmin(tx *Transaction) ([]Event, error) {
+	if err := bc.checkTokenAction(tx); err != nil {
+		return nil, err
+	}
+	params, _ := decodeTokenAdmin(tx)
+	tk := bc.TokenRegistry[tx.TokenID]
+
+	event := Event{TokenID: tx.TokenID, From: tx.From, To: tx.To}
+	var err error
+	switch params.Action {
+	case ActionGrantRole, ActionRevokeRole:
+		event = Event{Type: EventRoleChanged, TokenID: tx.TokenID, From: tk.RoleHolder(params.Role), To: tx.To, Role: string(params.Role)}
+		err = tk.GrantRole(tx.From, params.Role, tx.To)
+	case ActionPause:
+		event.Type = EventPaused
+		err = tk.Pause(tx.From)
+	case ActionUnpause:
+		event.Type = EventUnpaused
+		err = tk.Unpause(tx.From)
+	case ActionFreeze:
+		event.Type = EventFrozen
+		err = tk.Freeze(tx.From, tx.To)
+	case ActionUnfreeze:
+		event.Type = EventUnfrozen
+		err = tk.Unfreeze(tx.From, tx.To)
+	case ActionBlocklist:
+		event.Type = EventBlocklisted
+		err = tk.Blocklist(tx.From, tx.To)
+	case ActionUnblocklist:
+		event.Type = EventUnblocklisted
+		err = tk.Unblocklist(tx.From, tx.To)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("token %s failed: %v", params.Action, err)
+	}
+
+	fmt.Printf("   🛡️ %s %s by %s\n", tx.TokenID, params.Action, tx.From)
+	return []Event{event}, nil
+}
+
+// TokenControls returns a token's role holders, pause flag, frozen accounts and blocklist
+func (bc *Blockchain) TokenControls(symbol string) (*token.Controls, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	tk, exists := bc.TokenRegistry[symbol]
+	if !exists {
+		return nil, fmt.Errorf("token %s not found", symbol)
+	}
+	return tk.Controls(), nil
+}
+
+// applyTokenVest moves the amount to the beneficiary and locks it under the
+// schedule. A schedule without a start begins at the block being applied.
+func (bc *Blockchain) applyTokenVest(tx *Transaction) ([]Event, error) {
+	tk, exists := bc.TokenRegistry[tx.TokenID]
+	if !exists {
+		return nil, fmt.Errorf("token %s not found", tx.TokenID)
+	}
+	height, unix := bc.clock.Now()
+	schedule, err := decodeTokenVest(tx, height, unix)
+	if err != nil {
+		return nil, err
+	}
+	if err := tk.CreateVesting(tx.From, *schedule); err != nil {
+		return nil, fmt.Errorf("vesting failed: %v", err)
+	}
+
+	fmt.Printf("   🔒 Vesting %s %s from %s to %s over %d (%s)\n", tx.Amount, tx.TokenID, tx.From, tx.To, schedule.Duration, schedule.Basis)
+	return []Event{{Type: EventVest, TokenID: tx.TokenID, From: tx.From, To: tx.To, Amount: tx.Amount}}, nil
+}
+
+// VestingStatus returns the vested and unvested parts of an account's balance
+// of a token as of the tip
+func (bc *Blockchain) VestingStatus(symbol, address string) (*token.VestingStatus, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	tk, exists := bc.TokenRegistry[symbol]
+	if !exists {
+		return nil, fmt.Errorf("token %s not found", symbol)
+	}
+	return tk.VestingStatus(address)
+}
+
+// registerToken adds a token created by a transaction to the registry. While
+// a block is applied, the token is journaled so a reorg removes it again.
+// Caller must hold bc.mu.
+func (bc *Blockchain) registerToken(tk *token.Token) {
+	bc.TokenRegistry[tk.Symbol] = tk
+	bc.attachToken(tk)
+	if bc.undo != nil {
+		bc.undo.createdTokens = append(bc.undo.createdTokens, tk.Symbol)
+		tk.SetJournal(bc.undo)
+	}
+}
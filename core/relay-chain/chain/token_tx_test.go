@@ -0,0 +1,323 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func signedTokenCreate(t *testing.T, key *btcec.PrivateKey, nonce uint64, symbol string, params TokenCreateParams, supply uint64) *Transaction {
+	tx, err := NewTokenCreateTransaction("", symbol, params, uint256.NewInt(supply))
+	assert.Nil(t, err)
+	tx.Nonce = nonce
+	signTestTx(t, tx, key)
+	return tx
+}
+
+func signedTokenTx(t *testing.T, key *btcec.PrivateKey, txType int, nonce uint64, symbol, to string, amount uint64) *Transaction {
+	tx := NewTransaction(txType, "", to, uint256.NewInt(amount), nil)
+	tx.TokenID = symbol
+	tx.Nonce = nonce
+	signTestTx(t, tx, key)
+	return tx
+}
+
+func receiptStatus(t *testing.T, bc *Blockchain, tx *Transaction) ReceiptStatus {
+	receipt, err := bc.GetReceipt(tx.ID)
+	assert.Nil(t, err)
+	return receipt.Status
+}
+
+func TestTokenLifecycle(t *testing.T) {
+	bc, key := newTestChain(t)
+	issuer := PublicKeyToAddress(key.PubKey())
+	other, _ := btcec.NewPrivateKey()
+
+	create := signedTokenCreate(t, key, 0, "GOLD", TokenCreateParams{Name: "Gold", Decimals: 6, MaxSupply: uint256.NewInt(1000)}, 500)
+	assert.True(t, bc.AddBlock(nextBlock(bc, create)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, create))
+
+	gold := bc.TokenRegistry["GOLD"]
+	assert.NotNil(t, gold)
+	assert.Equal(t, issuer, gold.MintAuthority())
+	balance, _ := gold.BalanceOf(issuer)
+	assert.Equal(t, uint256.NewInt(500), balance)
+
+	mint := signedTokenTx(t, key, TokenMint, 1, "GOLD", "0xBob", 200)
+	burn := signedTokenTx(t, key, TokenBurn, 2, "GOLD", "", 100)
+	assert.True(t, bc.AddBlock(nextBlock(bc, mint, burn)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, mint))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, burn))
+
+	balance, _ = gold.BalanceOf("0xBob")
+	assert.Equal(t, uint256.NewInt(200), balance)
+	balance, _ = gold.BalanceOf(issuer)
+	assert.Equal(t, uint256.NewInt(400), balance)
+	assert.Equal(t, uint256.NewInt(600), gold.TotalSupply())
+
+	t.Run("Only the mint authority mints", func(t *testing.T) {
+		assert.Nil(t, bc.TokenRegistry["BHX"].Mint(PublicKeyToAddress(other.PubKey()), uint256.NewInt(100)))
+		forged := signedTokenTx(t, other, TokenMint, 0, "GOLD", "0xBob", 1)
+		assert.ErrorIs(t, bc.ValidateTransaction(forged), ErrNotMintAuthority)
+
+		assert.True(t, bc.AddBlock(nextBlock(bc, forged)))
+		assert.Equal(t, ReceiptFailed, receiptStatus(t, bc, forged))
+		assert.Equal(t, uint256.NewInt(600), gold.TotalSupply())
+	})
+
+	t.Run("Max supply holds", func(t *testing.T) {
+		over := signedTokenTx(t, key, TokenMint, 3, "GOLD", "0xBob", 500)
+		assert.True(t, bc.AddBlock(nextBlock(bc, over)))
+		assert.Equal(t, ReceiptFailed, receiptStatus(t, bc, over))
+	})
+
+	t.Run("Symbols are unique", func(t *testing.T) {
+		again := signedTokenCreate(t, key, 4, "GOLD", TokenCreateParams{Name: "Gold"}, 0)
+		assert.ErrorIs(t, bc.ValidateTransaction(again), ErrTokenExists)
+	})
+}
+
+func TestTokenCreateReverts(t *testing.T) {
+	bc, key := newTestChain(t)
+	root := bc.currentStateTree().Root()
+
+	create := signedTokenCreate(t, key, 0, "GOLD", TokenCreateParams{Name: "Gold", MintAuthority: noMintAuthority}, 500)
+	assert.True(t, bc.AddBlock(nextBlock(bc, create)))
+	assert.Equal(t, "", bc.TokenRegistry["GOLD"].MintAuthority())
+
+	_, err := bc.disconnectTip()
+	assert.Nil(t, err)
+	assert.NotContains(t, bc.TokenRegistry, "GOLD")
+	assert.Equal(t, root, bc.currentStateTree().Root())
+}
+
+func TestOnlyFeeTokenCanBeStaked(t *testing.T) {
+	bc, key := newTestChain(t)
+	attacker := PublicKeyToAddress(key.PubKey())
+	assert.Nil(t, bc.TokenRegistry["BHX"].Mint("staking_contract", uint256.NewInt(5000)))
+
+	// Stake a self-issued token, then withdraw the same amount as BHX
+	create := signedTokenCreate(t, key, 0, "FAKE", TokenCreateParams{Name: "Fake"}, 4000)
+	deposit := signedTokenTx(t, key, StakeDeposit, 1, "FAKE", "staking_contract", 4000)
+	withdraw := signedTokenTx(t, key, StakeWithdraw, 2, "BHX", "staking_contract", 4000)
+	assert.ErrorIs(t, bc.ValidateTransaction(deposit), ErrInvalidTxFields)
+
+	assert.True(t, bc.AddBlock(nextBlock(bc, create, deposit, withdraw)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, create))
+	assert.Equal(t, ReceiptFailed, receiptStatus(t, bc, deposit))
+	assert.Equal(t, ReceiptFailed, receiptStatus(t, bc, withdraw))
+	assert.True(t, bc.StakeLedger.GetStake(attacker).IsZero())
+	assert.Equal(t, uint256.NewInt(5000), bhxBalance(bc, "staking_contract"))
+}
+
+func TestCheckFields(t *testing.T) {
+	valid := TokenCreateParams{Name: "Gold", Decimals: 6, MaxSupply: uint256.NewInt(100)}
+	create := func(symbol string, params TokenCreateParams, supply uint64) *Transaction {
+		tx, err := NewTokenCreateTransaction("0xAlice", symbol, params, uint256.NewInt(supply))
+		assert.Nil(t, err)
+		return tx
+	}
+
+	assert.Nil(t, checkFields(create("GOLD", valid, 100)))
+	assert.Nil(t, checkFields(&Transaction{Type: TokenBurn, From: "0xAlice", Amount: uint256.NewInt(1), TokenID: "GOLD"}))
+
+	for name, tx := range map[string]*Transaction{
+		"Transfer without recipient": {Type: TokenTransfer, From: "0xAlice", Amount: uint256.NewInt(1), TokenID: "BHX"},
+		"Mint of nothing":            {Type: TokenMint, From: "0xAlice", To: "0xBob", TokenID: "GOLD"},
+		"Burn without token":         {Type: TokenBurn, From: "0xAlice", Amount: uint256.NewInt(1)},
+		"Lower case symbol":          create("gold", valid, 0),
+		"Long symbol":                create("GOLDGOLDGOLD", valid, 0),
+		"Unnamed token":              create("GOLD", TokenCreateParams{}, 0),
+		"Too many decimals":          create("GOLD", TokenCreateParams{Name: "Gold", Decimals: 19}, 0),
+		"Supply above max":           create("GOLD", valid, 101),
+		"Unknown type":               {Type: 99, From: "0xAlice", To: "0xBob", Amount: uint256.NewInt(1)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.NotNil(t, checkFields(tx))
+		})
+	}
+
+	assert.ErrorIs(t, checkFields(&Transaction{Type: SmartContractCall, From: "0xAlice", To: "0xContract"}), ErrInvalidContractTx)
+}
+
+func signedTokenAdmin(t *testing.T, key *btcec.PrivateKey, nonce uint64, symbol, account string, params TokenAdminParams) *Transaction {
+	tx, err := NewTokenAdminTransaction("", symbol, account, params)
+	assert.Nil(t, err)
+	tx.Nonce = nonce
+	signTestTx(t, tx, key)
+	return tx
+}
+
+func TestTokenAdmin(t *testing.T) {
+	bc, key := newTestChain(t)
+	issuer := PublicKeyToAddress(key.PubKey())
+	holderKey, _ := btcec.NewPrivateKey()
+	holder := PublicKeyToAddress(holderKey.PubKey())
+	assert.Nil(t, bc.TokenRegistry["BHX"].Mint(holder, uint256.NewInt(100)))
+
+	create := signedTokenCreate(t, key, 0, "USDX", TokenCreateParams{Name: "Dollar"}, 500)
+	grantPauser := signedTokenAdmin(t, key, 1, "USDX", issuer, TokenAdminParams{Action: ActionGrantRole, Role: token.RolePauser})
+	grantFreezer := signedTokenAdmin(t, key, 2, "USDX", issuer, TokenAdminParams{Action: ActionGrantRole, Role: token.RoleFreezer})
+	fund := signedTokenTx(t, key, TokenTransfer, 3, "USDX", holder, 100)
+	assert.True(t, bc.AddBlock(nextBlock(bc, create, grantPauser, grantFreezer, fund)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, fund))
+
+	usdx := bc.TokenRegistry["USDX"]
+	assert.Equal(t, issuer, usdx.RoleHolder(token.RoleAdmin))
+	assert.Equal(t, issuer, usdx.RoleHolder(token.RolePauser))
+
+	receipt, err := bc.GetReceipt(grantPauser.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, Event{Type: EventRoleChanged, TokenID: "USDX", To: issuer, Role: "pauser"}, receipt.Events[len(receipt.Events)-1])
+
+	t.Run("Only role holders act", func(t *testing.T) {
+		pause := signedTokenAdmin(t, holderKey, 0, "USDX", "", TokenAdminParams{Action: ActionPause})
+		assert.ErrorIs(t, bc.ValidateTransaction(pause), token.ErrUnauthorized)
+
+		grant := signedTokenAdmin(t, holderKey, 0, "USDX", holder, TokenAdminParams{Action: ActionGrantRole, Role: token.RoleMinter})
+		assert.ErrorIs(t, bc.ValidateTransaction(grant), token.ErrUnauthorized)
+	})
+
+	t.Run("Frozen accounts cannot send", func(t *testing.T) {
+		freeze := signedTokenAdmin(t, key, 4, "USDX", holder, TokenAdminParams{Action: ActionFreeze})
+		assert.True(t, bc.AddBlock(nextBlock(bc, freeze)))
+		assert.True(t, usdx.IsFrozen(holder))
+
+		send := signedTokenTx(t, holderKey, TokenTransfer, 0, "USDX", issuer, 10)
+		assert.ErrorIs(t, bc.ValidateTransaction(send), token.ErrFrozen)
+		assert.Nil(t, usdx.CheckMovement(issuer, holder), "frozen accounts still receive")
+
+		unfreeze := signedTokenAdmin(t, key, 5, "USDX", holder, TokenAdminParams{Action: ActionUnfreeze})
+		assert.True(t, bc.AddBlock(nextBlock(bc, unfreeze)))
+		assert.Nil(t, usdx.CheckMovement(holder, issuer))
+	})
+
+	t.Run("Blocklisted accounts cannot receive", func(t *testing.T) {
+		blocklist := signedTokenAdmin(t, key, 6, "USDX", "0xBob", TokenAdminParams{Action: ActionBlocklist})
+		send := signedTokenTx(t, key, TokenTransfer, 7, "USDX", "0xBob", 10)
+		assert.True(t, bc.AddBlock(nextBlock(bc, blocklist, send)))
+		assert.Equal(t, ReceiptFailed, receiptStatus(t, bc, send))
+		balance, _ := usdx.BalanceOf("0xBob")
+		assert.True(t, balance.IsZero())
+	})
+
+	t.Run("Pause stops transfers and reverts with the block", func(t *testing.T) {
+		root := bc.currentStateTree().Root()
+		pause := signedTokenAdmin(t, key, 8, "USDX", "", TokenAdminParams{Action: ActionPause})
+		assert.True(t, bc.AddBlock(nextBlock(bc, pause)))
+		assert.True(t, usdx.Paused())
+		assert.NotEqual(t, root, bc.currentStateTree().Root())
+
+		send := signedTokenTx(t, key, TokenTransfer, 9, "USDX", holder, 10)
+		assert.ErrorIs(t, bc.ValidateTransaction(send), token.ErrPaused)
+
+		_, err := bc.disconnectTip()
+		assert.Nil(t, err)
+		assert.False(t, usdx.Paused())
+		assert.Equal(t, root, bc.currentStateTree().Root())
+	})
+	assertStateTreeMatches(t, bc)
+}
+
+func TestDecodeTokenAdmin(t *testing.T) {
+	admin := func(account string, params TokenAdminParams) *Transaction {
+		tx, err := NewTokenAdminTransaction("0xAlice", "GOLD", account, params)
+		assert.Nil(t, err)
+		return tx
+	}
+
+	assert.Nil(t, checkFields(admin("0xBob", TokenAdminParams{Action: ActionGrantRole, Role: token.RoleMinter})))
+	assert.Nil(t, checkFields(admin("", TokenAdminParams{Action: ActionRevokeRole, Role: token.RoleMinter})))
+	assert.Nil(t, checkFields(admin("", TokenAdminParams{Action: ActionPause})))
+
+	for name, tx := range map[string]*Transaction{
+		"Unknown action":       admin("0xBob", TokenAdminParams{Action: "seize"}),
+		"Unknown role":         admin("0xBob", TokenAdminParams{Action: ActionGrantRole, Role: "owner"}),
+		"Grant without holder": admin("", TokenAdminParams{Action: ActionGrantRole, Role: token.RolePauser}),
+		"Pause with account":   admin("0xBob", TokenAdminParams{Action: ActionPause}),
+		"Freeze with role":     admin("0xBob", TokenAdminParams{Action: ActionFreeze, Role: token.RoleFreezer}),
+		"Freeze nobody":        admin("", TokenAdminParams{Action: ActionFreeze}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, checkFields(tx), ErrInvalidTokenAdmin)
+		})
+	}
+}
+
+func TestTokenVesting(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+	issuer := PublicKeyToAddress(key.PubKey())
+	teamKey, _ := btcec.NewPrivateKey()
+	team := PublicKeyToAddress(teamKey.PubKey())
+
+	g := testGenesis(t)
+	g.Tokens[0].Balances[issuer] = uint256.NewInt(1000)
+	validator := g.Validators[0].Address
+
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	bc, err := openChain(db, g, DefaultHistoryConfig())
+	assert.Nil(t, err)
+
+	create := signedTokenCreate(t, key, 0, "GOLD", TokenCreateParams{Name: "Gold"}, 500)
+	assert.True(t, bc.AddBlock(blockBy(bc, validator, create)))
+	createdRoot := bc.StateRoot()
+
+	// 400 GOLD vest linearly over 4 blocks from height 2, with a 2 block cliff
+	vest, err := NewTokenVestTransaction("", team, "GOLD", uint256.NewInt(400), VestingParams{Basis: token.VestByHeight, Cliff: 2, Duration: 4})
+	assert.Nil(t, err)
+	vest.Nonce = 1
+	signTestTx(t, vest, key)
+	gas := signedTokenTx(t, key, TokenTransfer, 2, "BHX", team, 100)
+	assert.True(t, bc.AddBlock(blockBy(bc, validator, vest, gas)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, vest))
+	assert.Equal(t, uint256.NewInt(400), goldBalance(bc, team), "locked tokens still count in the balance")
+
+	early := signedTokenTx(t, teamKey, TokenTransfer, 0, "GOLD", "0xBob", 1)
+	assert.NotNil(t, bc.ValidateTransaction(early))
+
+	assert.True(t, bc.AddBlock(blockBy(bc, validator)))
+	assert.True(t, bc.AddBlock(blockBy(bc, validator)))
+	status, err := bc.VestingStatus("GOLD", team)
+	assert.Nil(t, err)
+	assert.Equal(t, uint256.NewInt(200), status.Vested)
+	assert.Equal(t, uint256.NewInt(200), status.Unvested)
+	assert.Equal(t, uint256.NewInt(200), status.Spendable)
+
+	// At height 5 three quarters have vested
+	spend := signedTokenTx(t, teamKey, TokenTransfer, 0, "GOLD", "0xBob", 300)
+	assert.True(t, bc.AddBlock(blockBy(bc, validator, spend)))
+	assert.Equal(t, ReceiptSuccess, receiptStatus(t, bc, spend))
+	overspend := signedTokenTx(t, teamKey, TokenTransfer, 1, "GOLD", "0xBob", 1)
+	assert.NotNil(t, bc.ValidateTransaction(overspend))
+
+	t.Run("Schedules survive restarts", func(t *testing.T) {
+		restarted, err := openChain(db, g, DefaultHistoryConfig())
+		assert.Nil(t, err)
+		assert.Equal(t, bc.StateRoot(), restarted.StateRoot())
+
+		again, err := restarted.VestingStatus("GOLD", team)
+		assert.Nil(t, err)
+		assert.Equal(t, uint256.NewInt(100), again.Balance)
+		assert.Equal(t, uint256.NewInt(100), again.Unvested)
+		assert.True(t, again.Spendable.IsZero())
+	})
+
+	t.Run("Disconnecting the grant removes the schedule", func(t *testing.T) {
+		for len(bc.Blocks) > 2 {
+			_, err := bc.disconnectTip()
+			assert.Nil(t, err)
+		}
+		assert.Empty(t, bc.TokenRegistry["GOLD"].Vesting())
+		assert.Equal(t, createdRoot, bc.StateRoot())
+	})
+	assertStateTreeMatches(t, bc)
+}
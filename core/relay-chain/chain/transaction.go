@@ -1,17 +1,12 @@
 package chain
 
 import (
-	"crypto/ecdsa"
-	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"log"
-	"math/big"
 	"time"
 
-	// "github.com/Shivam-Patel-G/blackhole-blockchain/relay-chain/crypto"
-	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
 const (
@@ -22,14 +17,34 @@ const (
 	StakeDeposit
 	StakeWithdraw
 	SmartContractCall
+	TokenCreate
+	TokenAdmin
+	TokenVest
+	TokenPermit
+	CollectionCreate
+	ItemMint
+	ItemTransfer
+	ItemBurn
+	ItemApprove
+	ContractDeploy
+	EscrowCreate
+	EscrowAction
+	AddLiquidity
+	RemoveLiquidity
+	Swap
+	OTCCreate
+	OTCAction
+	MultisigCreate
+	MultisigAction
 )
 
 type Transaction struct {
 	ID        string
+	ChainID   uint64 // Network the transaction is valid on, part of the signed payload
 	Type      int
 	From      string
 	To        string
-	Amount    uint64
+	Amount    uint256.Int
 	TokenID   string // Add token identifier
 	Data      []byte // For staking parameters or contract calls
 	Timestamp int64
@@ -41,13 +56,15 @@ type Transaction struct {
 	PublicKey []byte
 }
 
-func (tx *Transaction) Serialize() (any, any) {
-	panic("unimplemented")
+// Serialize returns the canonical wire encoding of the transaction
+func (tx *Transaction) Serialize() []byte {
+	return tx.Encode()
 }
 
-func NewTransaction(txType int, from, to string, amount uint64, publicKey []byte) *Transaction {
+func NewTransaction(txType int, from, to string, amount uint256.Int, publicKey []byte) *Transaction {
 	tx := &Transaction{
 		ID:        "",
+		ChainID:   DefaultChainID,
 		Type:      txType,
 		From:      from,
 		To:        to,
@@ -57,89 +74,29 @@ func NewTransaction(txType int, from, to string, amount uint64, publicKey []byte
 		Timestamp: time.Now().Unix(),
 		Nonce:     0,
 		Fee:       0,
-		GasLimit:  0,
-		GasPrice:  0,
+		GasPrice:  DefaultFeeConfig().MinGasPrice,
 		PublicKey: publicKey, // ✅ include the public key
 	}
+	tx.GasLimit = IntrinsicGas(tx)
 	tx.ID = tx.CalculateHash()
 	return tx
 }
 
+// CalculateHash returns the transaction ID: the SHA-256 of its signing payload
 func (tx *Transaction) CalculateHash() string {
-	data, _ := json.Marshal(struct {
-		Type      int
-		From      string
-		To        string
-		Amount    uint64
-		TokenID   string
-		Data      []byte
-		Nonce     uint64
-		Timestamp int64
-		PublicKey []byte
-	}{
-		tx.Type,
-		tx.From,
-		tx.To,
-		tx.Amount,
-		tx.TokenID,
-		tx.Data,
-		tx.Nonce,
-		tx.Timestamp,
-		tx.PublicKey, // ✅ pass actual value
-	})
-	hash := sha256.Sum256(data)
+	hash := sha256.Sum256(tx.SigningBytes())
 	return hex.EncodeToString(hash[:])
 }
 
-func (tx *Transaction) Sign(privateKey *ecdsa.PrivateKey) error {
-	hash := tx.CalculateHash()
-	r, s, err := ecdsa.Sign(rand.Reader, privateKey, []byte(hash))
-	if err != nil {
-		return err
-	}
-
-	signature := append(r.Bytes(), s.Bytes()...)
-	tx.Signature = signature
-	return nil
-}
-
+// Verify reports whether the transaction may be applied: system transactions
+// are unsigned, every other transaction must carry a valid signature from From
 func (tx *Transaction) Verify() bool {
-	if tx.From == "system" && tx.Type == TokenTransfer {
-		log.Println("Info: System token transfer - auto verified")
+	if isSystemSender(tx.From) {
 		return true
 	}
-
-	if tx.Signature == nil || len(tx.Signature) == 0 {
-		log.Println("Error: Empty signature")
-		return false
-	}
-
-	publicKey, err := btcec.ParsePubKey(tx.PublicKey)
-	if err != nil {
-		log.Println("❌ Failed to parse public key:", err)
+	if err := tx.VerifySignature(); err != nil {
+		log.Printf("❌ Transaction %s failed verification: %v", tx.ID, err)
 		return false
 	}
-	log.Println("Info: Public key parsed successfully")
-
-	hashHex := tx.CalculateHash()
-	log.Printf("Info: Transaction hash (hex): %s\n", hashHex)
-
-	hashBytes, err := hex.DecodeString(hashHex)
-	if err != nil {
-		log.Println("❌ Failed to decode hash hex string:", err)
-		return false
-	}
-
-	r := big.Int{}
-	s := big.Int{}
-	sigLen := len(tx.Signature)
-	r.SetBytes(tx.Signature[:sigLen/2])
-	s.SetBytes(tx.Signature[sigLen/2:])
-	log.Printf("Info: Signature components r: %s, s: %s\n", r.String(), s.String())
-
-	ecdsaPubKey := publicKey.ToECDSA()
-
-	verified := ecdsa.Verify(ecdsaPubKey, hashBytes, &r, &s)
-	log.Printf("Info: Signature verification result: %v\n", verified)
-	return verified
+	return true
 }
@@ -0,0 +1,263 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// The transaction indexes live in the block store next to the blocks:
+//
+//	txindex:<tx id>                          -> TxLocation as JSON
+//	addrtx:<address>:<height>:<position>     -> tx id, for the sender and the recipient
+//	tokentx:<symbol>:<height>:<position>     -> tx id, for token transfers, mints and burns
+//	index:height                             -> height the indexes are built to
+//
+// Heights and positions are zero-padded so entries iterate in chain order.
+
+// Page sizes for index queries
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+var (
+	ErrTxNotFound    = errors.New("transaction not found")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+var indexHeightKey = []byte("index:height")
+
+// TxLocation is where a transaction sits on the main chain
+type TxLocation struct {
+	BlockHeight uint64 `json:"block_height"`
+	BlockHash   string `json:"block_hash"`
+	Index       int    `json:"index"`
+}
+
+// IndexedTx is a transaction found through an index, with its outcome
+type IndexedTx struct {
+	TxLocation
+	Transaction *Transaction  `json:"transaction"`
+	Status      ReceiptStatus `json:"status"`
+}
+
+// TxPage is one page of an index query, newest first. NextCursor is empty on
+// the last page.
+type TxPage struct {
+	Transactions []*IndexedTx `json:"transactions"`
+	NextCursor   string       `json:"next_cursor,omitempty"`
+}
+
+func txIndexKey(txID string) []byte {
+	return []byte("txindex:" + txID)
+}
+
+func addressIndexPrefix(address string) []byte {
+	return []byte("addrtx:" + address + ":")
+}
+
+func tokenIndexPrefix(symbol string) []byte {
+	return []byte("tokentx:" + symbol + ":")
+}
+
+// positionSuffix orders index entries by height, then position in the block.
+// It is also the cursor handed out to clients.
+func positionSuffix(height uint64, index int) string {
+	return fmt.Sprintf("%020d:%06d", height, index)
+}
+
+var positionSuffixLen = len(positionSuffix(0, 0))
+
+// isTokenMovement reports whether tx belongs in the index of its token or collection
+func isTokenMovement(tx *Transaction) bool {
+	switch tx.Type {
+	case TokenTransfer, TokenMint, TokenBurn, TokenCreate, TokenAdmin, TokenVest, TokenPermit,
+		CollectionCreate, ItemMint, ItemTransfer, ItemBurn, ItemApprove,
+		EscrowCreate, AddLiquidity, RemoveLiquidity, Swap, OTCCreate:
+		return tx.TokenID != ""
+	}
+	return false
+}
+
+// indexBlock adds index entries for every transaction in block to batch
+func indexBlock(batch *leveldb.Batch, block *Block) error {
+	for i, tx := range block.Transactions {
+		location, err := json.Marshal(TxLocation{BlockHeight: block.Header.Index, BlockHash: block.Hash, Index: i})
+		if err != nil {
+			return err
+		}
+		batch.Put(txIndexKey(tx.ID), location)
+
+		suffix := positionSuffix(block.Header.Index, i)
+		for _, address := range txAddresses(tx) {
+			batch.Put(append(addressIndexPrefix(address), suffix...), []byte(tx.ID))
+		}
+		if isTokenMovement(tx) {
+			batch.Put(append(tokenIndexPrefix(tx.TokenID), suffix...), []byte(tx.ID))
+		}
+	}
+	batch.Put(indexHeightKey, []byte(fmt.Sprintf("%d", block.Header.Index)))
+	return nil
+}
+
+// unindexBlock removes the index entries of a disconnected block
+func unindexBlock(batch *leveldb.Batch, block *Block) {
+	for i, tx := range block.Transactions {
+		batch.Delete(txIndexKey(tx.ID))
+
+		suffix := positionSuffix(block.Header.Index, i)
+		for _, address := range txAddresses(tx) {
+			batch.Delete(append(addressIndexPrefix(address), suffix...))
+		}
+		if isTokenMovement(tx) {
+			batch.Delete(append(tokenIndexPrefix(tx.TokenID), suffix...))
+		}
+	}
+	batch.Put(indexHeightKey, []byte(fmt.Sprintf("%d", block.Header.Index-1)))
+}
+
+// txAddresses returns the distinct addresses a transaction touches
+func txAddresses(tx *Transaction) []string {
+	var addresses []string
+	if tx.From != "" {
+		addresses = append(addresses, tx.From)
+	}
+	if tx.To != "" && tx.To != tx.From {
+		addresses = append(addresses, tx.To)
+	}
+	return addresses
+}
+
+// indexedHeight returns the height the stored indexes cover, and false if
+// the store predates them
+func (bc *Blockchain) indexedHeight() (uint64, bool) {
+	data, err := bc.DB.Get(indexHeightKey, nil)
+	if err != nil {
+		return 0, false
+	}
+	var height uint64
+	if _, err := fmt.Sscanf(string(data), "%d", &height); err != nil {
+		return 0, false
+	}
+	return height, true
+}
+
+// GetTransaction looks up a transaction on the main chain by ID
+func (bc *Blockchain) GetTransaction(txID string) (*IndexedTx, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	location, err := bc.txLocation(txID)
+	if err != nil {
+		return nil, err
+	}
+	return bc.indexedTx(txID, location)
+}
+
+// txLocation reads the index entry of a transaction. Caller must hold bc.mu.
+func (bc *Blockchain) txLocation(txID string) (TxLocation, error) {
+	var location TxLocation
+	data, err := bc.DB.Get(txIndexKey(txID), nil)
+	if err == leveldb.ErrNotFound {
+		return location, ErrTxNotFound
+	}
+	if err != nil {
+		return location, err
+	}
+	if err := json.Unmarshal(data, &location); err != nil {
+		return location, fmt.Errorf("corrupt index entry for %s: %v", txID, err)
+	}
+	return location, nil
+}
+
+// GetAddressTransactions returns a page of the transactions an address sent
+// or received, newest first. Pass the previous page's NextCursor to continue.
+func (bc *Blockchain) GetAddressTransactions(address, cursor string, limit int) (*TxPage, error) {
+	return bc.queryIndex(addressIndexPrefix(address), cursor, limit)
+}
+
+// GetTokenTransfers returns a page of the transfers, mints and burns of a
+// token, newest first. Pass the previous page's NextCursor to continue.
+func (bc *Blockchain) GetTokenTransfers(symbol, cursor string, limit int) (*TxPage, error) {
+	return bc.queryIndex(tokenIndexPrefix(symbol), cursor, limit)
+}
+
+// queryIndex walks an index backwards from just before cursor
+func (bc *Blockchain) queryIndex(prefix []byte, cursor string, limit int) (*TxPage, error) {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	keys := util.BytesPrefix(prefix)
+	if cursor != "" {
+		var height uint64
+		var index int
+		if _, err := fmt.Sscanf(cursor, "%d:%d", &height, &index); err != nil || cursor != positionSuffix(height, index) {
+			return nil, ErrInvalidCursor
+		}
+		keys.Limit = append(append([]byte{}, prefix...), cursor...)
+	}
+
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	iter := bc.DB.NewIterator(keys, nil)
+	defer iter.Release()
+
+	page := &TxPage{Transactions: []*IndexedTx{}}
+	for ok := iter.Last(); ok; ok = iter.Prev() {
+		// Skip entries of a longer key that shares this prefix, such as
+		// address "a:b" under the prefix of address "a"
+		suffix := string(iter.Key()[len(prefix):])
+		if len(suffix) != positionSuffixLen {
+			continue
+		}
+		if len(page.Transactions) == limit {
+			page.NextCursor = positionSuffix(page.Transactions[limit-1].BlockHeight, page.Transactions[limit-1].Index)
+			break
+		}
+
+		var location TxLocation
+		if _, err := fmt.Sscanf(suffix, "%d:%d", &location.BlockHeight, &location.Index); err != nil {
+			return nil, fmt.Errorf("corrupt index key %q: %v", iter.Key(), err)
+		}
+		if location.BlockHeight >= uint64(len(bc.Blocks)) {
+			continue
+		}
+		location.BlockHash = bc.Blocks[location.BlockHeight].Hash
+
+		tx, err := bc.indexedTx(string(iter.Value()), location)
+		if err != nil {
+			return nil, err
+		}
+		page.Transactions = append(page.Transactions, tx)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// indexedTx loads the transaction at location and its receipt status. Caller must hold bc.mu.
+func (bc *Blockchain) indexedTx(txID string, location TxLocation) (*IndexedTx, error) {
+	if location.BlockHeight >= uint64(len(bc.Blocks)) {
+		return nil, ErrTxNotFound
+	}
+	block := bc.Blocks[location.BlockHeight]
+	if location.Index < 0 || location.Index >= len(block.Transactions) || block.Transactions[location.Index].ID != txID {
+		return nil, fmt.Errorf("index entry for %s does not match block %d", txID, location.BlockHeight)
+	}
+
+	result := &IndexedTx{TxLocation: location, Transaction: block.Transactions[location.Index]}
+	if receipt, err := bc.GetReceipt(txID); err == nil {
+		result.Status = receipt.Status
+	}
+	return result, nil
+}
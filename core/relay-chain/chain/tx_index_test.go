@@ -0,0 +1,101 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+func TestTransactionIndex(t *testing.T) {
+	bc, key := newTestChain(t)
+	sender := PublicKeyToAddress(key.PubKey())
+
+	first, second, third := signedPayment(t, key, 0, 100), signedPayment(t, key, 1, 200), signedPayment(t, key, 2, 300)
+	assert.True(t, bc.AddBlock(nextBlock(bc, first, second)))
+	assert.True(t, bc.AddBlock(nextBlock(bc, third)))
+
+	t.Run("Lookup by ID", func(t *testing.T) {
+		tx, err := bc.GetTransaction(second.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(1), tx.BlockHeight)
+		assert.Equal(t, 2, tx.Index)
+		assert.Equal(t, bc.Blocks[1].Hash, tx.BlockHash)
+		assert.Equal(t, uint256.NewInt(200), tx.Transaction.Amount)
+		assert.Equal(t, ReceiptSuccess, tx.Status)
+
+		_, err = bc.GetTransaction("missing")
+		assert.ErrorIs(t, err, ErrTxNotFound)
+	})
+
+	t.Run("Both sides of a transfer", func(t *testing.T) {
+		for _, address := range []string{sender, "0xBob"} {
+			page, err := bc.GetAddressTransactions(address, "", 0)
+			assert.Nil(t, err)
+			assert.Len(t, page.Transactions, 3)
+			assert.Empty(t, page.NextCursor)
+		}
+	})
+
+	t.Run("Pages newest first", func(t *testing.T) {
+		var ids []string
+		cursor := ""
+		for {
+			page, err := bc.GetAddressTransactions("0xBob", cursor, 2)
+			assert.Nil(t, err)
+			for _, tx := range page.Transactions {
+				ids = append(ids, tx.Transaction.ID)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			cursor = page.NextCursor
+		}
+		assert.Equal(t, []string{third.ID, second.ID, first.ID}, ids)
+	})
+
+	t.Run("Token transfers", func(t *testing.T) {
+		page, err := bc.GetTokenTransfers("BHX", "", 10)
+		assert.Nil(t, err)
+		assert.Len(t, page.Transactions, 5, "three payments and two rewards")
+
+		page, err = bc.GetTokenTransfers("ABC", "", 10)
+		assert.Nil(t, err)
+		assert.Empty(t, page.Transactions)
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		_, err := bc.GetAddressTransactions("0xBob", "5", 10)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("Disconnected blocks leave the index", func(t *testing.T) {
+		_, err := bc.disconnectTip()
+		assert.Nil(t, err)
+
+		_, err = bc.GetTransaction(third.ID)
+		assert.ErrorIs(t, err, ErrTxNotFound)
+		page, err := bc.GetAddressTransactions("0xBob", "", 10)
+		assert.Nil(t, err)
+		assert.Len(t, page.Transactions, 2)
+
+		height, ok := bc.indexedHeight()
+		assert.True(t, ok)
+		assert.Equal(t, uint64(1), height)
+	})
+}
+
+func TestAddressIndexPrefixIsExact(t *testing.T) {
+	bc, _ := newTestChain(t)
+
+	// Entries of "0xBob:x" share the key prefix of "0xBob" but are not his
+	block := &Block{Header: BlockHeader{Index: 0}, Transactions: []*Transaction{{ID: "aa", From: "0xAlice", To: "0xBob:x"}}}
+	batch := new(leveldb.Batch)
+	assert.Nil(t, indexBlock(batch, block))
+	assert.Nil(t, bc.DB.Write(batch, nil))
+
+	page, err := bc.GetAddressTransactions("0xBob", "", 10)
+	assert.Nil(t, err)
+	assert.Empty(t, page.Transactions)
+}
@@ -1,6 +1,404 @@
 package chain
 
-// TxPool manages the collection of pending transactions
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrTxKnown            = errors.New("transaction already known")
+	ErrTxUnderpriced      = errors.New("transaction underpriced for a full pool")
+	ErrReplaceUnderpriced = errors.New("replacement transaction underpriced")
+	ErrSenderQueueFull    = errors.New("too many queued transactions from sender")
+	ErrNonceTooLow        = errors.New("nonce too low")
+)
+
+// TxPoolConfig holds the mempool limits
+type TxPoolConfig struct {
+	MaxSize         int           // Maximum number of transactions held
+	MaxPerSender    int           // Maximum number of transactions per sender
+	TTL             time.Duration // How long a transaction may wait before eviction
+	PriceBumpPct    uint64        // Minimum price increase (percent) to replace a transaction
+	MaxBlockTxCount int           // Maximum number of pool transactions selected per block
+}
+
+// DefaultTxPoolConfig returns the limits used by nodes
+func DefaultTxPoolConfig() TxPoolConfig {
+	return TxPoolConfig{
+		MaxSize:         5000,
+		MaxPerSender:    64,
+		TTL:             30 * time.Minute,
+		PriceBumpPct:    10,
+		MaxBlockTxCount: 500,
+	}
+}
+
+type pooledTx struct {
+	tx      *Transaction
+	addedAt time.Time
+}
+
+// senderQueue holds one sender's transactions ordered by nonce
+type senderQueue struct {
+	byNonce     map[uint64]*pooledTx
+	unsequenced bool // system senders do not use nonces
+}
+
+// TxPool manages the collection of pending transactions. Transactions are kept
+// per sender; those whose nonces follow the sender's account nonce without a
+// gap are pending and can be included in a block, the rest are future.
 type TxPool struct {
-    Transactions []*Transaction
-}
\ No newline at end of file
+	config      TxPoolConfig
+	all         map[string]*pooledTx
+	senders     map[string]*senderQueue
+	nonceSource func(address string) uint64
+	mu          sync.RWMutex
+}
+
+// NewTxPool creates an empty pool. nonceSource reports the next expected nonce
+// of an account in the current chain state.
+func NewTxPool(config TxPoolConfig, nonceSource func(address string) uint64) *TxPool {
+	return &TxPool{
+		config:      config,
+		all:         make(map[string]*pooledTx),
+		senders:     make(map[string]*senderQueue),
+		nonceSource: nonceSource,
+	}
+}
+
+// txPriority is the price used to order and replace transactions
+func txPriority(tx *Transaction) uint64 {
+	if tx.GasPrice > 0 {
+		return tx.GasPrice
+	}
+	return tx.Fee
+}
+
+func isSystemSender(address string) bool {
+	return address == "system"
+}
+
+// senderKey groups system transactions individually since they carry no nonce
+func senderKey(tx *Transaction) string {
+	if isSystemSender(tx.From) {
+		return "system:" + tx.ID
+	}
+	return tx.From
+}
+
+// Add validates the pool rules and inserts tx
+func (p *TxPool) Add(tx *Transaction) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	p.evictExpired(now)
+
+	if _, exists := p.all[tx.ID]; exists {
+		return ErrTxKnown
+	}
+
+	key := senderKey(tx)
+	queue, exists := p.senders[key]
+	if !exists {
+		queue = &senderQueue{
+			byNonce:     make(map[uint64]*pooledTx),
+			unsequenced: isSystemSender(tx.From),
+		}
+	}
+
+	if !queue.unsequenced && p.nonceSource != nil && tx.Nonce < p.nonceSource(tx.From) {
+		return fmt.Errorf("%w: account nonce is %d, got %d", ErrNonceTooLow, p.nonceSource(tx.From), tx.Nonce)
+	}
+
+	// Replace-by-fee for the same sender and nonce
+	if existing, conflict := queue.byNonce[tx.Nonce]; conflict {
+		oldPrice := txPriority(existing.tx)
+		minPrice := oldPrice + oldPrice*p.config.PriceBumpPct/100
+		if txPriority(tx) <= oldPrice || txPriority(tx) < minPrice {
+			return fmt.Errorf("%w: need at least %d, got %d", ErrReplaceUnderpriced, minPrice, txPriority(tx))
+		}
+		delete(p.all, existing.tx.ID)
+		entry := &pooledTx{tx: tx, addedAt: now}
+		queue.byNonce[tx.Nonce] = entry
+		p.all[tx.ID] = entry
+		fmt.Printf("♻️ Replaced transaction %s with %s (nonce %d)\n", existing.tx.ID, tx.ID, tx.Nonce)
+		return nil
+	}
+
+	if p.config.MaxPerSender > 0 && len(queue.byNonce) >= p.config.MaxPerSender {
+		return ErrSenderQueueFull
+	}
+
+	if p.config.MaxSize > 0 && len(p.all) >= p.config.MaxSize {
+		victim := p.lowestPriced()
+		if victim == nil || txPriority(victim.tx) >= txPriority(tx) {
+			return ErrTxUnderpriced
+		}
+		p.remove(victim.tx)
+		fmt.Printf("🗑️ Evicted transaction %s to make room for %s\n", victim.tx.ID, tx.ID)
+	}
+
+	entry := &pooledTx{tx: tx, addedAt: now}
+	queue.byNonce[tx.Nonce] = entry
+	p.senders[key] = queue
+	p.all[tx.ID] = entry
+	return nil
+}
+
+// Has reports whether the pool holds a transaction
+func (p *TxPool) Has(id string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	_, exists := p.all[id]
+	return exists
+}
+
+// Get returns a pooled transaction by ID
+func (p *TxPool) Get(id string) *Transaction {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if entry, exists := p.all[id]; exists {
+		return entry.tx
+	}
+	return nil
+}
+
+// Len returns the number of pooled transactions
+func (p *TxPool) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.all)
+}
+
+// Stats returns the number of pending and future transactions
+func (p *TxPool) Stats() (int, int) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	pending := 0
+	for key := range p.senders {
+		pending += len(p.pendingFor(key))
+	}
+	return pending, len(p.all) - pending
+}
+
+// NextNonce returns the first nonce after stateNonce that has no pooled
+// transaction from address, i.e. the nonce a new transaction should use
+func (p *TxPool) NextNonce(address string, stateNonce uint64) uint64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	next := stateNonce
+	if queue, exists := p.senders[address]; exists && !queue.unsequenced {
+		for {
+			if _, pooled := queue.byNonce[next]; !pooled {
+				break
+			}
+			next++
+		}
+	}
+	return next
+}
+
+// Transactions returns every pooled transaction ordered by sender and nonce
+func (p *TxPool) Transactions() []*Transaction {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	keys := make([]string, 0, len(p.senders))
+	for key := range p.senders {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	txs := make([]*Transaction, 0, len(p.all))
+	for _, key := range keys {
+		queue := p.senders[key]
+		for _, nonce := range queue.sortedNonces() {
+			txs = append(txs, queue.byNonce[nonce].tx)
+		}
+	}
+	return txs
+}
+
+// Select builds a block template: the highest priced pending transactions,
+// taking each sender's transactions in nonce order
+func (p *TxPool) Select(max int) []*Transaction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.evictExpired(time.Now())
+	if max <= 0 || (p.config.MaxBlockTxCount > 0 && max > p.config.MaxBlockTxCount) {
+		max = p.config.MaxBlockTxCount
+	}
+
+	candidates := &txHeap{}
+	for key := range p.senders {
+		if pending := p.pendingFor(key); len(pending) > 0 {
+			heap.Push(candidates, pending)
+		}
+	}
+
+	selected := make([]*Transaction, 0)
+	for candidates.Len() > 0 && (max <= 0 || len(selected) < max) {
+		pending := heap.Pop(candidates).([]*pooledTx)
+		selected = append(selected, pending[0].tx)
+		if len(pending) > 1 {
+			heap.Push(candidates, pending[1:])
+		}
+	}
+	return selected
+}
+
+// RemoveTxs drops transactions that were included in a block
+func (p *TxPool) RemoveTxs(txs []*Transaction) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, tx := range txs {
+		if entry, exists := p.all[tx.ID]; exists {
+			p.remove(entry.tx)
+		}
+	}
+}
+
+// Flush empties the pool
+func (p *TxPool) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.all = make(map[string]*pooledTx)
+	p.senders = make(map[string]*senderQueue)
+}
+
+// pendingFor returns the sender's executable transactions. Caller must hold p.mu.
+func (p *TxPool) pendingFor(key string) []*pooledTx {
+	queue := p.senders[key]
+	nonces := queue.sortedNonces()
+	if queue.unsequenced {
+		pending := make([]*pooledTx, 0, len(nonces))
+		for _, nonce := range nonces {
+			pending = append(pending, queue.byNonce[nonce])
+		}
+		return pending
+	}
+
+	if len(nonces) == 0 {
+		return nil
+	}
+	next := nonces[0]
+	if p.nonceSource != nil {
+		next = p.nonceSource(queue.byNonce[nonces[0]].tx.From)
+	}
+
+	pending := make([]*pooledTx, 0)
+	for _, nonce := range nonces {
+		if nonce < next {
+			continue
+		}
+		if nonce != next {
+			break
+		}
+		pending = append(pending, queue.byNonce[nonce])
+		next++
+	}
+	return pending
+}
+
+// lowestPriced picks the eviction victim, preferring future transactions. Caller must hold p.mu.
+func (p *TxPool) lowestPriced() *pooledTx {
+	pending := make(map[string]bool)
+	for key := range p.senders {
+		for _, entry := range p.pendingFor(key) {
+			pending[entry.tx.ID] = true
+		}
+	}
+
+	var victim *pooledTx
+	for _, entry := range p.all {
+		if victim == nil {
+			victim = entry
+			continue
+		}
+		if pending[victim.tx.ID] != pending[entry.tx.ID] {
+			if !pending[entry.tx.ID] {
+				victim = entry
+			}
+			continue
+		}
+		if txPriority(entry.tx) < txPriority(victim.tx) ||
+			(txPriority(entry.tx) == txPriority(victim.tx) && entry.addedAt.After(victim.addedAt)) {
+			victim = entry
+		}
+	}
+	return victim
+}
+
+// evictExpired drops transactions older than the TTL. Caller must hold p.mu.
+func (p *TxPool) evictExpired(now time.Time) {
+	if p.config.TTL <= 0 {
+		return
+	}
+	for _, entry := range p.all {
+		if now.Sub(entry.addedAt) > p.config.TTL {
+			fmt.Printf("⌛ Evicting expired transaction %s\n", entry.tx.ID)
+			p.remove(entry.tx)
+		}
+	}
+}
+
+// remove deletes tx from all indexes. Caller must hold p.mu.
+func (p *TxPool) remove(tx *Transaction) {
+	delete(p.all, tx.ID)
+	key := senderKey(tx)
+	if queue, exists := p.senders[key]; exists {
+		if entry, ok := queue.byNonce[tx.Nonce]; ok && entry.tx.ID == tx.ID {
+			delete(queue.byNonce, tx.Nonce)
+		}
+		if len(queue.byNonce) == 0 {
+			delete(p.senders, key)
+		}
+	}
+}
+
+func (q *senderQueue) sortedNonces() []uint64 {
+	nonces := make([]uint64, 0, len(q.byNonce))
+	for nonce := range q.byNonce {
+		nonces = append(nonces, nonce)
+	}
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+	return nonces
+}
+
+// txHeap orders sender queues by the price of their next transaction
+type txHeap [][]*pooledTx
+
+func (h txHeap) Len() int { return len(h) }
+
+func (h txHeap) Less(i, j int) bool {
+	a, b := h[i][0], h[j][0]
+	if txPriority(a.tx) != txPriority(b.tx) {
+		return txPriority(a.tx) > txPriority(b.tx)
+	}
+	if !a.addedAt.Equal(b.addedAt) {
+		return a.addedAt.Before(b.addedAt)
+	}
+	return a.tx.ID < b.tx.ID
+}
+
+func (h txHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *txHeap) Push(x any) { *h = append(*h, x.([]*pooledTx)) }
+
+func (h *txHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
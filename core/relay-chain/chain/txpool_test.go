@@ -0,0 +1,114 @@
+package chain
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+func poolTx(from string, nonce, fee uint64) *Transaction {
+	tx := &Transaction{
+		Type:      TokenTransfer,
+		From:      from,
+		To:        "0xReceiver",
+		Amount:    uint256.NewInt(1),
+		TokenID:   "BHX",
+		Nonce:     nonce,
+		Fee:       fee,
+		Timestamp: 1,
+	}
+	tx.ID = tx.CalculateHash()
+	return tx
+}
+
+func TestTxPoolSelection(t *testing.T) {
+	nonces := map[string]uint64{}
+	pool := NewTxPool(DefaultTxPoolConfig(), func(addr string) uint64 { return nonces[addr] })
+
+	assert.Nil(t, pool.Add(poolTx("0xAlice", 0, 1)))
+	assert.Nil(t, pool.Add(poolTx("0xAlice", 1, 50)))
+	assert.Nil(t, pool.Add(poolTx("0xBob", 0, 10)))
+	assert.Nil(t, pool.Add(poolTx("0xBob", 5, 100))) // future: gap after nonce 0
+
+	t.Run("Pending and future sets", func(t *testing.T) {
+		pending, future := pool.Stats()
+		assert.Equal(t, 3, pending)
+		assert.Equal(t, 1, future)
+	})
+
+	t.Run("Next nonce skips pooled transactions", func(t *testing.T) {
+		assert.Equal(t, uint64(2), pool.NextNonce("0xAlice", 0))
+		assert.Equal(t, uint64(1), pool.NextNonce("0xBob", 0))
+		assert.Equal(t, uint64(7), pool.NextNonce("0xNobody", 7))
+	})
+
+	t.Run("Fee priority respects nonce order", func(t *testing.T) {
+		selected := pool.Select(0)
+		assert.Len(t, selected, 3)
+		assert.Equal(t, "0xBob", selected[0].From)
+		assert.Equal(t, "0xAlice", selected[1].From)
+		assert.Equal(t, uint64(0), selected[1].Nonce)
+		assert.Equal(t, uint64(1), selected[2].Nonce)
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		assert.ErrorIs(t, pool.Add(poolTx("0xAlice", 0, 1)), ErrTxKnown)
+	})
+
+	t.Run("Nonce too low", func(t *testing.T) {
+		nonces["0xCarol"] = 3
+		assert.ErrorIs(t, pool.Add(poolTx("0xCarol", 2, 1)), ErrNonceTooLow)
+	})
+}
+
+func TestTxPoolReplaceByFee(t *testing.T) {
+	pool := NewTxPool(DefaultTxPoolConfig(), nil)
+	original := poolTx("0xAlice", 0, 100)
+	assert.Nil(t, pool.Add(original))
+
+	assert.ErrorIs(t, pool.Add(poolTx("0xAlice", 0, 105)), ErrReplaceUnderpriced)
+
+	replacement := poolTx("0xAlice", 0, 110)
+	assert.Nil(t, pool.Add(replacement))
+	assert.False(t, pool.Has(original.ID))
+	assert.True(t, pool.Has(replacement.ID))
+	assert.Equal(t, 1, pool.Len())
+}
+
+func TestTxPoolLimits(t *testing.T) {
+	t.Run("Full pool evicts the cheapest transaction", func(t *testing.T) {
+		config := DefaultTxPoolConfig()
+		config.MaxSize = 3
+		pool := NewTxPool(config, nil)
+		for i := 0; i < 3; i++ {
+			assert.Nil(t, pool.Add(poolTx(fmt.Sprintf("0xSender%d", i), 0, uint64(10+i))))
+		}
+
+		assert.ErrorIs(t, pool.Add(poolTx("0xCheap", 0, 5)), ErrTxUnderpriced)
+		assert.Nil(t, pool.Add(poolTx("0xRich", 0, 100)))
+		assert.Equal(t, 3, pool.Len())
+		assert.False(t, pool.Has(poolTx("0xSender0", 0, 10).ID))
+	})
+
+	t.Run("Per-sender cap", func(t *testing.T) {
+		config := DefaultTxPoolConfig()
+		config.MaxPerSender = 2
+		pool := NewTxPool(config, nil)
+		assert.Nil(t, pool.Add(poolTx("0xSpammer", 0, 1)))
+		assert.Nil(t, pool.Add(poolTx("0xSpammer", 1, 1)))
+		assert.ErrorIs(t, pool.Add(poolTx("0xSpammer", 2, 1)), ErrSenderQueueFull)
+	})
+
+	t.Run("TTL eviction", func(t *testing.T) {
+		config := DefaultTxPoolConfig()
+		config.TTL = time.Millisecond
+		pool := NewTxPool(config, nil)
+		assert.Nil(t, pool.Add(poolTx("0xAlice", 0, 1)))
+		time.Sleep(5 * time.Millisecond)
+		assert.Empty(t, pool.Select(0))
+		assert.Equal(t, 0, pool.Len())
+	})
+}
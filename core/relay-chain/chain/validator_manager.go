@@ -2,8 +2,11 @@ package chain
 
 import (
     "errors"
+    "math/big"
     "math/rand"
     "time"
+
+    "github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
 // ValidatorManager handles validator selection and management
@@ -26,24 +29,28 @@ func (vm *ValidatorManager) SelectValidator(stakeLedger *StakeLedger) (string, e
     }
     
     // Weight selection by stake amount
-    totalStake := uint64(0)
+    stakes := make([]uint256.Int, 0, len(validators))
     for _, stake := range validators {
-        totalStake += stake
+        stakes = append(stakes, stake)
+    }
+    totalStake, err := uint256.Sum(stakes...)
+    if err != nil {
+        return "", err
     }
     
     // Select validator proportionally to stake
-    if totalStake == 0 {
+    if totalStake.IsZero() {
         return "", errors.New("total stake is zero")
     }
     
     // Use random selection weighted by stake
-    rand.Seed(time.Now().UnixNano())
-    selection := rand.Uint64() % totalStake
+    rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+    selection, _ := uint256.FromBig(new(big.Int).Rand(rng, totalStake.Big()))
     
-    runningTotal := uint64(0)
+    var runningTotal uint256.Int
     for addr, stake := range validators {
-        runningTotal += stake
-        if runningTotal > selection {
+        runningTotal, _ = runningTotal.Add(stake)
+        if runningTotal.Gt(selection) {
             return addr, nil
         }
     }
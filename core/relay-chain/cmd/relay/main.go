@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"context"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"os"
@@ -18,6 +19,7 @@ import (
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/governance"
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/monitoring"
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/validation"
+	"github.com/btcsuite/btcd/btcec/v2"
 )
 
 func main() {
@@ -25,6 +27,7 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	var err error
 	port := 3000
 	if len(os.Args) > 1 {
 		fmt.Sscanf(os.Args[1], "%d", &port)
@@ -37,11 +40,49 @@ func main() {
 		fmt.Println("=====================================")
 	}
 
-	bc, err := chain.NewBlockchain(port)
+	// GENESIS_FILE selects the network; without it the development genesis is used
+	genesis := chain.DefaultGenesis()
+	if path := os.Getenv("GENESIS_FILE"); path != "" {
+		genesis, err = chain.LoadGenesis(path)
+		if err != nil {
+			log.Fatal("Failed to load genesis:", err)
+		}
+		fmt.Println("📜 Loaded genesis from", path)
+	}
+
+	// HISTORY_RETENTION keeps balance and stake history for that many blocks
+	// below the tip instead of chain.DefaultHistoryRetention; 0 keeps all of it.
+	// It must be known before the stored chain is replayed.
+	history := chain.DefaultHistoryConfig()
+	if retention := os.Getenv("HISTORY_RETENTION"); retention != "" {
+		history.Retention, err = strconv.ParseUint(retention, 10, 64)
+		if err != nil {
+			log.Fatal("HISTORY_RETENTION must be a number of blocks")
+		}
+		fmt.Printf("🗄️ Keeping %d blocks of balance history\n", history.Retention)
+	}
+
+	bc, err := chain.NewBlockchainWithGenesis(port, genesis, history)
 	if err != nil {
 		log.Fatal("Failed to create blockchain:", err)
 	}
 
+	// VALIDATOR_KEY is the hex private key of the validator this node runs. Every
+	// block must be signed by its proposer, so without it the node only follows
+	// the chain.
+	keyHex := os.Getenv("VALIDATOR_KEY")
+	if keyHex == "" {
+		fmt.Println("⚠️ VALIDATOR_KEY not set; this node will not mine blocks")
+	} else {
+		keyBytes, err := hex.DecodeString(keyHex)
+		if err != nil || len(keyBytes) != 32 {
+			log.Fatal("VALIDATOR_KEY must be a 32-byte hex private key")
+		}
+		key, _ := btcec.PrivKeyFromBytes(keyBytes)
+		bc.SetProposerKey(key)
+		fmt.Println("🔑 Signing blocks as validator", chain.PublicKeyToAddress(key.PubKey()))
+	}
+
 	// Create a node ID based on port for logging
 	nodeID := fmt.Sprintf("node_%d", port)
 
@@ -72,8 +113,8 @@ func main() {
 
 		// Record initial metrics
 		monitoring.GlobalMonitor.RecordMetric("blockchain_height", monitoring.MetricGauge, float64(len(bc.Blocks)), nil)
-		monitoring.GlobalMonitor.RecordMetric("pending_transactions", monitoring.MetricGauge, float64(len(bc.PendingTxs)), nil)
-		monitoring.GlobalMonitor.RecordMetric("total_supply", monitoring.MetricGauge, float64(bc.TotalSupply), nil)
+		monitoring.GlobalMonitor.RecordMetric("pending_transactions", monitoring.MetricGauge, float64(len(bc.GetPendingTransactions())), nil)
+		monitoring.GlobalMonitor.RecordMetric("total_supply", monitoring.MetricGauge, bc.TotalSupply.Float64(), nil)
 	}
 
 	// Initialize E2E validation system
@@ -154,11 +195,25 @@ func main() {
 		cancel()
 	}()
 
-	go miningLoop(ctx, bc, validator, nodeID)
+	if keyHex != "" {
+		go miningLoop(ctx, bc, validator, nodeID)
+	}
 
 	// Create bridge instance
 	bridgeInstance := bridge.NewBridge(bc)
 
+	// BRIDGE_RELAYER_KEY is the hex private key that signs the mints releasing
+	// bridge transfers into Blackhole. Without it those transfers fail.
+	if relayerHex := os.Getenv("BRIDGE_RELAYER_KEY"); relayerHex != "" {
+		keyBytes, err := hex.DecodeString(relayerHex)
+		if err != nil || len(keyBytes) != 32 {
+			log.Fatal("BRIDGE_RELAYER_KEY must be a 32-byte hex private key")
+		}
+		key, _ := btcec.PrivKeyFromBytes(keyBytes)
+		bridgeInstance.SetRelayer(key)
+		fmt.Println("🔑 Releasing bridge transfers as", chain.PublicKeyToAddress(key.PubKey()))
+	}
+
 	// Start API server for UI
 	apiServer := api.NewAPIServer(bc, bridgeInstance, 8080)
 	go apiServer.Start()
@@ -196,30 +251,18 @@ func miningLoop(ctx context.Context, bc *chain.Blockchain, validator *consensus.
 				continue
 			}
 
+			// Blocks can only be mined for the validator whose key this node holds
 			block := bc.MineBlock(validatorAddr)
+			if block == nil {
+				continue
+			}
 			if validator.ValidateBlock(block, bc) {
 				bc.BroadcastBlock(block)
 				time.Sleep(500 * time.Millisecond)
 
 				if bc.AddBlock(block) {
-					// Get token system for rewards
-					tokenSystem := bc.TokenRegistry["BHX"]
-					if tokenSystem == nil {
-						log.Printf("❌ BHX token not found in registry")
-						return
-					}
-
-					// Try to mint block reward (respects max supply)
-					err := tokenSystem.Mint(block.Header.Validator, bc.BlockReward)
-					if err != nil {
-						log.Printf("⚠️ Failed to mint block reward: %v", err)
-						// Continue without reward if supply limit reached
-					} else {
-						log.Printf("💰 Block reward of %d BHX minted to %s", bc.BlockReward, block.Header.Validator)
-					}
-
-					// Update stake ledger
-					bc.StakeLedger.AddStake(block.Header.Validator, bc.BlockReward)
+					// The block reward and the stake it adds are applied by the block's
+					// reward transaction, so every node credits them the same way.
 
 					log.Printf("✅ Block %d added with %d transactions", block.Header.Index, len(block.Transactions))
 
@@ -230,8 +273,8 @@ func miningLoop(ctx context.Context, bc *chain.Blockchain, validator *consensus.
 							"node_id":   nodeID,
 						})
 						monitoring.GlobalMonitor.RecordMetric("blockchain_height", monitoring.MetricGauge, float64(len(bc.Blocks)), nil)
-						monitoring.GlobalMonitor.RecordMetric("pending_transactions", monitoring.MetricGauge, float64(len(bc.PendingTxs)), nil)
-						monitoring.GlobalMonitor.RecordMetric("total_supply", monitoring.MetricGauge, float64(bc.TotalSupply), nil)
+						monitoring.GlobalMonitor.RecordMetric("pending_transactions", monitoring.MetricGauge, float64(len(bc.GetPendingTransactions())), nil)
+						monitoring.GlobalMonitor.RecordMetric("total_supply", monitoring.MetricGauge, bc.TotalSupply.Float64(), nil)
 						monitoring.GlobalMonitor.RecordMetric("transactions_per_block", monitoring.MetricGauge, float64(len(block.Transactions)), nil)
 
 						// Trigger alert for high transaction volume
@@ -279,8 +322,8 @@ func startCLI(ctx context.Context, bc *chain.Blockchain, nodeID string) {
 		case "status":
 			fmt.Println("📊 Blockchain Status")
 			fmt.Printf("  Block height       : %d\n", len(bc.Blocks))
-			fmt.Printf("  Pending Tx count   : %d\n", len(bc.PendingTxs))
-			fmt.Printf("  Total Supply       : %d BHX\n", bc.TotalSupply)
+			fmt.Printf("  Pending Tx count   : %d\n", len(bc.GetPendingTransactions()))
+			fmt.Printf("  Total Supply       : %s BHX\n", bc.TotalSupply)
 			fmt.Printf("  Latest Block Hash  : %s\n", bc.Blocks[len(bc.Blocks)-1].CalculateHash())
 		case "log":
 			fmt.Println("📝 Logging blockchain state...")
@@ -563,6 +606,10 @@ func MineOnce(ctx context.Context, bc *chain.Blockchain, validator *consensus.Va
 	}
 
 	block := bc.MineBlock(validatorAddr)
+	if block == nil {
+		log.Println("⚠️ Selected validator is not the one this node signs for")
+		return
+	}
 	if validator.ValidateBlock(block, bc) {
 		// First broadcast the block
 		bc.BroadcastBlock(block)
@@ -575,19 +622,7 @@ func MineOnce(ctx context.Context, bc *chain.Blockchain, validator *consensus.Va
 
 		// Then try to add it to our chain
 		if bc.AddBlock(block) {
-			// Try to mint block reward (respects max supply)
-			tokenSystem := bc.TokenRegistry["BHX"]
-			if tokenSystem != nil {
-				err := tokenSystem.Mint(block.Header.Validator, bc.BlockReward)
-				if err != nil {
-					log.Printf("⚠️ Failed to mint block reward: %v", err)
-				} else {
-					log.Printf("💰 Block reward of %d BHX minted to %s", bc.BlockReward, block.Header.Validator)
-				}
-			}
-
-			// Update stake ledger
-			bc.StakeLedger.AddStake(block.Header.Validator, bc.BlockReward)
+			// Block reward and stake are applied by the reward transaction inside the block
 
 			log.Println("=====================================")
 			log.Printf("✅ Block %d added successfully", block.Header.Index)
@@ -629,8 +664,8 @@ func startCLI1(ctx context.Context, bc *chain.Blockchain, validator *consensus.V
 		case "status":
 			fmt.Println("📊 Blockchain Status")
 			fmt.Printf("  Block height       : %d\n", len(bc.Blocks))
-			fmt.Printf("  Pending Tx count   : %d\n", len(bc.PendingTxs))
-			fmt.Printf("  Total Supply       : %d BHX\n", bc.TotalSupply)
+			fmt.Printf("  Pending Tx count   : %d\n", len(bc.GetPendingTransactions()))
+			fmt.Printf("  Total Supply       : %s BHX\n", bc.TotalSupply)
 			fmt.Printf("  Latest Block Hash  : %s\n", bc.Blocks[len(bc.Blocks)-1].CalculateHash())
 		case "mine":
 			fmt.Println("⛏️ Mining new block...")
@@ -2,11 +2,14 @@ package consensus
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"math/rand"
 	"sort"
 	"time"
 
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/chain"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
 type Validator struct {
@@ -17,15 +20,15 @@ type Validator struct {
 }
 
 type RewardStrategy interface {
-	CalculateReward(block *chain.Block) uint64
+	CalculateReward(block *chain.Block) uint256.Int
 }
 
 type DefaultRewardStrategy struct {
-	BaseReward uint64
+	BaseReward uint256.Int
 }
 
 // Default base reward logic
-func (d *DefaultRewardStrategy) CalculateReward(block *chain.Block) uint64 {
+func (d *DefaultRewardStrategy) CalculateReward(block *chain.Block) uint256.Int {
 	return d.BaseReward
 }
 
@@ -35,7 +38,7 @@ func NewValidator(stakeLedger *chain.StakeLedger) *Validator {
 		StakePool:     stakeLedger,
 		BlockInterval: 5 * time.Second,
 		RewardStrategy: &DefaultRewardStrategy{
-			BaseReward: 10,
+			BaseReward: uint256.NewInt(10),
 		},
 		LastBlockTime: time.Now().Add(-10 * time.Second), // allow first block immediately
 	}
@@ -51,29 +54,35 @@ func (v *Validator) SelectValidator() string {
 
 	type validatorStake struct {
 		address string
-		stake   uint64
+		stake   uint256.Int
 	}
 
 	var validators []validatorStake
-	totalStake := uint64(0)
+	var totalStake uint256.Int
 
 	for addr, stake := range stakes {
 		validators = append(validators, validatorStake{addr, stake})
-		totalStake += stake
+		var err error
+		if totalStake, err = totalStake.Add(stake); err != nil {
+			return ""
+		}
+	}
+	if totalStake.IsZero() {
+		return ""
 	}
 
 	// Sort by stake (desc)
 	sort.Slice(validators, func(i, j int) bool {
-		return validators[i].stake > validators[j].stake
+		return validators[i].stake.Gt(validators[j].stake)
 	})
 
-	rand.Seed(time.Now().UnixNano())
-	selection := rand.Uint64() % totalStake
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	selection, _ := uint256.FromBig(new(big.Int).Rand(rng, totalStake.Big()))
 
-	runningTotal := uint64(0)
+	var runningTotal uint256.Int
 	for _, vs := range validators {
-		runningTotal += vs.stake
-		if runningTotal > selection {
+		runningTotal, _ = runningTotal.Add(vs.stake)
+		if runningTotal.Gt(selection) {
 			return vs.address
 		}
 	}
@@ -123,14 +132,14 @@ func (v *Validator) ValidateBlock(block *chain.Block, blockchain *chain.Blockcha
 
 // DynamicRewardStrategy calculates rewards based on token supply
 type DynamicRewardStrategy struct {
-	BaseReward uint64 // Base reward amount
-	MaxSupply  uint64 // Maximum token supply
-	MinReward  uint64 // Minimum reward (never go below this)
-	Enabled    bool   // Whether dynamic rewards are enabled
+	BaseReward uint256.Int // Base reward amount
+	MaxSupply  uint256.Int // Maximum token supply
+	MinReward  uint256.Int // Minimum reward (never go below this)
+	Enabled    bool        // Whether dynamic rewards are enabled
 }
 
 // NewDynamicRewardStrategy creates a new dynamic reward strategy
-func NewDynamicRewardStrategy(baseReward, maxSupply, minReward uint64) *DynamicRewardStrategy {
+func NewDynamicRewardStrategy(baseReward, maxSupply, minReward uint256.Int) *DynamicRewardStrategy {
 	return &DynamicRewardStrategy{
 		BaseReward: baseReward,
 		MaxSupply:  maxSupply,
@@ -140,13 +149,13 @@ func NewDynamicRewardStrategy(baseReward, maxSupply, minReward uint64) *DynamicR
 }
 
 // CalculateReward calculates the block reward based on current supply
-func (d *DynamicRewardStrategy) CalculateReward(currentSupply uint64) uint64 {
-	if !d.Enabled || d.MaxSupply == 0 {
+func (d *DynamicRewardStrategy) CalculateReward(currentSupply uint256.Int) uint256.Int {
+	if !d.Enabled || d.MaxSupply.IsZero() {
 		return d.BaseReward
 	}
 
 	// Calculate supply ratio (0.0 to 1.0)
-	supplyRatio := float64(currentSupply) / float64(d.MaxSupply)
+	supplyRatio := currentSupply.Float64() / d.MaxSupply.Float64()
 
 	// Reduce rewards as supply approaches maximum
 	rewardMultiplier := 1.0
@@ -157,11 +166,13 @@ func (d *DynamicRewardStrategy) CalculateReward(currentSupply uint64) uint64 {
 		rewardMultiplier = 1.0 - (reductionFactor * 0.8) // Reduce by up to 80%
 	}
 
-	// Calculate new reward
-	newReward := uint64(float64(d.BaseReward) * rewardMultiplier)
+	// Calculate new reward, applying the multiplier in basis points so the
+	// amount itself never passes through a float
+	multiplierBasisPoints := uint64(math.Max(rewardMultiplier, 0) * 10000)
+	newReward, _ := d.BaseReward.MulDiv(uint256.NewInt(multiplierBasisPoints), uint256.NewInt(10000))
 
 	// Ensure we never go below minimum reward
-	if newReward < d.MinReward {
+	if newReward.Lt(d.MinReward) {
 		newReward = d.MinReward
 	}
 
@@ -169,8 +180,8 @@ func (d *DynamicRewardStrategy) CalculateReward(currentSupply uint64) uint64 {
 }
 
 // GetRewardInfo returns information about the current reward calculation
-func (d *DynamicRewardStrategy) GetRewardInfo(currentSupply uint64) map[string]interface{} {
-	supplyRatio := float64(currentSupply) / float64(d.MaxSupply)
+func (d *DynamicRewardStrategy) GetRewardInfo(currentSupply uint256.Int) map[string]interface{} {
+	supplyRatio := currentSupply.Float64() / d.MaxSupply.Float64()
 	currentReward := d.CalculateReward(currentSupply)
 
 	return map[string]interface{}{
@@ -7,6 +7,7 @@ import (
 
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/bridge"
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/chain"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
 // CrossChainSwapOrder represents a cross-chain swap order
@@ -17,18 +18,19 @@ type CrossChainSwapOrder struct {
 	DestChain       bridge.ChainType      `json:"dest_chain"`
 	TokenIn         string                `json:"token_in"`
 	TokenOut        string                `json:"token_out"`
-	AmountIn        uint64                `json:"amount_in"`
-	MinAmountOut    uint64                `json:"min_amount_out"`
-	EstimatedOut    uint64                `json:"estimated_out"`
-	Status          string                `json:"status"` // "pending", "bridging", "swapping", "completed", "failed"
+	AmountIn        uint256.Int           `json:"amount_in"`
+	MinAmountOut    uint256.Int           `json:"min_amount_out"`
+	EstimatedOut    uint256.Int           `json:"estimated_out"`
+	Status          string                `json:"status"` // "pending", "bridging", "bridged", "swapping", "completed", "failed"
 	BridgeTxID      string                `json:"bridge_tx_id,omitempty"`
 	SwapTxID        string                `json:"swap_tx_id,omitempty"`
 	CreatedAt       int64                 `json:"created_at"`
 	CompletedAt     int64                 `json:"completed_at,omitempty"`
 	ExpiresAt       int64                 `json:"expires_at"`
 	PriceImpact     float64               `json:"price_impact"`
-	BridgeFee       uint64                `json:"bridge_fee"`
-	SwapFee         uint64                `json:"swap_fee"`
+	BridgeFee       uint256.Int           `json:"bridge_fee"`
+	SwapFee         uint256.Int           `json:"swap_fee"`
+	lockTx          *chain.Transaction    // the user's signed lock of AmountIn when SourceChain is Blackhole
 	mu              sync.RWMutex
 }
 
@@ -40,7 +42,7 @@ type CrossChainDEX struct {
 	SwapOrders      map[string]*CrossChainSwapOrder   `json:"swap_orders"`
 	ChainDEXes      map[bridge.ChainType]*DEX         `json:"-"` // DEX instances for each chain
 	SupportedPairs  map[string][]bridge.ChainType     `json:"supported_pairs"` // token -> supported chains
-	BridgeFees      map[bridge.ChainType]uint64       `json:"bridge_fees"` // chain -> fee amount
+	BridgeFees      map[bridge.ChainType]uint256.Int  `json:"bridge_fees"` // chain -> fee amount
 	mu              sync.RWMutex
 }
 
@@ -53,7 +55,7 @@ func NewCrossChainDEX(localDEX *DEX, bridgeInstance *bridge.Bridge, blockchain *
 		SwapOrders:     make(map[string]*CrossChainSwapOrder),
 		ChainDEXes:     make(map[bridge.ChainType]*DEX),
 		SupportedPairs: make(map[string][]bridge.ChainType),
-		BridgeFees:     make(map[bridge.ChainType]uint64),
+		BridgeFees:     make(map[bridge.ChainType]uint256.Int),
 	}
 
 	// Initialize supported chains and fees
@@ -85,15 +87,15 @@ func (ccDEX *CrossChainDEX) initializeSupportedChains() {
 	}
 
 	// Set bridge fees (in base units)
-	ccDEX.BridgeFees[bridge.ChainTypeEthereum] = 10  // 10 units
-	ccDEX.BridgeFees[bridge.ChainTypeSolana] = 5     // 5 units
-	ccDEX.BridgeFees[bridge.ChainTypeBlackhole] = 1  // 1 unit
+	ccDEX.BridgeFees[bridge.ChainTypeEthereum] = uint256.NewInt(10)  // 10 units
+	ccDEX.BridgeFees[bridge.ChainTypeSolana] = uint256.NewInt(5)     // 5 units
+	ccDEX.BridgeFees[bridge.ChainTypeBlackhole] = uint256.NewInt(1)  // 1 unit
 
 	fmt.Printf("✅ Cross-chain DEX initialized with %d supported tokens\n", len(ccDEX.SupportedPairs))
 }
 
 // GetCrossChainQuote calculates quote for cross-chain swap
-func (ccDEX *CrossChainDEX) GetCrossChainQuote(sourceChain, destChain bridge.ChainType, tokenIn, tokenOut string, amountIn uint64) (*CrossChainSwapOrder, error) {
+func (ccDEX *CrossChainDEX) GetCrossChainQuote(sourceChain, destChain bridge.ChainType, tokenIn, tokenOut string, amountIn uint256.Int) (*CrossChainSwapOrder, error) {
 	ccDEX.mu.RLock()
 	defer ccDEX.mu.RUnlock()
 
@@ -107,12 +109,12 @@ func (ccDEX *CrossChainDEX) GetCrossChainQuote(sourceChain, destChain bridge.Cha
 
 	// Calculate bridge fee
 	bridgeFee := ccDEX.BridgeFees[destChain]
-	if amountIn <= bridgeFee {
+	if !amountIn.Gt(bridgeFee) {
 		return nil, fmt.Errorf("amount too small to cover bridge fee")
 	}
 
 	// Calculate swap quote on destination chain
-	amountAfterBridge := amountIn - bridgeFee
+	amountAfterBridge, _ := amountIn.Sub(bridgeFee)
 	
 	// Get swap quote (simulate destination DEX)
 	estimatedOut, err := ccDEX.getDestinationSwapQuote(destChain, tokenIn, tokenOut, amountAfterBridge)
@@ -124,8 +126,11 @@ func (ccDEX *CrossChainDEX) GetCrossChainQuote(sourceChain, destChain bridge.Cha
 	priceImpact, _ := ccDEX.calculateCrossChainPriceImpact(sourceChain, destChain, tokenIn, tokenOut, amountIn)
 
 	// Calculate swap fee (0.3% of output)
-	swapFee := uint64(float64(estimatedOut) * 0.003)
-	finalOutput := estimatedOut - swapFee
+	swapFee, err := estimatedOut.MulDiv(uint256.NewInt(3), uint256.NewInt(1000))
+	if err != nil {
+		return nil, err
+	}
+	finalOutput, _ := estimatedOut.Sub(swapFee)
 
 	quote := &CrossChainSwapOrder{
 		SourceChain:  sourceChain,
@@ -143,8 +148,10 @@ func (ccDEX *CrossChainDEX) GetCrossChainQuote(sourceChain, destChain bridge.Cha
 	return quote, nil
 }
 
-// InitiateCrossChainSwap starts a cross-chain swap
-func (ccDEX *CrossChainDEX) InitiateCrossChainSwap(user string, sourceChain, destChain bridge.ChainType, tokenIn, tokenOut string, amountIn, minAmountOut uint64) (*CrossChainSwapOrder, error) {
+// InitiateCrossChainSwap starts a cross-chain swap. Swaps out of Blackhole
+// need lockTx, the user's signed TokenTransfer of amountIn to the bridge; see
+// bridge.InitiateBridgeTransfer.
+func (ccDEX *CrossChainDEX) InitiateCrossChainSwap(user string, sourceChain, destChain bridge.ChainType, tokenIn, tokenOut string, amountIn, minAmountOut uint256.Int, lockTx *chain.Transaction) (*CrossChainSwapOrder, error) {
 	ccDEX.mu.Lock()
 	defer ccDEX.mu.Unlock()
 
@@ -158,8 +165,8 @@ func (ccDEX *CrossChainDEX) InitiateCrossChainSwap(user string, sourceChain, des
 	}
 
 	// Check slippage protection
-	if quote.EstimatedOut < minAmountOut {
-		return nil, fmt.Errorf("insufficient output amount: estimated %d, minimum %d", quote.EstimatedOut, minAmountOut)
+	if quote.EstimatedOut.Lt(minAmountOut) {
+		return nil, fmt.Errorf("%w: estimated %s, minimum %s", ErrInsufficientOutput, quote.EstimatedOut, minAmountOut)
 	}
 
 	// Create swap order
@@ -179,6 +186,7 @@ func (ccDEX *CrossChainDEX) InitiateCrossChainSwap(user string, sourceChain, des
 		PriceImpact:  quote.PriceImpact,
 		BridgeFee:    quote.BridgeFee,
 		SwapFee:      quote.SwapFee,
+		lockTx:       lockTx,
 	}
 
 	ccDEX.SwapOrders[orderID] = order
@@ -186,7 +194,7 @@ func (ccDEX *CrossChainDEX) InitiateCrossChainSwap(user string, sourceChain, des
 	// Start the cross-chain swap process
 	go ccDEX.executeCrossChainSwap(orderID)
 
-	fmt.Printf("✅ Cross-chain swap initiated: %s (%d %s on %s → %s on %s)\n", 
+	fmt.Printf("✅ Cross-chain swap initiated: %s (%s %s on %s → %s on %s)\n", 
 		orderID, amountIn, tokenIn, sourceChain, tokenOut, destChain)
 
 	return order, nil
@@ -211,6 +219,7 @@ func (ccDEX *CrossChainDEX) executeCrossChainSwap(orderID string) {
 		order.User, // Same user on destination
 		order.TokenIn,
 		order.AmountIn,
+		order.lockTx,
 	)
 
 	if err != nil {
@@ -239,13 +248,24 @@ func (ccDEX *CrossChainDEX) waitForBridgeAndSwap(orderID string) {
 	// Wait for bridge transaction to complete (simulate)
 	time.Sleep(5 * time.Second) // Simulate bridge confirmation time
 
+	// A swap on Blackhole is a Swap transaction signed by the user, so the
+	// order ends once the bridged tokens arrive
+	if order.DestChain == bridge.ChainTypeBlackhole {
+		order.mu.Lock()
+		order.Status = "bridged"
+		order.CompletedAt = time.Now().Unix()
+		order.mu.Unlock()
+		fmt.Printf("✅ Cross-chain order %s bridged; %s swaps the tokens with a signed Swap transaction\n", orderID, order.User)
+		return
+	}
+
 	// Step 3: Execute swap on destination chain
 	order.mu.Lock()
 	order.Status = "swapping"
 	order.mu.Unlock()
 
 	// Calculate amount after bridge fees
-	amountForSwap := order.AmountIn - order.BridgeFee
+	amountForSwap, _ := order.AmountIn.Sub(order.BridgeFee) // the quote checked the fee is covered
 
 	// Execute the swap (simulate destination chain swap)
 	swapResult, err := ccDEX.executeDestinationSwap(order.DestChain, order.TokenIn, order.TokenOut, amountForSwap, order.MinAmountOut, order.User)
@@ -265,7 +285,7 @@ func (ccDEX *CrossChainDEX) waitForBridgeAndSwap(orderID string) {
 	order.CompletedAt = time.Now().Unix()
 	order.mu.Unlock()
 
-	fmt.Printf("✅ Cross-chain swap completed: %s (Final output: %d %s)\n", 
+	fmt.Printf("✅ Cross-chain swap completed: %s (Final output: %s %s)\n", 
 		orderID, swapResult.AmountOut, order.TokenOut)
 }
 
@@ -284,7 +304,7 @@ func (ccDEX *CrossChainDEX) isTokenSupportedOnChain(token string, chainType brid
 	return false
 }
 
-func (ccDEX *CrossChainDEX) getDestinationSwapQuote(destChain bridge.ChainType, tokenIn, tokenOut string, amountIn uint64) (uint64, error) {
+func (ccDEX *CrossChainDEX) getDestinationSwapQuote(destChain bridge.ChainType, tokenIn, tokenOut string, amountIn uint256.Int) (uint256.Int, error) {
 	// If destination is local chain, use local DEX
 	if destChain == bridge.ChainTypeBlackhole {
 		return ccDEX.LocalDEX.GetSwapQuote(tokenIn, tokenOut, amountIn)
@@ -292,17 +312,18 @@ func (ccDEX *CrossChainDEX) getDestinationSwapQuote(destChain bridge.ChainType,
 	
 	// Simulate external chain DEX quote
 	// In production, this would call external chain APIs
-	simulatedRate := 1.0
+	// The rate is a fraction so the amount never passes through a float
+	rateNum, rateDen := uint256.NewInt(1), uint256.NewInt(1)
 	if tokenIn == "BHX" && tokenOut == "USDT" {
-		simulatedRate = 5.0 // 1 BHX = 5 USDT
+		rateNum = uint256.NewInt(5) // 1 BHX = 5 USDT
 	} else if tokenIn == "USDT" && tokenOut == "BHX" {
-		simulatedRate = 0.2 // 1 USDT = 0.2 BHX
+		rateDen = uint256.NewInt(5) // 1 USDT = 0.2 BHX
 	}
 	
-	return uint64(float64(amountIn) * simulatedRate), nil
+	return amountIn.MulDiv(rateNum, rateDen)
 }
 
-func (ccDEX *CrossChainDEX) calculateCrossChainPriceImpact(sourceChain, destChain bridge.ChainType, tokenIn, tokenOut string, amountIn uint64) (float64, error) {
+func (ccDEX *CrossChainDEX) calculateCrossChainPriceImpact(sourceChain, destChain bridge.ChainType, tokenIn, tokenOut string, amountIn uint256.Int) (float64, error) {
 	// Simplified price impact calculation for cross-chain
 	// In production, this would consider liquidity on both chains
 	if destChain == bridge.ChainTypeBlackhole {
@@ -316,31 +337,18 @@ func (ccDEX *CrossChainDEX) calculateCrossChainPriceImpact(sourceChain, destChai
 // SwapResult represents the result of a destination swap
 type SwapResult struct {
 	TxID      string
-	AmountOut uint64
+	AmountOut uint256.Int
 }
 
-func (ccDEX *CrossChainDEX) executeDestinationSwap(destChain bridge.ChainType, tokenIn, tokenOut string, amountIn, minAmountOut uint64, user string) (*SwapResult, error) {
-	// If destination is local chain, use local DEX
-	if destChain == bridge.ChainTypeBlackhole {
-		amountOut, err := ccDEX.LocalDEX.ExecuteSwap(tokenIn, tokenOut, amountIn, minAmountOut, user)
-		if err != nil {
-			return nil, err
-		}
-		
-		return &SwapResult{
-			TxID:      fmt.Sprintf("local_swap_%d", time.Now().UnixNano()),
-			AmountOut: amountOut,
-		}, nil
-	}
-	
+func (ccDEX *CrossChainDEX) executeDestinationSwap(destChain bridge.ChainType, tokenIn, tokenOut string, amountIn, minAmountOut uint256.Int, user string) (*SwapResult, error) {
 	// Simulate external chain swap
 	quote, err := ccDEX.getDestinationSwapQuote(destChain, tokenIn, tokenOut, amountIn)
 	if err != nil {
 		return nil, err
 	}
 	
-	if quote < minAmountOut {
-		return nil, fmt.Errorf("insufficient output amount")
+	if quote.Lt(minAmountOut) {
+		return nil, ErrInsufficientOutput
 	}
 	
 	return &SwapResult{
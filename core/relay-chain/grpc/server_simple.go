@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/chain"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 	"google.golang.org/grpc"
 )
 
@@ -69,7 +70,7 @@ func (s *SimpleRelayServer) GetBlockchainStatus() map[string]interface{} {
 	validatorCount := len(allStakes)
 
 	// Get total supply
-	totalSupply := uint64(0)
+	var totalSupply uint256.Int
 	if tokenSystem, exists := s.blockchain.TokenRegistry["BHX"]; exists {
 		totalSupply = tokenSystem.TotalSupply()
 	}
@@ -83,32 +84,38 @@ func (s *SimpleRelayServer) GetBlockchainStatus() map[string]interface{} {
 		"total_supply":       totalSupply,
 		"circulating_supply": totalSupply,
 		"validator_count":    validatorCount,
-		"pending_txs":        len(s.blockchain.PendingTxs),
+		"pending_txs":        len(s.blockchain.GetPendingTransactions()),
 	}
 }
 
 // SubmitTransactionSimple submits a transaction (simplified version)
-func (s *SimpleRelayServer) SubmitTransactionSimple(from, to, tokenID string, amount uint64) (string, error) {
+func (s *SimpleRelayServer) SubmitTransactionSimple(from, to, tokenID string, amount uint256.Int) (string, error) {
 	tx := &chain.Transaction{
+		ChainID:   s.blockchain.ChainID,
 		From:      from,
 		To:        to,
 		Amount:    amount,
 		TokenID:   tokenID,
 		Timestamp: time.Now().Unix(),
+		Nonce:     s.blockchain.GetNextNonce(from),
+		GasPrice:  s.blockchain.Fees.MinGasPrice,
 	}
+	tx.GasLimit = chain.IntrinsicGas(tx)
 
 	// Calculate transaction hash
 	tx.ID = tx.CalculateHash()
 
 	// Add transaction to pending pool
-	s.blockchain.PendingTxs = append(s.blockchain.PendingTxs, tx)
+	if err := s.blockchain.AddPendingTransaction(tx); err != nil {
+		return "", fmt.Errorf("failed to queue transaction: %v", err)
+	}
 
-	fmt.Printf("📤 Transaction submitted: %s -> %s (%d %s)\n", from, to, amount, tokenID)
+	fmt.Printf("📤 Transaction submitted: %s -> %s (%s %s)\n", from, to, amount, tokenID)
 	return tx.ID, nil
 }
 
 // GetBalanceSimple gets balance for an address (simplified version)
-func (s *SimpleRelayServer) GetBalanceSimple(address, tokenID string) (uint64, error) {
+func (s *SimpleRelayServer) GetBalanceSimple(address, tokenID string) (uint256.Int, error) {
 	if tokenID == "" {
 		tokenID = "BHX" // Default token
 	}
@@ -116,22 +123,22 @@ func (s *SimpleRelayServer) GetBalanceSimple(address, tokenID string) (uint64, e
 	if token, exists := s.blockchain.TokenRegistry[tokenID]; exists {
 		balance, err := token.BalanceOf(address)
 		if err != nil {
-			return 0, fmt.Errorf("failed to get balance: %v", err)
+			return uint256.Int{}, fmt.Errorf("failed to get balance: %v", err)
 		}
 		return balance, nil
 	}
 
-	return 0, fmt.Errorf("token %s not found", tokenID)
+	return uint256.Int{}, fmt.Errorf("token %s not found", tokenID)
 }
 
 // ValidateTransactionSimple validates a transaction (simplified version)
-func (s *SimpleRelayServer) ValidateTransactionSimple(from, to, tokenID string, amount uint64) (bool, string, error) {
+func (s *SimpleRelayServer) ValidateTransactionSimple(from, to, tokenID string, amount uint256.Int) (bool, string, error) {
 	// Basic validation
 	if from == "" || to == "" {
 		return false, "from and to addresses are required", nil
 	}
 
-	if amount == 0 {
+	if amount.IsZero() {
 		return false, "amount must be greater than 0", nil
 	}
 
@@ -148,7 +155,7 @@ func (s *SimpleRelayServer) ValidateTransactionSimple(from, to, tokenID string,
 				return false, fmt.Sprintf("failed to check balance: %v", err), nil
 			}
 
-			if balance < amount {
+			if balance.Lt(amount) {
 				return false, "insufficient balance", nil
 			}
 		}
@@ -190,7 +197,7 @@ func (s *SimpleRelayServer) GetValidatorsSimple() []map[string]interface{} {
 func (s *SimpleRelayServer) GetPendingTransactionsSimple() []map[string]interface{} {
 	pendingTxs := make([]map[string]interface{}, 0)
 
-	for _, tx := range s.blockchain.PendingTxs {
+	for _, tx := range s.blockchain.GetPendingTransactions() {
 		txInfo := map[string]interface{}{
 			"id":        tx.ID,
 			"from":      tx.From,
@@ -209,7 +216,7 @@ func (s *SimpleRelayServer) GetPendingTransactionsSimple() []map[string]interfac
 func (s *SimpleRelayServer) GetNetworkStatsSimple() map[string]interface{} {
 	stats := map[string]interface{}{
 		"total_blocks":      len(s.blockchain.Blocks),
-		"pending_txs":       len(s.blockchain.PendingTxs),
+		"pending_txs":       len(s.blockchain.GetPendingTransactions()),
 		"total_validators":  len(s.blockchain.StakeLedger.GetAllStakes()),
 		"network_hash_rate": "1.5 TH/s", // Placeholder
 		"avg_block_time":    "6s",       // Placeholder
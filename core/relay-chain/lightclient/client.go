@@ -0,0 +1,137 @@
+// Package lightclient follows the relay chain from block headers alone. It
+// checks that each header links to the previous one and is signed by a known
+// validator, and verifies transaction inclusion proofs against the stored
+// headers, so wallets and bridge relayers never need full blocks.
+package lightclient
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/chain"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+var (
+	ErrUntrustedCheckpoint = errors.New("checkpoint header does not match its trusted hash")
+	ErrHeaderNotLinked     = errors.New("header does not extend the current tip")
+	ErrUnknownProposer     = errors.New("header proposer is not in the validator set")
+	ErrUnknownHeader       = errors.New("header not known to the light client")
+)
+
+// Client holds a chain of verified headers starting at a trusted checkpoint
+type Client struct {
+	mu         sync.RWMutex
+	validators map[string]uint256.Int // validator address -> stake
+	headers    []*chain.SignedHeader
+	byHash     map[string]*chain.SignedHeader
+}
+
+// New starts a client at checkpoint, which must hash to trustedHash. The hash
+// comes from a source the caller trusts, such as the genesis block hash of the
+// network. validators maps each validator address to its stake.
+func New(checkpoint *chain.SignedHeader, trustedHash string, validators map[string]uint256.Int) (*Client, error) {
+	if checkpoint == nil || checkpoint.Hash != trustedHash || checkpoint.Header.Hash() != trustedHash {
+		return nil, ErrUntrustedCheckpoint
+	}
+	c := &Client{
+		headers: []*chain.SignedHeader{checkpoint},
+		byHash:  map[string]*chain.SignedHeader{checkpoint.Hash: checkpoint},
+	}
+	c.SetValidators(validators)
+	return c, nil
+}
+
+// ValidatorsFromGenesis returns the validator set a genesis config starts with
+func ValidatorsFromGenesis(genesis *chain.GenesisConfig) (map[string]uint256.Int, error) {
+	if err := genesis.Validate(); err != nil {
+		return nil, err
+	}
+	validators := make(map[string]uint256.Int, len(genesis.Validators))
+	for _, v := range genesis.Validators {
+		validators[v.Address] = v.Stake
+	}
+	return validators, nil
+}
+
+// SetValidators replaces the validator set that new headers are checked against
+func (c *Client) SetValidators(validators map[string]uint256.Int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.validators = make(map[string]uint256.Int, len(validators))
+	for addr, stake := range validators {
+		if !stake.IsZero() {
+			c.validators[addr] = stake
+		}
+	}
+}
+
+// AddHeader verifies a header against the tip and, if it is valid, makes it the new tip
+func (c *Client) AddHeader(header *chain.SignedHeader) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tip := c.headers[len(c.headers)-1]
+	if header.Header.Index != tip.Header.Index+1 || header.Header.PreviousHash != tip.Hash {
+		return fmt.Errorf("%w: expected %s at height %d", ErrHeaderNotLinked, tip.Hash, tip.Header.Index+1)
+	}
+	if !header.Header.Timestamp.After(tip.Header.Timestamp) {
+		return fmt.Errorf("%w: %s, parent %s", chain.ErrBadTimestamp, header.Header.Timestamp, tip.Header.Timestamp)
+	}
+	if _, ok := c.validators[header.Header.Validator]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownProposer, header.Header.Validator)
+	}
+	if err := header.VerifyProposer(); err != nil {
+		return err
+	}
+
+	c.headers = append(c.headers, header)
+	c.byHash[header.Hash] = header
+	return nil
+}
+
+// AddHeaders adds headers in order and stops at the first invalid one
+func (c *Client) AddHeaders(headers []*chain.SignedHeader) error {
+	for _, header := range headers {
+		if err := c.AddHeader(header); err != nil {
+			return fmt.Errorf("header %d: %w", header.Header.Index, err)
+		}
+	}
+	return nil
+}
+
+// Tip returns the latest verified header
+func (c *Client) Tip() *chain.SignedHeader {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.headers[len(c.headers)-1]
+}
+
+// Header returns the verified header at height
+func (c *Client) Header(height uint64) (*chain.SignedHeader, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	base := c.headers[0].Header.Index
+	if height < base || height-base >= uint64(len(c.headers)) {
+		return nil, fmt.Errorf("%w: height %d", ErrUnknownHeader, height)
+	}
+	return c.headers[height-base], nil
+}
+
+// VerifyTransaction checks that a transaction is included in a verified header
+func (c *Client) VerifyTransaction(proof *chain.TxProof) error {
+	if proof == nil {
+		return fmt.Errorf("%w: empty proof", chain.ErrBadTxProof)
+	}
+
+	c.mu.RLock()
+	header, ok := c.byHash[proof.BlockHash]
+	c.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownHeader, proof.BlockHash)
+	}
+	return chain.VerifyTxProof(header, proof)
+}
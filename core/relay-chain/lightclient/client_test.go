@@ -0,0 +1,116 @@
+package lightclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/chain"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// signedChain builds a checkpoint and n blocks on top of it, each signed by key
+func signedChain(t *testing.T, key *btcec.PrivateKey, n int) (*chain.Block, []*chain.Block) {
+	proposer := chain.PublicKeyToAddress(key.PubKey())
+	checkpoint := chain.NewBlock(0, nil, "genesis", "", uint256.Int{}, "", "")
+
+	blocks := make([]*chain.Block, 0, n)
+	parent := checkpoint
+	for i := 0; i < n; i++ {
+		tx := chain.NewTransaction(chain.TokenTransfer, "", "0xBob", uint256.NewInt(uint64(i+1)), nil)
+		assert.Nil(t, tx.Sign(key))
+
+		block := chain.NewBlock(parent.Header.Index+1, []*chain.Transaction{tx}, parent.Hash, proposer, uint256.NewInt(1000), "", "")
+		block.Header.Timestamp = parent.Header.Timestamp.Add(time.Second)
+		assert.Nil(t, block.Sign(key))
+		blocks = append(blocks, block)
+		parent = block
+	}
+	return checkpoint, blocks
+}
+
+func newClient(t *testing.T, key *btcec.PrivateKey, checkpoint *chain.Block) *Client {
+	client, err := New(checkpoint.SignedHeader(), checkpoint.Hash, map[string]uint256.Int{
+		chain.PublicKeyToAddress(key.PubKey()): uint256.NewInt(1000),
+	})
+	assert.Nil(t, err)
+	return client
+}
+
+func TestFollowHeaders(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+	checkpoint, blocks := signedChain(t, key, 3)
+	client := newClient(t, key, checkpoint)
+
+	for _, block := range blocks {
+		assert.Nil(t, client.AddHeader(block.SignedHeader()))
+	}
+	assert.Equal(t, blocks[2].Hash, client.Tip().Hash)
+
+	header, err := client.Header(2)
+	assert.Nil(t, err)
+	assert.Equal(t, blocks[1].Hash, header.Hash)
+
+	t.Run("Untrusted checkpoint", func(t *testing.T) {
+		_, err := New(checkpoint.SignedHeader(), blocks[0].Hash, nil)
+		assert.ErrorIs(t, err, ErrUntrustedCheckpoint)
+	})
+}
+
+func TestRejectHeaders(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+	checkpoint, blocks := signedChain(t, key, 2)
+
+	t.Run("Gap", func(t *testing.T) {
+		client := newClient(t, key, checkpoint)
+		assert.ErrorIs(t, client.AddHeader(blocks[1].SignedHeader()), ErrHeaderNotLinked)
+	})
+
+	t.Run("Unsigned", func(t *testing.T) {
+		client := newClient(t, key, checkpoint)
+		header := blocks[0].SignedHeader()
+		header.Signature = nil
+		assert.ErrorIs(t, client.AddHeader(header), chain.ErrBadProposerSignature)
+	})
+
+	t.Run("Proposer outside the validator set", func(t *testing.T) {
+		outsider, _ := btcec.NewPrivateKey()
+		otherCheckpoint, other := signedChain(t, outsider, 1)
+		client := newClient(t, key, otherCheckpoint)
+		assert.ErrorIs(t, client.AddHeader(other[0].SignedHeader()), ErrUnknownProposer)
+	})
+
+	t.Run("Tampered header", func(t *testing.T) {
+		client := newClient(t, key, checkpoint)
+		header := blocks[0].SignedHeader()
+		header.Header.StateRoot = "ff"
+		assert.ErrorIs(t, client.AddHeader(header), chain.ErrBadBlockHash)
+	})
+}
+
+func TestVerifyTransaction(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	assert.Nil(t, err)
+	checkpoint, blocks := signedChain(t, key, 2)
+	client := newClient(t, key, checkpoint)
+	assert.Nil(t, client.AddHeader(blocks[0].SignedHeader()))
+
+	proof, err := blocks[0].ProveTransaction(0)
+	assert.Nil(t, err)
+	assert.Nil(t, client.VerifyTransaction(proof))
+
+	t.Run("Block not followed yet", func(t *testing.T) {
+		proof, err := blocks[1].ProveTransaction(0)
+		assert.Nil(t, err)
+		assert.ErrorIs(t, client.VerifyTransaction(proof), ErrUnknownHeader)
+	})
+
+	t.Run("Forged transaction", func(t *testing.T) {
+		forged := *proof
+		forged.TxID = blocks[1].Transactions[0].ID
+		assert.ErrorIs(t, client.VerifyTransaction(&forged), chain.ErrBadTxProof)
+	})
+}
@@ -1,8 +1,13 @@
 package token
 
-import "errors"
+import (
+	"errors"
+	"fmt"
 
-func (t *Token) Approve(owner, spender string, amount uint64) error {
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+func (t *Token) Approve(owner, spender string, amount uint256.Int) error {
 	if !t.validateAddress(owner) || !t.validateAddress(spender) {
 		return errors.New("invalid address")
 	}
@@ -11,27 +16,26 @@ func (t *Token) Approve(owner, spender string, amount uint64) error {
 	defer t.mu.Unlock()
 
 	if t.allowances[owner] == nil {
-		t.allowances[owner] = make(map[string]uint64)
+		t.allowances[owner] = make(map[string]uint256.Int)
 	}
+	t.recordAllowance(owner, spender)
 	t.allowances[owner][spender] = amount
+	t.storeAllowance(owner, spender, amount)
 	return nil
 }
 
-func (t *Token) Allowance(owner, spender string) (uint64, error) {
+func (t *Token) Allowance(owner, spender string) (uint256.Int, error) {
 	if !t.validateAddress(owner) || !t.validateAddress(spender) {
-		return 0, errors.New("invalid address")
+		return uint256.Int{}, errors.New("invalid address")
 	}
 
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
-	if t.allowances[owner] == nil {
-		return 0, nil
-	}
 	return t.allowances[owner][spender], nil
 }
 
-func (t *Token) TransferFrom(owner, spender, to string, amount uint64) error {
+func (t *Token) TransferFrom(owner, spender, to string, amount uint256.Int) error {
 	if !t.validateAddress(owner) || !t.validateAddress(spender) || !t.validateAddress(to) {
 		return errors.New("invalid address")
 	}
@@ -39,16 +43,23 @@ func (t *Token) TransferFrom(owner, spender, to string, amount uint64) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if t.allowances[owner][spender] < amount {
+	if err := t.checkMovement(owner, to); err != nil {
+		return err
+	}
+	if t.controls.Blocklisted[spender] {
+		return fmt.Errorf("%w: %s", ErrBlocklisted, spender)
+	}
+	allowance, err := t.allowances[owner][spender].Sub(amount)
+	if err != nil {
 		return errors.New("allowance exceeded")
 	}
-	if t.balances[owner] < amount {
-		return errors.New("insufficient balance")
+	if err := t.move(owner, to, amount); err != nil {
+		return err
 	}
 
-	t.balances[owner] -= amount
-	t.balances[to] += amount
-	t.allowances[owner][spender] -= amount
+	t.recordAllowance(owner, spender)
+	t.allowances[owner][spender] = allowance
+	t.storeAllowance(owner, spender, allowance)
 
 	// Emit event
 	t.emitEvent(Event{
@@ -2,36 +2,48 @@ package token
 
 import (
 	"errors"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
-func (t *Token) TotalSupply() uint64 {
+func (t *Token) TotalSupply() uint256.Int {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 	return t.totalSupply
 }
 
 // MaxSupply returns the maximum supply limit
-func (t *Token) MaxSupply() uint64 {
+func (t *Token) MaxSupply() uint256.Int {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 	return t.maxSupply
 }
 
 // CirculatingSupply calculates the actual circulating supply
-func (t *Token) CirculatingSupply() uint64 {
+func (t *Token) CirculatingSupply() uint256.Int {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
-	supply := uint64(0)
+	supply, _ := t.circulating()
+	return supply
+}
+
+// circulating adds up all balances. The sum only overflows if the ledger was
+// corrupted. Caller must hold t.mu.
+func (t *Token) circulating() (uint256.Int, error) {
+	var supply uint256.Int
 	for _, balance := range t.balances {
-		supply += balance
+		var err error
+		if supply, err = supply.Add(balance); err != nil {
+			return uint256.Max, err
+		}
 	}
-	return supply
+	return supply, nil
 }
 
-func (t *Token) BalanceOf(address string) (uint64, error) {
+func (t *Token) BalanceOf(address string) (uint256.Int, error) {
 	if !t.validateAddress(address) {
-		return 0, errors.New("invalid address")
+		return uint256.Int{}, errors.New("invalid address")
 	}
 
 	t.mu.RLock()
@@ -46,7 +58,7 @@ func (t *Token) GetAllAddressesWithBalances() []string {
 
 	addresses := make([]string, 0, len(t.balances))
 	for addr, balance := range t.balances {
-		if balance > 0 {
+		if !balance.IsZero() {
 			addresses = append(addresses, addr)
 		}
 	}
@@ -2,9 +2,11 @@ package token
 
 import(
 	"errors"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
-func (t *Token) Burn(from string, amount uint64) error {
+func (t *Token) Burn(from string, amount uint256.Int) error {
 	if !t.validateAddress(from) {
 		return errors.New("invalid address")
 	}
@@ -12,12 +14,27 @@ func (t *Token) Burn(from string, amount uint64) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if t.balances[from] < amount {
+	if err := t.checkMovement(from, ""); err != nil {
+		return err
+	}
+	balance, err := t.balances[from].Sub(amount)
+	if err != nil {
 		return errors.New("insufficient balance")
 	}
+	if t.spendable(from).Lt(amount) {
+		return ErrLocked
+	}
+	supply, err := t.totalSupply.Sub(amount)
+	if err != nil {
+		return errors.New("burn exceeds total supply")
+	}
 
-	t.balances[from] -= amount
-	t.totalSupply -= amount
+	t.recordBalance(from)
+	t.recordSupply()
+	t.balances[from] = balance
+	t.totalSupply = supply
+	t.storeBalance(from, balance)
+	t.storeSupply()
 
 	// Emit event
 	t.emitEvent(Event{
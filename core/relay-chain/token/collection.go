@@ -0,0 +1,605 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+// CollectionKind is whether a collection's items are unique or held in amounts
+type CollectionKind string
+
+const (
+	NonFungible  CollectionKind = "non_fungible"  // each item ID has exactly one unit and one owner
+	SemiFungible CollectionKind = "semi_fungible" // each item ID has a supply shared out among holders
+)
+
+// Limits on item IDs and metadata URIs
+const (
+	MaxItemIDLength  = 64
+	MaxItemURILength = 256
+)
+
+var (
+	ErrUnknownKind     = errors.New("unknown collection kind")
+	ErrInvalidItem     = errors.New("invalid item")
+	ErrItemExists      = errors.New("item already exists")
+	ErrItemNotFound    = errors.New("item not found")
+	ErrNotItemOperator = errors.New("caller may not move or approve these items")
+	ErrItemBalance     = errors.New("insufficient item balance")
+)
+
+// Valid reports whether k is one of the defined kinds
+func (k CollectionKind) Valid() bool {
+	return k == NonFungible || k == SemiFungible
+}
+
+// ValidItemID reports whether id is 1-64 letters, digits, '.', '_' or '-'
+func ValidItemID(id string) bool {
+	if id == "" || len(id) > MaxItemIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Item is one ID of a collection
+type Item struct {
+	URI    string      `json:"uri"`
+	Supply uint256.Int `json:"supply"`
+	// Approved may move a non-fungible item for its owner until it changes hands
+	Approved string `json:"approved,omitempty"`
+}
+
+// Holding is an amount of one item held by an address
+type Holding struct {
+	ID      string      `json:"id"`
+	Address string      `json:"address"`
+	Amount  uint256.Int `json:"amount"`
+}
+
+// CollectionJournal is told what a collection held before each change, so
+// that a caller can undo the changes later
+type CollectionJournal interface {
+	ItemChanged(symbol, id string, previous *Item) // nil if the item did not exist
+	ItemBalanceChanged(symbol, id, address string, previous uint256.Int)
+	OperatorChanged(symbol, owner, operator string, previous bool)
+}
+
+// CollectionStore receives every change to a collection so it can be
+// persisted. A nil item, zero amount or false approval removes the entry.
+type CollectionStore interface {
+	PutItem(symbol, id string, item *Item)
+	PutItemBalance(symbol, id, address string, amount uint256.Int)
+	PutOperator(symbol, owner, operator string, approved bool)
+}
+
+// Collection is a set of non-fungible or semi-fungible items. An owner can
+// approve one address per non-fungible item, and operators for all its items.
+type Collection struct {
+	Name      string
+	Symbol    string
+	Kind      CollectionKind
+	mu        sync.RWMutex
+	minter    string
+	items     map[string]*Item
+	balances  map[string]map[string]uint256.Int // item ID -> holder -> amount
+	holdings  map[string]map[string]bool        // holder -> IDs of the items it holds
+	operators map[string]map[string]bool        // owner -> operators of all its items
+	journal   CollectionJournal
+	store     CollectionStore
+}
+
+func NewCollection(name, symbol string, kind CollectionKind) *Collection {
+	return &Collection{
+		Name:      name,
+		Symbol:    symbol,
+		Kind:      kind,
+		items:     make(map[string]*Item),
+		balances:  make(map[string]map[string]uint256.Int),
+		holdings:  make(map[string]map[string]bool),
+		operators: make(map[string]map[string]bool),
+	}
+}
+
+func (c *Collection) validateAddress(address string) bool {
+	return address != "" && len(address) < 256
+}
+
+// Minter returns the address allowed to mint items, or an empty string if
+// the collection is closed
+func (c *Collection) Minter() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.minter
+}
+
+// SetMinter sets the address allowed to mint items
+func (c *Collection) SetMinter(address string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.minter = address
+}
+
+// SetJournal reports every later change to j. A nil journal stops reporting.
+func (c *Collection) SetJournal(j CollectionJournal) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.journal = j
+}
+
+// SetStore writes every later change through s and writes the current
+// collection to it, so s starts out complete. A nil store stops writing.
+func (c *Collection) SetStore(s CollectionStore) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store = s
+	if s == nil {
+		return
+	}
+	for id, item := range c.items {
+		s.PutItem(c.Symbol, id, item)
+	}
+	for id, holders := range c.balances {
+		for address, amount := range holders {
+			s.PutItemBalance(c.Symbol, id, address, amount)
+		}
+	}
+	for owner, operators := range c.operators {
+		for operator := range operators {
+			s.PutOperator(c.Symbol, owner, operator, true)
+		}
+	}
+}
+
+// ItemCount returns how many item IDs have a supply
+func (c *Collection) ItemCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.items)
+}
+
+// Item returns a copy of an item
+func (c *Collection) Item(id string) (*Item, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, exists := c.items[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s/%s", ErrItemNotFound, c.Symbol, id)
+	}
+	copied := *item
+	return &copied, nil
+}
+
+// OwnerOf returns the owner of a non-fungible item
+func (c *Collection) OwnerOf(id string) (string, error) {
+	if c.Kind != NonFungible {
+		return "", fmt.Errorf("%w: %s is %s", ErrUnknownKind, c.Symbol, c.Kind)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	owner := c.owner(id)
+	if owner == "" {
+		return "", fmt.Errorf("%w: %s/%s", ErrItemNotFound, c.Symbol, id)
+	}
+	return owner, nil
+}
+
+// BalanceOf returns how much of an item address holds
+func (c *Collection) BalanceOf(address, id string) uint256.Int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.balances[id][address]
+}
+
+// IsOperator reports whether operator may move and approve all of owner's items
+func (c *Collection) IsOperator(owner, operator string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.operators[owner][operator]
+}
+
+// HoldingsOf lists the items address holds, ordered by ID
+func (c *Collection) HoldingsOf(address string) []Holding {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	holdings := make([]Holding, 0, len(c.holdings[address]))
+	for id := range c.holdings[address] {
+		holdings = append(holdings, Holding{id, address, c.balances[id][address]})
+	}
+	sort.Slice(holdings, func(i, j int) bool { return holdings[i].ID < holdings[j].ID })
+	return holdings
+}
+
+// HoldersOf lists who holds an item, ordered by address
+func (c *Collection) HoldersOf(id string) []Holding {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	holders := make([]Holding, 0, len(c.balances[id]))
+	for address, amount := range c.balances[id] {
+		holders = append(holders, Holding{id, address, amount})
+	}
+	sort.Slice(holders, func(i, j int) bool { return holders[i].Address < holders[j].Address })
+	return holders
+}
+
+// CheckMint reports whether caller may mint amount of item id with uri
+func (c *Collection) CheckMint(caller, id string, amount uint256.Int, uri string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.checkMint(caller, id, amount, uri)
+}
+
+// CheckMove reports whether caller may move or burn amount of from's item id
+func (c *Collection) CheckMove(caller, from, id string, amount uint256.Int) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.checkMove(caller, from, id, amount)
+}
+
+// CheckApprove reports whether caller may set the approved address of item id
+func (c *Collection) CheckApprove(caller, id string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, err := c.checkApprove(caller, id)
+	return err
+}
+
+// Mint creates amount of item id for to. A non-fungible item is minted once,
+// with an amount of 1. A semi-fungible item can be minted again to add to its
+// supply; uri is then left empty or repeated. Only the minter may mint.
+func (c *Collection) Mint(caller, to, id string, amount uint256.Int, uri string) error {
+	if !c.validateAddress(to) {
+		return errors.New("invalid address")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.checkMint(caller, id, amount, uri); err != nil {
+		return err
+	}
+	item := &Item{URI: uri}
+	if existing, exists := c.items[id]; exists {
+		copied := *existing
+		item = &copied
+	}
+	supply, err := item.Supply.Add(amount)
+	if err != nil {
+		return fmt.Errorf("%w: supply of %s overflows", ErrInvalidItem, id)
+	}
+	balance, _ := c.balances[id][to].Add(amount) // bounded by the supply
+	item.Supply = supply
+	c.setItem(id, item)
+	c.setBalance(id, to, balance)
+	return nil
+}
+
+// Transfer moves amount of item id from from to to. The caller must be from,
+// an operator of from, or the item's approved address.
+func (c *Collection) Transfer(caller, from, to, id string, amount uint256.Int) error {
+	if !c.validateAddress(to) {
+		return errors.New("invalid address")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.checkMove(caller, from, id, amount); err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+	fromBalance, _ := c.balances[id][from].Sub(amount) // checked by checkMove
+	toBalance, _ := c.balances[id][to].Add(amount)     // bounded by the supply
+	c.setBalance(id, from, fromBalance)
+	c.setBalance(id, to, toBalance)
+
+	// An approval only lasts while its owner holds the item
+	if item := c.items[id]; item.Approved != "" {
+		copied := *item
+		copied.Approved = ""
+		c.setItem(id, &copied)
+	}
+	return nil
+}
+
+// Burn destroys amount of from's item id. The caller must be allowed to move
+// it. An item whose supply reaches zero is removed.
+func (c *Collection) Burn(caller, from, id string, amount uint256.Int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.checkMove(caller, from, id, amount); err != nil {
+		return err
+	}
+	balance, _ := c.balances[id][from].Sub(amount)
+	c.setBalance(id, from, balance)
+
+	copied := *c.items[id]
+	copied.Supply, _ = copied.Supply.Sub(amount) // the supply covers every balance
+	if copied.Supply.IsZero() {
+		c.setItem(id, nil)
+		return nil
+	}
+	c.setItem(id, &copied)
+	return nil
+}
+
+// Approve lets spender move non-fungible item id for its owner. An empty
+// spender clears the approval. The caller must be the owner or its operator.
+func (c *Collection) Approve(caller, id, spender string) error {
+	if spender != "" && !c.validateAddress(spender) {
+		return errors.New("invalid address")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, err := c.checkApprove(caller, id)
+	if err != nil {
+		return err
+	}
+	copied := *item
+	copied.Approved = spender
+	c.setItem(id, &copied)
+	return nil
+}
+
+// SetOperator grants or revokes operator's right to move and approve all of
+// owner's items
+func (c *Collection) SetOperator(owner, operator string, approved bool) error {
+	if !c.validateAddress(owner) || !c.validateAddress(operator) || owner == operator {
+		return errors.New("invalid address")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := c.operators[owner][operator]
+	if previous == approved {
+		return nil
+	}
+	if c.journal != nil {
+		c.journal.OperatorChanged(c.Symbol, owner, operator, previous)
+	}
+	c.restoreOperator(owner, operator, approved)
+	return nil
+}
+
+// RestoreItem sets an item directly when undoing journaled changes. A nil
+// item removes it.
+func (c *Collection) RestoreItem(id string, item *Item) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.restoreItem(id, item)
+}
+
+// RestoreItemBalance sets a balance directly when undoing journaled changes
+func (c *Collection) RestoreItemBalance(id, address string, amount uint256.Int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.restoreBalance(id, address, amount)
+}
+
+// RestoreOperator sets an operator approval directly when undoing journaled changes
+func (c *Collection) RestoreOperator(owner, operator string, approved bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.restoreOperator(owner, operator, approved)
+}
+
+// CollectionLedger is the full state of a collection's items, holdings and operators
+type CollectionLedger struct {
+	Symbol    string          `json:"symbol"`
+	Items     []ItemEntry     `json:"items"`
+	Holdings  []Holding       `json:"holdings"`
+	Operators []OperatorEntry `json:"operators"`
+}
+
+// ItemEntry is one item in a collection ledger
+type ItemEntry struct {
+	ID string `json:"id"`
+	Item
+}
+
+// OperatorEntry is one operator approval in a collection ledger
+type OperatorEntry struct {
+	Owner    string `json:"owner"`
+	Operator string `json:"operator"`
+}
+
+// Export returns the collection's items, holdings and operators, sorted by
+// item ID, then address
+func (c *Collection) Export() *CollectionLedger {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ledger := &CollectionLedger{Symbol: c.Symbol, Items: []ItemEntry{}, Holdings: []Holding{}, Operators: []OperatorEntry{}}
+	for id, item := range c.items {
+		ledger.Items = append(ledger.Items, ItemEntry{id, *item})
+	}
+	for id, holders := range c.balances {
+		for address, amount := range holders {
+			ledger.Holdings = append(ledger.Holdings, Holding{id, address, amount})
+		}
+	}
+	for owner, operators := range c.operators {
+		for operator := range operators {
+			ledger.Operators = append(ledger.Operators, OperatorEntry{owner, operator})
+		}
+	}
+	sort.Slice(ledger.Items, func(i, j int) bool { return ledger.Items[i].ID < ledger.Items[j].ID })
+	sort.Slice(ledger.Holdings, func(i, j int) bool {
+		a, b := ledger.Holdings[i], ledger.Holdings[j]
+		return a.ID < b.ID || (a.ID == b.ID && a.Address < b.Address)
+	})
+	sort.Slice(ledger.Operators, func(i, j int) bool {
+		a, b := ledger.Operators[i], ledger.Operators[j]
+		return a.Owner < b.Owner || (a.Owner == b.Owner && a.Operator < b.Operator)
+	})
+	return ledger
+}
+
+// The check helpers validate an action without changing anything. Caller must hold c.mu.
+
+func (c *Collection) checkMint(caller, id string, amount uint256.Int, uri string) error {
+	if c.minter == "" || c.minter != caller {
+		return fmt.Errorf("%w: minter of %s", ErrUnauthorized, c.Symbol)
+	}
+	if !ValidItemID(id) {
+		return fmt.Errorf("%w: ID must be 1-%d letters, digits, '.', '_' or '-'", ErrInvalidItem, MaxItemIDLength)
+	}
+	if len(uri) > MaxItemURILength {
+		return fmt.Errorf("%w: URI is longer than %d bytes", ErrInvalidItem, MaxItemURILength)
+	}
+	if amount.IsZero() {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidItem)
+	}
+	existing, exists := c.items[id]
+	if c.Kind == NonFungible {
+		if exists {
+			return fmt.Errorf("%w: %s/%s", ErrItemExists, c.Symbol, id)
+		}
+		if amount != uint256.NewInt(1) {
+			return fmt.Errorf("%w: a non-fungible item is minted with an amount of 1", ErrInvalidItem)
+		}
+	}
+	if exists && uri != "" && uri != existing.URI {
+		return fmt.Errorf("%w: %s/%s already has a different URI", ErrInvalidItem, c.Symbol, id)
+	}
+	return nil
+}
+
+func (c *Collection) checkMove(caller, from, id string, amount uint256.Int) error {
+	item, exists := c.items[id]
+	if !exists {
+		return fmt.Errorf("%w: %s/%s", ErrItemNotFound, c.Symbol, id)
+	}
+	if amount.IsZero() {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidItem)
+	}
+	if caller != from && !c.operators[from][caller] && (item.Approved == "" || item.Approved != caller) {
+		return fmt.Errorf("%w: %s for %s", ErrNotItemOperator, caller, from)
+	}
+	if c.balances[id][from].Lt(amount) {
+		return fmt.Errorf("%w: %s holds %s of %s/%s", ErrItemBalance, from, c.balances[id][from], c.Symbol, id)
+	}
+	return nil
+}
+
+func (c *Collection) checkApprove(caller, id string) (*Item, error) {
+	if c.Kind != NonFungible {
+		return nil, fmt.Errorf("%w: only non-fungible items have approvals", ErrInvalidItem)
+	}
+	item, exists := c.items[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s/%s", ErrItemNotFound, c.Symbol, id)
+	}
+	owner := c.owner(id)
+	if caller != owner && !c.operators[owner][caller] {
+		return nil, fmt.Errorf("%w: %s for %s", ErrNotItemOperator, caller, owner)
+	}
+	return item, nil
+}
+
+// owner returns the holder of a non-fungible item, or an empty string if it
+// has none. Caller must hold c.mu.
+func (c *Collection) owner(id string) string {
+	for address := range c.balances[id] {
+		return address
+	}
+	return ""
+}
+
+// The set helpers journal and store a change before making it. Caller must hold c.mu.
+
+func (c *Collection) setItem(id string, item *Item) {
+	if c.journal != nil {
+		var previous *Item
+		if existing, exists := c.items[id]; exists {
+			copied := *existing
+			previous = &copied
+		}
+		c.journal.ItemChanged(c.Symbol, id, previous)
+	}
+	c.restoreItem(id, item)
+}
+
+func (c *Collection) setBalance(id, address string, amount uint256.Int) {
+	if c.journal != nil {
+		c.journal.ItemBalanceChanged(c.Symbol, id, address, c.balances[id][address])
+	}
+	c.restoreBalance(id, address, amount)
+}
+
+// The restore helpers change and store an entry without journaling it. Caller must hold c.mu.
+
+func (c *Collection) restoreItem(id string, item *Item) {
+	if c.store != nil {
+		c.store.PutItem(c.Symbol, id, item)
+	}
+	if item == nil {
+		delete(c.items, id)
+		return
+	}
+	copied := *item
+	c.items[id] = &copied
+}
+
+func (c *Collection) restoreBalance(id, address string, amount uint256.Int) {
+	if c.store != nil {
+		c.store.PutItemBalance(c.Symbol, id, address, amount)
+	}
+	if amount.IsZero() {
+		delete(c.balances[id], address)
+		if len(c.balances[id]) == 0 {
+			delete(c.balances, id)
+		}
+		delete(c.holdings[address], id)
+		if len(c.holdings[address]) == 0 {
+			delete(c.holdings, address)
+		}
+		return
+	}
+	if c.balances[id] == nil {
+		c.balances[id] = make(map[string]uint256.Int)
+	}
+	c.balances[id][address] = amount
+	if c.holdings[address] == nil {
+		c.holdings[address] = make(map[string]bool)
+	}
+	c.holdings[address][id] = true
+}
+
+func (c *Collection) restoreOperator(owner, operator string, approved bool) {
+	if c.store != nil {
+		c.store.PutOperator(c.Symbol, owner, operator, approved)
+	}
+	if !approved {
+		delete(c.operators[owner], operator)
+		if len(c.operators[owner]) == 0 {
+			delete(c.operators, owner)
+		}
+		return
+	}
+	if c.operators[owner] == nil {
+		c.operators[owner] = make(map[string]bool)
+	}
+	c.operators[owner][operator] = true
+}
@@ -4,31 +4,32 @@ import (
 	"fmt"
 
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/token"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
 func main() {
-	tk := token.NewToken("BlackHole", "BLH", 18, 0)
+	tk := token.NewToken("BlackHole", "BLH", 18, uint256.Int{})
 
 	fmt.Println("=== Token Demo ===")
-	fmt.Printf("Initial Supply: %d\n", tk.TotalSupply())
+	fmt.Printf("Initial Supply: %s\n", tk.TotalSupply())
 
 	// Mint tokens
-	tk.Mint("0xAlice", 1000)
-	fmt.Printf("After Mint(Alice,1000): Supply=%d, Alice=%d\n",
+	tk.Mint("0xAlice", uint256.NewInt(1000))
+	fmt.Printf("After Mint(Alice,1000): Supply=%s, Alice=%s\n",
 		tk.TotalSupply(), getBalance(tk, "0xAlice"))
 
 	// Transfer
-	tk.Transfer("0xAlice", "0xBob", 300)
-	fmt.Printf("After Transfer(Alice→Bob,300): Alice=%d, Bob=%d\n",
+	tk.Transfer("0xAlice", "0xBob", uint256.NewInt(300))
+	fmt.Printf("After Transfer(Alice→Bob,300): Alice=%s, Bob=%s\n",
 		getBalance(tk, "0xAlice"), getBalance(tk, "0xBob"))
 
 	// Burn
-	tk.Burn("0xAlice", 200)
-	fmt.Printf("After Burn(Alice,200): Supply=%d, Alice=%d\n",
+	tk.Burn("0xAlice", uint256.NewInt(200))
+	fmt.Printf("After Burn(Alice,200): Supply=%s, Alice=%s\n",
 		tk.TotalSupply(), getBalance(tk, "0xAlice"))
 }
 
-func getBalance(tk *token.Token, addr string) uint64 {
+func getBalance(tk *token.Token, addr string) uint256.Int {
 	bal, _ := tk.BalanceOf(addr)
 	return bal
 }
@@ -1,18 +1,31 @@
 package token
 
+import "github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+
 type EventType string
 
 const (
 	EventTransfer EventType = "Transfer"
 	EventMint     EventType = "Mint"
 	EventBurn     EventType = "Burn"
+
+	EventVestingCreated EventType = "VestingCreated" // Amount moved From the funder To the beneficiary, locked
+
+	EventRoleChanged   EventType = "RoleChanged" // From is the previous holder, To the new one
+	EventPaused        EventType = "Paused"
+	EventUnpaused      EventType = "Unpaused"
+	EventFrozen        EventType = "Frozen" // To is the affected account
+	EventUnfrozen      EventType = "Unfrozen"
+	EventBlocklisted   EventType = "Blocklisted"
+	EventUnblocklisted EventType = "Unblocklisted"
 )
 
 type Event struct {
 	Type    EventType
 	From    string  // Optional (e.g., for Mint)
 	To      string  // Optional (e.g., for Burn)
-	Amount  uint64
+	Amount  uint256.Int
+	Role    Role    // Only for role changes
 }
 
 // Emit events to a channel or logging system (customize as needed)
@@ -0,0 +1,145 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+var (
+	ErrHistoryPruned = errors.New("history at that height has been pruned")
+	ErrFutureHeight  = errors.New("height is above the chain tip")
+)
+
+// Checkpoint is the value an entry held before the block at Height first
+// changed it
+type Checkpoint struct {
+	Height uint64
+	Value  uint256.Int
+}
+
+// History keeps past values by copy-on-write: the first change to an entry in
+// a block saves the value it replaces. The value at height h is then the one
+// saved by the first checkpoint above h, or the current value if the entry has
+// not changed since. The zero History is empty and ready to use. It is not
+// safe for concurrent use; its owner's lock guards it.
+type History struct {
+	checkpoints map[string][]Checkpoint
+	blocks      []changedKeys // keys checkpointed by each block, oldest first
+	pruned      uint64        // heights below this can no longer be answered
+}
+
+// changedKeys lists the keys a block checkpointed, so pruning and rewinding
+// only visit the keys of the blocks they drop
+type changedKeys struct {
+	height uint64
+	keys   []string
+}
+
+// Record saves previous as the value of key before the block at height. Only
+// the first change in each block is kept.
+func (h *History) Record(key string, height uint64, previous uint256.Int) {
+	cps := h.checkpoints[key]
+	if n := len(cps); n > 0 && cps[n-1].Height >= height {
+		return
+	}
+	if h.checkpoints == nil {
+		h.checkpoints = make(map[string][]Checkpoint)
+	}
+	h.checkpoints[key] = append(cps, Checkpoint{height, previous})
+
+	if n := len(h.blocks); n > 0 && h.blocks[n-1].height == height {
+		h.blocks[n-1].keys = append(h.blocks[n-1].keys, key)
+	} else {
+		h.blocks = append(h.blocks, changedKeys{height: height, keys: []string{key}})
+	}
+}
+
+// At returns the value key held at the end of the block at height, given its
+// current value
+func (h *History) At(key string, height uint64, current uint256.Int) (uint256.Int, error) {
+	if height < h.pruned {
+		return uint256.Int{}, fmt.Errorf("%w: kept from height %d", ErrHistoryPruned, h.pruned)
+	}
+	cps := h.checkpoints[key]
+	i := sort.Search(len(cps), func(i int) bool { return cps[i].Height > height })
+	if i == len(cps) {
+		return current, nil
+	}
+	return cps[i].Value, nil
+}
+
+// Rewind drops the checkpoints of blocks above height, after those blocks
+// were disconnected and their changes undone
+func (h *History) Rewind(height uint64) {
+	for n := len(h.blocks); n > 0 && h.blocks[n-1].height > height; n-- {
+		for _, key := range h.blocks[n-1].keys {
+			cps := h.checkpoints[key]
+			if len(cps) == 1 {
+				delete(h.checkpoints, key)
+			} else {
+				h.checkpoints[key] = cps[:len(cps)-1]
+			}
+		}
+		h.blocks = h.blocks[:n-1]
+	}
+}
+
+// Prune forgets every height below before. Checkpoints at or below before
+// only answer those heights, so they are dropped.
+func (h *History) Prune(before uint64) {
+	if before <= h.pruned {
+		return
+	}
+	h.pruned = before
+	for len(h.blocks) > 0 && h.blocks[0].height <= before {
+		for _, key := range h.blocks[0].keys {
+			cps := h.checkpoints[key]
+			if len(cps) == 1 {
+				delete(h.checkpoints, key)
+			} else {
+				h.checkpoints[key] = cps[1:]
+			}
+		}
+		h.blocks[0] = changedKeys{}
+		h.blocks = h.blocks[1:]
+	}
+}
+
+// BalanceOfAt returns the balance address held at the end of the block at
+// height. Heights come from the token's clock, so a token without one only
+// answers height 0.
+func (t *Token) BalanceOfAt(address string, height uint64) (uint256.Int, error) {
+	if !t.validateAddress(address) {
+		return uint256.Int{}, errors.New("invalid address")
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	tip, _ := t.clock.Now()
+	switch {
+	case height > tip:
+		return uint256.Int{}, fmt.Errorf("%w: %d > %d", ErrFutureHeight, height, tip)
+	case height == tip:
+		return t.balances[address], nil
+	}
+	return t.history.At(address, height, t.balances[address])
+}
+
+// RewindHistory drops balance history above height once the blocks above it
+// have been disconnected
+func (t *Token) RewindHistory(height uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.history.Rewind(height)
+}
+
+// PruneHistory forgets balances at heights below before
+func (t *Token) PruneHistory(before uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.history.Prune(before)
+}
@@ -0,0 +1,96 @@
+package token
+
+import "github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+
+// Journal is told what a token's state held before each change, so that a
+// caller can undo the changes later
+type Journal interface {
+	BalanceChanged(symbol, address string, previous uint256.Int)
+	SupplyChanged(symbol string, previous uint256.Int)
+	AllowanceChanged(symbol, owner, spender string, previous uint256.Int)
+	ControlsChanged(symbol string, previous *Controls)
+	VestingChanged(symbol, address string, previous []VestingSchedule)
+}
+
+// SetJournal reports every later change to j. A nil journal stops reporting.
+func (t *Token) SetJournal(j Journal) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.journal = j
+}
+
+// RestoreBalance sets a balance directly, without checks or events. It is only
+// meant for undoing journaled changes.
+func (t *Token) RestoreBalance(address string, amount uint256.Int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.storeBalance(address, amount)
+	if amount.IsZero() {
+		delete(t.balances, address)
+		return
+	}
+	t.balances[address] = amount
+}
+
+// RestoreSupply sets the total supply directly when undoing journaled changes
+func (t *Token) RestoreSupply(supply uint256.Int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.totalSupply = supply
+	t.storeSupply()
+}
+
+// RestoreAllowance sets an allowance directly when undoing journaled changes
+func (t *Token) RestoreAllowance(owner, spender string, amount uint256.Int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.allowances[owner] == nil {
+		t.allowances[owner] = make(map[string]uint256.Int)
+	}
+	t.allowances[owner][spender] = amount
+	t.storeAllowance(owner, spender, amount)
+}
+
+// RestoreControls sets the roles, pause flag, frozen accounts and blocklist
+// directly when undoing journaled changes
+func (t *Token) RestoreControls(c *Controls) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.controls = *c.clone()
+}
+
+// The record helpers report the current value before it changes. Caller must hold t.mu.
+
+// recordBalance also checkpoints the balance into the history, so restored
+// balances, which skip it, leave no checkpoint
+func (t *Token) recordBalance(address string) {
+	height, _ := t.clock.Now()
+	t.history.Record(address, height, t.balances[address])
+	if t.journal != nil {
+		t.journal.BalanceChanged(t.Symbol, address, t.balances[address])
+	}
+}
+
+func (t *Token) recordSupply() {
+	if t.journal != nil {
+		t.journal.SupplyChanged(t.Symbol, t.totalSupply)
+	}
+}
+
+func (t *Token) recordAllowance(owner, spender string) {
+	if t.journal != nil {
+		t.journal.AllowanceChanged(t.Symbol, owner, spender, t.allowances[owner][spender])
+	}
+}
+
+func (t *Token) recordControls() {
+	if t.journal != nil {
+		t.journal.ControlsChanged(t.Symbol, t.controls.clone())
+	}
+}
+
+func (t *Token) recordVesting(address string) {
+	if t.journal != nil {
+		t.journal.VestingChanged(t.Symbol, address, append([]VestingSchedule(nil), t.vesting[address]...))
+	}
+}
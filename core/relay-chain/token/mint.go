@@ -3,16 +3,20 @@ package token
 import (
 	"errors"
 	"log"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
-func (t *Token) Mint(to string, amount uint64) error {
-	log.Printf("Minting %d tokens to %s", amount, to)
+// Mint creates amount for to without checking roles. Genesis and node
+// administration use it directly; mints on chain go through MintAs.
+func (t *Token) Mint(to string, amount uint256.Int) error {
+	log.Printf("Minting %s tokens to %s", amount, to)
 	if !t.validateAddress(to) {
 		err := errors.New("invalid address")
 		log.Printf("Mint failed: %v", err)
 		return err
 	}
-	if amount == 0 {
+	if amount.IsZero() {
 		err := errors.New("amount must be > 0")
 		log.Printf("Mint failed: %v", err)
 		return err
@@ -21,41 +25,49 @@ func (t *Token) Mint(to string, amount uint64) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	// Check if token has a maximum supply limit
-	if t.maxSupply > 0 {
-		// Calculate current circulating supply
-		currentSupply := uint64(0)
-		for _, balance := range t.balances {
-			currentSupply += balance
-		}
-
-		// Check if minting would exceed max supply
-		if currentSupply+amount > t.maxSupply {
-			err := errors.New("mint amount would exceed maximum supply")
-			log.Printf("Mint failed: %v (current: %d, requested: %d, max: %d)", err, currentSupply, amount, t.maxSupply)
-			return err
-		}
+	if err := t.checkMovement("", to); err != nil {
+		log.Printf("Mint failed: %v", err)
+		return err
 	}
 
-	// Overflow protection
-	if t.balances[to] > ^uint64(0)-amount {
-		err := errors.New("mint amount causes balance overflow")
+	// Calculate current circulating supply
+	currentSupply, err := t.circulating()
+	if err != nil {
+		log.Printf("Mint failed: %v", err)
+		return err
+	}
+	newSupply, err := currentSupply.Add(amount)
+	if err != nil {
+		err := errors.New("mint amount causes supply overflow")
 		log.Printf("Mint failed: %v", err)
 		return err
 	}
 
-	// Calculate current circulating supply for validation
-	currentSupply := uint64(0)
-	for _, balance := range t.balances {
-		currentSupply += balance
+	// Check if minting would exceed max supply
+	if !t.maxSupply.IsZero() && newSupply.Gt(t.maxSupply) {
+		err := errors.New("mint amount would exceed maximum supply")
+		log.Printf("Mint failed: %v (current: %s, requested: %s, max: %s)", err, currentSupply, amount, t.maxSupply)
+		return err
+	}
+
+	// Overflow protection
+	balance, err := t.balances[to].Add(amount)
+	if err != nil {
+		err := errors.New("mint amount causes balance overflow")
+		log.Printf("Mint failed: %v", err)
+		return err
 	}
 
-	t.balances[to] += amount
+	t.recordBalance(to)
+	t.recordSupply()
+	t.balances[to] = balance
 
 	// Update total supply to reflect actual circulating supply
-	t.totalSupply = currentSupply + amount
+	t.totalSupply = newSupply
+	t.storeBalance(to, balance)
+	t.storeSupply()
 
-	log.Printf("Mint successful: Balances[%s]=%d, TotalSupply=%d", to, t.balances[to], t.totalSupply)
+	log.Printf("Mint successful: Balances[%s]=%s, TotalSupply=%s", to, balance, t.totalSupply)
 	t.emitEvent(Event{Type: EventMint, To: to, Amount: amount})
 	log.Printf("Event emitted for mint: %+v", Event{Type: EventMint, To: to, Amount: amount})
 	return nil
@@ -0,0 +1,272 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+// Role is an administrative permission on a token. Each role is held by at
+// most one address.
+type Role string
+
+const (
+	RoleAdmin   Role = "admin"   // assigns every role, including its own
+	RoleMinter  Role = "minter"  // mints new supply
+	RolePauser  Role = "pauser"  // pauses and unpauses the token
+	RoleFreezer Role = "freezer" // freezes accounts and keeps the blocklist
+)
+
+// Roles lists every role in a fixed order
+var Roles = []Role{RoleAdmin, RoleMinter, RolePauser, RoleFreezer}
+
+var (
+	ErrUnknownRole  = errors.New("unknown role")
+	ErrUnauthorized = errors.New("caller does not hold the required role")
+	ErrPaused       = errors.New("token is paused")
+	ErrFrozen       = errors.New("account is frozen")
+	ErrBlocklisted  = errors.New("account is blocklisted")
+)
+
+// Valid reports whether r is one of the defined roles
+func (r Role) Valid() bool {
+	for _, role := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Controls is the administrative state of a token. While a token is paused no
+// balance changes. A frozen account cannot send, burn or have its tokens
+// spent, but can still receive. A blocklisted account can neither send nor
+// receive, and cannot spend allowances.
+type Controls struct {
+	Roles       map[Role]string `json:"roles"`
+	Paused      bool            `json:"paused"`
+	Frozen      map[string]bool `json:"frozen"`
+	Blocklisted map[string]bool `json:"blocklisted"`
+}
+
+func (c *Controls) clone() *Controls {
+	copied := &Controls{
+		Roles:       make(map[Role]string, len(c.Roles)),
+		Paused:      c.Paused,
+		Frozen:      make(map[string]bool, len(c.Frozen)),
+		Blocklisted: make(map[string]bool, len(c.Blocklisted)),
+	}
+	for role, holder := range c.Roles {
+		copied.Roles[role] = holder
+	}
+	for address := range c.Frozen {
+		copied.Frozen[address] = true
+	}
+	for address := range c.Blocklisted {
+		copied.Blocklisted[address] = true
+	}
+	return copied
+}
+
+// Controls returns a copy of the token's roles, pause flag, frozen accounts and blocklist
+func (t *Token) Controls() *Controls {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.controls.clone()
+}
+
+// RoleHolder returns the address holding role, or an empty string
+func (t *Token) RoleHolder(role Role) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.controls.Roles[role]
+}
+
+// SetRoleHolder assigns role without any checks or events. It is meant for
+// setting up a token before it is used; later changes go through GrantRole.
+func (t *Token) SetRoleHolder(role Role, address string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.setRole(role, address)
+}
+
+// Paused reports whether the token is paused
+func (t *Token) Paused() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.controls.Paused
+}
+
+// IsFrozen reports whether address is frozen
+func (t *Token) IsFrozen(address string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.controls.Frozen[address]
+}
+
+// IsBlocklisted reports whether address is on the blocklist
+func (t *Token) IsBlocklisted(address string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.controls.Blocklisted[address]
+}
+
+// GrantRole gives role to address, replacing its previous holder. An empty
+// address revokes the role. Only the admin may change roles.
+func (t *Token) GrantRole(caller string, role Role, address string) error {
+	if !role.Valid() {
+		return fmt.Errorf("%w: %q", ErrUnknownRole, role)
+	}
+	if address != "" && !t.validateAddress(address) {
+		return errors.New("invalid address")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.requireRole(caller, RoleAdmin); err != nil {
+		return err
+	}
+	previous := t.controls.Roles[role]
+	t.recordControls()
+	t.setRole(role, address)
+	t.emitEvent(Event{Type: EventRoleChanged, Role: role, From: previous, To: address})
+	return nil
+}
+
+// Pause stops every transfer, mint and burn until Unpause. Only the pauser may pause.
+func (t *Token) Pause(caller string) error {
+	return t.setPaused(caller, true)
+}
+
+// Unpause lifts a pause. Only the pauser may unpause.
+func (t *Token) Unpause(caller string) error {
+	return t.setPaused(caller, false)
+}
+
+func (t *Token) setPaused(caller string, paused bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.requireRole(caller, RolePauser); err != nil {
+		return err
+	}
+	if t.controls.Paused == paused {
+		return nil
+	}
+	t.recordControls()
+	t.controls.Paused = paused
+
+	eventType := EventUnpaused
+	if paused {
+		eventType = EventPaused
+	}
+	t.emitEvent(Event{Type: eventType, From: caller})
+	return nil
+}
+
+// Freeze stops address from moving its tokens. Only the freezer may freeze.
+func (t *Token) Freeze(caller, address string) error {
+	return t.setFlag(caller, address, &t.controls.Frozen, true, EventFrozen)
+}
+
+// Unfreeze lets a frozen address move its tokens again
+func (t *Token) Unfreeze(caller, address string) error {
+	return t.setFlag(caller, address, &t.controls.Frozen, false, EventUnfrozen)
+}
+
+// Blocklist bars address from sending, receiving and spending the token.
+// Only the freezer may change the blocklist.
+func (t *Token) Blocklist(caller, address string) error {
+	return t.setFlag(caller, address, &t.controls.Blocklisted, true, EventBlocklisted)
+}
+
+// Unblocklist removes address from the blocklist
+func (t *Token) Unblocklist(caller, address string) error {
+	return t.setFlag(caller, address, &t.controls.Blocklisted, false, EventUnblocklisted)
+}
+
+// setFlag adds address to or removes it from one of the freezer's sets
+func (t *Token) setFlag(caller, address string, set *map[string]bool, on bool, eventType EventType) error {
+	if !t.validateAddress(address) {
+		return errors.New("invalid address")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.requireRole(caller, RoleFreezer); err != nil {
+		return err
+	}
+	if (*set)[address] == on {
+		return nil
+	}
+	t.recordControls()
+	if on {
+		if *set == nil {
+			*set = make(map[string]bool)
+		}
+		(*set)[address] = true
+	} else {
+		delete(*set, address)
+	}
+	t.emitEvent(Event{Type: eventType, From: caller, To: address})
+	return nil
+}
+
+// MintAs mints like Mint, but only if caller holds the minter role
+func (t *Token) MintAs(caller, to string, amount uint256.Int) error {
+	t.mu.RLock()
+	err := t.requireRole(caller, RoleMinter)
+	t.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return t.Mint(to, amount)
+}
+
+// CheckMovement reports whether tokens may currently leave from and reach to.
+// An empty address is not checked, so a burn passes no recipient and a mint
+// no sender.
+func (t *Token) CheckMovement(from, to string) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.checkMovement(from, to)
+}
+
+// checkMovement is CheckMovement for callers that hold t.mu
+func (t *Token) checkMovement(from, to string) error {
+	if t.controls.Paused {
+		return fmt.Errorf("%w: %s", ErrPaused, t.Symbol)
+	}
+	for _, address := range []string{from, to} {
+		if address != "" && t.controls.Blocklisted[address] {
+			return fmt.Errorf("%w: %s", ErrBlocklisted, address)
+		}
+	}
+	if from != "" && t.controls.Frozen[from] {
+		return fmt.Errorf("%w: %s", ErrFrozen, from)
+	}
+	return nil
+}
+
+// requireRole fails unless caller holds role. Caller must hold t.mu.
+func (t *Token) requireRole(caller string, role Role) error {
+	if holder := t.controls.Roles[role]; holder == "" || holder != caller {
+		return fmt.Errorf("%w: %s of %s", ErrUnauthorized, role, t.Symbol)
+	}
+	return nil
+}
+
+// setRole assigns or, for an empty address, clears a role. Caller must hold t.mu.
+func (t *Token) setRole(role Role, address string) {
+	if address == "" {
+		delete(t.controls.Roles, role)
+		return
+	}
+	if t.controls.Roles == nil {
+		t.controls.Roles = make(map[Role]string)
+	}
+	t.controls.Roles[role] = address
+}
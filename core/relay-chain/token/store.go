@@ -0,0 +1,232 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+// Store receives every change to a token's ledger so it can be persisted.
+// A zero amount or an empty list means the entry was removed.
+type Store interface {
+	PutBalance(symbol, address string, amount uint256.Int)
+	PutSupply(symbol string, supply uint256.Int)
+	PutAllowance(symbol, owner, spender string, amount uint256.Int)
+	PutVesting(symbol, address string, schedules []VestingSchedule)
+}
+
+// Holder is one balance in an exported ledger
+type Holder struct {
+	Address string      `json:"address"`
+	Balance uint256.Int `json:"balance"`
+}
+
+// AllowanceEntry is one allowance in an exported ledger
+type AllowanceEntry struct {
+	Owner   string      `json:"owner"`
+	Spender string      `json:"spender"`
+	Amount  uint256.Int `json:"amount"`
+}
+
+// Ledger is the full state of a token's balances, allowances and vesting schedules
+type Ledger struct {
+	Symbol      string            `json:"symbol"`
+	TotalSupply uint256.Int       `json:"total_supply"`
+	Holders     []Holder          `json:"holders"`
+	Allowances  []AllowanceEntry  `json:"allowances"`
+	Vesting     []VestingSchedule `json:"vesting"`
+}
+
+var ErrInvalidLedger = errors.New("invalid token ledger")
+
+// SetStore writes every later change through s and writes the current ledger
+// to it, so s starts out complete. A nil store stops writing.
+func (t *Token) SetStore(s Store) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.store = s
+	if s == nil {
+		return
+	}
+	s.PutSupply(t.Symbol, t.totalSupply)
+	for address, balance := range t.balances {
+		s.PutBalance(t.Symbol, address, balance)
+	}
+	for owner, spenders := range t.allowances {
+		for spender, amount := range spenders {
+			s.PutAllowance(t.Symbol, owner, spender, amount)
+		}
+	}
+	for address, schedules := range t.vesting {
+		s.PutVesting(t.Symbol, address, schedules)
+	}
+}
+
+// Export returns the token's ledger with holders, allowances and vesting
+// schedules sorted by address
+func (t *Token) Export() *Ledger {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	ledger := &Ledger{
+		Symbol:      t.Symbol,
+		TotalSupply: t.totalSupply,
+		Holders:     make([]Holder, 0, len(t.balances)),
+		Allowances:  []AllowanceEntry{},
+		Vesting:     sortedVesting(t.vesting),
+	}
+	for address, balance := range t.balances {
+		if !balance.IsZero() {
+			ledger.Holders = append(ledger.Holders, Holder{address, balance})
+		}
+	}
+	for owner, spenders := range t.allowances {
+		for spender, amount := range spenders {
+			if !amount.IsZero() {
+				ledger.Allowances = append(ledger.Allowances, AllowanceEntry{owner, spender, amount})
+			}
+		}
+	}
+	sort.Slice(ledger.Holders, func(i, j int) bool {
+		return ledger.Holders[i].Address < ledger.Holders[j].Address
+	})
+	sort.Slice(ledger.Allowances, func(i, j int) bool {
+		a, b := ledger.Allowances[i], ledger.Allowances[j]
+		return a.Owner < b.Owner || (a.Owner == b.Owner && a.Spender < b.Spender)
+	})
+	return ledger
+}
+
+// Import replaces the token's balances, allowances, vesting schedules and
+// supply with ledger. The holders must add up to the total supply, which must
+// fit the max supply.
+func (t *Token) Import(ledger *Ledger) error {
+	if ledger.Symbol != t.Symbol {
+		return fmt.Errorf("%w: ledger is for %s, not %s", ErrInvalidLedger, ledger.Symbol, t.Symbol)
+	}
+
+	balances := make(map[string]uint256.Int, len(ledger.Holders))
+	var total uint256.Int
+	for _, h := range ledger.Holders {
+		if !t.validateAddress(h.Address) {
+			return fmt.Errorf("%w: invalid holder address %q", ErrInvalidLedger, h.Address)
+		}
+		if _, dup := balances[h.Address]; dup {
+			return fmt.Errorf("%w: holder %s listed twice", ErrInvalidLedger, h.Address)
+		}
+		var err error
+		if total, err = total.Add(h.Balance); err != nil {
+			return fmt.Errorf("%w: balances overflow", ErrInvalidLedger)
+		}
+		balances[h.Address] = h.Balance
+	}
+	if total != ledger.TotalSupply {
+		return fmt.Errorf("%w: holders add up to %s, total supply is %s", ErrInvalidLedger, total, ledger.TotalSupply)
+	}
+	if !t.maxSupply.IsZero() && total.Gt(t.maxSupply) {
+		return fmt.Errorf("%w: total supply %s exceeds max supply %s", ErrInvalidLedger, total, t.maxSupply)
+	}
+
+	allowances := make(map[string]map[string]uint256.Int)
+	for _, a := range ledger.Allowances {
+		if !t.validateAddress(a.Owner) || !t.validateAddress(a.Spender) {
+			return fmt.Errorf("%w: invalid allowance %s -> %s", ErrInvalidLedger, a.Owner, a.Spender)
+		}
+		if allowances[a.Owner] == nil {
+			allowances[a.Owner] = make(map[string]uint256.Int)
+		}
+		allowances[a.Owner][a.Spender] = a.Amount
+	}
+
+	vesting := make(map[string][]VestingSchedule)
+	for _, s := range ledger.Vesting {
+		if !t.validateAddress(s.Beneficiary) {
+			return fmt.Errorf("%w: invalid vesting beneficiary %q", ErrInvalidLedger, s.Beneficiary)
+		}
+		if err := s.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidLedger, err)
+		}
+		if countGranted(vesting[s.Beneficiary], s.Grantor) == MaxVestingSchedules {
+			return fmt.Errorf("%w: %s has more than %d vesting schedules from %s", ErrInvalidLedger, s.Beneficiary, MaxVestingSchedules, s.Grantor)
+		}
+		vesting[s.Beneficiary] = append(vesting[s.Beneficiary], s)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Clear entries the ledger drops, then write the new ones. Unchanged
+	// balances are skipped so they leave no history checkpoint.
+	for address := range t.balances {
+		if _, kept := balances[address]; !kept {
+			t.recordBalance(address)
+			t.storeBalance(address, uint256.Int{})
+		}
+	}
+	for owner, spenders := range t.allowances {
+		for spender := range spenders {
+			if _, kept := allowances[owner][spender]; !kept {
+				t.recordAllowance(owner, spender)
+				t.storeAllowance(owner, spender, uint256.Int{})
+			}
+		}
+	}
+	for address := range t.vesting {
+		if _, kept := vesting[address]; !kept {
+			t.recordVesting(address)
+			t.storeVesting(address, nil)
+		}
+	}
+	for address, balance := range balances {
+		if current, exists := t.balances[address]; exists && current == balance {
+			continue
+		}
+		t.recordBalance(address)
+		t.storeBalance(address, balance)
+	}
+	for owner, spenders := range allowances {
+		for spender, amount := range spenders {
+			t.recordAllowance(owner, spender)
+			t.storeAllowance(owner, spender, amount)
+		}
+	}
+	for address, schedules := range vesting {
+		t.recordVesting(address)
+		t.storeVesting(address, schedules)
+	}
+	t.recordSupply()
+	t.balances = balances
+	t.allowances = allowances
+	t.vesting = vesting
+	t.totalSupply = total
+	t.storeSupply()
+	return nil
+}
+
+// The store helpers write a value through to the store. Caller must hold t.mu.
+
+func (t *Token) storeBalance(address string, amount uint256.Int) {
+	if t.store != nil {
+		t.store.PutBalance(t.Symbol, address, amount)
+	}
+}
+
+func (t *Token) storeSupply() {
+	if t.store != nil {
+		t.store.PutSupply(t.Symbol, t.totalSupply)
+	}
+}
+
+func (t *Token) storeAllowance(owner, spender string, amount uint256.Int) {
+	if t.store != nil {
+		t.store.PutAllowance(t.Symbol, owner, spender, amount)
+	}
+}
+
+func (t *Token) storeVesting(address string, schedules []VestingSchedule) {
+	if t.store != nil {
+		t.store.PutVesting(t.Symbol, address, schedules)
+	}
+}
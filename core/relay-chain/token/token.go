@@ -2,44 +2,52 @@ package token
 
 import (
 	"sync"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
 type Token struct {
 	Name        string
 	Symbol      string
 	Decimals    uint8
-	totalSupply uint64
-	maxSupply   uint64 // Maximum supply limit (0 = unlimited)
-	balances    map[string]uint64
-	allowances  map[string]map[string]uint64
+	totalSupply uint256.Int
+	maxSupply   uint256.Int // Maximum supply limit (0 = unlimited)
+	balances    map[string]uint256.Int
+	allowances  map[string]map[string]uint256.Int
 	mu          sync.RWMutex
+	controls    Controls // Roles, pause flag, frozen accounts and blocklist
+	vesting     map[string][]VestingSchedule
+	clock       *Clock
+	history     History // balances before each block changed them
 	events      []Event
+	journal     Journal
+	store       Store
 }
 
-func NewToken(name, symbol string, decimals uint8, initialSupply uint64) *Token {
+func NewToken(name, symbol string, decimals uint8, initialSupply uint256.Int) *Token {
 	t := &Token{
 		Name:        name,
 		Symbol:      symbol,
 		Decimals:    decimals,
-		totalSupply: 0,             // Start with 0, will be updated as tokens are minted
+		totalSupply: uint256.Int{}, // Start with 0, will be updated as tokens are minted
 		maxSupply:   initialSupply, // Set max supply to initial supply parameter
-		balances:    make(map[string]uint64),
-		allowances:  make(map[string]map[string]uint64),
+		balances:    make(map[string]uint256.Int),
+		allowances:  make(map[string]map[string]uint256.Int),
 		events:      []Event{},
 	}
 	return t
 }
 
 // NewTokenWithMaxSupply creates a token with a specific maximum supply
-func NewTokenWithMaxSupply(name, symbol string, decimals uint8, maxSupply uint64) *Token {
+func NewTokenWithMaxSupply(name, symbol string, decimals uint8, maxSupply uint256.Int) *Token {
 	t := &Token{
 		Name:        name,
 		Symbol:      symbol,
 		Decimals:    decimals,
-		totalSupply: 0,
+		totalSupply: uint256.Int{},
 		maxSupply:   maxSupply,
-		balances:    make(map[string]uint64),
-		allowances:  make(map[string]map[string]uint64),
+		balances:    make(map[string]uint256.Int),
+		allowances:  make(map[string]map[string]uint256.Int),
 		events:      []Event{},
 	}
 	return t
@@ -48,3 +56,14 @@ func NewTokenWithMaxSupply(name, symbol string, decimals uint8, maxSupply uint64
 func (t *Token) validateAddress(address string) bool {
 	return address != "" && len(address) < 256
 }
+
+// MintAuthority returns the address allowed to mint the token on chain, or an
+// empty string if the supply is fixed
+func (t *Token) MintAuthority() string {
+	return t.RoleHolder(RoleMinter)
+}
+
+// SetMintAuthority sets the address allowed to mint the token on chain
+func (t *Token) SetMintAuthority(address string) {
+	t.SetRoleHolder(RoleMinter, address)
+}
@@ -3,125 +3,453 @@ package token
 import (
 	"sync"
 	"testing"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestNewToken(t *testing.T) {
 	t.Run("Valid Initialization", func(t *testing.T) {
-		tk := NewToken("BlackHole", "BLH", 18, 1000)
+		tk := NewToken("BlackHole", "BLH", 18, uint256.NewInt(1000))
 		assert.Equal(t, "BlackHole", tk.Name)
 		assert.Equal(t, uint8(18), tk.Decimals)
-		assert.Equal(t, uint64(1000), tk.TotalSupply())
+		assert.Equal(t, uint256.NewInt(1000), tk.TotalSupply())
 	})
 }
 
 func TestMint(t *testing.T) {
-	tk := NewToken("Test", "TST", 18, 0)
+	tk := NewToken("Test", "TST", 18, uint256.NewInt(0))
 
 	t.Run("Mint to valid address", func(t *testing.T) {
-		err := tk.Mint("0xAlice", 500)
+		err := tk.Mint("0xAlice", uint256.NewInt(500))
 		assert.Nil(t, err)
-		assert.Equal(t, uint64(500), tk.TotalSupply())
+		assert.Equal(t, uint256.NewInt(500), tk.TotalSupply())
 		
 		balance, _ := tk.BalanceOf("0xAlice")
-		assert.Equal(t, uint64(500), balance)
+		assert.Equal(t, uint256.NewInt(500), balance)
 	})
 
 	t.Run("Mint zero amount", func(t *testing.T) {
-		err := tk.Mint("0xBob", 0)
+		err := tk.Mint("0xBob", uint256.NewInt(0))
 		assert.ErrorContains(t, err, "amount must be > 0")
 	})
 }
 
 func TestBurn(t *testing.T) {
 	// Initialize with 1000 total supply
-	tk := NewToken("Test", "TST", 18, 1000)
+	tk := NewToken("Test", "TST", 18, uint256.NewInt(1000))
 	
 	// Mint 500 to Alice (total supply = 1000 + 500 = 1500)
-	tk.Mint("0xAlice", 500)
+	tk.Mint("0xAlice", uint256.NewInt(500))
 
 	t.Run("Burn valid amount", func(t *testing.T) {
 		// Burn 300 from Alice
-		err := tk.Burn("0xAlice", 300)
+		err := tk.Burn("0xAlice", uint256.NewInt(300))
 		assert.Nil(t, err)
 
 		// Verify total supply (1500 - 300 = 1200)
-		assert.Equal(t, uint64(1200), tk.TotalSupply())
+		assert.Equal(t, uint256.NewInt(1200), tk.TotalSupply())
 
 		// Verify Alice's balance (500 - 300 = 200)
 		balance, _ := tk.BalanceOf("0xAlice")
-		assert.Equal(t, uint64(200), balance)
+		assert.Equal(t, uint256.NewInt(200), balance)
 	})
 }
 
 func TestTransfer(t *testing.T) {
-	tk := NewToken("Test", "TST", 18, 1000)
-	tk.Mint("0xAlice", 1000)
+	tk := NewToken("Test", "TST", 18, uint256.NewInt(1000))
+	tk.Mint("0xAlice", uint256.NewInt(1000))
 
 	t.Run("Valid transfer", func(t *testing.T) {
-		err := tk.Transfer("0xAlice", "0xBob", 200)
+		err := tk.Transfer("0xAlice", "0xBob", uint256.NewInt(200))
 		assert.Nil(t, err)
 		
 		aliceBal, _ := tk.BalanceOf("0xAlice")
 		bobBal, _ := tk.BalanceOf("0xBob")
-		assert.Equal(t, uint64(800), aliceBal)
-		assert.Equal(t, uint64(200), bobBal)
+		assert.Equal(t, uint256.NewInt(800), aliceBal)
+		assert.Equal(t, uint256.NewInt(200), bobBal)
 	})
 
 	t.Run("Insufficient balance", func(t *testing.T) {
-		err := tk.Transfer("0xAlice", "0xBob", 1000)
+		err := tk.Transfer("0xAlice", "0xBob", uint256.NewInt(1000))
 		assert.ErrorContains(t, err, "insufficient balance")
 	})
 }
 
 func TestAllowances(t *testing.T) {
-	tk := NewToken("Test", "TST", 18, 1000)
-	tk.Mint("0xOwner", 1000)
+	tk := NewToken("Test", "TST", 18, uint256.NewInt(1000))
+	tk.Mint("0xOwner", uint256.NewInt(1000))
 
 	t.Run("Approve and TransferFrom", func(t *testing.T) {
-		_ = tk.Approve("0xOwner", "0xSpender", 300)
+		_ = tk.Approve("0xOwner", "0xSpender", uint256.NewInt(300))
 		
 		allowance, _ := tk.Allowance("0xOwner", "0xSpender")
-		assert.Equal(t, uint64(300), allowance)
+		assert.Equal(t, uint256.NewInt(300), allowance)
 
-		err := tk.TransferFrom("0xOwner", "0xSpender", "0xRecipient", 200)
+		err := tk.TransferFrom("0xOwner", "0xSpender", "0xRecipient", uint256.NewInt(200))
 		assert.Nil(t, err)
 		
 		recipientBal, _ := tk.BalanceOf("0xRecipient")
-		assert.Equal(t, uint64(200), recipientBal)
+		assert.Equal(t, uint256.NewInt(200), recipientBal)
 	})
 }
 
 func TestConcurrentMints(t *testing.T) {
-	tk := NewToken("Test", "TST", 18, 0)
+	tk := NewToken("Test", "TST", 18, uint256.NewInt(0))
 	var wg sync.WaitGroup
 
 	for i := 0; i < 100; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			_ = tk.Mint("0xConcurrent", 10)
+			_ = tk.Mint("0xConcurrent", uint256.NewInt(10))
 		}()
 	}
 
 	wg.Wait()
-	assert.Equal(t, uint64(1000), tk.TotalSupply()) // 100 goroutines * 10 tokens
+	assert.Equal(t, uint256.NewInt(1000), tk.TotalSupply()) // 100 goroutines * 10 tokens
 }
 
 func TestEdgeCases(t *testing.T) {
-	tk := NewToken("Test", "TST", 18, 0)
+	tk := NewToken("Test", "TST", 18, uint256.NewInt(0))
 
 	t.Run("Overflow protection", func(t *testing.T) {
-			// Mint max uint64 to Alice
-			err := tk.Mint("0xAlice", ^uint64(0))
+			// Mint the largest amount to Alice
+			err := tk.Mint("0xAlice", uint256.Max)
 			assert.Nil(t, err)
 
 			// Attempt to mint 1 more (should overflow)
-			err = tk.Mint("0xAlice", 1)
+			err = tk.Mint("0xAlice", uint256.NewInt(1))
 			assert.ErrorContains(t, err, "overflow")
 
 			// Verify balance didn't change
 			balance, _ := tk.BalanceOf("0xAlice")
-			assert.Equal(t, ^uint64(0), balance)
+			assert.Equal(t, uint256.Max, balance)
 	})
-}
\ No newline at end of file
+}
+// mapStore records the latest value written for each ledger entry
+type mapStore map[string]uint256.Int
+
+func (s mapStore) PutBalance(symbol, address string, amount uint256.Int) {
+	s["balance/"+symbol+"/"+address] = amount
+}
+
+func (s mapStore) PutSupply(symbol string, supply uint256.Int) {
+	s["supply/"+symbol] = supply
+}
+
+func (s mapStore) PutAllowance(symbol, owner, spender string, amount uint256.Int) {
+	s["allowance/"+symbol+"/"+owner+"/"+spender] = amount
+}
+
+func (s mapStore) PutVesting(symbol, address string, schedules []VestingSchedule) {
+	var locked uint256.Int
+	for _, schedule := range schedules {
+		locked, _ = locked.Add(schedule.Total)
+	}
+	s["vesting/"+symbol+"/"+address] = locked
+}
+
+func TestStore(t *testing.T) {
+	tk := NewToken("Test", "TST", 18, uint256.NewInt(0))
+	tk.Mint("0xAlice", uint256.NewInt(500))
+
+	store := mapStore{}
+	tk.SetStore(store)
+	assert.Equal(t, mapStore{"supply/TST": uint256.NewInt(500), "balance/TST/0xAlice": uint256.NewInt(500)}, store)
+
+	tk.Transfer("0xAlice", "0xBob", uint256.NewInt(200))
+	tk.Approve("0xBob", "0xCarol", uint256.NewInt(50))
+	tk.Burn("0xAlice", uint256.NewInt(100))
+	assert.Equal(t, mapStore{
+		"supply/TST":                  uint256.NewInt(400),
+		"balance/TST/0xAlice":         uint256.NewInt(200),
+		"balance/TST/0xBob":           uint256.NewInt(200),
+		"allowance/TST/0xBob/0xCarol": uint256.NewInt(50),
+	}, store)
+
+	t.Run("Export and import", func(t *testing.T) {
+		ledger := tk.Export()
+		assert.Equal(t, []Holder{{"0xAlice", uint256.NewInt(200)}, {"0xBob", uint256.NewInt(200)}}, ledger.Holders)
+		assert.Equal(t, []AllowanceEntry{{"0xBob", "0xCarol", uint256.NewInt(50)}}, ledger.Allowances)
+
+		copied := NewToken("Test", "TST", 18, uint256.NewInt(0))
+		assert.Nil(t, copied.Import(ledger))
+		assert.Equal(t, ledger, copied.Export())
+
+		ledger.Holders = []Holder{{"0xDave", uint256.NewInt(400)}}
+		assert.Nil(t, tk.Import(ledger))
+		assert.Equal(t, uint256.NewInt(0), store["balance/TST/0xAlice"])
+		assert.Equal(t, uint256.NewInt(400), store["balance/TST/0xDave"])
+
+		ledger.TotalSupply = uint256.NewInt(1)
+		assert.ErrorIs(t, tk.Import(ledger), ErrInvalidLedger)
+	})
+}
+
+func TestRolesAndControls(t *testing.T) {
+	tk := NewToken("Test", "TST", 18, uint256.NewInt(0))
+	tk.SetRoleHolder(RoleAdmin, "0xAdmin")
+	tk.Mint("0xAlice", uint256.NewInt(1000))
+
+	t.Run("Only the admin grants roles", func(t *testing.T) {
+		assert.ErrorIs(t, tk.GrantRole("0xAlice", RolePauser, "0xAlice"), ErrUnauthorized)
+		assert.ErrorIs(t, tk.GrantRole("0xAdmin", "owner", "0xAlice"), ErrUnknownRole)
+		assert.Nil(t, tk.GrantRole("0xAdmin", RolePauser, "0xPauser"))
+		assert.Nil(t, tk.GrantRole("0xAdmin", RoleFreezer, "0xFreezer"))
+		assert.Nil(t, tk.GrantRole("0xAdmin", RoleMinter, "0xMinter"))
+		assert.Equal(t, "0xMinter", tk.MintAuthority())
+	})
+
+	t.Run("MintAs checks the minter", func(t *testing.T) {
+		assert.ErrorIs(t, tk.MintAs("0xAlice", "0xAlice", uint256.NewInt(1)), ErrUnauthorized)
+		assert.Nil(t, tk.MintAs("0xMinter", "0xAlice", uint256.NewInt(1)))
+	})
+
+	t.Run("Pause", func(t *testing.T) {
+		assert.ErrorIs(t, tk.Pause("0xAdmin"), ErrUnauthorized)
+		assert.Nil(t, tk.Pause("0xPauser"))
+		assert.ErrorIs(t, tk.Transfer("0xAlice", "0xBob", uint256.NewInt(1)), ErrPaused)
+		assert.ErrorIs(t, tk.Burn("0xAlice", uint256.NewInt(1)), ErrPaused)
+		assert.ErrorIs(t, tk.Mint("0xAlice", uint256.NewInt(1)), ErrPaused)
+		assert.Nil(t, tk.Unpause("0xPauser"))
+		assert.Nil(t, tk.Transfer("0xAlice", "0xBob", uint256.NewInt(1)))
+	})
+
+	t.Run("Freeze", func(t *testing.T) {
+		assert.Nil(t, tk.Freeze("0xFreezer", "0xAlice"))
+		assert.ErrorIs(t, tk.Transfer("0xAlice", "0xBob", uint256.NewInt(1)), ErrFrozen)
+		assert.ErrorIs(t, tk.Burn("0xAlice", uint256.NewInt(1)), ErrFrozen)
+		assert.Nil(t, tk.Approve("0xAlice", "0xSpender", uint256.NewInt(10)))
+		assert.ErrorIs(t, tk.TransferFrom("0xAlice", "0xSpender", "0xBob", uint256.NewInt(1)), ErrFrozen)
+		assert.Nil(t, tk.Transfer("0xBob", "0xAlice", uint256.NewInt(1)), "frozen accounts still receive")
+		assert.Nil(t, tk.Unfreeze("0xFreezer", "0xAlice"))
+	})
+
+	t.Run("Blocklist", func(t *testing.T) {
+		assert.ErrorIs(t, tk.Blocklist("0xAdmin", "0xBob"), ErrUnauthorized)
+		assert.Nil(t, tk.Blocklist("0xFreezer", "0xBob"))
+		assert.ErrorIs(t, tk.Transfer("0xAlice", "0xBob", uint256.NewInt(1)), ErrBlocklisted)
+		assert.ErrorIs(t, tk.Mint("0xBob", uint256.NewInt(1)), ErrBlocklisted)
+
+		assert.Nil(t, tk.Blocklist("0xFreezer", "0xSpender"))
+		assert.ErrorIs(t, tk.TransferFrom("0xAlice", "0xSpender", "0xCarol", uint256.NewInt(1)), ErrBlocklisted)
+		assert.Nil(t, tk.Unblocklist("0xFreezer", "0xSpender"))
+		assert.Nil(t, tk.TransferFrom("0xAlice", "0xSpender", "0xCarol", uint256.NewInt(1)))
+	})
+
+	t.Run("Events", func(t *testing.T) {
+		var types []EventType
+		for _, e := range tk.events {
+			if e.Type != EventTransfer && e.Type != EventMint {
+				types = append(types, e.Type)
+			}
+		}
+		assert.Equal(t, []EventType{
+			EventRoleChanged, EventRoleChanged, EventRoleChanged,
+			EventPaused, EventUnpaused,
+			EventFrozen, EventUnfrozen,
+			EventBlocklisted, EventBlocklisted, EventUnblocklisted,
+		}, types)
+	})
+}
+
+func TestVesting(t *testing.T) {
+	clock := &Clock{}
+	tk := NewToken("Test", "TST", 18, uint256.NewInt(0))
+	tk.SetClock(clock)
+	tk.Mint("0xIssuer", uint256.NewInt(1000))
+
+	schedule := VestingSchedule{Beneficiary: "0xTeam", Total: uint256.NewInt(400), Basis: VestByHeight, Start: 10, Cliff: 10, Duration: 40}
+	assert.Nil(t, tk.CreateVesting("0xIssuer", schedule))
+
+	t.Run("Locked tokens cannot move", func(t *testing.T) {
+		balance, _ := tk.BalanceOf("0xTeam")
+		assert.Equal(t, uint256.NewInt(400), balance)
+		assert.ErrorIs(t, tk.Transfer("0xTeam", "0xBob", uint256.NewInt(1)), ErrLocked)
+		assert.ErrorIs(t, tk.Burn("0xTeam", uint256.NewInt(1)), ErrLocked)
+		assert.Nil(t, tk.Approve("0xTeam", "0xSpender", uint256.NewInt(100)))
+		assert.ErrorIs(t, tk.TransferFrom("0xTeam", "0xSpender", "0xBob", uint256.NewInt(1)), ErrLocked)
+	})
+
+	t.Run("Nothing vests before the cliff", func(t *testing.T) {
+		clock.Set(19, 0)
+		spendable, _ := tk.Spendable("0xTeam")
+		assert.True(t, spendable.IsZero())
+	})
+
+	t.Run("Linear release after the cliff", func(t *testing.T) {
+		clock.Set(30, 0)
+		status, err := tk.VestingStatus("0xTeam")
+		assert.Nil(t, err)
+		assert.Equal(t, uint256.NewInt(200), status.Vested)
+		assert.Equal(t, uint256.NewInt(200), status.Unvested)
+		assert.Equal(t, uint256.NewInt(200), status.Spendable)
+		assert.Nil(t, tk.Transfer("0xTeam", "0xBob", uint256.NewInt(200)))
+		assert.ErrorIs(t, tk.Transfer("0xTeam", "0xBob", uint256.NewInt(1)), ErrLocked)
+
+		clock.Set(50, 0)
+		assert.Nil(t, tk.Transfer("0xTeam", "0xBob", uint256.NewInt(200)))
+	})
+
+	t.Run("Cliff-only schedule on block time", func(t *testing.T) {
+		cliff := VestingSchedule{Beneficiary: "0xAdvisor", Total: uint256.NewInt(100), Basis: VestByTime, Start: 1000, Cliff: 60, Duration: 60}
+		assert.Equal(t, uint256.NewInt(0), cliff.VestedAt(0, 1059))
+		assert.Equal(t, uint256.NewInt(100), cliff.VestedAt(0, 1060))
+	})
+
+	t.Run("Invalid schedules", func(t *testing.T) {
+		assert.ErrorIs(t, tk.CreateVesting("0xIssuer", VestingSchedule{Beneficiary: "0xTeam", Total: uint256.NewInt(1), Basis: "epoch", Duration: 1}), ErrInvalidVesting)
+		assert.ErrorIs(t, tk.CreateVesting("0xIssuer", VestingSchedule{Beneficiary: "0xTeam", Total: uint256.NewInt(1), Basis: VestByHeight, Cliff: 2, Duration: 1}), ErrInvalidVesting)
+	})
+
+	t.Run("Schedule limit is per grantor", func(t *testing.T) {
+		tk.Mint("0xMallory", uint256.NewInt(100))
+		grant := VestingSchedule{Beneficiary: "0xDave", Total: uint256.NewInt(1), Basis: VestByHeight, Start: 100, Duration: 10}
+		for i := 0; i < MaxVestingSchedules; i++ {
+			assert.Nil(t, tk.CreateVesting("0xMallory", grant))
+		}
+		assert.ErrorIs(t, tk.CreateVesting("0xMallory", grant), ErrTooManySchedules)
+		assert.Nil(t, tk.CreateVesting("0xIssuer", grant))
+
+		status, _ := tk.VestingStatus("0xDave")
+		assert.Len(t, status.Schedules, MaxVestingSchedules+1)
+		assert.Equal(t, "0xIssuer", status.Schedules[MaxVestingSchedules].Grantor)
+	})
+
+	t.Run("Export and import keep schedules", func(t *testing.T) {
+		assert.Nil(t, tk.CreateVesting("0xIssuer", VestingSchedule{Beneficiary: "0xCarol", Total: uint256.NewInt(100), Basis: VestByHeight, Start: 50, Duration: 10}))
+		ledger := tk.Export()
+		assert.Len(t, ledger.Vesting, MaxVestingSchedules+3)
+
+		copied := NewToken("Test", "TST", 18, uint256.NewInt(0))
+		copied.SetClock(clock)
+		assert.Nil(t, copied.Import(ledger))
+		assert.ErrorIs(t, copied.Transfer("0xCarol", "0xBob", uint256.NewInt(1)), ErrLocked)
+	})
+}
+
+func TestBalanceHistory(t *testing.T) {
+	clock := &Clock{}
+	tk := NewToken("Test", "TST", 18, uint256.NewInt(0))
+	tk.SetClock(clock)
+	tk.Mint("0xAlice", uint256.NewInt(100))
+
+	clock.Set(5, 0)
+	assert.Nil(t, tk.Transfer("0xAlice", "0xBob", uint256.NewInt(30)))
+	assert.Nil(t, tk.Transfer("0xAlice", "0xBob", uint256.NewInt(10)))
+	clock.Set(9, 0)
+	assert.Nil(t, tk.Burn("0xAlice", uint256.NewInt(20)))
+
+	for _, tc := range []struct {
+		height     uint64
+		alice, bob uint64
+	}{
+		{0, 100, 0},
+		{4, 100, 0},
+		{5, 60, 40},
+		{8, 60, 40},
+		{9, 40, 40},
+	} {
+		balance, err := tk.BalanceOfAt("0xAlice", tc.height)
+		assert.Nil(t, err)
+		assert.Equal(t, uint256.NewInt(tc.alice), balance, "height %d", tc.height)
+		balance, err = tk.BalanceOfAt("0xBob", tc.height)
+		assert.Nil(t, err)
+		assert.Equal(t, uint256.NewInt(tc.bob), balance, "height %d", tc.height)
+	}
+
+	_, err := tk.BalanceOfAt("0xAlice", 10)
+	assert.ErrorIs(t, err, ErrFutureHeight)
+
+	t.Run("Rewind", func(t *testing.T) {
+		tk.RestoreBalance("0xAlice", uint256.NewInt(60))
+		tk.RewindHistory(8)
+		clock.Set(8, 0)
+		alice, _ := tk.BalanceOfAt("0xAlice", 5)
+		assert.Equal(t, uint256.NewInt(60), alice)
+	})
+
+	t.Run("Prune", func(t *testing.T) {
+		tk.PruneHistory(5)
+		_, err := tk.BalanceOfAt("0xAlice", 4)
+		assert.ErrorIs(t, err, ErrHistoryPruned)
+		alice, err := tk.BalanceOfAt("0xAlice", 5)
+		assert.Nil(t, err)
+		assert.Equal(t, uint256.NewInt(60), alice)
+
+		// The checkpoints of height 5 only answered heights below it
+		assert.Empty(t, tk.history.checkpoints)
+		assert.Empty(t, tk.history.blocks)
+	})
+}
+
+func TestNonFungibleCollection(t *testing.T) {
+	c := NewCollection("Tickets", "TIX", NonFungible)
+	c.SetMinter("0xBoxOffice")
+	one := uint256.NewInt(1)
+
+	t.Run("Only the minter mints, once per ID", func(t *testing.T) {
+		assert.ErrorIs(t, c.Mint("0xAlice", "0xAlice", "seat-1", one, "ipfs://seat-1"), ErrUnauthorized)
+		assert.Nil(t, c.Mint("0xBoxOffice", "0xAlice", "seat-1", one, "ipfs://seat-1"))
+		assert.ErrorIs(t, c.Mint("0xBoxOffice", "0xBob", "seat-1", one, ""), ErrItemExists)
+		assert.ErrorIs(t, c.Mint("0xBoxOffice", "0xBob", "seat-2", uint256.NewInt(2), ""), ErrInvalidItem)
+		assert.ErrorIs(t, c.Mint("0xBoxOffice", "0xBob", "seat/2", one, ""), ErrInvalidItem)
+
+		owner, err := c.OwnerOf("seat-1")
+		assert.Nil(t, err)
+		assert.Equal(t, "0xAlice", owner)
+		item, _ := c.Item("seat-1")
+		assert.Equal(t, "ipfs://seat-1", item.URI)
+	})
+
+	t.Run("Approval moves one item and is cleared by the transfer", func(t *testing.T) {
+		assert.ErrorIs(t, c.Transfer("0xBob", "0xAlice", "0xBob", "seat-1", one), ErrNotItemOperator)
+		assert.ErrorIs(t, c.Approve("0xBob", "seat-1", "0xBob"), ErrNotItemOperator)
+		assert.Nil(t, c.Approve("0xAlice", "seat-1", "0xBob"))
+		assert.Nil(t, c.Transfer("0xBob", "0xAlice", "0xCarol", "seat-1", one))
+
+		owner, _ := c.OwnerOf("seat-1")
+		assert.Equal(t, "0xCarol", owner)
+		item, _ := c.Item("seat-1")
+		assert.Equal(t, "", item.Approved)
+		assert.ErrorIs(t, c.Transfer("0xBob", "0xCarol", "0xBob", "seat-1", one), ErrNotItemOperator)
+	})
+
+	t.Run("Operators move all of an owner's items", func(t *testing.T) {
+		assert.Nil(t, c.Mint("0xBoxOffice", "0xCarol", "seat-2", one, ""))
+		assert.Nil(t, c.SetOperator("0xCarol", "0xReseller", true))
+		assert.Nil(t, c.Approve("0xReseller", "seat-2", "0xDave"))
+		assert.Nil(t, c.Transfer("0xReseller", "0xCarol", "0xEve", "seat-1", one))
+		assert.Equal(t, []Holding{{"seat-2", "0xCarol", one}}, c.HoldingsOf("0xCarol"))
+
+		assert.Nil(t, c.SetOperator("0xCarol", "0xReseller", false))
+		assert.ErrorIs(t, c.Burn("0xReseller", "0xCarol", "seat-2", one), ErrNotItemOperator)
+	})
+
+	t.Run("Burning removes the item", func(t *testing.T) {
+		assert.Nil(t, c.Burn("0xCarol", "0xCarol", "seat-2", one))
+		_, err := c.OwnerOf("seat-2")
+		assert.ErrorIs(t, err, ErrItemNotFound)
+		assert.Empty(t, c.HoldingsOf("0xCarol"))
+		assert.Equal(t, 1, c.ItemCount())
+	})
+}
+
+func TestSemiFungibleCollection(t *testing.T) {
+	c := NewCollection("Passes", "PASS", SemiFungible)
+	c.SetMinter("0xBoxOffice")
+
+	assert.Nil(t, c.Mint("0xBoxOffice", "0xAlice", "day-pass", uint256.NewInt(10), "ipfs://day"))
+	assert.Nil(t, c.Mint("0xBoxOffice", "0xBob", "day-pass", uint256.NewInt(5), ""))
+	assert.ErrorIs(t, c.Mint("0xBoxOffice", "0xBob", "day-pass", uint256.NewInt(5), "ipfs://other"), ErrInvalidItem)
+	assert.ErrorIs(t, c.Approve("0xAlice", "day-pass", "0xBob"), ErrInvalidItem)
+
+	assert.Nil(t, c.Transfer("0xAlice", "0xAlice", "0xBob", "day-pass", uint256.NewInt(4)))
+	assert.ErrorIs(t, c.Transfer("0xAlice", "0xAlice", "0xBob", "day-pass", uint256.NewInt(7)), ErrItemBalance)
+	assert.Equal(t, uint256.NewInt(9), c.BalanceOf("0xBob", "day-pass"))
+
+	assert.Nil(t, c.Burn("0xBob", "0xBob", "day-pass", uint256.NewInt(9)))
+	item, _ := c.Item("day-pass")
+	assert.Equal(t, uint256.NewInt(6), item.Supply)
+	assert.Equal(t, []Holding{{"day-pass", "0xAlice", uint256.NewInt(6)}}, c.HoldersOf("day-pass"))
+}
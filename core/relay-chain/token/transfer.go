@@ -2,9 +2,11 @@ package token
 
 import(
 	"errors"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
-func (t *Token) Transfer(from, to string, amount uint64) error {
+func (t *Token) Transfer(from, to string, amount uint256.Int) error {
 	if !t.validateAddress(from) || !t.validateAddress(to) {
 		return errors.New("invalid address")
 	}
@@ -12,12 +14,12 @@ func (t *Token) Transfer(from, to string, amount uint64) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if t.balances[from] < amount {
-		return errors.New("insufficient balance")
+	if err := t.checkMovement(from, to); err != nil {
+		return err
+	}
+	if err := t.move(from, to, amount); err != nil {
+		return err
 	}
-
-	t.balances[from] -= amount
-	t.balances[to] += amount
 
 	// Emit event
 	t.emitEvent(Event{
@@ -27,4 +29,30 @@ func (t *Token) Transfer(from, to string, amount uint64) error {
 		Amount: amount,
 	})
 	return nil
+}
+
+// move takes amount from one balance and adds it to another. Caller must hold t.mu.
+func (t *Token) move(from, to string, amount uint256.Int) error {
+	fromBalance, err := t.balances[from].Sub(amount)
+	if err != nil {
+		return errors.New("insufficient balance")
+	}
+	if t.spendable(from).Lt(amount) {
+		return ErrLocked
+	}
+	if from == to {
+		return nil
+	}
+	toBalance, err := t.balances[to].Add(amount)
+	if err != nil {
+		return errors.New("transfer causes balance overflow")
+	}
+
+	t.recordBalance(from)
+	t.recordBalance(to)
+	t.balances[from] = fromBalance
+	t.balances[to] = toBalance
+	t.storeBalance(from, fromBalance)
+	t.storeBalance(to, toBalance)
+	return nil
 }
\ No newline at end of file
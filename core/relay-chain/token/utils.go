@@ -2,13 +2,15 @@ package token
 
 import (
 	"math/big"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 )
 
 // Convert raw amount to human-readable format (adjust for decimals)
-func (t *Token) FormatAmount(amount uint64) *big.Float {
+func (t *Token) FormatAmount(amount uint256.Int) *big.Float {
 	decimals := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(t.Decimals)), nil)
 	result := new(big.Float).Quo(
-		new(big.Float).SetUint64(amount),
+		new(big.Float).SetInt(amount.Big()),
 		new(big.Float).SetInt(decimals),
 	)
 	return result
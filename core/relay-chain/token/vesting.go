@@ -0,0 +1,315 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+)
+
+// VestingBasis is what a vesting schedule is measured in
+type VestingBasis string
+
+const (
+	VestByHeight VestingBasis = "height" // block heights
+	VestByTime   VestingBasis = "time"   // block times in Unix seconds
+)
+
+// MaxVestingSchedules bounds the schedules one grantor can have vesting to an
+// account at once. The bound is per grantor so that nobody can use up an
+// account's schedules and block grants from others.
+const MaxVestingSchedules = 16
+
+var (
+	ErrInvalidVesting   = errors.New("invalid vesting schedule")
+	ErrTooManySchedules = fmt.Errorf("grantor already has %d schedules vesting to the account", MaxVestingSchedules)
+	ErrLocked           = errors.New("amount exceeds unlocked balance")
+)
+
+// VestingSchedule locks Total of the beneficiary's balance and releases it
+// linearly from Start to Start+Duration. Nothing is released before
+// Start+Cliff, at which point everything vested so far is released at once;
+// a schedule whose Cliff equals its Duration releases everything at the end.
+// Grantor is the account the locked amount came from.
+type VestingSchedule struct {
+	Beneficiary string       `json:"beneficiary"`
+	Grantor     string       `json:"grantor"`
+	Total       uint256.Int  `json:"total"`
+	Basis       VestingBasis `json:"basis"`
+	Start       uint64       `json:"start"`
+	Cliff       uint64       `json:"cliff"`
+	Duration    uint64       `json:"duration"`
+}
+
+// Validate checks the schedule's own fields
+func (s *VestingSchedule) Validate() error {
+	if s.Basis != VestByHeight && s.Basis != VestByTime {
+		return fmt.Errorf("%w: basis must be %q or %q", ErrInvalidVesting, VestByHeight, VestByTime)
+	}
+	if s.Total.IsZero() {
+		return fmt.Errorf("%w: total must be positive", ErrInvalidVesting)
+	}
+	if s.Duration == 0 || s.Cliff > s.Duration {
+		return fmt.Errorf("%w: duration must be positive and at least the cliff", ErrInvalidVesting)
+	}
+	if s.Start+s.Duration < s.Start {
+		return fmt.Errorf("%w: schedule ends after the largest %s", ErrInvalidVesting, s.Basis)
+	}
+	return nil
+}
+
+// VestedAt returns how much of the schedule has vested at the given block
+// height and time
+func (s *VestingSchedule) VestedAt(height uint64, unix int64) uint256.Int {
+	now := height
+	if s.Basis == VestByTime {
+		now = 0
+		if unix > 0 {
+			now = uint64(unix)
+		}
+	}
+	if now < s.Start {
+		return uint256.Int{}
+	}
+	elapsed := now - s.Start
+	if elapsed < s.Cliff {
+		return uint256.Int{}
+	}
+	if elapsed >= s.Duration {
+		return s.Total
+	}
+	vested, _ := s.Total.MulDiv(uint256.NewInt(elapsed), uint256.NewInt(s.Duration))
+	return vested
+}
+
+// Clock is the chain position vesting is measured against. The chain moves
+// it as blocks are applied and disconnected. A token without a clock sees
+// height 0 and time 0.
+type Clock struct {
+	mu     sync.RWMutex
+	height uint64
+	time   int64
+}
+
+// Set moves the clock to a block's height and time
+func (c *Clock) Set(height uint64, unix int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.height, c.time = height, unix
+}
+
+// Now returns the current block height and time
+func (c *Clock) Now() (uint64, int64) {
+	if c == nil {
+		return 0, 0
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.height, c.time
+}
+
+// SetClock measures vesting against c from now on
+func (t *Token) SetClock(c *Clock) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clock = c
+}
+
+// ScheduleStatus is a vesting schedule with what it has released so far
+type ScheduleStatus struct {
+	VestingSchedule
+	Vested   uint256.Int `json:"vested"`
+	Unvested uint256.Int `json:"unvested"`
+}
+
+// VestingStatus shows how much of an account's balance is locked
+type VestingStatus struct {
+	Address   string           `json:"address"`
+	Balance   uint256.Int      `json:"balance"`
+	Vested    uint256.Int      `json:"vested"`   // released by the account's schedules
+	Unvested  uint256.Int      `json:"unvested"` // still locked
+	Spendable uint256.Int      `json:"spendable"`
+	Schedules []ScheduleStatus `json:"schedules"`
+}
+
+// VestingStatus returns the account's balance split into locked and spendable
+// parts, along with each of its schedules
+func (t *Token) VestingStatus(address string) (*VestingStatus, error) {
+	if !t.validateAddress(address) {
+		return nil, errors.New("invalid address")
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	height, unix := t.clock.Now()
+	status := &VestingStatus{
+		Address:   address,
+		Balance:   t.balances[address],
+		Schedules: make([]ScheduleStatus, 0, len(t.vesting[address])),
+	}
+	for _, s := range t.vesting[address] {
+		vested := s.VestedAt(height, unix)
+		unvested, _ := s.Total.Sub(vested)
+		status.Vested, _ = status.Vested.Add(vested)
+		status.Unvested, _ = status.Unvested.Add(unvested)
+		status.Schedules = append(status.Schedules, ScheduleStatus{s, vested, unvested})
+	}
+	status.Spendable = t.spendable(address)
+	return status, nil
+}
+
+// Spendable returns the part of the account's balance that is not locked by vesting
+func (t *Token) Spendable(address string) (uint256.Int, error) {
+	if !t.validateAddress(address) {
+		return uint256.Int{}, errors.New("invalid address")
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.spendable(address), nil
+}
+
+// Vesting returns a copy of every account's schedules
+func (t *Token) Vesting() map[string][]VestingSchedule {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	vesting := make(map[string][]VestingSchedule, len(t.vesting))
+	for address, schedules := range t.vesting {
+		vesting[address] = append([]VestingSchedule(nil), schedules...)
+	}
+	return vesting
+}
+
+// VestingOf returns a copy of the schedules vesting to address
+func (t *Token) VestingOf(address string) []VestingSchedule {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return append([]VestingSchedule(nil), t.vesting[address]...)
+}
+
+// CreateVesting moves the schedule's total from from to its beneficiary and
+// locks it there until it vests. from becomes the schedule's grantor and may
+// be the beneficiary, which locks part of its own balance.
+func (t *Token) CreateVesting(from string, s VestingSchedule) error {
+	if !t.validateAddress(from) || !t.validateAddress(s.Beneficiary) {
+		return errors.New("invalid address")
+	}
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkMovement(from, s.Beneficiary); err != nil {
+		return err
+	}
+	s.Grantor = from
+	schedules := t.activeSchedules(s.Beneficiary)
+	if countGranted(schedules, from) >= MaxVestingSchedules {
+		return ErrTooManySchedules
+	}
+	if err := t.move(from, s.Beneficiary, s.Total); err != nil {
+		return err
+	}
+	t.setVesting(s.Beneficiary, append(schedules, s))
+
+	t.emitEvent(Event{
+		Type:   EventVestingCreated,
+		From:   from,
+		To:     s.Beneficiary,
+		Amount: s.Total,
+	})
+	return nil
+}
+
+// RestoreVesting sets an account's schedules directly when undoing journaled changes
+func (t *Token) RestoreVesting(address string, schedules []VestingSchedule) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.storeVesting(address, schedules)
+	if len(schedules) == 0 {
+		delete(t.vesting, address)
+		return
+	}
+	if t.vesting == nil {
+		t.vesting = make(map[string][]VestingSchedule)
+	}
+	t.vesting[address] = append([]VestingSchedule(nil), schedules...)
+}
+
+// locked returns how much of the account's balance has not vested yet. The
+// locked amounts were moved into the balance, so their sum cannot overflow.
+// Caller must hold t.mu.
+func (t *Token) locked(address string) uint256.Int {
+	height, unix := t.clock.Now()
+	var locked uint256.Int
+	for _, s := range t.vesting[address] {
+		unvested, _ := s.Total.Sub(s.VestedAt(height, unix))
+		locked, _ = locked.Add(unvested)
+	}
+	return locked
+}
+
+// spendable returns the balance minus the locked amount. Caller must hold t.mu.
+func (t *Token) spendable(address string) uint256.Int {
+	spendable, err := t.balances[address].Sub(t.locked(address))
+	if err != nil {
+		return uint256.Int{}
+	}
+	return spendable
+}
+
+// activeSchedules returns the account's schedules that have not fully vested.
+// Caller must hold t.mu.
+func (t *Token) activeSchedules(address string) []VestingSchedule {
+	height, unix := t.clock.Now()
+	var active []VestingSchedule
+	for _, s := range t.vesting[address] {
+		if vested := s.VestedAt(height, unix); vested.Lt(s.Total) {
+			active = append(active, s)
+		}
+	}
+	return active
+}
+
+// countGranted returns how many of schedules came from grantor
+func countGranted(schedules []VestingSchedule, grantor string) int {
+	n := 0
+	for _, s := range schedules {
+		if s.Grantor == grantor {
+			n++
+		}
+	}
+	return n
+}
+
+// setVesting replaces an account's schedules. Caller must hold t.mu.
+func (t *Token) setVesting(address string, schedules []VestingSchedule) {
+	t.recordVesting(address)
+	if t.vesting == nil {
+		t.vesting = make(map[string][]VestingSchedule)
+	}
+	t.vesting[address] = schedules
+	t.storeVesting(address, schedules)
+}
+
+// sortedVesting flattens vesting into a list ordered by beneficiary, keeping
+// each account's schedules in creation order
+func sortedVesting(vesting map[string][]VestingSchedule) []VestingSchedule {
+	addresses := make([]string, 0, len(vesting))
+	for address := range vesting {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	all := []VestingSchedule{}
+	for _, address := range addresses {
+		all = append(all, vesting[address]...)
+	}
+	return all
+}
@@ -0,0 +1,244 @@
+// Package uint256 implements the fixed 256-bit unsigned integer used for token
+// amounts. Arithmetic is checked: an operation that would wrap around returns
+// an error instead. Values encode to JSON as decimal strings, so clients
+// never lose precision to floating point.
+package uint256
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+)
+
+var (
+	ErrOverflow  = errors.New("uint256: overflow")
+	ErrUnderflow = errors.New("uint256: underflow")
+	ErrDivByZero = errors.New("uint256: division by zero")
+	ErrSyntax    = errors.New("uint256: invalid decimal number")
+)
+
+// Int is a 256-bit unsigned integer stored as four 64-bit words, least
+// significant first. The zero value is 0, and values compare with ==.
+type Int [4]uint64
+
+// Max is the largest Int, 2^256 - 1
+var Max = Int{^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)}
+
+var maxBig = Max.Big()
+
+// NewInt returns v as an Int
+func NewInt(v uint64) Int {
+	return Int{v}
+}
+
+// FromBig converts b, which must be in [0, 2^256)
+func FromBig(b *big.Int) (Int, error) {
+	if b.Sign() < 0 {
+		return Int{}, fmt.Errorf("%w: %s is negative", ErrUnderflow, b)
+	}
+	if b.Cmp(maxBig) > 0 {
+		return Int{}, fmt.Errorf("%w: %s", ErrOverflow, b)
+	}
+	var buf [32]byte
+	b.FillBytes(buf[:])
+	return FromBytes32(buf), nil
+}
+
+// FromDecimal parses a base 10 string of digits
+func FromDecimal(s string) (Int, error) {
+	if s == "" || len(s) > 78 {
+		return Int{}, fmt.Errorf("%w: %q", ErrSyntax, s)
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return Int{}, fmt.Errorf("%w: %q", ErrSyntax, s)
+		}
+	}
+	b, _ := new(big.Int).SetString(s, 10)
+	return FromBig(b)
+}
+
+// MustFromDecimal is FromDecimal for constants; it panics on invalid input
+func MustFromDecimal(s string) Int {
+	x, err := FromDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+// FromBytes32 decodes a 32-byte big-endian value
+func FromBytes32(b [32]byte) Int {
+	return Int{
+		binary.BigEndian.Uint64(b[24:32]),
+		binary.BigEndian.Uint64(b[16:24]),
+		binary.BigEndian.Uint64(b[8:16]),
+		binary.BigEndian.Uint64(b[0:8]),
+	}
+}
+
+// Bytes32 returns x as 32 big-endian bytes
+func (x Int) Bytes32() [32]byte {
+	var b [32]byte
+	binary.BigEndian.PutUint64(b[0:8], x[3])
+	binary.BigEndian.PutUint64(b[8:16], x[2])
+	binary.BigEndian.PutUint64(b[16:24], x[1])
+	binary.BigEndian.PutUint64(b[24:32], x[0])
+	return b
+}
+
+// Big returns x as a new big.Int
+func (x Int) Big() *big.Int {
+	b := x.Bytes32()
+	return new(big.Int).SetBytes(b[:])
+}
+
+// IsZero reports whether x is 0
+func (x Int) IsZero() bool {
+	return x == Int{}
+}
+
+// Uint64 returns x as a uint64 and whether it fits
+func (x Int) Uint64() (uint64, bool) {
+	return x[0], x[1] == 0 && x[2] == 0 && x[3] == 0
+}
+
+// Float64 returns the nearest float64, for display and price estimates only
+func (x Int) Float64() float64 {
+	f, _ := new(big.Float).SetInt(x.Big()).Float64()
+	return f
+}
+
+// Cmp returns -1, 0 or +1 as x is less than, equal to or greater than y
+func (x Int) Cmp(y Int) int {
+	for i := 3; i >= 0; i-- {
+		switch {
+		case x[i] < y[i]:
+			return -1
+		case x[i] > y[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// Lt reports whether x < y
+func (x Int) Lt(y Int) bool {
+	return x.Cmp(y) < 0
+}
+
+// Gt reports whether x > y
+func (x Int) Gt(y Int) bool {
+	return x.Cmp(y) > 0
+}
+
+// Add returns x + y, or ErrOverflow
+func (x Int) Add(y Int) (Int, error) {
+	var z Int
+	var carry uint64
+	for i := range z {
+		z[i], carry = bits.Add64(x[i], y[i], carry)
+	}
+	if carry != 0 {
+		return Int{}, ErrOverflow
+	}
+	return z, nil
+}
+
+// Sub returns x - y, or ErrUnderflow if y > x
+func (x Int) Sub(y Int) (Int, error) {
+	var z Int
+	var borrow uint64
+	for i := range z {
+		z[i], borrow = bits.Sub64(x[i], y[i], borrow)
+	}
+	if borrow != 0 {
+		return Int{}, ErrUnderflow
+	}
+	return z, nil
+}
+
+// Mul returns x * y, or ErrOverflow
+func (x Int) Mul(y Int) (Int, error) {
+	z, err := FromBig(new(big.Int).Mul(x.Big(), y.Big()))
+	if err != nil {
+		return Int{}, ErrOverflow
+	}
+	return z, nil
+}
+
+// Div returns x / y rounded down, or ErrDivByZero
+func (x Int) Div(y Int) (Int, error) {
+	if y.IsZero() {
+		return Int{}, ErrDivByZero
+	}
+	z, _ := FromBig(new(big.Int).Quo(x.Big(), y.Big()))
+	return z, nil
+}
+
+// MulDiv returns x * y / z rounded down. The product is computed at full
+// precision, so only a quotient above 2^256 - 1 overflows.
+func (x Int) MulDiv(y, z Int) (Int, error) {
+	if z.IsZero() {
+		return Int{}, ErrDivByZero
+	}
+	q := new(big.Int).Mul(x.Big(), y.Big())
+	r, err := FromBig(q.Quo(q, z.Big()))
+	if err != nil {
+		return Int{}, ErrOverflow
+	}
+	return r, nil
+}
+
+// Sqrt returns the integer square root of x
+func (x Int) Sqrt() Int {
+	z, _ := FromBig(new(big.Int).Sqrt(x.Big()))
+	return z
+}
+
+// Sum adds values, or returns ErrOverflow
+func Sum(values ...Int) (Int, error) {
+	var total Int
+	for _, v := range values {
+		var err error
+		if total, err = total.Add(v); err != nil {
+			return Int{}, err
+		}
+	}
+	return total, nil
+}
+
+// String returns x in base 10
+func (x Int) String() string {
+	if x[1] == 0 && x[2] == 0 && x[3] == 0 {
+		return fmt.Sprint(x[0])
+	}
+	return x.Big().String()
+}
+
+// MarshalJSON encodes x as a decimal string
+func (x Int) MarshalJSON() ([]byte, error) {
+	return json.Marshal(x.String())
+}
+
+// UnmarshalJSON accepts a decimal string, or a plain JSON number written by
+// older versions that used uint64 amounts
+func (x *Int) UnmarshalJSON(data []byte) error {
+	var s string
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	} else {
+		s = string(data)
+	}
+	v, err := FromDecimal(s)
+	if err != nil {
+		return err
+	}
+	*x = v
+	return nil
+}
@@ -0,0 +1,79 @@
+package uint256
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArithmetic(t *testing.T) {
+	big := MustFromDecimal("1000000000000000000000000") // 1e24, above 2^64
+
+	t.Run("Add carries across words", func(t *testing.T) {
+		sum, err := NewInt(^uint64(0)).Add(NewInt(1))
+		assert.Nil(t, err)
+		assert.Equal(t, Int{0, 1}, sum)
+	})
+
+	t.Run("Overflow and underflow are errors", func(t *testing.T) {
+		_, err := Max.Add(NewInt(1))
+		assert.ErrorIs(t, err, ErrOverflow)
+		_, err = NewInt(1).Sub(NewInt(2))
+		assert.ErrorIs(t, err, ErrUnderflow)
+		_, err = Max.Mul(NewInt(2))
+		assert.ErrorIs(t, err, ErrOverflow)
+		_, err = big.Div(Int{})
+		assert.ErrorIs(t, err, ErrDivByZero)
+	})
+
+	t.Run("MulDiv keeps the full product", func(t *testing.T) {
+		q, err := Max.MulDiv(NewInt(3), NewInt(6))
+		assert.Nil(t, err)
+		half, _ := Max.Div(NewInt(2))
+		assert.Equal(t, half, q)
+	})
+
+	t.Run("Sqrt", func(t *testing.T) {
+		square, _ := big.Mul(big)
+		assert.Equal(t, big, square.Sqrt())
+	})
+
+	diff, err := big.Sub(NewInt(1))
+	assert.Nil(t, err)
+	assert.Equal(t, "999999999999999999999999", diff.String())
+	assert.True(t, diff.Lt(big))
+	_, fits := big.Uint64()
+	assert.False(t, fits)
+}
+
+func TestDecimal(t *testing.T) {
+	max := "115792089237316195423570985008687907853269984665640564039457584007913129639935"
+	assert.Equal(t, Max, MustFromDecimal(max))
+	assert.Equal(t, max, Max.String())
+	assert.Equal(t, Max, FromBytes32(Max.Bytes32()))
+
+	for _, s := range []string{"", "-1", "1.5", "0x10", " 1", "115792089237316195423570985008687907853269984665640564039457584007913129639936"} {
+		_, err := FromDecimal(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		Amount Int `json:"amount"`
+	}
+	v.Amount = MustFromDecimal("1000000000000000000000000")
+
+	data, err := json.Marshal(v)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"amount":"1000000000000000000000000"}`, string(data))
+
+	t.Run("Numbers are accepted", func(t *testing.T) {
+		assert.Nil(t, json.Unmarshal([]byte(`{"amount":42}`), &v))
+		assert.Equal(t, NewInt(42), v.Amount)
+	})
+
+	assert.NotNil(t, json.Unmarshal([]byte(`{"amount":"-1"}`), &v))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"amount":1.5}`), &v))
+}
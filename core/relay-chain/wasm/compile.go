@@ -0,0 +1,243 @@
+package wasm
+
+import "fmt"
+
+// Opcodes the interpreter runs. Instructions prefixed with 0xfc are stored as
+// 0xfc00 plus their sub-opcode.
+const (
+	opUnreachable  = 0x00
+	opNop          = 0x01
+	opBlock        = 0x02
+	opLoop         = 0x03
+	opIf           = 0x04
+	opElse         = 0x05
+	opEnd          = 0x0b
+	opBr           = 0x0c
+	opBrIf         = 0x0d
+	opBrTable      = 0x0e
+	opReturn       = 0x0f
+	opCall         = 0x10
+	opCallIndirect = 0x11
+	opDrop         = 0x1a
+	opSelect       = 0x1b
+	opSelectTyped  = 0x1c
+	opLocalGet     = 0x20
+	opLocalSet     = 0x21
+	opLocalTee     = 0x22
+	opGlobalGet    = 0x23
+	opGlobalSet    = 0x24
+	opI32Load      = 0x28
+	opI64Load      = 0x29
+	opI32Load8S    = 0x2c
+	opI32Load8U    = 0x2d
+	opI32Load16S   = 0x2e
+	opI32Load16U   = 0x2f
+	opI64Load8S    = 0x30
+	opI64Load8U    = 0x31
+	opI64Load16S   = 0x32
+	opI64Load16U   = 0x33
+	opI64Load32S   = 0x34
+	opI64Load32U   = 0x35
+	opI32Store     = 0x36
+	opI64Store     = 0x37
+	opI32Store8    = 0x3a
+	opI32Store16   = 0x3b
+	opI64Store8    = 0x3c
+	opI64Store16   = 0x3d
+	opI64Store32   = 0x3e
+	opMemorySize   = 0x3f
+	opMemoryGrow   = 0x40
+	opI32Const     = 0x41
+	opI64Const     = 0x42
+
+	// Integer comparisons and arithmetic, operands and results as in the spec
+	opI32Eqz    = 0x45
+	opI32GeU    = 0x4f
+	opI64Eqz    = 0x50
+	opI64GeU    = 0x5a
+	opI32Clz    = 0x67
+	opI32Rotr   = 0x78
+	opI64Clz    = 0x79
+	opI64Rotr   = 0x8a
+	opI32Wrap   = 0xa7
+	opI64ExtS   = 0xac
+	opI64ExtU   = 0xad
+	opI32Ext8S  = 0xc0
+	opI64Ext32S = 0xc4
+
+	opPrefix     = 0xfc
+	opMemoryCopy = 0xfc00 | 10
+	opMemoryFill = 0xfc00 | 11
+)
+
+// instr is one decoded instruction
+type instr struct {
+	op      uint16
+	imm     uint64 // constant, index, memory offset or branch depth
+	end     uint32 // block, loop, if and else: position of the matching end
+	els     uint32 // if: position of the else, or 0 when there is none
+	params  uint32 // block, loop and if: values the block takes
+	results uint32 // block, loop and if: values the block leaves
+}
+
+// compileBody decodes the instructions of fn from r, checking every index and
+// pairing each block with its end so branches need no search at run time
+func compileBody(m *Module, fn *function, r *reader) {
+	ft := m.types[fn.typ]
+	numLocals := uint64(len(ft.Params) + fn.locals)
+	var open []int // positions of the enclosing block, loop and if instructions
+
+	for r.err == nil {
+		op := r.byte()
+		if r.err != nil {
+			return
+		}
+		in := instr{op: uint16(op)}
+		switch {
+		case op == opUnreachable, op == opNop, op == opReturn, op == opDrop, op == opSelect:
+		case op == opSelectTyped:
+			if n := r.vectorLength(); n != 1 {
+				r.fail(fmt.Errorf("%w: select with %d types", ErrInvalidModule, n))
+				return
+			}
+			r.valueType()
+			in.op = opSelect
+		case op == opBlock, op == opLoop, op == opIf:
+			in.params, in.results = blockType(m, r)
+			open = append(open, len(fn.code))
+		case op == opElse:
+			if len(open) == 0 || fn.code[open[len(open)-1]].op != opIf || fn.code[open[len(open)-1]].els != 0 {
+				r.fail(fmt.Errorf("%w: else without if", ErrInvalidModule))
+				return
+			}
+			fn.code[open[len(open)-1]].els = uint32(len(fn.code))
+		case op == opEnd:
+			if len(open) == 0 {
+				fn.code = append(fn.code, in)
+				if r.pos != len(r.data) {
+					r.fail(fmt.Errorf("%w: code after the end of the function", ErrInvalidModule))
+				}
+				return
+			}
+			start := open[len(open)-1]
+			open = open[:len(open)-1]
+			end := uint32(len(fn.code))
+			fn.code[start].end = end
+			if els := fn.code[start].els; els != 0 {
+				fn.code[els].end = end
+			}
+		case op == opBr, op == opBrIf:
+			in.imm = uint64(r.u32())
+			checkDepth(r, in.imm, len(open))
+		case op == opBrTable:
+			n := r.vectorLength()
+			if n > MaxBranchTable {
+				r.fail(fmt.Errorf("%w: branch table of %d entries", ErrInvalidModule, n))
+				return
+			}
+			targets := make([]uint32, 0, n+1)
+			for i := 0; i <= n && r.err == nil; i++ {
+				depth := r.u32()
+				checkDepth(r, uint64(depth), len(open))
+				targets = append(targets, depth)
+			}
+			in.imm = uint64(len(fn.tables))
+			fn.tables = append(fn.tables, targets)
+		case op == opCall:
+			in.imm = uint64(r.u32())
+			if r.err == nil && in.imm >= uint64(m.numFuncs()) {
+				r.fail(fmt.Errorf("%w: call of unknown function %d", ErrInvalidModule, in.imm))
+			}
+		case op == opCallIndirect:
+			in.imm = uint64(r.u32())
+			m.typeAt(r, uint32(in.imm))
+			if table := r.u32(); r.err == nil && (table != 0 || !m.hasTable) {
+				r.fail(fmt.Errorf("%w: call through unknown table %d", ErrInvalidModule, table))
+			}
+		case op >= opLocalGet && op <= opLocalTee:
+			in.imm = uint64(r.u32())
+			if r.err == nil && in.imm >= numLocals {
+				r.fail(fmt.Errorf("%w: unknown local %d", ErrInvalidModule, in.imm))
+			}
+		case op == opGlobalGet, op == opGlobalSet:
+			in.imm = uint64(r.u32())
+			switch {
+			case r.err != nil:
+			case in.imm >= uint64(len(m.globals)):
+				r.fail(fmt.Errorf("%w: unknown global %d", ErrInvalidModule, in.imm))
+			case op == opGlobalSet && !m.globals[in.imm].mutable:
+				r.fail(fmt.Errorf("%w: global %d is immutable", ErrInvalidModule, in.imm))
+			}
+		case op == opI32Load, op == opI64Load, op >= opI32Load8S && op <= opI64Store32 && op != 0x38 && op != 0x39:
+			requireMemory(m, r)
+			r.u32() // alignment is only a hint
+			in.imm = uint64(r.u32())
+		case op == opMemorySize, op == opMemoryGrow:
+			requireMemory(m, r)
+			if mem := r.byte(); r.err == nil && mem != 0 {
+				r.fail(fmt.Errorf("%w: memory %d", ErrInvalidModule, mem))
+			}
+		case op == opI32Const:
+			in.imm = uint64(uint32(r.s32()))
+		case op == opI64Const:
+			in.imm = uint64(r.s64())
+		case op >= opI32Eqz && op <= opI64GeU, op >= opI32Clz && op <= opI64Rotr,
+			op == opI32Wrap, op == opI64ExtS, op == opI64ExtU, op >= opI32Ext8S && op <= opI64Ext32S:
+		case op == opPrefix:
+			sub := r.u32()
+			in.op = uint16(0xfc00 | sub&0xff)
+			switch {
+			case r.err != nil:
+			case sub == 10:
+				requireMemory(m, r)
+				if dst, src := r.byte(), r.byte(); r.err == nil && (dst != 0 || src != 0) {
+					r.fail(fmt.Errorf("%w: memory.copy between memories", ErrInvalidModule))
+				}
+			case sub == 11:
+				requireMemory(m, r)
+				if mem := r.byte(); r.err == nil && mem != 0 {
+					r.fail(fmt.Errorf("%w: memory %d", ErrInvalidModule, mem))
+				}
+			default:
+				r.fail(fmt.Errorf("%w: opcode 0xfc %d", ErrUnsupported, sub))
+			}
+		default:
+			r.fail(fmt.Errorf("%w: opcode 0x%02x", ErrUnsupported, op))
+		}
+		fn.code = append(fn.code, in)
+	}
+}
+
+// blockType reads the signature of a block as its parameter and result counts
+func blockType(m *Module, r *reader) (uint32, uint32) {
+	if r.err != nil || r.pos >= len(r.data) {
+		r.next(1)
+		return 0, 0
+	}
+	switch b := r.data[r.pos]; ValueType(b) {
+	case 0x40:
+		r.pos++
+		return 0, 0
+	case I32, I64, 0x7d, 0x7c:
+		r.valueType()
+		return 0, 1
+	}
+	idx := r.leb(33, true)
+	if r.err == nil && int64(idx) < 0 {
+		r.fail(fmt.Errorf("%w: block type %d", ErrInvalidModule, int64(idx)))
+	}
+	ft := m.typeAt(r, uint32(idx))
+	return uint32(len(ft.Params)), uint32(len(ft.Results))
+}
+
+func checkDepth(r *reader, depth uint64, open int) {
+	if r.err == nil && depth > uint64(open) {
+		r.fail(fmt.Errorf("%w: branch depth %d", ErrInvalidModule, depth))
+	}
+}
+
+func requireMemory(m *Module, r *reader) {
+	if r.err == nil && !m.hasMem {
+		r.fail(fmt.Errorf("%w: memory instruction without a memory", ErrInvalidModule))
+	}
+}
@@ -0,0 +1,707 @@
+package wasm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"runtime"
+)
+
+// HostModule is the only module name imports may come from
+const HostModule = "env"
+
+// Gas charged on top of one unit per instruction executed
+const (
+	GasPerPage  uint64 = 64 // per memory page, at instantiation and on memory.grow
+	GasPerWord  uint64 = 1  // per 32 bytes moved by memory.copy and memory.fill
+	GasPerLocal uint64 = 1  // per parameter and local of a called function
+)
+
+var (
+	ErrTrap          = errors.New("wasm trap")
+	ErrOutOfGas      = errors.New("out of gas")
+	ErrUnknownImport = errors.New("import not provided by the host")
+	ErrNotExported   = errors.New("function not exported")
+)
+
+// HostFunc is a function the host provides to modules. Call receives the
+// arguments in order and returns the results; an error stops execution and is
+// returned from Instance.Call unchanged.
+type HostFunc struct {
+	Type FuncType
+	Call func(inst *Instance, args []uint64) ([]uint64, error)
+}
+
+// CheckImports verifies host provides every import of m with the right signature
+func (m *Module) CheckImports(host map[string]HostFunc) error {
+	for _, imp := range m.imports {
+		h, ok := host[imp.Name]
+		if imp.Module != HostModule || !ok {
+			return fmt.Errorf("%w: %s.%s", ErrUnknownImport, imp.Module, imp.Name)
+		}
+		if !h.Type.equal(imp.Type) {
+			return fmt.Errorf("%w: %s.%s has type %s, host provides %s", ErrUnknownImport, imp.Module, imp.Name, imp.Type, h.Type)
+		}
+	}
+	return nil
+}
+
+// Instance is a module with its own memory, globals and table, linked to host
+// functions. It is not safe for concurrent use.
+type Instance struct {
+	module   *Module
+	host     []HostFunc
+	memory   []byte
+	maxPages uint32
+	globals  []uint64
+	table    []int64 // function index, or -1 for an empty entry
+	stack    []uint64
+	depth    int
+	gasLimit uint64
+	gasUsed  uint64
+}
+
+// Instantiate links m to host and sets up its memory, globals and table. The
+// instance can use at most gasLimit gas, including what setting up its memory
+// costs.
+func Instantiate(m *Module, host map[string]HostFunc, gasLimit uint64) (*Instance, error) {
+	if err := m.CheckImports(host); err != nil {
+		return nil, err
+	}
+	inst := &Instance{module: m, gasLimit: gasLimit}
+	for _, imp := range m.imports {
+		inst.host = append(inst.host, host[imp.Name])
+	}
+	if m.hasMem {
+		if err := inst.UseGas(uint64(m.memMin) * GasPerPage); err != nil {
+			return nil, err
+		}
+		inst.memory = make([]byte, int(m.memMin)*PageSize)
+		inst.maxPages = m.memMax
+	}
+
+	inst.globals = make([]uint64, len(m.globals))
+	for i, g := range m.globals {
+		inst.globals[i] = inst.eval(g.init)
+	}
+
+	if m.hasTable {
+		inst.table = make([]int64, m.tableMin)
+		for i := range inst.table {
+			inst.table[i] = -1
+		}
+	}
+	for _, seg := range m.elements {
+		offset := uint64(uint32(inst.eval(seg.offset)))
+		if offset+uint64(len(seg.funcs)) > uint64(len(inst.table)) {
+			return nil, fmt.Errorf("%w: element segment out of bounds", ErrTrap)
+		}
+		for i, f := range seg.funcs {
+			inst.table[offset+uint64(i)] = int64(f)
+		}
+	}
+	for _, seg := range m.data {
+		offset := uint64(uint32(inst.eval(seg.offset)))
+		if offset+uint64(len(seg.bytes)) > uint64(len(inst.memory)) {
+			return nil, fmt.Errorf("%w: data segment out of bounds", ErrTrap)
+		}
+		copy(inst.memory[offset:], seg.bytes)
+	}
+	return inst, nil
+}
+
+func (inst *Instance) eval(expr constExpr) uint64 {
+	if expr.global {
+		return inst.globals[expr.value]
+	}
+	return expr.value
+}
+
+// GasUsed returns the gas the instance has used so far
+func (inst *Instance) GasUsed() uint64 {
+	return inst.gasUsed
+}
+
+// UseGas charges n gas. When the limit is exceeded all the gas is used up and
+// ErrOutOfGas is returned; host functions return it to stop execution.
+func (inst *Instance) UseGas(n uint64) error {
+	if n > inst.gasLimit-inst.gasUsed {
+		inst.gasUsed = inst.gasLimit
+		return ErrOutOfGas
+	}
+	inst.gasUsed += n
+	return nil
+}
+
+// Read returns a copy of n bytes of memory starting at ptr
+func (inst *Instance) Read(ptr, n uint32) ([]byte, error) {
+	if uint64(ptr)+uint64(n) > uint64(len(inst.memory)) {
+		return nil, fmt.Errorf("%w: read of %d bytes at %d is out of bounds", ErrTrap, n, ptr)
+	}
+	return append([]byte(nil), inst.memory[ptr:ptr+n]...), nil
+}
+
+// Write copies data into memory starting at ptr
+func (inst *Instance) Write(ptr uint32, data []byte) error {
+	if uint64(ptr)+uint64(len(data)) > uint64(len(inst.memory)) {
+		return fmt.Errorf("%w: write of %d bytes at %d is out of bounds", ErrTrap, len(data), ptr)
+	}
+	copy(inst.memory[ptr:], data)
+	return nil
+}
+
+// Call runs an exported function. A trap, running out of gas or an error from
+// a host function stops it; memory and globals keep whatever it wrote before.
+func (inst *Instance) Call(name string, args ...uint64) (results []uint64, err error) {
+	idx, ok := inst.module.exports[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotExported, name)
+	}
+	if ft := inst.module.funcType(idx); len(args) != len(ft.Params) {
+		return nil, fmt.Errorf("%w: %s takes %d arguments, got %d", ErrTrap, name, len(ft.Params), len(args))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch e := r.(type) {
+			case trap:
+				err = e.err
+			case runtime.Error:
+				// Malformed code that underflows the value stack
+				err = fmt.Errorf("%w: %v", ErrTrap, e)
+			default:
+				panic(r)
+			}
+			inst.stack, inst.depth, results = inst.stack[:0], 0, nil
+		}
+	}()
+	inst.stack = append(inst.stack[:0], args...)
+	inst.call(idx)
+	results = append([]uint64(nil), inst.stack...)
+	inst.stack = inst.stack[:0]
+	return results, nil
+}
+
+// trap carries the error that stops execution up to Call
+type trap struct {
+	err error
+}
+
+func fail(format string, args ...any) {
+	panic(trap{fmt.Errorf("%w: "+format, append([]any{ErrTrap}, args...)...)})
+}
+
+func (inst *Instance) charge(n uint64) {
+	if err := inst.UseGas(n); err != nil {
+		panic(trap{err})
+	}
+}
+
+func (inst *Instance) push(v uint64) {
+	inst.stack = append(inst.stack, v)
+}
+
+func (inst *Instance) pop() uint64 {
+	v := inst.stack[len(inst.stack)-1]
+	inst.stack = inst.stack[:len(inst.stack)-1]
+	return v
+}
+
+// unwind drops the values between height and the top arity values
+func (inst *Instance) unwind(height, arity int) {
+	top := len(inst.stack) - arity
+	if top < height {
+		fail("value stack underflow")
+	}
+	copy(inst.stack[height:], inst.stack[top:])
+	inst.stack = inst.stack[:height+arity]
+}
+
+func (inst *Instance) call(idx uint32) {
+	if int(idx) < len(inst.host) {
+		inst.callHost(inst.host[idx])
+		return
+	}
+	fn := &inst.module.funcs[int(idx)-len(inst.host)]
+	ft := inst.module.types[fn.typ]
+	if inst.depth >= MaxCallDepth {
+		fail("call stack exhausted")
+	}
+	if len(inst.stack) > MaxStackHeight {
+		fail("value stack exhausted")
+	}
+
+	params := len(ft.Params)
+	locals := make([]uint64, params+fn.locals)
+	inst.charge(uint64(len(locals)) * GasPerLocal)
+	base := len(inst.stack) - params
+	if base < 0 {
+		fail("value stack underflow")
+	}
+	copy(locals, inst.stack[base:])
+	inst.stack = inst.stack[:base]
+
+	inst.depth++
+	inst.execute(fn, locals, base, len(ft.Results))
+	inst.depth--
+}
+
+func (inst *Instance) callHost(h HostFunc) {
+	base := len(inst.stack) - len(h.Type.Params)
+	if base < 0 {
+		fail("value stack underflow")
+	}
+	args := append([]uint64(nil), inst.stack[base:]...)
+	inst.stack = inst.stack[:base]
+	results, err := h.Call(inst, args)
+	if err != nil {
+		panic(trap{err})
+	}
+	if len(results) != len(h.Type.Results) {
+		fail("host function returned %d results, expected %d", len(results), len(h.Type.Results))
+	}
+	for i, v := range results {
+		if h.Type.Results[i] == I32 {
+			v = uint64(uint32(v))
+		}
+		inst.push(v)
+	}
+}
+
+// label is a block a branch can target
+type label struct {
+	height  int // stack height below the block's values
+	arity   int // values a branch to the label carries
+	results int // values the block leaves when it ends
+	cont    int // position execution continues at after a branch
+}
+
+// execute runs fn with its locals. base is the stack height below the frame,
+// where its results are left.
+func (inst *Instance) execute(fn *function, locals []uint64, base, results int) {
+	var labels []label
+	pc := 0
+	for {
+		in := &fn.code[pc]
+		inst.charge(1)
+
+		switch in.op {
+		case opUnreachable:
+			fail("unreachable executed")
+		case opNop:
+		case opBlock, opLoop, opIf:
+			if in.op == opIf && inst.pop() == 0 {
+				if in.els == 0 {
+					pc = int(in.end) + 1
+					continue
+				}
+				labels = append(labels, inst.enter(in, int(in.end)+1))
+				pc = int(in.els) + 1
+				continue
+			}
+			if in.op == opLoop {
+				labels = append(labels, inst.enter(in, pc))
+			} else {
+				labels = append(labels, inst.enter(in, int(in.end)+1))
+			}
+		case opElse:
+			// The then branch finished, so skip the else branch
+			l := labels[len(labels)-1]
+			labels = labels[:len(labels)-1]
+			inst.unwind(l.height, l.results)
+			pc = int(in.end) + 1
+			continue
+		case opEnd:
+			if len(labels) == 0 {
+				inst.unwind(base, results)
+				return
+			}
+			l := labels[len(labels)-1]
+			labels = labels[:len(labels)-1]
+			inst.unwind(l.height, l.results)
+		case opBr, opBrIf, opBrTable:
+			depth := in.imm
+			if in.op == opBrIf && inst.pop() == 0 {
+				break
+			}
+			if in.op == opBrTable {
+				targets := fn.tables[in.imm]
+				i := uint64(uint32(inst.pop()))
+				if last := uint64(len(targets) - 1); i > last {
+					i = last
+				}
+				depth = uint64(targets[i])
+			}
+			if int(depth) == len(labels) {
+				inst.unwind(base, results)
+				return
+			}
+			l := labels[len(labels)-1-int(depth)]
+			labels = labels[:len(labels)-1-int(depth)]
+			inst.unwind(l.height, l.arity)
+			pc = l.cont
+			continue
+		case opReturn:
+			inst.unwind(base, results)
+			return
+		case opCall:
+			inst.call(uint32(in.imm))
+		case opCallIndirect:
+			i := uint64(uint32(inst.pop()))
+			if i >= uint64(len(inst.table)) {
+				fail("undefined table element %d", i)
+			}
+			f := inst.table[i]
+			if f < 0 {
+				fail("uninitialized table element %d", i)
+			}
+			if !inst.module.funcType(uint32(f)).equal(inst.module.types[in.imm]) {
+				fail("indirect call type mismatch")
+			}
+			inst.call(uint32(f))
+		case opDrop:
+			inst.pop()
+		case opSelect:
+			c := inst.pop()
+			b := inst.pop()
+			a := inst.pop()
+			if c != 0 {
+				inst.push(a)
+			} else {
+				inst.push(b)
+			}
+		case opLocalGet:
+			inst.push(locals[in.imm])
+		case opLocalSet:
+			locals[in.imm] = inst.pop()
+		case opLocalTee:
+			locals[in.imm] = inst.stack[len(inst.stack)-1]
+		case opGlobalGet:
+			inst.push(inst.globals[in.imm])
+		case opGlobalSet:
+			inst.globals[in.imm] = inst.pop()
+		case opI32Const, opI64Const:
+			inst.push(in.imm)
+		case opMemorySize:
+			inst.push(uint64(len(inst.memory) / PageSize))
+		case opMemoryGrow:
+			inst.push(inst.grow(uint32(inst.pop())))
+		case opMemoryCopy:
+			n, src, dst := uint64(uint32(inst.pop())), uint64(uint32(inst.pop())), uint64(uint32(inst.pop()))
+			if src+n > uint64(len(inst.memory)) || dst+n > uint64(len(inst.memory)) {
+				fail("memory.copy out of bounds")
+			}
+			inst.charge((n + 31) / 32 * GasPerWord)
+			copy(inst.memory[dst:dst+n], inst.memory[src:src+n])
+		case opMemoryFill:
+			n, v, dst := uint64(uint32(inst.pop())), byte(inst.pop()), uint64(uint32(inst.pop()))
+			if dst+n > uint64(len(inst.memory)) {
+				fail("memory.fill out of bounds")
+			}
+			inst.charge((n + 31) / 32 * GasPerWord)
+			for i := dst; i < dst+n; i++ {
+				inst.memory[i] = v
+			}
+		default:
+			switch {
+			case in.op >= opI32Load && in.op <= opI64Store32:
+				inst.memoryAccess(in)
+			default:
+				inst.push(numeric(in.op, inst))
+			}
+		}
+		pc++
+	}
+}
+
+// enter starts the block of in, continuing at cont when a branch targets it
+func (inst *Instance) enter(in *instr, cont int) label {
+	height := len(inst.stack) - int(in.params)
+	if height < 0 {
+		fail("value stack underflow")
+	}
+	if len(inst.stack) > MaxStackHeight {
+		fail("value stack exhausted")
+	}
+	arity := int(in.results)
+	if in.op == opLoop {
+		arity = int(in.params)
+	}
+	return label{height: height, arity: arity, results: int(in.results), cont: cont}
+}
+
+// grow adds delta pages of memory and returns the previous size in pages, or
+// -1 as an i32 if the memory would exceed its maximum
+func (inst *Instance) grow(delta uint32) uint64 {
+	pages := uint32(len(inst.memory) / PageSize)
+	if uint64(pages)+uint64(delta) > uint64(inst.maxPages) {
+		return 0xffffffff
+	}
+	inst.charge(uint64(delta) * GasPerPage)
+	inst.memory = append(inst.memory, make([]byte, int(delta)*PageSize)...)
+	return uint64(pages)
+}
+
+// memoryAccess runs a load or store
+func (inst *Instance) memoryAccess(in *instr) {
+	var v uint64
+	store := in.op >= opI32Store
+	if store {
+		v = inst.pop()
+	}
+	var size uint64
+	switch in.op {
+	case opI32Load8S, opI32Load8U, opI64Load8S, opI64Load8U, opI32Store8, opI64Store8:
+		size = 1
+	case opI32Load16S, opI32Load16U, opI64Load16S, opI64Load16U, opI32Store16, opI64Store16:
+		size = 2
+	case opI32Load, opI64Load32S, opI64Load32U, opI32Store, opI64Store32:
+		size = 4
+	default:
+		size = 8
+	}
+	ea := uint64(uint32(inst.pop())) + in.imm
+	if ea+size > uint64(len(inst.memory)) {
+		fail("out of bounds memory access at %d", ea)
+	}
+	mem := inst.memory[ea : ea+size]
+
+	if store {
+		switch size {
+		case 1:
+			mem[0] = byte(v)
+		case 2:
+			binary.LittleEndian.PutUint16(mem, uint16(v))
+		case 4:
+			binary.LittleEndian.PutUint32(mem, uint32(v))
+		default:
+			binary.LittleEndian.PutUint64(mem, v)
+		}
+		return
+	}
+
+	switch in.op {
+	case opI32Load:
+		v = uint64(binary.LittleEndian.Uint32(mem))
+	case opI64Load:
+		v = binary.LittleEndian.Uint64(mem)
+	case opI32Load8S:
+		v = uint64(uint32(int32(int8(mem[0]))))
+	case opI32Load8U, opI64Load8U:
+		v = uint64(mem[0])
+	case opI32Load16S:
+		v = uint64(uint32(int32(int16(binary.LittleEndian.Uint16(mem)))))
+	case opI32Load16U, opI64Load16U:
+		v = uint64(binary.LittleEndian.Uint16(mem))
+	case opI64Load8S:
+		v = uint64(int64(int8(mem[0])))
+	case opI64Load16S:
+		v = uint64(int64(int16(binary.LittleEndian.Uint16(mem))))
+	case opI64Load32S:
+		v = uint64(int64(int32(binary.LittleEndian.Uint32(mem))))
+	case opI64Load32U:
+		v = uint64(binary.LittleEndian.Uint32(mem))
+	}
+	inst.push(v)
+}
+
+func boolValue(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// numeric pops the operands of an integer comparison, arithmetic or
+// conversion instruction and returns its result
+func numeric(op uint16, inst *Instance) uint64 {
+	// Unary operators
+	switch op {
+	case 0x45:
+		return boolValue(uint32(inst.pop()) == 0)
+	case 0x50:
+		return boolValue(inst.pop() == 0)
+	case 0x67:
+		return uint64(bits.LeadingZeros32(uint32(inst.pop())))
+	case 0x68:
+		return uint64(bits.TrailingZeros32(uint32(inst.pop())))
+	case 0x69:
+		return uint64(bits.OnesCount32(uint32(inst.pop())))
+	case 0x79:
+		return uint64(bits.LeadingZeros64(inst.pop()))
+	case 0x7a:
+		return uint64(bits.TrailingZeros64(inst.pop()))
+	case 0x7b:
+		return uint64(bits.OnesCount64(inst.pop()))
+	case opI32Wrap:
+		return uint64(uint32(inst.pop()))
+	case opI64ExtS:
+		return uint64(int64(int32(inst.pop())))
+	case opI64ExtU:
+		return uint64(uint32(inst.pop()))
+	case 0xc0:
+		return uint64(uint32(int32(int8(inst.pop()))))
+	case 0xc1:
+		return uint64(uint32(int32(int16(inst.pop()))))
+	case 0xc2:
+		return uint64(int64(int8(inst.pop())))
+	case 0xc3:
+		return uint64(int64(int16(inst.pop())))
+	case 0xc4:
+		return uint64(int64(int32(inst.pop())))
+	}
+
+	y := inst.pop()
+	x := inst.pop()
+	if op <= opI32GeU || (op >= 0x6a && op <= opI32Rotr) {
+		return uint64(binary32(op, uint32(x), uint32(y)))
+	}
+	return binary64(op, x, y)
+}
+
+func binary32(op uint16, a, b uint32) uint32 {
+	switch op {
+	case 0x46:
+		return uint32(boolValue(a == b))
+	case 0x47:
+		return uint32(boolValue(a != b))
+	case 0x48:
+		return uint32(boolValue(int32(a) < int32(b)))
+	case 0x49:
+		return uint32(boolValue(a < b))
+	case 0x4a:
+		return uint32(boolValue(int32(a) > int32(b)))
+	case 0x4b:
+		return uint32(boolValue(a > b))
+	case 0x4c:
+		return uint32(boolValue(int32(a) <= int32(b)))
+	case 0x4d:
+		return uint32(boolValue(a <= b))
+	case 0x4e:
+		return uint32(boolValue(int32(a) >= int32(b)))
+	case 0x4f:
+		return uint32(boolValue(a >= b))
+	case 0x6a:
+		return a + b
+	case 0x6b:
+		return a - b
+	case 0x6c:
+		return a * b
+	case 0x6d:
+		if b == 0 {
+			fail("integer divide by zero")
+		}
+		if int32(a) == -1<<31 && int32(b) == -1 {
+			fail("integer overflow")
+		}
+		return uint32(int32(a) / int32(b))
+	case 0x6e:
+		if b == 0 {
+			fail("integer divide by zero")
+		}
+		return a / b
+	case 0x6f:
+		if b == 0 {
+			fail("integer divide by zero")
+		}
+		if int32(b) == -1 {
+			return 0
+		}
+		return uint32(int32(a) % int32(b))
+	case 0x70:
+		if b == 0 {
+			fail("integer divide by zero")
+		}
+		return a % b
+	case 0x71:
+		return a & b
+	case 0x72:
+		return a | b
+	case 0x73:
+		return a ^ b
+	case 0x74:
+		return a << (b & 31)
+	case 0x75:
+		return uint32(int32(a) >> (b & 31))
+	case 0x76:
+		return a >> (b & 31)
+	case 0x77:
+		return bits.RotateLeft32(a, int(b&31))
+	default: // 0x78
+		return bits.RotateLeft32(a, -int(b&31))
+	}
+}
+
+func binary64(op uint16, a, b uint64) uint64 {
+	switch op {
+	case 0x51:
+		return boolValue(a == b)
+	case 0x52:
+		return boolValue(a != b)
+	case 0x53:
+		return boolValue(int64(a) < int64(b))
+	case 0x54:
+		return boolValue(a < b)
+	case 0x55:
+		return boolValue(int64(a) > int64(b))
+	case 0x56:
+		return boolValue(a > b)
+	case 0x57:
+		return boolValue(int64(a) <= int64(b))
+	case 0x58:
+		return boolValue(a <= b)
+	case 0x59:
+		return boolValue(int64(a) >= int64(b))
+	case 0x5a:
+		return boolValue(a >= b)
+	case 0x7c:
+		return a + b
+	case 0x7d:
+		return a - b
+	case 0x7e:
+		return a * b
+	case 0x7f:
+		if b == 0 {
+			fail("integer divide by zero")
+		}
+		if int64(a) == -1<<63 && int64(b) == -1 {
+			fail("integer overflow")
+		}
+		return uint64(int64(a) / int64(b))
+	case 0x80:
+		if b == 0 {
+			fail("integer divide by zero")
+		}
+		return a / b
+	case 0x81:
+		if b == 0 {
+			fail("integer divide by zero")
+		}
+		if int64(b) == -1 {
+			return 0
+		}
+		return uint64(int64(a) % int64(b))
+	case 0x82:
+		if b == 0 {
+			fail("integer divide by zero")
+		}
+		return a % b
+	case 0x83:
+		return a & b
+	case 0x84:
+		return a | b
+	case 0x85:
+		return a ^ b
+	case 0x86:
+		return a << (b & 63)
+	case 0x87:
+		return uint64(int64(a) >> (b & 63))
+	case 0x88:
+		return a >> (b & 63)
+	case 0x89:
+		return bits.RotateLeft64(a, int(b&63))
+	default: // 0x8a
+		return bits.RotateLeft64(a, -int(b&63))
+	}
+}
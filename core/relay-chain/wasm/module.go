@@ -0,0 +1,626 @@
+// Package wasm decodes and runs WebAssembly modules for smart contracts. It is
+// a small interpreter written for determinism rather than speed: only integer
+// types and instructions are accepted, memory and call depth are bounded, and
+// every instruction is metered so execution stops at a gas limit. Host
+// functions are imported from the "env" module.
+package wasm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Limits a module must stay within. They keep the memory and time one
+// execution can take bounded and identical on every node.
+const (
+	PageSize        = 65536 // bytes per memory page
+	MaxPages        = 16    // 1 MiB of linear memory
+	MaxTableSize    = 1024
+	MaxFunctions    = 4096
+	MaxLocals       = 1024 // parameters and locals of one function
+	MaxCallDepth    = 256
+	MaxStackHeight  = 1 << 16
+	MaxBranchTable  = 1024
+	maxVectorLength = 1 << 16
+)
+
+var (
+	ErrInvalidModule = errors.New("invalid wasm module")
+	ErrUnsupported   = errors.New("unsupported wasm feature")
+)
+
+// ValueType is the type of a parameter, result, local or global
+type ValueType byte
+
+const (
+	I32 ValueType = 0x7f
+	I64 ValueType = 0x7e
+)
+
+// FuncType is the signature of a function
+type FuncType struct {
+	Params  []ValueType
+	Results []ValueType
+}
+
+func (ft FuncType) equal(other FuncType) bool {
+	if len(ft.Params) != len(other.Params) || len(ft.Results) != len(other.Results) {
+		return false
+	}
+	for i := range ft.Params {
+		if ft.Params[i] != other.Params[i] {
+			return false
+		}
+	}
+	for i := range ft.Results {
+		if ft.Results[i] != other.Results[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (ft FuncType) String() string {
+	return fmt.Sprintf("%v -> %v", ft.Params, ft.Results)
+}
+
+// Import is a function the module expects its host to provide
+type Import struct {
+	Module string
+	Name   string
+	Type   FuncType
+}
+
+type global struct {
+	mutable bool
+	init    constExpr
+}
+
+// constExpr is an i32.const, i64.const or global.get initializer
+type constExpr struct {
+	global bool
+	value  uint64 // the constant, or the index of the global read
+}
+
+type element struct {
+	offset constExpr
+	funcs  []uint32
+}
+
+type dataSegment struct {
+	offset constExpr
+	bytes  []byte
+}
+
+type function struct {
+	typ    uint32
+	locals int // locals declared in the body, after the parameters
+	code   []instr
+	tables [][]uint32 // br_table targets, the default last
+}
+
+// Module is a decoded and validated module. It holds no execution state, so
+// one Module can be instantiated any number of times.
+type Module struct {
+	types    []FuncType
+	imports  []Import
+	funcs    []function
+	hasTable bool
+	tableMin uint32
+	hasMem   bool
+	memMin   uint32
+	memMax   uint32
+	globals  []global
+	exports  map[string]uint32 // exported function name -> function index
+	elements []element
+	data     []dataSegment
+}
+
+// Imports lists the functions the module imports, in index order
+func (m *Module) Imports() []Import {
+	return append([]Import(nil), m.imports...)
+}
+
+// ExportedFunction returns the signature of an exported function
+func (m *Module) ExportedFunction(name string) (FuncType, bool) {
+	idx, ok := m.exports[name]
+	if !ok {
+		return FuncType{}, false
+	}
+	return m.funcType(idx), true
+}
+
+// Exports lists the names of the exported functions
+func (m *Module) Exports() []string {
+	names := make([]string, 0, len(m.exports))
+	for name := range m.exports {
+		names = append(names, name)
+	}
+	return names
+}
+
+// funcType returns the signature of function idx in the combined index space
+// of imports followed by module functions
+func (m *Module) funcType(idx uint32) FuncType {
+	if int(idx) < len(m.imports) {
+		return m.imports[idx].Type
+	}
+	return m.types[m.funcs[int(idx)-len(m.imports)].typ]
+}
+
+func (m *Module) numFuncs() int {
+	return len(m.imports) + len(m.funcs)
+}
+
+// Section IDs in the order the binary format requires them
+const (
+	secCustom    = 0
+	secType      = 1
+	secImport    = 2
+	secFunction  = 3
+	secTable     = 4
+	secMemory    = 5
+	secGlobal    = 6
+	secExport    = 7
+	secStart     = 8
+	secElement   = 9
+	secCode      = 10
+	secData      = 11
+	secDataCount = 12
+)
+
+// sectionRank orders sections; data count sits between element and code
+var sectionRank = map[byte]int{
+	secType: 1, secImport: 2, secFunction: 3, secTable: 4, secMemory: 5, secGlobal: 6,
+	secExport: 7, secStart: 8, secElement: 9, secDataCount: 10, secCode: 11, secData: 12,
+}
+
+var wasmHeader = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+// Decode parses and validates a binary module. Floating point types and
+// instructions, start functions, passive segments and imports other than
+// functions are rejected.
+func Decode(code []byte) (*Module, error) {
+	if len(code) < len(wasmHeader) || string(code[:len(wasmHeader)]) != string(wasmHeader) {
+		return nil, fmt.Errorf("%w: bad magic number or version", ErrInvalidModule)
+	}
+	m := &Module{exports: make(map[string]uint32)}
+	r := &reader{data: code, pos: len(wasmHeader)}
+	bodies := 0
+	lastRank := 0
+	for r.err == nil && r.pos < len(r.data) {
+		id := r.byte()
+		size := r.u32()
+		body := r.next(int(size))
+		if r.err != nil {
+			break
+		}
+		if id == secCustom {
+			continue
+		}
+		rank, known := sectionRank[id]
+		if !known {
+			return nil, fmt.Errorf("%w: unknown section %d", ErrInvalidModule, id)
+		}
+		if rank <= lastRank {
+			return nil, fmt.Errorf("%w: section %d out of order", ErrInvalidModule, id)
+		}
+		lastRank = rank
+
+		s := &reader{data: body}
+		switch id {
+		case secType:
+			m.decodeTypes(s)
+		case secImport:
+			m.decodeImports(s)
+		case secFunction:
+			m.decodeFunctions(s)
+		case secTable:
+			m.decodeTable(s)
+		case secMemory:
+			m.decodeMemory(s)
+		case secGlobal:
+			m.decodeGlobals(s)
+		case secExport:
+			m.decodeExports(s)
+		case secStart:
+			s.fail(fmt.Errorf("%w: start function", ErrUnsupported))
+		case secElement:
+			m.decodeElements(s)
+		case secDataCount:
+			s.u32()
+		case secCode:
+			bodies = m.decodeCode(s)
+		case secData:
+			m.decodeData(s)
+		}
+		if s.err == nil && s.pos != len(s.data) {
+			s.fail(fmt.Errorf("%w: section %d has trailing bytes", ErrInvalidModule, id))
+		}
+		if s.err != nil {
+			return nil, s.err
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if bodies != len(m.funcs) {
+		return nil, fmt.Errorf("%w: %d functions declared, %d bodies", ErrInvalidModule, len(m.funcs), bodies)
+	}
+	return m, nil
+}
+
+func (m *Module) decodeTypes(r *reader) {
+	n := r.vectorLength()
+	for i := 0; i < n && r.err == nil; i++ {
+		if form := r.byte(); r.err == nil && form != 0x60 {
+			r.fail(fmt.Errorf("%w: type form 0x%x", ErrInvalidModule, form))
+			return
+		}
+		var ft FuncType
+		ft.Params = r.valueTypes()
+		ft.Results = r.valueTypes()
+		if len(ft.Results) > 1 {
+			r.fail(fmt.Errorf("%w: multiple results", ErrUnsupported))
+			return
+		}
+		m.types = append(m.types, ft)
+	}
+}
+
+func (m *Module) decodeImports(r *reader) {
+	n := r.vectorLength()
+	for i := 0; i < n && r.err == nil; i++ {
+		imp := Import{Module: r.name(), Name: r.name()}
+		if kind := r.byte(); r.err == nil && kind != 0x00 {
+			r.fail(fmt.Errorf("%w: import %s.%s is not a function", ErrUnsupported, imp.Module, imp.Name))
+			return
+		}
+		imp.Type = m.typeAt(r, r.u32())
+		m.imports = append(m.imports, imp)
+	}
+}
+
+// decodeFunctions declares the module's functions; the code section fills in
+// their bodies
+func (m *Module) decodeFunctions(r *reader) {
+	n := r.vectorLength()
+	if len(m.imports)+n > MaxFunctions {
+		r.fail(fmt.Errorf("%w: more than %d functions", ErrInvalidModule, MaxFunctions))
+		return
+	}
+	for i := 0; i < n && r.err == nil; i++ {
+		idx := r.u32()
+		m.typeAt(r, idx)
+		m.funcs = append(m.funcs, function{typ: idx})
+	}
+}
+
+func (m *Module) decodeTable(r *reader) {
+	if n := r.vectorLength(); n != 1 {
+		r.fail(fmt.Errorf("%w: %d tables", ErrUnsupported, n))
+		return
+	}
+	if ref := r.byte(); r.err == nil && ref != 0x70 {
+		r.fail(fmt.Errorf("%w: table of type 0x%x", ErrUnsupported, ref))
+		return
+	}
+	min, _ := r.limits()
+	if min > MaxTableSize {
+		r.fail(fmt.Errorf("%w: table of %d entries exceeds %d", ErrInvalidModule, min, MaxTableSize))
+	}
+	m.hasTable, m.tableMin = true, min
+}
+
+func (m *Module) decodeMemory(r *reader) {
+	if n := r.vectorLength(); n != 1 {
+		r.fail(fmt.Errorf("%w: %d memories", ErrUnsupported, n))
+		return
+	}
+	min, max := r.limits()
+	if min > MaxPages {
+		r.fail(fmt.Errorf("%w: memory of %d pages exceeds %d", ErrInvalidModule, min, MaxPages))
+		return
+	}
+	if max > MaxPages {
+		max = MaxPages
+	}
+	m.hasMem, m.memMin, m.memMax = true, min, max
+}
+
+func (m *Module) decodeGlobals(r *reader) {
+	n := r.vectorLength()
+	for i := 0; i < n && r.err == nil; i++ {
+		r.valueType()
+		mut := r.byte()
+		if mut > 1 {
+			r.fail(fmt.Errorf("%w: global mutability %d", ErrInvalidModule, mut))
+			return
+		}
+		m.globals = append(m.globals, global{mutable: mut == 1, init: m.constExpr(r, len(m.globals))})
+	}
+}
+
+func (m *Module) decodeExports(r *reader) {
+	n := r.vectorLength()
+	for i := 0; i < n && r.err == nil; i++ {
+		name := r.name()
+		kind := r.byte()
+		idx := r.u32()
+		if r.err != nil {
+			return
+		}
+		if kind != 0x00 {
+			// Exported memories, tables and globals are not visible to the host
+			continue
+		}
+		if int(idx) >= m.numFuncs() {
+			r.fail(fmt.Errorf("%w: export %s of unknown function %d", ErrInvalidModule, name, idx))
+			return
+		}
+		if _, dup := m.exports[name]; dup {
+			r.fail(fmt.Errorf("%w: duplicate export %s", ErrInvalidModule, name))
+			return
+		}
+		m.exports[name] = idx
+	}
+}
+
+func (m *Module) decodeElements(r *reader) {
+	n := r.vectorLength()
+	for i := 0; i < n && r.err == nil; i++ {
+		if flags := r.u32(); r.err == nil && flags != 0 {
+			r.fail(fmt.Errorf("%w: element segment kind %d", ErrUnsupported, flags))
+			return
+		}
+		if !m.hasTable {
+			r.fail(fmt.Errorf("%w: element segment without a table", ErrInvalidModule))
+			return
+		}
+		seg := element{offset: m.constExpr(r, len(m.globals))}
+		count := r.vectorLength()
+		for j := 0; j < count && r.err == nil; j++ {
+			idx := r.u32()
+			if int(idx) >= m.numFuncs() {
+				r.fail(fmt.Errorf("%w: element of unknown function %d", ErrInvalidModule, idx))
+				return
+			}
+			seg.funcs = append(seg.funcs, idx)
+		}
+		m.elements = append(m.elements, seg)
+	}
+}
+
+// decodeCode compiles the function bodies and returns how many there were
+func (m *Module) decodeCode(r *reader) int {
+	n := r.vectorLength()
+	if n != len(m.funcs) {
+		r.fail(fmt.Errorf("%w: %d functions declared, %d bodies", ErrInvalidModule, len(m.funcs), n))
+		return n
+	}
+	for i := 0; i < n && r.err == nil; i++ {
+		size := r.u32()
+		body := &reader{data: r.next(int(size))}
+		if r.err != nil {
+			return n
+		}
+		fn := &m.funcs[i]
+		params := len(m.types[fn.typ].Params)
+		groups := body.vectorLength()
+		for j := 0; j < groups && body.err == nil; j++ {
+			count := body.u32()
+			body.valueType()
+			if uint64(params)+uint64(fn.locals)+uint64(count) > MaxLocals {
+				body.fail(fmt.Errorf("%w: function %d has more than %d locals", ErrInvalidModule, i, MaxLocals))
+				break
+			}
+			fn.locals += int(count)
+		}
+		if body.err == nil {
+			compileBody(m, fn, body)
+		}
+		if body.err != nil {
+			r.fail(fmt.Errorf("function %d: %w", i, body.err))
+			return n
+		}
+	}
+	return n
+}
+
+func (m *Module) decodeData(r *reader) {
+	n := r.vectorLength()
+	for i := 0; i < n && r.err == nil; i++ {
+		flags := r.u32()
+		switch {
+		case r.err != nil:
+			return
+		case flags == 2:
+			if mem := r.u32(); r.err == nil && mem != 0 {
+				r.fail(fmt.Errorf("%w: data for memory %d", ErrInvalidModule, mem))
+				return
+			}
+		case flags != 0:
+			r.fail(fmt.Errorf("%w: passive data segment", ErrUnsupported))
+			return
+		}
+		if !m.hasMem {
+			r.fail(fmt.Errorf("%w: data segment without a memory", ErrInvalidModule))
+			return
+		}
+		seg := dataSegment{offset: m.constExpr(r, len(m.globals))}
+		seg.bytes = r.next(int(r.u32()))
+		m.data = append(m.data, seg)
+	}
+}
+
+// typeAt returns type idx, failing r if there is no such type
+func (m *Module) typeAt(r *reader, idx uint32) FuncType {
+	if r.err == nil && int(idx) >= len(m.types) {
+		r.fail(fmt.Errorf("%w: unknown type %d", ErrInvalidModule, idx))
+	}
+	if r.err != nil {
+		return FuncType{}
+	}
+	return m.types[idx]
+}
+
+// constExpr reads an initializer. It may only read globals with an index
+// below globals, which are already initialized when it runs.
+func (m *Module) constExpr(r *reader, globals int) constExpr {
+	var expr constExpr
+	switch op := r.byte(); op {
+	case opI32Const:
+		expr.value = uint64(uint32(r.s32()))
+	case opI64Const:
+		expr.value = uint64(r.s64())
+	case opGlobalGet:
+		idx := r.u32()
+		if r.err == nil && int(idx) >= globals {
+			r.fail(fmt.Errorf("%w: initializer reads unknown global %d", ErrInvalidModule, idx))
+		}
+		expr.global, expr.value = true, uint64(idx)
+	default:
+		if r.err == nil {
+			r.fail(fmt.Errorf("%w: initializer opcode 0x%x", ErrUnsupported, op))
+		}
+	}
+	if end := r.byte(); r.err == nil && end != opEnd {
+		r.fail(fmt.Errorf("%w: initializer is not a single constant", ErrInvalidModule))
+	}
+	return expr
+}
+
+// reader decodes the binary format; the first error sticks and later reads
+// return zero values
+type reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *reader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.data)-r.pos < n {
+		r.fail(fmt.Errorf("%w: unexpected end", ErrInvalidModule))
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *reader) byte() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+// leb reads a LEB128 integer of at most bits bits, sign extending it if signed
+func (r *reader) leb(bits uint, signed bool) uint64 {
+	var result uint64
+	var shift uint
+	for {
+		b := r.byte()
+		if r.err != nil {
+			return 0
+		}
+		if shift >= bits {
+			r.fail(fmt.Errorf("%w: integer too long", ErrInvalidModule))
+			return 0
+		}
+		result |= uint64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if signed && shift < 64 && b&0x40 != 0 {
+				result |= ^uint64(0) << shift
+			}
+			return result
+		}
+	}
+}
+
+func (r *reader) u32() uint32 {
+	v := r.leb(32, false)
+	if v > 0xffffffff {
+		r.fail(fmt.Errorf("%w: integer too large", ErrInvalidModule))
+		return 0
+	}
+	return uint32(v)
+}
+
+func (r *reader) s32() int32 {
+	v := int64(r.leb(32, true))
+	if v < -1<<31 || v > 1<<31-1 {
+		r.fail(fmt.Errorf("%w: integer too large", ErrInvalidModule))
+		return 0
+	}
+	return int32(v)
+}
+
+func (r *reader) s64() int64 {
+	return int64(r.leb(64, true))
+}
+
+func (r *reader) vectorLength() int {
+	n := r.u32()
+	if n > maxVectorLength {
+		r.fail(fmt.Errorf("%w: vector of %d entries", ErrInvalidModule, n))
+		return 0
+	}
+	return int(n)
+}
+
+func (r *reader) name() string {
+	return string(r.next(int(r.u32())))
+}
+
+func (r *reader) valueType() ValueType {
+	t := ValueType(r.byte())
+	switch {
+	case r.err != nil:
+	case t == 0x7d || t == 0x7c:
+		r.fail(fmt.Errorf("%w: floating point", ErrUnsupported))
+	case t != I32 && t != I64:
+		r.fail(fmt.Errorf("%w: value type 0x%x", ErrUnsupported, byte(t)))
+	}
+	return t
+}
+
+func (r *reader) valueTypes() []ValueType {
+	n := r.vectorLength()
+	if n > MaxLocals {
+		r.fail(fmt.Errorf("%w: %d values", ErrInvalidModule, n))
+		return nil
+	}
+	types := make([]ValueType, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		types = append(types, r.valueType())
+	}
+	return types
+}
+
+// limits reads a table or memory size; max is math.MaxUint32 when absent
+func (r *reader) limits() (min, max uint32) {
+	switch flag := r.byte(); flag {
+	case 0x00:
+		return r.u32(), 0xffffffff
+	case 0x01:
+		min, max = r.u32(), r.u32()
+		if r.err == nil && max < min {
+			r.fail(fmt.Errorf("%w: limit maximum below minimum", ErrInvalidModule))
+		}
+		return min, max
+	default:
+		r.fail(fmt.Errorf("%w: limits flag 0x%x", ErrUnsupported, flag))
+		return 0, 0
+	}
+}
@@ -0,0 +1,251 @@
+package wasm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Helpers that assemble binary modules by hand
+
+func leb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func vec(items ...[]byte) []byte {
+	out := leb(uint64(len(items)))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func section(id byte, content []byte) []byte {
+	return append(append([]byte{id}, leb(uint64(len(content)))...), content...)
+}
+
+func name(s string) []byte {
+	return append(leb(uint64(len(s))), s...)
+}
+
+func funcType(params, results []byte) []byte {
+	return append(append([]byte{0x60}, append(leb(uint64(len(params))), params...)...), append(leb(uint64(len(results))), results...)...)
+}
+
+// body wraps code in a function body declaring count i32 locals
+func body(locals uint32, code ...byte) []byte {
+	var decl []byte
+	if locals == 0 {
+		decl = []byte{0x00}
+	} else {
+		decl = append([]byte{0x01}, append(leb(uint64(locals)), 0x7f)...)
+	}
+	content := append(decl, code...)
+	return append(leb(uint64(len(content))), content...)
+}
+
+func export(n string, idx uint32) []byte {
+	return append(append(name(n), 0x00), leb(uint64(idx))...)
+}
+
+func module(sections ...[]byte) []byte {
+	out := append([]byte(nil), wasmHeader...)
+	for _, s := range sections {
+		out = append(out, s...)
+	}
+	return out
+}
+
+func instantiate(t *testing.T, code []byte, host map[string]HostFunc, gas uint64) *Instance {
+	t.Helper()
+	m, err := Decode(code)
+	assert.Nil(t, err)
+	inst, err := Instantiate(m, host, gas)
+	assert.Nil(t, err)
+	return inst
+}
+
+func TestControlFlow(t *testing.T) {
+	i32, i64 := byte(I32), byte(I64)
+	code := module(
+		section(secType, vec(
+			funcType([]byte{i64}, []byte{i64}),
+			funcType([]byte{i32}, []byte{i32}),
+		)),
+		section(secFunction, vec([]byte{0}, []byte{1}, []byte{1})),
+		section(secExport, vec(export("factorial", 0), export("sum", 1), export("switch", 2))),
+		section(secCode, vec(
+			// n == 0 ? 1 : n * factorial(n - 1)
+			body(0, 0x20, 0x00, 0x50, 0x04, i64, 0x42, 0x01, 0x05,
+				0x20, 0x00, 0x20, 0x00, 0x42, 0x01, 0x7d, 0x10, 0x00, 0x7e, 0x0b, 0x0b),
+			// for acc := 0; n != 0; n-- { acc += n }
+			body(1, 0x02, 0x40, 0x03, 0x40, 0x20, 0x00, 0x45, 0x0d, 0x01,
+				0x20, 0x01, 0x20, 0x00, 0x6a, 0x21, 0x01,
+				0x20, 0x00, 0x41, 0x01, 0x6b, 0x21, 0x00, 0x0c, 0x00, 0x0b, 0x0b, 0x20, 0x01, 0x0b),
+			// br_table over 0, 1 and everything else
+			body(0, 0x02, 0x40, 0x02, 0x40, 0x02, 0x40, 0x20, 0x00, 0x0e, 0x02, 0x00, 0x01, 0x02, 0x0b,
+				0x41, 0x0a, 0x0f, 0x0b, 0x41, 0x14, 0x0f, 0x0b, 0x41, 0x1e, 0x0b),
+		)),
+	)
+	inst := instantiate(t, code, nil, 1_000_000)
+
+	results, err := inst.Call("factorial", 20)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{2432902008176640000}, results)
+
+	results, err = inst.Call("sum", 100)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{5050}, results)
+
+	for in, want := range map[uint64]uint64{0: 10, 1: 20, 2: 30, 0xffffffff: 30} {
+		results, err = inst.Call("switch", in)
+		assert.Nil(t, err)
+		assert.Equal(t, []uint64{want}, results)
+	}
+
+	_, err = inst.Call("missing")
+	assert.ErrorIs(t, err, ErrNotExported)
+}
+
+func TestMemory(t *testing.T) {
+	code := module(
+		section(secType, vec(funcType(nil, []byte{byte(I32)}))),
+		section(secFunction, vec([]byte{0}, []byte{0})),
+		section(secMemory, vec([]byte{0x01, 0x01, 0x02})),
+		section(secExport, vec(export("grow", 0), export("copy", 1))),
+		section(secCode, vec(
+			// Grow to the maximum of 2 pages, then past it
+			body(0, 0x41, 0x01, 0x40, 0x00, 0x1a, 0x41, 0x01, 0x40, 0x00, 0x0b),
+			// Copy the data segment to 100 and read it back
+			body(0, 0x41, 0xe4, 0x00, 0x41, 0x00, 0x41, 0x02, 0xfc, 0x0a, 0x00, 0x00,
+				0x41, 0xe4, 0x00, 0x2f, 0x01, 0x00, 0x0b),
+		)),
+		section(secData, vec(append([]byte{0x00, 0x41, 0x00, 0x0b}, name("hi")...))),
+	)
+	inst := instantiate(t, code, nil, 1_000_000)
+
+	data, err := inst.Read(0, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hi"), data)
+
+	results, err := inst.Call("copy")
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{0x6968}, results)
+
+	results, err = inst.Call("grow")
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{0xffffffff}, results)
+	assert.Nil(t, inst.Write(2*PageSize-1, []byte{1}))
+	assert.ErrorIs(t, inst.Write(2*PageSize, []byte{1}), ErrTrap)
+}
+
+func TestGasAndTraps(t *testing.T) {
+	code := module(
+		section(secType, vec(funcType(nil, nil), funcType(nil, []byte{byte(I32)}))),
+		section(secFunction, vec([]byte{0}, []byte{0}, []byte{1}, []byte{1})),
+		section(secMemory, vec([]byte{0x00, 0x01})),
+		section(secExport, vec(export("spin", 0), export("recurse", 1), export("divide", 2), export("load", 3))),
+		section(secCode, vec(
+			body(0, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b),
+			body(0, 0x10, 0x01, 0x0b),
+			body(0, 0x41, 0x01, 0x41, 0x00, 0x6e, 0x0b),
+			body(0, 0x41, 0x7f, 0x28, 0x02, 0x00, 0x0b),
+		)),
+	)
+
+	inst := instantiate(t, code, nil, 5000)
+	_, err := inst.Call("spin")
+	assert.ErrorIs(t, err, ErrOutOfGas)
+	assert.Equal(t, uint64(5000), inst.GasUsed())
+
+	inst = instantiate(t, code, nil, 1_000_000)
+	_, err = inst.Call("recurse")
+	assert.ErrorIs(t, err, ErrTrap)
+	_, err = inst.Call("divide")
+	assert.ErrorIs(t, err, ErrTrap)
+	_, err = inst.Call("load")
+	assert.ErrorIs(t, err, ErrTrap)
+}
+
+func TestHostFunctions(t *testing.T) {
+	i64 := byte(I64)
+	code := module(
+		section(secType, vec(funcType([]byte{i64}, []byte{i64}), funcType(nil, nil))),
+		section(secImport, vec(
+			append(append(name(HostModule), name("double")...), 0x00, 0x00),
+			append(append(name(HostModule), name("abort")...), 0x00, 0x01),
+		)),
+		section(secFunction, vec([]byte{0}, []byte{1})),
+		section(secExport, vec(export("run", 2), export("stop", 3))),
+		section(secCode, vec(
+			body(0, 0x20, 0x00, 0x10, 0x00, 0x0b),
+			body(0, 0x10, 0x01, 0x0b),
+		)),
+	)
+	errAborted := errors.New("aborted")
+	host := map[string]HostFunc{
+		"double": {Type: FuncType{Params: []ValueType{I64}, Results: []ValueType{I64}}, Call: func(inst *Instance, args []uint64) ([]uint64, error) {
+			return []uint64{args[0] * 2}, inst.UseGas(10)
+		}},
+		"abort": {Type: FuncType{}, Call: func(*Instance, []uint64) ([]uint64, error) {
+			return nil, errAborted
+		}},
+	}
+
+	m, err := Decode(code)
+	assert.Nil(t, err)
+	assert.Len(t, m.Imports(), 2)
+	assert.ErrorIs(t, m.CheckImports(map[string]HostFunc{"double": host["double"]}), ErrUnknownImport)
+
+	inst, err := Instantiate(m, host, 1_000_000)
+	assert.Nil(t, err)
+	results, err := inst.Call("run", 21)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{42}, results)
+	assert.Greater(t, inst.GasUsed(), uint64(10))
+
+	_, err = inst.Call("stop")
+	assert.Equal(t, errAborted, err)
+}
+
+func TestDecodeRejects(t *testing.T) {
+	cases := map[string][]byte{
+		"bad magic":      {0x00, 0x61, 0x73, 0x6d, 0x02, 0x00, 0x00, 0x00},
+		"float type":     module(section(secType, vec(funcType([]byte{0x7d}, nil)))),
+		"start function": module(section(secStart, []byte{0x00})),
+		"float opcode": module(
+			section(secType, vec(funcType(nil, nil))),
+			section(secFunction, vec([]byte{0})),
+			section(secCode, vec(body(0, 0x43, 0x00, 0x00, 0x00, 0x00, 0x1a, 0x0b))),
+		),
+		"missing body": module(
+			section(secType, vec(funcType(nil, nil))),
+			section(secFunction, vec([]byte{0})),
+		),
+		"bad branch": module(
+			section(secType, vec(funcType(nil, nil))),
+			section(secFunction, vec([]byte{0})),
+			section(secCode, vec(body(0, 0x0c, 0x01, 0x0b))),
+		),
+		"memory too large": module(section(secMemory, vec([]byte{0x00, 0x20}))),
+		"sections out of order": module(
+			section(secFunction, vec()),
+			section(secType, vec()),
+		),
+	}
+	for name, code := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Decode(code)
+			assert.NotNil(t, err)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"flag"
@@ -1810,16 +1809,6 @@ func serveDashboard(w http.ResponseWriter, r *http.Request) {
                         <label>Expiration (hours):</label>
                         <input type="number" id="otcExpiration" value="24" min="1" max="168">
                     </div>
-                    <div class="form-group">
-                        <label>
-                            <input type="checkbox" id="otcMultiSig"> Multi-Signature Required
-                        </label>
-                    </div>
-                </div>
-
-                <div id="otcMultiSigSection" style="display: none;">
-                    <label>Required Signers (comma-separated addresses):</label>
-                    <textarea id="otcRequiredSigs" placeholder="addr1,addr2,addr3"></textarea>
                 </div>
 
                 <button type="button" class="btn btn-primary" onclick="createOTCOrder()">Create OTC Order</button>
@@ -2011,16 +2000,6 @@ func serveDashboard(w http.ResponseWriter, r *http.Request) {
                         <label>Expiration (hours):</label>
                         <input type="number" id="otcExpiration" value="24" min="1" max="168">
                     </div>
-                    <div class="form-group" style="flex: 1;">
-                        <label>
-                            <input type="checkbox" id="otcMultiSig"> Multi-Signature Required
-                        </label>
-                    </div>
-                </div>
-
-                <div id="otcMultiSigSection" style="display: none;">
-                    <label>Required Signers (comma-separated addresses):</label>
-                    <textarea id="otcRequiredSigs" placeholder="addr1,addr2,addr3" style="width: 100%; height: 60px;"></textarea>
                 </div>
 
                 <button type="button" class="btn btn-primary" onclick="createOTCOrder()">Create OTC Order</button>
@@ -2509,13 +2488,6 @@ func serveDashboard(w http.ResponseWriter, r *http.Request) {
             const tokenRequested = document.getElementById('otcTokenRequested').value;
             const amountRequested = parseInt(document.getElementById('otcAmountRequested').value);
             const expiration = parseInt(document.getElementById('otcExpiration').value);
-            const isMultiSig = document.getElementById('otcMultiSig').checked;
-
-            let requiredSigs = [];
-            if (isMultiSig) {
-                const sigsText = document.getElementById('otcRequiredSigs').value;
-                requiredSigs = sigsText.split(',').map(s => s.trim()).filter(s => s);
-            }
 
             try {
                 const response = await fetch('/api/otc/create', {
@@ -2528,9 +2500,7 @@ func serveDashboard(w http.ResponseWriter, r *http.Request) {
                         amount_offered: amountOffered,
                         token_requested: tokenRequested,
                         amount_requested: amountRequested,
-                        expiration_hours: expiration,
-                        is_multi_sig: isMultiSig,
-                        required_sigs: requiredSigs
+                        expiration_hours: expiration
                     })
                 });
 
@@ -2628,16 +2598,7 @@ func serveDashboard(w http.ResponseWriter, r *http.Request) {
             }
         }
 
-        // Toggle multi-sig section
         document.addEventListener('DOMContentLoaded', function() {
-            const multiSigCheckbox = document.getElementById('otcMultiSig');
-            if (multiSigCheckbox) {
-                multiSigCheckbox.addEventListener('change', function() {
-                    const section = document.getElementById('otcMultiSigSection');
-                    section.style.display = this.checked ? 'block' : 'none';
-                });
-            }
-
             // Auto-refresh OTC orders when the modal is opened
             const advancedModal = document.getElementById('advancedTransactionsModal');
             if (advancedModal) {
@@ -2675,30 +2636,6 @@ func serveDashboard(w http.ResponseWriter, r *http.Request) {
             }
         }
 
-        // Check for OTC events (real-time updates)
-        async function checkOTCEvents() {
-            try {
-                const response = await fetch('http://localhost:8080/api/otc/events');
-                const result = await response.json();
-
-                if (result.success && result.data && result.data.length > 0) {
-                    // Process recent events
-                    const recentEvents = result.data.slice(-5); // Last 5 events
-                    recentEvents.forEach(event => {
-                        if (event.type === 'order_created' || event.type === 'order_updated') {
-                            // Refresh orders if there are relevant events
-                            refreshOTCOrders();
-                        }
-                    });
-                }
-            } catch (error) {
-                console.log('Failed to check OTC events:', error);
-            }
-        }
-
-        // Start event checking when page loads
-        setInterval(checkOTCEvents, 3000); // Check every 3 seconds
-
         // Add global keyboard support for modals
         document.addEventListener('keydown', function(event) {
             if (event.key === 'Escape') {
@@ -3956,15 +3893,13 @@ func handleCreateOTCOrder(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		WalletName      string   `json:"wallet_name"`
-		Password        string   `json:"password"`
-		TokenOffered    string   `json:"token_offered"`
-		AmountOffered   uint64   `json:"amount_offered"`
-		TokenRequested  string   `json:"token_requested"`
-		AmountRequested uint64   `json:"amount_requested"`
-		ExpirationHours int      `json:"expiration_hours"`
-		IsMultiSig      bool     `json:"is_multi_sig"`
-		RequiredSigs    []string `json:"required_sigs"`
+		WalletName      string `json:"wallet_name"`
+		Password        string `json:"password"`
+		TokenOffered    string `json:"token_offered"`
+		AmountOffered   uint64 `json:"amount_offered"`
+		TokenRequested  string `json:"token_requested"`
+		AmountRequested uint64 `json:"amount_requested"`
+		ExpirationHours int    `json:"expiration_hours"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -3979,28 +3914,21 @@ func handleCreateOTCOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get wallet details to get the address
+	// Sign and send the OTCCreate transaction that locks the offer
 	ctx := context.Background()
-	walletDoc, _, _, err := wallet.GetWalletDetails(ctx, user, req.WalletName, req.Password)
-	if err != nil {
-		sendJSONResponse(w, APIResponse{Success: false, Message: "Failed to access wallet: " + err.Error()}, http.StatusInternalServerError)
-		return
-	}
-
-	// Connect to blockchain and create OTC order
-	orderData, err := createOTCOrderOnBlockchain(walletDoc.Address, req.TokenOffered, req.TokenRequested,
-		req.AmountOffered, req.AmountRequested, req.ExpirationHours, req.IsMultiSig, req.RequiredSigs)
+	order, err := wallet.CreateOTCOrder(ctx, user, req.WalletName, req.Password, req.TokenOffered, req.AmountOffered,
+		req.TokenRequested, req.AmountRequested, req.ExpirationHours)
 	if err != nil {
 		sendJSONResponse(w, APIResponse{Success: false, Message: "Failed to create OTC order: " + err.Error()}, http.StatusInternalServerError)
 		return
 	}
 
-	logSuccess("OTC_ORDER_CREATE", fmt.Sprintf("Order %s created by %s", orderData["order_id"], user.Username))
+	logSuccess("OTC_ORDER_CREATE", fmt.Sprintf("Order %s created by %s", order.ID, user.Username))
 
 	sendJSONResponse(w, APIResponse{
 		Success: true,
-		Message: "OTC order created successfully",
-		Data:    orderData,
+		Message: "OTC order submitted",
+		Data:    order,
 	}, http.StatusOK)
 }
 
@@ -4010,18 +3938,8 @@ func handleGetOTCOrders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get user from session to filter orders
-	user, err := getUserFromSession(r)
-	if err != nil {
-		sendJSONResponse(w, APIResponse{Success: false, Message: "Authentication required"}, http.StatusUnauthorized)
-		return
-	}
-
-	// Get user's wallet address (use first wallet for now)
-	userAddress := fmt.Sprintf("user_%s", user.Username) // Use username as placeholder address
-
-	// Get orders from blockchain
-	orders, err := getOTCOrdersFromBlockchain(userAddress)
+	// Orders of one address, or every open order when none is given
+	orders, err := wallet.GetOTCOrders(r.URL.Query().Get("address"))
 	if err != nil {
 		sendJSONResponse(w, APIResponse{Success: false, Message: "Failed to get OTC orders: " + err.Error()}, http.StatusInternalServerError)
 		return
@@ -4054,35 +3972,22 @@ func handleCancelOTCOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get wallet details to get the address
+	// Sign and send the OTCAction transaction that refunds the offer
 	ctx := context.Background()
-	walletDoc, _, _, err := wallet.GetWalletDetails(ctx, user, req.WalletName, req.Password)
-	if err != nil {
-		sendJSONResponse(w, APIResponse{Success: false, Message: "Failed to access wallet: " + err.Error()}, http.StatusInternalServerError)
-		return
-	}
-
-	// Connect to blockchain and cancel OTC order
-	success, err := cancelOTCOrderOnBlockchain(req.OrderID, walletDoc.Address)
+	address, err := wallet.CancelOTCOrder(ctx, user, req.WalletName, req.Password, req.OrderID)
 	if err != nil {
 		sendJSONResponse(w, APIResponse{Success: false, Message: "Failed to cancel OTC order: " + err.Error()}, http.StatusInternalServerError)
 		return
 	}
 
-	if !success {
-		sendJSONResponse(w, APIResponse{Success: false, Message: "Order cancellation failed"}, http.StatusInternalServerError)
-		return
-	}
-
 	logSuccess("OTC_ORDER_CANCEL", fmt.Sprintf("Order %s cancelled by %s", req.OrderID, user.Username))
 
 	sendJSONResponse(w, APIResponse{
 		Success: true,
-		Message: "OTC order cancelled successfully",
+		Message: "OTC order cancellation submitted",
 		Data: map[string]interface{}{
 			"order_id":     req.OrderID,
-			"cancelled_by": walletDoc.Address,
-			"cancelled_at": time.Now().Unix(),
+			"cancelled_by": address,
 		},
 	}, http.StatusOK)
 }
@@ -4111,258 +4016,26 @@ func handleMatchOTCOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get wallet details to get the address
+	// Sign and send the OTCAction transaction that fills the order
 	ctx := context.Background()
-	walletDoc, _, _, err := wallet.GetWalletDetails(ctx, user, req.WalletName, req.Password)
-	if err != nil {
-		sendJSONResponse(w, APIResponse{Success: false, Message: "Failed to access wallet: " + err.Error()}, http.StatusInternalServerError)
-		return
-	}
-
-	// Connect to blockchain and match OTC order
-	success, err := matchOTCOrderOnBlockchain(req.OrderID, walletDoc.Address)
+	address, err := wallet.MatchOTCOrder(ctx, user, req.WalletName, req.Password, req.OrderID)
 	if err != nil {
 		sendJSONResponse(w, APIResponse{Success: false, Message: "Failed to match OTC order: " + err.Error()}, http.StatusInternalServerError)
 		return
 	}
 
-	if !success {
-		sendJSONResponse(w, APIResponse{Success: false, Message: "Order matching failed"}, http.StatusInternalServerError)
-		return
-	}
-
 	logSuccess("OTC_ORDER_MATCH", fmt.Sprintf("Order %s matched by %s", req.OrderID, user.Username))
 
 	sendJSONResponse(w, APIResponse{
 		Success: true,
-		Message: "OTC order matched successfully",
+		Message: "OTC order match submitted",
 		Data: map[string]interface{}{
 			"order_id":   req.OrderID,
-			"matched_by": walletDoc.Address,
-			"matched_at": time.Now().Unix(),
+			"matched_by": address,
 		},
 	}, http.StatusOK)
 }
 
-// Blockchain OTC Integration Functions
-func createOTCOrderOnBlockchain(creator, tokenOffered, tokenRequested string, amountOffered, amountRequested uint64, expirationHours int, isMultiSig bool, requiredSigs []string) (map[string]interface{}, error) {
-	// Try to connect to blockchain API with retry logic
-	blockchainURL := "http://localhost:8080/api/otc/create"
-
-	// Test blockchain connectivity first
-	if !testBlockchainConnection() {
-		fmt.Printf("⚠️ Blockchain API not available, creating simulated OTC order\n")
-		return createSimulatedOTCOrder(creator, tokenOffered, tokenRequested, amountOffered, amountRequested, expirationHours, isMultiSig, requiredSigs), nil
-	}
-
-	requestData := map[string]interface{}{
-		"creator":          creator,
-		"token_offered":    tokenOffered,
-		"amount_offered":   amountOffered,
-		"token_requested":  tokenRequested,
-		"amount_requested": amountRequested,
-		"expiration_hours": expirationHours,
-		"is_multisig":      isMultiSig,
-		"required_sigs":    requiredSigs,
-	}
-
-	jsonData, err := json.Marshal(requestData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %v", err)
-	}
-
-	resp, err := http.Post(blockchainURL, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		// If blockchain API is not available, create a simulated order
-		fmt.Printf("⚠️ Blockchain API not available, creating simulated OTC order\n")
-		return createSimulatedOTCOrder(creator, tokenOffered, tokenRequested, amountOffered, amountRequested, expirationHours, isMultiSig, requiredSigs), nil
-	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-
-	if success, ok := result["success"].(bool); !ok || !success {
-		message := "Unknown error"
-		if msg, ok := result["message"].(string); ok {
-			message = msg
-		}
-		return nil, fmt.Errorf("blockchain error: %s", message)
-	}
-
-	if data, ok := result["data"].(map[string]interface{}); ok {
-		return data, nil
-	}
-
-	return nil, fmt.Errorf("invalid response format")
-}
-
-func createSimulatedOTCOrder(creator, tokenOffered, tokenRequested string, amountOffered, amountRequested uint64, expirationHours int, isMultiSig bool, requiredSigs []string) map[string]interface{} {
-	// Safely handle short creator addresses
-	creatorSuffix := creator
-	if len(creator) > 8 {
-		creatorSuffix = creator[:8]
-	}
-	orderID := fmt.Sprintf("otc_%d_%s", time.Now().UnixNano(), creatorSuffix)
-
-	return map[string]interface{}{
-		"order_id":         orderID,
-		"creator":          creator,
-		"token_offered":    tokenOffered,
-		"amount_offered":   amountOffered,
-		"token_requested":  tokenRequested,
-		"amount_requested": amountRequested,
-		"expiration_hours": expirationHours,
-		"is_multi_sig":     isMultiSig,
-		"required_sigs":    requiredSigs,
-		"status":           "open",
-		"created_at":       time.Now().Unix(),
-		"expires_at":       time.Now().Add(time.Duration(expirationHours) * time.Hour).Unix(),
-		"note":             "Simulated order - blockchain API not available",
-	}
-}
-
-func getOTCOrdersFromBlockchain(userAddress string) ([]map[string]interface{}, error) {
-	// Try to connect to blockchain API
-	blockchainURL := fmt.Sprintf("http://localhost:8080/api/otc/orders?user=%s", userAddress)
-
-	resp, err := http.Get(blockchainURL)
-	if err != nil {
-		// If blockchain API is not available, return simulated orders
-		fmt.Printf("⚠️ Blockchain API not available, returning simulated OTC orders\n")
-		return getSimulatedOTCOrders(userAddress), nil
-	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-
-	if success, ok := result["success"].(bool); !ok || !success {
-		return getSimulatedOTCOrders(userAddress), nil
-	}
-
-	if data, ok := result["data"].([]interface{}); ok {
-		orders := make([]map[string]interface{}, len(data))
-		for i, order := range data {
-			if orderMap, ok := order.(map[string]interface{}); ok {
-				orders[i] = orderMap
-			}
-		}
-		return orders, nil
-	}
-
-	return getSimulatedOTCOrders(userAddress), nil
-}
-
-func getSimulatedOTCOrders(userAddress string) []map[string]interface{} {
-	return []map[string]interface{}{
-		{
-			"order_id":         "otc_example_1",
-			"creator":          userAddress,
-			"token_offered":    "BHX",
-			"amount_offered":   1000,
-			"token_requested":  "USDT",
-			"amount_requested": 5000,
-			"status":           "open",
-			"created_at":       time.Now().Unix() - 3600,
-			"expires_at":       time.Now().Unix() + 82800, // 23 hours from now
-			"note":             "Simulated order",
-		},
-		{
-			"order_id":         "otc_example_2",
-			"creator":          "0x1234...5678",
-			"token_offered":    "USDT",
-			"amount_offered":   2000,
-			"token_requested":  "BHX",
-			"amount_requested": 400,
-			"status":           "open",
-			"created_at":       time.Now().Unix() - 1800,
-			"expires_at":       time.Now().Unix() + 84600, // 23.5 hours from now
-			"note":             "Simulated order from another user",
-		},
-	}
-}
-
-func cancelOTCOrderOnBlockchain(orderID, canceller string) (bool, error) {
-	// Try to connect to blockchain API
-	blockchainURL := "http://localhost:8080/api/otc/cancel"
-
-	requestData := map[string]interface{}{
-		"order_id":  orderID,
-		"canceller": canceller,
-	}
-
-	jsonData, err := json.Marshal(requestData)
-	if err != nil {
-		return false, fmt.Errorf("failed to marshal request: %v", err)
-	}
-
-	resp, err := http.Post(blockchainURL, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		// If blockchain API is not available, simulate cancellation
-		fmt.Printf("⚠️ Blockchain API not available, simulating OTC order cancellation\n")
-		return true, nil
-	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return false, fmt.Errorf("failed to decode response: %v", err)
-	}
-
-	if success, ok := result["success"].(bool); !ok || !success {
-		message := "Unknown error"
-		if msg, ok := result["message"].(string); ok {
-			message = msg
-		}
-		return false, fmt.Errorf("blockchain error: %s", message)
-	}
-
-	return true, nil
-}
-
-func matchOTCOrderOnBlockchain(orderID, counterparty string) (bool, error) {
-	// Try to connect to blockchain API
-	blockchainURL := "http://localhost:8080/api/otc/match"
-
-	requestData := map[string]interface{}{
-		"order_id":     orderID,
-		"counterparty": counterparty,
-	}
-
-	jsonData, err := json.Marshal(requestData)
-	if err != nil {
-		return false, fmt.Errorf("failed to marshal request: %v", err)
-	}
-
-	resp, err := http.Post(blockchainURL, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		// If blockchain API is not available, simulate matching
-		fmt.Printf("⚠️ Blockchain API not available, simulating OTC order matching\n")
-		return true, nil
-	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return false, fmt.Errorf("failed to decode response: %v", err)
-	}
-
-	if success, ok := result["success"].(bool); !ok || !success {
-		message := "Unknown error"
-		if msg, ok := result["message"].(string); ok {
-			message = msg
-		}
-		return false, fmt.Errorf("blockchain error: %s", message)
-	}
-
-	return true, nil
-}
-
 // Blockchain connection testing functions
 func testBlockchainConnection() bool {
 	// Test basic connectivity to blockchain API
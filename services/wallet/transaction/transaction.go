@@ -11,12 +11,12 @@ import (
 	"time"
 
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/chain"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 	wallet_core "github.com/Shivam-Patel-G/blackhole-blockchain/services/wallet/wallet"
 
 	"golang.org/x/crypto/blake2b"
 
 	"github.com/btcsuite/btcd/btcec/v2"
-	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -27,17 +27,9 @@ import (
 
 // SignTransaction signs a transaction using the sender's private key
 func SignTransaction(tx *chain.Transaction, privKey *btcec.PrivateKey) ([]byte, error) {
-	txCopy := *tx
-	txCopy.Signature = nil
-
-	txBytes, err := json.Marshal(txCopy)
-	if err != nil {
+	if err := tx.Sign(privKey); err != nil {
 		return nil, err
 	}
-
-	hash := blake2b.Sum256(txBytes)
-	signature := ecdsa.Sign(privKey, hash[:])
-	tx.Signature = signature.Serialize()
 	return tx.Signature, nil
 }
 
@@ -46,20 +38,10 @@ func VerifyTransactionSignature(tx *chain.Transaction, pubKey *btcec.PublicKey)
 	if tx.Signature == nil {
 		return false, fmt.Errorf("no signature found")
 	}
-	txCopy := *tx
-	txCopy.Signature = nil
-
-	txBytes, err := json.Marshal(txCopy)
-	if err != nil {
+	if err := tx.VerifySignature(); err != nil {
 		return false, err
 	}
-	hash := blake2b.Sum256(txBytes)
-	sig, err := ecdsa.ParseDERSignature(tx.Signature)
-	if err != nil {
-		return false, err
-	}
-
-	return sig.Verify(hash[:], pubKey), nil
+	return tx.From == chain.PublicKeyToAddress(pubKey), nil
 }
 
 // UseWalletAndSignTx loads a wallet, decrypts the key, signs a transaction
@@ -85,7 +67,7 @@ func UseWalletAndSignTx(ctx context.Context, wallet *wallet_core.Wallet, passwor
 	tx := &chain.Transaction{
 		From:      wallet.PublicKey,
 		To:        toAddress,
-		Amount:    uint64(amount),
+		Amount:    uint256.NewInt(uint64(amount)),
 		Timestamp: time.Now().Unix(),
 		Nonce:     uint64(time.Now().UnixNano()),
 	}
@@ -24,6 +24,7 @@ import (
 	wallet_core "github.com/Shivam-Patel-G/blackhole-blockchain/services/wallet/wallet"
 
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/chain"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 	"github.com/btcsuite/btcd/btcec/v2"
 
 	"github.com/libp2p/go-libp2p"
@@ -122,7 +123,7 @@ func main() {
 	tx := &chain.Transaction{
 		From:      selectedWallet.Address,
 		To:        toAddr,
-		Amount:    uint64(amount),
+		Amount:    uint256.NewInt(uint64(amount)),
 		Nonce:     uint64(nonce),
 		Timestamp: time.Now().Unix(), // Set timestamp explicitly
 	}
@@ -132,8 +133,6 @@ func main() {
 		log.Fatalf("Failed to sign transaction: %v", err)
 	}
 	tx.Signature = sig
-	pubKeyBytes := privKey.PubKey().SerializeCompressed()
-	tx.PublicKey = pubKeyBytes
 
 	// Step 6: Verify the transaction signature
 
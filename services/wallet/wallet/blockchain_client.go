@@ -3,23 +3,32 @@ package wallet
 import (
 	"bytes"
 	"context"
-	"encoding/gob"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/chain"
-	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/escrow"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
+	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/multiformats/go-multiaddr"
 )
 
+// Gas the node charges for the transactions the wallet sends; fees are paid in
+// BHX base units at the node's default minimum price of 1 gwei
+const (
+	defaultGasPrice  = 1000000000
+	tokenTransferGas = 15
+	stakeGas         = 25
+)
+
 // BridgeEvent represents a bridge event notification
 type BridgeEvent struct {
 	ID          string `json:"id"`
@@ -215,25 +224,32 @@ func (client *BlockchainClient) TransferTokens(from, to, tokenSymbol string, amo
 	// ===== CREATE AND SEND TRANSACTION =====
 	fmt.Printf("🚀 Creating transaction...\n")
 
+	nonce, err := client.GetNextNonce(from)
+	if err != nil {
+		return fmt.Errorf("failed to get account nonce: %v", err)
+	}
+
 	// Create and sign transaction
 	tx := &chain.Transaction{
+		ChainID:   chain.DefaultChainID,
 		Type:      chain.TokenTransfer,
 		From:      from,
 		To:        to,
-		Amount:    amount,
+		Amount:    uint256.NewInt(amount),
 		TokenID:   tokenSymbol,
 		Fee:       0,
-		Nonce:     uint64(time.Now().UnixNano()), // Use timestamp as nonce for now
+		GasLimit:  tokenTransferGas,
+		GasPrice:  defaultGasPrice,
+		Nonce:     nonce,
 		Timestamp: time.Now().Unix(),
 	}
 
-	// Calculate transaction ID
-	tx.ID = tx.CalculateHash()
+	// Sign transaction, which also sets its ID
+	if err := signTransaction(tx, privateKey); err != nil {
+		return err
+	}
 	fmt.Printf("   📝 Transaction ID: %s\n", tx.ID)
 
-	// Sign transaction (simplified - would need actual signing logic)
-	// tx.Sign(privateKey)
-
 	// Send transaction to connected blockchain nodes via P2P
 	fmt.Printf("   📡 Sending transaction to blockchain network...\n")
 	err = client.sendTransactionToNetwork(tx)
@@ -246,7 +262,7 @@ func (client *BlockchainClient) TransferTokens(from, to, tokenSymbol string, amo
 }
 
 // TransferTokensWithEscrow transfers tokens using escrow for added security
-func (client *BlockchainClient) TransferTokensWithEscrow(from, to, arbitrator, tokenSymbol string, amount uint64, expirationHours int, description string, privateKey []byte) (*escrow.EscrowContract, error) {
+func (client *BlockchainClient) TransferTokensWithEscrow(from, to, arbitrator, tokenSymbol string, amount uint64, expirationHours int, description string, privateKey []byte) (*chain.Escrow, error) {
 	fmt.Printf("🔒 Starting escrow transfer...\n")
 
 	// Use the same pre-transfer validation as regular transfers
@@ -286,8 +302,8 @@ func (client *BlockchainClient) TransferTokensWithEscrow(from, to, arbitrator, t
 	fmt.Printf("      - ID: %s\n", contract.ID)
 	fmt.Printf("      - From: %s\n", contract.Sender)
 	fmt.Printf("      - To: %s\n", contract.Receiver)
-	fmt.Printf("      - Amount: %d %s\n", contract.Amount, contract.TokenSymbol)
-	fmt.Printf("      - Status: %s\n", contract.Status.String())
+	fmt.Printf("      - Amount: %s %s\n", contract.Amount, contract.TokenID)
+	fmt.Printf("      - Status: %s\n", contract.Status)
 	fmt.Printf("      - Expires: %s\n", time.Unix(contract.ExpiresAt, 0).Format("2006-01-02 15:04:05"))
 
 	return contract, nil
@@ -299,41 +315,54 @@ func (client *BlockchainClient) StakeTokens(address, tokenSymbol string, amount
 		return fmt.Errorf("not connected to any blockchain nodes")
 	}
 
+	nonce, err := client.GetNextNonce(address)
+	if err != nil {
+		return fmt.Errorf("failed to get account nonce: %v", err)
+	}
+
 	// Create staking transaction
 	tx := &chain.Transaction{
+		ChainID:   chain.DefaultChainID,
 		Type:      chain.StakeDeposit,
 		From:      address,
 		To:        "staking_contract",
-		Amount:    amount,
+		Amount:    uint256.NewInt(amount),
 		TokenID:   tokenSymbol,
 		Fee:       0,
-		Nonce:     uint64(time.Now().UnixNano()), // Use timestamp as nonce for now
+		GasLimit:  stakeGas,
+		GasPrice:  defaultGasPrice,
+		Nonce:     nonce,
 		Timestamp: time.Now().Unix(),
 	}
 
-	// Calculate transaction ID
-	tx.ID = tx.CalculateHash()
-
-	// Sign transaction (simplified - would need actual signing logic)
-	// tx.Sign(privateKey)
+	// Sign transaction, which also sets its ID
+	if err := signTransaction(tx, privateKey); err != nil {
+		return err
+	}
 
 	// Send transaction to connected blockchain nodes via P2P
 	return client.sendTransactionToNetwork(tx)
 }
 
-// sendTransactionToNetwork sends a transaction to all connected blockchain nodes
-func (client *BlockchainClient) sendTransactionToNetwork(tx *chain.Transaction) error {
-	// Encode the transaction to bytes
-	var txBuf bytes.Buffer
-	txEncoder := gob.NewEncoder(&txBuf)
-	if err := txEncoder.Encode(tx); err != nil {
-		return fmt.Errorf("failed to encode transaction: %v", err)
+// signTransaction signs tx with privateKey, which must belong to the sender
+func signTransaction(tx *chain.Transaction, privateKey []byte) error {
+	key, _ := btcec.PrivKeyFromBytes(privateKey)
+	from := tx.From
+	if err := tx.Sign(key); err != nil {
+		return fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	if tx.From != from {
+		return fmt.Errorf("private key does not belong to sender %s", from)
 	}
+	return nil
+}
 
-	// Wrap it in a Message
+// sendTransactionToNetwork sends a transaction to all connected blockchain nodes
+func (client *BlockchainClient) sendTransactionToNetwork(tx *chain.Transaction) error {
+	// Wrap the canonical transaction encoding in a Message
 	msg := &chain.Message{
 		Type:    chain.MessageTypeTx,
-		Data:    txBuf.Bytes(),
+		Data:    tx.Encode(),
 		Version: chain.ProtocolVersion,
 	}
 
@@ -592,14 +621,105 @@ func (client *BlockchainClient) queryBalanceViaHTTP(address, tokenSymbol string)
 
 	fmt.Printf("   📍 Addresses with %s balances: %v\n", tokenSymbol, getMapKeys(tokenData))
 
-	balance, ok := tokenData[address].(float64)
+	raw, ok := tokenData[address]
 	if !ok {
 		fmt.Printf("   ℹ️ Address %s not found in %s balances, returning 0\n", address, tokenSymbol)
 		return 0, nil // Address not found, balance is 0
 	}
+	balance, err := balanceFromJSON(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid balance for %s: %v", address, err)
+	}
+
+	fmt.Printf("   ✅ Found balance: %d\n", balance)
+	return balance, nil
+}
 
-	fmt.Printf("   ✅ Found balance: %f\n", balance)
-	return uint64(balance), nil
+// GetNextNonce asks the node for the nonce the address's next transaction must use
+func (client *BlockchainClient) GetNextNonce(address string) (uint64, error) {
+	var lastErr error
+	for _, endpoint := range client.apiEndpoints() {
+		nonce, err := client.queryNextNonce(endpoint, address)
+		if err == nil {
+			fmt.Printf("   🔢 Next nonce for %s: %d\n", address, nonce)
+			return nonce, nil
+		}
+		lastErr = err
+	}
+	return 0, lastErr
+}
+
+// apiEndpoints returns the HTTP APIs of the configured and connected nodes,
+// falling back to a local node
+func (client *BlockchainClient) apiEndpoints() []string {
+	endpoints := make([]string, 0)
+	if client.APIEndpoint != "" {
+		endpoints = append(endpoints, client.APIEndpoint)
+	}
+	for _, peerAddr := range client.ConnectedPeers {
+		if apiPort := client.extractAPIPortFromPeer(peerAddr); apiPort != "" {
+			endpoints = append(endpoints, "http://localhost:"+apiPort)
+		}
+	}
+	return append(endpoints, "http://localhost:8080")
+}
+
+// queryNextNonce queries the nonce endpoint of one node
+func (client *BlockchainClient) queryNextNonce(endpoint, address string) (uint64, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/api/account/nonce?address=%s", endpoint, address))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query nonce endpoint: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+		Data    struct {
+			NextNonce uint64 `json:"next_nonce"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return 0, fmt.Errorf("failed to parse response: %v", err)
+	}
+	if !response.Success {
+		return 0, fmt.Errorf("nonce query failed: %s", response.Error)
+	}
+	return response.Data.NextNonce, nil
+}
+
+// parseAmount reads an amount from a generically decoded response. Nodes
+// send amounts as decimal strings; older nodes sent plain JSON numbers.
+func parseAmount(v interface{}) (uint256.Int, error) {
+	switch v := v.(type) {
+	case string:
+		return uint256.FromDecimal(v)
+	case float64:
+		if v < 0 || v != float64(uint64(v)) {
+			return uint256.Int{}, fmt.Errorf("%v is not a whole amount", v)
+		}
+		return uint256.NewInt(uint64(v)), nil
+	}
+	return uint256.Int{}, fmt.Errorf("unexpected amount type %T", v)
+}
+
+// amountFromJSON is parseAmount for fields that fall back to zero
+func amountFromJSON(v interface{}) uint256.Int {
+	amount, _ := parseAmount(v)
+	return amount
+}
+
+// balanceFromJSON parses a balance into the uint64 the wallet works in
+func balanceFromJSON(v interface{}) (uint64, error) {
+	amount, err := parseAmount(v)
+	if err != nil {
+		return 0, err
+	}
+	balance, ok := amount.Uint64()
+	if !ok {
+		return 0, fmt.Errorf("balance %s exceeds the wallet's 64-bit range", amount)
+	}
+	return balance, nil
 }
 
 // getMapKeys returns the keys of a map[string]interface{} for debugging
@@ -664,13 +784,13 @@ func (client *BlockchainClient) queryBalanceViaDedicatedEndpoint(address, tokenS
 		return 0, fmt.Errorf("invalid response format: missing data")
 	}
 
-	balance, ok := data["balance"].(float64)
-	if !ok {
-		return 0, fmt.Errorf("invalid response format: missing balance")
+	balance, err := balanceFromJSON(data["balance"])
+	if err != nil {
+		return 0, fmt.Errorf("invalid response format: balance: %v", err)
 	}
 
-	fmt.Printf("   ✅ Dedicated endpoint returned balance: %f\n", balance)
-	return uint64(balance), nil
+	fmt.Printf("   ✅ Dedicated endpoint returned balance: %d\n", balance)
+	return balance, nil
 }
 
 // extractAPIPortFromPeer extracts the API port from a peer address
@@ -728,20 +848,26 @@ func (client *BlockchainClient) queryBalanceViaHTTPPort(address, tokenSymbol, po
 
 	fmt.Printf("   📍 Addresses with %s balances: %v\n", tokenSymbol, getMapKeys(tokenData))
 
-	balance, ok := tokenData[address].(float64)
+	raw, ok := tokenData[address]
 	if !ok {
 		fmt.Printf("   ℹ️ Address %s not found in %s balances, returning 0\n", address, tokenSymbol)
 		return 0, nil // Address not found, balance is 0
 	}
+	balance, err := balanceFromJSON(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid balance for %s: %v", address, err)
+	}
 
-	fmt.Printf("   ✅ Found balance: %f\n", balance)
-	return uint64(balance), nil
+	fmt.Printf("   ✅ Found balance: %d\n", balance)
+	return balance, nil
 }
 
 // ===== ESCROW OPERATIONS =====
 
-// CreateEscrow creates a new escrow contract via blockchain
-func (client *BlockchainClient) CreateEscrow(sender, receiver, arbitrator, tokenSymbol string, amount uint64, expirationHours int, description string, privateKey []byte) (*escrow.EscrowContract, error) {
+// CreateEscrow locks amount of tokenSymbol for receiver with an EscrowCreate
+// transaction. The returned escrow's ID is the transaction ID; its times are
+// estimates until the transaction is in a block.
+func (client *BlockchainClient) CreateEscrow(sender, receiver, arbitrator, tokenSymbol string, amount uint64, expirationHours int, description string, privateKey []byte) (*chain.Escrow, error) {
 	if len(client.ConnectedPeers) == 0 {
 		return nil, fmt.Errorf("not connected to any blockchain nodes")
 	}
@@ -761,288 +887,231 @@ func (client *BlockchainClient) CreateEscrow(sender, receiver, arbitrator, token
 		return nil, fmt.Errorf("receiver address cannot be empty")
 	}
 
-	// Create escrow creation message
-	escrowData := map[string]interface{}{
-		"action":           "create_escrow",
-		"sender":           sender,
-		"receiver":         receiver,
-		"arbitrator":       arbitrator,
-		"token_symbol":     tokenSymbol,
-		"amount":           amount,
-		"expiration_hours": expirationHours,
-		"description":      description,
+	params := chain.EscrowCreateParams{
+		Arbitrator:  arbitrator,
+		ExpiresIn:   int64(expirationHours) * 60 * 60,
+		Description: description,
 	}
-
-	// Send escrow creation request to blockchain
-	response, err := client.sendEscrowRequest(escrowData)
+	tx, err := chain.NewEscrowCreateTransaction(sender, receiver, tokenSymbol, uint256.NewInt(amount), params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create escrow: %v", err)
 	}
-
-	// Parse response to get escrow contract
-	escrowID, ok := response["escrow_id"].(string)
-	if !ok {
-		return nil, fmt.Errorf("invalid response: missing escrow_id")
-	}
-
-	// Create and return escrow contract object
-	contract := &escrow.EscrowContract{
-		ID:           escrowID,
-		Sender:       sender,
-		Receiver:     receiver,
-		Arbitrator:   arbitrator,
-		TokenSymbol:  tokenSymbol,
-		Amount:       amount,
-		Status:       escrow.EscrowPending,
-		Description:  description,
-		CreatedAt:    time.Now().Unix(),
-		ExpiresAt:    time.Now().Add(time.Duration(expirationHours) * time.Hour).Unix(),
-		Signatures:   make(map[string]bool),
-		RequiredSigs: 2,
-		Conditions:   make(map[string]interface{}),
+	if err := client.signAndSend(tx, privateKey); err != nil {
+		return nil, fmt.Errorf("failed to create escrow: %v", err)
 	}
 
-	if arbitrator != "" {
-		contract.RequiredSigs = 2 // Any 2 of 3
+	now := time.Now().Unix()
+	contract := &chain.Escrow{
+		ID:          tx.ID,
+		Sender:      sender,
+		Receiver:    receiver,
+		Arbitrator:  arbitrator,
+		TokenID:     tokenSymbol,
+		Amount:      tx.Amount,
+		Status:      chain.EscrowPending,
+		Description: description,
+		CreatedAt:   now,
+		ExpiresAt:   now + params.ExpiresIn,
 	}
 
-	fmt.Printf("✅ Escrow created successfully: %s\n", escrowID)
+	fmt.Printf("✅ Escrow submitted: %s\n", contract.ID)
 	return contract, nil
 }
 
-// ConfirmEscrow confirms an escrow contract
+// ConfirmEscrow confirms an escrow with an EscrowAction transaction
 func (client *BlockchainClient) ConfirmEscrow(escrowID, confirmer string, privateKey []byte) error {
-	if len(client.ConnectedPeers) == 0 {
-		return fmt.Errorf("not connected to any blockchain nodes")
-	}
-
-	// Create escrow confirm message
-	escrowData := map[string]interface{}{
-		"action":    "confirm_escrow",
-		"escrow_id": escrowID,
-		"confirmer": confirmer,
-	}
-
-	// Send escrow confirm request to blockchain
-	_, err := client.sendEscrowRequest(escrowData)
-	if err != nil {
+	if err := client.sendEscrowAction(escrowID, confirmer, chain.EscrowConfirm, privateKey); err != nil {
 		return fmt.Errorf("failed to confirm escrow: %v", err)
 	}
 
-	fmt.Printf("✅ Escrow %s confirmed successfully\n", escrowID)
+	fmt.Printf("✅ Escrow %s confirmation submitted\n", escrowID)
 	return nil
 }
 
-// ReleaseEscrow releases funds from an escrow to the receiver
+// ReleaseEscrow releases funds from an escrow to the receiver with an EscrowAction transaction
 func (client *BlockchainClient) ReleaseEscrow(escrowID, releaser string, privateKey []byte) error {
-	if len(client.ConnectedPeers) == 0 {
-		return fmt.Errorf("not connected to any blockchain nodes")
-	}
-
-	// Create escrow release message
-	escrowData := map[string]interface{}{
-		"action":    "release_escrow",
-		"escrow_id": escrowID,
-		"releaser":  releaser,
-	}
-
-	// Send escrow release request to blockchain
-	_, err := client.sendEscrowRequest(escrowData)
-	if err != nil {
+	if err := client.sendEscrowAction(escrowID, releaser, chain.EscrowRelease, privateKey); err != nil {
 		return fmt.Errorf("failed to release escrow: %v", err)
 	}
 
-	fmt.Printf("✅ Escrow %s released successfully\n", escrowID)
+	fmt.Printf("✅ Escrow %s release submitted\n", escrowID)
 	return nil
 }
 
-// CancelEscrow cancels an escrow and returns funds to sender
+// CancelEscrow returns an escrow's funds to the sender with an EscrowAction transaction
 func (client *BlockchainClient) CancelEscrow(escrowID, canceller string, privateKey []byte) error {
-	if len(client.ConnectedPeers) == 0 {
-		return fmt.Errorf("not connected to any blockchain nodes")
-	}
-
-	// Create escrow cancel message
-	escrowData := map[string]interface{}{
-		"action":    "cancel_escrow",
-		"escrow_id": escrowID,
-		"canceller": canceller,
-	}
-
-	// Send escrow cancel request to blockchain
-	_, err := client.sendEscrowRequest(escrowData)
-	if err != nil {
+	if err := client.sendEscrowAction(escrowID, canceller, chain.EscrowCancel, privateKey); err != nil {
 		return fmt.Errorf("failed to cancel escrow: %v", err)
 	}
 
-	fmt.Printf("✅ Escrow %s cancelled successfully\n", escrowID)
+	fmt.Printf("✅ Escrow %s cancellation submitted\n", escrowID)
 	return nil
 }
 
-// GetEscrowDetails gets details of an escrow contract
-func (client *BlockchainClient) GetEscrowDetails(escrowID string) (*escrow.EscrowContract, error) {
-	if len(client.ConnectedPeers) == 0 {
-		return nil, fmt.Errorf("not connected to any blockchain nodes")
+// GetEscrowDetails gets an escrow from chain state
+func (client *BlockchainClient) GetEscrowDetails(escrowID string) (*chain.Escrow, error) {
+	var contract chain.Escrow
+	if err := client.queryAPI("/api/escrow?id="+url.QueryEscape(escrowID), &contract); err != nil {
+		return nil, fmt.Errorf("failed to get escrow details: %v", err)
 	}
+	return &contract, nil
+}
 
-	// Create escrow details request
-	escrowData := map[string]interface{}{
-		"action":    "get_escrow",
-		"escrow_id": escrowID,
+// GetUserEscrows gets all escrows where the user is the sender, receiver or arbitrator
+func (client *BlockchainClient) GetUserEscrows(userAddress string) ([]*chain.Escrow, error) {
+	var data struct {
+		Escrows []*chain.Escrow `json:"escrows"`
 	}
-
-	// Send escrow details request to blockchain
-	response, err := client.sendEscrowRequest(escrowData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get escrow details: %v", err)
+	if err := client.queryAPI("/api/escrows?address="+url.QueryEscape(userAddress), &data); err != nil {
+		return nil, fmt.Errorf("failed to get user escrows: %v", err)
 	}
+	return data.Escrows, nil
+}
 
-	// Parse response to create escrow contract
-	contract := &escrow.EscrowContract{
-		ID:           escrowID,
-		Sender:       response["sender"].(string),
-		Receiver:     response["receiver"].(string),
-		Arbitrator:   response["arbitrator"].(string),
-		TokenSymbol:  response["token_symbol"].(string),
-		Amount:       uint64(response["amount"].(float64)),
-		Status:       parseEscrowStatus(response["status"].(string)),
-		Description:  response["description"].(string),
-		CreatedAt:    int64(response["created_at"].(float64)),
-		ExpiresAt:    int64(response["expires_at"].(float64)),
-		Signatures:   make(map[string]bool),
-		RequiredSigs: int(response["required_sigs"].(float64)),
-		Conditions:   make(map[string]interface{}),
+// sendEscrowAction signs and sends an EscrowAction transaction from sender
+func (client *BlockchainClient) sendEscrowAction(escrowID, sender, action string, privateKey []byte) error {
+	if len(client.ConnectedPeers) == 0 {
+		return fmt.Errorf("not connected to any blockchain nodes")
 	}
-
-	return contract, nil
+	tx, err := chain.NewEscrowActionTransaction(sender, escrowID, action)
+	if err != nil {
+		return err
+	}
+	return client.signAndSend(tx, privateKey)
 }
 
-// GetUserEscrows gets all escrows where the user is involved
-func (client *BlockchainClient) GetUserEscrows(userAddress string) ([]*escrow.EscrowContract, error) {
+// ===== OTC OPERATIONS =====
+
+// CreateOTCOrder offers amountOffered of tokenOffered for amountRequested of
+// tokenRequested with an OTCCreate transaction. The returned order's ID is the
+// transaction ID; its times are estimates until the transaction is in a block.
+func (client *BlockchainClient) CreateOTCOrder(creator, tokenOffered string, amountOffered uint64, tokenRequested string, amountRequested uint64, expirationHours int, privateKey []byte) (*chain.OTCOrder, error) {
 	if len(client.ConnectedPeers) == 0 {
 		return nil, fmt.Errorf("not connected to any blockchain nodes")
 	}
 
-	// Create user escrows request
-	escrowData := map[string]interface{}{
-		"action":       "get_user_escrows",
-		"user_address": userAddress,
+	balance, err := client.GetTokenBalance(creator, tokenOffered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check balance: %v", err)
+	}
+	if balance < amountOffered {
+		return nil, fmt.Errorf("insufficient balance: has %d, needs %d", balance, amountOffered)
 	}
 
-	// Send user escrows request to blockchain
-	response, err := client.sendEscrowRequest(escrowData)
+	params := chain.OTCCreateParams{
+		TokenRequested:  tokenRequested,
+		AmountRequested: uint256.NewInt(amountRequested),
+		ExpiresIn:       int64(expirationHours) * 60 * 60,
+	}
+	tx, err := chain.NewOTCCreateTransaction(creator, tokenOffered, uint256.NewInt(amountOffered), params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user escrows: %v", err)
+		return nil, fmt.Errorf("failed to create OTC order: %v", err)
+	}
+	if err := client.signAndSend(tx, privateKey); err != nil {
+		return nil, fmt.Errorf("failed to create OTC order: %v", err)
 	}
 
-	// Parse response to create escrow contracts list
-	escrowsData, ok := response["escrows"].([]interface{})
-	if !ok {
-		return []*escrow.EscrowContract{}, nil // Return empty list if no escrows
-	}
-
-	var contracts []*escrow.EscrowContract
-	for _, escrowData := range escrowsData {
-		data := escrowData.(map[string]interface{})
-		contract := &escrow.EscrowContract{
-			ID:           data["id"].(string),
-			Sender:       data["sender"].(string),
-			Receiver:     data["receiver"].(string),
-			Arbitrator:   data["arbitrator"].(string),
-			TokenSymbol:  data["token_symbol"].(string),
-			Amount:       uint64(data["amount"].(float64)),
-			Status:       parseEscrowStatus(data["status"].(string)),
-			Description:  data["description"].(string),
-			CreatedAt:    int64(data["created_at"].(float64)),
-			ExpiresAt:    int64(data["expires_at"].(float64)),
-			Signatures:   make(map[string]bool),
-			RequiredSigs: int(data["required_sigs"].(float64)),
-			Conditions:   make(map[string]interface{}),
-		}
-		contracts = append(contracts, contract)
+	now := time.Now().Unix()
+	order := &chain.OTCOrder{
+		ID:              tx.ID,
+		Creator:         creator,
+		TokenOffered:    tokenOffered,
+		AmountOffered:   tx.Amount,
+		TokenRequested:  tokenRequested,
+		AmountRequested: params.AmountRequested,
+		Status:          chain.OTCOpen,
+		CreatedAt:       now,
+		ExpiresAt:       now + params.ExpiresIn,
 	}
 
-	return contracts, nil
+	fmt.Printf("✅ OTC order submitted: %s\n", order.ID)
+	return order, nil
 }
 
-// sendEscrowRequest sends an escrow-related request to the blockchain
-func (client *BlockchainClient) sendEscrowRequest(escrowData map[string]interface{}) (map[string]interface{}, error) {
-	// For now, we'll use HTTP API to send escrow requests
-	// In a full implementation, this would use P2P messaging
-
-	if len(client.ConnectedPeers) == 0 {
-		return nil, fmt.Errorf("not connected to any blockchain nodes")
+// MatchOTCOrder fills an open OTC order with an OTCAction transaction
+func (client *BlockchainClient) MatchOTCOrder(orderID, counterparty string, privateKey []byte) error {
+	if err := client.sendOTCAction(orderID, counterparty, chain.OTCMatch, privateKey); err != nil {
+		return fmt.Errorf("failed to match OTC order: %v", err)
 	}
 
-	// Try to send request via HTTP API
-	for _, peerAddr := range client.ConnectedPeers {
-		if apiPort := client.extractAPIPortFromPeer(peerAddr); apiPort != "" {
-			response, err := client.sendEscrowRequestViaHTTP(escrowData, apiPort)
-			if err == nil {
-				return response, nil
-			}
-			fmt.Printf("⚠️ Failed to send escrow request via HTTP API on port %s: %v\n", apiPort, err)
-		}
+	fmt.Printf("✅ OTC order %s match submitted\n", orderID)
+	return nil
+}
+
+// CancelOTCOrder returns an open order's offer to its creator with an OTCAction transaction
+func (client *BlockchainClient) CancelOTCOrder(orderID, creator string, privateKey []byte) error {
+	if err := client.sendOTCAction(orderID, creator, chain.OTCCancel, privateKey); err != nil {
+		return fmt.Errorf("failed to cancel OTC order: %v", err)
 	}
 
-	return nil, fmt.Errorf("failed to send escrow request to any connected peers")
+	fmt.Printf("✅ OTC order %s cancellation submitted\n", orderID)
+	return nil
 }
 
-// sendEscrowRequestViaHTTP sends escrow request via HTTP API
-func (client *BlockchainClient) sendEscrowRequestViaHTTP(escrowData map[string]interface{}, port string) (map[string]interface{}, error) {
-	url := fmt.Sprintf("http://localhost:%s/api/escrow/request", port)
-
-	// Convert escrow data to JSON
-	jsonData, err := json.Marshal(escrowData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal escrow data: %v", err)
+// GetOTCOrders gets every order a user created or filled, or every open
+// order when userAddress is empty
+func (client *BlockchainClient) GetOTCOrders(userAddress string) ([]*chain.OTCOrder, error) {
+	var data struct {
+		Orders []*chain.OTCOrder `json:"orders"`
 	}
-
-	// Send POST request
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to send HTTP request: %v", err)
+	if err := client.queryAPI("/api/otc/orders?user="+url.QueryEscape(userAddress), &data); err != nil {
+		return nil, fmt.Errorf("failed to get OTC orders: %v", err)
 	}
-	defer resp.Body.Close()
+	return data.Orders, nil
+}
 
-	// Read response
-	body, err := io.ReadAll(resp.Body)
+// sendOTCAction signs and sends an OTCAction transaction from sender
+func (client *BlockchainClient) sendOTCAction(orderID, sender, action string, privateKey []byte) error {
+	if len(client.ConnectedPeers) == 0 {
+		return fmt.Errorf("not connected to any blockchain nodes")
+	}
+	tx, err := chain.NewOTCActionTransaction(sender, orderID, action)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
+		return err
 	}
+	return client.signAndSend(tx, privateKey)
+}
 
-	// Parse response
-	var response map[string]interface{}
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %v", err)
+// signAndSend gives tx the sender's next nonce, signs it and sends it to the network
+func (client *BlockchainClient) signAndSend(tx *chain.Transaction, privateKey []byte) error {
+	nonce, err := client.GetNextNonce(tx.From)
+	if err != nil {
+		return fmt.Errorf("failed to get account nonce: %v", err)
 	}
+	tx.Nonce = nonce
+	tx.GasPrice = defaultGasPrice
 
-	// Check if request was successful
-	if success, ok := response["success"].(bool); !ok || !success {
-		errorMsg := "unknown error"
-		if msg, ok := response["error"].(string); ok {
-			errorMsg = msg
-		}
-		return nil, fmt.Errorf("escrow request failed: %s", errorMsg)
+	// Sign transaction, which also sets its ID
+	if err := signTransaction(tx, privateKey); err != nil {
+		return err
 	}
-
-	return response, nil
+	return client.sendTransactionToNetwork(tx)
 }
 
-// parseEscrowStatus converts string status to EscrowStatus
-func parseEscrowStatus(status string) escrow.EscrowStatus {
-	switch status {
-	case "pending":
-		return escrow.EscrowPending
-	case "confirmed":
-		return escrow.EscrowConfirmed
-	case "released":
-		return escrow.EscrowReleased
-	case "cancelled":
-		return escrow.EscrowCancelled
-	case "disputed":
-		return escrow.EscrowDisputed
-	default:
-		return escrow.EscrowPending
+// queryAPI reads the data of a GET endpoint of the first node that answers
+func (client *BlockchainClient) queryAPI(path string, data interface{}) error {
+	var lastErr error
+	for _, endpoint := range client.apiEndpoints() {
+		resp, err := http.Get(endpoint + path)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to send HTTP request: %v", err)
+			continue
+		}
+		response := struct {
+			Success bool        `json:"success"`
+			Error   string      `json:"error"`
+			Data    interface{} `json:"data"`
+		}{Data: data}
+		err = json.NewDecoder(resp.Body).Decode(&response)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("failed to parse response: %v", err)
+			continue
+		}
+		if !response.Success {
+			return fmt.Errorf("request failed: %s", response.Error)
+		}
+		return nil
 	}
+	return lastErr
 }
@@ -4,11 +4,11 @@ import (
     "context"
     "fmt"
 
-    "github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/escrow"
+    "github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/chain"
 )
 
 // CreateEscrowTransfer initiates an escrow transfer from the wallet
-func CreateEscrowTransfer(ctx context.Context, user *User, walletName, password, receiverAddress, arbitratorAddress, tokenSymbol string, amount uint64, expirationHours int, description string) (*escrow.EscrowContract, error) {
+func CreateEscrowTransfer(ctx context.Context, user *User, walletName, password, receiverAddress, arbitratorAddress, tokenSymbol string, amount uint64, expirationHours int, description string) (*chain.Escrow, error) {
     // Get wallet details
     wallet, privKey, _, err := GetWalletDetails(ctx, user, walletName, password)
     if err != nil {
@@ -100,7 +100,7 @@ func CancelEscrow(ctx context.Context, user *User, walletName, password, escrowI
 }
 
 // GetEscrowDetails gets details of an escrow contract
-func GetEscrowDetails(ctx context.Context, escrowID string) (*escrow.EscrowContract, error) {
+func GetEscrowDetails(ctx context.Context, escrowID string) (*chain.Escrow, error) {
     // Get escrow details
     contract, err := DefaultBlockchainClient.GetEscrowDetails(escrowID)
     if err != nil {
@@ -111,7 +111,7 @@ func GetEscrowDetails(ctx context.Context, escrowID string) (*escrow.EscrowContr
 }
 
 // ListUserEscrows lists all escrows where the user is involved
-func ListUserEscrows(ctx context.Context, userAddress string) ([]*escrow.EscrowContract, error) {
+func ListUserEscrows(ctx context.Context, userAddress string) ([]*chain.Escrow, error) {
     // Get all escrows for the user
     contracts, err := DefaultBlockchainClient.GetUserEscrows(userAddress)
     if err != nil {
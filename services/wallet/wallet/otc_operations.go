@@ -0,0 +1,68 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/chain"
+)
+
+// CreateOTCOrder offers tokens from the wallet for a fixed amount of another token
+func CreateOTCOrder(ctx context.Context, user *User, walletName, password, tokenOffered string, amountOffered uint64, tokenRequested string, amountRequested uint64, expirationHours int) (*chain.OTCOrder, error) {
+	// Get wallet details
+	wallet, privKey, _, err := GetWalletDetails(ctx, user, walletName, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %v", err)
+	}
+
+	order, err := DefaultBlockchainClient.CreateOTCOrder(
+		wallet.Address,
+		tokenOffered,
+		amountOffered,
+		tokenRequested,
+		amountRequested,
+		expirationHours,
+		privKey,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTC order: %v", err)
+	}
+
+	return order, nil
+}
+
+// MatchOTCOrder fills an open OTC order from the wallet
+func MatchOTCOrder(ctx context.Context, user *User, walletName, password, orderID string) (string, error) {
+	// Get wallet details
+	wallet, privKey, _, err := GetWalletDetails(ctx, user, walletName, password)
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallet: %v", err)
+	}
+
+	if err := DefaultBlockchainClient.MatchOTCOrder(orderID, wallet.Address, privKey); err != nil {
+		return "", fmt.Errorf("failed to match OTC order: %v", err)
+	}
+
+	return wallet.Address, nil
+}
+
+// CancelOTCOrder cancels an open OTC order the wallet created
+func CancelOTCOrder(ctx context.Context, user *User, walletName, password, orderID string) (string, error) {
+	// Get wallet details
+	wallet, privKey, _, err := GetWalletDetails(ctx, user, walletName, password)
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallet: %v", err)
+	}
+
+	if err := DefaultBlockchainClient.CancelOTCOrder(orderID, wallet.Address, privKey); err != nil {
+		return "", fmt.Errorf("failed to cancel OTC order: %v", err)
+	}
+
+	return wallet.Address, nil
+}
+
+// GetOTCOrders gets every OTC order an address created or filled, or every
+// open order when address is empty
+func GetOTCOrders(address string) ([]*chain.OTCOrder, error) {
+	return DefaultBlockchainClient.GetOTCOrders(address)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/chain"
+	"github.com/Shivam-Patel-G/blackhole-blockchain/core/relay-chain/uint256"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -123,7 +124,7 @@ func TransferTokensWithHistory(ctx context.Context, user *User, walletName, pass
 		Type:      chain.TokenTransfer,
 		From:      wallet.Address,
 		To:        toAddress,
-		Amount:    amount,
+		Amount:    uint256.NewInt(amount),
 		TokenID:   tokenSymbol,
 		Fee:       0,
 		Nonce:     uint64(time.Now().UnixNano()),
@@ -170,7 +171,7 @@ func StakeTokensWithHistory(ctx context.Context, user *User, walletName, passwor
 		Type:      chain.StakeDeposit,
 		From:      wallet.Address,
 		To:        "staking_contract",
-		Amount:    amount,
+		Amount:    uint256.NewInt(amount),
 		TokenID:   tokenSymbol,
 		Fee:       0,
 		Nonce:     uint64(time.Now().UnixNano()),